@@ -0,0 +1,314 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Ingester scores the findings of an upload of size bytes read from r,
+// like a Prioritizer, calling stage as it moves between the stages of a
+// job, such as "scoring" once the upload is read.
+type Ingester func(ctx context.Context, r io.ReaderAt, size int64, format string, now time.Time, stage func(string)) (vs []vuln.Vulnerability, skipped int, err error)
+
+// MaxIngestBytes limits the body of POST /ingest. Uploads are spooled to
+// a temporary file, not held in memory, so the limit is well above
+// MaxUploadBytes.
+const MaxIngestBytes = 4 << 30
+
+// Job statuses.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// Job is an ingestion job, as GET /jobs/{id} reports it.
+type Job struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	// Stage is what a running job is doing: "reading" the upload, then
+	// the stages its Ingester reports.
+	Stage string `json:"stage,omitempty"`
+	// Bytes is the size of the upload, and ReadBytes how far into it
+	// the job has read.
+	Bytes       int64      `json:"bytes"`
+	ReadBytes   int64      `json:"read_bytes"`
+	Findings    int        `json:"findings"`
+	Skipped     int        `json:"skipped"`
+	Error       string     `json:"error,omitempty"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	// Results is the path the findings of a done job are queried at.
+	Results string `json:"results,omitempty"`
+}
+
+// maxJobs is how many jobs are kept. Past it, the oldest finished job,
+// and its findings, are forgotten.
+const maxJobs = 100
+
+// queueSize is how many jobs may wait for the worker.
+const queueSize = 32
+
+// job is a Job with what the worker needs. Job and findings are guarded
+// by jobs.mu.
+type job struct {
+	Job
+	path     string // the spooled upload
+	format   string
+	now      time.Time
+	read     atomic.Int64
+	findings []vuln.Vulnerability
+}
+
+// jobs holds the ingestion jobs. One worker runs them in turn, in
+// submission order, so an upload never competes with another for memory.
+type jobs struct {
+	mu    sync.Mutex
+	byID  map[string]*job
+	order []string // IDs, in submission order
+	queue chan *job
+}
+
+// HandleIngest serves ingestion jobs, run by ing until ctx is done:
+//
+//	POST /ingest               spool the body and queue a job for it
+//	GET  /jobs                 every job, newest first
+//	GET  /jobs/{id}            a job's progress
+//	GET  /jobs/{id}/findings   the findings of a done job a Query selects
+//
+// POST /ingest takes format and today as POST /prioritize does, and
+// answers 202 Accepted with the queued Job, and its URL in Location.
+func (s *Server) HandleIngest(ctx context.Context, ing Ingester) {
+	js := &jobs{byID: make(map[string]*job), queue: make(chan *job, queueSize)}
+	go js.work(ctx, ing)
+	s.mux.HandleFunc("POST /ingest", js.submit)
+	s.mux.HandleFunc("GET /jobs", js.list)
+	s.mux.HandleFunc("GET /jobs/{id}", js.get)
+	s.mux.HandleFunc("GET /jobs/{id}/findings", js.findings)
+}
+
+func (js *jobs) submit(w http.ResponseWriter, r *http.Request) {
+	j := &job{format: r.URL.Query().Get("format"), now: time.Now()}
+	if today := r.URL.Query().Get("today"); today != "" {
+		d, err := vuln.ParseDate(today)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "today: "+err.Error())
+			return
+		}
+		j.now = d.Time
+	}
+	f, err := os.CreateTemp("", "prioritizer-upload-*")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	n, err := io.Copy(f, http.MaxBytesReader(w, r.Body, MaxIngestBytes))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "the upload is larger than "+strconv.Itoa(MaxIngestBytes>>30)+" GiB")
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	j.path = f.Name()
+	j.ID, j.Status, j.Bytes, j.SubmittedAt = newJobID(), JobQueued, n, time.Now().UTC()
+
+	js.mu.Lock()
+	select {
+	case js.queue <- j:
+	default:
+		js.mu.Unlock()
+		os.Remove(j.path)
+		writeError(w, http.StatusServiceUnavailable, "too many queued jobs; retry later")
+		return
+	}
+	js.byID[j.ID] = j
+	js.order = append(js.order, j.ID)
+	js.evict()
+	status := j.status()
+	js.mu.Unlock()
+
+	w.Header().Set("Location", "/jobs/"+j.ID)
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// evict forgets the oldest finished jobs past maxJobs. Queued and running
+// jobs are kept.
+func (js *jobs) evict() {
+	for i := 0; len(js.order) > maxJobs && i < len(js.order); {
+		id := js.order[i]
+		if st := js.byID[id].Status; st != JobDone && st != JobFailed {
+			i++
+			continue
+		}
+		delete(js.byID, id)
+		js.order = append(js.order[:i], js.order[i+1:]...)
+	}
+}
+
+// work runs the queued jobs until ctx is done, then removes the uploads
+// of those still queued.
+func (js *jobs) work(ctx context.Context, ing Ingester) {
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case j := <-js.queue:
+					os.Remove(j.path)
+				default:
+					return
+				}
+			}
+		case j := <-js.queue:
+			js.run(ctx, ing, j)
+		}
+	}
+}
+
+func (js *jobs) run(ctx context.Context, ing Ingester, j *job) {
+	js.mu.Lock()
+	started := time.Now().UTC()
+	j.Status, j.Stage, j.StartedAt = JobRunning, "reading", &started
+	js.mu.Unlock()
+
+	vs, skipped, err := js.ingest(ctx, ing, j)
+	os.Remove(j.path)
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	finished := time.Now().UTC()
+	j.Stage, j.FinishedAt = "", &finished
+	if err != nil {
+		j.Status, j.Error = JobFailed, err.Error()
+		return
+	}
+	if vs == nil {
+		vs = []vuln.Vulnerability{}
+	}
+	j.Status, j.Findings, j.Skipped, j.findings = JobDone, len(vs), skipped, vs
+	j.Results = "/jobs/" + j.ID + "/findings"
+	js.evict()
+}
+
+func (js *jobs) ingest(ctx context.Context, ing Ingester, j *job) ([]vuln.Vulnerability, int, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	stage := func(name string) {
+		js.mu.Lock()
+		j.Stage = name
+		js.mu.Unlock()
+	}
+	return ing(ctx, &countingReaderAt{r: f, max: &j.read}, j.Bytes, j.format, j.now, stage)
+}
+
+// countingReaderAt records the furthest offset read from r, as the
+// progress of a job reading it.
+type countingReaderAt struct {
+	r   io.ReaderAt
+	max *atomic.Int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	for end := off + int64(n); ; {
+		cur := c.max.Load()
+		if end <= cur || c.max.CompareAndSwap(cur, end) {
+			break
+		}
+	}
+	return n, err
+}
+
+// status returns j as reported, with how far it has read. js.mu must be
+// held.
+func (j *job) status() Job {
+	out := j.Job
+	out.ReadBytes = min(j.read.Load(), j.Bytes)
+	if j.Status == JobDone {
+		out.ReadBytes = j.Bytes
+	}
+	return out
+}
+
+func (js *jobs) list(w http.ResponseWriter, r *http.Request) {
+	js.mu.Lock()
+	out := make([]Job, 0, len(js.order))
+	for i := len(js.order) - 1; i >= 0; i-- {
+		out = append(out, js.byID[js.order[i]].status())
+	}
+	js.mu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (js *jobs) get(w http.ResponseWriter, r *http.Request) {
+	js.mu.Lock()
+	j, ok := js.byID[r.PathValue("id")]
+	var status Job
+	if ok {
+		status = j.status()
+	}
+	js.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no job "+r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// findings serves a done job's findings as GET /findings serves the
+// result set. A job not done yet gets a 409.
+func (js *jobs) findings(w http.ResponseWriter, r *http.Request) {
+	q, err := ParseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	js.mu.Lock()
+	j, ok := js.byID[r.PathValue("id")]
+	var status Job
+	var vs []vuln.Vulnerability
+	if ok {
+		status, vs = j.status(), j.findings
+	}
+	js.mu.Unlock()
+	switch {
+	case !ok:
+		writeError(w, http.StatusNotFound, "no job "+r.PathValue("id"))
+	case status.Status == JobFailed:
+		writeError(w, http.StatusConflict, "job "+status.ID+" failed: "+status.Error)
+	case status.Status != JobDone:
+		writeError(w, http.StatusConflict, "job "+status.ID+" is "+status.Status)
+	default:
+		writeJSON(w, http.StatusOK, q.Apply(vs))
+	}
+}
+
+// newJobID returns a random job ID.
+func newJobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
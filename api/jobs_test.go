@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestServerIngest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	release := make(chan struct{})
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	s := NewServer(nil)
+	s.HandleIngest(ctx, func(_ context.Context, r io.ReaderAt, size int64, format string, now time.Time, stage func(string)) ([]vuln.Vulnerability, int, error) {
+		body, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, 0, err
+		}
+		<-release
+		stage("scoring")
+		if string(body) == "bad" {
+			return nil, 0, errors.New("unreadable upload")
+		}
+		if format != "csv" || !now.Equal(time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("ingester got format %q, now %v", format, now)
+		}
+		return findings, 1, nil
+	})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	do := func(method, path, body string, status int, v any) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("%s %s: status %d, want %d", method, path, resp.StatusCode, status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		return resp
+	}
+	// wait polls the job until it is no longer queued or running.
+	wait := func(id string) Job {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			var j Job
+			do(http.MethodGet, "/jobs/"+id, "", http.StatusOK, &j)
+			if j.Status != JobQueued && j.Status != JobRunning {
+				return j
+			}
+		}
+		t.Fatalf("job %s did not finish", id)
+		return Job{}
+	}
+
+	var job, bad Job
+	resp := do(http.MethodPost, "/ingest?format=csv&today=2025-02-05", "Identifier\nCVE-1\n", http.StatusAccepted, &job)
+	if job.ID == "" || job.Status != JobQueued || job.Bytes != 17 || resp.Header.Get("Location") != "/jobs/"+job.ID {
+		t.Errorf("POST /ingest: %+v, Location %q", job, resp.Header.Get("Location"))
+	}
+	do(http.MethodPost, "/ingest", "bad", http.StatusAccepted, &bad)
+
+	// Running, with the upload read.
+	var got Job
+	for deadline := time.Now().Add(5 * time.Second); got.ReadBytes < job.Bytes && time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		do(http.MethodGet, "/jobs/"+job.ID, "", http.StatusOK, &got)
+	}
+	if got.Status != JobRunning || got.Stage != "reading" || got.ReadBytes != 17 || got.StartedAt == nil {
+		t.Errorf("running job %+v", got)
+	}
+	var errBody map[string]string
+	do(http.MethodGet, "/jobs/"+job.ID+"/findings", "", http.StatusConflict, &errBody)
+	if errBody["error"] != "job "+job.ID+" is running" {
+		t.Errorf("findings of a running job: %v", errBody)
+	}
+	close(release)
+
+	done := wait(job.ID)
+	if done.Status != JobDone || done.Findings != 4 || done.Skipped != 1 || done.FinishedAt == nil || done.Results != "/jobs/"+job.ID+"/findings" {
+		t.Errorf("done job %+v", done)
+	}
+	var page Page
+	do(http.MethodGet, done.Results+"?severity=Critical&min_score=8", "", http.StatusOK, &page)
+	if page.Total != 1 || ids(page.Findings) != "1" {
+		t.Errorf("job findings %+v", page)
+	}
+	do(http.MethodGet, done.Results+"?limit=-1", "", http.StatusBadRequest, &errBody)
+
+	failed := wait(bad.ID)
+	if failed.Status != JobFailed || failed.Error != "unreadable upload" {
+		t.Errorf("failed job %+v", failed)
+	}
+	do(http.MethodGet, "/jobs/"+bad.ID+"/findings", "", http.StatusConflict, &errBody)
+
+	var list []Job
+	do(http.MethodGet, "/jobs", "", http.StatusOK, &list)
+	if len(list) != 2 || list[0].ID != bad.ID || list[1].ID != job.ID {
+		t.Errorf("GET /jobs = %+v", list)
+	}
+	do(http.MethodGet, "/jobs/nope", "", http.StatusNotFound, &errBody)
+	do(http.MethodPost, "/ingest?today=yesterday", "", http.StatusBadRequest, &errBody)
+
+	// The spooled uploads are removed once processed.
+	if left, _ := filepath.Glob(filepath.Join(tmp, "*")); len(left) != 0 {
+		t.Errorf("uploads left behind: %v", left)
+	}
+}
@@ -0,0 +1,326 @@
+// Package api serves prioritized findings over HTTP as JSON, with
+// filtering, sorting and pagination, for UI clients that should not have
+// to download the whole result set.
+package api
+
+import (
+	"cmp"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Page size limits.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Query selects, orders and pages findings. The zero Query matches every
+// finding in result order.
+type Query struct {
+	// Severities, Sources, Assets, Identifiers, Timeframes and States
+	// each match any of their values, ignoring case; empty matches
+	// everything. Assets match an asset name or ID, and may be glob
+	// patterns, as in prod-*.
+	Severities  []vuln.Severity
+	Sources     []string
+	Assets      []string
+	Identifiers []string
+	Timeframes  []string
+	States      []string
+	// MinScore and MaxScore bound the priority score, and MinCVSS the
+	// CVSS score, when set.
+	MinScore, MaxScore *float64
+	MinCVSS            *float64
+	// KEV, when set, matches findings in, or not in, the KEV catalog.
+	KEV *bool
+
+	// Sort orders the matches by these keys in turn; ties keep result
+	// order.
+	Sort []SortKey
+	// Limit is the page size; Offset is the number of matches skipped.
+	Limit, Offset int
+}
+
+// SortKey is a field to sort by, descending when Desc is set.
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// sortFields compares findings by the sortable fields, ascending.
+// Severity ascends from Low to Critical.
+var sortFields = map[string]func(a, b *vuln.Vulnerability) int{
+	"priority_score":      func(a, b *vuln.Vulnerability) int { return cmp.Compare(a.PriorityScore, b.PriorityScore) },
+	"cvss":                func(a, b *vuln.Vulnerability) int { return cmp.Compare(a.CVSS, b.CVSS) },
+	"epss":                func(a, b *vuln.Vulnerability) int { return cmp.Compare(a.EPSS, b.EPSS) },
+	"effort":              func(a, b *vuln.Vulnerability) int { return cmp.Compare(a.Effort, b.Effort) },
+	"severity":            func(a, b *vuln.Vulnerability) int { return cmp.Compare(b.Severity.Rank(), a.Severity.Rank()) },
+	"due_date":            func(a, b *vuln.Vulnerability) int { return a.DueDate.Compare(b.DueDate.Time) },
+	"first_detected_date": func(a, b *vuln.Vulnerability) int { return a.FirstDetectedDate.Compare(b.FirstDetectedDate.Time) },
+	"breaches_sla_on":     func(a, b *vuln.Vulnerability) int { return a.BreachesSLAOn.Compare(b.BreachesSLAOn.Time) },
+	"score_in_30_days":    func(a, b *vuln.Vulnerability) int { return cmp.Compare(a.ScoreIn30Days, b.ScoreIn30Days) },
+	"identifier":          func(a, b *vuln.Vulnerability) int { return strings.Compare(a.Identifier, b.Identifier) },
+	"asset_name":          func(a, b *vuln.Vulnerability) int { return strings.Compare(a.AssetName, b.AssetName) },
+	"package_name":        func(a, b *vuln.Vulnerability) int { return strings.Compare(a.PackageName, b.PackageName) },
+}
+
+// dateFields are the sort fields whose zero value, an unknown date, sorts
+// last in either direction.
+var dateFields = map[string]func(v *vuln.Vulnerability) vuln.Date{
+	"due_date":            func(v *vuln.Vulnerability) vuln.Date { return v.DueDate },
+	"first_detected_date": func(v *vuln.Vulnerability) vuln.Date { return v.FirstDetectedDate },
+	"breaches_sla_on":     func(v *vuln.Vulnerability) vuln.Date { return v.BreachesSLAOn },
+}
+
+// ParseQuery reads a Query from URL query parameters:
+//
+//	severity, source, asset, identifier, timeframe, state  comma-separated or repeated
+//	min_score, max_score                                   priority score bounds
+//	min_cvss                                               CVSS score bound
+//	kev                                                    true or false
+//	sort                                                   fields, "-" for descending, e.g. -priority_score,due_date
+//	limit                                                  page size (default DefaultLimit, at most MaxLimit)
+//	cursor                                                 next_cursor of the previous page
+func ParseQuery(params url.Values) (*Query, error) {
+	q := &Query{Limit: DefaultLimit}
+	for name := range params {
+		if !knownParams[name] {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	for _, s := range list(params, "severity") {
+		sev, err := vuln.ParseSeverity(s)
+		if err != nil {
+			return nil, fmt.Errorf("severity: %w", err)
+		}
+		q.Severities = append(q.Severities, sev)
+	}
+	q.Sources = list(params, "source")
+	q.Assets = list(params, "asset")
+	q.Identifiers = list(params, "identifier")
+	q.Timeframes = list(params, "timeframe")
+	q.States = list(params, "state")
+
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{{"min_score", &q.MinScore}, {"max_score", &q.MaxScore}, {"min_cvss", &q.MinCVSS}} {
+		if s := params.Get(p.name); s != "" {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", p.name, s)
+			}
+			*p.dst = &f
+		}
+	}
+	if s := params.Get("kev"); s != "" {
+		kev, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kev %q", s)
+		}
+		q.KEV = &kev
+	}
+	for _, a := range q.Assets {
+		if _, err := path.Match(a, ""); err != nil {
+			return nil, fmt.Errorf("invalid asset pattern %q", a)
+		}
+	}
+	for _, s := range list(params, "sort") {
+		key := SortKey{Field: strings.TrimPrefix(s, "-"), Desc: strings.HasPrefix(s, "-")}
+		if sortFields[key.Field] == nil {
+			return nil, fmt.Errorf("cannot sort by %q", key.Field)
+		}
+		q.Sort = append(q.Sort, key)
+	}
+	if s := params.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxLimit {
+			return nil, fmt.Errorf("limit must be 1-%d, got %q", MaxLimit, s)
+		}
+		q.Limit = n
+	}
+	if s := params.Get("cursor"); s != "" {
+		offset, err := decodeCursor(s)
+		if err != nil {
+			return nil, err
+		}
+		q.Offset = offset
+	}
+	return q, nil
+}
+
+var knownParams = map[string]bool{
+	"severity": true, "source": true, "asset": true, "identifier": true, "timeframe": true, "state": true,
+	"min_score": true, "max_score": true, "min_cvss": true, "kev": true, "sort": true, "limit": true, "cursor": true,
+}
+
+// list returns the comma-separated values of every name parameter.
+func list(params url.Values, name string) []string {
+	var values []string
+	for _, p := range params[name] {
+		for _, s := range strings.Split(p, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// Page is one page of query results.
+type Page struct {
+	// Total is the number of findings the query matches, on all pages.
+	Total    int                  `json:"total"`
+	Findings []vuln.Vulnerability `json:"findings"`
+	// NextCursor fetches the next page; it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Apply returns the page of vs that q selects. vs is not modified.
+func (q *Query) Apply(vs []vuln.Vulnerability) Page {
+	matches := q.Select(vs)
+	page := Page{Total: len(matches), Findings: []vuln.Vulnerability{}}
+	if q.Offset < len(matches) {
+		end := min(q.Offset+q.Limit, len(matches))
+		page.Findings = matches[q.Offset:end]
+		if end < len(matches) {
+			page.NextCursor = encodeCursor(end)
+		}
+	}
+	return page
+}
+
+// Select returns every finding of vs that q matches, sorted, ignoring
+// Limit and Offset.
+func (q *Query) Select(vs []vuln.Vulnerability) []vuln.Vulnerability {
+	var matches []vuln.Vulnerability
+	for i := range vs {
+		if q.matches(&vs[i]) {
+			matches = append(matches, vs[i])
+		}
+	}
+	if len(q.Sort) > 0 {
+		slices.SortStableFunc(matches, func(a, b vuln.Vulnerability) int {
+			for _, k := range q.Sort {
+				if date := dateFields[k.Field]; date != nil {
+					if c := compareKnown(date(&a), date(&b)); c != 0 {
+						return c
+					}
+				}
+				c := sortFields[k.Field](&a, &b)
+				if k.Desc {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+	}
+	return matches
+}
+
+// compareKnown orders known dates before unknown ones.
+func compareKnown(a, b vuln.Date) int {
+	switch {
+	case a.IsZero() == b.IsZero():
+		return 0
+	case a.IsZero():
+		return 1
+	default:
+		return -1
+	}
+}
+
+func (q *Query) matches(v *vuln.Vulnerability) bool {
+	if len(q.Severities) > 0 && !slices.Contains(q.Severities, v.Severity) {
+		return false
+	}
+	if len(q.Assets) > 0 && !anyMatch(q.Assets, []string{v.AssetName, v.AssetID}) {
+		return false
+	}
+	for _, f := range []struct {
+		want   []string
+		values []string
+	}{
+		{q.Sources, []string{v.Source}},
+		{q.Identifiers, []string{v.Identifier}},
+		{q.Timeframes, []string{v.ActionTimeframe}},
+		{q.States, []string{v.State}},
+	} {
+		if len(f.want) > 0 && !anyEqualFold(f.want, f.values) {
+			return false
+		}
+	}
+	if q.MinScore != nil && v.PriorityScore < *q.MinScore {
+		return false
+	}
+	if q.MaxScore != nil && v.PriorityScore > *q.MaxScore {
+		return false
+	}
+	if q.MinCVSS != nil && v.CVSS < *q.MinCVSS {
+		return false
+	}
+	return q.KEV == nil || v.KEV == *q.KEV
+}
+
+func anyEqualFold(want, values []string) bool {
+	for _, w := range want {
+		for _, v := range values {
+			if strings.EqualFold(w, strings.TrimSpace(v)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// anyMatch reports whether any of values matches any of the glob patterns
+// want, ignoring case. A * matches across slashes too, so that *hasura
+// matches an image repository's ARN.
+func anyMatch(want, values []string) bool {
+	for _, w := range want {
+		for _, v := range values {
+			if ok, _ := path.Match(globFold(w), globFold(strings.TrimSpace(v))); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// globFold lowers s and replaces its slashes, which path.Match stops at.
+func globFold(s string) string {
+	return strings.ReplaceAll(strings.ToLower(s), "/", "\x00")
+}
+
+// Cursors are opaque to clients; they encode the offset of the next page.
+const cursorPrefix = "o:"
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+var errCursor = errors.New("invalid cursor")
+
+func decodeCursor(s string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, errCursor
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(string(b), cursorPrefix))
+	if err != nil || n < 0 || !strings.HasPrefix(string(b), cursorPrefix) {
+		return 0, errCursor
+	}
+	return n, nil
+}
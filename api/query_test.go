@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+var findings = []vuln.Vulnerability{
+	{UniqueID: "1", Identifier: "CVE-1", Severity: vuln.Critical, Source: "aws", AssetName: "match-v1", CVSS: 9.8, PriorityScore: 9.1, KEV: true, DueDate: vuln.NewDate(2025, 3, 1)},
+	{UniqueID: "2", Identifier: "CVE-2", Severity: vuln.High, Source: "github", AssetName: "apply-api", AssetID: "acme/apply-api", CVSS: 7.5, PriorityScore: 8.2},
+	{UniqueID: "3", Identifier: "CVE-3", Severity: vuln.Critical, Source: "github", AssetID: "464322508", CVSS: 9.1, PriorityScore: 7.5, DueDate: vuln.NewDate(2025, 2, 10)},
+	{UniqueID: "4", Identifier: "CVE-4", Severity: vuln.Low, Source: "aws", AssetName: "match-v1", CVSS: 3.1, PriorityScore: 3, DueDate: vuln.NewDate(2025, 2, 20)},
+}
+
+func ids(vs []vuln.Vulnerability) string {
+	var s []string
+	for _, v := range vs {
+		s = append(s, v.UniqueID)
+	}
+	return strings.Join(s, ",")
+}
+
+func TestQueryApply(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "1,2,3,4"},
+		{"severity=critical", "1,3"},
+		{"severity=Critical&severity=low", "1,3,4"},
+		{"severity=Critical,Low&source=AWS", "1,4"},
+		{"asset=464322508,apply-api", "2,3"},
+		{"asset=MATCH-*", "1,4"},
+		{"asset=acme*api", "2"}, // across the slash
+		{"asset=4643?2508", "3"},
+		{"min_cvss=9", "1,3"},
+		{"min_cvss=7&min_score=8", "1,2"},
+		{"min_score=8", "1,2"},
+		{"min_score=3&max_score=7.5", "3,4"},
+		{"kev=false", "2,3,4"},
+		{"sort=priority_score", "4,3,2,1"},
+		{"sort=-severity,priority_score", "3,1,2,4"},
+		{"sort=due_date", "3,4,1,2"},  // unknown dates last
+		{"sort=-due_date", "1,4,3,2"}, // in either direction
+		{"identifier=cve-2", "2"},
+		{"identifier=CVE-9", ""},
+	}
+	for _, tt := range tests {
+		params, _ := url.ParseQuery(tt.query)
+		q, err := ParseQuery(params)
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		page := q.Apply(findings)
+		if got := ids(page.Findings); got != tt.want || page.Total != len(page.Findings) {
+			t.Errorf("%q: got %q (total %d), want %q", tt.query, got, page.Total, tt.want)
+		}
+	}
+}
+
+func TestQueryPages(t *testing.T) {
+	var got []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("cursor does not advance")
+		}
+		params := url.Values{"limit": {"3"}, "sort": {"-priority_score"}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		q, err := ParseQuery(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		page := q.Apply(findings)
+		if page.Total != 4 {
+			t.Errorf("total = %d, want 4", page.Total)
+		}
+		got = append(got, ids(page.Findings))
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	if strings.Join(got, "|") != "1,2,3|4" {
+		t.Errorf("pages = %q", got)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		"severity=severe",
+		"min_score=high",
+		"min_cvss=high",
+		"asset=prod-[",
+		"kev=maybe",
+		"sort=title",
+		"limit=0",
+		"limit=1001",
+		"cursor=bm9wZQ", // "nope"
+		"sevrity=High",
+	} {
+		params, _ := url.ParseQuery(query)
+		if _, err := ParseQuery(params); err == nil {
+			t.Errorf("%q: want error", query)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Server serves a result set:
+//
+//	GET /findings                the findings a Query selects, as a Page
+//	GET /findings/{fingerprint}  the findings with a fingerprint
+//	GET /schema                  the JSON Schema of a finding
+//	GET /healthz                 {"status": "ok"} while the server is up
+//
+// and, with HandlePrioritize, scores uploaded exports:
+//
+//	POST /prioritize             the scored findings of the body
+//
+// or, with HandleIngest, scores them as background jobs.
+type Server struct {
+	findings []vuln.Vulnerability
+	mux      *http.ServeMux
+}
+
+// Prioritizer scores the findings of an uploaded export, read in format,
+// or the detected format when format is "", at the reference time now. It
+// returns them in priority order, with the number of input rows it
+// skipped. An error means the upload cannot be read.
+type Prioritizer func(ctx context.Context, body []byte, format string, now time.Time) (vs []vuln.Vulnerability, skipped int, err error)
+
+// MaxUploadBytes limits the body of POST /prioritize.
+const MaxUploadBytes = 64 << 20
+
+// NewServer returns a Server for vs, which must be in priority order, as
+// the tool writes results.
+func NewServer(vs []vuln.Vulnerability) *Server {
+	s := &Server{findings: vs, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /findings", s.list)
+	s.mux.HandleFunc("GET /findings/{fingerprint}", s.get)
+	s.mux.HandleFunc("GET /schema", schema)
+	s.mux.HandleFunc("GET /healthz", healthz)
+	return s
+}
+
+// HandlePrioritize serves POST /prioritize with p. The body is an export
+// in any input format, or a JSON array of findings to score again; the
+// format query parameter names its format, and today the reference date
+// (YYYY-MM-DD, default the current date). The response is the scored
+// findings as a JSON array, in priority order, with the number of skipped
+// input rows in the X-Skipped-Rows header.
+func (s *Server) HandlePrioritize(p Prioritizer) {
+	s.mux.HandleFunc("POST /prioritize", func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		if today := r.URL.Query().Get("today"); today != "" {
+			d, err := vuln.ParseDate(today)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "today: "+err.Error())
+				return
+			}
+			now = d.Time
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxUploadBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, "the upload is larger than "+strconv.Itoa(MaxUploadBytes>>20)+" MiB")
+				return
+			}
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		vs, skipped, err := p(r.Context(), body, r.URL.Query().Get("format"), now)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if vs == nil {
+			vs = []vuln.Vulnerability{}
+		}
+		w.Header().Set("X-Skipped-Rows", strconv.Itoa(skipped))
+		writeJSON(w, http.StatusOK, vs)
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	q, err := ParseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, q.Apply(s.findings))
+}
+
+// get returns every finding with the fingerprint, since repeated reports
+// of one finding share it.
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	fingerprint := r.PathValue("fingerprint")
+	matches := []vuln.Vulnerability{}
+	for i := range s.findings {
+		if s.findings[i].Fingerprint() == fingerprint {
+			matches = append(matches, s.findings[i])
+		}
+	}
+	if len(matches) == 0 {
+		writeError(w, http.StatusNotFound, "no findings with fingerprint "+fingerprint)
+		return
+	}
+	writeJSON(w, http.StatusOK, matches)
+}
+
+// schema serves output.FindingSchema, which describes the findings of every
+// response.
+func schema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(output.FindingSchema)
+}
+
+func healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestServer(t *testing.T) {
+	ts := httptest.NewServer(NewServer(findings))
+	defer ts.Close()
+
+	get := func(path string, status int, v any) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("GET %s: status %d, want %d", path, resp.StatusCode, status)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("GET %s: Content-Type %q", path, ct)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+	}
+
+	var page Page
+	get("/findings?severity=Critical&min_score=8&sort=-priority_score&limit=1", http.StatusOK, &page)
+	if page.Total != 1 || ids(page.Findings) != "1" || page.NextCursor != "" {
+		t.Errorf("page = %+v", page)
+	}
+
+	var errBody map[string]string
+	get("/findings?limit=-1", http.StatusBadRequest, &errBody)
+	if errBody["error"] == "" {
+		t.Error("400 response has no error message")
+	}
+
+	var one []vuln.Vulnerability
+	get("/findings/"+findings[2].Fingerprint(), http.StatusOK, &one)
+	if ids(one) != "3" {
+		t.Errorf("by fingerprint = %q", ids(one))
+	}
+	get("/findings/feed", http.StatusNotFound, &errBody)
+
+	resp, err := http.Get(ts.URL + "/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var schema struct {
+		Title string `json:"title"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&schema); err != nil || schema.Title != "Finding" || resp.Header.Get("Content-Type") != "application/schema+json" {
+		t.Errorf("GET /schema: %v, %q, %+v", err, resp.Header.Get("Content-Type"), schema)
+	}
+
+	var health map[string]string
+	get("/healthz", http.StatusOK, &health)
+	if health["status"] != "ok" {
+		t.Errorf("GET /healthz = %v", health)
+	}
+	if resp, err := http.Post(ts.URL+"/prioritize", "text/csv", strings.NewReader("")); err != nil || resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotFound {
+		t.Errorf("POST /prioritize without HandlePrioritize: %v, %v", err, resp)
+	}
+}
+
+func TestServerPrioritize(t *testing.T) {
+	s := NewServer(nil)
+	var gotFormat string
+	var gotNow time.Time
+	s.HandlePrioritize(func(_ context.Context, body []byte, format string, now time.Time) ([]vuln.Vulnerability, int, error) {
+		gotFormat, gotNow = format, now
+		if string(body) == "bad" {
+			return nil, 0, errors.New("unreadable upload")
+		}
+		return findings[:2], 3, nil
+	})
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	post := func(query, body string, status int, v any) *http.Response {
+		t.Helper()
+		resp, err := http.Post(ts.URL+"/prioritize"+query, "text/csv", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("POST %s: status %d, want %d", query, resp.StatusCode, status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("POST %s: %v", query, err)
+		}
+		return resp
+	}
+
+	var vs []vuln.Vulnerability
+	resp := post("?format=trivy&today=2025-02-05", "Identifier\nCVE-1\n", http.StatusOK, &vs)
+	if ids(vs) != "1,2" || resp.Header.Get("X-Skipped-Rows") != "3" {
+		t.Errorf("findings %q, skipped %q", ids(vs), resp.Header.Get("X-Skipped-Rows"))
+	}
+	if gotFormat != "trivy" || !gotNow.Equal(time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("prioritizer got format %q, now %v", gotFormat, gotNow)
+	}
+
+	var errBody map[string]string
+	post("", "bad", http.StatusBadRequest, &errBody)
+	if errBody["error"] != "unreadable upload" {
+		t.Errorf("error = %v", errBody)
+	}
+	post("?today=yesterday", "", http.StatusBadRequest, &errBody)
+	post("", strings.Repeat("x", MaxUploadBytes+1), http.StatusRequestEntityTooLarge, &errBody)
+}
@@ -0,0 +1,162 @@
+// Package assetgroup groups the instances of one logical asset, such as
+// the clones an autoscaling group launches. Instances share a name, or a
+// name up to an instance suffix, but each has its own asset ID, so an
+// export lists every vulnerability of the image they run once per
+// instance. Grouped, those reports become one finding on the logical
+// asset that lists the instances affected.
+package assetgroup
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/merge"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Grouper names the logical asset of each asset. The zero Grouper groups
+// assets by name.
+type Grouper struct {
+	// Pattern, when set, matches the names of instances. Its subexpression
+	// named "asset", or else its first, is the logical asset's name. A
+	// name it does not match is its own logical asset's.
+	Pattern *regexp.Regexp
+}
+
+// Compile returns a Grouper for pattern, which must have a subexpression
+// for the logical asset's name, as in `^(.+)-i-[0-9a-f]+$`. An empty
+// pattern groups assets by name.
+func Compile(pattern string) (*Grouper, error) {
+	if pattern == "" {
+		return &Grouper{}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.NumSubexp() == 0 {
+		return nil, errors.New("want a subexpression, as in (.+), for the logical asset's name")
+	}
+	return &Grouper{Pattern: re}, nil
+}
+
+// Name returns the name of the logical asset the asset named name is an
+// instance of.
+func (g *Grouper) Name(name string) string {
+	name = strings.TrimSpace(name)
+	if g.Pattern == nil {
+		return name
+	}
+	m := g.Pattern.FindStringSubmatch(name)
+	if m == nil {
+		return name
+	}
+	i := g.Pattern.SubexpIndex("asset")
+	if i < 0 {
+		i = 1
+	}
+	if logical := strings.TrimSpace(m[i]); logical != "" {
+		return logical
+	}
+	return name
+}
+
+// Stats counts what Apply grouped.
+type Stats struct {
+	Assets    int // logical assets of more than one instance
+	Instances int // instances of those assets
+	Merged    int // findings merged into another instance's report
+}
+
+// Apply groups the findings of vs on logical assets of more than one
+// instance, that is whose instances have more than one asset ID. Such a
+// finding's asset name becomes the logical asset's name and its asset ID
+// the same, so that its fingerprint holds as instances come and go, and
+// its Instances lists the asset IDs of the instances it was reported on.
+// Findings that then share a fingerprint merge into the first as for
+// dedup.Apply: the earliest first detected and due dates, blanks filled
+// in from the later reports, and their Occurrences, if any, summed. A
+// finding already grouped counts its own Instances, so grouping grouped
+// results again changes nothing.
+//
+// It returns the findings left, in input order.
+func (g *Grouper) Apply(vs []vuln.Vulnerability) ([]vuln.Vulnerability, Stats) {
+	var stats Stats
+	names := make([]string, len(vs))
+	ids := make(map[string][]string) // instance IDs by logical name
+	for i := range vs {
+		v := &vs[i]
+		names[i] = g.Name(v.AssetName)
+		if names[i] == "" {
+			continue
+		}
+		for _, id := range instances(v) {
+			if !slices.Contains(ids[names[i]], id) {
+				ids[names[i]] = append(ids[names[i]], id)
+			}
+		}
+	}
+	for _, instances := range ids {
+		if len(instances) > 1 {
+			stats.Assets++
+			stats.Instances += len(instances)
+		}
+	}
+
+	out := vs[:0]
+	kept := make(map[string]int) // fingerprint to index in out
+	for i, v := range vs {
+		if len(ids[names[i]]) < 2 {
+			out = append(out, v)
+			continue
+		}
+		v.Instances = instances(&v)
+		v.AssetName, v.AssetID = names[i], names[i]
+		fp := v.Fingerprint()
+		j, ok := kept[fp]
+		if !ok {
+			kept[fp] = len(out)
+			out = append(out, v)
+			continue
+		}
+		cur := &out[j]
+		cur.FirstDetectedDate = earliest(cur.FirstDetectedDate, v.FirstDetectedDate)
+		cur.DueDate = earliest(cur.DueDate, v.DueDate)
+		if cur.Occurrences > 0 || v.Occurrences > 0 {
+			cur.Occurrences = max(cur.Occurrences, 1) + max(v.Occurrences, 1)
+		}
+		for _, id := range v.Instances {
+			if !slices.Contains(cur.Instances, id) {
+				cur.Instances = append(cur.Instances, id)
+			}
+		}
+		merge.FillBlanks(cur, &v)
+		stats.Merged++
+	}
+	for i := range out {
+		slices.Sort(out[i].Instances)
+	}
+	return out, stats
+}
+
+// instances returns the asset IDs of the instances v was reported on: its
+// Instances once grouped, else its asset ID.
+func instances(v *vuln.Vulnerability) []string {
+	if len(v.Instances) > 0 {
+		return slices.Clone(v.Instances)
+	}
+	if id := strings.TrimSpace(v.AssetID); id != "" {
+		return []string{id}
+	}
+	return nil
+}
+
+// earliest returns the earlier of two dates, ignoring a zero one.
+func earliest(a, b vuln.Date) vuln.Date {
+	if a.IsZero() || (!b.IsZero() && b.Before(a.Time)) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,70 @@
+package assetgroup
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestApply(t *testing.T) {
+	finding := func(name, id, cve string, first vuln.Date) vuln.Vulnerability {
+		return vuln.Vulnerability{AssetName: name, AssetID: id, Identifier: cve, PackageName: "openssl", InstalledVersion: "3.0.2", FirstDetectedDate: first}
+	}
+	a := finding("web", "i-0aaa", "CVE-2024-5535", vuln.NewDate(2025, 1, 10))
+	b := finding("web", "i-0bbb", "CVE-2024-5535", vuln.NewDate(2024, 12, 1))
+	b.FixedVersion = "3.0.14"
+	c := finding("web", "i-0ccc", "CVE-2024-5535", vuln.Date{})
+	only := finding("web", "i-0bbb", "CVE-2024-6119", vuln.NewDate(2025, 1, 1))
+	single := finding("db", "i-0ddd", "CVE-2024-5535", vuln.Date{})
+
+	vs, stats := (&Grouper{}).Apply([]vuln.Vulnerability{a, single, b, only, c})
+	if stats != (Stats{Assets: 1, Instances: 3, Merged: 2}) || len(vs) != 3 {
+		t.Fatalf("stats %+v, left %d, want 1 asset of 3 instances, 2 merged and 3 left", stats, len(vs))
+	}
+	got := vs[0]
+	if got.AssetName != "web" || got.AssetID != "web" || !slices.Equal(got.Instances, []string{"i-0aaa", "i-0bbb", "i-0ccc"}) {
+		t.Errorf("grouped finding = %+v", got)
+	}
+	if got.FirstDetectedDate.String() != "2024-12-01" || got.FixedVersion != "3.0.14" {
+		t.Errorf("grouped finding: first detected %s, fixed %q", got.FirstDetectedDate, got.FixedVersion)
+	}
+	if vs[1].AssetID != "i-0ddd" || vs[1].Instances != nil {
+		t.Errorf("single instance asset = %+v", vs[1])
+	}
+	if vs[2].Identifier != "CVE-2024-6119" || !slices.Equal(vs[2].Instances, []string{"i-0bbb"}) {
+		t.Errorf("finding on one instance = %+v", vs[2])
+	}
+
+	// Grouped results grouped again are unchanged, and a new instance joins.
+	d := finding("web", "i-0eee", "CVE-2024-5535", vuln.Date{})
+	again, stats := (&Grouper{}).Apply(append(slices.Clone(vs), d))
+	if stats.Merged != 1 || len(again) != 3 || !slices.Equal(again[0].Instances, []string{"i-0aaa", "i-0bbb", "i-0ccc", "i-0eee"}) {
+		t.Errorf("re-applied: %+v, %+v", stats, again[0])
+	}
+}
+
+func TestPattern(t *testing.T) {
+	g, err := Compile(`^(?P<asset>.+)-[0-9]+$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"api-7": "api", "api-12": "api", "api": "api", "-3": "-3"} {
+		if got := g.Name(name); got != want {
+			t.Errorf("Name(%q) = %q, want %q", name, got, want)
+		}
+	}
+	vs, stats := g.Apply([]vuln.Vulnerability{
+		{AssetName: "api-7", AssetID: "1", Identifier: "CVE-1"},
+		{AssetName: "api-12", AssetID: "2", Identifier: "CVE-1"},
+		{AssetName: "worker-1", AssetID: "3", Identifier: "CVE-1"},
+	})
+	if stats.Merged != 1 || len(vs) != 2 || vs[0].AssetName != "api" || vs[1].AssetName != "worker-1" {
+		t.Errorf("stats %+v, findings %+v", stats, vs)
+	}
+	for _, bad := range []string{"(", "^api-[0-9]+$"} {
+		if _, err := Compile(bad); err == nil {
+			t.Errorf("Compile(%q): want error", bad)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package awsfindings pulls package vulnerability findings straight from
+// Amazon Inspector or AWS Security Hub, through their HTTP APIs, so a run
+// needs no export. Findings are read as for ingest.ReadInspector and
+// ingest.ReadSecurityHub, a page at a time.
+package awsfindings
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+)
+
+// Prefix marks an input pulled from AWS, as in aws:inspector2.
+const Prefix = "aws:"
+
+// Service is an AWS service findings are pulled from.
+type Service string
+
+// Services.
+const (
+	// Inspector lists its active package vulnerability findings with
+	// ListFindings.
+	Inspector Service = "inspector2"
+	// SecurityHub gets its active vulnerability findings, of any product
+	// that sends them, with GetFindings.
+	SecurityHub Service = "securityhub"
+)
+
+// Inputs are the -input values that pull from AWS, for flag help.
+var Inputs = []string{Prefix + string(Inspector), Prefix + string(SecurityHub)}
+
+// ParseService returns the service named name, as in the input
+// aws:inspector2.
+func ParseService(name string) (Service, error) {
+	switch s := Service(strings.ToLower(name)); s {
+	case Inspector, SecurityHub:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown AWS service %q: want %s or %s", name, Inspector, SecurityHub)
+}
+
+// pageSize is how many findings a page asks for, the most both APIs allow.
+const pageSize = 100
+
+// Client pulls findings from one service.
+type Client struct {
+	Service Service
+	Config  *Config
+	// Endpoint overrides the service's regional endpoint, for tests.
+	Endpoint string
+	Client   *http.Client
+
+	now func() time.Time // for tests
+}
+
+// Findings are the findings of a pull.
+type Findings struct {
+	*ingest.Result
+	Pages  int
+	SHA256 string // checksum of the response bodies, in order
+	Bytes  int64  // their size
+}
+
+// Pull reads every active finding, following the pages to the last.
+// Skipped findings are numbered by their position in the whole pull.
+func (c *Client) Pull(ctx context.Context) (*Findings, error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	out := &Findings{Result: &ingest.Result{}}
+	sum := sha256.New()
+	read := 0 // findings on earlier pages
+	token := ""
+	seen := make(map[string]bool)
+	for {
+		body, err := c.page(ctx, client, token)
+		if err != nil {
+			return nil, err
+		}
+		sum.Write(body)
+		out.Pages++
+		out.Bytes += int64(len(body))
+
+		// Inspector's members are camelCase and Security Hub's start with
+		// a capital, as encoding/json matches either.
+		var page struct {
+			Findings  []json.RawMessage `json:"findings"`
+			NextToken string            `json:"nextToken"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Service, err)
+		}
+		res, err := c.read(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		out.Header = res.Header
+		out.Vulns = append(out.Vulns, res.Vulns...)
+		for _, e := range res.Skipped {
+			e.Line += read
+			out.Skipped = append(out.Skipped, e)
+		}
+		read += len(page.Findings)
+
+		if page.NextToken == "" {
+			break
+		}
+		if seen[page.NextToken] {
+			return nil, fmt.Errorf("%s: page token repeated after %d pages", c.Service, out.Pages)
+		}
+		seen[page.NextToken] = true
+		token = page.NextToken
+	}
+	out.SHA256 = hex.EncodeToString(sum.Sum(nil))
+	return out, nil
+}
+
+func (c *Client) read(r io.Reader) (*ingest.Result, error) {
+	if c.Service == SecurityHub {
+		return ingest.ReadSecurityHub(r)
+	}
+	return ingest.ReadInspector(r)
+}
+
+// page fetches the page after token, or the first page when it is "".
+func (c *Client) page(ctx context.Context, client *http.Client, token string) ([]byte, error) {
+	var path, action string
+	var in map[string]any
+	switch c.Service {
+	case Inspector:
+		path, action = "/findings/list", "ListFindings"
+		in = map[string]any{
+			"filterCriteria": map[string]any{
+				"findingStatus": []map[string]string{{"comparison": "EQUALS", "value": "ACTIVE"}},
+				"findingType":   []map[string]string{{"comparison": "EQUALS", "value": "PACKAGE_VULNERABILITY"}},
+			},
+			"maxResults": pageSize,
+		}
+		if token != "" {
+			in["nextToken"] = token
+		}
+	case SecurityHub:
+		path, action = "/findings", "GetFindings"
+		in = map[string]any{
+			"Filters": map[string]any{
+				"RecordState": []map[string]string{{"Comparison": "EQUALS", "Value": "ACTIVE"}},
+				// Suppressed and resolved findings are left out, as
+				// Inspector leaves them out of its active ones.
+				"WorkflowStatus": []map[string]string{{"Comparison": "EQUALS", "Value": "NEW"}, {"Comparison": "EQUALS", "Value": "NOTIFIED"}},
+				"Type":           []map[string]string{{"Comparison": "PREFIX", "Value": "Software and Configuration Checks/Vulnerabilities"}},
+			},
+			"MaxResults": pageSize,
+		}
+		if token != "" {
+			in["NextToken"] = token
+		}
+	default:
+		return nil, fmt.Errorf("unknown AWS service %q", c.Service)
+	}
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint()+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	c.Config.Credentials.sign(req, payload, string(c.Service), c.Config.Region, now())
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", c.Service, action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s: %s", c.Service, action, resp.Status, apiError(resp.Header, body))
+	}
+	return body, nil
+}
+
+// endpoint returns the service's endpoint in the configured region.
+func (c *Client) endpoint() string {
+	if c.Endpoint != "" {
+		return strings.TrimSuffix(c.Endpoint, "/")
+	}
+	return "https://" + string(c.Service) + "." + c.Config.Region + ".amazonaws.com"
+}
+
+// apiError returns the type and message of an AWS error response, or its
+// body when it has neither.
+func apiError(h http.Header, body []byte) string {
+	var e struct {
+		Type    string `json:"__type"`
+		Message string `json:"message"` // or Message, as encoding/json matches either
+	}
+	json.Unmarshal(body, &e)
+	kind := h.Get("X-Amzn-Errortype")
+	if kind == "" {
+		kind = e.Type
+	}
+	kind, _, _ = strings.Cut(kind, ":")
+	switch {
+	case kind != "" && e.Message != "":
+		return kind + ": " + e.Message
+	case kind != "":
+		return kind
+	case e.Message != "":
+		return e.Message
+	}
+	msg := bytes.TrimSpace(body)
+	return string(msg[:min(len(msg), 4096)])
+}
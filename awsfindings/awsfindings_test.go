@@ -0,0 +1,191 @@
+package awsfindings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSign checks the get-vanilla case of the AWS Signature Version 4
+// test suite.
+func TestSign(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	creds.sign(req, nil, "service", "us-east-1", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %s\nwant %s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %s", got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	for _, env := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", write("credentials", `
+[default]
+aws_access_key_id = AKIADEFAULT
+aws_secret_access_key = default-secret
+
+[security]
+aws_access_key_id=AKIASECURITY
+aws_secret_access_key=security-secret
+aws_session_token=token
+`))
+	t.Setenv("AWS_CONFIG_FILE", write("config", `
+[default]
+region = us-east-1
+
+[profile security]
+region = us-west-2
+s3 =
+  max_concurrent_requests = 10
+
+[profile sso]
+sso_session = acme
+region = eu-west-1
+`))
+
+	c, err := Load("security", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Region != "us-west-2" || c.Credentials != (Credentials{"AKIASECURITY", "security-secret", "token"}) {
+		t.Errorf("profile security: %+v", c)
+	}
+	if c, err := Load("", "ap-south-1"); err != nil || c.Region != "ap-south-1" || c.Credentials.AccessKeyID != "AKIADEFAULT" {
+		t.Errorf("default profile: %+v, %v", c, err)
+	}
+
+	// The environment's keys, unless a profile is named.
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	t.Setenv("AWS_REGION", "us-east-2")
+	if c, err := Load("", ""); err != nil || c.Region != "us-east-2" || c.Credentials.AccessKeyID != "AKIAENV" {
+		t.Errorf("environment: %+v, %v", c, err)
+	}
+	if c, err := Load("security", ""); err != nil || c.Region != "us-east-2" || c.Credentials.AccessKeyID != "AKIASECURITY" {
+		t.Errorf("named profile over the environment: %+v, %v", c, err)
+	}
+
+	if _, err := Load("sso", ""); err == nil || !strings.Contains(err.Error(), "export-credentials --profile sso") {
+		t.Errorf("SSO profile: %v", err)
+	}
+	if _, err := Load("missing", ""); err == nil {
+		t.Error("missing profile: no error")
+	}
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "none"))
+	if _, err := Load("security", ""); err == nil || !strings.Contains(err.Error(), "no region") {
+		t.Errorf("no region: %v", err)
+	}
+}
+
+func TestPull(t *testing.T) {
+	finding := func(n int) string {
+		return fmt.Sprintf(`{"findingArn": "arn:aws:inspector2:us-west-2:253710526682:finding/%d", "awsAccountId": "253710526682", "severity": "HIGH", "type": "PACKAGE_VULNERABILITY",
+			"resources": [{"id": "i-0%d", "region": "us-west-2", "type": "AWS_EC2_INSTANCE", "details": {"awsEc2Instance": {}}}],
+			"packageVulnerabilityDetails": {"vulnerabilityId": "CVE-2024-%04d", "vulnerablePackages": [{"name": "openssl", "version": "3.0.2"}]}}`, n, n, n)
+	}
+	pages := []string{
+		`{"findings": [` + finding(1) + `, {"findingArn": "network", "type": "NETWORK_REACHABILITY"}], "nextToken": "page2"}`,
+		`{"findings": [{"findingArn": "code", "type": "CODE_VULNERABILITY"}, ` + finding(2) + `]}`,
+	}
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIAEXAMPLE/20250205/us-west-2/inspector2/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token,") {
+			t.Errorf("Authorization %s", auth)
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/findings/list" {
+			t.Errorf("%s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			FilterCriteria map[string][]struct{ Comparison, Value string } `json:"filterCriteria"`
+			MaxResults     int                                             `json:"maxResults"`
+			NextToken      string                                          `json:"nextToken"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.FilterCriteria["findingStatus"][0].Value != "ACTIVE" || body.FilterCriteria["findingType"][0].Value != "PACKAGE_VULNERABILITY" || body.MaxResults != 100 {
+			t.Errorf("request %+v", body)
+		}
+		page := 0
+		if body.NextToken == "page2" {
+			page = 1
+		}
+		io.WriteString(w, pages[page])
+	}))
+	defer srv.Close()
+
+	c := &Client{
+		Service:  Inspector,
+		Config:   &Config{Credentials: Credentials{"AKIAEXAMPLE", "secret", "session"}, Region: "us-west-2"},
+		Endpoint: srv.URL,
+		now:      func() time.Time { return time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC) },
+	}
+	got, err := c.Pull(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || got.Pages != 2 || got.Bytes != int64(len(pages[0])+len(pages[1])) || len(got.SHA256) != 64 {
+		t.Errorf("%d requests, %d pages of %d bytes, sha256 %q", requests, got.Pages, got.Bytes, got.SHA256)
+	}
+	if len(got.Vulns) != 2 || got.Vulns[0].Identifier != "CVE-2024-0001" || got.Vulns[1].AssetID != "i-02" {
+		t.Errorf("findings %+v", got.Vulns)
+	}
+	if len(got.Skipped) != 2 || got.Skipped[0].Line != 2 || got.Skipped[1].Line != 3 {
+		t.Errorf("skipped %+v", got.Skipped)
+	}
+}
+
+func TestPullError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/findings" {
+			t.Errorf("path %s", r.URL.Path)
+		}
+		w.Header().Set("X-Amzn-Errortype", "AccessDeniedException:http://internal.amazon.com/coral/com.amazon.coral.service/")
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"Message": "User is not authorized to perform: securityhub:GetFindings"}`)
+	}))
+	defer srv.Close()
+	c := &Client{Service: SecurityHub, Config: &Config{Credentials: Credentials{AccessKeyID: "AKIAEXAMPLE", SecretAccessKey: "secret"}, Region: "us-west-2"}, Endpoint: srv.URL}
+	_, err := c.Pull(context.Background())
+	want := "securityhub GetFindings: 403 Forbidden: AccessDeniedException: User is not authorized to perform: securityhub:GetFindings"
+	if err == nil || err.Error() != want {
+		t.Errorf("error %v, want %s", err, want)
+	}
+}
+
+func TestParseService(t *testing.T) {
+	if s, err := ParseService("SecurityHub"); err != nil || s != SecurityHub {
+		t.Errorf("ParseService(SecurityHub) = %q, %v", s, err)
+	}
+	if _, err := ParseService("guardduty"); err == nil {
+		t.Error("ParseService(guardduty): no error")
+	}
+}
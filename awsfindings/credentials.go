@@ -0,0 +1,159 @@
+package awsfindings
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials are AWS access keys, with the session token of temporary
+// ones.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// Config is the credentials and region requests are signed with.
+type Config struct {
+	Credentials Credentials
+	Region      string
+}
+
+// Load returns the credentials and region of the named profile, found as
+// the AWS CLI finds them. Without a profile the credentials come from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN when set,
+// and otherwise from the profile $AWS_PROFILE, else default. A profile's
+// keys are read from the shared credentials file ($AWS_SHARED_CREDENTIALS_FILE,
+// else ~/.aws/credentials), else the config file ($AWS_CONFIG_FILE, else
+// ~/.aws/config). Profiles that assume a role, sign in with SSO or run a
+// credential process need the CLI: export their credentials to the
+// environment with aws configure export-credentials --format env.
+//
+// Without a region the region is $AWS_REGION, else $AWS_DEFAULT_REGION,
+// else the profile's region in the config file.
+func Load(profile, region string) (*Config, error) {
+	named := profile != ""
+	if !named {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+	credsPath, err := awsFile("AWS_SHARED_CREDENTIALS_FILE", "credentials")
+	if err != nil {
+		return nil, err
+	}
+	configPath, err := awsFile("AWS_CONFIG_FILE", "config")
+	if err != nil {
+		return nil, err
+	}
+	creds, err := readINI(credsPath)
+	if err != nil {
+		return nil, err
+	}
+	configs, err := readINI(configPath)
+	if err != nil {
+		return nil, err
+	}
+	section := "profile " + profile
+	if profile == "default" && configs[section] == nil {
+		section = "default"
+	}
+	conf := configs[section]
+
+	c := &Config{Region: region}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if c.Region == "" {
+			c.Region = os.Getenv(env)
+		}
+	}
+	if c.Region == "" {
+		c.Region = conf["region"]
+	}
+	if c.Region == "" {
+		return nil, fmt.Errorf("no region for profile %s: set AWS_REGION or the profile's region", profile)
+	}
+
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" && !named {
+		c.Credentials = Credentials{AccessKeyID: id, SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"), SessionToken: os.Getenv("AWS_SESSION_TOKEN")}
+		if c.Credentials.SecretAccessKey == "" {
+			return nil, errors.New("AWS_ACCESS_KEY_ID is set without AWS_SECRET_ACCESS_KEY")
+		}
+		return c, nil
+	}
+	keys := creds[profile]
+	if keys["aws_access_key_id"] == "" {
+		keys = conf
+	}
+	if keys["aws_access_key_id"] != "" {
+		c.Credentials = Credentials{AccessKeyID: keys["aws_access_key_id"], SecretAccessKey: keys["aws_secret_access_key"], SessionToken: keys["aws_session_token"]}
+		if c.Credentials.SecretAccessKey == "" {
+			return nil, fmt.Errorf("profile %s: aws_access_key_id without aws_secret_access_key", profile)
+		}
+		return c, nil
+	}
+	for _, key := range []string{"role_arn", "sso_session", "sso_start_url", "credential_process", "web_identity_token_file"} {
+		if conf[key] != "" {
+			return nil, fmt.Errorf("profile %s gets its credentials with %s, which needs the AWS CLI; export them with aws configure export-credentials --profile %s --format env", profile, key, profile)
+		}
+	}
+	if creds[profile] == nil && conf == nil {
+		return nil, fmt.Errorf("no AWS credentials: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or add the profile %s to %s", profile, credsPath)
+	}
+	return nil, fmt.Errorf("profile %s has no aws_access_key_id in %s or %s", profile, credsPath, configPath)
+}
+
+// awsFile returns the path of a shared AWS file: $env, else name in ~/.aws.
+func awsFile(env, name string) (string, error) {
+	if path := os.Getenv(env); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("finding ~/.aws/%s: %w", name, err)
+	}
+	return filepath.Join(home, ".aws", name), nil
+}
+
+// readINI returns the keys of each section of the shared AWS file at path,
+// or nil if there is no such file. Keys are lowercased; the indented keys
+// of nested settings, such as a service's, are left out.
+func readINI(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sections := make(map[string]map[string]string)
+	var cur map[string]string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", line[0] == '#', line[0] == ';', indented:
+		case line[0] == '[' && strings.HasSuffix(line, "]"):
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			if sections[name] == nil {
+				sections[name] = make(map[string]string)
+			}
+			cur = sections[name]
+		case cur != nil:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				cur[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return sections, nil
+}
@@ -0,0 +1,118 @@
+// Package canonical writes JSON in one byte-for-byte form per value, so
+// that result files kept in git differ only where the data does.
+package canonical
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns the canonical JSON encoding of v: v's encoding/json
+// encoding with object keys sorted, two-space indentation, no HTML
+// escaping and a final newline. Integers are written as they are, and
+// other numbers in the shortest decimal form that reads back exactly,
+// never with an exponent, so 1e-05 is written 0.00001 and 7.50 is 7.5.
+func Marshal(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var tree any
+	if err := dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := write(&buf, tree, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func write(buf *bytes.Buffer, v any, indent string) error {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteString("{\n")
+		for i, k := range keys {
+			buf.WriteString(indent + "  ")
+			writeString(buf, k)
+			buf.WriteString(": ")
+			if err := write(buf, v[k], indent+"  "); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case []any:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, e := range v {
+			buf.WriteString(indent + "  ")
+			if err := write(buf, e, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(v)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	case json.Number:
+		s, err := number(v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(s)
+	case string:
+		writeString(buf, v)
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	}
+	return nil
+}
+
+// number returns the canonical form of n.
+func number(n json.Number) (string, error) {
+	if !strings.ContainsAny(string(n), ".eE") {
+		return string(n), nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return "", err
+	}
+	if f == 0 {
+		// Also for -0.
+		return "0", nil
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode ends the value with a newline.
+	buf.Truncate(buf.Len() - 1)
+}
@@ -0,0 +1,59 @@
+package canonical
+
+import (
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	type finding struct {
+		Zeta  string             `json:"zeta"`
+		Alpha float64            `json:"alpha"`
+		Tags  []string           `json:"tags"`
+		Extra map[string]float64 `json:"extra"`
+		Empty map[string]string  `json:"empty"`
+		Count int                `json:"count"`
+	}
+	got, err := Marshal([]finding{{
+		Zeta:  "<a href=\"x\">&</a>",
+		Alpha: 7.50,
+		Tags:  []string{"v2", "latest"},
+		Extra: map[string]float64{"tiny": 0.00001, "huge": 1e21, "neg": -0.0, "epss": 0.00043},
+		Empty: map[string]string{},
+		Count: 3,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "alpha": 7.5,
+    "count": 3,
+    "empty": {},
+    "extra": {
+      "epss": 0.00043,
+      "huge": 1000000000000000000000,
+      "neg": 0,
+      "tiny": 0.00001
+    },
+    "tags": [
+      "v2",
+      "latest"
+    ],
+    "zeta": "<a href=\"x\">&</a>"
+  }
+]
+`
+	if string(got) != want {
+		t.Errorf("Marshal =\n%s\nwant\n%s", got, want)
+	}
+
+	// Decoded JSON, with nulls and empty arrays.
+	var tree any = []any{map[string]any{"b": 1.25, "a": []any{}, "c": nil, "d": true}}
+	untyped, err := Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(untyped) != "[\n  {\n    \"a\": [],\n    \"b\": 1.25,\n    \"c\": null,\n    \"d\": true\n  }\n]\n" {
+		t.Errorf("Marshal(tree) =\n%s", untyped)
+	}
+}
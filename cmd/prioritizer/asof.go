@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/history"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/report"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/tracking"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// runAsOf writes the findings that were open on a past day, with the
+// scores and workflow states they had then, rebuilt from the -db
+// tracking store and the state audit log, for auditors asking about the
+// posture at a point in time.
+func runAsOf(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("as-of: want a date first, as in as-of 2025-03-01 -db findings.json")
+	}
+	day, err := vuln.ParseDate(args[0])
+	if err != nil {
+		return fmt.Errorf("as-of: %w", err)
+	}
+	fs := flag.NewFlagSet("prioritizer as-of", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dbPath := fs.String("db", "", "tracking store `file` written by -db (JSON, required)")
+	statePath := fs.String("state", "", "take the workflow states of the day from the audit log of the state store `file`")
+	auditPath := fs.String("audit", "", "workflow state audit log `file` (default "+auditFileName+" next to -state)")
+	historyPath := fs.String("history", "", "list the runs the history store `file` had processed by the day as evidence")
+	format := fs.String("format", "text", "output `format`: text, csv or json")
+	outPath := fs.String("output", "", "write the report to `file` (default standard output)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *dbPath == "" {
+		return errors.New("as-of: -db is required")
+	}
+	var write func(*report.Posture, io.Writer) error
+	switch *format {
+	case "text":
+		write = (*report.Posture).WriteText
+	case "csv":
+		write = (*report.Posture).WriteCSV
+	case "json":
+		write = func(p *report.Posture, w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(p)
+		}
+	default:
+		return fmt.Errorf("as-of: unknown -format %q", *format)
+	}
+	// The runs of the day count: they are recorded at its start.
+	at := day.Time.AddDate(0, 0, 1)
+
+	// An auditor is told of a missing store, not of an empty posture.
+	db, err := manifest.HashFile(*dbPath)
+	if err != nil {
+		return err
+	}
+	store, err := tracking.Load(*dbPath)
+	if err != nil {
+		return err
+	}
+	evidence := []report.Source{{Name: "tracking", Path: db.Path, SHA256: db.SHA256, Detail: fmt.Sprintf("%d findings", len(store.Findings))}}
+	var states map[string]string
+	if *auditPath == "" && *statePath != "" {
+		*auditPath = filepath.Join(filepath.Dir(*statePath), auditFileName)
+	}
+	if *auditPath != "" {
+		changes, err := state.ReadAudit(*auditPath)
+		if err != nil {
+			return err
+		}
+		states = state.StatesAt(changes, at)
+		src := report.Source{Name: "audit", Path: *auditPath, Detail: "no changes"}
+		if len(changes) > 0 {
+			f, err := manifest.HashFile(*auditPath)
+			if err != nil {
+				return err
+			}
+			by := 0
+			for _, c := range changes {
+				if c.At.Before(at) {
+					by++
+				}
+			}
+			src.SHA256, src.Detail = f.SHA256, fmt.Sprintf("%d of %d changes by then", by, len(changes))
+		}
+		evidence = append(evidence, src)
+	}
+	if *historyPath != "" {
+		h, err := history.Load(*historyPath)
+		if err != nil {
+			return err
+		}
+		var runs []history.Run
+		for _, r := range h.Runs {
+			if r.ProcessedAt.Before(at) {
+				runs = append(runs, r)
+			}
+		}
+		src := report.Source{Name: "history", Path: *historyPath, Detail: "no runs by then"}
+		if n := len(runs); n > 0 {
+			last := runs[n-1]
+			src.Detail = fmt.Sprintf("%d runs by then, the last of %s (sha256:%s) at %s", n, last.Input, last.InputSHA256, last.ProcessedAt.UTC().Format(time.RFC3339))
+			f, err := manifest.HashFile(*historyPath)
+			if err != nil {
+				return err
+			}
+			src.SHA256 = f.SHA256
+		}
+		evidence = append(evidence, src)
+	}
+	p := report.NewPosture(store.AsOf(at), states, day)
+	p.Evidence = evidence
+
+	if *outPath == "" {
+		return write(p, stdout)
+	}
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	if err := write(p, f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", *outPath, err)
+	}
+	return f.Close()
+}
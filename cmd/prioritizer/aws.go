@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/VioletX-Dev/devsecops-test/awsfindings"
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+)
+
+// awsInput is an -input pulled from AWS, as in aws:inspector2.
+type awsInput struct {
+	name     string // the -input
+	service  awsfindings.Service
+	profile  string
+	region   string
+	endpoint string
+	client   *http.Client // the -egress client
+}
+
+// pull pulls the findings, logging every skipped one, and returns them
+// with the input's manifest record: its name and the checksum of what was
+// pulled. The credentials are loaded for each pull, so a daemon picks up
+// rotated keys.
+func (in *awsInput) pull(ctx context.Context, logger *slog.Logger) (*ingest.Result, manifest.File, error) {
+	cfg, err := awsfindings.Load(in.profile, in.region)
+	if err != nil {
+		return nil, manifest.File{}, fmt.Errorf("%s: %w", in.name, err)
+	}
+	c := &awsfindings.Client{Service: in.service, Config: cfg, Endpoint: in.endpoint, Client: in.client}
+	got, err := c.Pull(ctx)
+	if err != nil {
+		return nil, manifest.File{}, fmt.Errorf("%s: %w", in.name, err)
+	}
+	for _, skipped := range got.Skipped {
+		logger.Warn("skipped row", "input", in.name, "line", skipped.Line, "error", skipped.Err)
+	}
+	logger.Info("pulled input", "input", in.name, "region", cfg.Region, "pages", got.Pages, "findings", len(got.Vulns), "skipped", len(got.Skipped))
+	return got.Result, manifest.File{Path: in.name, SHA256: got.SHA256, Bytes: got.Bytes}, nil
+}
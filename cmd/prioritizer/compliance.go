@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/customer"
+	"github.com/VioletX-Dev/devsecops-test/dedup"
+	"github.com/VioletX-Dev/devsecops-test/history"
+	"github.com/VioletX-Dev/devsecops-test/imageref"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/report"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// runComplianceReport writes SLA adherence by severity, from an export
+// and the workflow state store, as evidence for auditors.
+func runComplianceReport(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer compliance-report", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "vulnerability export `file` (required)")
+	statePath := fs.String("state", "", "workflow state store `file` recording resolutions (JSON, required)")
+	historyPath := fs.String("history", "", "list the processed runs in the history store `file` as evidence")
+	configPath := fs.String("config", "", "configuration `file` with the SLA policy (JSON or YAML; default the input's due dates)")
+	today := fs.String("today", "", "report `date` (YYYY-MM-DD) (default current date)")
+	format := fs.String("format", "text", "output `format`: text, csv or json")
+	outPath := fs.String("output", "", "write the report to `file` (default standard output)")
+	customersPath := fs.String("customers", "", "write a report per customer of the customer `file`, under its contracted SLA (JSON or YAML; needs -output_dir)")
+	outDir := fs.String("output_dir", "", "write the -customers reports to `directory`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" || *statePath == "" {
+		return errors.New("compliance-report: -input and -state are required")
+	}
+	switch {
+	case *customersPath != "" && *outDir == "":
+		return errors.New("compliance-report: -customers needs -output_dir")
+	case *customersPath == "" && *outDir != "":
+		return errors.New("compliance-report: -output_dir needs -customers")
+	case *customersPath != "" && *outPath != "":
+		return errors.New("compliance-report: -output and -customers are mutually exclusive")
+	}
+	var write func(*report.Compliance, io.Writer) error
+	ext := *format
+	switch *format {
+	case "text":
+		write, ext = (*report.Compliance).WriteText, "txt"
+	case "csv":
+		write = (*report.Compliance).WriteCSV
+	case "json":
+		write = func(c *report.Compliance, w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(c)
+		}
+	default:
+		return fmt.Errorf("compliance-report: unknown -format %q", *format)
+	}
+	cfg := config.Default()
+	if *configPath != "" {
+		var err error
+		if cfg, err = config.Load(*configPath); err != nil {
+			return err
+		}
+	}
+	var customers *customer.Customers
+	if *customersPath != "" {
+		var err error
+		if customers, err = customer.Load(*customersPath); err != nil {
+			return err
+		}
+	}
+	vs, err := loadScored(*input, *configPath, *today, stderr)
+	if err != nil {
+		return err
+	}
+	// The state store tracks findings by fingerprint, once each.
+	vs, _ = imageref.Apply(vs)
+	vs, _ = dedup.Apply(vs)
+	states, err := state.Load(*statePath)
+	if err != nil {
+		return err
+	}
+	now, _ := parseToday(*today) // loadScored has parsed it
+
+	in, err := manifest.HashFile(*input)
+	if err != nil {
+		return err
+	}
+	var evidence []report.Source
+	st, err := manifest.HashFile(*statePath)
+	switch {
+	case err == nil:
+		evidence = append(evidence, report.Source{Name: "state", Path: st.Path, SHA256: st.SHA256, Detail: fmt.Sprintf("%d records", len(states.Records))})
+	case errors.Is(err, os.ErrNotExist):
+		evidence = append(evidence, report.Source{Name: "state", Path: *statePath, Detail: "no records"})
+	default:
+		return err
+	}
+	if *historyPath != "" {
+		h, err := history.Load(*historyPath)
+		if err != nil {
+			return err
+		}
+		src := report.Source{Name: "history", Path: *historyPath, Detail: "no runs"}
+		if n := len(h.Runs); n > 0 {
+			first, last := h.Runs[0].ProcessedAt.UTC(), h.Runs[n-1].ProcessedAt.UTC()
+			src.Detail = fmt.Sprintf("%d runs, %s to %s", n, first.Format(time.DateOnly), last.Format(time.DateOnly))
+			f, err := manifest.HashFile(*historyPath)
+			if err != nil {
+				return err
+			}
+			src.SHA256 = f.SHA256
+		}
+		evidence = append(evidence, src)
+	}
+
+	newReport := func(vs []vuln.Vulnerability, policy sla.Policy, detail string) *report.Compliance {
+		c := report.NewCompliance(vs, states, policy, now)
+		c.Evidence = append([]report.Source{{Name: "input", Path: in.Path, SHA256: in.SHA256, Detail: detail}}, evidence...)
+		return c
+	}
+	if customers != nil {
+		return writeCustomerCompliance(customers, vs, cfg.SLA, newReport, *outDir, ext, write, stdout)
+	}
+	c := newReport(vs, cfg.SLA, fmt.Sprintf("%d findings", len(vs)))
+	if *outPath == "" {
+		return write(c, stdout)
+	}
+	return writeComplianceFile(c, *outPath, write)
+}
+
+// writeCustomerCompliance writes a compliance report per customer to dir,
+// named after the customer and the report date, over the findings in the
+// customer's accounts with the customer's SLA windows in place of policy's.
+// newReport builds a report from findings, the windows they are due by and
+// the input's evidence detail. It reports the files written, and how many
+// findings no customer owns, to stdout.
+func writeCustomerCompliance(customers *customer.Customers, vs []vuln.Vulnerability, policy sla.Policy,
+	newReport func([]vuln.Vulnerability, sla.Policy, string) *report.Compliance,
+	dir, ext string, write func(*report.Compliance, io.Writer) error, stdout io.Writer) error {
+	owned := make(map[*customer.Customer][]vuln.Vulnerability)
+	unowned := 0
+	for i := range vs {
+		if c := customers.Owner(&vs[i]); c != nil {
+			owned[c] = append(owned[c], vs[i])
+		} else {
+			unowned++
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for i := range customers.Customers {
+		cust := &customers.Customers[i]
+		terms := make(sla.Policy, len(policy)+len(cust.SLA))
+		maps.Copy(terms, policy)
+		maps.Copy(terms, cust.SLA)
+		sub := owned[cust]
+		terms.Apply(sub)
+		c := newReport(sub, terms, fmt.Sprintf("%d of %d findings", len(sub), len(vs)))
+		c.SetContract(cust.Name, cust.Accounts, cust.TargetAdherence)
+		path := filepath.Join(dir, fmt.Sprintf("%s-sla-%s.%s", cust.FileName(), c.AsOf, ext))
+		if err := writeComplianceFile(c, path, write); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "wrote %s: %d findings\n", path, len(sub))
+	}
+	if unowned > 0 {
+		fmt.Fprintf(stdout, "%d findings are in accounts no customer lists and are not reported\n", unowned)
+	}
+	return nil
+}
+
+func writeComplianceFile(c *report.Compliance, path string, write func(*report.Compliance, io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(c, f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/jobqueue"
+	"github.com/VioletX-Dev/devsecops-test/notify"
+	"github.com/VioletX-Dev/devsecops-test/scorecache"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+)
+
+// runDaemon reprioritizes the input every -interval until interrupted.
+// A warm-start cache carries enrichment and static score points between
+// cycles, so each cycle only enriches findings that are new or changed.
+// The daemon reloads its configuration, policy, suppression and asset
+// mapping files when they change; see reloadDaemon.
+func runDaemon(args []string, _, stderr io.Writer) error {
+	var (
+		interval  time.Duration
+		cycles    int
+		cachePath string
+		channels  string
+		notified  string
+		queuePath string
+		reloadAt  time.Duration
+		set       *flag.FlagSet
+	)
+	extra := func(fs *flag.FlagSet) {
+		set = fs
+		fs.DurationVar(&interval, "interval", time.Hour, "time between cycles")
+		fs.IntVar(&cycles, "cycles", 0, "stop after `n` cycles (default run until interrupted)")
+		fs.StringVar(&cachePath, "cache", "", "keep the warm-start cache in `file` across restarts")
+		fs.StringVar(&channels, "notify", "", "send digests of new findings to the channels in `file` (JSON)")
+		fs.StringVar(&notified, "notify_state", "", "keep what each channel has been told in `file` across restarts")
+		fs.StringVar(&queuePath, "queue", "", "deliver integration actions through the durable job queue `file`, retrying failures (see prioritizer queue)")
+		fs.DurationVar(&reloadAt, "reload_interval", 10*time.Second, "check the configuration, policy, suppression and asset mapping files for changes every `interval` (0 for only between cycles)")
+	}
+	opts, err := parseFlagsWith("prioritizer daemon", args, stderr, extra)
+	if err != nil {
+		return err
+	}
+	if opts.gate.Enabled() || opts.baseline != "" {
+		return errors.New("daemon: -fail_on_severity, -fail_on_score and -baseline gate a single run")
+	}
+	if slices.Contains(opts.inputs, stdio) || opts.piped {
+		return errors.New("daemon: standard input and output are read and written once; name files for -input and the outputs")
+	}
+	opts.daemon = true
+	if opts.readOnly && (cachePath != "" || channels != "" || notified != "" || queuePath != "") {
+		return errors.New("daemon: -read_only: -cache, -notify, -notify_state and -queue change stores or call integrations")
+	}
+	if channels != "" {
+		cfg, err := notify.Load(channels)
+		if err != nil {
+			return err
+		}
+		st := notify.NewState()
+		if notified != "" {
+			if st, err = notify.LoadState(notified); err != nil {
+				return err
+			}
+		}
+		opts.notifier = &notify.Notifier{Config: cfg, State: st}
+	}
+	var queue *jobqueue.Queue
+	if queuePath != "" {
+		if queue, err = jobqueue.Load(queuePath); err != nil {
+			return err
+		}
+		if opts.notifier != nil {
+			opts.notifier.Queue = queue
+		}
+	}
+	es, err := opts.enrich.enrichers()
+	if err != nil {
+		return err
+	}
+	if opts.rego != nil {
+		if err := opts.rego.Check(context.Background(), scoring.DefaultTiers); err != nil {
+			return fmt.Errorf("-rego: %w", err)
+		}
+	}
+	watch := newWatcher(set)
+	logger := newLogger(stderr, opts.quiet)
+
+	cache := scorecache.New()
+	if cachePath != "" {
+		if cache, err = scorecache.Load(cachePath); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// reload swaps in the files as they are now, if they changed and
+	// validate; otherwise the daemon keeps the last good version.
+	reload := func() {
+		changed := watch.changed()
+		if len(changed) == 0 {
+			return
+		}
+		next, nextEs, err := reloadDaemon(ctx, args, extra, opts)
+		if err != nil {
+			logger.Error("rejected reload", "changed", strings.Join(changed, ","), "error", err)
+			return
+		}
+		// The cache keeps enrichment from the asset mappings.
+		if slices.ContainsFunc(changed, func(path string) bool {
+			return path == opts.enrich.regions || path == opts.enrich.classifications
+		}) {
+			cache = scorecache.New()
+		}
+		opts, es = next, nextEs
+		logger.Info("reloaded configuration", "changed", strings.Join(changed, ","))
+	}
+	var tick <-chan time.Time
+	if reloadAt > 0 {
+		ticker := time.NewTicker(reloadAt)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for cycle := 1; ; cycle++ {
+		if cycle > 1 {
+			reload()
+		}
+		if !opts.fixedToday {
+			opts.today = time.Now()
+		}
+		start := time.Now()
+		err = prioritize(ctx, opts, es, cache, logger, nil)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logger.Error("cycle failed", "cycle", cycle, "error", err)
+		} else {
+			if cachePath != "" {
+				if err = cache.Save(cachePath); err != nil {
+					logger.Error("saving cache", "path", cachePath, "error", err)
+				}
+			}
+			if queue != nil {
+				deliverQueue(ctx, queue, queuePath, logger)
+			}
+			if opts.notifier != nil && notified != "" {
+				if err = opts.notifier.State.Save(notified); err != nil {
+					logger.Error("saving notification state", "path", notified, "error", err)
+				}
+			}
+			logger.Info("cycle done", "cycle", cycle, "duration", time.Since(start))
+		}
+		if cycles > 0 && cycle >= cycles {
+			return err
+		}
+
+		next := time.After(interval)
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-tick:
+				reload()
+			case <-next:
+				break wait
+			}
+		}
+	}
+}
+
+// deliverQueue delivers the queued jobs that are due and saves the queue.
+// Failures stay queued for a later cycle, so they are only logged.
+func deliverQueue(ctx context.Context, q *jobqueue.Queue, path string, logger *slog.Logger) {
+	stats := q.Process(ctx, nil, time.Now())
+	logger.Info("delivered queued jobs", "delivered", stats.Delivered, "failed", stats.Failed,
+		"dead_lettered", stats.DeadLettered, "waiting", stats.Waiting, "dead", len(q.Dead))
+	if err := q.Save(path); err != nil {
+		logger.Error("saving job queue", "path", path, "error", err)
+	}
+}
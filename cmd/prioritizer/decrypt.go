@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/VioletX-Dev/devsecops-test/seal"
+)
+
+// runDecrypt writes the plaintext of an output file sealed with -encrypt.
+func runDecrypt(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer decrypt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	keyPath := fs.String("key", "", "the key `file` the output was encrypted with (required)")
+	input := fs.String("input", "", "the encrypted output `file` (required)")
+	out := fs.String("output", "", "write the plaintext to `file`, with permissions 0600 (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keyPath == "" || *input == "" {
+		return errors.New("decrypt: -key and -input are required")
+	}
+	key, err := seal.LoadKey(*keyPath)
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	f, err := os.Open(*input)
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	defer f.Close()
+	r, err := seal.NewReader(f, key)
+	if err != nil {
+		return fmt.Errorf("decrypt: %s: %w", *input, err)
+	}
+	if *out == "" {
+		if _, err := io.Copy(stdout, r); err != nil {
+			return fmt.Errorf("decrypt: %s: %w", *input, err)
+		}
+		return nil
+	}
+	w, err := createFile(*out, fileOptions{mode: 0o600})
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		// Leave no part of a file that did not decrypt.
+		w.Abort()
+		return fmt.Errorf("decrypt: %s: %w", *input, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("decrypt: %s: %w", *input, err)
+	}
+	return nil
+}
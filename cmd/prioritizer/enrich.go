@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/egress"
+	"github.com/VioletX-Dev/devsecops-test/enrich"
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// enrichFlags select the enrichment sources. An empty source disables it.
+type enrichFlags struct {
+	epss, kev, exploitDB, metasploit, nvd, msrc, cpe, regions, classifications string
+
+	// nvdKey, nvdCache and nvdComplete configure the NVD lookups.
+	nvdKey, nvdCache string
+	nvdComplete      bool
+
+	// client fetches the sources, as -egress configures it, or is nil for
+	// the default client.
+	client *http.Client
+}
+
+// addEnrichFlags registers the enrichment flags on fs. When enabled is
+// false the sources default to empty, so enrichment is opt-in.
+func addEnrichFlags(fs *flag.FlagSet, enabled bool) *enrichFlags {
+	f := &enrichFlags{}
+	def := func(src string) string {
+		if enabled {
+			return src
+		}
+		return ""
+	}
+	fs.StringVar(&f.epss, "epss", def(enrich.EPSSURL), "EPSS scores `source` (URL or file, optionally gzipped)")
+	fs.StringVar(&f.kev, "kev", def(enrich.KEVURL), "CISA KEV catalog `source` (URL or file)")
+	fs.StringVar(&f.exploitDB, "exploitdb", "", "Exploit Database files_exploits.csv `source` (URL or file) for flagging CVEs with a public proof of concept (e.g. "+enrich.ExploitDBURL+")")
+	fs.StringVar(&f.metasploit, "metasploit", "", "Metasploit modules_metadata_base.json `source` (URL or file) for flagging CVEs with a weaponized exploit (e.g. "+enrich.MetasploitURL+")")
+	fs.StringVar(&f.nvd, "nvd", def(enrich.NVDURL), "NVD CVE API `URL` for filling in blank or zero CVSS scores")
+	fs.StringVar(&f.nvdKey, "nvd_api_key", os.Getenv("NVD_API_KEY"), "NVD API `key` for -nvd and -cpe_match, allowing ten times the request rate")
+	fs.StringVar(&f.nvdCache, "nvd_cache", "", "keep NVD records in `dir` for a day, so later runs do not request them again")
+	fs.BoolVar(&f.nvdComplete, "nvd_complete", false, "also look up findings that have a CVSS score and description, to fill in CVSS vectors and CWEs")
+	fs.StringVar(&f.msrc, "msrc", def(enrich.MSRCURL), "MSRC Security Updates Guide `URL` for resolving KB findings to their CVEs and severities")
+	fs.StringVar(&f.cpe, "cpe_match", "", "NVD CVE API `URL` for discovering CVEs of host packages the scanner did not report, by CPE (e.g. "+enrich.NVDURL+")")
+	fs.StringVar(&f.regions, "regions", "", "attribute findings to regions with the organization/account mapping in `file` (JSON)")
+	fs.StringVar(&f.classifications, "classifications", "", "set the data classification of findings from the asset mapping in `file` (JSON)")
+	fs.Func("egress", "fetch enrichment sources and APIs through the proxy, CA bundles and per-endpoint TLS settings in `file` (JSON or YAML)", func(path string) error {
+		cfg, err := egress.Load(path)
+		if err != nil {
+			return err
+		}
+		f.client, err = cfg.Client()
+		return err
+	})
+	return f
+}
+
+func (f *enrichFlags) enrichers() ([]enrich.Enricher, error) {
+	var es []enrich.Enricher
+	if f.regions != "" {
+		r, err := enrich.LoadRegions(f.regions)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, r)
+	}
+	if f.classifications != "" {
+		c, err := enrich.LoadClassifications(f.classifications)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, c)
+	}
+	// KB findings are resolved first, so EPSS and KEV see their CVEs.
+	if f.msrc != "" {
+		es = append(es, &enrich.MSRC{BaseURL: f.msrc, Interval: enrich.MSRCInterval, Client: f.client})
+	}
+	if f.epss != "" {
+		es = append(es, &enrich.EPSS{Source: f.epss, Client: f.client})
+	}
+	if f.kev != "" {
+		es = append(es, &enrich.KEV{Source: f.kev, Client: f.client})
+	}
+	if f.exploitDB != "" {
+		es = append(es, &enrich.ExploitDB{Source: f.exploitDB, Client: f.client})
+	}
+	if f.metasploit != "" {
+		es = append(es, &enrich.Metasploit{Source: f.metasploit, Client: f.client})
+	}
+	if f.nvd != "" {
+		es = append(es, &enrich.NVD{
+			BaseURL: f.nvd, Client: f.client, APIKey: f.nvdKey, Interval: f.nvdInterval(),
+			CacheDir: f.nvdCache, Complete: f.nvdComplete,
+		})
+	}
+	return es, nil
+}
+
+// nvdInterval is the delay between NVD requests the rate limit allows,
+// with or without an API key.
+func (f *enrichFlags) nvdInterval() time.Duration {
+	if f.nvdKey != "" {
+		return enrich.NVDKeyInterval
+	}
+	return enrich.NVDInterval
+}
+
+// discover adds to vs the findings CPE matching discovers, when
+// -cpe_match is set, and returns how many it added.
+func (f *enrichFlags) discover(ctx context.Context, vs []vuln.Vulnerability, logger *slog.Logger) ([]vuln.Vulnerability, int, error) {
+	if f.cpe == "" {
+		return vs, 0, nil
+	}
+	found, err := (&enrich.CPEMatch{BaseURL: f.cpe, Client: f.client, APIKey: f.nvdKey, Interval: f.nvdInterval()}).Discover(ctx, vs)
+	if err != nil {
+		return vs, 0, fmt.Errorf("cpe_match: %w", err)
+	}
+	logger.Info("discovered findings by CPE", "findings", len(found))
+	return append(vs, found...), len(found), nil
+}
+
+// runEnrichment applies es to vs, logging how many findings each source
+// changed.
+func runEnrichment(ctx context.Context, vs []vuln.Vulnerability, es []enrich.Enricher, logger *slog.Logger) error {
+	stats, err := enrich.Run(ctx, vs, es)
+	for _, s := range stats {
+		logger.Info("enriched", "source", s.Name, "findings", s.Enriched)
+	}
+	return err
+}
+
+// runEnrich enriches an existing result file and writes the augmented
+// results, so enrichment can run on its own schedule.
+func runEnrich(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer enrich", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "result `file` to enrich (.json, or CSV; required)")
+	out := fs.String("output", "", "write the enriched results to `file` (.json, or CSV; default overwrite -input)")
+	sources := addEnrichFlags(fs, true)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return errors.New("enrich: -input is required")
+	}
+	if *out == "" {
+		*out = *input
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+
+	es, err := sources.enrichers()
+	if err != nil {
+		return err
+	}
+	vs, err := readResults(*input, logger)
+	if err != nil {
+		return err
+	}
+	if vs, _, err = sources.discover(context.Background(), vs, logger); err != nil {
+		return err
+	}
+	if err := runEnrichment(context.Background(), vs, es, logger); err != nil {
+		return err
+	}
+	if err := writeFile(*out, defaultFiles, vs, writerFor(*out)); err != nil {
+		return err
+	}
+	logger.Info("wrote enriched results", "path", *out, "findings", len(vs))
+	return nil
+}
+
+// readResults reads a result file written by -output_json, -output_jsonl or
+// -output_csv.
+func readResults(path string, logger *slog.Logger) ([]vuln.Vulnerability, error) {
+	if isJSONL(path) {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return output.ReadJSONL(f)
+	}
+	if !isJSON(path) {
+		res, err := load(path, logger)
+		if err != nil {
+			return nil, err
+		}
+		return res.Vulns, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return output.ReadJSON(f)
+}
+
+// writerFor picks the output format from path's extension.
+func writerFor(path string) func(io.Writer, []vuln.Vulnerability) error {
+	switch {
+	case isJSON(path):
+		return output.WriteJSON
+	case isJSONL(path):
+		return output.WriteJSONL
+	}
+	return output.WriteCSV
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// isJSONL reports whether path names a JSON Lines file, .jsonl or .ndjson.
+func isJSONL(path string) bool {
+	ext := filepath.Ext(path)
+	return strings.EqualFold(ext, ".jsonl") || strings.EqualFold(ext, ".ndjson")
+}
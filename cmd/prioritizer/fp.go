@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/suppress"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// fingerprintPattern matches a vuln.Vulnerability fingerprint.
+var fingerprintPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// fpActions maps the fp subcommand's actions to their entry points.
+var fpActions = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"list":   runFPList,
+	"mark":   runFPMark,
+	"rate":   runFPRate,
+	"unmark": runFPUnmark,
+}
+
+// runFP manages the false-positive store: marking and unmarking findings,
+// listing the entries, and reporting false-positive rates by source.
+func runFP(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || fpActions[args[0]] == nil {
+		names := make([]string, 0, len(fpActions))
+		for name := range fpActions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("fp: want an action: %s", strings.Join(names, ", "))
+	}
+	return fpActions[args[0]](args[1:], stdout, stderr)
+}
+
+func fpFlags(action string, stderr io.Writer) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("prioritizer fp "+action, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs, fs.String("store", "", "false-positive store `file` (JSON, required)")
+}
+
+// runFPMark marks findings, given by unique ID or fingerprint, as false
+// positives.
+func runFPMark(args []string, stdout, stderr io.Writer) error {
+	fs, store := fpFlags("mark", stderr)
+	input := fs.String("input", "", "export or result `file` to look findings up in (required for unique IDs)")
+	reason := fs.String("reason", "", "why the findings are false positives (required)")
+	by := fs.String("by", os.Getenv("USER"), "`name` of who marked them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" || *reason == "" || fs.NArg() == 0 {
+		return errors.New("fp mark: -store, -reason and at least one unique ID or fingerprint are required")
+	}
+	if err := checkWritable("fp mark"); err != nil {
+		return err
+	}
+	s, err := suppress.Load(*store)
+	if err != nil {
+		return err
+	}
+	var vs []vuln.Vulnerability
+	if *input != "" {
+		if vs, err = readResults(*input, slog.New(slog.NewTextHandler(stderr, nil))); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	for _, id := range fs.Args() {
+		// Duplicate rows share a fingerprint; mark each fingerprint once.
+		marked := make(map[string]bool)
+		for i := range vs {
+			fp := vs[i].Fingerprint()
+			if (vs[i].UniqueID == id || fp == id) && !marked[fp] {
+				e := s.Mark(&vs[i], *reason, *by, now)
+				fmt.Fprintf(stdout, "marked %s %s %s\n", e.Fingerprint, e.Identifier, e.PackageName)
+				marked[fp] = true
+			}
+		}
+		if len(marked) > 0 {
+			continue
+		}
+		if *input != "" || !fingerprintPattern.MatchString(id) {
+			return fmt.Errorf("fp mark: no finding %q", id)
+		}
+		s.MarkFingerprint(id, *reason, *by, now)
+		fmt.Fprintf(stdout, "marked %s\n", id)
+	}
+	return s.Save(*store)
+}
+
+// runFPUnmark removes findings, by fingerprint, from the store.
+func runFPUnmark(args []string, stdout, stderr io.Writer) error {
+	fs, store := fpFlags("unmark", stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" || fs.NArg() == 0 {
+		return errors.New("fp unmark: -store and at least one fingerprint are required")
+	}
+	if err := checkWritable("fp unmark"); err != nil {
+		return err
+	}
+	s, err := suppress.Load(*store)
+	if err != nil {
+		return err
+	}
+	for _, fp := range fs.Args() {
+		if !s.Unmark(fp) {
+			return fmt.Errorf("fp unmark: %s is not marked", fp)
+		}
+		fmt.Fprintf(stdout, "unmarked %s\n", fp)
+	}
+	return s.Save(*store)
+}
+
+// runFPList prints the store's entries, most recent first.
+func runFPList(args []string, stdout, stderr io.Writer) error {
+	fs, store := fpFlags("list", stderr)
+	asJSON := fs.Bool("json", false, "print the entries as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" {
+		return errors.New("fp list: -store is required")
+	}
+	s, err := suppress.Load(*store)
+	if err != nil {
+		return err
+	}
+	entries := s.List()
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MARKED\tBY\tIDENTIFIER\tPACKAGE\tSOURCE\tFINGERPRINT\tREASON")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.12s\t%s\n", e.MarkedAt.Format(vuln.DateLayout), e.MarkedBy, e.Identifier, e.PackageName, e.Source, e.Fingerprint, e.Reason)
+	}
+	return tw.Flush()
+}
+
+// runFPRate prints the false-positive rate of each source among an
+// input's findings, and the reliability -source_reliability scores it by.
+func runFPRate(args []string, stdout, stderr io.Writer) error {
+	fs, store := fpFlags("rate", stderr)
+	input := fs.String("input", "", "export or result `file` (required)")
+	asJSON := fs.Bool("json", false, "print the rates as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" || *input == "" {
+		return errors.New("fp rate: -store and -input are required")
+	}
+	s, err := suppress.Load(*store)
+	if err != nil {
+		return err
+	}
+	vs, err := readResults(*input, slog.New(slog.NewTextHandler(stderr, nil)))
+	if err != nil {
+		return err
+	}
+	rates := s.Rates(vs)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rates)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tFINDINGS\tFALSE POSITIVES\tFP RATE\tRELIABILITY")
+	for _, r := range rates {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f%%\t%.4g\n", r.Source, r.Findings, r.FalsePositives, r.Rate*100, r.Reliability)
+	}
+	return tw.Flush()
+}
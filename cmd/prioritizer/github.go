@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/githubalerts"
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+)
+
+// githubInput is the alerts -github_repos pulls from GitHub.
+type githubInput struct {
+	name    string // github: and the -github_repos
+	targets []githubalerts.Target
+	kinds   []githubalerts.Kind
+	client  githubalerts.Client
+}
+
+// merge pulls the alerts, logging every skipped one, and merges them with
+// res, the findings of the -input named input, when there is one. It
+// returns the merged findings with the pull's manifest record.
+func (in *githubInput) merge(ctx context.Context, res *ingest.Result, input string, logger *slog.Logger) (*ingest.Result, manifest.File, error) {
+	got, err := in.client.Pull(ctx, in.targets, in.kinds)
+	if err != nil {
+		return nil, manifest.File{}, fmt.Errorf("-github_repos: %w", err)
+	}
+	var names []string
+	var parts []*ingest.Result
+	if res != nil {
+		names, parts = append(names, input), append(parts, res)
+	}
+	for _, p := range got.Parts {
+		for _, skipped := range p.Skipped {
+			logger.Warn("skipped row", "input", p.Name(), "line", skipped.Line, "error", skipped.Err)
+		}
+		logger.Info("pulled input", "input", p.Name(), "pages", p.Pages, "findings", len(p.Vulns), "skipped", len(p.Skipped))
+		names, parts = append(names, p.Name()), append(parts, p.Result)
+	}
+	merged, err := ingest.MergeMembers(names, parts)
+	if err != nil {
+		return nil, manifest.File{}, fmt.Errorf("-github_repos: %w", err)
+	}
+	return merged, manifest.File{Path: in.name, SHA256: got.SHA256, Bytes: got.Bytes}, nil
+}
+
+// joinInputs returns the history record of a run of several inputs: their
+// names and the checksum of their checksums, so the run is processed
+// again when any of them changes. Inputs without a checksum are left out.
+func joinInputs(files ...manifest.File) manifest.File {
+	var hashed []manifest.File
+	for _, f := range files {
+		if f.SHA256 != "" {
+			hashed = append(hashed, f)
+		}
+	}
+	if len(hashed) == 1 {
+		return hashed[0]
+	}
+	var out manifest.File
+	var paths []string
+	h := sha256.New()
+	for _, f := range hashed {
+		paths = append(paths, f.Path)
+		h.Write([]byte(f.SHA256))
+		out.Bytes += f.Bytes
+	}
+	out.Path, out.SHA256 = strings.Join(paths, ","), hex.EncodeToString(h.Sum(nil))
+	return out
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/VioletX-Dev/devsecops-test/report"
+	"github.com/VioletX-Dev/devsecops-test/theme"
+)
+
+// runHeatmap scores an input and writes a matrix of assets by their most
+// affected packages, as HTML or CSV.
+func runHeatmap(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer heatmap", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "vulnerability export `file` (CSV or .xlsx, required)")
+	packages := fs.Int("packages", 15, "show the top `n` packages by finding count (0 for all)")
+	format := fs.String("format", "html", "output `format`: html or csv")
+	outPath := fs.String("output", "", "write the heatmap to `file` (default standard output)")
+	configPath := fs.String("config", "", "scoring configuration `file` (JSON or YAML; default built-in weights)")
+	today := fs.String("today", "", "reference `date` (YYYY-MM-DD) (default current date)")
+	summary := fs.Bool("summary", false, "head the HTML page with an executive summary of each asset")
+	themePath := fs.String("theme", "", "brand the HTML page with the theme `file` (JSON or YAML)")
+	localeName := fs.String("locale", "", "write the scores of the HTML page as `locale` does, as in de-DE, in place of the theme's")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return errors.New("heatmap: -input is required")
+	}
+	var th *theme.Theme
+	if *themePath != "" {
+		var err error
+		if th, err = theme.Load(*themePath); err != nil {
+			return fmt.Errorf("heatmap: -theme: %w", err)
+		}
+	}
+	th, err := withLocale(th, *localeName)
+	if err != nil {
+		return fmt.Errorf("heatmap: -locale: %w", err)
+	}
+	var write func(*report.Heatmap, io.Writer) error
+	switch *format {
+	case "html":
+		write = func(h *report.Heatmap, w io.Writer) error { return h.WriteHTML(w, th) }
+	case "csv":
+		write = (*report.Heatmap).WriteCSV
+	default:
+		return fmt.Errorf("heatmap: unknown -format %q", *format)
+	}
+	vs, err := loadScored(*input, *configPath, *today, stderr)
+	if err != nil {
+		return err
+	}
+	h := report.NewHeatmap(vs, *packages)
+	if *summary {
+		now, _ := parseToday(*today) // loadScored has parsed it
+		h.Summaries = report.Summaries(vs, report.ByAsset, topRisks, now)
+	}
+	if *outPath == "" {
+		return write(h, stdout)
+	}
+	f, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	if err := write(h, f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", *outPath, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+)
+
+// stdio is the -input value that reads the export from standard input,
+// and the output file name that writes to standard output.
+const stdio = "-"
+
+// inputFlag is the -input flag. Each value adds a file, a directory or a
+// glob to the inputs of the run.
+type inputFlag struct {
+	values *[]string
+}
+
+func (f inputFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return strings.Join(*f.values, ",")
+}
+
+func (f inputFlag) Set(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return errors.New("empty value")
+	}
+	*f.values = append(*f.values, s)
+	return nil
+}
+
+// expandInputs returns the files the -input values name, in order and
+// each once: the files of a directory, but not of those under it, and the
+// files a glob matches, by name. Hidden files are left out of directories,
+// as they are of bundles. A value naming nothing is returned as it is, for
+// reading it to fail as a missing file.
+func expandInputs(values []string) ([]string, error) {
+	var files []string
+	add := func(path string) {
+		if !slices.Contains(files, path) {
+			files = append(files, path)
+		}
+	}
+	for _, v := range values {
+		info, err := os.Stat(v)
+		switch {
+		case v == stdio:
+			add(v)
+		case err == nil && info.IsDir():
+			entries, err := os.ReadDir(v)
+			if err != nil {
+				return nil, err
+			}
+			n := 0
+			for _, e := range entries {
+				if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+					add(filepath.Join(v, e.Name()))
+					n++
+				}
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("directory %s holds no files", v)
+			}
+		case err != nil && strings.ContainsAny(v, "*?["):
+			matches, err := filepath.Glob(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", v, err)
+			}
+			n := 0
+			for _, m := range matches {
+				if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() {
+					add(m)
+					n++
+				}
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("%s matches no files", v)
+			}
+		default:
+			add(v)
+		}
+	}
+	return files, nil
+}
+
+// readStdin reads standard input whole when files has it, returning its
+// content and checksum, or nil. It can be read only once, and is read
+// before anything else so that the history can check it.
+func readStdin(files []string) ([]byte, *manifest.File, error) {
+	if !slices.Contains(files, stdio) {
+		return nil, nil, nil
+	}
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading standard input: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return b, &manifest.File{Path: stdio, SHA256: hex.EncodeToString(sum[:]), Bytes: int64(len(b))}, nil
+}
+
+// loadInputs reads the files of the -input values, and stdin for -input -,
+// each in the format it is detected as unless format is set, and merges
+// them into one result, whose skipped rows name their file.
+func loadInputs(files []string, stdin []byte, format, sheet string, logger *slog.Logger) (*ingest.Result, error) {
+	load := func(path string) (*ingest.Result, error) {
+		if path == stdio {
+			return readLogged(bytes.NewReader(stdin), int64(len(stdin)), stdio, format, sheet, logger)
+		}
+		return loadSheet(path, format, sheet, logger)
+	}
+	if len(files) == 1 {
+		return load(files[0])
+	}
+	parts := make([]*ingest.Result, len(files))
+	for i, path := range files {
+		res, err := load(path)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = res
+	}
+	return ingest.MergeMembers(files, parts)
+}
+
+// hashInputs returns the history record of the files of the -input
+// values, as joinInputs makes it for several, with stdin the record of
+// standard input if they read it.
+func hashInputs(files []string, stdin *manifest.File) (manifest.File, error) {
+	hashed := make([]manifest.File, len(files))
+	for i, path := range files {
+		if path == stdio {
+			hashed[i] = *stdin
+			continue
+		}
+		f, err := manifest.HashFile(path)
+		if err != nil {
+			return manifest.File{}, err
+		}
+		hashed[i] = f
+	}
+	return joinInputs(hashed...), nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+)
+
+// previewWidth is the widest cell shown in the inspect preview.
+const previewWidth = 24
+
+// runInspect previews a new export with guessed column types, date formats
+// and header mappings, to help onboard a vendor format.
+func runInspect(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer inspect", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "CSV `file` to inspect (required)")
+	rows := fs.Int("rows", 5, "number of rows to preview")
+	sample := fs.Int("sample", 1000, "number of rows to guess column types from")
+	asJSON := fs.Bool("json", false, "print the schema as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return errors.New("inspect: -input is required")
+	}
+
+	f, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	s, err := ingest.Inspect(f, *rows, max(*sample, *rows))
+	if err != nil {
+		return fmt.Errorf("%s: %w", *input, err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	return writeSchema(stdout, s)
+}
+
+func writeSchema(w io.Writer, s *ingest.Schema) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tHEADER\tTYPE\tFORMAT\tEMPTY\tMAPS TO")
+	for _, c := range s.Columns {
+		format := strings.Join(c.DateFormats, " or ")
+		if format != "" && !c.DateSupported {
+			format += " (unsupported)"
+		}
+		mapping := string(c.Field)
+		if mapping == "" {
+			mapping = "-"
+			if len(c.Candidates) > 0 {
+				names := make([]string, len(c.Candidates))
+				for i, f := range c.Candidates {
+					names[i] = string(f)
+				}
+				mapping = "? " + strings.Join(names, ", ")
+			}
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\n", c.Index+1, c.Header, c.Type, format, c.Empty, mapping)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nSampled %d rows (%d malformed).", s.Sampled, s.Malformed)
+	if len(s.Missing) > 0 {
+		names := make([]string, len(s.Missing))
+		for i, f := range s.Missing {
+			names[i] = string(f)
+		}
+		fmt.Fprintf(w, " No column maps to required field(s): %s.", strings.Join(names, ", "))
+	}
+	fmt.Fprint(w, "\n\n")
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, c := range s.Columns {
+		if i > 0 {
+			fmt.Fprint(tw, "\t")
+		}
+		fmt.Fprint(tw, previewCell(c.Header))
+	}
+	fmt.Fprintln(tw)
+	for _, row := range s.Preview {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, previewCell(cell))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// previewCell flattens a cell onto one line and truncates it to
+// previewWidth runes.
+func previewCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > previewWidth {
+		return string(r[:previewWidth-1]) + "…"
+	}
+	return s
+}
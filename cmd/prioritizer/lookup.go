@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// runLookup prints every finding of the given identifiers in a result
+// file: the affected assets, their scores and how to remediate.
+func runLookup(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer lookup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	results := fs.String("results", "", "result `file` written by -output_csv or -output_json (default the first output of -manifest)")
+	manifestPath := fs.String("manifest", manifest.FileName, "find the latest results in the run manifest `file`")
+	asJSON := fs.Bool("json", false, "print the matching findings as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("lookup: no identifiers given")
+	}
+	path := *results
+	if path == "" {
+		var err error
+		if path, err = latestResults(*manifestPath); err != nil {
+			return fmt.Errorf("lookup: %w", err)
+		}
+	}
+	vs, err := readResults(path, slog.New(slog.NewTextHandler(stderr, nil)))
+	if err != nil {
+		return err
+	}
+
+	want := make(map[string]bool, fs.NArg())
+	for _, id := range fs.Args() {
+		want[strings.ToUpper(strings.TrimSpace(id))] = true
+	}
+	found := make(map[string]bool, len(want))
+	var matches []vuln.Vulnerability
+	for _, v := range vs {
+		if id := strings.ToUpper(strings.TrimSpace(v.Identifier)); want[id] {
+			matches = append(matches, v)
+			found[id] = true
+		}
+	}
+
+	if *asJSON {
+		if err := output.WriteJSON(stdout, matches); err != nil {
+			return err
+		}
+	} else if len(matches) > 0 {
+		writeLookup(stdout, matches)
+	}
+	var missing []string
+	for _, id := range fs.Args() {
+		if !found[strings.ToUpper(strings.TrimSpace(id))] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("lookup: no findings for %s in %s", strings.Join(missing, ", "), path)
+	}
+	return nil
+}
+
+// latestResults returns the results file of the run recorded in the
+// manifest at path: its first output, which is -output_csv or, without
+// it, -output_json.
+func latestResults(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("no -results given and %w", err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+	if len(m.Outputs) == 0 {
+		return "", fmt.Errorf("%s records no outputs", path)
+	}
+	return m.Outputs[0].Path, nil
+}
+
+// writeLookup prints vs as an aligned table, one row per affected asset
+// and package, in the results' priority order.
+func writeLookup(w io.Writer, vs []vuln.Vulnerability) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "IDENTIFIER\tSCORE\tTIMEFRAME\tSEVERITY\tASSET\tPACKAGE\tINSTALLED\tFIXED\tDUE\tREMEDIATION")
+	for i := range vs {
+		v := &vs[i]
+		asset := v.AssetName
+		if asset == "" {
+			asset = v.AssetID
+		}
+		remediation := v.Remediation
+		switch {
+		case remediation != "":
+		case v.HasFix():
+			remediation = "Update to " + v.FixedVersion
+		default:
+			remediation = "No fix available"
+		}
+		fmt.Fprintf(tw, "%s\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			v.Identifier, v.PriorityScore, v.ActionTimeframe, v.Severity, asset,
+			v.PackageName, v.InstalledVersion, v.FixedVersion, v.DueDate, remediation)
+	}
+	tw.Flush()
+}
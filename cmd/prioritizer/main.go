@@ -0,0 +1,1958 @@
+// Command prioritizer reads a vulnerability export, scores every finding,
+// and writes the findings in priority order with a recommended action
+// timeframe.
+//
+// Usage:
+//
+//	prioritizer -input export.csv|export.xlsx|results.sarif|trivy.json|grype.json|inspector.json|securityhub.json|bundle.zip|bundle.tar.gz|dir|"glob"|-|aws:inspector2|aws:securityhub [-input ...] [-aws_profile profile] [-aws_region region] [-aws_endpoint url] [-github_repos owner/repo,org [-github_alerts dependabot,code_scanning] [-github_api url]] [-format csv|xlsx|sarif|trivy|grype|inspector|securityhub|bundle] [-sheet name] [-output out.json|-] [-output_csv out.csv] [-output_xlsx out.xlsx] [-output_json out.json [-canonical_json [-canonical_timestamps]]] [-output_jsonl out.jsonl] [-output_html report.html [-html_top 20]] [-output_pdf report.pdf] [-theme theme.yaml] [-locale de-DE] [-output_md summary.md] [-output_tickets tickets.csv [-ticket_format jira|servicenow]] [-output_vex vex.json [-vex_format openvex|cyclonedx] [-vex_author name]] [-output_cyclonedx bom.json] [-sort priority|score|effort|cvss|due] [-top n [-page n]] [-columns rank,score,...] [-no_color] [-severity Critical,High] [-source aws] [-asset "prod-*"] [-min_cvss 7.0] [-min_priority 5.0] [-max_per_team 50 [-quota_summary quota.csv]] [-output_mode 0600] [-encrypt aes:key_file] [-validate_output] [-output_sheet id [-sheets_credentials key.json]] [-jira [-jira_group asset|package] [-jira_min_score 7.0]] [-notify_slack url] [-notify_teams url] [-quarantine skipped.csv] [-quiet] [-custom_fields] [-dedup] [-renames renames.yaml] [-group_assets [-asset_pattern regexp]] [-manifest run-manifest.json] [-attestation provenance.json] [-otlp_endpoint url [-otlp_headers key=value,...]] [-epss src] [-kev src] [-exploitdb src] [-metasploit src] [-nvd url [-nvd_api_key key] [-nvd_cache dir] [-nvd_complete]] [-msrc url] [-cpe_match url] [-regions regions.json] [-classifications classes.json] [-egress egress.yaml] [-eol] [-remediation_hints [-remediation_templates templates.yaml]] [-dependency_graphs graphs.json] [-patch_windows windows.json] [-confidence_policy policy.json] [-demote] [-review_queue review.csv] [-rego policy.rego,... [-opa opa]] [-suppressions fp.json [-source_reliability]] [-assets assets.yaml] [-decommissioned retired.json [-stale_assets drop|tag]] [-waivers waivers.yaml [-waiver_mode drop|mark]] [-state state.json [-age_out n]] [-history history.json [-force]] [-db findings.json] [-shard i/N] [-post_cmd command ...] [-post_plugin file.so ...] [-profiles a.json,b.json] [-config scoring.yaml] [-scoring_model weighted|cvss|cvss_epss|ssvc] [-sla Critical=15,High=30] [-score_bands 2,4,6,8] [-fail_on_severity Critical] [-fail_on_score 8.0] [-baseline golden.json [-baseline_mode fail|warn]] [weight flags]
+//	prioritizer as-of date -db findings.json [-state state.json | -audit state-audit.jsonl] [-history history.json] [-format text|csv|json] [-output file]
+//	prioritizer compliance-report -input export.csv -state state.json [-history history.json] [-config config.yaml] [-today date] [-format text|csv|json] [-output file | -customers customers.yaml -output_dir dir]
+//	prioritizer decrypt -key key_file -input out.csv.enc [-output out.csv]
+//	prioritizer daemon -input export.csv [-interval 1h] [-cache cache.json] [-notify channels.json [-notify_state notify-state.json]] [-queue queue.json] [-reload_interval 10s] [prioritization flags]
+//	prioritizer enrich -input results.json [-output enriched.json] [-epss src] [-kev src] [-exploitdb src] [-metasploit src] [-nvd url [-nvd_api_key key] [-nvd_cache dir] [-nvd_complete]] [-msrc url] [-cpe_match url] [-egress egress.yaml]
+//	prioritizer fixtures [-profile vendor|brief|results] [-rows 100] [-broken n] [-seed n] [-evolve] [-output export.csv]
+//	prioritizer fp mark|unmark|list|rate -store fp.json [-input export.csv] [-reason text] [unique ID or fingerprint...]
+//	prioritizer heatmap -input export.csv [-packages n] [-format html|csv] [-output file] [-config config.json] [-summary] [-theme theme.yaml] [-locale de-DE]
+//	prioritizer inspect -input export.csv [-rows 5] [-json]
+//	prioritizer lookup [-results results.csv] [-manifest run-manifest.json] [-json] identifier...
+//	prioritizer merge [-conflict highest|first|last] [-output_csv out.csv] [-output_json out.json [-canonical_json]] [-output_jsonl out.jsonl] [-no_color] part.json...
+//	prioritizer queue list|retry|deliver -store queue.json [-all] [job ID...]
+//	prioritizer report -input export.csv [-by asset|ecosystem|region|team] [-regions regions.json] [-config config.json] [-json] [-summary | -hierarchy org.yaml]
+//	prioritizer sample draw|verify|list -store state.json [-results results.json] [-n 10] [-seed n] [-dry_run] [-verdict confirmed|false_positive [-note text] fingerprint...] [-pending] [-json]
+//	prioritizer selftest [-rows 200] [-broken n] [-seed n] [-v]
+//	prioritizer serve [-results results.json] [-manifest run-manifest.json] [-config config.yaml] [-addr localhost:8080]
+//	prioritizer simulate -input export.csv -proposed proposed.json [-current current.json]
+//	prioritizer state set|list -store state.json [-select query -to state -reason text [-dry_run] [-audit audit.jsonl]]
+//	prioritizer state import -store state.json -tickets jira-export.csv|-jira [-config config.yaml] [-results results.json] [-map Duplicate=resolved,...] [-dry_run] [-audit audit.jsonl]
+//	prioritizer stream -input export.csv|- -output_csv out.csv|- [-buffer_size 1024] [-workers n] [-quarantine skipped.csv] [-custom_fields] [-config scoring.yaml] [-scoring_model model] [-sla Critical=15,High=30] [-profiles a.json,b.json] [-renames renames.yaml] [-assets assets.yaml] [filter flags] [weight flags]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/api"
+	"github.com/VioletX-Dev/devsecops-test/assetgroup"
+	"github.com/VioletX-Dev/devsecops-test/awsfindings"
+	"github.com/VioletX-Dev/devsecops-test/confidence"
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/decommission"
+	"github.com/VioletX-Dev/devsecops-test/dedup"
+	"github.com/VioletX-Dev/devsecops-test/depgraph"
+	"github.com/VioletX-Dev/devsecops-test/effort"
+	"github.com/VioletX-Dev/devsecops-test/enrich"
+	"github.com/VioletX-Dev/devsecops-test/eol"
+	"github.com/VioletX-Dev/devsecops-test/gate"
+	"github.com/VioletX-Dev/devsecops-test/githubalerts"
+	"github.com/VioletX-Dev/devsecops-test/gsheets"
+	"github.com/VioletX-Dev/devsecops-test/history"
+	"github.com/VioletX-Dev/devsecops-test/imageref"
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/inventory"
+	"github.com/VioletX-Dev/devsecops-test/jira"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/notify"
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/patchwindow"
+	"github.com/VioletX-Dev/devsecops-test/platform"
+	"github.com/VioletX-Dev/devsecops-test/postproc"
+	"github.com/VioletX-Dev/devsecops-test/quota"
+	"github.com/VioletX-Dev/devsecops-test/rego"
+	"github.com/VioletX-Dev/devsecops-test/rename"
+	"github.com/VioletX-Dev/devsecops-test/report"
+	"github.com/VioletX-Dev/devsecops-test/scorecache"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/seal"
+	"github.com/VioletX-Dev/devsecops-test/shard"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/suppress"
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/ticket"
+	"github.com/VioletX-Dev/devsecops-test/tracing"
+	"github.com/VioletX-Dev/devsecops-test/tracking"
+	"github.com/VioletX-Dev/devsecops-test/vex"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+	"github.com/VioletX-Dev/devsecops-test/waiver"
+)
+
+// version is the tool version recorded in run manifests. Release builds
+// set it with -ldflags "-X main.version=v1.2.3".
+var version = ""
+
+// builderID identifies the tool as the builder in attestations.
+const builderID = "https://github.com/VioletX-Dev/devsecops-test/cmd/prioritizer"
+
+// toolVersion returns version, falling back to the module version from the
+// build info.
+func toolVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "prioritizer:", err)
+		}
+		var failed *gate.Error
+		if errors.As(err, &failed) {
+			os.Exit(gate.ExitCode)
+		}
+		os.Exit(1)
+	}
+}
+
+// filterFlags are the flags that select the findings written, by the
+// serve query parameter each sets.
+var filterFlags = []struct{ name, param, usage string }{
+	{"severity", "severity", "write only findings of the comma-separated `severities`"},
+	{"source", "source", "write only findings from the comma-separated `sources`"},
+	{"asset", "asset", "write only findings on the comma-separated assets, by name or ID, or glob `patterns` such as prod-*"},
+	{"min_cvss", "min_cvss", "write only findings with a CVSS `score` of at least this"},
+	{"min_priority", "min_score", "write only findings with a priority `score` of at least this"},
+}
+
+// options are the parsed command-line flags.
+type options struct {
+	input      string       // the -input values, joined by commas
+	inputs     []string     // the -input values: files, directories and globs
+	aws        *awsInput    // nil unless -input is aws:service
+	github     *githubInput // nil without -github_repos
+	format     string
+	sheet      string
+	outputCSV  string
+	outputXLSX string
+	outputJSON string
+	jsonl      string // -output_jsonl
+	outputHTML string
+	outputPDF  string
+	outputMD   string
+	tickets    string // -output_tickets
+	ticketFmt  string // ticket.Formats
+	vex        string // -output_vex
+	vexFormat  string // vex.Formats
+	vexAuthor  string
+	cyclonedx  string // -output_cyclonedx
+	htmlTop    int
+	theme      *theme.Theme // nil without -theme
+	sortBy     string
+	terminal   terminalView
+	noColor    bool
+	maxPerTeam int
+	quotaCSV   string
+	canonical  bool // write -output_json and the manifest canonically
+	timestamps bool // keep the manifest's timings when canonical
+	validate   bool // check the findings against output.FindingSchema
+	sheets     sheetsFlags
+	quarantine string
+	quiet      bool // log only errors
+	piped      bool // an output file is -, standard output
+	custom     bool
+	dedup      bool
+	renames    *rename.Map          // nil without -renames
+	groups     *assetgroup.Grouper  // nil without -group_assets or -asset_pattern
+	windows    *patchwindow.Windows // nil without -patch_windows
+	eol        bool
+	hints      platform.Templates // nil without -remediation_hints
+	graphs     string
+	confidence *confidence.Policy // -confidence_policy, or confidence.Default
+	demote     bool
+	review     string
+	fpStore    *suppress.Store      // nil without -suppressions
+	reliable   bool                 // -source_reliability
+	inventory  *inventory.Inventory // nil without -assets
+	retired    *decommission.List
+	staleMode  string
+	waivers    *waiver.File
+	filter     *api.Query // nil without filtering flags
+	waiverMode string
+	stateStore string
+	ageOut     int
+	history    string
+	db         string            // -db tracking store
+	jira       *jira.Config      // nil without -jira
+	rego       *rego.Evaluator   // nil without -rego
+	webhooks   map[string]string // -notify_slack and -notify_teams URLs by notify.ChatServices
+	tracer     *tracing.Tracer   // nil without -otlp_endpoint
+	force      bool              // process an input the history has
+	manifest   string
+	attest     string // -attestation
+	gate       gate.Thresholds
+	baseline   string // -baseline results file
+	baseMode   string // gate.BaselineModes
+	model      string // scoring.Models; "" is weighted
+	today      time.Time
+	fixedToday bool // -today was given
+	weights    scoring.Weights
+	bands      []float64        // -score_bands bounds
+	sla        sla.Policy       // from -config; replaces input due dates
+	profiles   []config.Profile // scored alongside weights
+	enrich     *enrichFlags
+	batch      output.BatchOptions
+	shard      shard.Shard
+	notifier   *notify.Notifier // set in daemon mode
+	daemon     bool             // set in daemon mode
+	readOnly   bool
+	post       []postproc.Step // run over the findings before output
+	postTime   time.Duration   // limit on all post-processors together
+	files      fileOptions     // how output files are created
+}
+
+func parseFlags(args []string, stderr io.Writer) (*options, error) {
+	return parseFlagsWith("prioritizer", args, stderr, nil)
+}
+
+// parseFlagsWith parses the prioritization flags for the named command,
+// after extra, when set, registers the command's own flags.
+func parseFlagsWith(name string, args []string, stderr io.Writer, extra func(*flag.FlagSet)) (*options, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	opts := &options{weights: scoring.DefaultWeights()}
+	w := &opts.weights
+	fs.Var(inputFlag{&opts.inputs}, "input", "vulnerability export `file`: CSV, .xlsx, SARIF, Trivy, Grype, Inspector or Security Hub JSON, or a .zip or .tar.gz bundle of them; a directory or glob of such files; or "+strings.Join(awsfindings.Inputs, " or ")+" to pull the findings from AWS (required without -github_repos); repeat to prioritize several together")
+	awsProfile := fs.String("aws_profile", "", "sign -input aws:... requests with the credentials of the AWS CLI `profile` (default $AWS_PROFILE, else $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY, else default)")
+	awsRegion := fs.String("aws_region", "", "pull -input aws:... findings from the AWS `region` (default $AWS_REGION, else $AWS_DEFAULT_REGION, else the profile's)")
+	awsEndpoint := fs.String("aws_endpoint", "", "send -input aws:... requests to the `URL`, such as a VPC or FIPS endpoint (default the service's regional endpoint)")
+	githubRepos := fs.String("github_repos", "", "also pull the open alerts of the comma-separated GitHub `repositories`, as owner/repo, and organizations, with the token in $GITHUB_TOKEN or $GH_TOKEN")
+	githubAlerts := fs.String("github_alerts", "", "pull the comma-separated `kinds` of -github_repos alert: dependabot, code_scanning (default both)")
+	githubAPI := fs.String("github_api", "", "pull -github_repos alerts from the GitHub REST API at `URL`, as https://HOST/api/v3 for GitHub Enterprise Server (default "+githubalerts.DefaultBaseURL+")")
+	fs.StringVar(&opts.format, "format", "", "input `format`: "+strings.Join(ingest.Formats, ", ")+" (default detected)")
+	fs.StringVar(&opts.sheet, "sheet", "", "read the worksheet `name` of an .xlsx input (default the first sheet)")
+	outPath := fs.String("output", "", "write prioritized findings to `file` as JSON, as -output_json does; - writes them to standard output, for piping to jq")
+	fs.StringVar(&opts.outputCSV, "output_csv", "", "write prioritized findings to `file` as CSV")
+	fs.StringVar(&opts.outputXLSX, "output_xlsx", "", "write prioritized findings to `file` as an XLSX workbook with a Findings sheet coloured by severity and a Summary sheet of counts")
+	fs.StringVar(&opts.outputJSON, "output_json", "", "write prioritized findings to `file` as JSON")
+	fs.StringVar(&opts.jsonl, "output_jsonl", "", "write prioritized findings to `file` as JSON Lines, one object per finding and line, for log pipelines and bulk loaders")
+	fs.StringVar(&opts.outputHTML, "output_html", "", "write a self-contained HTML report with charts to `file`")
+	fs.IntVar(&opts.htmlTop, "html_top", 20, "list the top `n` priority findings in the -output_html and -output_pdf reports")
+	fs.StringVar(&opts.outputPDF, "output_pdf", "", "write a paged PDF report, with the sections of the HTML report, to `file`")
+	themePath := fs.String("theme", "", "brand the -output_html, -output_xlsx and -output_pdf reports with the title, footer, font, colours and logo of the theme `file` (JSON or YAML)")
+	localeName := fs.String("locale", "", "write the dates and decimal numbers of the -output_html, -output_xlsx and -output_pdf reports as `locale` does, as in de-DE, in place of the theme's (default ISO 8601 dates and decimal points)")
+	fs.StringVar(&opts.outputMD, "output_md", "", "write a short Markdown summary, as for a pull request comment, to `file`")
+	fs.StringVar(&opts.tickets, "output_tickets", "", "write a ticket for each open finding to `file` as CSV, for bulk import into an issue tracker")
+	fs.StringVar(&opts.ticketFmt, "ticket_format", ticket.Jira, "write -output_tickets in the import `format` "+strings.Join(ticket.Formats, " or "))
+	fs.StringVar(&opts.vex, "output_vex", "", "write the triage of each finding to `file` as a VEX document, for SBOM tooling: fixed, affected, not affected (the -suppressions false positives) or under investigation")
+	fs.StringVar(&opts.vexFormat, "vex_format", vex.OpenVEX, "write -output_vex in the `format` "+strings.Join(vex.Formats, " or "))
+	fs.StringVar(&opts.vexAuthor, "vex_author", "", "the `name` of who made the triage decisions in -output_vex and -output_cyclonedx")
+	fs.StringVar(&opts.cyclonedx, "output_cyclonedx", "", "write the findings to `file` as a CycloneDX SBOM, the packages of each asset as components and the findings as vulnerabilities with their ratings, recommendations and triage, for Dependency-Track")
+	fs.StringVar(&opts.sortBy, "sort", "priority", "order the findings by `order`: priority (or score), effort to list the cheapest fixes of each action timeframe first, cvss for the highest CVSS scores first, or due for the earliest due dates first")
+	fs.IntVar(&opts.terminal.top, "top", 0, "print `n` findings per page in the terminal table (0 for all)")
+	fs.IntVar(&opts.terminal.page, "page", 1, "print page `n` of -top findings in the terminal table")
+	fs.BoolVar(&opts.noColor, "no_color", noColorEnv(), "print the terminal table without ANSI colors, which it has only on a terminal (default $NO_COLOR set)")
+	columns := fs.String("columns", strings.Join(columnNames(), ","), "print the comma-separated `columns` in the terminal table, in that order: "+strings.Join(columnNames(), ", "))
+	fs.IntVar(&opts.maxPerTeam, "max_per_team", 0, "write at most `n` findings of each team and severity, the highest scores first, to -output_csv, -output_xlsx, -output_tickets and -output_sheet, summarizing the rest (0 for no cap)")
+	fs.StringVar(&opts.quotaCSV, "quota_summary", "", "write the findings -max_per_team withholds, counted by team and severity, to `file` as CSV")
+	fs.BoolVar(&opts.canonical, "canonical_json", false, "write -output_json and the manifest as canonical JSON, for diffing: sorted keys, findings in fingerprint order, fixed number formatting and no run timestamps")
+	fs.BoolVar(&opts.timestamps, "canonical_timestamps", false, "keep the manifest's start time and timings with -canonical_json")
+	fs.BoolVar(&opts.validate, "validate_output", false, "check every finding against the published finding JSON Schema before writing, failing the run on a mismatch")
+	fs.StringVar(&opts.sheets.id, "output_sheet", "", "write prioritized findings to the Google Sheet `id`, one worksheet per severity")
+	fs.StringVar(&opts.sheets.credentials, "sheets_credentials", os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"), "service account key `file` for -output_sheet")
+	fs.StringVar(&opts.sheets.api, "sheets_api", gsheets.BaseURL, "Google Sheets API `URL`")
+	jiraMode := fs.Bool("jira", false, "create or update a Jira issue for each asset or package with findings at or above -jira_min_score, configured by JIRA_URL, JIRA_USER, JIRA_API_TOKEN and JIRA_PROJECT or the jira section of -config")
+	jiraGroup := fs.String("jira_group", "", "file one -jira issue per `grouping`: "+strings.Join(jira.Groupings, " or ")+" (default the config's group_by, else asset)")
+	jiraScore := fs.Float64("jira_min_score", 0, "file -jira issues for findings with a priority `score` of at least this (default the config's min_score, else 7)")
+	slackHook := fs.String("notify_slack", "", "post a summary of new Critical findings, SLA breaches and the top priorities to the Slack incoming webhook `URL`")
+	teamsHook := fs.String("notify_teams", "", "post a summary of new Critical findings, SLA breaches and the top priorities to the Microsoft Teams incoming webhook `URL`")
+	otlpEndpoint := fs.String("otlp_endpoint", "", "export each run as a trace, with a span for each stage, to the OTLP/HTTP collector at `URL` (default $OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, else $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	otlpHeaders := fs.String("otlp_headers", "", "send the `key=value,...` headers, such as an API key, with -otlp_endpoint exports (default $OTEL_EXPORTER_OTLP_HEADERS)")
+	fs.BoolVar(&opts.quiet, "quiet", false, "log only errors to standard error")
+	fs.StringVar(&opts.quarantine, "quarantine", "", "write skipped input rows, with the reason, to `file` as CSV")
+	fs.BoolVar(&opts.custom, "custom_fields", false, "carry unrecognised input columns through to the outputs as custom_fields")
+	fs.BoolVar(&opts.dedup, "dedup", false, "merge repeated reports of a finding (same identifier, asset, package and installed version) into one, counting them in occurrences")
+	renames := fs.String("renames", "", "report renamed and relocated packages under their current names, from the renames `file` (YAML or JSON), so -dedup merges their reports")
+	groupAssets := fs.Bool("group_assets", false, "group the instances of a logical asset, assets that share a name but not an asset ID, reporting each finding once with the instances' IDs")
+	assetPattern := fs.String("asset_pattern", "", "name logical assets by the first subexpression, or the one named asset, of the `regexp` instance names match, as in ^(.+)-[0-9]+$; implies -group_assets")
+	fs.StringVar(&opts.graphs, "dependency_graphs", "", "classify findings as direct or transitive dependencies with the asset dependency graphs mapped in `file` (JSON)")
+	fs.BoolVar(&opts.eol, "eol", false, "flag assets whose operating system is past the end of security support, adding a finding for each")
+	hints := fs.Bool("remediation_hints", false, "fill in blank remediations with the update command of the asset's package manager (apt, yum, dnf, zypper, apk, choco)")
+	hintTemplates := fs.String("remediation_templates", "", "remediation templates by package manager in `file` (YAML or JSON), over the built-in ones; implies -remediation_hints")
+	windows := fs.String("patch_windows", "", "schedule findings by the asset patch windows in `file` (JSON)")
+	confidencePath := fs.String("confidence_policy", "", "rate finding confidence with the policy in `file` (JSON; default built-in heuristics)")
+	fs.BoolVar(&opts.demote, "demote", false, "scale the score of findings below the confidence threshold by their confidence")
+	regoFiles := fs.String("rego", "", "suppress, escalate and gate findings by the OPA Rego policies in `files` (comma-separated), evaluated with -opa")
+	opaBinary := fs.String("opa", rego.DefaultBinary, "the opa `command` that evaluates -rego policies")
+	fs.StringVar(&opts.review, "review_queue", "", "write low-confidence findings that score high to `file` as CSV, for review")
+	fs.StringVar(&opts.stateStore, "state", "", "set each finding's workflow state from the store `file` (see prioritizer state)")
+	fs.IntVar(&opts.ageOut, "age_out", 0, "track when each finding was last seen in the -state store, and mark findings `n` consecutive runs miss stale (0 for off)")
+	fpStore := fs.String("suppressions", "", "drop findings marked as false positives in the store `file` (see prioritizer fp)")
+	fs.BoolVar(&opts.reliable, "source_reliability", false, "scale scores by each source's reliability, learned from its false-positive rate in the -suppressions store")
+	assets := fs.String("assets", "", "set the criticality, environment and owner of assets, weighting their findings' scores, from the asset inventory `file` (YAML or JSON)")
+	retired := fs.String("decommissioned", "", "drop or tag findings on the decommissioned assets listed in `file` (JSON)")
+	fs.StringVar(&opts.staleMode, "stale_assets", decommission.Drop, "what to do with findings on decommissioned assets: `mode` "+strings.Join(decommission.Modes, " or "))
+	filters := url.Values{}
+	for _, f := range filterFlags {
+		fs.Func(f.name, f.usage, func(s string) error {
+			filters.Add(f.param, s)
+			return nil
+		})
+	}
+	waivers := fs.String("waivers", "", "drop or mark the findings covered by the unexpired waivers listed in `file` (YAML or JSON)")
+	fs.StringVar(&opts.waiverMode, "waiver_mode", waiver.Drop, "what to do with waived findings: `mode` "+strings.Join(waiver.Modes, " or "))
+	fs.StringVar(&opts.history, "history", "", "skip inputs already processed, by checksum, as recorded in the history `file` (JSON)")
+	fs.BoolVar(&opts.force, "force", false, "process the input even if -history records it")
+	fs.StringVar(&opts.db, "db", "", "track every finding across runs, by unique ID, in the store `file` (JSON): first and last seen, score history and open, resolved and reopened transitions")
+	fs.BoolVar(&opts.readOnly, "read_only", readOnlyEnv(), "change no store and call no integration; only the output files are written (default $"+readOnlyVar+")")
+	fileMode := fs.String("output_mode", "0644", "create the output files with the octal permissions `mode`, whatever the umask")
+	fs.IntVar(&opts.files.retry.retries, "output_retries", defaultRetry.retries, "write an output file again up to `n` times when it fails with a transient error, as network filesystems return")
+	fs.DurationVar(&opts.files.retry.backoff, "output_retry_backoff", defaultRetry.backoff, "wait `duration` before the first -output_retries retry, doubling it for each further one")
+	encrypt := fs.String("encrypt", "", "encrypt the output files with AES-256-GCM under the key in `aes:key_file`; read them with prioritizer decrypt")
+	fs.Var(postFlag{&opts.post, stderr, false}, "post_cmd", "pipe the findings, as JSON, through the shell `command` before writing them; repeat to chain")
+	fs.Var(postFlag{&opts.post, stderr, true}, "post_plugin", "pass the findings through the PostProcess function of the Go plugin `file` before writing them; repeat to chain")
+	fs.DurationVar(&opts.postTime, "post_timeout", 5*time.Minute, "stop the post-processors after `duration`")
+	fs.StringVar(&opts.manifest, "manifest", "", "write the run manifest to `file` (default "+manifest.FileName+" next to the first output)")
+	fs.StringVar(&opts.attest, "attestation", "", "write an in-toto SLSA provenance statement of the run's outputs to `file`")
+	opts.enrich = addEnrichFlags(fs, false)
+	fs.IntVar(&opts.batch.Size, "batch_size", output.DefaultBatchOptions.Size, "write CSV output in batches of `n` findings")
+	fs.DurationVar(&opts.batch.FlushInterval, "flush_interval", output.DefaultBatchOptions.FlushInterval, "flush a partial CSV batch after `duration`")
+	shardFlag := fs.String("shard", "", "process only shard `i/N` of the findings, by fingerprint")
+	profiles := fs.String("profiles", "", "also score every finding against each of the comma-separated weight `profiles`: configuration files, name=file pairs or "+config.DefaultProfile)
+	today := fs.String("today", "", "reference `date` (YYYY-MM-DD) for due-date urgency (default current date)")
+	model := fs.String("scoring_model", "", "score with the `model` "+strings.Join(scoring.Models, ", ")+" (default the -config model, else weighted)")
+	configPath := fs.String("config", "", "scoring configuration `file` (JSON, or YAML if named .yaml or .yml; default built-in weights); weight flags override it")
+	failSeverity := fs.String("fail_on_severity", "", "exit with status 3 if an open finding has this `severity` or higher, as a CI gate")
+	fs.Float64Var(&opts.gate.Score, "fail_on_score", 0, "exit with status 3 if an open finding has a priority `score` of at least this, as a CI gate")
+	fs.StringVar(&opts.baseline, "baseline", "", "gate only findings the approved results in `file` (-output_json or -output_csv) do not have, failing on any without -fail_on_severity or -fail_on_score")
+	fs.StringVar(&opts.baseMode, "baseline_mode", gate.Fail, "what to do with findings not in the -baseline: `mode` "+strings.Join(gate.BaselineModes, " or ")+" (exit with status 3, or only log them)")
+	bands := fs.String("score_bands", "", "count priority scores in the run summary and manifest in bands starting at the comma-separated `scores`, as in 2,4,6,8 (default one point wide)")
+	slaFlag := fs.String("sla", "", "remediation `windows` in days from first detection, as in Critical=15,High=30, replacing due dates; they override the -config sla")
+
+	fs.Float64Var(&w.CVSS, "cvss", w.CVSS, "points per CVSS point")
+	fs.BoolVar(&w.Temporal, "temporal", false, "score by the CVSS temporal score instead of the base score")
+	critical := fs.Float64("critical", w.Severity[vuln.Critical], "points for Critical severity")
+	high := fs.Float64("high", w.Severity[vuln.High], "points for High severity")
+	medium := fs.Float64("medium", w.Severity[vuln.Medium], "points for Medium severity")
+	low := fs.Float64("low", w.Severity[vuln.Low], "points for Low severity")
+	aws := fs.Float64("aws", w.Source["aws"], "points for findings from AWS")
+	github := fs.Float64("github", w.Source["github"], "points for findings from GitHub")
+	fs.Float64Var(&w.Fix, "fix", w.Fix, "points when a fixed version is available")
+	fs.Float64Var(&w.Transitive, "transitive", w.Transitive, "points deducted from findings in transitive dependencies")
+	if extra != nil {
+		extra(fs)
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.inputs) == 0 && *githubRepos == "" {
+		return nil, errors.New("-input or -github_repos is required")
+	}
+	opts.input = strings.Join(opts.inputs, ",")
+	if !slices.Contains(ticket.Formats, opts.ticketFmt) {
+		return nil, fmt.Errorf("-ticket_format %q: want %s", opts.ticketFmt, strings.Join(ticket.Formats, " or "))
+	}
+	if !slices.Contains(vex.Formats, opts.vexFormat) {
+		return nil, fmt.Errorf("-vex_format %q: want %s", opts.vexFormat, strings.Join(vex.Formats, " or "))
+	}
+	if !slices.Contains(sortOrders, opts.sortBy) {
+		return nil, fmt.Errorf("-sort %q: want %s", opts.sortBy, strings.Join(sortOrders, ", "))
+	}
+	switch {
+	case opts.terminal.top < 0:
+		return nil, fmt.Errorf("-top %d: want 0 or more", opts.terminal.top)
+	case opts.terminal.page < 1:
+		return nil, fmt.Errorf("-page %d: want 1 or more", opts.terminal.page)
+	case opts.terminal.page > 1 && opts.terminal.top == 0:
+		return nil, errors.New("-page needs -top")
+	}
+	if opts.terminal.columns, err = parseColumns(*columns); err != nil {
+		return nil, fmt.Errorf("-columns: %w", err)
+	}
+	if opts.maxPerTeam < 0 {
+		return nil, fmt.Errorf("-max_per_team %d: want 0 or more", opts.maxPerTeam)
+	}
+	if opts.quotaCSV != "" && opts.maxPerTeam == 0 {
+		return nil, errors.New("-quota_summary needs -max_per_team")
+	}
+	if !slices.Contains(decommission.Modes, opts.staleMode) {
+		return nil, fmt.Errorf("-stale_assets %q: want %s", opts.staleMode, strings.Join(decommission.Modes, " or "))
+	}
+	if *groupAssets || *assetPattern != "" {
+		if opts.groups, err = assetgroup.Compile(*assetPattern); err != nil {
+			return nil, fmt.Errorf("-asset_pattern: %w", err)
+		}
+	}
+	if *renames != "" {
+		if opts.renames, err = rename.Load(*renames); err != nil {
+			return nil, err
+		}
+	}
+	if *assets != "" {
+		if opts.inventory, err = inventory.Load(*assets); err != nil {
+			return nil, err
+		}
+	}
+	if *retired != "" {
+		if opts.retired, err = decommission.Load(*retired); err != nil {
+			return nil, err
+		}
+	}
+	if *fpStore != "" {
+		if opts.fpStore, err = suppress.Load(*fpStore); err != nil {
+			return nil, err
+		}
+	}
+	opts.confidence = confidence.Default()
+	if *confidencePath != "" {
+		if opts.confidence, err = confidence.Load(*confidencePath); err != nil {
+			return nil, err
+		}
+	}
+	if *windows != "" {
+		if opts.windows, err = patchwindow.Load(*windows); err != nil {
+			return nil, err
+		}
+	}
+	if len(filters) > 0 {
+		if opts.filter, err = api.ParseQuery(filters); err != nil {
+			return nil, fmt.Errorf("filtering: %w", err)
+		}
+	}
+	if !slices.Contains(waiver.Modes, opts.waiverMode) {
+		return nil, fmt.Errorf("-waiver_mode %q: want %s", opts.waiverMode, strings.Join(waiver.Modes, " or "))
+	}
+	if *waivers != "" {
+		if opts.waivers, err = waiver.Load(*waivers); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case *hintTemplates != "":
+		if opts.hints, err = platform.LoadTemplates(*hintTemplates); err != nil {
+			return nil, err
+		}
+	case *hints:
+		if opts.hints, err = platform.ParseTemplates(nil); err != nil {
+			return nil, err
+		}
+	}
+	if *regoFiles != "" {
+		if opts.rego, err = rego.Load(*opaBinary, strings.Split(*regoFiles, ",")); err != nil {
+			return nil, fmt.Errorf("-rego: %w", err)
+		}
+	}
+	if opts.format != "" && !slices.Contains(ingest.Formats, opts.format) {
+		return nil, fmt.Errorf("-format %q: want one of %s", opts.format, strings.Join(ingest.Formats, ", "))
+	}
+	opts.sheets.client = opts.enrich.client
+	pulled := func(in string) bool { return strings.HasPrefix(in, awsfindings.Prefix) }
+	if len(opts.inputs) > 1 && slices.ContainsFunc(opts.inputs, pulled) {
+		return nil, fmt.Errorf("-input %s: an input pulled from AWS cannot be repeated or read with files; run once for each", opts.input)
+	}
+	if name, ok := strings.CutPrefix(opts.input, awsfindings.Prefix); ok {
+		in := &awsInput{name: opts.input, profile: *awsProfile, region: *awsRegion, endpoint: *awsEndpoint, client: opts.enrich.client}
+		if in.service, err = awsfindings.ParseService(name); err != nil {
+			return nil, fmt.Errorf("-input: %w", err)
+		}
+		if opts.format != "" || opts.sheet != "" {
+			return nil, fmt.Errorf("-input %s: -format and -sheet apply to files", opts.input)
+		}
+		// The credentials are checked now, and loaded again for each pull.
+		if _, err := awsfindings.Load(in.profile, in.region); err != nil {
+			return nil, fmt.Errorf("-input %s: %w", opts.input, err)
+		}
+		opts.aws = in
+	} else if *awsProfile != "" || *awsRegion != "" || *awsEndpoint != "" {
+		return nil, fmt.Errorf("-aws_profile, -aws_region and -aws_endpoint need -input %s", strings.Join(awsfindings.Inputs, " or "))
+	} else if len(opts.inputs) > 0 {
+		files, err := expandInputs(opts.inputs)
+		if err != nil {
+			return nil, fmt.Errorf("-input: %w", err)
+		}
+		// Several exports of one finding, from scanners or accounts that
+		// overlap, are merged unless -dedup=false says otherwise.
+		dedupSet := false
+		fs.Visit(func(f *flag.Flag) { dedupSet = dedupSet || f.Name == "dedup" })
+		if len(files) > 1 && !dedupSet {
+			opts.dedup = true
+		}
+	}
+	if *githubRepos != "" {
+		in := &githubInput{
+			name:   "github:" + *githubRepos,
+			kinds:  githubalerts.Kinds,
+			client: githubalerts.Client{Token: cmp.Or(os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN")), BaseURL: *githubAPI, Client: opts.enrich.client},
+		}
+		if in.targets, err = githubalerts.ParseTargets(*githubRepos); err != nil {
+			return nil, fmt.Errorf("-github_repos: %w", err)
+		}
+		if *githubAlerts != "" {
+			if in.kinds, err = githubalerts.ParseKinds(*githubAlerts); err != nil {
+				return nil, fmt.Errorf("-github_alerts: %w", err)
+			}
+		}
+		if in.client.Token == "" {
+			return nil, errors.New("-github_repos needs a token in GITHUB_TOKEN or GH_TOKEN")
+		}
+		opts.github = in
+	} else if *githubAlerts != "" || *githubAPI != "" {
+		return nil, errors.New("-github_alerts and -github_api need -github_repos")
+	}
+	if opts.sheets.id != "" && opts.sheets.credentials == "" {
+		return nil, errors.New("-output_sheet needs -sheets_credentials or GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if opts.readOnly {
+		switch {
+		case opts.sheets.id != "":
+			return nil, errors.New("-read_only: -output_sheet writes to Google Sheets")
+		case opts.history != "":
+			return nil, errors.New("-read_only: -history records the run")
+		case len(opts.post) > 0:
+			return nil, errors.New("-read_only: -post_cmd and -post_plugin run code outside the tool")
+		case opts.ageOut > 0:
+			return nil, errors.New("-read_only: -age_out updates the state store")
+		case opts.db != "":
+			return nil, errors.New("-read_only: -db updates the tracking store")
+		case *jiraMode:
+			return nil, errors.New("-read_only: -jira writes to Jira")
+		case *slackHook != "" || *teamsHook != "":
+			return nil, errors.New("-read_only: -notify_slack and -notify_teams post to chat")
+		}
+	}
+	if opts.files.mode, err = parseFileMode(*fileMode); err != nil {
+		return nil, fmt.Errorf("-output_mode: %w", err)
+	}
+	if opts.files.retry.retries < 0 || opts.files.retry.backoff < 0 {
+		return nil, errors.New("-output_retries and -output_retry_backoff must not be negative")
+	}
+	if *encrypt != "" {
+		if opts.files.key, err = loadEncryptKey(*encrypt); err != nil {
+			return nil, fmt.Errorf("-encrypt: %w", err)
+		}
+	}
+
+	cfg := config.Default()
+	if *configPath != "" {
+		if cfg, err = config.Load(*configPath); err != nil {
+			return nil, fmt.Errorf("-config: %w", err)
+		}
+	}
+	if *failSeverity != "" {
+		if opts.gate.Severity, err = vuln.ParseSeverity(*failSeverity); err != nil {
+			return nil, fmt.Errorf("-fail_on_severity: %w", err)
+		}
+	}
+	if opts.gate.Score < 0 || opts.gate.Score > 10 {
+		return nil, fmt.Errorf("-fail_on_score %v: want a score from 0 to 10", opts.gate.Score)
+	}
+	if opts.reliable && opts.fpStore == nil {
+		return nil, errors.New("-source_reliability: learns from the -suppressions store, which is not set")
+	}
+	if !slices.Contains(gate.BaselineModes, opts.baseMode) {
+		return nil, fmt.Errorf("-baseline_mode %q: want %s", opts.baseMode, strings.Join(gate.BaselineModes, " or "))
+	}
+	opts.bands = scoring.DefaultBands
+	if *bands != "" {
+		if opts.bands, err = scoring.ParseBands(*bands); err != nil {
+			return nil, fmt.Errorf("-score_bands: %w", err)
+		}
+	}
+	if *slaFlag != "" {
+		windows, err := sla.ParsePolicy(*slaFlag)
+		if err != nil {
+			return nil, fmt.Errorf("-sla: %w", err)
+		}
+		policy := maps.Clone(cfg.SLA)
+		if policy == nil {
+			policy = make(sla.Policy)
+		}
+		maps.Copy(policy, windows)
+		cfg.SLA = policy
+	}
+	if *jiraMode {
+		jc := jira.Config{}
+		if cfg.Jira != nil {
+			jc = *cfg.Jira
+		}
+		jc.Env(os.Getenv)
+		if *jiraGroup != "" {
+			jc.GroupBy = *jiraGroup
+		}
+		if *jiraScore != 0 {
+			jc.MinScore = *jiraScore
+		}
+		if err := jc.Validate(); err != nil {
+			return nil, fmt.Errorf("-jira: %w", err)
+		}
+		opts.jira = &jc
+	} else if *jiraGroup != "" || *jiraScore != 0 {
+		return nil, errors.New("-jira_group and -jira_min_score need -jira")
+	}
+	opts.model = cfg.Model
+	if *model != "" {
+		if !slices.Contains(scoring.Models, *model) {
+			return nil, fmt.Errorf("-scoring_model %q: want %s", *model, strings.Join(scoring.Models, ", "))
+		}
+		opts.model = *model
+	}
+	if opts.model == scoring.ModelWeighted {
+		opts.model = ""
+	}
+	flagged := *w
+	*w, opts.sla = cfg.Weights, cfg.SLA
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "cvss":
+			w.CVSS = flagged.CVSS
+		case "temporal":
+			w.Temporal = flagged.Temporal
+		case "fix":
+			w.Fix = flagged.Fix
+		case "transitive":
+			w.Transitive = flagged.Transitive
+		case "critical":
+			w.Severity[vuln.Critical] = *critical
+		case "high":
+			w.Severity[vuln.High] = *high
+		case "medium":
+			w.Severity[vuln.Medium] = *medium
+		case "low":
+			w.Severity[vuln.Low] = *low
+		case "aws":
+			w.Source["aws"] = *aws
+		case "github":
+			w.Source["github"] = *github
+		}
+	})
+
+	if opts.today, err = parseToday(*today); err != nil {
+		return nil, err
+	}
+	opts.fixedToday = *today != ""
+	if opts.shard, err = shard.Parse(*shardFlag); err != nil {
+		return nil, fmt.Errorf("-shard: %w", err)
+	}
+	switch {
+	case opts.ageOut < 0:
+		return nil, errors.New("-age_out must not be negative")
+	case opts.ageOut > 0 && opts.stateStore == "":
+		return nil, errors.New("-age_out needs -state")
+	case opts.ageOut > 0 && opts.shard.Count > 0:
+		// A shard does not report the findings of the others.
+		return nil, errors.New("-age_out cannot be used with -shard")
+	case opts.db != "" && opts.shard.Count > 0:
+		return nil, errors.New("-db cannot be used with -shard")
+	case *jiraMode && opts.shard.Count > 0:
+		// A package's findings span the shards.
+		return nil, errors.New("-jira cannot be used with -shard")
+	case (*slackHook != "" || *teamsHook != "") && opts.shard.Count > 0:
+		return nil, errors.New("-notify_slack and -notify_teams cannot be used with -shard")
+	}
+	for service, hook := range map[string]string{notify.Slack: *slackHook, notify.Teams: *teamsHook} {
+		if hook == "" {
+			continue
+		}
+		// The URL is the webhook's credential, so errors leave it out.
+		if u, err := url.Parse(hook); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, fmt.Errorf("-notify_%s: want an http(s) webhook URL", service)
+		}
+		if opts.webhooks == nil {
+			opts.webhooks = make(map[string]string)
+		}
+		opts.webhooks[service] = hook
+	}
+	if *otlpEndpoint == "" {
+		*otlpEndpoint = cmp.Or(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"), os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	}
+	if *otlpHeaders == "" {
+		*otlpHeaders = os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")
+	}
+	if *otlpEndpoint != "" {
+		endpoint, err := tracing.Endpoint(*otlpEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("-otlp_endpoint: %w", err)
+		}
+		headers, err := tracing.ParseHeaders(*otlpHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("-otlp_headers: %w", err)
+		}
+		opts.tracer = &tracing.Tracer{
+			Endpoint: endpoint, Headers: headers, Service: os.Getenv("OTEL_SERVICE_NAME"), Version: toolVersion(), Client: opts.enrich.client,
+		}
+	}
+	if opts.profiles, err = config.LoadProfiles(*profiles); err != nil {
+		return nil, fmt.Errorf("-profiles: %w", err)
+	}
+	if *themePath != "" {
+		if opts.theme, err = theme.Load(*themePath); err != nil {
+			return nil, fmt.Errorf("-theme: %w", err)
+		}
+	}
+	if opts.theme, err = withLocale(opts.theme, *localeName); err != nil {
+		return nil, fmt.Errorf("-locale: %w", err)
+	}
+	if *outPath != "" {
+		if opts.outputJSON != "" {
+			return nil, errors.New("-output and -output_json both write the findings as JSON; pass one")
+		}
+		opts.outputJSON = *outPath
+	}
+	outputs := []struct{ flag, path string }{
+		{"output_csv", opts.outputCSV}, {"output_xlsx", opts.outputXLSX}, {"output_json", opts.outputJSON}, {"output_jsonl", opts.jsonl},
+		{"output_html", opts.outputHTML}, {"output_pdf", opts.outputPDF}, {"output_md", opts.outputMD}, {"output_tickets", opts.tickets},
+		{"output_vex", opts.vex}, {"output_cyclonedx", opts.cyclonedx},
+		{"quota_summary", opts.quotaCSV},
+		{"review_queue", opts.review}, {"quarantine", opts.quarantine},
+	}
+	var piped []string
+	for _, out := range outputs {
+		if out.path == stdio {
+			piped = append(piped, "-"+out.flag)
+		}
+	}
+	if len(piped) > 1 {
+		return nil, fmt.Errorf("more than one output writes to standard output: %s", strings.Join(piped, ", "))
+	}
+	opts.piped = len(piped) > 0
+	// A manifest goes next to output files, not to the working directory
+	// of a pipeline.
+	if opts.manifest == "" {
+		for _, out := range outputs {
+			if out.path != "" && out.path != stdio {
+				opts.manifest = filepath.Join(filepath.Dir(out.path), manifest.FileName)
+				break
+			}
+		}
+	}
+	if opts.attest != "" && opts.manifest == "" {
+		return nil, errors.New("-attestation needs an output file or -manifest to attest")
+	}
+	return opts, nil
+}
+
+// postFlag is a -post_cmd or -post_plugin flag. Both add to one chain, so
+// steps run in command-line order.
+type postFlag struct {
+	steps  *[]postproc.Step
+	stderr io.Writer // of commands
+	plugin bool
+}
+
+func (f postFlag) String() string { return "" }
+
+func (f postFlag) Set(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return errors.New("empty value")
+	}
+	if !f.plugin {
+		*f.steps = append(*f.steps, &postproc.Command{Line: s, Stderr: f.stderr})
+		return nil
+	}
+	p, err := postproc.OpenPlugin(s)
+	if err != nil {
+		return err
+	}
+	*f.steps = append(*f.steps, p)
+	return nil
+}
+
+// parseToday parses the -today flag, defaulting to the current time.
+// withLocale returns t with its locale set to name, or an otherwise empty
+// theme with it when t is nil. It returns t as it is when name is empty.
+func withLocale(t *theme.Theme, name string) (*theme.Theme, error) {
+	if name == "" {
+		return t, nil
+	}
+	if t == nil {
+		t = &theme.Theme{}
+	}
+	return t, t.SetLocale(name)
+}
+
+func parseToday(s string) (time.Time, error) {
+	if s == "" {
+		return time.Now(), nil
+	}
+	d, err := vuln.ParseDate(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("-today: %w", err)
+	}
+	return d.Time, nil
+}
+
+// subcommands maps subcommand names to their entry points. Without a
+// subcommand, run prioritizes an input.
+var subcommands = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"as-of":             runAsOf,
+	"compliance-report": runComplianceReport,
+	"daemon":            runDaemon,
+	"decrypt":           runDecrypt,
+	"enrich":            runEnrich,
+	"fixtures":          runFixtures,
+	"fp":                runFP,
+	"heatmap":           runHeatmap,
+	"inspect":           runInspect,
+	"lookup":            runLookup,
+	"merge":             runMerge,
+	"queue":             runQueue,
+	"report":            runReport,
+	"sample":            runSample,
+	"selftest":          runSelftest,
+	"serve":             runServe,
+	"simulate":          runSimulate,
+	"state":             runState,
+	"stream":            runStream,
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) > 0 {
+		if cmd, ok := subcommands[args[0]]; ok {
+			return cmd(args[1:], stdout, stderr)
+		}
+	}
+	return runPrioritize(args, stdout, stderr)
+}
+
+func runPrioritize(args []string, stdout, stderr io.Writer) error {
+	opts, err := parseFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+	es, err := opts.enrich.enrichers()
+	if err != nil {
+		return err
+	}
+	logger := newLogger(stderr, opts.quiet)
+	opts.files.stdout, opts.files.logger = stdout, logger
+	if opts.piped {
+		// The findings go to standard output in place of the table.
+		stdout = nil
+	}
+	return prioritize(context.Background(), opts, es, nil, logger, stdout)
+}
+
+// newLogger returns the logger of the prioritization commands, which logs
+// to stderr, only errors when quiet.
+func newLogger(stderr io.Writer, quiet bool) *slog.Logger {
+	if quiet {
+		return slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	}
+	return slog.New(slog.NewTextHandler(stderr, nil))
+}
+
+// prioritize runs the pipeline once. With a cache, unchanged findings
+// reuse their enrichment and static score points. The table goes to
+// stdout unless it is nil. With -otlp_endpoint the run is exported as a
+// trace with a span for each stage.
+func prioritize(ctx context.Context, opts *options, es []enrich.Enricher, cache *scorecache.Cache, logger *slog.Logger, stdout io.Writer) (err error) {
+	m := manifest.New(toolVersion())
+	ctx, span := opts.tracer.Start(ctx, "prioritize", tracing.String("prioritizer.input", opts.input))
+	if span != nil {
+		defer func() { exportTrace(ctx, opts.tracer, span, m, err, logger) }()
+	}
+	// stage times a stage for the manifest and traces it as a child of
+	// span, in the context it returns.
+	stage := func(name string) (context.Context, func()) {
+		endStage := m.StartStage(name)
+		ctx, s := tracing.Start(ctx, name)
+		return ctx, func() {
+			endStage()
+			s.SetAttributes(tracing.Float("prioritizer.cpu_ms", m.Stages[len(m.Stages)-1].CPUMS))
+			s.End(nil)
+		}
+	}
+
+	var (
+		runs  *history.Store
+		input manifest.File
+		files []string // of -input
+		stdin []byte   // of -input -
+		piped *manifest.File
+	)
+	// A directory or glob is expanded on every run, so the daemon reads
+	// the exports added to it since.
+	if opts.aws == nil && len(opts.inputs) > 0 {
+		if files, err = expandInputs(opts.inputs); err != nil {
+			return fmt.Errorf("-input: %w", err)
+		}
+		if stdin, piped, err = readStdin(files); err != nil {
+			return err
+		}
+	}
+	var baseline gate.Baseline
+	if opts.baseline != "" {
+		approved, err := readResults(opts.baseline, logger)
+		if err != nil {
+			return fmt.Errorf("-baseline: %w", err)
+		}
+		baseline = gate.NewBaseline(approved)
+	}
+	// processed reports whether the history records the input, and so
+	// the run is skipped.
+	processed := func(input manifest.File) bool {
+		r, ok := runs.Find(input.SHA256, opts.shard.String())
+		if !ok || opts.force {
+			return false
+		}
+		logger.Info("skipped input already processed", "path", input.Path, "sha256", input.SHA256, "processed_at", r.ProcessedAt)
+		if stdout != nil {
+			fmt.Fprintf(stdout, "%s already processed at %s; use -force to process it again\n", input.Path, r.ProcessedAt.Format(time.RFC3339))
+		}
+		return true
+	}
+	if opts.history != "" {
+		var err error
+		if runs, err = history.Load(opts.history); err != nil {
+			return err
+		}
+		// A pulled input is checked once it is pulled.
+		if len(files) > 0 {
+			if input, err = hashInputs(files, piped); err != nil {
+				return err
+			}
+			if opts.github == nil && processed(input) {
+				return nil
+			}
+		}
+	}
+
+	ingestCtx, end := stage("ingest")
+	var res *ingest.Result
+	switch {
+	case opts.aws != nil:
+		if res, input, err = opts.aws.pull(ingestCtx, logger); err != nil {
+			return err
+		}
+		m.Inputs = append(m.Inputs, input)
+	case len(files) > 0:
+		if res, err = loadInputs(files, stdin, opts.format, opts.sheet, logger); err != nil {
+			return err
+		}
+		if piped != nil {
+			m.Inputs = append(m.Inputs, *piped)
+		}
+	}
+	if opts.github != nil {
+		var pulled manifest.File
+		if res, pulled, err = opts.github.merge(ingestCtx, res, opts.input, logger); err != nil {
+			return err
+		}
+		m.Inputs = append(m.Inputs, pulled)
+		input = joinInputs(input, pulled)
+	}
+	if runs != nil && (opts.aws != nil || opts.github != nil) && processed(input) {
+		return nil
+	}
+	if !opts.custom {
+		res.DropCustomFields()
+	}
+	vs := res.Vulns
+	m.Rows.Read, m.Rows.Skipped = len(vs), len(res.Skipped)
+	// Image findings are keyed by digest, and packages by their current
+	// names, before anything looks up their fingerprints.
+	if vs, m.Rows.Collapsed = imageref.Apply(vs); m.Rows.Collapsed > 0 {
+		logger.Info("collapsed image tag aliases", "findings", m.Rows.Collapsed)
+	}
+	if opts.renames != nil {
+		n := opts.renames.Apply(vs)
+		logger.Info("renamed packages", "renames", len(opts.renames.Packages), "findings", n)
+	}
+	if opts.dedup {
+		vs, m.Rows.Duplicates = dedup.Apply(vs)
+		logger.Info("merged duplicate findings", "findings", m.Rows.Duplicates)
+	}
+	if opts.groups != nil {
+		var stats assetgroup.Stats
+		vs, stats = opts.groups.Apply(vs)
+		m.Rows.Grouped = stats.Merged
+		logger.Info("grouped asset instances", "assets", stats.Assets, "instances", stats.Instances, "findings", stats.Merged)
+	}
+	if store := opts.fpStore; store != nil {
+		rates := store.Rates(vs)
+		for _, r := range rates {
+			logger.Info("false positive rate", "source", r.Source, "findings", r.Findings, "false_positives", r.FalsePositives, "rate", r.Rate)
+		}
+		if opts.reliable {
+			if opts.weights.Reliability == nil {
+				opts.weights.Reliability = make(map[string]float64)
+			}
+			maps.Copy(opts.weights.Reliability, suppress.Reliabilities(rates))
+			for _, r := range rates {
+				logger.Info("source reliability", "source", r.Source, "factor", r.Reliability)
+			}
+		}
+		vs, m.Rows.Suppressed = store.Filter(vs)
+		logger.Info("suppressed false positives", "findings", m.Rows.Suppressed)
+	}
+	if opts.inventory != nil {
+		n := opts.inventory.Apply(vs)
+		logger.Info("applied asset inventory", "assets", len(opts.inventory.Assets), "findings", n)
+	}
+	if opts.retired != nil {
+		vs, m.Rows.Stale = opts.retired.Apply(vs, opts.staleMode, opts.today)
+		logger.Info("findings on decommissioned assets", "findings", m.Rows.Stale, "mode", opts.staleMode)
+	}
+	if opts.waivers != nil {
+		var res waiver.Result
+		vs, res = opts.waivers.Apply(vs, opts.waiverMode, opts.today)
+		for _, w := range res.Expired {
+			logger.Warn("waiver expired; its findings are reported again", "id", w.ID, "asset", w.Asset, "package", w.Package, "expired", w.Expires)
+		}
+		m.Rows.Waived = res.Waived
+		logger.Info("waived findings", "findings", res.Waived, "mode", opts.waiverMode, "reactivated", res.Reactivated)
+	}
+	if opts.shard.Count > 0 {
+		vs = opts.shard.Filter(vs)
+		m.Shard = opts.shard.String()
+		logger.Info("selected shard", "shard", m.Shard, "findings", len(vs))
+	}
+	// Discovered findings are added before states are applied, so states
+	// recorded for them in earlier runs hold.
+	if vs, m.Rows.Discovered, err = opts.enrich.discover(ctx, vs, logger); err != nil {
+		return err
+	}
+	if opts.eol {
+		added := eol.Apply(vs, opts.today)
+		vs = append(vs, added...)
+		m.Rows.EndOfLife = len(added)
+		logger.Info("flagged end-of-life assets", "assets", len(added))
+	}
+	platform.Apply(vs)
+	if opts.hints != nil {
+		n, err := opts.hints.Apply(vs)
+		if err != nil {
+			return fmt.Errorf("remediation templates: %w", err)
+		}
+		logger.Info("filled in platform remediations", "findings", n)
+	}
+	if opts.stateStore != "" {
+		s, err := state.Load(opts.stateStore)
+		if err != nil {
+			return err
+		}
+		if opts.ageOut > 0 {
+			if err := ageOut(s, vs, opts, logger); err != nil {
+				return err
+			}
+		}
+		s.Apply(vs)
+	}
+	if opts.graphs != "" {
+		gs, err := depgraph.Load(opts.graphs)
+		if err != nil {
+			return err
+		}
+		direct, transitive := gs.Apply(vs)
+		logger.Info("classified dependencies", "direct", direct, "transitive", transitive)
+	}
+	end()
+
+	opts.sla.Apply(vs)
+	sla.Assess(vs, opts.today)
+	effort.Apply(vs)
+	model, err := scoring.NewScorer(opts.model, opts.weights, opts.today)
+	if err != nil {
+		return err
+	}
+	if cache != nil {
+		var ectx context.Context
+		ectx, end = stage("enrich")
+		stats, err := cache.Enrich(ectx, vs, es)
+		if err != nil {
+			return err
+		}
+		end()
+		logger.Info("score cache", "hits", stats.Hits, "misses", stats.Misses, "evicted", stats.Evicted)
+
+		_, end = stage("score")
+		classify(vs, logger)
+		// Only the weighted model's static points are cached.
+		if w, ok := model.(scoring.Weighted); ok {
+			cache.Score(vs, w)
+			scoring.Rank(vs, scoring.DefaultTiers)
+		} else {
+			scoring.Prioritize(vs, model, scoring.DefaultTiers)
+		}
+		scoring.Project(vs, model)
+		config.ScoreProfiles(vs, opts.profiles, opts.today)
+		end()
+	} else {
+		if len(es) > 0 {
+			var ectx context.Context
+			ectx, end = stage("enrich")
+			if err := runEnrichment(ectx, vs, es, logger); err != nil {
+				return err
+			}
+			end()
+		}
+
+		_, end = stage("score")
+		classify(vs, logger)
+		scoring.Prioritize(vs, model, scoring.DefaultTiers)
+		scoring.Project(vs, model)
+		config.ScoreProfiles(vs, opts.profiles, opts.today)
+		end()
+	}
+
+	_, end = stage("confidence")
+	policy := opts.confidence
+	noisy := policy.Apply(vs)
+	// The review queue holds findings as scored before any demotion.
+	queue := policy.ReviewQueue(vs)
+	if opts.demote {
+		policy.Demote(vs)
+		scoring.Rank(vs, scoring.DefaultTiers)
+	}
+	logger.Info("rated confidence", "low_confidence", noisy, "review_queue", len(queue), "demoted", opts.demote)
+	end()
+
+	var decisions *rego.Decisions
+	if opts.rego != nil {
+		_, end = stage("policy")
+		if decisions, err = opts.rego.Evaluate(ctx, vs, opts.today, scoring.DefaultTiers); err != nil {
+			return fmt.Errorf("-rego: %w", err)
+		}
+		a := decisions.Apply(vs, scoring.DefaultTiers)
+		logger.Info("evaluated policies", "files", len(opts.rego.Policies), "suppressed", a.Suppressed, "escalated", a.Escalated, "denied", len(decisions.Deny))
+		end()
+	}
+
+	if opts.windows != nil {
+		_, end = stage("schedule")
+		n := opts.windows.Apply(vs, opts.today)
+		logger.Info("scheduled patch windows", "emergency_changes", n)
+		end()
+	}
+
+	if len(opts.post) > 0 {
+		_, end = stage("postprocess")
+		before := len(vs)
+		postCtx, cancel := context.WithTimeout(ctx, opts.postTime)
+		vs, err = postproc.Run(postCtx, vs, opts.post)
+		cancel()
+		if err != nil {
+			return err
+		}
+		logger.Info("post-processed findings", "steps", len(opts.post), "before", before, "after", len(vs))
+		end()
+	}
+
+	if opts.db != "" {
+		if err := track(opts, vs, logger); err != nil {
+			return err
+		}
+	}
+
+	if opts.filter != nil {
+		before := len(vs)
+		if vs = opts.filter.Select(vs); vs == nil {
+			vs = []vuln.Vulnerability{}
+		}
+		m.Rows.Filtered = before - len(vs)
+		logger.Info("filtered findings", "kept", len(vs), "filtered", m.Rows.Filtered)
+	}
+
+	_, end = stage("output")
+	sortFindings(vs, opts.sortBy)
+	if opts.validate {
+		if err := output.ValidateFindings(vs); err != nil {
+			return fmt.Errorf("findings do not match the finding schema:\n%w", err)
+		}
+		logger.Info("validated findings against the schema", "findings", len(vs))
+	}
+	if opts.jira != nil {
+		if err := fileJira(ctx, opts, vs, logger); err != nil {
+			return err
+		}
+	}
+	var outputs []string
+	distributed := vs
+	if opts.maxPerTeam > 0 {
+		var withheld []quota.Withheld
+		distributed, withheld = quota.Apply(vs, opts.maxPerTeam)
+		m.Rows.Withheld = len(vs) - len(distributed)
+		for _, w := range withheld {
+			logger.Info("withheld findings over the team quota", "team", w.Team, "severity", w.Severity, "findings", w.Findings, "withheld", w.Withheld)
+		}
+		if opts.quotaCSV != "" {
+			if err := writeFile(opts.quotaCSV, opts.files, withheld, quota.WriteCSV); err != nil {
+				return err
+			}
+			logger.Info("wrote quota summary", "path", opts.quotaCSV, "groups", len(withheld))
+			outputs = append(outputs, opts.quotaCSV)
+		}
+	}
+	if opts.outputCSV != "" {
+		if err := writeCSV(opts.outputCSV, opts.files, distributed, weightProfileNames(opts.profiles), res.CustomColumns, opts.batch); err != nil {
+			return err
+		}
+		logger.Info("wrote CSV", "path", opts.outputCSV)
+		outputs = append(outputs, opts.outputCSV)
+	}
+	if opts.outputXLSX != "" {
+		write := func(w io.Writer, vs []vuln.Vulnerability) error {
+			return output.WriteXLSX(w, vs, weightProfileNames(opts.profiles), res.CustomColumns, opts.theme)
+		}
+		if err := writeFile(opts.outputXLSX, opts.files, distributed, write); err != nil {
+			return err
+		}
+		logger.Info("wrote XLSX workbook", "path", opts.outputXLSX)
+		outputs = append(outputs, opts.outputXLSX)
+	}
+	if opts.outputJSON != "" {
+		write := output.WriteJSON
+		if opts.canonical {
+			write = output.WriteCanonicalJSON
+		}
+		if err := writeFile(opts.outputJSON, opts.files, vs, write); err != nil {
+			return err
+		}
+		logger.Info("wrote JSON", "path", opts.outputJSON)
+		outputs = append(outputs, opts.outputJSON)
+	}
+	if opts.jsonl != "" {
+		if err := writeFile(opts.jsonl, opts.files, vs, output.WriteJSONL); err != nil {
+			return err
+		}
+		logger.Info("wrote JSON Lines", "path", opts.jsonl)
+		outputs = append(outputs, opts.jsonl)
+	}
+	if opts.outputHTML != "" {
+		write := func(w io.Writer, vs []vuln.Vulnerability) error {
+			return report.NewOverview(vs, opts.htmlTop, opts.today).WriteHTML(w, opts.theme)
+		}
+		if err := writeFile(opts.outputHTML, opts.files, vs, write); err != nil {
+			return err
+		}
+		logger.Info("wrote HTML report", "path", opts.outputHTML)
+		outputs = append(outputs, opts.outputHTML)
+	}
+	if opts.outputPDF != "" {
+		write := func(w io.Writer, vs []vuln.Vulnerability) error {
+			return report.NewOverview(vs, opts.htmlTop, opts.today).WritePDF(w, opts.theme)
+		}
+		if err := writeFile(opts.outputPDF, opts.files, vs, write); err != nil {
+			return err
+		}
+		logger.Info("wrote PDF report", "path", opts.outputPDF)
+		outputs = append(outputs, opts.outputPDF)
+	}
+	if opts.outputMD != "" {
+		write := func(w io.Writer, vs []vuln.Vulnerability) error {
+			return report.WriteMarkdown(w, vs, markdownTop, opts.today)
+		}
+		if err := writeFile(opts.outputMD, opts.files, vs, write); err != nil {
+			return err
+		}
+		logger.Info("wrote Markdown summary", "path", opts.outputMD)
+		outputs = append(outputs, opts.outputMD)
+	}
+	if opts.tickets != "" {
+		write := func(w io.Writer, vs []vuln.Vulnerability) error {
+			return ticket.Write(w, opts.ticketFmt, vs)
+		}
+		if err := writeFile(opts.tickets, opts.files, distributed, write); err != nil {
+			return err
+		}
+		logger.Info("wrote tickets", "path", opts.tickets, "format", opts.ticketFmt, "tickets", len(ticket.Open(distributed)))
+		outputs = append(outputs, opts.tickets)
+	}
+	if opts.vex != "" {
+		var falsePositives []*suppress.Entry
+		if opts.fpStore != nil {
+			falsePositives = opts.fpStore.List()
+		}
+		ss := vex.Statements(vs, falsePositives)
+		d := vex.Document{Author: opts.vexAuthor, Tool: "prioritizer", ToolVersion: toolVersion(), Timestamp: time.Now()}
+		write := func(w io.Writer, _ []vuln.Vulnerability) error { return vex.Write(w, opts.vexFormat, ss, d) }
+		if err := writeFile(opts.vex, opts.files, vs, write); err != nil {
+			return err
+		}
+		logger.Info("wrote VEX document", "path", opts.vex, "format", opts.vexFormat, "statements", len(ss))
+		outputs = append(outputs, opts.vex)
+	}
+	if opts.cyclonedx != "" {
+		d := vex.Document{Author: opts.vexAuthor, Tool: "prioritizer", ToolVersion: toolVersion(), Timestamp: time.Now()}
+		write := func(w io.Writer, vs []vuln.Vulnerability) error { return vex.WriteBOM(w, vs, d) }
+		if err := writeFile(opts.cyclonedx, opts.files, vs, write); err != nil {
+			return err
+		}
+		logger.Info("wrote CycloneDX SBOM", "path", opts.cyclonedx)
+		outputs = append(outputs, opts.cyclonedx)
+	}
+	if opts.sheets.id != "" {
+		if err := opts.sheets.write(ctx, distributed); err != nil {
+			return err
+		}
+		logger.Info("wrote Google Sheet", "id", opts.sheets.id)
+	}
+	if opts.review != "" {
+		if err := writeFile(opts.review, opts.files, queue, output.WriteCSV); err != nil {
+			return err
+		}
+		logger.Info("wrote review queue", "path", opts.review, "findings", len(queue))
+		outputs = append(outputs, opts.review)
+	}
+	if opts.quarantine != "" {
+		if err := writeQuarantine(opts.quarantine, opts.files, res); err != nil {
+			return err
+		}
+		logger.Info("wrote quarantine", "path", opts.quarantine, "rows", len(res.Skipped))
+		outputs = append(outputs, opts.quarantine)
+	}
+	if opts.notifier != nil {
+		stats, err := opts.notifier.Notify(ctx, vs, time.Now())
+		if err != nil {
+			// Unsent messages stay queued for the next cycle.
+			logger.Error("sending notifications", "error", err)
+		}
+		logger.Info("notified", "queued", stats.Queued, "immediate", stats.Immediate, "digests", stats.Digests, "digest_findings", stats.Sent)
+	}
+	if len(opts.webhooks) > 0 {
+		postSummary(ctx, opts, vs, logger)
+	}
+	if stdout != nil {
+		view := opts.terminal
+		view.color = !opts.noColor && isTerminal(stdout)
+		printToTerminal(stdout, vs, opts.bands, view)
+	}
+	end()
+	m.Rows.Written = len(vs)
+	if s := sla.Summarize(vs); s.Tracked > 0 {
+		m.SLA = &s
+		logger.Info("SLA breaches", "findings", s.Breached, "tracked", s.Tracked, "max_days_overdue", s.MaxDaysOverdue)
+	}
+	if len(vs) > 0 {
+		h := scoring.NewHistogram(vs, opts.bands)
+		m.Scores = &h
+		logger.Info("priority scores", "mean", h.Mean, "bounds", opts.bands, "findings", h.Counts())
+	}
+
+	m.Finish()
+	for _, s := range m.Stages {
+		logger.Info("stage usage", "stage", s.Name, "duration_ms", s.DurationMS, "cpu_ms", s.CPUMS)
+	}
+	r := m.Resources
+	logger.Info("run usage", "duration_ms", r.DurationMS, "cpu_ms", r.CPUMS, "peak_rss_bytes", r.PeakRSSBytes, "rows_per_sec", r.RowsPerSec)
+	if opts.manifest != "" {
+		if err := writeManifest(m, opts, files, outputs); err != nil {
+			return fmt.Errorf("writing manifest: %w", err)
+		}
+		logger.Info("wrote manifest", "path", opts.manifest)
+	}
+	if opts.attest != "" {
+		if err := writeAttestation(m, opts); err != nil {
+			return fmt.Errorf("writing attestation: %w", err)
+		}
+		logger.Info("wrote attestation", "path", opts.attest, "subjects", len(m.Outputs)+1)
+	}
+	if runs != nil {
+		// Recorded last, so a failed run is retried.
+		runs.Add(history.Run{
+			InputSHA256: input.SHA256, Input: input.Path, Shard: m.Shard,
+			ToolVersion: m.ToolVersion, ProcessedAt: m.StartedAt, Findings: len(vs),
+		})
+		if err := runs.Save(opts.history); err != nil {
+			return fmt.Errorf("writing history: %w", err)
+		}
+		logger.Info("recorded run in history", "path", opts.history)
+	}
+	if opts.gate.Enabled() || baseline != nil {
+		if err := checkGate(opts, vs, baseline, logger); err != nil {
+			return err
+		}
+	}
+	if decisions != nil {
+		return checkPolicy(opts, decisions, vs, logger)
+	}
+	return nil
+}
+
+// checkGate fails the run when the findings of vs fail the -fail_on_*
+// thresholds. With a -baseline only the findings it does not have are
+// checked, and any of them fails without thresholds; -baseline_mode warn
+// logs them instead.
+func checkGate(opts *options, vs []vuln.Vulnerability, baseline gate.Baseline, logger *slog.Logger) error {
+	checked := vs
+	if baseline != nil {
+		checked = baseline.New(vs)
+		logger.Info("compared with the baseline", "path", opts.baseline, "findings", len(vs), "new", len(checked))
+	}
+	failed := checked
+	if opts.gate.Enabled() {
+		failed = opts.gate.Check(checked)
+	}
+	msg := "finding fails the gate"
+	if baseline != nil && opts.baseMode == gate.Warn {
+		msg = "finding is not in the baseline"
+	}
+	for _, v := range failed[:min(len(failed), gateListed)] {
+		logger.Warn(msg, "identifier", v.Identifier, "asset", report.ByAsset(&v), "severity", v.Severity, "score", v.PriorityScore)
+	}
+	if len(failed) > 0 && (baseline == nil || opts.baseMode == gate.Fail) {
+		return &gate.Error{Thresholds: opts.gate, Failed: len(failed), Baseline: baseline != nil}
+	}
+	logger.Info("passed the gate", "findings", len(vs), "warned", len(failed))
+	return nil
+}
+
+// checkPolicy fails the run when -rego policies deny any of the findings
+// of vs that no state or waiver exempts. The daemon only logs them, as
+// it gates no single run.
+func checkPolicy(opts *options, d *rego.Decisions, vs []vuln.Vulnerability, logger *slog.Logger) error {
+	denied := d.Denied(vs)
+	for _, v := range denied[:min(len(denied), gateListed)] {
+		logger.Warn("policy denies finding", "identifier", v.Identifier, "asset", report.ByAsset(&v), "reason", d.Deny[v.Fingerprint()])
+	}
+	if len(denied) > 0 && !opts.daemon {
+		return &gate.Error{Failed: len(denied), Policy: true}
+	}
+	return nil
+}
+
+// gateListed is how many of the findings that fail the gate are logged.
+const gateListed = 10
+
+// writeManifest checksums the run's input files, configuration and
+// outputs into m and writes it to opts.manifest.
+func writeManifest(m *manifest.Manifest, opts *options, inputs, outputs []string) error {
+	// A pulled input is recorded as it is pulled, and standard input as
+	// it is read.
+	for _, path := range inputs {
+		if path == stdio {
+			continue
+		}
+		if err := m.AddInput(path); err != nil {
+			return err
+		}
+	}
+	var err error
+	if m.ConfigSHA256, err = manifest.HashJSON(runConfig(opts)); err != nil {
+		return err
+	}
+	for _, path := range outputs {
+		if path == stdio {
+			continue
+		}
+		if err := m.AddOutput(path); err != nil {
+			return err
+		}
+	}
+	return withRetry(opts.manifest, opts.files, func() error {
+		if opts.canonical {
+			return m.WriteCanonicalFile(opts.manifest, opts.files.mode, opts.timestamps)
+		}
+		return m.WriteFile(opts.manifest, opts.files.mode)
+	})
+}
+
+// fileJira files the findings of vs at or above the -jira threshold in
+// Jira and sets their Ticket, so that the outputs carry the issue keys.
+func fileJira(ctx context.Context, opts *options, vs []vuln.Vulnerability, logger *slog.Logger) error {
+	s := &jira.Syncer{Config: opts.jira, Client: opts.enrich.client}
+	res, err := s.Sync(ctx, vs)
+	if err != nil {
+		return fmt.Errorf("-jira: %w", err)
+	}
+	logger.Info("synced Jira issues", "project", opts.jira.Project, "group_by", opts.jira.GroupBy, "groups", res.Groups, "findings", res.Findings, "created", res.Created, "updated", res.Updated)
+	return nil
+}
+
+// exportTrace ends the run's root span, with the run's error and row
+// counts, and exports its trace. A failed export is logged rather than
+// failing the run.
+func exportTrace(ctx context.Context, t *tracing.Tracer, span *tracing.Span, m *manifest.Manifest, err error, logger *slog.Logger) {
+	span.SetAttributes(
+		tracing.Int("prioritizer.rows.read", m.Rows.Read), tracing.Int("prioritizer.rows.skipped", m.Rows.Skipped),
+		tracing.Int("prioritizer.rows.written", m.Rows.Written),
+	)
+	span.End(err)
+	// Export even when the run was interrupted, but not for long.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), exportTimeout)
+	defer cancel()
+	if err := t.Export(ctx, span); err != nil {
+		logger.Error("exporting trace", "trace_id", span.TraceID(), "error", err)
+		return
+	}
+	logger.Info("exported trace", "trace_id", span.TraceID(), "endpoint", t.Endpoint)
+}
+
+// exportTimeout bounds a trace export.
+const exportTimeout = 10 * time.Second
+
+// postSummary posts a summary of vs to the -notify_slack and -notify_teams
+// webhooks. A failed post is logged rather than failing the run, whose
+// outputs are written by then.
+func postSummary(ctx context.Context, opts *options, vs []vuln.Vulnerability, logger *slog.Logger) {
+	s := notify.Summarize(vs, opts.today)
+	for _, service := range notify.ChatServices {
+		hook := opts.webhooks[service]
+		if hook == "" {
+			continue
+		}
+		if err := s.Post(ctx, opts.enrich.client, service, hook); err != nil {
+			logger.Error("posting summary", "service", service, "error", err)
+			continue
+		}
+		logger.Info("posted summary", "service", service, "findings", s.Findings, "new_critical", len(s.NewCritical), "sla_breaches", s.SLA.Breached)
+	}
+}
+
+// track records vs as every finding of the run in the -db store, before
+// filtering, so findings are resolved only when the run no longer reports
+// them.
+func track(opts *options, vs []vuln.Vulnerability, logger *slog.Logger) error {
+	db, err := tracking.Load(opts.db)
+	if err != nil {
+		return err
+	}
+	c := db.Upsert(vs, opts.today)
+	if err := db.Save(opts.db); err != nil {
+		return fmt.Errorf("writing %s: %w", opts.db, err)
+	}
+	counts := db.Counts()
+	mttr, resolutions := db.MeanTimeToRemediate()
+	logger.Info("tracked findings", "path", opts.db, "new", c.New, "seen", c.Seen, "reopened", c.Reopened, "resolved", c.Resolved, "rescored", c.Rescored,
+		"open", counts[tracking.Open]+counts[tracking.Reopened], "mttr_days", math.Round(mttr.Hours()/24*10)/10, "resolutions", resolutions)
+	return nil
+}
+
+// classify sets the vulnerability class of the findings the input did not
+// classify, once enrichment has filled in their CWEs and descriptions.
+func classify(vs []vuln.Vulnerability, logger *slog.Logger) {
+	logger.Info("classified findings", "findings", taxonomy.Apply(vs))
+}
+
+// ageOut records the run's findings as seen in the state store s, moving
+// findings opts.ageOut consecutive runs have missed to stale and those
+// seen again back to open, and saves s. Changes are audited as for state
+// set.
+func ageOut(s *state.Store, vs []vuln.Vulnerability, opts *options, logger *slog.Logger) error {
+	changes := s.Observe(vs, time.Now(), opts.ageOut, "prioritizer -age_out")
+	stale := 0
+	for _, c := range changes {
+		if c.To == state.Stale {
+			stale++
+		}
+	}
+	if len(changes) > 0 {
+		audit := filepath.Join(filepath.Dir(opts.stateStore), auditFileName)
+		if err := state.AppendAudit(audit, changes); err != nil {
+			return fmt.Errorf("writing audit log: %w", err)
+		}
+	}
+	s.Commit(changes)
+	if err := s.Save(opts.stateStore); err != nil {
+		return err
+	}
+	logger.Info("aged out findings", "tracked", len(s.Seen), "stale", stale, "reopened", len(changes)-stale)
+	return nil
+}
+
+// sortOrders are the orders -sort accepts.
+var sortOrders = []string{"priority", "score", "effort", "cvss", "due"}
+
+// sortFindings orders vs, in priority order, by the -sort order. Findings
+// of equal CVSS score or due date keep their priority order, and those
+// without a due date go last.
+func sortFindings(vs []vuln.Vulnerability, order string) {
+	switch order {
+	case "effort":
+		effort.Sort(vs)
+	case "cvss":
+		slices.SortStableFunc(vs, func(a, b vuln.Vulnerability) int { return cmp.Compare(b.CVSS, a.CVSS) })
+	case "due":
+		slices.SortStableFunc(vs, func(a, b vuln.Vulnerability) int {
+			switch {
+			case a.DueDate.IsZero() == b.DueDate.IsZero():
+				return a.DueDate.Compare(b.DueDate.Time)
+			case a.DueDate.IsZero():
+				return 1
+			}
+			return -1
+		})
+	}
+}
+
+// markdownTop is how many of the top priorities and SLA breaches the
+// -output_md summary lists.
+const markdownTop = 10
+
+// runConfig is the configuration of a run, as its checksum is taken.
+func runConfig(opts *options) config.Config {
+	return config.Config{Model: opts.model, Weights: opts.weights, SLA: opts.sla}
+}
+
+// writeAttestation writes the provenance of the run m describes, after
+// writeManifest, to opts.attest. The manifest is attested with the
+// outputs.
+func writeAttestation(m *manifest.Manifest, opts *options) error {
+	mf, err := manifest.HashFile(opts.manifest)
+	if err != nil {
+		return err
+	}
+	timestamps := !opts.canonical || opts.timestamps
+	st := m.Attestation(builderID, runConfig(opts), timestamps, mf)
+	return st.WriteFile(opts.attest, opts.files.mode, opts.canonical)
+}
+
+// load reads the input export, logging every skipped row. The format is
+// detected from the content (see ingest.DetectFormat), and workbooks are
+// read from their first sheet.
+func load(path string, logger *slog.Logger) (*ingest.Result, error) {
+	return loadSheet(path, "", "", logger)
+}
+
+// loadSheet is load, reading the input in format, or the detected format
+// when format is "", and the named sheet of a workbook.
+func loadSheet(path, format, sheet string, logger *slog.Logger) (*ingest.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return readLogged(f, info.Size(), path, format, sheet, logger)
+}
+
+// readLogged is readInput, logging the rows skipped and the findings read
+// from the input at path, which may be standard input.
+func readLogged(ra io.ReaderAt, size int64, path, format, sheet string, logger *slog.Logger) (*ingest.Result, error) {
+	res, err := readInput(ra, size, path, format, sheet)
+	name := path
+	if path == stdio {
+		name = "standard input"
+	}
+	if errors.Is(err, errUnrecognised) {
+		return nil, fmt.Errorf("%s: %w; pass -format (one of %s)", name, err, strings.Join(ingest.Formats, ", "))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for _, skipped := range res.Skipped {
+		if skipped.Member != "" {
+			logger.Warn("skipped row", "input", path, "member", skipped.Member, "line", skipped.Line, "error", skipped.Err)
+			continue
+		}
+		logger.Warn("skipped row", "input", path, "line", skipped.Line, "error", skipped.Err)
+	}
+	logger.Info("read input", "path", path, "findings", len(res.Vulns), "skipped", len(res.Skipped))
+	return res, nil
+}
+
+// errUnrecognised is readInput's error for JSON of no known input format.
+var errUnrecognised = errors.New("unrecognised JSON input")
+
+// readInput reads the size-byte input named name in format, or the format
+// detected from name and the content when format is "", and the named
+// sheet of a workbook. A bundle's members are read in their detected
+// formats, as one input.
+func readInput(ra io.ReaderAt, size int64, name, format, sheet string) (*ingest.Result, error) {
+	r := bufio.NewReaderSize(io.NewSectionReader(ra, 0, size), ingest.DetectBytes)
+	if format == "" {
+		head, err := r.Peek(ingest.DetectBytes)
+		if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+		if format = ingest.DetectFormat(name, head); format == "" {
+			return nil, errUnrecognised
+		}
+	}
+	switch format {
+	case ingest.FormatBundle:
+		return readBundle(ra, size, sheet)
+	case ingest.FormatXLSX:
+		return ingest.ReadXLSX(ra, size, sheet)
+	case ingest.FormatSARIF:
+		return ingest.ReadSARIF(r)
+	case ingest.FormatTrivy:
+		return ingest.ReadTrivy(r)
+	case ingest.FormatGrype:
+		return ingest.ReadGrype(r)
+	case ingest.FormatInspector:
+		return ingest.ReadInspector(r)
+	case ingest.FormatSecurityHub:
+		return ingest.ReadSecurityHub(r)
+	default:
+		return ingest.ReadCSV(r)
+	}
+}
+
+// readBundle reads every member of the size-byte bundle ra, and the named
+// sheet of its workbooks.
+func readBundle(ra io.ReaderAt, size int64, sheet string) (*ingest.Result, error) {
+	members, err := ingest.ReadBundle(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(members))
+	parts := make([]*ingest.Result, len(members))
+	for i, m := range members {
+		head := m.Data[:min(len(m.Data), ingest.DetectBytes)]
+		if ingest.DetectFormat(m.Name, head) == ingest.FormatBundle {
+			return nil, fmt.Errorf("%s: bundles cannot be nested", m.Name)
+		}
+		res, err := readInput(bytes.NewReader(m.Data), int64(len(m.Data)), m.Name, "", sheet)
+		if errors.Is(err, errUnrecognised) {
+			// -format applies to the bundle, not its members.
+			return nil, fmt.Errorf("%s: %v", m.Name, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		names[i], parts[i] = m.Name, res
+	}
+	return ingest.MergeMembers(names, parts)
+}
+
+// defaultFileMode is the permissions of output files without -output_mode.
+const defaultFileMode os.FileMode = 0o644
+
+// fileOptions are how output files are created: their permissions, the
+// key they are sealed with, if any, the standard output that the file
+// named - is written to, when the command has one, and how writes that
+// fail with a transient error are retried, logging to logger when set.
+type fileOptions struct {
+	mode   os.FileMode
+	key    *seal.Key
+	stdout io.Writer
+	retry  retryPolicy
+	logger *slog.Logger
+}
+
+// retryPolicy is how often, and after how long, an output file whose
+// write fails with a transient error, as network filesystems return when
+// the server goes away for a moment, is written again from the start.
+type retryPolicy struct {
+	retries int
+	backoff time.Duration // before the first retry, doubling for each further one
+}
+
+// defaultRetry is the retryPolicy without -output_retries and
+// -output_retry_backoff.
+var defaultRetry = retryPolicy{retries: 3, backoff: 500 * time.Millisecond}
+
+// defaultFiles are the fileOptions of subcommands without -output_mode
+// and -encrypt.
+var defaultFiles = fileOptions{mode: defaultFileMode, retry: defaultRetry}
+
+// loadEncryptKey reads the key file of an -encrypt value, aes:file.
+func loadEncryptKey(s string) (*seal.Key, error) {
+	scheme, path, _ := strings.Cut(s, ":")
+	switch {
+	case scheme == "age":
+		// Its X25519 and ChaCha20-Poly1305 need golang.org/x/crypto.
+		return nil, errors.New("age recipients are not supported; use aes:key_file")
+	case scheme != "aes" || path == "":
+		return nil, fmt.Errorf("%q: want aes:key_file", s)
+	}
+	return seal.LoadKey(path)
+}
+
+// parseFileMode parses octal file permissions, such as 0600.
+func parseFileMode(s string) (os.FileMode, error) {
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 0o777 {
+		return 0, fmt.Errorf("invalid mode %q (want octal permissions, such as 0600)", s)
+	}
+	return os.FileMode(n), nil
+}
+
+// createFile creates path with the permissions of o. What is written
+// goes to a temporary file beside path, which Close renames over it once
+// complete, so a run that fails part way leaves the previous file, or
+// none, and never part of one. The mode is set once the temporary file is
+// open, so the umask does not change it. With a key, what is written is
+// sealed, so no plaintext reaches the disk. Standard output and special
+// files, such as /dev/null, are written in place.
+func createFile(path string, o fileOptions) (*outputFile, error) {
+	out := &outputFile{path: path}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		// Replace the file a link points at, not the link.
+		out.path = resolved
+	}
+	switch fi, err := os.Stat(out.path); {
+	case path == stdio && o.stdout != nil:
+		out.Writer, out.f = o.stdout, nopCloser{o.stdout}
+	case err == nil && !fi.Mode().IsRegular():
+		file, err := os.OpenFile(out.path, os.O_WRONLY, 0)
+		if err != nil {
+			return nil, err
+		}
+		out.Writer, out.f = file, file
+	default:
+		file, err := os.CreateTemp(filepath.Dir(out.path), "."+filepath.Base(out.path)+".*.tmp")
+		if err != nil {
+			return nil, err
+		}
+		out.Writer, out.f, out.tmp = file, file, file.Name()
+		if err := file.Chmod(o.mode); err != nil {
+			out.Abort()
+			return nil, err
+		}
+	}
+	if o.key == nil {
+		return out, nil
+	}
+	w, err := seal.NewWriter(out.Writer, o.key)
+	if err != nil {
+		out.Abort()
+		return nil, err
+	}
+	out.Writer, out.seal = w, w
+	return out, nil
+}
+
+// outputFile is an output file being written, as createFile opens it.
+type outputFile struct {
+	io.Writer
+	seal io.WriteCloser // the seal writer, with a key
+	f    io.Closer      // the file written, or standard output
+	tmp  string         // the temporary file renamed to path, if any
+	path string
+}
+
+// Close finishes the file: it flushes the seal writer, syncs the
+// temporary file to disk and renames it over path. The temporary file is
+// removed if any of that fails.
+func (o *outputFile) Close() error {
+	var err error
+	if o.seal != nil {
+		err = o.seal.Close()
+	}
+	if f, ok := o.f.(*os.File); ok && o.tmp != "" && err == nil {
+		err = f.Sync()
+	}
+	if cerr := o.f.Close(); err == nil {
+		err = cerr
+	}
+	if o.tmp == "" {
+		return err
+	}
+	if err == nil {
+		err = os.Rename(o.tmp, o.path)
+	}
+	if err != nil {
+		os.Remove(o.tmp)
+	}
+	return err
+}
+
+// Abort closes the file without finishing it, removing the temporary
+// file, for a write that failed.
+func (o *outputFile) Abort() {
+	o.f.Close()
+	if o.tmp != "" {
+		os.Remove(o.tmp)
+	}
+}
+
+// nopCloser is standard output as an output file, which closing leaves
+// open.
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// transientErrors are the errors of a write that may succeed if tried
+// again: an I/O error or timeout from a file server that went away, and a
+// stale file handle once it is back.
+var transientErrors = []error{syscall.EIO, syscall.ESTALE, syscall.ETIMEDOUT, syscall.EAGAIN, syscall.EINTR}
+
+// withRetry calls write, which writes path from the start, and calls it
+// again after a backoff while it fails with one of transientErrors, up to
+// the retries of o. Standard output is written once, as what reached it
+// cannot be taken back.
+func withRetry(path string, o fileOptions, write func() error) error {
+	delay := o.retry.backoff
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || attempt > o.retry.retries || path == stdio && o.stdout != nil || !slices.ContainsFunc(transientErrors, func(e error) bool { return errors.Is(err, e) }) {
+			return err
+		}
+		if o.logger != nil {
+			o.logger.Warn("retrying output write", "path", path, "attempt", attempt+1, "after", delay, "error", err)
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// writeFile creates path as o says and writes data, usually the findings,
+// to it with write.
+func writeFile[T any](path string, o fileOptions, data T, write func(io.Writer, T) error) error {
+	return withRetry(path, o, func() error {
+		f, err := createFile(path, o)
+		if err != nil {
+			return err
+		}
+		if err := write(f, data); err != nil {
+			f.Abort()
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		return f.Close()
+	})
+}
+
+// writeCSV streams vs to path through a Batcher, so a slow destination
+// applies back-pressure instead of buffering the whole output. The profile
+// score and custom field columns follow the standard ones.
+func writeCSV(path string, o fileOptions, vs []vuln.Vulnerability, profiles, custom []string, opts output.BatchOptions) error {
+	return withRetry(path, o, func() error {
+		f, err := createFile(path, o)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		sink := output.NewCSVSink(f, profiles, custom)
+		b := output.NewBatcher(ctx, sink, opts)
+		for _, v := range vs {
+			if err = b.Add(ctx, v); err != nil {
+				break
+			}
+		}
+		if cerr := b.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = sink.Close()
+		}
+		if err != nil {
+			f.Abort()
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		return f.Close()
+	})
+}
+
+// weightProfileNames returns the names of ps, in order.
+func weightProfileNames(ps []config.Profile) []string {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// writeQuarantine writes the rows skipped while reading res to path.
+func writeQuarantine(path string, o fileOptions, res *ingest.Result) error {
+	return writeFile(path, o, res, ingest.WriteQuarantine)
+}
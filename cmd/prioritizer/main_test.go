@@ -0,0 +1,4390 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/api"
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/gate"
+	"github.com/VioletX-Dev/devsecops-test/history"
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/notify"
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/report"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/suppress"
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/tracking"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// sampleExport is the full vendor export shipped with the repository.
+const sampleExport = "../../vuln_findings_export_ACMEINC20250205a-FULL.csv"
+
+func TestRunSampleExport(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "out.json")
+	csvPath := filepath.Join(dir, "out.csv")
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{
+		"-input", sampleExport,
+		"-today", "2025-02-05",
+		"-output_json", jsonPath,
+		"-output_csv", csvPath,
+		"-batch_size", "100",
+	}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+
+	f, err := os.Open(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 1377 {
+		t.Fatalf("wrote %d findings, want 1377", len(vs))
+	}
+	for i := 1; i < len(vs); i++ {
+		if vs[i].PriorityScore > vs[i-1].PriorityScore {
+			t.Fatalf("findings not sorted at %d", i)
+		}
+	}
+	if vs[0].ActionTimeframe != "Immediate" {
+		t.Errorf("top finding timeframe = %s", vs[0].ActionTimeframe)
+	}
+
+	cf, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cf.Close()
+	rows, err := csv.NewReader(cf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1378 {
+		t.Errorf("CSV output has %d rows, want header + 1377", len(rows))
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 1392 {
+		t.Errorf("terminal output has %d lines, want header + 1377, the score histogram and the SLA summary", lines)
+	}
+	if !strings.Contains(stdout.String(), "\nPriority scores (mean 5.97):\n  0-1     0\n") || !strings.Contains(stdout.String(), "\n  5-6   496  "+strings.Repeat("#", 40)+"\n") {
+		t.Errorf("terminal output lacks the score histogram")
+	}
+	const breaches = "SLA: 245 of 1377 findings are past their due date (Critical 2, High 144, Medium 67, Low 32); the most overdue by 244 days.\n"
+	if !strings.HasSuffix(stdout.String(), "\n"+breaches) {
+		t.Errorf("terminal output does not end with the SLA summary %q", breaches)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Rows.Read != 1377 || m.Rows.Written != 1377 || len(m.Inputs) != 1 || len(m.Outputs) != 2 || len(m.Stages) != 4 || m.ConfigSHA256 == "" {
+		t.Errorf("manifest = %+v", m)
+	}
+	if h := m.Scores; h == nil || len(h.Bands) != 10 || h.Bands[5] != (scoring.Band{From: 5, To: 6, Findings: 496}) || h.Mean != 5.97 {
+		t.Errorf("manifest scores = %+v", h)
+	}
+	if m.SLA == nil || m.SLA.Tracked != 1377 || m.SLA.Breached != 245 || m.SLA.BySeverity[vuln.High] != 144 || m.SLA.MaxDaysOverdue != 244 {
+		t.Errorf("manifest SLA = %+v", m.SLA)
+	}
+	if r := m.Resources; r.DurationMS <= 0 || r.RowsPerSec <= 0 {
+		t.Errorf("resources = %+v", r)
+	}
+	if !strings.Contains(stderr.String(), `msg="run usage"`) || strings.Count(stderr.String(), `msg="stage usage"`) != 4 {
+		t.Errorf("log does not report usage:\n%s", stderr.String())
+	}
+}
+
+func TestRunWeightFlags(t *testing.T) {
+	opts, err := parseFlags([]string{"-input", "x.csv", "-critical", "9", "-aws", "0"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.weights.Severity["Critical"] != 9 || opts.weights.Source["aws"] != 0 {
+		t.Errorf("weights = %+v", opts.weights)
+	}
+	if _, err := parseFlags(nil, &bytes.Buffer{}); err == nil {
+		t.Error("missing -input: want error")
+	}
+}
+
+func TestRunSimulate(t *testing.T) {
+	proposed := filepath.Join(t.TempDir(), "proposed.json")
+	if err := os.WriteFile(proposed, []byte(`{"sla": {"Critical": 15, "High": 30, "Medium": 90, "Low": 180}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"simulate", "-input", sampleExport, "-proposed", proposed, "-today", "2025-02-05", "-json"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("simulate: %v\n%s", err, stderr.String())
+	}
+	var report struct {
+		Findings        int `json:"findings"`
+		BreachedCurrent int `json:"breached_current"`
+		DueDateChanges  int `json:"due_date_changes"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	// 245 findings in the export are past their due date on 2025-02-05.
+	if report.Findings != 1377 || report.BreachedCurrent != 245 || report.DueDateChanges == 0 {
+		t.Errorf("report = %+v", report)
+	}
+}
+
+func TestRunQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	quarantine := filepath.Join(dir, "skipped.csv")
+	in := "Identifier,CVSS,Severity\nCVE-1,5.0,Medium\nCVE-2,5.0,Severe\n"
+	if err := os.WriteFile(input, []byte(in), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", input, "-quarantine", quarantine}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	b, err := os.ReadFile(quarantine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Identifier,CVSS,Severity,error_reason\nCVE-2,5.0,Severe,\"line 3: unknown severity \"\"Severe\"\"\"\n"
+	if string(b) != want {
+		t.Errorf("quarantine =\n%s\nwant\n%s", b, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifest.FileName)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunHistorySkipsProcessedInput(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "out.csv")
+	hist := filepath.Join(dir, "history.json")
+	if err := os.WriteFile(input, []byte("Identifier,CVSS,Severity\nCVE-1,5.0,Medium\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"-input", input, "-output_csv", out, "-history", hist}
+	var stdout, stderr bytes.Buffer
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("first run: %v\n%s", err, stderr.String())
+	}
+
+	// A retry of the same input is skipped and writes nothing.
+	if err := os.Remove(out); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("retry: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "already processed at ") {
+		t.Errorf("retry output lacks the skip report:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("retry wrote the output: %v", err)
+	}
+
+	if err := run(append(args, "-force"), &stdout, &stderr); err != nil {
+		t.Fatalf("forced run: %v\n%s", err, stderr.String())
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("forced run: %v", err)
+	}
+	s, err := history.Load(hist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Runs) != 2 || s.Runs[0].InputSHA256 != s.Runs[1].InputSHA256 || s.Runs[1].Findings != 1 {
+		t.Errorf("history runs = %+v, want two runs of one input", s.Runs)
+	}
+}
+
+func TestRunEnrich(t *testing.T) {
+	dir := t.TempDir()
+	results := filepath.Join(dir, "out.csv")
+	epss := filepath.Join(dir, "epss.csv")
+	if err := os.WriteFile(epss, []byte("#model_version:v2025.03.14\ncve,epss,percentile\nCVE-2024-37890,0.5,0.9\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_csv", results}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+
+	// CSV in, JSON out; only the local EPSS source is enabled.
+	enriched := filepath.Join(dir, "enriched.json")
+	args := []string{"enrich", "-input", results, "-output", enriched, "-epss", epss, "-kev", "", "-nvd", ""}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("enrich: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(enriched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, v := range vs {
+		if v.EPSS == 0.5 {
+			n++
+		}
+	}
+	// CVE-2024-37890 appears in 38 findings; scores survive the CSV round trip.
+	if len(vs) != 1377 || n != 38 || vs[0].PriorityScore == 0 {
+		t.Errorf("%d findings, %d enriched, top score %v", len(vs), n, vs[0].PriorityScore)
+	}
+}
+
+func TestRunClassFactors(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "classes.yaml")
+	if err := os.WriteFile(cfg, []byte("weights:\n  class:\n    denial of service: 0.5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	scores := func(args ...string) map[string]vuln.Vulnerability {
+		t.Helper()
+		out := filepath.Join(dir, "out.json")
+		var stdout, stderr bytes.Buffer
+		if err := run(append([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out}, args...), &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, err := output.ReadJSON(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		byID := make(map[string]vuln.Vulnerability, len(vs))
+		for _, v := range vs {
+			byID[v.UniqueID] = v
+		}
+		return byID
+	}
+	def, halved := scores(), scores("-config", cfg)
+
+	classes := make(map[string]int)
+	for id, v := range def {
+		classes[v.Class]++
+		h := halved[id]
+		if v.Class == taxonomy.DoS && math.Abs(h.PriorityScore-v.PriorityScore/2) > 0.01 || v.Class != taxonomy.DoS && h.PriorityScore != v.PriorityScore {
+			t.Errorf("%s (%s): score %v, %v with the class factor", v.Identifier, v.Class, v.PriorityScore, h.PriorityScore)
+		}
+	}
+	want := map[string]int{"": 433, taxonomy.RCE: 360, taxonomy.PrivilegeEscalation: 38, taxonomy.InfoDisclosure: 111, taxonomy.DoS: 435}
+	if !maps.Equal(classes, want) {
+		t.Errorf("classes = %v, want %v", classes, want)
+	}
+}
+
+func TestRunScoringModels(t *testing.T) {
+	dir := t.TempDir()
+	kev := filepath.Join(dir, "kev.json")
+	if err := os.WriteFile(kev, []byte(`{"vulnerabilities": [{"cveID": "CVE-2023-45853"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	epss := filepath.Join(dir, "epss.csv")
+	if err := os.WriteFile(epss, []byte("#model_version:v2025.03.14\ncve,epss,percentile\nCVE-2024-43796,0.2,0.96\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tiers := func(args ...string) map[string]int {
+		t.Helper()
+		out := filepath.Join(dir, "out.json")
+		var stdout, stderr bytes.Buffer
+		if err := run(append([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out}, args...), &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, err := output.ReadJSON(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := make(map[string]int)
+		for _, v := range vs {
+			counts[v.ActionTimeframe]++
+			if v.ScoreIn90Days != v.PriorityScore && !slices.Contains(args, scoring.ModelWeighted) {
+				t.Errorf("%s: projected %v from %v", v.Identifier, v.ScoreIn90Days, v.PriorityScore)
+			}
+		}
+		return counts
+	}
+
+	if got, want := tiers("-scoring_model", scoring.ModelWeighted), map[string]int{"Immediate": 172, "Urgent": 455, "Scheduled": 660, "Planned": 90}; !maps.Equal(got, want) {
+		t.Errorf("weighted tiers = %v, want %v", got, want)
+	}
+	// By CVSS alone, 118 findings score 8 or more and 110 below 4.
+	if got, want := tiers("-scoring_model", scoring.ModelCVSS), map[string]int{"Immediate": 118, "Urgent": 649, "Scheduled": 500, "Planned": 110}; !maps.Equal(got, want) {
+		t.Errorf("CVSS tiers = %v, want %v", got, want)
+	}
+	// Without EPSS scores half of the blend is 0, so only CVSS 8 and up
+	// reaches Scheduled.
+	if got, want := tiers("-scoring_model", scoring.ModelCVSSEPSS), map[string]int{"Scheduled": 118, "Planned": 1259}; !maps.Equal(got, want) {
+		t.Errorf("CVSS+EPSS tiers = %v, want %v", got, want)
+	}
+	// The exploited zlib heap overflow and the express redirect that "may
+	// execute untrusted code" are classed rce, a total technical impact.
+	// Without vectors neither is automatable: the zlib one, in the KEV
+	// catalog, is Attend, and the express one, at a 20% EPSS probability,
+	// Track*.
+	if got, want := tiers("-scoring_model", scoring.ModelSSVC, "-kev", kev, "-epss", epss), map[string]int{"Urgent": 2, "Scheduled": 12, "Planned": 1363}; !maps.Equal(got, want) {
+		t.Errorf("SSVC tiers = %v, want %v", got, want)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-scoring_model", "epss"}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "-scoring_model") {
+		t.Errorf("unknown model: %v", err)
+	}
+}
+
+func TestRunNVD(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("apiKey") != "test-key" {
+			http.Error(w, "no API key", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `{"vulnerabilities": [{"cve": {"id": %q,
+			"weaknesses": [{"type": "Primary", "description": [{"lang": "en", "value": "CWE-1333"}]}],
+			"metrics": {"cvssMetricV31": [{"type": "Primary", "cvssData": {"baseScore": 5.3, "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L"}}]}}}]}`,
+			r.URL.Query().Get("cveId"))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	results := filepath.Join(dir, "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", results}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+
+	cache := filepath.Join(dir, "nvd")
+	enriched := filepath.Join(dir, "enriched.json")
+	args := []string{"enrich", "-input", results, "-output", enriched, "-epss", "", "-kev", "", "-msrc", "", "-nvd", srv.URL, "-nvd_api_key", "test-key", "-nvd_cache", cache}
+	for range 2 {
+		if err := run(args, &stdout, &stderr); err != nil {
+			t.Fatalf("enrich: %v\n%s", err, stderr.String())
+		}
+		// The 40 GitHub findings with a zero CVSS score are on five CVEs;
+		// the second run reads them from the cache.
+		if requests != 5 {
+			t.Errorf("%d NVD requests, want 5", requests)
+		}
+	}
+	b, err := os.ReadFile(enriched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, err := output.ReadJSON(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, v := range vs {
+		if v.CVSSSource == "nvd" {
+			n++
+			if v.CVSS != 5.3 || v.Source != "github" || v.CVSSVector == "" || !slices.Equal(v.CWEs, []string{"CWE-1333"}) {
+				t.Errorf("%s: %+v", v.Identifier, v)
+			}
+		}
+	}
+	if n != 40 {
+		t.Errorf("%d findings scored from the NVD, want 40", n)
+	}
+}
+
+func TestRunEgress(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "cve,epss,percentile\nCVE-2024-37890,0.5,0.9\n")
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "proxy-ca.pem")
+	if err := os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := filepath.Join(dir, "egress.yaml")
+	if err := os.WriteFile(cfg, []byte("proxy: direct\nca_bundle: "+bundle+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.json")
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out, "-epss", srv.URL + "/epss.csv"}
+
+	var stdout, stderr bytes.Buffer
+	if err := run(args, &stdout, &stderr); err == nil {
+		t.Fatal("untrusted certificate accepted")
+	}
+	if err := run(append(args, "-egress", cfg), &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, err := output.ReadJSON(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, v := range vs {
+		if v.EPSS == 0.5 {
+			n++
+		}
+	}
+	if n != 38 {
+		t.Errorf("%d findings enriched through the CA bundle, want 38", n)
+	}
+
+	if err := os.WriteFile(cfg, []byte("proxy: ftp://proxy.corp\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(append(args, "-egress", cfg), &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "ftp://proxy.corp") {
+		t.Errorf("bad proxy: %v", err)
+	}
+}
+
+func TestRunReportByEcosystem(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"report", "-input", sampleExport, "-today", "2025-02-05", "-json"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("report: %v\n%s", err, stderr.String())
+	}
+	var groups []report.Group
+	if err := json.Unmarshal(stdout.Bytes(), &groups); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int{}
+	for _, g := range groups {
+		got[g.Key] = g.Findings
+	}
+	// AWS Inspector reports OS packages; GitHub package names carry an
+	// npm- or go- prefix.
+	want := map[string]int{"os-pkg": 1120, "npm": 253, "go": 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ecosystems = %v, want %v", got, want)
+	}
+}
+
+func TestRunReportByRegion(t *testing.T) {
+	regions := filepath.Join(t.TempDir(), "regions.json")
+	if err := os.WriteFile(regions, []byte(`{"acme-inc-Education": "NA", "us-west-2": "NA"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"report", "-input", sampleExport, "-by", "region", "-regions", regions, "-json"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("report: %v\n%s", err, stderr.String())
+	}
+	var groups []report.Group
+	if err := json.Unmarshal(stdout.Bytes(), &groups); err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Key != "NA" || groups[0].Findings != 1377 {
+		t.Errorf("groups = %+v", groups)
+	}
+}
+
+func TestRunReportHierarchy(t *testing.T) {
+	org := filepath.Join(t.TempDir(), "org.yaml")
+	content := "divisions:\n  - name: Consumer\n    business_units:\n      - name: Education\n        accounts: [acme-inc-Education]\n"
+	if err := os.WriteFile(org, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"report", "-input", sampleExport, "-today", "2025-02-05", "-hierarchy", org, "-json"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("report: %v\n%s", err, stderr.String())
+	}
+	var rs []report.Rollup
+	if err := json.Unmarshal(stdout.Bytes(), &rs); err != nil {
+		t.Fatal(err)
+	}
+	// The AWS account is not in the hierarchy.
+	if len(rs) != 2 || rs[0].Key != "unassigned" || rs[0].Findings != 1120 || rs[1].Key != "Consumer" || rs[1].Findings != 257 {
+		t.Fatalf("divisions = %+v", rs)
+	}
+	if acct := rs[1].Children[0].Children[0]; acct.Level != "account" || acct.Key != "acme-inc-Education" || acct.Findings != 257 {
+		t.Errorf("account = %+v", acct)
+	}
+
+	err = run([]string{"report", "-input", sampleExport, "-hierarchy", org, "-by", "team"}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "-by cannot be used with -hierarchy") {
+		t.Errorf("-by with -hierarchy: %v", err)
+	}
+}
+
+func TestRunReportSummary(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"report", "-input", sampleExport, "-today", "2025-02-05", "-by", "asset", "-summary", "-json"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("report: %v\n%s", err, stderr.String())
+	}
+	var ss []report.Summary
+	if err := json.Unmarshal(stdout.Bytes(), &ss); err != nil {
+		t.Fatal(err)
+	}
+	if len(ss) != 16 {
+		t.Fatalf("got %d summaries, want one per asset", len(ss))
+	}
+	for _, s := range ss {
+		if s.Key != "match-v1" {
+			continue
+		}
+		// None of match-v1's AWS findings has a fixed version.
+		if !strings.HasPrefix(s.Narrative, "match-v1 has 1034 findings: 44 critical, 379 high, 534 medium and 77 low. None has a fixed version yet.") {
+			t.Errorf("match-v1 narrative = %s", s.Narrative)
+		}
+		if len(s.TopRisks) != 3 {
+			t.Errorf("top risks = %+v", s.TopRisks)
+		}
+	}
+}
+
+func TestRunComplianceReport(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, "state.json")
+	states := state.New()
+	braces := vuln.Vulnerability{Identifier: "CVE-2024-4068", AssetID: "464323181", PackageName: "npm-braces", InstalledVersion: "< 3.0.3"}
+	states.Commit(states.Plan([]vuln.Vulnerability{braces}, state.Resolved, "upgraded", "dev", time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)))
+	if err := states.Save(store); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"compliance-report", "-input", sampleExport, "-state", store, "-today", "2025-02-05", "-format", "json"}, &stdout, &stderr); err != nil {
+		t.Fatalf("compliance-report: %v\n%s", err, stderr.String())
+	}
+	var c report.Compliance
+	if err := json.Unmarshal(stdout.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	// Repeated reports count once: 1240 findings, 441 of them High. The
+	// braces finding, first detected on 2024-06-14 and due on 2024-07-14,
+	// was resolved in time.
+	high := c.Bands[1]
+	if c.Total.Findings != 1240 || high.Findings != 441 || high.WithinSLA != 381 || high.Breached != 60 ||
+		high.Resolved != 1 || high.AverageDaysToFix == nil || *high.AverageDaysToFix != 17 {
+		t.Errorf("high band %+v, total %+v", high, c.Total)
+	}
+	if c.Total.Breached != 2+60+51+26 || len(c.Evidence) != 2 || c.Evidence[1].Detail != "1 records" {
+		t.Errorf("total %+v, evidence %+v", c.Total, c.Evidence)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"compliance-report", "-input", sampleExport, "-state", store, "-today", "2025-02-05", "-format", "csv"}, &stdout, &stderr); err != nil {
+		t.Fatalf("compliance-report: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "\nHigh,-,441,381,60,0,440,1,17.0,86.4%\n") {
+		t.Errorf("csv:\n%s", stdout.String())
+	}
+}
+
+func TestRunComplianceReportCustomers(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, "state.json")
+	customers := filepath.Join(dir, "customers.yaml")
+	if err := os.WriteFile(customers, []byte(`customers:
+  - name: Acme Education
+    accounts: [acme-inc-Education]
+    sla: {Critical: 7}
+    target_adherence: 0.25
+  - name: Acme Cloud
+    accounts: ["111122223333"]
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "reports")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"compliance-report", "-input", sampleExport, "-state", store, "-today", "2025-02-05",
+		"-customers", customers, "-output_dir", out, "-format", "json"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("compliance-report: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "1120 findings are in accounts no customer lists") {
+		t.Errorf("stdout:\n%s", stdout.String())
+	}
+	b, err := os.ReadFile(filepath.Join(out, "acme-education-sla-2025-02-05.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c report.Compliance
+	if err := json.Unmarshal(b, &c); err != nil {
+		t.Fatal(err)
+	}
+	// The 120 GitHub findings, whose 6 Critical ones are due a week after
+	// their first detection; only one is still within it.
+	critical := c.Bands[0]
+	if c.Total.Findings != 120 || critical.SLADays != 7 || critical.Findings != 6 || critical.WithinSLA != 1 {
+		t.Errorf("critical band %+v, total %+v", critical, c.Total)
+	}
+	if k := c.Contract; k == nil || k.Customer != "Acme Education" || k.TargetMet == nil || !*k.TargetMet {
+		t.Errorf("contract %+v", c.Contract)
+	}
+	if c.Evidence[0].Detail != "120 of 1240 findings" {
+		t.Errorf("evidence %+v", c.Evidence)
+	}
+	if _, err := os.Stat(filepath.Join(out, "acme-cloud-sla-2025-02-05.json")); err != nil {
+		t.Errorf("no report for a customer without findings: %v", err)
+	}
+
+	if err := run([]string{"compliance-report", "-input", sampleExport, "-state", store, "-customers", customers}, &stdout, &stderr); err == nil {
+		t.Error("-customers without -output_dir ran")
+	}
+}
+
+func TestRunInspect(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"inspect", "-input", sampleExport, "-rows", "3", "-sample", "5000"}, &stdout, &stderr); err != nil {
+		t.Fatalf("inspect: %v\n%s", err, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Sampled 1377 rows (0 malformed).", "M/D/YYYY", "first_detected_date"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunShardsMerge(t *testing.T) {
+	dir := t.TempDir()
+	full := filepath.Join(dir, "full.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", full}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+
+	var parts []string
+	for i := 1; i <= 3; i++ {
+		part := filepath.Join(dir, fmt.Sprintf("part%d.json", i))
+		args := []string{"-input", sampleExport, "-today", "2025-02-05", "-shard", fmt.Sprintf("%d/3", i), "-output_json", part, "-manifest", part + ".manifest"}
+		if err := run(args, &stdout, &stderr); err != nil {
+			t.Fatalf("shard %d: %v\n%s", i, err, stderr.String())
+		}
+		parts = append(parts, part)
+	}
+	// Overlapping inputs: the full result again only adds duplicates.
+	merged := filepath.Join(dir, "merged.json")
+	if err := run(append([]string{"merge", "-output_json", merged, full}, parts...), &stdout, &stderr); err != nil {
+		t.Fatalf("merge: %v\n%s", err, stderr.String())
+	}
+
+	want, err := os.ReadFile(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("merged shards differ from the full run")
+	}
+	if _, err := parseFlags([]string{"-input", "x", "-shard", "4/3"}, &bytes.Buffer{}); err == nil {
+		t.Error("-shard 4/3: want error")
+	}
+}
+
+func TestRunDaemonWarmStart(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+	cache := filepath.Join(dir, "cache.json")
+	var stdout, stderr bytes.Buffer
+	args := []string{"daemon", "-input", sampleExport, "-today", "2025-02-05", "-output_json", out,
+		"-cycles", "2", "-interval", "0", "-cache", cache}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("daemon: %v\n%s", err, stderr.String())
+	}
+	logs := stderr.String()
+	if !strings.Contains(logs, "hits=0 misses=1377") || !strings.Contains(logs, "hits=1377 misses=0") {
+		t.Errorf("second cycle did not hit the cache:\n%s", logs)
+	}
+	if stdout.Len() != 0 {
+		t.Error("daemon printed the table")
+	}
+
+	// The daemon's output matches a one-off run.
+	want := filepath.Join(dir, "want.json")
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", want}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.ReadFile(out)
+	b, _ := os.ReadFile(want)
+	if len(a) == 0 || !bytes.Equal(a, b) {
+		t.Error("daemon output differs from a one-off run")
+	}
+	if _, err := os.Stat(cache); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunDaemonReload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "scoring.json")
+	out := filepath.Join(dir, "out.json")
+	oneOff := func(config string) []byte {
+		t.Helper()
+		want := filepath.Join(dir, "want.json")
+		args := []string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", want, "-config", config, "-post_cmd", "cat"}
+		if err := run(args, &bytes.Buffer{}, &bytes.Buffer{}); err != nil {
+			t.Fatal(err)
+		}
+		b, _ := os.ReadFile(want)
+		return b
+	}
+	// daemon runs two cycles, the first of which replaces the config with
+	// next, and returns the last cycle's output and the logs.
+	daemon := func(next string) ([]byte, string) {
+		t.Helper()
+		if err := os.WriteFile(cfg, []byte(`{"model": "weighted"}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		swap := filepath.Join(dir, "next.json")
+		if err := os.WriteFile(swap, []byte(next), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var stderr bytes.Buffer
+		args := []string{"daemon", "-input", sampleExport, "-today", "2025-02-05", "-output_json", out,
+			"-cycles", "2", "-interval", "0", "-config", cfg, "-post_cmd", "cp " + swap + " " + cfg + "; cat"}
+		if err := run(args, &bytes.Buffer{}, &stderr); err != nil {
+			t.Fatalf("daemon: %v\n%s", err, stderr.String())
+		}
+		b, _ := os.ReadFile(out)
+		return b, stderr.String()
+	}
+
+	got, logs := daemon(`{"model": "cvss"}`)
+	if !strings.Contains(logs, `msg="reloaded configuration" changed=`+cfg) {
+		t.Errorf("no reload logged:\n%s", logs)
+	}
+	if want := oneOff(filepath.Join(dir, "next.json")); len(got) == 0 || !bytes.Equal(got, want) {
+		t.Error("second cycle did not score with the reloaded config")
+	}
+
+	// A config that does not load is rejected and the last good one kept.
+	got, logs = daemon(`{"model": "cvss-typo"}`)
+	if !strings.Contains(logs, `msg="rejected reload" changed=`+cfg) || strings.Contains(logs, "reloaded configuration") {
+		t.Errorf("bad config not rejected:\n%s", logs)
+	}
+	if strings.Count(logs, `msg="cycle done"`) != 2 {
+		t.Errorf("a cycle failed:\n%s", logs)
+	}
+	os.WriteFile(cfg, []byte(`{"model": "weighted"}`), 0o644)
+	if want := oneOff(cfg); !bytes.Equal(got, want) {
+		t.Error("second cycle did not keep the last good config")
+	}
+}
+
+func TestRunXLSXInputDispatch(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "export.XLSX")
+	if err := os.WriteFile(input, []byte("Identifier,CVSS,Severity\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := run([]string{"-input", input}, &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "not an XLSX workbook") {
+		t.Errorf("err = %v, want the XLSX reader's error", err)
+	}
+}
+
+func TestRunSheetsNeedsCredentials(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	_, err := parseFlags([]string{"-input", sampleExport, "-output_sheet", "sheet-1"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "-sheets_credentials") {
+		t.Errorf("err = %v, want missing credentials", err)
+	}
+}
+
+func TestRunCustomFields(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "export.csv")
+	in := "Identifier,CVSS,Severity,Cost Center,Patch Window\n" +
+		"CVE-2024-0001,7.5,High,CC-42,Sun 02:00\n"
+	if err := os.WriteFile(input, []byte(in), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, pass := range []bool{false, true} {
+		out := filepath.Join(dir, "out.csv")
+		args := []string{"-input", input, "-today", "2025-02-05", "-output_csv", out}
+		if pass {
+			args = append(args, "-custom_fields")
+		}
+		var stdout, stderr bytes.Buffer
+		if err := run(args, &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		extra := rows[0][len(output.Columns):]
+		if !pass && len(extra) != 0 {
+			t.Errorf("without -custom_fields: extra columns %q", extra)
+		}
+		if pass && !reflect.DeepEqual(rows[1][len(output.Columns):], []string{"CC-42", "Sun 02:00"}) {
+			t.Errorf("with -custom_fields: header %q, row %q", extra, rows[1][len(output.Columns):])
+		}
+	}
+}
+
+func TestRunTemporal(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "export.csv")
+	in := "Identifier,CVSS,Severity,CVSS Vector\n" +
+		"CVE-2024-0001,9.8,Critical,CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:U/RC:R\n"
+	if err := os.WriteFile(input, []byte(in), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	scores := map[bool]float64{}
+	for _, temporal := range []bool{false, true} {
+		out := filepath.Join(dir, "out.csv")
+		args := []string{"-input", input, "-today", "2025-02-05", "-output_csv", out}
+		if temporal {
+			args = append(args, "-temporal")
+		}
+		var stdout, stderr bytes.Buffer
+		if err := run(args, &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := ingest.ReadCSV(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := res.Vulns[0]
+		if v.TemporalCVSS != 8.6 { // 9.8 × 0.91 × 0.96, rounded up
+			t.Errorf("temporal=%v: Temporal CVSS = %v, want 8.6", temporal, v.TemporalCVSS)
+		}
+		scores[temporal] = v.PriorityScore
+	}
+	if scores[true] >= scores[false] {
+		t.Errorf("score with -temporal = %v, want below the base score's %v", scores[true], scores[false])
+	}
+}
+
+func TestRunLookup(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "prioritized.csv")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_csv", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+
+	stdout.Reset()
+	if err := run([]string{"lookup", "-manifest", filepath.Join(dir, manifest.FileName), "cve-2024-4068"}, &stdout, &stderr); err != nil {
+		t.Fatalf("lookup: %v\n%s", err, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 1+26 {
+		t.Fatalf("printed %d lines, want a header and 26 findings:\n%s", len(lines), stdout.String())
+	}
+	if !strings.Contains(lines[1], "npm-braces") || !strings.Contains(lines[1], "Update npm-braces from < 3.0.3 to 3.0.3.") {
+		t.Errorf("first finding = %q", lines[1])
+	}
+
+	stdout.Reset()
+	err := run([]string{"lookup", "-results", out, "-json", "CVE-2024-4068", "CVE-0000-0000"}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "CVE-0000-0000") {
+		t.Errorf("unknown identifier: err = %v", err)
+	}
+	var vs []json.RawMessage
+	if err := json.Unmarshal(stdout.Bytes(), &vs); err != nil || len(vs) != 26 {
+		t.Errorf("-json printed %d findings (%v), want 26", len(vs), err)
+	}
+}
+
+func TestRunDependencyGraphs(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-dependency_graphs", "../../depgraph/testdata/graphs.json", "-output_json", out}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	// In the acme-inc-webhooks lockfile, express (3 findings), axios (4)
+	// and vite (6) are direct; body-parser, cookie, send (3 each) and
+	// rollup (2) are transitive. apply-frontend, by asset ID, has one
+	// transitive cookie finding.
+	if !strings.Contains(stderr.String(), "direct=13 transitive=12") {
+		t.Errorf("log does not report the classification:\n%s", stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vs {
+		if v.AssetName == "acme-inc-webhooks" && v.PackageName == "npm-cookie" && (v.Dependency != "transitive" || v.DependencyDepth != 2) {
+			t.Errorf("npm-cookie: %q at depth %d", v.Dependency, v.DependencyDepth)
+		}
+	}
+}
+
+func TestRunStateBulk(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+	store := filepath.Join(dir, "state.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+
+	// match-v1 has 77 Low findings, each with its own fingerprint.
+	set := []string{"state", "set", "-store", store, "-manifest", filepath.Join(dir, manifest.FileName),
+		"-select", "asset=match-v1&severity=low", "-to", "resolved", "-reason", "image retired", "-by", "ana"}
+	stdout.Reset()
+	if err := run(append(set, "-dry_run"), &stdout, &stderr); err != nil {
+		t.Fatalf("dry run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "dry run: would move 77 findings to resolved") {
+		t.Errorf("dry run output:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(store); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote the store: %v", err)
+	}
+
+	stdout.Reset()
+	if err := run(set, &stdout, &stderr); err != nil {
+		t.Fatalf("set: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "moved 77 findings to resolved") {
+		t.Errorf("set output:\n%s", stdout.String())
+	}
+	audit, err := os.ReadFile(filepath.Join(dir, auditFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(audit), "\n"); n != 77 || !strings.Contains(string(audit), `"by":"ana"`) {
+		t.Errorf("audit log has %d entries:\n%.300s", n, audit)
+	}
+
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-state", store, "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run -state: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := map[string]int{}
+	for _, v := range vs {
+		states[v.State]++
+	}
+	if states["resolved"] != 77 || states["open"] != 1377-77 {
+		t.Errorf("states = %v", states)
+	}
+}
+
+func TestRunStateImport(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+	tickets := filepath.Join(dir, "tickets.csv")
+	store := filepath.Join(dir, "state.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out, "-output_tickets", tickets}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	// A Jira export of the first four tickets, whose descriptions name
+	// their findings' fingerprints, and of an issue of no finding.
+	f, err := os.Open(tickets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(f).ReadAll()
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{"Summary", "Issue key", "Status", "Status Category", "Resolution", "Description"})
+	for i, st := range [][2]string{{"Done", "Done"}, {"Done", "Won't Fix"}, {"In Progress", ""}, {"Closed", "Duplicate"}} {
+		category := st[0]
+		if category == "Closed" {
+			category = "Done"
+		}
+		w.Write([]string{rows[i+1][0], fmt.Sprintf("SEC-%d", i+1), st[0], category, st[1], rows[i+1][8]})
+	}
+	w.Write([]string{"Rotate keys", "SEC-9", "Done", "Done", "Done", ""})
+	w.Flush()
+	export := filepath.Join(dir, "export.csv")
+	if err := os.WriteFile(export, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	imp := []string{"state", "import", "-store", store, "-tickets", export, "-results", out, "-map", "duplicate=resolved"}
+	stdout.Reset()
+	if err := run(append(imp, "-dry_run"), &stdout, &stderr); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "1 of 5 issues track no finding") || !strings.Contains(stdout.String(), "would change 4 findings from 4 issues") {
+		t.Errorf("dry run output:\n%s", stdout.String())
+	}
+	stdout.Reset()
+	if err := run(imp, &stdout, &stderr); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	s, err := state.Load(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range s.List() {
+		got = append(got, r.Identifier+" "+r.State+" "+r.Reason)
+	}
+	slices.Sort(got)
+	want := []string{
+		"CVE-2022-2216 risk_accepted Jira SEC-2: Done (Won't Fix)",
+		"CVE-2022-29078 in_progress Jira SEC-3: In Progress",
+		"CVE-2022-37601 resolved Jira SEC-4: Closed (Duplicate)",
+		"CVE-2024-45337 resolved Jira SEC-1: Done (Done)",
+	}
+	if !slices.Equal(got, want) || s.List()[0].ChangedBy != "jira" {
+		t.Errorf("records:\n%s", strings.Join(got, "\n"))
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "state-audit.jsonl")); err != nil || strings.Count(string(b), "\n") != 4 {
+		t.Errorf("audit log: %v\n%s", err, b)
+	}
+	// Importing again changes nothing.
+	stdout.Reset()
+	if err := run(imp, &stdout, &stderr); err != nil || !strings.Contains(stdout.String(), "no findings to change") {
+		t.Errorf("second import: %v\n%s", err, stdout.String())
+	}
+	if err := run([]string{"state", "import", "-store", store, "-results", out}, &stdout, &stderr); err == nil {
+		t.Error("import without -tickets or -jira: want error")
+	}
+	if err := run(append(imp, "-map", "duplicate=closed"), &stdout, &stderr); err == nil {
+		t.Error("-map to an unknown state: want error")
+	}
+}
+
+func TestRunSample(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+	store := filepath.Join(dir, "state.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	draw := []string{"sample", "draw", "-store", store, "-results", out, "-n", "10", "-seed", "7", "-by", "ana"}
+	stdout.Reset()
+	if err := run(append(draw, "-dry_run"), &stdout, &stderr); err != nil {
+		t.Fatalf("dry run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "dry run: would sample 10 findings (seed 7)") {
+		t.Errorf("dry run output:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(store); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote the store: %v", err)
+	}
+
+	// The second draw leaves out the findings the first drew.
+	for range 2 {
+		if err := run(draw, &stdout, &stderr); err != nil {
+			t.Fatalf("draw: %v\n%s", err, stderr.String())
+		}
+	}
+	s, err := state.Load(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := s.SampleList()
+	if len(samples) != 20 {
+		t.Fatalf("%d samples, want 20", len(samples))
+	}
+
+	verify := []string{"sample", "verify", "-store", store, "-verdict", "false_positive", "-note", "not installed", "-by", "bo", samples[0].Fingerprint[:12]}
+	if err := run(verify, &stdout, &stderr); err != nil {
+		t.Fatalf("verify: %v\n%s", err, stderr.String())
+	}
+	// Workflow state changes keep the samples.
+	set := []string{"state", "set", "-store", store, "-results", out, "-select", "asset=hasura", "-to", "resolved", "-reason", "retired", "-audit", filepath.Join(dir, "audit.jsonl")}
+	if err := run(set, &stdout, &stderr); err != nil {
+		t.Fatalf("set: %v\n%s", err, stderr.String())
+	}
+	stdout.Reset()
+	if err := run([]string{"sample", "list", "-store", store, "-pending", "-json"}, &stdout, &stderr); err != nil {
+		t.Fatalf("list: %v\n%s", err, stderr.String())
+	}
+	var list struct {
+		Samples  []state.Sample   `json:"samples"`
+		Accuracy []state.Accuracy `json:"accuracy"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Samples) != 19 {
+		t.Errorf("%d pending samples, want 19", len(list.Samples))
+	}
+	fps := 0
+	for _, a := range list.Accuracy {
+		fps += a.FalsePositives
+		if a.Source == samples[0].Source && (a.FalsePositives != 1 || a.Precision != 0) {
+			t.Errorf("%s accuracy = %+v", a.Source, a)
+		}
+	}
+	if fps != 1 {
+		t.Errorf("accuracy = %+v", list.Accuracy)
+	}
+
+	if err := run([]string{"sample", "verify", "-store", store, "-verdict", "maybe", samples[1].Fingerprint}, &stdout, &stderr); err == nil {
+		t.Error("verify -verdict maybe: ran")
+	}
+}
+
+func TestRunServeNeedsResults(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"serve", "-manifest", filepath.Join(t.TempDir(), manifest.FileName)}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "no -results given") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestServePrioritize(t *testing.T) {
+	s := api.NewServer(nil)
+	s.HandlePrioritize(uploadPrioritizer(config.Default(), slog.New(slog.NewTextHandler(io.Discard, nil))))
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	post := func(query string, body []byte) ([]vuln.Vulnerability, *http.Response) {
+		t.Helper()
+		resp, err := http.Post(ts.URL+"/prioritize"+query, "application/octet-stream", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var vs []vuln.Vulnerability
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&vs); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return vs, resp
+	}
+	export, err := os.ReadFile(sampleExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs, resp := post("?today=2025-02-05", export)
+	if len(vs) != 1377 || resp.Header.Get("X-Skipped-Rows") != "0" {
+		t.Fatalf("CSV upload: %d findings, %s skipped", len(vs), resp.Header.Get("X-Skipped-Rows"))
+	}
+	for i := 1; i < len(vs); i++ {
+		if vs[i].PriorityScore > vs[i-1].PriorityScore {
+			t.Fatalf("findings not sorted at %d", i)
+		}
+	}
+
+	// Results posted back are scored again, here at a later date.
+	results, err := json.Marshal(vs[:5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := post("?today=2026-02-05", results); len(again) != 5 {
+		t.Errorf("JSON upload: %d findings, want 5", len(again))
+	}
+	grype, err := os.ReadFile("../../ingest/testdata/grype.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vs, resp := post("", grype); len(vs) != 4 || resp.Header.Get("X-Skipped-Rows") != "2" {
+		t.Errorf("Grype upload: %d findings, %s skipped", len(vs), resp.Header.Get("X-Skipped-Rows"))
+	}
+	for _, bad := range []struct{ query, body string }{
+		{"?format=nessus", "Identifier\n"},
+		{"", `{"unknown": true}`},
+	} {
+		if _, resp := post(bad.query, []byte(bad.body)); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("POST %s %s: status %d, want 400", bad.query, bad.body, resp.StatusCode)
+		}
+	}
+}
+
+func TestServeIngest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var logs bytes.Buffer
+	s := api.NewServer(nil)
+	s.HandleIngest(ctx, uploadIngester(config.Default(), slog.New(slog.NewTextHandler(&logs, nil))))
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	export, err := os.Open(sampleExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer export.Close()
+	resp, err := http.Post(ts.URL+"/ingest?today=2025-02-05", "text/csv", export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job api.Job
+	err = json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST /ingest: %d, %v", resp.StatusCode, err)
+	}
+	get := func(path string, v any) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for deadline := time.Now().Add(30 * time.Second); job.Status != api.JobDone && job.Status != api.JobFailed && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		get("/jobs/"+job.ID, &job)
+	}
+	if job.Status != api.JobDone || job.Findings != 1377 || job.ReadBytes != job.Bytes {
+		t.Fatalf("job %+v", job)
+	}
+	var page api.Page
+	get(job.Results+"?severity=Critical&limit=5", &page)
+	if page.Total != 51 || len(page.Findings) != 5 || page.Findings[0].PriorityScore < page.Findings[4].PriorityScore {
+		t.Errorf("job findings: %d of %d", len(page.Findings), page.Total)
+	}
+	if !strings.Contains(logs.String(), `msg="ingested upload" bytes=`) {
+		t.Errorf("log lacks the job:\n%s", logs.String())
+	}
+}
+
+func TestRunPatchWindows(t *testing.T) {
+	dir := t.TempDir()
+	windows := filepath.Join(dir, "windows.json")
+	if err := os.WriteFile(windows, []byte(`{"*": "3rd Saturday monthly"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.json")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-patch_windows", windows, "-output_json", out}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The next window is 2025-02-15; 679 of the 1,377 findings are due
+	// before it.
+	emergency := 0
+	for _, v := range vs {
+		if v.NextPatchOpportunity.String() != "2025-02-15" {
+			t.Fatalf("next patch opportunity = %s", v.NextPatchOpportunity)
+		}
+		if v.EmergencyChange {
+			emergency++
+		}
+	}
+	if emergency != 679 {
+		t.Errorf("%d emergency changes, want 679", emergency)
+	}
+	if !strings.Contains(stderr.String(), "emergency_changes=679") {
+		t.Errorf("log does not report the count:\n%s", stderr.String())
+	}
+}
+
+func TestRunHeatmap(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"heatmap", "-input", sampleExport, "-today", "2025-02-05", "-packages", "3", "-format", "csv"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sample export covers 16 assets; its most common packages are
+	// linux (479 findings), imagemagick (58) and tiff (55).
+	if want := []string{"Asset", "Findings", "Max Score", "linux", "imagemagick", "tiff"}; !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("header = %q, want %q", rows[0], want)
+	}
+	if len(rows) != 17 {
+		t.Errorf("got %d rows, want header + 16 assets", len(rows))
+	}
+	total := 0
+	for _, r := range rows[1:] {
+		n, _ := strconv.Atoi(r[1])
+		total += n
+	}
+	if total != 1377 {
+		t.Errorf("findings across rows = %d, want 1377", total)
+	}
+}
+
+func TestRunReviewQueue(t *testing.T) {
+	dir := t.TempDir()
+	review := filepath.Join(dir, "review.csv")
+	out := filepath.Join(dir, "out.json")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-demote", "-review_queue", review, "-output_json", out}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	// All 479 linux kernel findings are in the match-v1 ECR image, and 194
+	// of them score 6 or more before demotion.
+	f, err := os.Open(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := ingest.ReadCSV(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 194 {
+		t.Errorf("review queue has %d findings, want 194", len(res.Vulns))
+	}
+	for _, v := range res.Vulns {
+		if v.PackageName != "linux" || v.PriorityScore < 6 || v.Confidence != 0.3 {
+			t.Fatalf("unexpected review item %+v", v)
+		}
+	}
+
+	g, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	vs, err := output.ReadJSON(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	demoted := 0
+	for _, v := range vs {
+		switch {
+		case v.PackageName == "linux" && strings.HasPrefix(v.AssetID, "arn:aws:ecr:"):
+			if v.PriorityScore > 3 || !reflect.DeepEqual(v.NoiseReasons, []string{"kernel-in-container-image"}) {
+				t.Fatalf("kernel finding not demoted: %+v", v)
+			}
+			demoted++
+		case v.Confidence != 1:
+			t.Fatalf("confidence %v, want 1: %+v", v.Confidence, v)
+		}
+	}
+	if demoted != 479 {
+		t.Errorf("demoted %d findings, want 479", demoted)
+	}
+}
+
+func TestRunFalsePositives(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, "fp.json")
+	var stdout, stderr bytes.Buffer
+	// Unique ID 3 is CVE-2024-4068 in npm-braces; five other rows (4, 6,
+	// 7, 8 and 48) share its fingerprint.
+	err := run([]string{"fp", "mark", "-store", store, "-input", sampleExport, "-reason", "braces is build-time only", "-by", "alice", "3"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("mark: %v\n%s", err, stderr.String())
+	}
+	if strings.Count(stdout.String(), "marked ") != 1 {
+		t.Errorf("mark output:\n%s", stdout.String())
+	}
+	if err := run([]string{"fp", "mark", "-store", store, "-input", sampleExport, "-reason", "x", "no-such-id"}, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Error("marking an unknown unique ID: want error")
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if err := run([]string{"fp", "rate", "-store", store, "-input", sampleExport, "-json"}, &stdout, &stderr); err != nil {
+		t.Fatalf("rate: %v\n%s", err, stderr.String())
+	}
+	var rates []suppress.Rate
+	if err := json.Unmarshal(stdout.Bytes(), &rates); err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 || rates[0] != (suppress.Rate{Source: "github", Findings: 257, FalsePositives: 6, Rate: 0.0233, Reliability: 0.9775}) {
+		t.Errorf("rates = %+v", rates)
+	}
+
+	out := filepath.Join(dir, "out.json")
+	stderr.Reset()
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-suppressions", store, "-output_json", out}, &bytes.Buffer{}, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	b, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Rows.Suppressed != 6 || m.Rows.Written != 1371 {
+		t.Errorf("manifest rows = %+v, want 6 suppressed and 1371 written", m.Rows)
+	}
+	if !strings.Contains(stderr.String(), "source=github findings=257 false_positives=6 rate=0.0233") {
+		t.Errorf("log has no FP rate metric:\n%s", stderr.String())
+	}
+
+	// -source_reliability scales GitHub findings by 1 - 6/(257+10).
+	reliable := filepath.Join(dir, "reliable.json")
+	stderr.Reset()
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-suppressions", store, "-source_reliability", "-output_json", reliable}, &bytes.Buffer{}, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "source reliability\" source=github factor=0.9775") {
+		t.Errorf("log has no reliability factor:\n%s", stderr.String())
+	}
+	read := func(path string) []vuln.Vulnerability {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, err := output.ReadJSON(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vs
+	}
+	before, after := read(out), read(reliable)
+	scores := make(map[string]float64, len(before))
+	for _, v := range before {
+		scores[v.UniqueID] = v.PriorityScore
+	}
+	for _, v := range after {
+		want := scores[v.UniqueID]
+		if v.Source == "github" {
+			want *= 0.9775
+		}
+		if math.Abs(v.PriorityScore-want) > 0.01 {
+			t.Fatalf("%s finding %s scores %v, want %v", v.Source, v.UniqueID, v.PriorityScore, want)
+		}
+	}
+	if err := run([]string{"-input", sampleExport, "-source_reliability"}, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Error("-source_reliability without -suppressions: want error")
+	}
+
+	var entries []suppress.Entry
+	stdout.Reset()
+	if err := run([]string{"fp", "list", "-store", store, "-json"}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil || len(entries) != 1 || entries[0].MarkedBy != "alice" {
+		t.Fatalf("entries = %+v, %v", entries, err)
+	}
+	if err := run([]string{"fp", "unmark", "-store", store, entries[0].Fingerprint}, &bytes.Buffer{}, &stderr); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunSelftest(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"selftest", "-rows", "40"}, &stdout, &stderr); err != nil {
+		t.Fatalf("selftest: %v\n%s%s", err, stdout.String(), stderr.String())
+	}
+	if n := strings.Count(stdout.String(), "PASS"); n != 6 {
+		t.Errorf("%d cases passed, want 6:\n%s", n, stdout.String())
+	}
+}
+
+func TestRunEndOfLife(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-eol", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// match-v1's packages are Debian 10 builds, past support since
+	// 2024-06-30; sst-asset is on Debian 12 and the other two assets on
+	// Ubuntu 22.04.
+	hostOS := make(map[string]string)
+	eolFindings, added := 0, 0
+	for _, v := range vs {
+		if v.Source == "aws" {
+			hostOS[v.AssetName] = v.OS
+		}
+		if v.OSEndOfLife {
+			eolFindings++
+		}
+		if v.Identifier == "EOL-DEBIAN-10" {
+			added++
+		}
+	}
+	if hostOS["match-v1"] != "Debian 10" || hostOS["sst-asset"] != "Debian 12" {
+		t.Errorf("OS by asset = %v", hostOS)
+	}
+	if added != 1 || eolFindings != 1035 {
+		t.Errorf("added %d EoL findings, %d flagged; want 1 and 1,035", added, eolFindings)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"report", "-input", sampleExport, "-today", "2025-02-05"}, &stdout, &stderr); err != nil {
+		t.Fatalf("report: %v\n%s", err, stderr.String())
+	}
+	if !regexp.MustCompile(`match-v1 +Debian 10 +2024-06-30 +1034 +1034`).MatchString(stdout.String()) {
+		t.Errorf("report lacks the end-of-life asset:\n%s", stdout.String())
+	}
+}
+
+func TestRunRemediationHints(t *testing.T) {
+	dir := t.TempDir()
+	templates := filepath.Join(dir, "templates.yaml")
+	if err := os.WriteFile(templates, []byte("apt: \"sudo apt-get install --only-upgrade {{.Package}}\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-eol", "-remediation_templates", templates, "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The AWS findings have no remediation. The 86 on the Debian 12 and
+	// Ubuntu 22.04 assets get an apt command; the Debian 10 ones get none,
+	// as no update will come.
+	hinted := make(map[string]int)
+	for _, v := range vs {
+		if v.Source != "aws" {
+			continue
+		}
+		if v.Remediation == "sudo apt-get install --only-upgrade "+v.PackageName {
+			hinted[v.OSFamily+" "+v.OSVersion]++
+		} else if v.OS != "Debian 10" {
+			t.Errorf("%s on %s (%s): remediation %q", v.Identifier, v.AssetName, v.OS, v.Remediation)
+		}
+	}
+	if want := map[string]int{"debian 12": 7, "ubuntu 22.04": 79}; !maps.Equal(hinted, want) {
+		t.Errorf("hinted = %v, want %v", hinted, want)
+	}
+	if !strings.Contains(stderr.String(), `msg="filled in platform remediations" findings=86`) {
+		t.Errorf("log lacks the count:\n%s", stderr.String())
+	}
+
+	if err := os.WriteFile(templates, []byte("pacman: \"pacman -S {{.Package}}\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"-input", sampleExport, "-remediation_templates", templates, "-output_json", out}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "unknown package manager") {
+		t.Errorf("unknown package manager: %v", err)
+	}
+}
+
+func TestRunProfiles(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.csv")
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-profiles", "current=default,../../config/testdata/proposed.json", "-output_csv", out}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := rows[0]
+	if got := header[len(header)-2:]; got[0] != "Priority Score (current)" || got[1] != "Priority Score (proposed)" {
+		t.Fatalf("profile columns = %q", got)
+	}
+	score := slices.Index(header, "Priority Score")
+	changed := 0
+	for _, row := range rows[1:] {
+		// The default profile scores as the run does without weight flags.
+		if row[len(row)-2] != row[score] {
+			t.Fatalf("current profile scored %s, run scored %s", row[len(row)-2], row[score])
+		}
+		if row[len(row)-1] != row[score] {
+			changed++
+		}
+	}
+	if changed == 0 {
+		t.Error("the proposed profile changed no score")
+	}
+}
+
+func TestRunDaemonNotifyDigest(t *testing.T) {
+	dir := t.TempDir()
+	sent := filepath.Join(dir, "sent.jsonl")
+	channels := filepath.Join(dir, "channels.json")
+	if err := os.WriteFile(channels, []byte(`{"channels": [{"name": "team", "target": "`+sent+`", "digest": "hourly", "min_severity": "High"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	notified := filepath.Join(dir, "notify-state.json")
+	var stdout, stderr bytes.Buffer
+	args := []string{"daemon", "-input", sampleExport, "-today", "2025-02-05", "-cycles", "2", "-interval", "0",
+		"-notify", channels, "-notify_state", notified}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("daemon: %v\n%s", err, stderr.String())
+	}
+	// Both cycles fall within the hour: the first sends a digest of every
+	// High and Critical finding, the second has nothing new to send.
+	b, err := os.ReadFile(sent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("sent %d messages, want one digest", len(lines))
+	}
+	var m notify.Message
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Kind != notify.Digest || len(m.Findings) == 0 {
+		t.Fatalf("sent %s of %d findings", m.Kind, len(m.Findings))
+	}
+	for _, it := range m.Findings {
+		if it.Severity != vuln.Critical && it.Severity != vuln.High {
+			t.Fatalf("digest includes a %s finding", it.Severity)
+		}
+	}
+	if !strings.Contains(stderr.String(), "queued=0 immediate=0 digests=0") {
+		t.Errorf("second cycle notified again:\n%s", stderr.String())
+	}
+	if _, err := os.Stat(notified); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunDaemonQueuesFailedNotifications(t *testing.T) {
+	up := false
+	received := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			http.Error(w, "maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		received++
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	channels := filepath.Join(dir, "channels.json")
+	if err := os.WriteFile(channels, []byte(`{"channels": [{"name": "hook", "target": "`+srv.URL+`", "min_severity": "Critical"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	queue := filepath.Join(dir, "queue.json")
+	var stdout, stderr bytes.Buffer
+	args := []string{"daemon", "-input", sampleExport, "-today", "2025-02-05", "-cycles", "1", "-notify", channels, "-queue", queue}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("daemon: %v\n%s", err, stderr.String())
+	}
+	// The outage fails neither the cycle nor the digest: it waits in the
+	// queue for a retry.
+	if !strings.Contains(stderr.String(), "cycle done") || !strings.Contains(stderr.String(), "delivered=0 failed=1") {
+		t.Fatalf("daemon log:\n%s", stderr.String())
+	}
+	stdout.Reset()
+	if err := run([]string{"queue", "list", "-store", queue}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`job-1 +pending +notify +` + regexp.QuoteMeta(srv.URL) + ` +1 .*503`).MatchString(stdout.String()) {
+		t.Errorf("queue list:\n%s", stdout.String())
+	}
+
+	up = true
+	if err := run([]string{"queue", "deliver", "-store", queue}, &stdout, &stderr); err == nil {
+		t.Error("deliver sent a job waiting for its retry")
+	}
+	if err := run([]string{"queue", "deliver", "-all", "-store", queue}, &stdout, &stderr); err != nil {
+		t.Fatalf("deliver -all: %v\n%s", err, stderr.String())
+	}
+	if received != 1 {
+		t.Errorf("webhook received %d messages, want the queued digest", received)
+	}
+}
+
+func TestRunOutputMode(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.csv")
+	// An existing, more open file is tightened too.
+	if err := os.WriteFile(out, nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-output_mode", "0600",
+		"-output_csv", out, "-output_json", filepath.Join(dir, "out.json"), "-quarantine", filepath.Join(dir, "skipped.csv")}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	for _, name := range []string{"out.csv", "out.json", "skipped.csv", manifest.FileName} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("%s has mode %v, want 0600", name, info.Mode().Perm())
+		}
+	}
+	for _, mode := range []string{"rw", "1777", "0999"} {
+		if _, err := parseFlags([]string{"-input", sampleExport, "-output_mode", mode}, &stderr); err == nil {
+			t.Errorf("-output_mode %s accepted", mode)
+		}
+	}
+}
+
+func TestWriteFileRetries(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.csv")
+	if err := os.WriteFile(out, []byte("previous run\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	o := fileOptions{mode: 0o640, retry: retryPolicy{retries: 2}, logger: slog.New(slog.NewTextHandler(&log, nil))}
+	calls := 0
+	// An NFS server that goes away for two writes.
+	flaky := func(failures int, err error) func(io.Writer, string) error {
+		calls = 0
+		return func(w io.Writer, s string) error {
+			calls++
+			io.WriteString(w, "part of "+s)
+			if calls <= failures {
+				return &os.PathError{Op: "write", Path: out, Err: err}
+			}
+			_, err := io.WriteString(w, ", all of it\n")
+			return err
+		}
+	}
+	left := func() []string {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+	contents := func() string {
+		t.Helper()
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	if err := writeFile(out, o, "this run", flaky(2, syscall.EIO)); err != nil || calls != 3 {
+		t.Fatalf("writeFile: %v after %d calls", err, calls)
+	}
+	if got := contents(); got != "part of this run, all of it\n" {
+		t.Errorf("out.csv = %q", got)
+	}
+	if !strings.Contains(log.String(), `msg="retrying output write" path=`+out+" attempt=3") {
+		t.Errorf("log lacks the retries:\n%s", log.String())
+	}
+	if info, _ := os.Stat(out); info.Mode().Perm() != 0o640 {
+		t.Errorf("mode %v, want 0640", info.Mode().Perm())
+	}
+
+	// A write that keeps failing, or fails for good, leaves the previous
+	// file and nothing else.
+	for _, tt := range []struct {
+		err   error
+		calls int
+	}{{syscall.EIO, 3}, {syscall.ENOSPC, 1}} {
+		if err := writeFile(out, o, "another run", flaky(5, tt.err)); !errors.Is(err, tt.err) || calls != tt.calls {
+			t.Errorf("%v: writeFile = %v after %d calls, want %d", tt.err, err, calls, tt.calls)
+		}
+		if got := contents(); got != "part of this run, all of it\n" {
+			t.Errorf("%v: out.csv = %q", tt.err, got)
+		}
+		if names := left(); !slices.Equal(names, []string{"out.csv"}) {
+			t.Errorf("%v: left %q", tt.err, names)
+		}
+	}
+
+	// A link is followed and kept.
+	link := filepath.Join(dir, "latest.csv")
+	if err := os.Symlink(out, link); err != nil {
+		t.Skip(err)
+	}
+	if err := writeFile(link, o, "a link", flaky(0, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("latest.csv is no longer a link: %v", err)
+	}
+	if got := contents(); got != "part of a link, all of it\n" {
+		t.Errorf("out.csv = %q", got)
+	}
+}
+
+func TestRunReadOnly(t *testing.T) {
+	var stderr bytes.Buffer
+	for _, args := range [][]string{
+		{"-input", sampleExport, "-read_only", "-history", "history.json"},
+		{"-input", sampleExport, "-read_only", "-output_sheet", "sheet-1", "-sheets_credentials", "key.json"},
+	} {
+		if _, err := parseFlags(args, &stderr); err == nil || !strings.Contains(err.Error(), "-read_only") {
+			t.Errorf("parseFlags(%q) = %v, want a read-only error", args, err)
+		}
+	}
+	dir := t.TempDir()
+	queue := filepath.Join(dir, "queue.json")
+	if err := run([]string{"daemon", "-input", sampleExport, "-read_only", "-cycles", "1", "-queue", queue}, io.Discard, &stderr); err == nil {
+		t.Error("daemon accepted -queue in read-only mode")
+	}
+
+	// The environment variable holds for every command.
+	t.Setenv(readOnlyVar, "1")
+	store := filepath.Join(dir, "fp.json")
+	err := run([]string{"fp", "mark", "-store", store, "-reason", "test", strings.Repeat("a", 64)}, io.Discard, &stderr)
+	if err == nil || !strings.Contains(err.Error(), readOnlyVar) {
+		t.Errorf("fp mark = %v, want a read-only error", err)
+	}
+	if _, err := os.Stat(store); !os.IsNotExist(err) {
+		t.Errorf("fp mark wrote the store: %v", err)
+	}
+	opts, err := parseFlags([]string{"-input", sampleExport}, &stderr)
+	if err != nil || !opts.readOnly {
+		t.Errorf("parseFlags with %s set: read-only %v, %v", readOnlyVar, opts != nil && opts.readOnly, err)
+	}
+}
+
+func TestRunXLSX(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.xlsx")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_xlsx", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ingest.ReadXLSX(f, info.Size(), output.FindingsSheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 1377 || len(res.Skipped) != 0 || res.Vulns[0].Identifier != "CVE-2024-45337" || res.Vulns[0].PriorityScore != 9.34 {
+		t.Errorf("read back %d findings, skipped %d, first %+v", len(res.Vulns), len(res.Skipped), res.Vulns[0])
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifest.FileName)); err != nil {
+		t.Errorf("no manifest beside the workbook: %v", err)
+	}
+}
+
+func TestRunImageDigests(t *testing.T) {
+	dir := t.TempDir()
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	input := filepath.Join(dir, "images.csv")
+	if err := os.WriteFile(input, []byte("Asset name,Asset id,Identifier,CVSS,Severity,Package Name,Installed Version,Image Digest,Image Tags\n"+
+		"api,arn:aws:ecr:us-west-2:253710526682:repository/api,CVE-2023-1,9.8,Critical,openssl,3.0.2,"+strings.ToUpper(digest)+",latest\n"+
+		"api,arn:aws:ecr:us-west-2:253710526682:repository/api,CVE-2023-1,9.8,Critical,openssl,3.0.2,"+digest+",v1.4\n"+
+		"api,arn:aws:ecr:us-west-2:253710526682:repository/api,CVE-2023-2,5.0,Medium,zlib,1.2.13,,v1.4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", input, "-today", "2025-02-05", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asset := "253710526682.dkr.ecr.us-west-2.amazonaws.com/api@" + digest
+	if len(vs) != 2 || vs[0].AssetID != asset || vs[1].AssetID != asset || strings.Join(vs[1].ImageTags, ",") != "latest,v1.4" {
+		t.Fatalf("results %+v", vs)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Rows.Read != 3 || m.Rows.Collapsed != 1 || m.Rows.Written != 2 {
+		t.Errorf("manifest rows %+v", m.Rows)
+	}
+
+	stdout.Reset()
+	if err := run([]string{"report", "-input", input, "-today", "2025-02-05"}, &stdout, &stderr); err != nil {
+		t.Fatalf("report: %v\n%s", err, stderr.String())
+	}
+	if !regexp.MustCompile(`api +sha256:abababababab +latest, v1.4 +2 +1 +0`).MatchString(stdout.String()) {
+		t.Errorf("report lacks the vulnerable image:\n%s", stdout.String())
+	}
+}
+
+func TestRunGroupAssets(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "clones.csv")
+	if err := os.WriteFile(input, []byte("Asset name,Asset id,Identifier,CVSS,Severity,Package Name,Installed Version,First detected date\n"+
+		"web-asg,i-0aaa,CVE-2023-1,9.8,Critical,openssl,3.0.2,1/20/2025\n"+
+		"web-asg,i-0bbb,CVE-2023-1,9.8,Critical,openssl,3.0.2,1/10/2025\n"+
+		"web-asg,i-0bbb,CVE-2023-2,5.0,Medium,zlib,1.2.13,1/10/2025\n"+
+		"db,i-0ccc,CVE-2023-1,9.8,Critical,openssl,3.0.2,1/10/2025\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.csv")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", input, "-today", "2025-02-05", "-group_assets", "-output_csv", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `msg="grouped asset instances" assets=1 instances=2 findings=1`) {
+		t.Errorf("log lacks the grouping:\n%s", stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := make(map[string]int)
+	for i, h := range rows[0] {
+		col[h] = i
+	}
+	got := make(map[string]string)
+	for _, r := range rows[1:] {
+		got[r[col["Identifier"]]+" "+r[col["Asset name"]]] = r[col["Asset id"]] + " " + r[col["Instances"]] + " " + r[col["Instance IDs"]] + " " + r[col["First detected date"]]
+	}
+	want := map[string]string{
+		"CVE-2023-1 web-asg": "web-asg 2 i-0aaa; i-0bbb 2025-01-10",
+		"CVE-2023-2 web-asg": "web-asg 1 i-0bbb 2025-01-10",
+		"CVE-2023-1 db":      "i-0ccc   2025-01-10",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("findings %v, want %v", got, want)
+	}
+
+	// The grouped results read back as they were written.
+	if err := run([]string{"-input", out, "-today", "2025-02-05", "-group_assets", "-output_csv", filepath.Join(dir, "again.csv")}, &stdout, &stderr); err != nil {
+		t.Fatalf("rerun: %v\n%s", err, stderr.String())
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "again.csv")); len(strings.Split(strings.TrimSpace(string(b)), "\n")) != 4 || !strings.Contains(string(b), "i-0aaa; i-0bbb") {
+		t.Errorf("regrouped results:\n%s", b)
+	}
+
+	if err := run([]string{"-input", input, "-asset_pattern", "^web-[0-9]+$"}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "-asset_pattern") {
+		t.Errorf("pattern without a subexpression: %v", err)
+	}
+}
+
+func TestRunAWS(t *testing.T) {
+	page, err := os.ReadFile("../../ingest/testdata/inspector.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pulls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pulls++
+		if r.URL.Path != "/findings/list" || !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIAEXAMPLE/") {
+			t.Errorf("%s %s, Authorization %s", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+		}
+		w.Write(page)
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "none"))
+
+	out := filepath.Join(dir, "out.json")
+	hist := filepath.Join(dir, "history.json")
+	args := []string{"-input", "aws:inspector2", "-aws_region", "us-west-2", "-aws_endpoint", srv.URL, "-today", "2025-02-05", "-output_json", out, "-history", hist, "-manifest", filepath.Join(dir, "m.json")}
+	var stdout, stderr bytes.Buffer
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `msg="pulled input" input=aws:inspector2 region=us-west-2 pages=1 findings=4 skipped=1`) {
+		t.Errorf("log lacks the pull:\n%s", stderr.String())
+	}
+	vs, err := readResults(out, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 4 || vs[0].Identifier != "CVE-2024-45337" || vs[0].Source != "aws" || vs[0].AssetName != "acme-inc-lambda" {
+		t.Errorf("findings %+v", vs)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "m.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Inputs) != 1 || m.Inputs[0].Path != "aws:inspector2" || m.Inputs[0].Bytes != int64(len(page)) || len(m.Inputs[0].SHA256) != 64 {
+		t.Errorf("manifest inputs %+v", m.Inputs)
+	}
+
+	// The same findings pulled again are skipped, as an input processed.
+	stdout.Reset()
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("rerun: %v\n%s", err, stderr.String())
+	}
+	if pulls != 2 || !strings.Contains(stdout.String(), "aws:inspector2 already processed at ") {
+		t.Errorf("%d pulls, rerun output:\n%s", pulls, stdout.String())
+	}
+
+	for _, tt := range []struct{ args []string }{
+		{[]string{"-input", "aws:guardduty"}},
+		{[]string{"-input", "aws:inspector2", "-format", "csv"}},
+		{[]string{"-input", "export.csv", "-aws_region", "us-west-2"}},
+	} {
+		if err := run(tt.args, &stdout, &stderr); err == nil {
+			t.Errorf("%v: no error", tt.args)
+		}
+	}
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	if err := run([]string{"-input", "aws:securityhub"}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "no region") {
+		t.Errorf("no region: %v", err)
+	}
+}
+
+func TestRunGitHub(t *testing.T) {
+	page, err := os.ReadFile("../../ingest/testdata/dependabot.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pulls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghp_example" {
+			t.Errorf("GET %s, Authorization %s", r.URL, r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/orgs/acme-inc-Education/dependabot/alerts":
+			pulls++
+			w.Write(page)
+		case "/orgs/acme-inc-Education/code-scanning/alerts":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "no analysis found"}`)
+		default:
+			t.Errorf("GET %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	t.Setenv("GITHUB_TOKEN", "ghp_example")
+
+	out := filepath.Join(dir, "out.json")
+	hist := filepath.Join(dir, "history.json")
+	args := []string{"-input", sampleExport, "-github_repos", "acme-inc-Education", "-github_api", srv.URL, "-today", "2025-02-05", "-output_json", out, "-history", hist, "-manifest", filepath.Join(dir, "m.json")}
+	var stdout, stderr bytes.Buffer
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	for _, want := range []string{
+		`msg="pulled input" input="github:acme-inc-Education dependabot" pages=1 findings=2 skipped=1`,
+		`msg="pulled input" input="github:acme-inc-Education code_scanning" pages=0 findings=0 skipped=0`,
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("log lacks %s:\n%s", want, stderr.String())
+		}
+	}
+	vs, err := readResults(out, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The alerts are scored with the export's findings.
+	var exported, alerts int
+	for _, v := range vs {
+		switch {
+		case v.Identifier == "GHSA-q2x7-8rv6-6q7h" && v.AssetName == "course-api":
+			alerts++
+		case strings.HasPrefix(v.UniqueID, "https://github.com/acme-inc-Education/apply-frontend/security/dependabot/"):
+			alerts++
+		default:
+			exported++
+		}
+	}
+	if exported != 1377 || alerts != 2 {
+		t.Errorf("%d exported findings, %d alerts", exported, alerts)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "m.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Inputs) != 2 || m.Inputs[0].Path != "github:acme-inc-Education" || m.Inputs[0].Bytes != int64(len(page)) || m.Inputs[1].Path != sampleExport {
+		t.Errorf("manifest inputs %+v", m.Inputs)
+	}
+
+	// The same export and alerts are skipped, as an input processed.
+	stdout.Reset()
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("rerun: %v\n%s", err, stderr.String())
+	}
+	if pulls != 2 || !strings.Contains(stdout.String(), sampleExport+",github:acme-inc-Education already processed at ") {
+		t.Errorf("%d pulls, rerun output:\n%s", pulls, stdout.String())
+	}
+
+	// Alerts alone need no -input.
+	if err := run([]string{"-github_repos", "acme-inc-Education", "-github_api", srv.URL, "-github_alerts", "dependabot", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run without -input: %v", err)
+	}
+	if vs, err := readResults(out, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil || len(vs) != 2 {
+		t.Errorf("alerts alone: %d findings, %v", len(vs), err)
+	}
+
+	for _, tt := range []struct{ args []string }{
+		{[]string{"-github_repos", "acme-inc-Education/"}},
+		{[]string{"-github_repos", "acme-inc-Education", "-github_alerts", "secret_scanning"}},
+		{[]string{"-input", sampleExport, "-github_alerts", "dependabot"}},
+		{[]string{"stream", "-github_repos", "acme-inc-Education", "-output_csv", out}},
+	} {
+		if err := run(tt.args, &stdout, &stderr); err == nil {
+			t.Errorf("%v: no error", tt.args)
+		}
+	}
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+	if err := run([]string{"-github_repos", "acme-inc-Education"}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("no token: %v", err)
+	}
+}
+
+func TestRunDedup(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-dedup", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 190 rows of the sample report 53 findings again in later scans.
+	if len(vs) != 1240 {
+		t.Fatalf("%d findings, want 1240", len(vs))
+	}
+	var braces *vuln.Vulnerability
+	for i := range vs {
+		if vs[i].Occurrences < 1 {
+			t.Fatalf("finding %s has occurrences %d", vs[i].UniqueID, vs[i].Occurrences)
+		}
+		if vs[i].Identifier == "CVE-2024-4068" && vs[i].AssetID == "464323181" {
+			braces = &vs[i]
+		}
+	}
+	if braces == nil || braces.UniqueID != "3" || braces.Occurrences != 6 || braces.FirstDetectedDate.String() != "2024-06-14" {
+		t.Errorf("CVE-2024-4068 on 464323181 = %+v, want unique ID 3 with 6 occurrences since 2024-06-14", braces)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Rows.Read != 1377 || m.Rows.Duplicates != 137 || m.Rows.Written != 1240 {
+		t.Errorf("manifest rows %+v", m.Rows)
+	}
+}
+
+func TestRunSARIF(t *testing.T) {
+	dir := t.TempDir()
+	b, err := os.ReadFile("../../ingest/testdata/codeql.sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A name that does not say SARIF needs -format.
+	input := filepath.Join(dir, "codeql-results.json")
+	if err := os.WriteFile(input, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.csv")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", input, "-format", "sarif", "-today", "2025-02-05", "-output_csv", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("wrote %d rows, want a header and 3 findings", len(records))
+	}
+	// The critical XSS ranks first.
+	id := slices.Index(records[0], "Identifier")
+	if records[1][id] != "js/xss" {
+		t.Errorf("first finding %v", records[1])
+	}
+	if !strings.Contains(stderr.String(), "skipped=2") {
+		t.Errorf("log does not report the two skipped results:\n%s", stderr.String())
+	}
+
+	if err := run([]string{"-input", input, "-format", "json"}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "-format") {
+		t.Errorf("-format json: %v", err)
+	}
+}
+
+func TestRunDecommissioned(t *testing.T) {
+	dir := t.TempDir()
+	retired := filepath.Join(dir, "retired.json")
+	// hasura is retired; sst-asset is due to be, after -today.
+	if err := os.WriteFile(retired, []byte(`{"assets": [
+		{"asset": "arn:aws:ecr:us-west-2:253710526682:repository/hasura", "decommissioned": "2025-01-20", "reason": "replaced by the managed service"},
+		{"asset": "sst-asset", "decommissioned": "2025-03-01"}
+	]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		mode          string
+		written, tags int
+	}{
+		{"drop", 1377 - 22, 0},
+		{"tag", 1377, 22},
+	} {
+		out := filepath.Join(dir, tt.mode+".json")
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-decommissioned", retired, "-stale_assets", tt.mode, "-output_json", out}, &stdout, &stderr); err != nil {
+			t.Fatalf("%s: %v\n%s", tt.mode, err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, err := output.ReadJSON(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags := 0
+		for _, v := range vs {
+			if v.StaleAsset {
+				tags++
+				if v.AssetName != "hasura" {
+					t.Errorf("%s: %s on %s tagged stale", tt.mode, v.Identifier, v.AssetName)
+				}
+			}
+		}
+		if len(vs) != tt.written || tags != tt.tags {
+			t.Errorf("%s: wrote %d findings, %d tagged; want %d and %d", tt.mode, len(vs), tags, tt.written, tt.tags)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m manifest.Manifest
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Rows.Stale != 22 {
+			t.Errorf("%s: manifest counts %d stale findings, want 22", tt.mode, m.Rows.Stale)
+		}
+	}
+
+	var stderr bytes.Buffer
+	if _, err := parseFlags([]string{"-input", sampleExport, "-stale_assets", "ignore"}, &stderr); err == nil {
+		t.Error("-stale_assets ignore accepted")
+	}
+}
+
+func TestRunTrivy(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	var stdout, stderr bytes.Buffer
+	// The format is detected from the content.
+	if err := run([]string{"-input", "../../ingest/testdata/trivy.json", "-today", "2025-02-05", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 3 {
+		t.Fatalf("wrote %d findings, want 3", len(vs))
+	}
+	// The image's findings are keyed by its digest, not its tag.
+	for _, v := range vs {
+		if v.AssetID != "253710526682.dkr.ecr.us-west-2.amazonaws.com/hasura@sha256:4be4aa5d16a227b5b1e9075b6ff3bd4dd2e200543e3a1eb3e2e55bb57e4ae1f4" || v.AssetName != "253710526682.dkr.ecr.us-west-2.amazonaws.com/hasura" {
+			t.Errorf("%s asset %q %q", v.Identifier, v.AssetName, v.AssetID)
+		}
+	}
+	if vs[0].Identifier != "CVE-2024-6387" || vs[0].PriorityScore <= vs[2].PriorityScore {
+		t.Errorf("priority order %s %v, %s %v", vs[0].Identifier, vs[0].PriorityScore, vs[2].Identifier, vs[2].PriorityScore)
+	}
+
+	other := filepath.Join(t.TempDir(), "findings.json")
+	if err := os.WriteFile(other, []byte(`{"findings": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"-input", other}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "pass -format") {
+		t.Errorf("unrecognised JSON: %v", err)
+	}
+}
+
+func TestRunGrype(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", "../../ingest/testdata/grype.json", "-today", "2025-02-05", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 4 {
+		t.Fatalf("wrote %d findings, want 4", len(vs))
+	}
+	for _, v := range vs {
+		if v.AssetID != "253710526682.dkr.ecr.us-west-2.amazonaws.com/hasura@sha256:0d3cbd5e09bdb7052c45b2f5bfe4019128035f9ab7d1d30a61f1d2f903b1ee8e" {
+			t.Errorf("%s asset %q", v.Identifier, v.AssetID)
+		}
+	}
+	if !strings.Contains(stderr.String(), "skipped=2") {
+		t.Errorf("skipped matches not logged:\n%s", stderr.String())
+	}
+}
+
+func TestRunCanonicalJSON(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+	runOnce := func() (results, m string) {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out, "-canonical_json"}, &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		a, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(a), string(b)
+	}
+	results, m := runOnce()
+	again, m2 := runOnce()
+	if results != again || m != m2 {
+		t.Error("consecutive runs on the same input wrote different files")
+	}
+	if strings.Contains(m, "started_at") {
+		t.Errorf("manifest has the start time:\n%s", m)
+	}
+	vs, err := output.ReadJSON(strings.NewReader(results))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 1377 || vs[0].Fingerprint() > vs[1].Fingerprint() {
+		t.Errorf("read %d findings back, first %s", len(vs), vs[0].Fingerprint())
+	}
+}
+
+func TestRunAttestation(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.json")
+	attestation := filepath.Join(dir, "provenance.json")
+	runOnce := func() string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out, "-canonical_json", "-attestation", attestation}, &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		b, err := os.ReadFile(attestation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	first := runOnce()
+	if again := runOnce(); again != first {
+		t.Error("consecutive canonical runs attested differently")
+	}
+	var st manifest.Statement
+	if err := json.Unmarshal([]byte(first), &st); err != nil {
+		t.Fatal(err)
+	}
+	results, err := manifest.HashFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := manifest.HashFile(sampleExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Subject) != 2 || st.Subject[0].Digest["sha256"] != results.SHA256 || st.Subject[1].Name != filepath.Join(dir, manifest.FileName) {
+		t.Errorf("subjects %+v", st.Subject)
+	}
+	b := st.Predicate.BuildDefinition
+	want, err := manifest.HashJSON(config.Config{Weights: scoring.DefaultWeights()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ExternalParameters.ConfigSHA256 != want || len(b.ResolvedDependencies) != 1 || b.ResolvedDependencies[0].Digest["sha256"] != input.SHA256 {
+		t.Errorf("build definition %+v", b)
+	}
+	if st.Predicate.RunDetails.Metadata != nil {
+		t.Errorf("canonical attestation has timings %+v", st.Predicate.RunDetails.Metadata)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-attestation", attestation}, &stdout, &stderr); err == nil {
+		t.Error("-attestation without outputs succeeded")
+	}
+}
+
+func TestRunOutputHTML(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "report.html")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_html", out, "-html_top", "5"}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(b)
+	for _, want := range []string{
+		"The fleet has 1377 findings: 51 critical, 524 high, 635 medium and 167 low.",
+		"<h2>Top 5 priority findings</h2>",
+		"<title>635 Medium</title>",
+		"<summary>match-v1</summary>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+	if n := strings.Count(page, "<details>"); n != 16 {
+		t.Errorf("%d asset breakdowns, want 16", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifest.FileName)); err != nil {
+		t.Errorf("no manifest next to the report: %v", err)
+	}
+}
+
+func TestRunTheme(t *testing.T) {
+	dir := t.TempDir()
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewNRGBA(image.Rect(0, 0, 90, 30))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "acme-logo.png"), logo.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The README's example.
+	themePath := filepath.Join(dir, "theme.yaml")
+	if err := os.WriteFile(themePath, []byte(`title: Acme Security vulnerability report
+footer: "Confidential: prepared for Example Corp by Acme Security"
+font: "'Open Sans', Arial, sans-serif"
+primary: "#1F3864"        # headings and table headers
+primary_text: "#FFFFFF"   # text on them (default black or white, as primary needs)
+logo: acme-logo.png       # PNG or JPEG, relative to the theme file
+severities:
+  Critical: "#7B0000"
+  High: "#C55A11"
+  Medium: "#FFC000"
+  Low: "#9DC3E6"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	html, xlsx, pdf := filepath.Join(dir, "report.html"), filepath.Join(dir, "report.xlsx"), filepath.Join(dir, "report.pdf")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-theme", themePath, "-output_html", html, "-output_xlsx", xlsx, "-output_pdf", pdf, "-html_top", "5"}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	page, err := os.ReadFile(html)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<h1>Acme Security vulnerability report</h1>", "fill: #7B0000", `<img class="logo" src="data:image/png;base64,`} {
+		if !bytes.Contains(page, []byte(want)) {
+			t.Errorf("HTML report lacks %q", want)
+		}
+	}
+	doc, err := os.ReadFile(pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(doc, []byte("%PDF-")) || !bytes.Contains(doc, []byte("/Title (Acme Security vulnerability report)")) || !bytes.Contains(doc, []byte("/Im1 ")) {
+		t.Errorf("PDF report is not themed")
+	}
+	if !strings.Contains(stderr.String(), `msg="wrote PDF report"`) {
+		t.Errorf("log does not report the PDF:\n%s", stderr.String())
+	}
+	// The themed workbook still reads back as input.
+	res, err := load(xlsx, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 1377 {
+		t.Errorf("workbook has %d findings, want 1377", len(res.Vulns))
+	}
+
+	if err := os.WriteFile(themePath, []byte("primary: navy\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"-input", sampleExport, "-theme", themePath, "-output_pdf", pdf}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "-theme") {
+		t.Errorf("bad theme: %v", err)
+	}
+}
+
+func TestRunAgeOut(t *testing.T) {
+	dir := t.TempDir()
+	header := "Asset id,Identifier,CVSS,Severity,Package Name,Installed Version\n"
+	both := header + "i-1,CVE-2023-1,9.8,Critical,openssl,3.0.2\ni-2,CVE-2023-2,5.0,Medium,zlib,1.2.13\n"
+	first := header + "i-1,CVE-2023-1,9.8,Critical,openssl,3.0.2\n"
+	store := filepath.Join(dir, "state.json")
+	out := filepath.Join(dir, "out.json")
+	runWith := func(export string) []vuln.Vulnerability {
+		t.Helper()
+		input := filepath.Join(dir, "export.csv")
+		if err := os.WriteFile(input, []byte(export), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var stdout, stderr bytes.Buffer
+		if err := run([]string{"-input", input, "-state", store, "-age_out", "2", "-output_json", out}, &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		vs, err := output.ReadJSON(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vs
+	}
+	zlib := vuln.Vulnerability{AssetID: "i-2", Identifier: "CVE-2023-2", PackageName: "zlib", InstalledVersion: "1.2.13"}
+	stateOf := func() string {
+		t.Helper()
+		s, err := state.Load(store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.Of(zlib.Fingerprint())
+	}
+
+	runWith(both)
+	runWith(first)
+	if got := stateOf(); got != state.Open {
+		t.Fatalf("after one missed run, zlib is %s", got)
+	}
+	runWith(first)
+	if got := stateOf(); got != state.Stale {
+		t.Fatalf("after two missed runs, zlib is %s", got)
+	}
+	vs := runWith(both)
+	if got := stateOf(); got != state.Open || len(vs) != 2 || vs[1].State != state.Open {
+		t.Errorf("seen again, zlib is %s; results %+v", got, vs)
+	}
+	audit, err := os.ReadFile(filepath.Join(dir, auditFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(audit)), "\n"); len(lines) != 2 || !strings.Contains(lines[0], `"to":"stale"`) || !strings.Contains(lines[1], `"reason":"seen again"`) {
+		t.Errorf("audit log:\n%s", audit)
+	}
+
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{
+		{"-input", sampleExport, "-age_out", "2"},
+		{"-input", sampleExport, "-state", store, "-age_out", "2", "-shard", "1/2"},
+		{"-input", sampleExport, "-state", store, "-age_out", "2", "-read_only"},
+	} {
+		if err := run(args, &stdout, &stderr); err == nil {
+			t.Errorf("run %v succeeded", args)
+		}
+	}
+}
+
+func TestRunOutputMarkdown(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "summary.md")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_md", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := string(b)
+	if !strings.Contains(md, "**1377 findings** on 16 assets") || !strings.Contains(md, "### Top 10 priorities") {
+		t.Errorf("summary:\n%s", md)
+	}
+	for _, want := range []string{
+		"| High | 524 | 144 |",
+		"| **Total** | **1377** | **245** |",
+		"245 findings are past their due date; the 10 most overdue:",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("summary does not contain %q", want)
+		}
+	}
+	// Header, separator and ten findings each.
+	if rows := strings.Count(md, "\n| "); rows != 7+12+12 {
+		t.Errorf("%d table rows", rows)
+	}
+}
+
+func TestRunSortByEffort(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-sort", "effort", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The 1120 AWS rows are distribution updates, a point each before
+	// rolling them out; the GitHub rows give affected ranges, most
+	// without a lower bound to measure the upgrade from.
+	counts := make(map[int]int)
+	for i, v := range vs {
+		counts[v.Effort]++
+		if i > 0 && v.RemediateWithinDays == vs[i-1].RemediateWithinDays && v.Effort < vs[i-1].Effort {
+			t.Fatalf("finding %d (effort %d) after one of effort %d in %s", i, v.Effort, vs[i-1].Effort, v.ActionTimeframe)
+		}
+	}
+	want := map[int]int{1: 1058, 2: 71, 3: 39, 5: 105, 8: 104}
+	if !maps.Equal(counts, want) {
+		t.Errorf("findings by effort = %v, want %v", counts, want)
+	}
+
+	if err := run([]string{"-input", sampleExport, "-sort", "cost"}, &stdout, &stderr); err == nil {
+		t.Error("unknown -sort order accepted")
+	}
+}
+
+func TestRunOutputJSONL(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.jsonl")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_jsonl", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 1377 {
+		t.Fatalf("%d lines, want one per finding", len(lines))
+	}
+	var top vuln.Vulnerability
+	if err := json.Unmarshal([]byte(lines[0]), &top); err != nil || top.ActionTimeframe != "Immediate" {
+		t.Errorf("first line = %s (%v)", lines[0], err)
+	}
+	if !strings.Contains(stderr.String(), `msg="wrote JSON Lines"`) {
+		t.Errorf("log:\n%s", stderr.String())
+	}
+
+	// Commands that read results take JSON Lines too.
+	stdout.Reset()
+	if err := run([]string{"lookup", "-results", out, "CVE-2024-4068"}, &stdout, &stderr); err != nil || !strings.Contains(stdout.String(), "npm-braces") {
+		t.Errorf("lookup: %v\n%s", err, stdout.String())
+	}
+	merged := filepath.Join(dir, "merged.ndjson")
+	if err := run([]string{"merge", "-output_jsonl", merged, out, out}, &stdout, &stderr); err != nil {
+		t.Fatalf("merge: %v\n%s", err, stderr.String())
+	}
+	if b, err := os.ReadFile(merged); err != nil || strings.Count(string(b), "\n") != 1377 {
+		t.Errorf("merged %d lines, %v", strings.Count(string(b), "\n"), err)
+	}
+}
+
+func TestRunOutputVEX(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, "fp.json")
+	var stdout, stderr bytes.Buffer
+	// Unique ID 3 is CVE-2024-4068 in npm-braces on apply-api.
+	if err := run([]string{"fp", "mark", "-store", store, "-input", sampleExport, "-reason", "braces is build-time only", "3"}, &stdout, &stderr); err != nil {
+		t.Fatalf("mark: %v\n%s", err, stderr.String())
+	}
+	out := filepath.Join(dir, "vex.json")
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-suppressions", store, "-output_vex", out, "-vex_author", "Acme Security"}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `msg="wrote VEX document" path=`+out+` format=openvex statements=1240`) {
+		t.Errorf("log:\n%s", stderr.String())
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Author     string `json:"author"`
+		Statements []struct {
+			Vulnerability struct{ Name string } `json:"vulnerability"`
+			Products      []struct {
+				ID string `json:"@id"`
+			} `json:"products"`
+			Status string `json:"status"`
+			Impact string `json:"impact_statement"`
+		} `json:"statements"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	// The 1240 findings, one per fingerprint, merge into 1117 statements.
+	if len(doc.Statements) != 1117 {
+		t.Errorf("%d statements", len(doc.Statements))
+	}
+	var braces []string
+	for _, s := range doc.Statements {
+		if s.Vulnerability.Name == "CVE-2024-4068" {
+			braces = append(braces, fmt.Sprintf("%s %d %s %s", s.Status, len(s.Products), s.Products[0].ID, s.Impact))
+		}
+	}
+	want := []string{"under_investigation 8 acme-inc-customer-api ", "not_affected 1 apply-api False positive: braces is build-time only"}
+	if doc.Author != "Acme Security" || !slices.Equal(braces, want) {
+		t.Errorf("author %q, CVE-2024-4068 statements %q", doc.Author, braces)
+	}
+
+	cdx := filepath.Join(dir, "vex.cdx.json")
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_vex", cdx, "-vex_format", "cyclonedx"}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if b, err := os.ReadFile(cdx); err != nil || !strings.Contains(string(b), `"bomFormat": "CycloneDX"`) {
+		t.Errorf("CycloneDX document: %v\n%.200s", err, b)
+	}
+	if err := run([]string{"-input", sampleExport, "-output_vex", out, "-vex_format", "spdx"}, &stdout, &stderr); err == nil {
+		t.Error("-vex_format spdx: want error")
+	}
+}
+
+func TestRunOutputCycloneDX(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "bom.json")
+	var stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_cyclonedx", out}, &bytes.Buffer{}, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `msg="wrote CycloneDX SBOM" path=`+out) {
+		t.Errorf("log:\n%s", stderr.String())
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bom struct {
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			Components []struct{ PURL string }
+		} `json:"components"`
+		Vulnerabilities []struct {
+			ID      string
+			Ratings []struct {
+				Score    float64
+				Severity string
+			}
+			Recommendation string
+			Affects        []struct{ Ref string }
+			Properties     []struct{ Name, Value string }
+		} `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(b, &bom); err != nil {
+		t.Fatal(err)
+	}
+	packages := 0
+	for _, c := range bom.Components {
+		packages += len(c.Components)
+	}
+	// The 1377 findings have 1240 fingerprints, on 16 assets.
+	if bom.BOMFormat != "CycloneDX" || len(bom.Components) != 16 || packages != 225 || len(bom.Vulnerabilities) != 1240 {
+		t.Fatalf("%s: %d components, %d packages, %d vulnerabilities", bom.BOMFormat, len(bom.Components), packages, len(bom.Vulnerabilities))
+	}
+	// In priority order.
+	v := bom.Vulnerabilities[1]
+	if v.ID != "CVE-2022-2216" || v.Ratings[0].Score != 9.8 || v.Ratings[0].Severity != "critical" ||
+		v.Recommendation != "Update npm-parse-url from < 6.0.1 to 6.0.1." || v.Affects[0].Ref != "match-frontend#pkg:npm/parse-url" ||
+		v.Properties[0].Value != "9.24" || v.Properties[1].Value != "Immediate" {
+		t.Errorf("second vulnerability = %+v", v)
+	}
+}
+
+func TestRunTerminalPage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-sort", "cvss", "-top", "10", "-page", "3", "-columns", "rank, cvss,identifier"}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	lines := strings.Split(stdout.String(), "\n")
+	if lines[0] != "RANK  CVSS  IDENTIFIER" || !strings.HasPrefix(lines[1], "21    ") || !strings.HasPrefix(lines[10], "30    ") || lines[11] != "" {
+		t.Errorf("page 3:\n%s", strings.Join(lines[:12], "\n"))
+	}
+	for i := 2; i <= 10; i++ {
+		prev, _ := strconv.ParseFloat(strings.Fields(lines[i-1])[1], 64)
+		if cvss, _ := strconv.ParseFloat(strings.Fields(lines[i])[1], 64); cvss > prev {
+			t.Errorf("CVSS rises from row %d to %d", i-1, i)
+		}
+	}
+	if lines[12] != "Showing findings 21-30 of 1377 (page 3 of 138)." {
+		t.Errorf("page line = %q", lines[12])
+	}
+	// The SLA summary still covers every finding.
+	if !strings.Contains(stdout.String(), "SLA: 245 of 1377 findings") {
+		t.Errorf("SLA summary:\n%s", stdout.String())
+	}
+
+	for _, tt := range []struct{ args []string }{
+		{[]string{"-columns", "rank,owner"}},
+		{[]string{"-page", "2"}},
+		{[]string{"-top", "-1"}},
+		{[]string{"-sort", "epss"}},
+	} {
+		if err := run(append([]string{"-input", sampleExport}, tt.args...), &stdout, &stderr); err == nil {
+			t.Errorf("%v: ran", tt.args)
+		}
+	}
+}
+
+func TestPrintToTerminalColor(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{Severity: vuln.Critical, ActionTimeframe: "Immediate", Identifier: "CVE-2024-3094", PriorityScore: 9.5},
+		{Severity: vuln.High, ActionTimeframe: "Scheduled", Identifier: "CVE-2024-4068", PriorityScore: 6.2},
+		{Severity: vuln.Low, ActionTimeframe: "Planned", Identifier: "CVE-2023-44487", PriorityScore: 2.1},
+	}
+	var plain, colored strings.Builder
+	view := defaultView
+	printToTerminal(&plain, vs, scoring.DefaultBands, view)
+	view.color = true
+	printToTerminal(&colored, vs, scoring.DefaultBands, view)
+	for _, want := range []string{ansiRed + "Immediate", ansiRed + "Critical", ansiYellow + "High", ansiDefault + "Scheduled", ansiDefault + "Low"} {
+		if !strings.Contains(colored.String(), want+ansiReset) {
+			t.Errorf("colored table lacks %q", want)
+		}
+	}
+	// Every cell of a colored column has escapes of the same length, so
+	// without them the table lines up as the plain one does.
+	if got := regexp.MustCompile("\x1b\\[[0-9]+m").ReplaceAllString(colored.String(), ""); got != plain.String() {
+		t.Errorf("colored table:\n%s\nwithout escapes:\n%s\nplain:\n%s", colored.String(), got, plain.String())
+	}
+	if isTerminal(&plain) {
+		t.Error("a strings.Builder is a terminal")
+	}
+}
+
+func TestRunSLA(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.csv")
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-sla", "Critical=15,High=30,Medium=90,Low=180", "-output_csv", out}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	const breaches = "SLA: 1284 of 1377 findings are past their due date (Critical 50, High 524, Medium 610, Low 100); the most overdue by 551 days.\n"
+	if !strings.HasSuffix(stdout.String(), breaches) {
+		t.Errorf("terminal output does not end with %q", breaches)
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := make(map[string]int)
+	for i, h := range rows[0] {
+		col[h] = i
+	}
+	for _, row := range rows[1:] {
+		// First detected 2024-06-14, so due 30 days later.
+		if row[col["Unique ID"]] == "3" {
+			if row[col["Due date"]] != "2024-07-14" || row[col["SLA Status"]] != "breached" || row[col["Days Overdue"]] != "206" {
+				t.Errorf("unique ID 3: due %s, %s, %s days overdue", row[col["Due date"]], row[col["SLA Status"]], row[col["Days Overdue"]])
+			}
+		}
+	}
+
+	if err := run([]string{"-input", sampleExport, "-sla", "High=a month"}, &stdout, &stderr); err == nil {
+		t.Error("invalid -sla accepted")
+	}
+}
+
+func TestRunProjection(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	soon := 0
+	for _, v := range vs {
+		if v.ScoreIn30Days < v.PriorityScore || v.ScoreIn60Days < v.ScoreIn30Days || v.ScoreIn90Days < v.ScoreIn60Days {
+			t.Fatalf("%s on %s: scores %v, %v, %v, %v do not rise", v.Identifier, v.AssetID, v.PriorityScore, v.ScoreIn30Days, v.ScoreIn60Days, v.ScoreIn90Days)
+		}
+		switch v.SLAStatus {
+		case "breached":
+			// Overdue findings earn the most due-date points already.
+			if !v.BreachesSLAOn.IsZero() || v.ScoreIn90Days != v.PriorityScore {
+				t.Fatalf("breached %s on %s: breaches SLA on %s, score %v in 90 days", v.Identifier, v.AssetID, v.BreachesSLAOn, v.ScoreIn90Days)
+			}
+		case "within_sla":
+			if v.BreachesSLAOn.Sub(v.DueDate.Time) != 24*time.Hour {
+				t.Fatalf("%s on %s due %s breaches SLA on %s", v.Identifier, v.AssetID, v.DueDate, v.BreachesSLAOn)
+			}
+			if v.BreachesSLAOn.Before(vuln.NewDate(2025, 3, 8).Time) {
+				soon++
+			}
+		}
+	}
+	// Of the 1132 findings within their SLA, 472 breach it within 30 days.
+	if soon != 472 {
+		t.Errorf("%d findings breach their SLA by 2025-03-07, want 472", soon)
+	}
+}
+
+func TestRunGate(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.csv")
+	for _, tt := range []struct {
+		flags  []string
+		failed int
+	}{
+		{[]string{"-fail_on_severity", "critical"}, 51},
+		// The Immediate tier.
+		{[]string{"-fail_on_score", "8"}, 172},
+		// Every Critical finding scores 8 or more.
+		{[]string{"-fail_on_severity", "Critical", "-fail_on_score", "8"}, 172},
+		// The top score is 9.34.
+		{[]string{"-fail_on_score", "9.5"}, 0},
+	} {
+		os.Remove(out)
+		var stdout, stderr bytes.Buffer
+		err := run(append([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_csv", out}, tt.flags...), &stdout, &stderr)
+		var failed *gate.Error
+		switch {
+		case tt.failed == 0 && err != nil:
+			t.Errorf("%v: %v", tt.flags, err)
+		case tt.failed > 0 && (!errors.As(err, &failed) || failed.Failed != tt.failed):
+			t.Errorf("%v: error %v, want %d findings failing the gate", tt.flags, err, tt.failed)
+		}
+		// The outputs are written either way.
+		if _, err := os.Stat(out); err != nil {
+			t.Errorf("%v: %v", tt.flags, err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{{"-fail_on_severity", "Severe"}, {"-fail_on_score", "11"}} {
+		if err := run(append([]string{"-input", sampleExport}, args...), &stdout, &stderr); err == nil || errors.As(err, new(*gate.Error)) {
+			t.Errorf("%v: error %v, want a usage error", args, err)
+		}
+	}
+}
+
+func TestRunBaseline(t *testing.T) {
+	dir := t.TempDir()
+	golden, out := filepath.Join(dir, "golden.json"), filepath.Join(dir, "out.csv")
+	base := []string{"-input", sampleExport, "-today", "2025-02-05", "-output_csv", out}
+	var stdout, stderr bytes.Buffer
+	// Approve only the GitHub findings, so the 1120 AWS ones are new.
+	if err := run(append(base, "-source", "github", "-output_json", golden), &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		flags  []string
+		failed int
+	}{
+		{[]string{"-baseline", golden}, 1120},
+		{[]string{"-baseline", golden, "-fail_on_severity", "Critical"}, 45},
+		{[]string{"-baseline", golden, "-baseline_mode", "warn"}, 0},
+	} {
+		stderr.Reset()
+		err := run(append(base, tt.flags...), &stdout, &stderr)
+		var failed *gate.Error
+		switch {
+		case tt.failed == 0 && err != nil:
+			t.Errorf("%v: %v", tt.flags, err)
+		case tt.failed > 0 && (!errors.As(err, &failed) || failed.Failed != tt.failed || !failed.Baseline):
+			t.Errorf("%v: error %v, want %d new findings failing the gate", tt.flags, err, tt.failed)
+		}
+		if !strings.Contains(stderr.String(), "new=1120") {
+			t.Errorf("%v: log does not count the new findings:\n%s", tt.flags, stderr.String())
+		}
+	}
+
+	// A run's own CSV output is a baseline with nothing new.
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_csv", filepath.Join(dir, "again.csv"), "-baseline", out}, &stdout, &stderr); err != nil {
+		t.Errorf("against its own results: %v", err)
+	}
+	for _, args := range [][]string{{"-baseline", golden, "-baseline_mode", "drop"}, {"-baseline", filepath.Join(dir, "missing.json")}} {
+		if err := run(append(base, args...), &stdout, &stderr); err == nil || errors.As(err, new(*gate.Error)) {
+			t.Errorf("%v: error %v, want a usage error", args, err)
+		}
+	}
+}
+
+func TestRunValidateOutput(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.json")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-validate_output", "-output_json", out}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "validated findings against the schema") {
+		t.Errorf("validation not logged:\n%s", stderr.String())
+	}
+}
+
+func TestRunClassifications(t *testing.T) {
+	scores := func(extra ...string) map[string]vuln.Vulnerability {
+		t.Helper()
+		out := filepath.Join(t.TempDir(), "out.json")
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out}, extra...)
+		if err := run(args, &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		vs, err := output.ReadJSON(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byID := make(map[string]vuln.Vulnerability)
+		for _, v := range vs {
+			byID[v.UniqueID] = v
+		}
+		return byID
+	}
+	plain := scores()
+	classified := scores("-classifications", "../../enrich/testdata/classifications.json")
+	counts := make(map[string]int)
+	for id, v := range classified {
+		counts[v.DataClassification]++
+		switch before := plain[id].PriorityScore; v.DataClassification {
+		case vuln.ClassRestricted:
+			if v.PriorityScore <= before && before < 10 {
+				t.Errorf("restricted %s scored %v, %v unclassified", id, v.PriorityScore, before)
+			}
+		case vuln.ClassPublic:
+			if v.PriorityScore >= before && before > 0 {
+				t.Errorf("public %s scored %v, %v unclassified", id, v.PriorityScore, before)
+			}
+		default:
+			if v.PriorityScore != before {
+				t.Errorf("unclassified %s scored %v, %v before", id, v.PriorityScore, before)
+			}
+		}
+	}
+	// acme-inc-customer-api by its asset ID, explore-web by name.
+	if counts[vuln.ClassRestricted] != 40 || counts[vuln.ClassPublic] != 11 {
+		t.Errorf("classified %v", counts)
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "scoring.yaml")
+	yaml := `weights:
+  severity: {critical: 4}
+  source:
+    aws: 0
+    github: 0
+  normalize_max: 29
+sla:
+  low: 1000
+`
+	if err := os.WriteFile(cfg, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.json")
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out, "-config", cfg, "-critical", "8"}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	vs, err := output.ReadJSON(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file zeroes both sources and doubles the normalization maximum;
+	// the -critical flag overrides its Critical weight.
+	want := scoring.DefaultWeights()
+	want.Source["aws"], want.Source["github"] = 0, 0
+	want.Severity[vuln.Critical] = 8
+	want.NormalizeMax = 29
+	today, _ := vuln.ParseDate("2025-02-05")
+	low := 0
+	for _, v := range vs {
+		if got := (scoring.Weighted{Weights: want, Now: today.Time}).Score(v); got != v.PriorityScore {
+			t.Fatalf("%s scored %v, want %v", v.UniqueID, v.PriorityScore, got)
+		}
+		if v.Severity == vuln.Low {
+			low++
+			if due := v.FirstDetectedDate.AddDate(0, 0, 1000); !v.DueDate.Equal(due) {
+				t.Fatalf("%s due %v, want 1000 days after %v", v.UniqueID, v.DueDate, v.FirstDetectedDate)
+			}
+		}
+	}
+	if low == 0 {
+		t.Error("no Low findings to check the SLA on")
+	}
+}
+
+func TestRunPostCmd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	dir := t.TempDir()
+	seen := filepath.Join(dir, "seen.json")
+	out := filepath.Join(dir, "out.json")
+	// The first command keeps a copy; the second replaces the findings
+	// with one of its own.
+	keep := `cat > /dev/null; echo '[{"unique_id": "x", "identifier": "CVE-2024-0001", "severity": "Critical", "priority_score": 9}]'`
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out, "-post_cmd", "cat > " + seen, "-post_cmd", keep}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	read := func(path string) []vuln.Vulnerability {
+		t.Helper()
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		vs, err := output.ReadJSON(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vs
+	}
+	if n := len(read(seen)); n != 1377 {
+		t.Errorf("first post-processor read %d findings, want 1377", n)
+	}
+	if vs := read(out); len(vs) != 1 || vs[0].UniqueID != "x" {
+		t.Errorf("output = %+v, want the last post-processor's findings", vs)
+	}
+	if !strings.Contains(stderr.String(), `msg="post-processed findings" steps=2 before=1377 after=1`) {
+		t.Errorf("log lacks the post-processing:\n%s", stderr.String())
+	}
+
+	err := run([]string{"-input", sampleExport, "-post_cmd", "exit 1"}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), `post-processor "exit 1"`) {
+		t.Errorf("failing post-processor: run = %v", err)
+	}
+	if _, err := parseFlags([]string{"-input", "x.csv", "-read_only", "-post_cmd", "cat"}, &bytes.Buffer{}); err == nil {
+		t.Error("-read_only accepted -post_cmd")
+	}
+}
+
+func TestRunMaxPerTeam(t *testing.T) {
+	dir := t.TempDir()
+	csvOut, jsonOut, summary, mpath := filepath.Join(dir, "out.csv"), filepath.Join(dir, "out.json"), filepath.Join(dir, "quota.csv"), filepath.Join(dir, "m.json")
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-max_per_team", "50", "-quota_summary", summary,
+		"-output_csv", csvOut, "-output_json", jsonOut, "-manifest", mpath}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	f, err := os.Open(csvOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sample has two teams, by account: every severity is capped at
+	// 50 but the AWS account's 45 and the GitHub organization's 6
+	// Critical and 47 Medium findings.
+	if len(rows) != 1+348 {
+		t.Errorf("CSV has %d findings, want 348", len(rows)-1)
+	}
+	jf, err := os.Open(jsonOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jf.Close()
+	if vs, err := output.ReadJSON(jf); err != nil || len(vs) != 1377 {
+		t.Errorf("JSON has %d findings (%v), want all 1377", len(vs), err)
+	}
+
+	b, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Team,Severity,Findings,Included,Withheld,Top Withheld Score\n" +
+		"253710526682: us-west-2,High,380,50,330,"
+	if lines := strings.Split(strings.TrimSpace(string(b)), "\n"); len(lines) != 6 || !strings.HasPrefix(string(b), want) {
+		t.Errorf("quota summary = %q", b)
+	}
+
+	b, err = os.ReadFile(mpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Rows.Withheld != 1029 || m.Rows.Written != 1377 {
+		t.Errorf("manifest rows = %+v", m.Rows)
+	}
+
+	if err := run([]string{"-input", sampleExport, "-quota_summary", summary}, &stdout, &stderr); err == nil {
+		t.Error("-quota_summary accepted without -max_per_team")
+	}
+}
+
+func TestRunBundle(t *testing.T) {
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "nightly.zip")
+	f, err := os.Create(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, m := range [][2]string{
+		{"aws/export.csv", sampleExport},
+		{"images/trivy.json", "../../ingest/testdata/trivy.json"},
+		{"images/grype.json", "../../ingest/testdata/grype.json"},
+		{"broken/garbled.csv", ""},
+	} {
+		name, path := m[0], m[1]
+		b := []byte("Identifier,CVSS,Severity\nCVE-2024-0001,7.5,Severe\n")
+		if path != "" {
+			if b, err = os.ReadFile(path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(b)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	out, quarantine := filepath.Join(dir, "out.json"), filepath.Join(dir, "skipped.csv")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", bundle, "-today", "2025-02-05", "-output_json", out, "-quarantine", quarantine}, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	jf, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jf.Close()
+	vs, err := output.ReadJSON(jf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := make(map[string]int)
+	for _, v := range vs {
+		sources[v.Source]++
+	}
+	if want := map[string]int{"aws": 1120, "github": 257, "trivy": 3, "grype": 4}; !maps.Equal(sources, want) {
+		t.Errorf("findings by source = %v, want %v", sources, want)
+	}
+	qf, err := os.Open(quarantine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer qf.Close()
+	rows, err := csv.NewReader(qf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The Trivy and Grype test reports each have two broken findings.
+	var reasons []string
+	for _, row := range rows[1:] {
+		reasons = append(reasons, row[len(row)-1])
+	}
+	if len(rows) != 1+5 || !slices.Contains(reasons, `broken/garbled.csv line 2: unknown severity "Severe"`) {
+		t.Errorf("quarantined %q", reasons)
+	}
+
+	if err := os.WriteFile(bundle, []byte("not an archive"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"-input", bundle}, &stdout, &stderr); err == nil {
+		t.Error("read a broken bundle")
+	}
+}
+
+func TestRunInputs(t *testing.T) {
+	dir := t.TempDir()
+	scans := filepath.Join(dir, "scans")
+	if err := os.Mkdir(scans, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"trivy.json", "grype.json"} {
+		b, err := os.ReadFile("../../ingest/testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(scans, name), b, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Hidden files are not exports.
+	if err := os.WriteFile(filepath.Join(scans, ".DS_Store"), []byte{0}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(t *testing.T, args ...string) ([]vuln.Vulnerability, manifest.Manifest) {
+		t.Helper()
+		out := filepath.Join(t.TempDir(), "out.json")
+		var stdout, stderr bytes.Buffer
+		if err := run(append(args, "-today", "2025-02-05", "-output_json", out), &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		vs, err := output.ReadJSON(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(filepath.Dir(out), manifest.FileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m manifest.Manifest
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		return vs, m
+	}
+	bySource := func(vs []vuln.Vulnerability) map[string]int {
+		sources := make(map[string]int)
+		for _, v := range vs {
+			sources[v.Source]++
+		}
+		return sources
+	}
+
+	// The glob matches the directory's files again; each is read once,
+	// and the findings are deduped across them.
+	vs, m := read(t, "-input", sampleExport, "-input", scans, "-input", filepath.Join(scans, "*.json"))
+	if want := map[string]int{"aws": 1120, "github": 120, "trivy": 3, "grype": 4}; !maps.Equal(bySource(vs), want) {
+		t.Errorf("findings by source = %v, want %v", bySource(vs), want)
+	}
+	var paths []string
+	for _, in := range m.Inputs {
+		paths = append(paths, in.Path)
+	}
+	if want := []string{sampleExport, filepath.Join(scans, "grype.json"), filepath.Join(scans, "trivy.json")}; !slices.Equal(paths, want) {
+		t.Errorf("manifest inputs %q, want %q", paths, want)
+	}
+	if m.Rows.Read != 1377+7 || m.Rows.Duplicates != 137 || m.Rows.Skipped != 4 {
+		t.Errorf("manifest rows %+v", m.Rows)
+	}
+
+	if vs, _ := read(t, "-input", sampleExport, "-input", scans, "-dedup=false"); len(vs) != 1377+7 {
+		t.Errorf("-dedup=false: %d findings, want %d", len(vs), 1377+7)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-input", filepath.Join(dir, "*.xlsx")}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "matches no files") {
+		t.Errorf("glob matching nothing: %v", err)
+	}
+	if err := run([]string{"-input", sampleExport, "-input", "aws:inspector2"}, &stdout, &stderr); err == nil || !strings.Contains(err.Error(), "cannot be repeated") {
+		t.Errorf("aws input with a file: %v", err)
+	}
+}
+
+func TestRunWaivers(t *testing.T) {
+	dir := t.TempDir()
+	waivers := filepath.Join(dir, "waivers.yaml")
+	// The first covers the 15 ws findings on one repository; the second
+	// has expired, so its 26 braces findings are reported again.
+	if err := os.WriteFile(waivers, []byte(`waivers:
+  - id: CVE-2024-37890
+    asset: acme-inc-integrations
+    expires: 2025-03-31
+    justification: ws is only reached from the internal network
+  - id: CVE-2024-4068
+    expires: 2025-01-31
+    justification: braces upgrade scheduled
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, mpath := filepath.Join(dir, "out.json"), filepath.Join(dir, "m.json")
+	for mode, want := range map[string]int{"mark": 1377, "drop": 1362} {
+		var stdout, stderr bytes.Buffer
+		args := []string{"-input", sampleExport, "-today", "2025-02-05", "-waivers", waivers, "-waiver_mode", mode, "-output_json", out, "-manifest", mpath}
+		if err := run(args, &stdout, &stderr); err != nil {
+			t.Fatalf("%s: run: %v\n%s", mode, err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, err := output.ReadJSON(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		suppressed := 0
+		for _, v := range vs {
+			if v.Suppressed {
+				suppressed++
+			}
+			if v.Identifier == "CVE-2024-37890" && v.AssetName == "acme-inc-integrations" && (mode == "drop" || v.Waiver == "") {
+				t.Errorf("%s: waived finding %s written as %+v", mode, v.UniqueID, v)
+			}
+		}
+		if len(vs) != want || mode == "mark" && suppressed != 15 || mode == "drop" && suppressed != 0 {
+			t.Errorf("%s: wrote %d findings, %d suppressed", mode, len(vs), suppressed)
+		}
+		if !strings.Contains(stderr.String(), "waiver expired") || !strings.Contains(stderr.String(), "reactivated=26") {
+			t.Errorf("%s: expired waiver not logged:\n%s", mode, stderr.String())
+		}
+		b, err := os.ReadFile(mpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m manifest.Manifest
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Rows.Waived != 15 {
+			t.Errorf("%s: manifest rows = %+v", mode, m.Rows)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-waivers", waivers, "-waiver_mode", "hide"}, &stdout, &stderr); err == nil {
+		t.Error("unknown -waiver_mode accepted")
+	}
+}
+
+func TestRunFilters(t *testing.T) {
+	dir := t.TempDir()
+	out, mpath := filepath.Join(dir, "out.json"), filepath.Join(dir, "m.json")
+	for _, tt := range []struct {
+		flags []string
+		want  int
+	}{
+		{[]string{"-severity", "Critical,High", "-source", "AWS"}, 425},
+		{[]string{"-min_cvss", "9"}, 55},
+		{[]string{"-asset", "acme-inc-*"}, 194},
+		{[]string{"-asset", "hasura", "-asset", "sst-*"}, 29},
+		// The Immediate tier.
+		{[]string{"-min_priority", "8"}, 172},
+	} {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out, "-manifest", mpath}, tt.flags...)
+		if err := run(args, &stdout, &stderr); err != nil {
+			t.Fatalf("%v: run: %v\n%s", tt.flags, err, stderr.String())
+		}
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, err := output.ReadJSON(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(mpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m manifest.Manifest
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		if len(vs) != tt.want || m.Rows.Filtered != 1377-tt.want || m.Rows.Written != tt.want {
+			t.Errorf("%v: wrote %d findings, manifest rows %+v; want %d", tt.flags, len(vs), m.Rows, tt.want)
+		}
+	}
+
+	for _, flags := range [][]string{{"-severity", "Severe"}, {"-min_cvss", "high"}, {"-asset", "prod-["}} {
+		var stdout, stderr bytes.Buffer
+		if err := run(append([]string{"-input", sampleExport}, flags...), &stdout, &stderr); err == nil {
+			t.Errorf("%v accepted", flags)
+		}
+	}
+}
+
+func TestRunEncrypt(t *testing.T) {
+	dir := t.TempDir()
+	key := filepath.Join(dir, "report.key")
+	if err := os.WriteFile(key, []byte(strings.Repeat("5a", 32)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sealed, plain := filepath.Join(dir, "out.csv.enc"), filepath.Join(dir, "out.csv")
+	var stdout, stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-encrypt", "aes:" + key, "-output_csv", sealed, "-manifest", filepath.Join(dir, "m.json")}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	b, err := os.ReadFile(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("match-v1")) {
+		t.Fatal("the encrypted CSV holds asset names in the clear")
+	}
+
+	if err := run([]string{"decrypt", "-key", key, "-input", sealed, "-output", plain}, &stdout, &stderr); err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	f, err := os.Open(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1+1377 || rows[0][0] != "Unique ID" {
+		t.Errorf("decrypted %d rows, header %q", len(rows), rows[0])
+	}
+	if info, err := os.Stat(plain); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("decrypted file mode %v, %v", info.Mode(), err)
+	}
+
+	b[len(b)-1] ^= 1
+	if err := os.WriteFile(sealed, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(plain)
+	if err := run([]string{"decrypt", "-key", key, "-input", sealed, "-output", plain}, &stdout, &stderr); err == nil {
+		t.Error("decrypted a modified file")
+	}
+	if _, err := os.Stat(plain); !os.IsNotExist(err) {
+		t.Error("a failed decryption left its output")
+	}
+
+	for _, value := range []string{"age:age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p", "aes:" + filepath.Join(dir, "missing.key"), "rot13:" + key} {
+		if err := run([]string{"-input", sampleExport, "-encrypt", value}, &stdout, &stderr); err == nil {
+			t.Errorf("-encrypt %s accepted", value)
+		}
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	dir := t.TempDir()
+	whole := filepath.Join(dir, "whole.csv")
+	streamed := filepath.Join(dir, "streamed.csv")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_csv", whole}, nil, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	stderr.Reset()
+	err := run([]string{"stream", "-input", sampleExport, "-today", "2025-02-05", "-output_csv", streamed, "-buffer_size", "16", "-workers", "4", "-batch_size", "100"}, &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("stream: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `msg="streamed input" path=`+sampleExport+` findings=1377 skipped=0 written=1377 workers=4 buffer_size=16`) {
+		t.Errorf("log does not report the stream:\n%s", stderr.String())
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	want, err := readResults(whole, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := readResults(streamed, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1377 {
+		t.Fatalf("streamed %d findings, want 1377", len(got))
+	}
+	byID := make(map[string]vuln.Vulnerability, len(want))
+	for _, v := range want {
+		byID[v.UniqueID] = v
+	}
+	for i, v := range got {
+		// The export is in unique ID order, which the stream keeps.
+		if v.UniqueID != strconv.Itoa(i+1) {
+			t.Fatalf("finding %d has unique ID %s", i, v.UniqueID)
+		}
+		w := byID[v.UniqueID]
+		if v.PriorityScore != w.PriorityScore || v.ActionTimeframe != w.ActionTimeframe || v.TemporalCVSS != w.TemporalCVSS ||
+			v.ScoreIn90Days != w.ScoreIn90Days || v.SLAStatus != w.SLAStatus || v.Confidence != w.Confidence {
+			t.Fatalf("streamed %+v\nwant %+v", v, w)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-input", sampleExport, "-output_csv", streamed, "-dedup", "-output_json", "out.json"},
+		{"-input", sampleExport},
+		{"-input", sampleExport, "-output_csv", streamed, "-buffer_size", "0"},
+		{"-input", "../../ingest/testdata/trivy.json", "-output_csv", streamed},
+	} {
+		if err := run(append([]string{"stream"}, args...), &stdout, &stderr); err == nil {
+			t.Errorf("stream %q accepted", args)
+		}
+	}
+}
+
+func TestRunStreamQuarantine(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	out := filepath.Join(dir, "out.csv")
+	quarantine := filepath.Join(dir, "skipped.csv")
+	in := "Identifier,CVSS,Severity\nCVE-1,5.0,Medium\nCVE-2,5.0,Severe\nCVE-3,9.8,Critical\n"
+	if err := os.WriteFile(input, []byte(in), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stderr bytes.Buffer
+	if err := run([]string{"stream", "-input", input, "-output_csv", out, "-quarantine", quarantine, "-severity", "Critical"}, nil, &stderr); err != nil {
+		t.Fatalf("stream: %v\n%s", err, stderr.String())
+	}
+	b, err := os.ReadFile(quarantine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Identifier,CVSS,Severity,error_reason\nCVE-2,5.0,Severe,\"line 3: unknown severity \"\"Severe\"\"\"\n"
+	if string(b) != want {
+		t.Errorf("quarantine =\n%s\nwant\n%s", b, want)
+	}
+	vs, err := readResults(out, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 1 || vs[0].Identifier != "CVE-3" || vs[0].ActionTimeframe == "" {
+		t.Errorf("streamed %+v", vs)
+	}
+}
+
+func TestRunTickets(t *testing.T) {
+	dir := t.TempDir()
+	jira := filepath.Join(dir, "jira.csv")
+	snow := filepath.Join(dir, "servicenow.csv")
+	var stderr bytes.Buffer
+	for path, format := range map[string]string{jira: "jira", snow: "servicenow"} {
+		if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_tickets", path, "-ticket_format", format}, nil, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+	}
+	if !strings.Contains(stderr.String(), `msg="wrote tickets" path=`+jira+` format=jira tickets=1377`) {
+		t.Errorf("log does not report the tickets:\n%s", stderr.String())
+	}
+	read := func(path string) [][]string {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		rows, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1+1377 {
+			t.Fatalf("%s has %d rows, want header + 1377", path, len(rows))
+		}
+		return rows
+	}
+	// In priority order, the 172 Immediate findings first.
+	rows := read(jira)
+	if rows[1][2] != "Highest" || rows[172][2] != "Highest" || rows[173][2] != "High" {
+		t.Errorf("Jira priorities %q, %q, %q", rows[1][2], rows[172][2], rows[173][2])
+	}
+	immediate := 0
+	for _, r := range read(snow)[1:] {
+		if r[5] == "1" {
+			immediate++
+		}
+	}
+	if immediate != 172 {
+		t.Errorf("%d ServiceNow tickets of priority 1, want 172", immediate)
+	}
+	if err := run([]string{"-input", sampleExport, "-output_tickets", jira, "-ticket_format", "github"}, nil, &stderr); err == nil {
+		t.Error("-ticket_format github accepted")
+	}
+}
+
+func TestRunTracking(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "findings.json")
+	b, err := os.ReadFile(sampleExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first 10 findings of the export.
+	rows, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var part bytes.Buffer
+	if err := csv.NewWriter(&part).WriteAll(rows[:11]); err != nil {
+		t.Fatal(err)
+	}
+	partial := filepath.Join(dir, "partial.csv")
+	if err := os.WriteFile(partial, part.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	for _, tc := range []struct {
+		input, today, want string
+	}{
+		{sampleExport, "2025-02-05", "new=1377 seen=0 reopened=0 resolved=0 rescored=0 open=1377 mttr_days=0 resolutions=0"},
+		{partial, "2025-02-12", "new=0 seen=10 reopened=0 resolved=1367 rescored=0 open=10 mttr_days=7 resolutions=1367"},
+		{sampleExport, "2025-02-19", "new=0 seen=10 reopened=1367 resolved=0 rescored=472 open=1377 mttr_days=7 resolutions=1367"},
+	} {
+		stderr.Reset()
+		// -severity drops findings from the outputs, not from tracking.
+		if err := run([]string{"-input", tc.input, "-today", tc.today, "-db", db, "-severity", "Critical"}, nil, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), `msg="tracked findings" path=`+db+" "+tc.want) {
+			t.Errorf("%s on %s: log does not report %s:\n%s", tc.input, tc.today, tc.want, stderr.String())
+		}
+	}
+	s, err := tracking.Load(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := s.Findings["1377"]
+	if f == nil || f.Status != tracking.Reopened || len(f.Transitions) != 3 || f.FirstSeen.Format(time.DateOnly) != "2025-02-05" {
+		t.Errorf("finding 1377 = %+v", f)
+	}
+	for _, args := range [][]string{{"-shard", "1/2"}, {"-read_only"}} {
+		if err := run(append([]string{"-input", sampleExport, "-db", db}, args...), nil, &stderr); err == nil {
+			t.Errorf("-db with %q accepted", args)
+		}
+	}
+}
+
+func TestRunAsOf(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "findings.json")
+	b, err := os.ReadFile(sampleExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var part bytes.Buffer
+	if err := csv.NewWriter(&part).WriteAll(rows[:11]); err != nil {
+		t.Fatal(err)
+	}
+	partial := filepath.Join(dir, "partial.csv")
+	if err := os.WriteFile(partial, part.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	for _, r := range []struct{ input, today string }{{sampleExport, "2025-02-05"}, {partial, "2025-02-12"}} {
+		if err := run([]string{"-input", r.input, "-today", r.today, "-db", db}, nil, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+	}
+	// The first finding of the export, risk accepted between the runs.
+	s, err := tracking.Load(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := s.Findings[rows[1][0]]
+	audit := filepath.Join(dir, "state-audit.jsonl")
+	if err := state.AppendAudit(audit, []state.Change{{At: time.Date(2025, 2, 10, 9, 0, 0, 0, time.UTC), Fingerprint: first.Fingerprint, From: state.Open, To: state.RiskAccepted}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		day            string
+		open, accepted int
+	}{
+		{"2025-02-04", 0, 0},
+		{"2025-02-05", 1377, 0},
+		{"2025-02-11", 1377, 1},
+		{"2025-02-12", 10, 1},
+	} {
+		stdout.Reset()
+		if err := run([]string{"as-of", tc.day, "-db", db, "-state", filepath.Join(dir, "state.json"), "-format", "json"}, &stdout, &stderr); err != nil {
+			t.Fatalf("as-of %s: %v", tc.day, err)
+		}
+		var p report.Posture
+		if err := json.Unmarshal(stdout.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		accepted := 0
+		for _, f := range p.Findings {
+			if f.State == state.RiskAccepted {
+				accepted++
+			}
+		}
+		if p.AsOf.String() != tc.day || p.Open != tc.open || len(p.Findings) != tc.open || accepted != tc.accepted || len(p.Evidence) != 2 {
+			t.Errorf("as of %s: %d open, %d risk accepted, evidence %+v", tc.day, p.Open, accepted, p.Evidence)
+		}
+	}
+
+	stdout.Reset()
+	if err := run([]string{"as-of", "2025-02-12", "-db", db, "-format", "csv"}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	got, err := csv.NewReader(&stdout).ReadAll()
+	if err != nil || len(got) != 11 || got[0][0] != "Priority Score" {
+		t.Errorf("CSV: %d rows, %v", len(got), err)
+	}
+
+	for _, args := range [][]string{
+		{"as-of", "-db", db},
+		{"as-of", "yesterday", "-db", db},
+		{"as-of", "2025-02-12"},
+		{"as-of", "2025-02-12", "-db", filepath.Join(dir, "none.json")},
+	} {
+		if err := run(args, &stdout, &stderr); err == nil {
+			t.Errorf("%q: no error", args)
+		}
+	}
+}
+
+func TestRunJira(t *testing.T) {
+	var issues [][]any // labels by issue number - 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pat" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			StartAt int            `json:"startAt"`
+			Fields  map[string]any `json:"fields"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case r.URL.Path == "/rest/api/2/search":
+			var page []map[string]any
+			for i := body.StartAt; i < len(issues); i++ {
+				page = append(page, map[string]any{"key": fmt.Sprintf("SEC-%d", i+1), "fields": map[string]any{"labels": issues[i]}})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"total": len(issues), "issues": page})
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			issues = append(issues, body.Fields["labels"].([]any))
+			json.NewEncoder(w).Encode(map[string]string{"key": fmt.Sprintf("SEC-%d", len(issues))})
+		case r.Method == http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("JIRA_URL", srv.URL)
+	t.Setenv("JIRA_USER", "")
+	t.Setenv("JIRA_API_TOKEN", "pat")
+	t.Setenv("JIRA_PROJECT", "SEC")
+
+	out := filepath.Join(t.TempDir(), "out.csv")
+	var stderr bytes.Buffer
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		// 502 findings score 7 or more, on 14 assets.
+		{nil, "group_by=asset groups=14 findings=502 created=14 updated=0"},
+		// 172 score 8 or more, in 33 packages, which have no issues yet.
+		{[]string{"-jira_group", "package", "-jira_min_score", "8"}, "group_by=package groups=33 findings=172 created=33 updated=0"},
+		{[]string{"-jira_group", "package", "-jira_min_score", "8"}, "group_by=package groups=33 findings=172 created=0 updated=33"},
+	} {
+		stderr.Reset()
+		args := append([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_csv", out, "-jira"}, tc.args...)
+		if err := run(args, nil, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		if !strings.Contains(stderr.String(), `msg="synced Jira issues" project=SEC `+tc.want) {
+			t.Errorf("%q: log does not report %s:\n%s", tc.args, tc.want, stderr.String())
+		}
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := slices.Index(rows[0], "Ticket")
+	filed := 0
+	for _, r := range rows[1:] {
+		if r[col] != "" {
+			filed++
+		}
+	}
+	if filed != 172 || !strings.HasPrefix(rows[1][col], "SEC-") {
+		t.Errorf("%d findings with a ticket, want 172; first %q", filed, rows[1][col])
+	}
+
+	for _, args := range [][]string{{"-jira", "-shard", "1/2"}, {"-jira", "-read_only"}, {"-jira_group", "package"}, {"-jira", "-jira_group", "team"}} {
+		if err := run(append([]string{"-input", sampleExport}, args...), nil, &stderr); err == nil {
+			t.Errorf("%q accepted", args)
+		}
+	}
+	t.Setenv("JIRA_PROJECT", "")
+	if err := run([]string{"-input", sampleExport, "-jira"}, nil, &stderr); err == nil || !strings.Contains(err.Error(), "JIRA_PROJECT") {
+		t.Errorf("without a project: err = %v", err)
+	}
+	// The config file fills in what the environment leaves out.
+	cfg := filepath.Join(t.TempDir(), "scoring.yaml")
+	if err := os.WriteFile(cfg, []byte("jira:\n  url: https://jira.invalid\n  project: SEC\n  group_by: package\n  min_score: 8\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stderr.Reset()
+	if err := run([]string{"-input", sampleExport, "-today", "2025-02-05", "-config", cfg, "-jira"}, nil, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), `msg="synced Jira issues" project=SEC group_by=package groups=33 findings=172 created=0 updated=33`) {
+		t.Errorf("-config: log does not report the package issues:\n%s", stderr.String())
+	}
+}
+
+func TestRunNotifyChat(t *testing.T) {
+	posts := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/teams-down" {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		posts[r.URL.Path] = string(b)
+	}))
+	defer srv.Close()
+	out := filepath.Join(t.TempDir(), "out.json")
+	var stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out,
+		"-notify_slack", srv.URL + "/slack", "-notify_teams", srv.URL + "/teams-down"}
+	// A failed post is logged; the run has written its outputs.
+	if err := run(args, &bytes.Buffer{}, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	logs := stderr.String()
+	if !strings.Contains(logs, `msg="posted summary" service=slack findings=1377 new_critical=1 sla_breaches=245`) ||
+		!strings.Contains(logs, `msg="posting summary" service=teams error="teams webhook: 502 Bad Gateway"`) {
+		t.Errorf("logs lack the posts:\n%s", logs)
+	}
+	if strings.Contains(logs, srv.URL) {
+		t.Error("logs name the webhook URL")
+	}
+	slack := posts["/slack"]
+	for _, want := range []string{
+		"CVE-2025-24964 in npm-vitest on acme-inc-webhooks: score 8.52, Immediate",
+		"245 of 1377 findings are past their due date",
+		"1. CVE-2024-45337 in go-golang.org/x/crypto on acme-inc-lambda: score 9.34, Immediate",
+	} {
+		if !strings.Contains(slack, want) {
+			t.Errorf("Slack post lacks %q:\n%s", want, slack)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-input", "x.csv", "-notify_slack", "hooks.slack.com/services/secret"},
+		{"-input", "x.csv", "-notify_teams", srv.URL, "-read_only"},
+		{"-input", "x.csv", "-notify_slack", srv.URL, "-shard", "1/2"},
+	} {
+		_, err := parseFlags(args, &bytes.Buffer{})
+		if err == nil || strings.Contains(err.Error(), "secret") {
+			t.Errorf("parseFlags(%q) = %v", args, err)
+		}
+	}
+}
+
+func TestRunTrace(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "x-api-key=secret")
+	var traces []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("X-Api-Key") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		traces = append(traces, body)
+	}))
+	defer srv.Close()
+	// spans returns the spans of the last trace by name, as "parent>name"
+	// for children, and their errors.
+	spans := func() map[string]string {
+		t.Helper()
+		if len(traces) == 0 {
+			t.Fatal("no trace exported")
+		}
+		rs := traces[len(traces)-1]["resourceSpans"].([]any)[0].(map[string]any)
+		list := rs["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)
+		names := make(map[string]string)
+		for _, s := range list {
+			s := s.(map[string]any)
+			names[s["spanId"].(string)] = s["name"].(string)
+		}
+		got := make(map[string]string)
+		for _, s := range list {
+			s := s.(map[string]any)
+			name := s["name"].(string)
+			if parent, ok := s["parentSpanId"].(string); ok {
+				name = names[parent] + ">" + name
+			}
+			status, _ := s["status"].(map[string]any)
+			msg, _ := status["message"].(string)
+			got[name] = msg
+		}
+		return got
+	}
+
+	var stderr bytes.Buffer
+	args := []string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", filepath.Join(t.TempDir(), "out.json"),
+		"-epss", "../../enrich/testdata/epss.csv", "-otlp_endpoint", srv.URL}
+	if err := run(args, &bytes.Buffer{}, &stderr); err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr.String())
+	}
+	got := spans()
+	for _, want := range []string{"prioritize", "prioritize>ingest", "prioritize>enrich", "enrich>enrich epss", "prioritize>score", "prioritize>confidence", "prioritize>output"} {
+		if msg, ok := got[want]; !ok || msg != "" {
+			t.Errorf("span %s: present %v, error %q; spans %v", want, ok, msg, got)
+		}
+	}
+	if !strings.Contains(stderr.String(), `msg="exported trace" trace_id=`) {
+		t.Errorf("export not logged:\n%s", stderr.String())
+	}
+
+	// A failed run is exported too, with its error on the root and the
+	// stage it failed in.
+	args = []string{"-input", sampleExport, "-today", "2025-02-05", "-post_cmd", "exit 1", "-otlp_endpoint", srv.URL}
+	if err := run(args, &bytes.Buffer{}, &bytes.Buffer{}); err == nil {
+		t.Fatal("failing post-processor: run = nil")
+	}
+	got = spans()
+	if !strings.Contains(got["prioritize"], "post-processor") || got["prioritize>postprocess"] != "span did not end" {
+		t.Errorf("failed run spans %v", got)
+	}
+
+	if _, err := parseFlags([]string{"-input", "x.csv", "-otlp_endpoint", "collector:4318"}, &bytes.Buffer{}); err == nil {
+		t.Error("-otlp_endpoint without a scheme accepted")
+	}
+}
+
+func TestRunRego(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake opa is written for sh")
+	}
+	f, err := os.Open(sampleExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ingest.ReadCSV(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Finding 1 scores 8.55 and finding 2 7.97, Urgent.
+	fp1, fp2 := res.Vulns[0].Fingerprint(), res.Vulns[1].Fingerprint()
+	dir := t.TempDir()
+	policy := filepath.Join(dir, "policy.rego")
+	if err := os.WriteFile(policy, []byte("package prioritizer\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A fake opa that prints the decisions whatever the policy says.
+	decisions := fmt.Sprintf(`{"result": [{"expressions": [{"value": {"suppress": {%q: "accepted"}, "escalate": {%q: "Immediate"}, "deny": {%q: "no", %q: "no"}}}]}]}`, fp1, fp2, fp1, fp2)
+	opa := filepath.Join(dir, "opa")
+	if err := os.WriteFile(opa, []byte("#!/bin/sh\ncat > /dev/null\necho '"+decisions+"'\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "out.csv")
+	var stderr bytes.Buffer
+	err = run([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_csv", out, "-rego", policy, "-opa", opa}, nil, &stderr)
+	// The suppressed finding 1 passes the gate; finding 2 fails it.
+	var failed *gate.Error
+	if !errors.As(err, &failed) || failed.Failed != 1 || !failed.Policy || err.Error() != "gate failed: 1 finding denied by policy" {
+		t.Errorf("err = %v, want finding 2 denied", err)
+	}
+	if !strings.Contains(stderr.String(), `msg="evaluated policies" files=1 suppressed=1 escalated=1 denied=2`) ||
+		!strings.Contains(stderr.String(), `msg="policy denies finding" identifier=CVE-2024-34342 asset=apply-frontend reason=no`) {
+		t.Errorf("log does not report the policy decisions:\n%s", stderr.String())
+	}
+	f, err = os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if res, err = ingest.ReadCSV(f); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range res.Vulns {
+		switch v.UniqueID {
+		case "1":
+			if !v.Suppressed || v.Waiver != "accepted" {
+				t.Errorf("finding 1 not suppressed: %v, %q", v.Suppressed, v.Waiver)
+			}
+		case "2":
+			if v.PriorityScore != 8 || v.ActionTimeframe != "Immediate" {
+				t.Errorf("finding 2 not escalated: %v, %s", v.PriorityScore, v.ActionTimeframe)
+			}
+		}
+	}
+
+	for _, args := range [][]string{
+		{"-rego", filepath.Join(dir, "missing.rego")},
+		{"-rego", policy, "-opa", filepath.Join(dir, "missing-opa")},
+	} {
+		if err := run(append([]string{"-input", sampleExport}, args...), nil, &stderr); err == nil || errors.As(err, new(*gate.Error)) {
+			t.Errorf("%q: err = %v", args, err)
+		}
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	// pipe runs the tool with the sample export as standard input.
+	pipe := func(t *testing.T, args ...string) (string, string, error) {
+		t.Helper()
+		f, err := os.Open(sampleExport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		saved := os.Stdin
+		os.Stdin = f
+		defer func() { os.Stdin = saved }()
+		var stdout, stderr bytes.Buffer
+		err = run(append(args, "-today", "2025-02-05"), &stdout, &stderr)
+		return stdout.String(), stderr.String(), err
+	}
+
+	stdout, stderr, err := pipe(t, "-input", "-", "-output", "-", "-quiet")
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr)
+	}
+	if stderr != "" {
+		t.Errorf("-quiet logged:\n%s", stderr)
+	}
+	vs, err := output.ReadJSON(strings.NewReader(stdout))
+	if err != nil {
+		t.Fatalf("standard output is not the findings as JSON: %v", err)
+	}
+	if len(vs) != 1377 || vs[0].PriorityScore < vs[len(vs)-1].PriorityScore {
+		t.Errorf("%d findings, from %v to %v", len(vs), vs[0].PriorityScore, vs[len(vs)-1].PriorityScore)
+	}
+
+	// Without -quiet the logs still go to standard error, and the history
+	// knows standard input by its checksum.
+	dir := t.TempDir()
+	hist := filepath.Join(dir, "history.json")
+	stdout, stderr, err = pipe(t, "-input", "-", "-output_csv", "-", "-history", hist, "-manifest", filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		t.Fatalf("run: %v\n%s", err, stderr)
+	}
+	if !strings.HasPrefix(stdout, "Unique ID,") || strings.Contains(stdout, "RANK  SCORE") {
+		t.Errorf("standard output is not only the CSV:\n%.200s", stdout)
+	}
+	if !strings.Contains(stderr, `msg="read input" path=- findings=1377`) {
+		t.Errorf("stderr lacks the input:\n%s", stderr)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	sample, err := manifest.HashFile(sampleExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Inputs) != 1 || m.Inputs[0].Path != "-" || m.Inputs[0].SHA256 != sample.SHA256 || len(m.Outputs) != 0 {
+		t.Errorf("manifest inputs %+v, outputs %+v", m.Inputs, m.Outputs)
+	}
+	if stdout, stderr, err = pipe(t, "-input", "-", "-output", "-", "-history", hist); err != nil || stdout != "" || !strings.Contains(stderr, "skipped input already processed") {
+		t.Errorf("second run: %v, stdout %.200q\n%s", err, stdout, stderr)
+	}
+
+	for _, args := range [][]string{
+		{"-input", "-", "-output", "-", "-output_md", "-"},
+		{"-input", "-", "-output", "-", "-output_json", "out.json"},
+		{"daemon", "-input", "-"},
+	} {
+		if _, _, err := pipe(t, args...); err == nil {
+			t.Errorf("%q: no error", args)
+		}
+	}
+}
+
+func TestRunAssets(t *testing.T) {
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets.yaml")
+	if err := os.WriteFile(assets, []byte("assets:\n  - asset: match-v1\n    criticality: business_critical\n    environment: prod\n    owner: matching-oncall@example.com\n  - asset: \"apply-*\"\n    environment: dev\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	scores := func(args ...string) (map[string]vuln.Vulnerability, string) {
+		t.Helper()
+		out := filepath.Join(dir, "out.json")
+		var stdout, stderr bytes.Buffer
+		if err := run(append([]string{"-input", sampleExport, "-today", "2025-02-05", "-output_json", out}, args...), &stdout, &stderr); err != nil {
+			t.Fatalf("run: %v\n%s", err, stderr.String())
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs, err := output.ReadJSON(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		byID := make(map[string]vuln.Vulnerability, len(vs))
+		for _, v := range vs {
+			byID[v.UniqueID] = v
+		}
+		return byID, stderr.String()
+	}
+	def, _ := scores()
+	weighted, log := scores("-assets", assets)
+	if !strings.Contains(log, `msg="applied asset inventory" assets=2 findings=1062`) {
+		t.Errorf("log lacks the inventory:\n%s", log)
+	}
+
+	for id, v := range def {
+		w, factor := weighted[id], 1.0
+		switch {
+		case v.AssetName == "match-v1":
+			factor = 1.2 * 1.1
+			if w.Owner != "matching-oncall@example.com" || w.Environment != vuln.EnvProduction {
+				t.Errorf("%s: owner %q, environment %q", id, w.Owner, w.Environment)
+			}
+		case strings.HasPrefix(v.AssetName, "apply-"):
+			factor = 0.8
+		}
+		if want := math.Min(v.PriorityScore*factor, 10); math.Abs(w.PriorityScore-want) > 0.02 && v.PriorityScore < 10 {
+			t.Errorf("%s on %s: score %v, %v with the inventory", v.Identifier, v.AssetName, v.PriorityScore, w.PriorityScore)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/VioletX-Dev/devsecops-test/merge"
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// runMerge combines partial result files, such as the outputs of -shard
+// runs or of separate teams, into one deduplicated, globally sorted result.
+func runMerge(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer merge", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	outputCSV := fs.String("output_csv", "", "write the merged findings to `file` as CSV")
+	outputJSON := fs.String("output_json", "", "write the merged findings to `file` as JSON")
+	outputJSONL := fs.String("output_jsonl", "", "write the merged findings to `file` as JSON Lines")
+	canonicalJSON := fs.Bool("canonical_json", false, "write -output_json as canonical JSON, for diffing: sorted keys, findings in fingerprint order and fixed number formatting")
+	conflict := fs.String("conflict", string(merge.Highest), "`rule` for differing copies of a finding: highest (priority score), first or last (input)")
+	noColor := fs.Bool("no_color", noColorEnv(), "print the terminal table without ANSI colors, which it has only on a terminal (default $NO_COLOR set)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("merge: no result files given")
+	}
+	rule, err := merge.ParseRule(*conflict)
+	if err != nil {
+		return fmt.Errorf("merge: %w", err)
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+
+	var parts [][]vuln.Vulnerability
+	for _, path := range fs.Args() {
+		part, err := readResults(path, logger)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, part)
+	}
+	vs, stats := merge.Merge(parts, rule)
+	logger.Info("merged results", "files", fs.NArg(), "read", stats.Read, "duplicates", stats.Duplicates, "conflicts", stats.Conflicts, "findings", len(vs))
+
+	if *outputCSV != "" {
+		if err := writeFile(*outputCSV, defaultFiles, vs, output.WriteCSV); err != nil {
+			return err
+		}
+	}
+	if *outputJSON != "" {
+		write := output.WriteJSON
+		if *canonicalJSON {
+			write = output.WriteCanonicalJSON
+		}
+		if err := writeFile(*outputJSON, defaultFiles, vs, write); err != nil {
+			return err
+		}
+	}
+	if *outputJSONL != "" {
+		if err := writeFile(*outputJSONL, defaultFiles, vs, output.WriteJSONL); err != nil {
+			return err
+		}
+	}
+
+	view := defaultView
+	view.color = !*noColor && isTerminal(stdout)
+	printToTerminal(stdout, vs, scoring.DefaultBands, view)
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/jobqueue"
+)
+
+// queueActions maps the queue subcommand's actions to their entry points.
+var queueActions = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"deliver": runQueueDeliver,
+	"list":    runQueueList,
+	"retry":   runQueueRetry,
+}
+
+// runQueue inspects and drains the durable job queue the daemon delivers
+// integration actions through.
+func runQueue(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || queueActions[args[0]] == nil {
+		names := make([]string, 0, len(queueActions))
+		for name := range queueActions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("queue: want an action: %s", strings.Join(names, ", "))
+	}
+	return queueActions[args[0]](args[1:], stdout, stderr)
+}
+
+// queueFlags parses the flags every queue action takes, after extra, when
+// set, registers the action's own, and loads the queue.
+func queueFlags(action string, args []string, stderr io.Writer, extra func(*flag.FlagSet)) (*flag.FlagSet, string, *jobqueue.Queue, error) {
+	fs := flag.NewFlagSet("prioritizer queue "+action, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if extra != nil {
+		extra(fs)
+	}
+	store := fs.String("store", "", "job queue `file` (JSON, required)")
+	if err := fs.Parse(args); err != nil {
+		return nil, "", nil, err
+	}
+	if *store == "" {
+		return nil, "", nil, fmt.Errorf("queue %s: -store is required", action)
+	}
+	q, err := jobqueue.Load(*store)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return fs, *store, q, nil
+}
+
+// runQueueList prints the pending jobs and the dead letters.
+func runQueueList(args []string, stdout, stderr io.Writer) error {
+	_, _, q, err := queueFlags("list", args, stderr, nil)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tKIND\tTARGET\tATTEMPTS\tNEXT ATTEMPT\tLAST ERROR")
+	for _, list := range []struct {
+		status string
+		jobs   []jobqueue.Job
+	}{{"pending", q.Pending}, {"dead", q.Dead}} {
+		for _, j := range list.jobs {
+			next := ""
+			if list.status == "pending" && !j.NextAttempt.IsZero() {
+				next = j.NextAttempt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", j.ID, list.status, j.Kind, j.Target, j.Attempts, next, j.LastError)
+		}
+	}
+	return tw.Flush()
+}
+
+// runQueueRetry moves dead letters back to the pending jobs.
+func runQueueRetry(args []string, stdout, stderr io.Writer) error {
+	fs, store, q, err := queueFlags("retry", args, stderr, nil)
+	if err != nil {
+		return err
+	}
+	if err := checkWritable("queue retry"); err != nil {
+		return err
+	}
+	n := q.Retry(fs.Args()...)
+	if n == 0 && fs.NArg() > 0 {
+		return errors.New("queue retry: no dead letter has those IDs")
+	}
+	if err := q.Save(store); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "requeued %d jobs\n", n)
+	return nil
+}
+
+// runQueueDeliver delivers the pending jobs that are due now, or with
+// -all every pending job.
+func runQueueDeliver(args []string, stdout, stderr io.Writer) error {
+	var all bool
+	_, store, q, err := queueFlags("deliver", args, stderr, func(fs *flag.FlagSet) {
+		fs.BoolVar(&all, "all", false, "also deliver the jobs waiting to be retried, as after an outage has ended")
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkWritable("queue deliver"); err != nil {
+		return err
+	}
+	if all {
+		for i := range q.Pending {
+			q.Pending[i].NextAttempt = time.Time{}
+		}
+	}
+	deliverQueue(context.Background(), q, store, slog.New(slog.NewTextHandler(stderr, nil)))
+	if len(q.Pending) > 0 {
+		return fmt.Errorf("queue deliver: %d jobs still pending", len(q.Pending))
+	}
+	return nil
+}
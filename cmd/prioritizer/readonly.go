@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// readOnlyVar names the environment variable that puts every command in
+// read-only mode, as on an analyst's laptop with production data.
+const readOnlyVar = "PRIORITIZER_READ_ONLY"
+
+// readOnlyEnv reports whether readOnlyVar is set to a true value.
+func readOnlyEnv() bool {
+	on, _ := strconv.ParseBool(os.Getenv(readOnlyVar))
+	return on
+}
+
+// checkWritable returns an error for the command, which changes a store,
+// in read-only mode.
+func checkWritable(command string) error {
+	if readOnlyEnv() {
+		return fmt.Errorf("%s: %s is set; the command would change a store", command, readOnlyVar)
+	}
+	return nil
+}
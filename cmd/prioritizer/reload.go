@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/enrich"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+)
+
+// reloadFlags are the flags naming files the daemon reloads when they
+// change: the scoring configuration, the policies, the false-positive
+// store and the asset mappings.
+var reloadFlags = []string{
+	"config", "profiles", "confidence_policy", "rego", "suppressions", "waivers", "renames", "assets", "decommissioned",
+	"regions", "classifications", "patch_windows", "remediation_templates",
+}
+
+// watcher tells when the files of reloadFlags change, by their contents,
+// so that touching a file without changing it reloads nothing.
+type watcher struct {
+	paths []string
+	sums  map[string]string
+}
+
+// newWatcher watches the files the reloadFlags of set name, as they are.
+func newWatcher(set *flag.FlagSet) *watcher {
+	w := &watcher{sums: make(map[string]string)}
+	for _, name := range reloadFlags {
+		for _, path := range strings.Split(set.Lookup(name).Value.String(), ",") {
+			if path != "" && !slices.Contains(w.paths, path) {
+				w.paths = append(w.paths, path)
+				w.sums[path] = digest(path)
+			}
+		}
+	}
+	return w
+}
+
+// changed returns the watched files whose contents changed since the last
+// call, or since newWatcher.
+func (w *watcher) changed() []string {
+	var changed []string
+	for _, path := range w.paths {
+		if sum := digest(path); sum != w.sums[path] {
+			w.sums[path] = sum
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
+
+// digest returns the SHA-256 of the contents of path, or of every file
+// under it for a directory, or "" if it cannot be read.
+func digest(path string) string {
+	h := sha256.New()
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", p, len(b))
+		h.Write(b)
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reloadDaemon parses the daemon's arguments again, reading its files as
+// they are now, and validates the result as a start would: every file
+// must load, and -rego policies compile. On an error the daemon keeps
+// running with cur. Daemon state that no file sets carries over.
+func reloadDaemon(ctx context.Context, args []string, extra func(*flag.FlagSet), cur *options) (*options, []enrich.Enricher, error) {
+	opts, err := parseFlagsWith("prioritizer daemon", args, io.Discard, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.rego != nil {
+		if err := opts.rego.Check(ctx, scoring.DefaultTiers); err != nil {
+			return nil, nil, fmt.Errorf("-rego: %w", err)
+		}
+	}
+	es, err := opts.enrich.enrichers()
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.daemon, opts.notifier = true, cur.notifier
+	return opts, es, nil
+}
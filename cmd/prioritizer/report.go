@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/effort"
+	"github.com/VioletX-Dev/devsecops-test/enrich"
+	"github.com/VioletX-Dev/devsecops-test/eol"
+	"github.com/VioletX-Dev/devsecops-test/hierarchy"
+	"github.com/VioletX-Dev/devsecops-test/imageref"
+	"github.com/VioletX-Dev/devsecops-test/quota"
+	"github.com/VioletX-Dev/devsecops-test/report"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// breakdowns maps -by values to their grouping key and table title.
+var breakdowns = map[string]struct {
+	title string
+	key   func(*vuln.Vulnerability) string
+}{
+	"asset":     {"ASSET", report.ByAsset},
+	"ecosystem": {"ECOSYSTEM", report.ByEcosystem},
+	"region":    {"REGION", report.ByRegion},
+	"team":      {"TEAM", quota.Team},
+}
+
+// topRisks is how many of a group's findings an executive summary names.
+const topRisks = 3
+
+// runReport scores an input and prints finding counts, mean scores and fix
+// availability grouped by one attribute, or rolled up through an
+// organization hierarchy.
+func runReport(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer report", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "vulnerability export `file` (CSV, required)")
+	by := fs.String("by", "ecosystem", "group findings by `attribute`: "+strings.Join(breakdownNames(), ", "))
+	regionsPath := fs.String("regions", "", "organization/account to region mapping `file` (JSON; default the cloud region in the account)")
+	configPath := fs.String("config", "", "scoring configuration `file` (JSON or YAML; default built-in weights)")
+	today := fs.String("today", "", "reference `date` (YYYY-MM-DD) (default current date)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	summary := fs.Bool("summary", false, "print an executive summary paragraph per group instead of the table")
+	hierarchyPath := fs.String("hierarchy", "", "account to business unit to division hierarchy `file` (JSON or YAML); roll findings up through it instead of -by")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return errors.New("report: -input is required")
+	}
+	var org *hierarchy.Hierarchy
+	if *hierarchyPath != "" {
+		bySet := false
+		fs.Visit(func(f *flag.Flag) { bySet = bySet || f.Name == "by" })
+		switch {
+		case bySet:
+			return errors.New("report: -by cannot be used with -hierarchy")
+		case *summary:
+			return errors.New("report: -summary cannot be used with -hierarchy")
+		}
+		var err error
+		if org, err = hierarchy.Load(*hierarchyPath); err != nil {
+			return err
+		}
+	}
+	b, ok := breakdowns[*by]
+	if !ok {
+		return fmt.Errorf("report: unknown -by %q", *by)
+	}
+	regions := &enrich.Regions{}
+	if *regionsPath != "" {
+		var err error
+		if regions, err = enrich.LoadRegions(*regionsPath); err != nil {
+			return err
+		}
+	}
+	vs, err := loadScored(*input, *configPath, *today, stderr)
+	if err != nil {
+		return err
+	}
+	vs, _ = imageref.Apply(vs)
+	if _, err := regions.Enrich(context.Background(), vs); err != nil {
+		return err
+	}
+
+	now, _ := parseToday(*today) // loadScored has parsed it
+	if *summary {
+		ss := report.Summaries(vs, b.key, topRisks, now)
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(ss)
+		}
+		return report.WriteSummaries(stdout, ss)
+	}
+	if org != nil {
+		rs := report.Rollups(vs, hierarchy.Levels, org.Path)
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(rs)
+		}
+		if err := report.WriteRollups(stdout, "DIVISION / BUSINESS UNIT / ACCOUNT", rs); err != nil {
+			return err
+		}
+	} else {
+		groups := report.Breakdown(vs, b.key)
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(groups)
+		}
+		if err := report.WriteText(stdout, b.title, groups); err != nil {
+			return err
+		}
+	}
+	if err := report.WriteEndOfLife(stdout, eol.Assets(vs, now)); err != nil {
+		return err
+	}
+	return report.WriteImages(stdout, imageref.Images(vs))
+}
+
+func breakdownNames() []string {
+	names := make([]string, 0, len(breakdowns))
+	for name := range breakdowns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadScored reads input and scores it, like a prioritization run, with the
+// configuration in configPath (default built-in) at the reference date
+// today (default the current date).
+func loadScored(input, configPath, today string, stderr io.Writer) ([]vuln.Vulnerability, error) {
+	now, err := parseToday(today)
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.Default()
+	if configPath != "" {
+		if cfg, err = config.Load(configPath); err != nil {
+			return nil, err
+		}
+	}
+	res, err := load(input, slog.New(slog.NewTextHandler(stderr, nil)))
+	if err != nil {
+		return nil, err
+	}
+	vs := res.Vulns
+	cfg.SLA.Apply(vs)
+	sla.Assess(vs, now)
+	effort.Apply(vs)
+	taxonomy.Apply(vs)
+	model, err := scoring.NewScorer(cfg.Model, cfg.Weights, now)
+	if err != nil {
+		return nil, err
+	}
+	scoring.Prioritize(vs, model, scoring.DefaultTiers)
+	scoring.Project(vs, model)
+	return vs, nil
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/sample"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// sampleActions maps the sample subcommand's actions to their entry points.
+var sampleActions = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"draw":   runSampleDraw,
+	"list":   runSampleList,
+	"verify": runSampleVerify,
+}
+
+// runSample manages the manual verification of findings: drawing a
+// risk-weighted random sample of a run's findings into the state store,
+// recording the analysts' verdicts, and listing them with each source's
+// accuracy.
+func runSample(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || sampleActions[args[0]] == nil {
+		names := make([]string, 0, len(sampleActions))
+		for name := range sampleActions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("sample: want an action: %s", strings.Join(names, ", "))
+	}
+	return sampleActions[args[0]](args[1:], stdout, stderr)
+}
+
+func sampleFlags(action string, stderr io.Writer) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("prioritizer sample "+action, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs, fs.String("store", "", "state store `file` (JSON, required)")
+}
+
+// runSampleDraw draws findings of a run that were not drawn before and
+// records them in the store, pending verification.
+func runSampleDraw(args []string, stdout, stderr io.Writer) error {
+	fs, store := sampleFlags("draw", stderr)
+	results := fs.String("results", "", "result `file` to draw findings from (default the first output of -manifest)")
+	manifestPath := fs.String("manifest", manifest.FileName, "find the latest results in the run manifest `file`")
+	n := fs.Int("n", 10, "draw `n` findings")
+	seed := fs.Uint64("seed", 0, "random `seed`; the same seed draws the same sample from the same results and store (default random)")
+	by := fs.String("by", os.Getenv("USER"), "`name` of who drew them")
+	dryRun := fs.Bool("dry_run", false, "print the sample without recording it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" {
+		return errors.New("sample draw: -store is required")
+	}
+	if *n < 1 {
+		return fmt.Errorf("sample draw: -n %d: want 1 or more", *n)
+	}
+	if !*dryRun {
+		if err := checkWritable("sample draw"); err != nil {
+			return err
+		}
+	}
+	path := *results
+	if path == "" {
+		var err error
+		if path, err = latestResults(*manifestPath); err != nil {
+			return fmt.Errorf("sample draw: %w", err)
+		}
+	}
+	vs, err := readResults(path, slog.New(slog.NewTextHandler(stderr, nil)))
+	if err != nil {
+		return err
+	}
+	s, err := state.Load(*store)
+	if err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+	rng := rand.New(rand.NewPCG(*seed, *seed^0x9e3779b97f4a7c15))
+	picked := sample.Draw(vs, *n, rng, func(v *vuln.Vulnerability) bool { return s.Sampled(v.Fingerprint()) })
+
+	now := time.Now()
+	samples := make([]*state.Sample, len(picked))
+	for i := range picked {
+		samples[i] = s.AddSample(&picked[i], now, *by)
+	}
+	writeSamples(stdout, samples)
+	if *dryRun {
+		fmt.Fprintf(stdout, "dry run: would sample %d findings (seed %d)\n", len(samples), *seed)
+		return nil
+	}
+	if err := s.Save(*store); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "sampled %d findings (seed %d)\n", len(samples), *seed)
+	return nil
+}
+
+// runSampleVerify records an analyst's verdict on sampled findings, given
+// by fingerprint or its first 12 digits.
+func runSampleVerify(args []string, stdout, stderr io.Writer) error {
+	fs, store := sampleFlags("verify", stderr)
+	verdict := fs.String("verdict", "", "the `verdict`: "+strings.Join(state.Verdicts, " or ")+" (required)")
+	note := fs.String("note", "", "what the analyst found")
+	by := fs.String("by", os.Getenv("USER"), "`name` of who verified them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" || *verdict == "" || fs.NArg() == 0 {
+		return errors.New("sample verify: -store, -verdict and at least one fingerprint are required")
+	}
+	if err := checkWritable("sample verify"); err != nil {
+		return err
+	}
+	v, err := state.ParseVerdict(*verdict)
+	if err != nil {
+		return fmt.Errorf("sample verify: %w", err)
+	}
+	s, err := state.Load(*store)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, fp := range fs.Args() {
+		sm, err := s.FindSample(fp)
+		if err != nil {
+			return fmt.Errorf("sample verify: %w", err)
+		}
+		sm.Verify(v, *note, *by, now)
+		fmt.Fprintf(stdout, "verified %s %s %s: %s\n", sm.Fingerprint, sm.Identifier, sm.PackageName, v)
+	}
+	return s.Save(*store)
+}
+
+// runSampleList prints the samples, most recently drawn first, and the
+// accuracy of each source.
+func runSampleList(args []string, stdout, stderr io.Writer) error {
+	fs, store := sampleFlags("list", stderr)
+	pending := fs.Bool("pending", false, "list only the samples not verified yet")
+	asJSON := fs.Bool("json", false, "print the samples and accuracy as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" {
+		return errors.New("sample list: -store is required")
+	}
+	s, err := state.Load(*store)
+	if err != nil {
+		return err
+	}
+	samples := s.SampleList()
+	if *pending {
+		samples = pendingSamples(samples)
+	}
+	accuracy := s.Accuracy()
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Samples  []*state.Sample  `json:"samples"`
+			Accuracy []state.Accuracy `json:"accuracy"`
+		}{samples, accuracy})
+	}
+	writeSamples(stdout, samples)
+	if len(accuracy) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nSOURCE\tSAMPLED\tVERIFIED\tCONFIRMED\tFALSE POSITIVES\tPRECISION")
+	for _, a := range accuracy {
+		precision := "-"
+		if a.Verified > 0 {
+			precision = fmt.Sprintf("%.0f%%", a.Precision*100)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\n", a.Source, a.Sampled, a.Verified, a.Confirmed, a.FalsePositives, precision)
+	}
+	return tw.Flush()
+}
+
+// pendingSamples returns the samples without a verdict.
+func pendingSamples(samples []*state.Sample) []*state.Sample {
+	var left []*state.Sample
+	for _, sm := range samples {
+		if sm.Verdict == "" {
+			left = append(left, sm)
+		}
+	}
+	return left
+}
+
+func writeSamples(w io.Writer, samples []*state.Sample) {
+	if len(samples) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SAMPLED\tFINGERPRINT\tSCORE\tIDENTIFIER\tASSET\tPACKAGE\tSOURCE\tVERDICT")
+	for _, sm := range samples {
+		asset := sm.AssetName
+		if asset == "" {
+			asset = sm.AssetID
+		}
+		verdict := sm.Verdict
+		if verdict == "" {
+			verdict = "pending"
+		}
+		fmt.Fprintf(tw, "%s\t%.12s\t%.2f\t%s\t%s\t%s\t%s\t%s\n", sm.SampledAt.Format(vuln.DateLayout), sm.Fingerprint, sm.PriorityScore, sm.Identifier, asset, sm.PackageName, sm.Source, verdict)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/VioletX-Dev/devsecops-test/fixtures"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+)
+
+// runFixtures writes a generated sample export in one of the input
+// profiles, valid findings mixed with the requested number of broken
+// rows, and logs where the broken rows are.
+func runFixtures(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer fixtures", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profile := fs.String("profile", "vendor", "input `profile`: "+profileNames())
+	rows := fs.Int("rows", 100, "number of valid findings")
+	broken := fs.Int("broken", 0, "number of broken rows, cycling through: "+strings.Join(fixtures.Defects, ", "))
+	seed := fs.Uint64("seed", 1, "random `seed`; the same seed writes the same export")
+	evolve := fs.Bool("evolve", false, "drift the schema: reorder columns, respell headers and add unknown columns")
+	out := fs.String("output", "", "write the export to `file` (default standard output)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	p, ok := fixtures.Lookup(*profile)
+	if !ok {
+		return fmt.Errorf("fixtures: unknown profile %q (want one of %s)", *profile, profileNames())
+	}
+	if *rows < 0 || *broken < 0 {
+		return errors.New("fixtures: -rows and -broken must not be negative")
+	}
+
+	w, f := stdout, (*os.File)(nil)
+	if *out != "" {
+		var err error
+		if f, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	fx, err := fixtures.Generate(w, p, fixtures.Options{Rows: *rows, Broken: *broken, Seed: *seed, Evolve: *evolve})
+	if err != nil {
+		return err
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+	for _, b := range fx.Broken {
+		logger.Info("broken row", "line", b.Line, "defect", b.Defect)
+	}
+	logger.Info("generated fixture", "profile", p.Name, "valid", len(fx.Findings), "broken", len(fx.Broken))
+	if f != nil {
+		return f.Close()
+	}
+	return nil
+}
+
+func profileNames() string {
+	names := make([]string, len(fixtures.Profiles))
+	for i, p := range fixtures.Profiles {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// runSelftest generates an export in every input profile, with and
+// without schema drift, runs the full pipeline on each and checks the
+// outcome against what was generated. It fails if any case does.
+func runSelftest(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer selftest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	rows := fs.Int("rows", 200, "number of valid findings per case")
+	broken := fs.Int("broken", 2*len(fixtures.Defects), "number of broken rows per case")
+	seed := fs.Uint64("seed", 1, "random `seed`")
+	verbose := fs.Bool("v", false, "log the pipeline runs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "prioritizer-selftest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	logs := io.Discard
+	if *verbose {
+		logs = stderr
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROFILE\tSCHEMA\tROWS\tBROKEN\tRESULT")
+	failed := 0
+	for _, p := range fixtures.Profiles {
+		for _, evolve := range []bool{false, true} {
+			schema := "as documented"
+			if evolve {
+				schema = "evolved"
+			}
+			opts := fixtures.Options{Rows: *rows, Broken: *broken, Seed: *seed, Evolve: evolve}
+			result := "PASS"
+			if err := selftestCase(filepath.Join(dir, p.Name+"-"+strings.ReplaceAll(schema, " ", "-")), p, opts, logs); err != nil {
+				result = "FAIL: " + err.Error()
+				failed++
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", p.Name, schema, *rows, *broken, result)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("selftest: %d of %d cases failed", failed, 2*len(fixtures.Profiles))
+	}
+	return nil
+}
+
+// selftestCase generates one export in dir, prioritizes it and checks the
+// results, quarantine and manifest.
+func selftestCase(dir string, p *fixtures.Profile, opts fixtures.Options, logs io.Writer) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	input := filepath.Join(dir, "export.csv")
+	f, err := os.Create(input)
+	if err != nil {
+		return err
+	}
+	fx, err := fixtures.Generate(f, p, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	results := filepath.Join(dir, "results.json")
+	quarantine := filepath.Join(dir, "quarantine.csv")
+	if err := runPrioritize([]string{"-input", input, "-output_json", results, "-quarantine", quarantine, "-today", "2025-02-05"}, io.Discard, logs); err != nil {
+		return err
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		return err
+	}
+	var m manifest.Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	if m.Rows.Read != len(fx.Findings) || m.Rows.Skipped != len(fx.Broken) || m.Rows.Written != len(fx.Findings) {
+		return fmt.Errorf("manifest counts %+v, want %d read and written, %d skipped", m.Rows, len(fx.Findings), len(fx.Broken))
+	}
+
+	vs, err := readResults(results, slog.New(slog.NewTextHandler(logs, nil)))
+	if err != nil {
+		return err
+	}
+	want := make(map[string]int, len(fx.Findings))
+	for i := range fx.Findings {
+		want[fx.Findings[i].Fingerprint()]++
+	}
+	maxScore := scoring.DefaultWeights().Max()
+	for i := range vs {
+		v := &vs[i]
+		if want[v.Fingerprint()] == 0 {
+			return fmt.Errorf("result %s on %s was not generated", v.Identifier, v.AssetName)
+		}
+		want[v.Fingerprint()]--
+		if v.PriorityScore < 0 || v.PriorityScore > maxScore || v.ActionTimeframe == "" {
+			return fmt.Errorf("%s scored %v %q", v.Identifier, v.PriorityScore, v.ActionTimeframe)
+		}
+		if i > 0 && v.PriorityScore > vs[i-1].PriorityScore {
+			return fmt.Errorf("results not in priority order at %d", i)
+		}
+	}
+
+	// Every broken row is quarantined with the line it was read from.
+	qf, err := os.Open(quarantine)
+	if err != nil {
+		return err
+	}
+	defer qf.Close()
+	records, err := csv.NewReader(qf).ReadAll()
+	if err != nil {
+		return fmt.Errorf("reading quarantine: %w", err)
+	}
+	if len(records)-1 != len(fx.Broken) {
+		return fmt.Errorf("quarantined %d rows, want %d", len(records)-1, len(fx.Broken))
+	}
+	for i, b := range fx.Broken {
+		record := records[i+1]
+		if reason := record[len(record)-1]; !strings.HasPrefix(reason, fmt.Sprintf("line %d: ", b.Line)) {
+			return fmt.Errorf("quarantined %q, want line %d (%s)", reason, b.Line, b.Defect)
+		}
+	}
+	return nil
+}
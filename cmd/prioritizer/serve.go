@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/api"
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/effort"
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// runServe serves a result file, and prioritizes uploaded exports, at once
+// or as background jobs, over HTTP until interrupted.
+func runServe(args []string, _, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	results := fs.String("results", "", "result `file` written by -output_csv or -output_json (default the first output of -manifest)")
+	manifestPath := fs.String("manifest", manifest.FileName, "find the latest results in the run manifest `file`")
+	configPath := fs.String("config", "", "scoring configuration `file` for POST /prioritize and POST /ingest (JSON or YAML; default built-in weights)")
+	addr := fs.String("addr", "localhost:8080", "listen on `address`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	cfg := config.Default()
+	if *configPath != "" {
+		var err error
+		if cfg, err = config.Load(*configPath); err != nil {
+			return fmt.Errorf("serve: %w", err)
+		}
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, nil))
+	path := *results
+	if path == "" {
+		var err error
+		path, err = latestResults(*manifestPath)
+		switch {
+		case errors.Is(err, os.ErrNotExist) && !explicit["manifest"]:
+			// Nothing to serve yet; uploads can still be prioritized.
+			logger.Info("no run manifest; serving uploads only", "manifest", *manifestPath)
+		case err != nil:
+			return fmt.Errorf("serve: %w", err)
+		}
+	}
+	var vs []vuln.Vulnerability
+	if path != "" {
+		var err error
+		if vs, err = readResults(path, logger); err != nil {
+			return err
+		}
+	}
+
+	handler := api.NewServer(vs)
+	handler.HandlePrioritize(uploadPrioritizer(cfg, logger))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	handler.HandleIngest(ctx, uploadIngester(cfg, logger))
+	srv := &http.Server{Addr: *addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	logger.Info("serving findings", "addr", *addr, "results", path, "findings", len(vs))
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// uploadPrioritizer returns the api.Prioritizer of the serve command. It
+// reads an upload like a run reads its input, or as findings when it is a
+// JSON array, and scores it like the report command does, with cfg.
+func uploadPrioritizer(cfg *config.Config, logger *slog.Logger) api.Prioritizer {
+	return func(_ context.Context, body []byte, format string, now time.Time) ([]vuln.Vulnerability, int, error) {
+		vs, skipped, err := prioritizeUpload(bytes.NewReader(body), int64(len(body)), format, now, cfg, func(string) {})
+		if err != nil {
+			return nil, 0, err
+		}
+		logger.Info("prioritized upload", "bytes", len(body), "findings", len(vs), "skipped", skipped)
+		return vs, skipped, nil
+	}
+}
+
+// uploadIngester returns the api.Ingester of the serve command, which
+// reads and scores an upload as uploadPrioritizer does.
+func uploadIngester(cfg *config.Config, logger *slog.Logger) api.Ingester {
+	return func(_ context.Context, r io.ReaderAt, size int64, format string, now time.Time, stage func(string)) ([]vuln.Vulnerability, int, error) {
+		start := time.Now()
+		vs, skipped, err := prioritizeUpload(r, size, format, now, cfg, stage)
+		if err != nil {
+			logger.Warn("ingestion failed", "bytes", size, "error", err)
+			return nil, 0, err
+		}
+		logger.Info("ingested upload", "bytes", size, "findings", len(vs), "skipped", skipped, "seconds", math.Round(time.Since(start).Seconds()*10)/10)
+		return vs, skipped, nil
+	}
+}
+
+// prioritizeUpload reads the size-byte upload r in format, or the detected
+// format, or as findings when it is a JSON array, and scores it with cfg
+// at now, calling stage once it is read.
+func prioritizeUpload(r io.ReaderAt, size int64, format string, now time.Time, cfg *config.Config, stage func(string)) ([]vuln.Vulnerability, int, error) {
+	var (
+		vs      []vuln.Vulnerability
+		skipped int
+	)
+	head := make([]byte, min(size, ingest.DetectBytes))
+	if _, err := r.ReadAt(head, 0); err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+	switch {
+	case format != "" && !slices.Contains(ingest.Formats, format):
+		return nil, 0, fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(ingest.Formats, ", "))
+	case format == "" && bytes.HasPrefix(bytes.TrimSpace(head), []byte("[")):
+		var err error
+		if vs, err = output.ReadJSON(io.NewSectionReader(r, 0, size)); err != nil {
+			return nil, 0, err
+		}
+	default:
+		res, err := readInput(r, size, "", format, "")
+		if errors.Is(err, errUnrecognised) {
+			return nil, 0, fmt.Errorf("%w; give the format parameter (one of %s)", err, strings.Join(ingest.Formats, ", "))
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		vs, skipped = res.Vulns, len(res.Skipped)
+	}
+	stage("scoring")
+	cfg.SLA.Apply(vs)
+	sla.Assess(vs, now)
+	effort.Apply(vs)
+	taxonomy.Apply(vs)
+	model, err := scoring.NewScorer(cfg.Model, cfg.Weights, now)
+	if err != nil {
+		return nil, 0, err
+	}
+	scoring.Prioritize(vs, model, scoring.DefaultTiers)
+	scoring.Project(vs, model)
+	return vs, skipped, nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/VioletX-Dev/devsecops-test/gsheets"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// sheetsFlags are the Google Sheets output flags.
+type sheetsFlags struct {
+	id          string
+	credentials string
+	api         string
+	client      *http.Client // the -egress client
+}
+
+// write replaces the severity worksheets of the spreadsheet with vs.
+func (f *sheetsFlags) write(ctx context.Context, vs []vuln.Vulnerability) error {
+	sa, err := gsheets.LoadServiceAccount(f.credentials)
+	if err != nil {
+		return err
+	}
+	w := &gsheets.Writer{Account: sa, SpreadsheetID: f.id, BaseURL: f.api, Client: f.client}
+	return w.Write(ctx, vs)
+}
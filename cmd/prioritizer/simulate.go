@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/simulate"
+)
+
+// runSimulate reports how a proposed configuration would change tiers, SLA
+// breaches and due dates compared to the current one, without writing any
+// results.
+func runSimulate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("prioritizer simulate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "vulnerability export `file` (CSV, required)")
+	proposedPath := fs.String("proposed", "", "proposed configuration `file` (JSON or YAML, required)")
+	currentPath := fs.String("current", "", "current configuration `file` (JSON or YAML; default built-in weights and input due dates)")
+	today := fs.String("today", "", "reference `date` (YYYY-MM-DD) (default current date)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" || *proposedPath == "" {
+		return errors.New("simulate: -input and -proposed are required")
+	}
+	now, err := parseToday(*today)
+	if err != nil {
+		return err
+	}
+
+	current := config.Default()
+	if *currentPath != "" {
+		if current, err = config.Load(*currentPath); err != nil {
+			return err
+		}
+	}
+	proposed, err := config.Load(*proposedPath)
+	if err != nil {
+		return err
+	}
+
+	res, err := load(*input, slog.New(slog.NewTextHandler(stderr, nil)))
+	if err != nil {
+		return err
+	}
+
+	report := simulate.Run(res.Vulns, current, proposed, now)
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	return report.WriteText(stdout)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/api"
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/jira"
+	"github.com/VioletX-Dev/devsecops-test/manifest"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// auditFileName is the audit log's file name next to the state store.
+const auditFileName = "state-audit.jsonl"
+
+// stateActions maps the state subcommand's actions to their entry points.
+var stateActions = map[string]func(args []string, stdout, stderr io.Writer) error{
+	"import": runStateImport,
+	"list":   runStateList,
+	"set":    runStateSet,
+}
+
+// runState manages the workflow state store: moving findings between
+// states in bulk, importing them from Jira tickets and listing the
+// recorded states.
+func runState(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || stateActions[args[0]] == nil {
+		names := make([]string, 0, len(stateActions))
+		for name := range stateActions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("state: want an action: %s", strings.Join(names, ", "))
+	}
+	return stateActions[args[0]](args[1:], stdout, stderr)
+}
+
+func stateFlags(action string, stderr io.Writer) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("prioritizer state "+action, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs, fs.String("store", "", "state store `file` (JSON, required)")
+}
+
+// runStateSet moves every finding a selection matches to one state,
+// recording each change in the audit log. With -dry_run it only prints
+// the changes.
+func runStateSet(args []string, stdout, stderr io.Writer) error {
+	fs, store := stateFlags("set", stderr)
+	results := fs.String("results", "", "result `file` to select findings from (default the first output of -manifest)")
+	manifestPath := fs.String("manifest", manifest.FileName, "find the latest results in the run manifest `file`")
+	selection := fs.String("select", "", "findings to change, as serve query parameters, e.g. `asset=match-v1&severity=Low` (required)")
+	to := fs.String("to", "", "`state` to move the findings to: "+strings.Join(state.States, ", ")+" (required)")
+	reason := fs.String("reason", "", "why the findings change state (required)")
+	by := fs.String("by", os.Getenv("USER"), "`name` of who changed them")
+	audit := fs.String("audit", "", "append the changes to the audit log `file` (default "+auditFileName+" next to -store)")
+	dryRun := fs.Bool("dry_run", false, "print the changes without making them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" || *selection == "" || *to == "" || *reason == "" {
+		return errors.New("state set: -store, -select, -to and -reason are required")
+	}
+	if !*dryRun {
+		if err := checkWritable("state set"); err != nil {
+			return err
+		}
+	}
+	target, err := state.ParseState(*to)
+	if err != nil {
+		return fmt.Errorf("state set: %w", err)
+	}
+	params, err := url.ParseQuery(*selection)
+	if err != nil {
+		return fmt.Errorf("state set: -select: %w", err)
+	}
+	q, err := api.ParseQuery(params)
+	if err != nil {
+		return fmt.Errorf("state set: -select: %w", err)
+	}
+	path := *results
+	if path == "" {
+		if path, err = latestResults(*manifestPath); err != nil {
+			return fmt.Errorf("state set: %w", err)
+		}
+	}
+	vs, err := readResults(path, slog.New(slog.NewTextHandler(stderr, nil)))
+	if err != nil {
+		return err
+	}
+	s, err := state.Load(*store)
+	if err != nil {
+		return err
+	}
+	s.Apply(vs)
+
+	changes := s.Plan(q.Select(vs), target, *reason, *by, time.Now())
+	writeChanges(stdout, changes)
+	if *dryRun {
+		fmt.Fprintf(stdout, "dry run: would move %d findings to %s\n", len(changes), target)
+		return nil
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "no findings to change")
+		return nil
+	}
+	if *audit == "" {
+		*audit = filepath.Join(filepath.Dir(*store), auditFileName)
+	}
+	// Audit first: a change is never made without its audit entry.
+	if err := state.AppendAudit(*audit, changes); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	s.Commit(changes)
+	if err := s.Save(*store); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "moved %d findings to %s\n", len(changes), target)
+	return nil
+}
+
+// runStateImport moves the findings that Jira issues track to the states
+// the issues' resolutions and statuses map to, as runStateSet does, so
+// that work done in Jira shows in the reports. The issues come from a
+// Jira CSV export or, with -jira, the Jira API.
+func runStateImport(args []string, stdout, stderr io.Writer) error {
+	fs, store := stateFlags("import", stderr)
+	tickets := fs.String("tickets", "", "read the issues from the Jira CSV export `file`")
+	jiraMode := fs.Bool("jira", false, "read the issues from the Jira API, configured by JIRA_URL, JIRA_USER, JIRA_API_TOKEN and JIRA_PROJECT or the jira section of -config")
+	configPath := fs.String("config", "", "configuration `file` with a jira section")
+	results := fs.String("results", "", "result `file` with the findings the issues track (default the first output of -manifest)")
+	manifestPath := fs.String("manifest", manifest.FileName, "find the latest results in the run manifest `file`")
+	mapping := fs.String("map", "", "override the states of resolutions and statuses, as in `Duplicate=resolved,Blocked=in_progress`")
+	by := fs.String("by", "jira", "`name` the changes are audited by")
+	audit := fs.String("audit", "", "append the changes to the audit log `file` (default "+auditFileName+" next to -store)")
+	dryRun := fs.Bool("dry_run", false, "print the changes without making them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" || (*tickets == "") == !*jiraMode {
+		return errors.New("state import: -store and one of -tickets or -jira are required")
+	}
+	if *configPath != "" && !*jiraMode {
+		return errors.New("state import: -config needs -jira")
+	}
+	if !*dryRun {
+		if err := checkWritable("state import"); err != nil {
+			return err
+		}
+	}
+	overrides, err := jira.ParseStates(*mapping)
+	if err != nil {
+		return fmt.Errorf("state import: -map: %w", err)
+	}
+	var issues []jira.Issue
+	if *jiraMode {
+		jc := jira.Config{}
+		if *configPath != "" {
+			cfg, err := config.Load(*configPath)
+			if err != nil {
+				return err
+			}
+			if cfg.Jira != nil {
+				jc = *cfg.Jira
+			}
+		}
+		jc.Env(os.Getenv)
+		if err := jc.Validate(); err != nil {
+			return fmt.Errorf("state import: -jira: %w", err)
+		}
+		if issues, err = (&jira.Syncer{Config: &jc}).Issues(context.Background()); err != nil {
+			return fmt.Errorf("state import: %w", err)
+		}
+	} else {
+		f, err := os.Open(*tickets)
+		if err != nil {
+			return err
+		}
+		issues, err = jira.ReadCSV(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("state import: %s: %w", *tickets, err)
+		}
+	}
+	path := *results
+	if path == "" {
+		if path, err = latestResults(*manifestPath); err != nil {
+			return fmt.Errorf("state import: %w", err)
+		}
+	}
+	vs, err := readResults(path, slog.New(slog.NewTextHandler(stderr, nil)))
+	if err != nil {
+		return err
+	}
+	s, err := state.Load(*store)
+	if err != nil {
+		return err
+	}
+	s.Apply(vs)
+
+	now := time.Now()
+	var changes []state.Change
+	tracking := make(map[string]bool) // keys of the issues that track a finding
+	for _, l := range jira.Links(issues, vs) {
+		tracking[l.Issue.Key] = true
+		to := l.Issue.State(overrides)
+		// An issue in progress does not reopen a resolved or accepted
+		// finding.
+		if to == "" || to == state.InProgress && l.Finding.State != state.Open {
+			continue
+		}
+		reason := "Jira " + l.Issue.Key + ": " + l.Issue.Status
+		if l.Issue.Resolution != "" {
+			reason += " (" + l.Issue.Resolution + ")"
+		}
+		changes = append(changes, s.Plan([]vuln.Vulnerability{*l.Finding}, to, reason, *by, now)...)
+	}
+	writeChanges(stdout, changes)
+	if n := len(issues) - len(tracking); n > 0 {
+		fmt.Fprintf(stdout, "%d of %d issues track no finding of %s\n", n, len(issues), path)
+	}
+	if *dryRun {
+		fmt.Fprintf(stdout, "dry run: would change %d findings from %d issues\n", len(changes), len(tracking))
+		return nil
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "no findings to change")
+		return nil
+	}
+	if *audit == "" {
+		*audit = filepath.Join(filepath.Dir(*store), auditFileName)
+	}
+	if err := state.AppendAudit(*audit, changes); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	s.Commit(changes)
+	if err := s.Save(*store); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "changed %d findings from %d issues\n", len(changes), len(tracking))
+	return nil
+}
+
+func writeChanges(w io.Writer, changes []state.Change) {
+	if len(changes) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FINGERPRINT\tIDENTIFIER\tASSET\tPACKAGE\tFROM\tTO")
+	for _, c := range changes {
+		asset := c.AssetName
+		if asset == "" {
+			asset = c.AssetID
+		}
+		fmt.Fprintf(tw, "%.12s\t%s\t%s\t%s\t%s\t%s\n", c.Fingerprint, c.Identifier, asset, c.PackageName, c.From, c.To)
+	}
+	tw.Flush()
+}
+
+// runStateList prints the recorded states, most recently changed first.
+func runStateList(args []string, stdout, stderr io.Writer) error {
+	fs, store := stateFlags("list", stderr)
+	asJSON := fs.Bool("json", false, "print the records as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *store == "" {
+		return errors.New("state list: -store is required")
+	}
+	s, err := state.Load(*store)
+	if err != nil {
+		return err
+	}
+	records := s.List()
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHANGED\tBY\tSTATE\tIDENTIFIER\tASSET\tPACKAGE\tFINGERPRINT\tREASON")
+	for _, r := range records {
+		asset := r.AssetName
+		if asset == "" {
+			asset = r.AssetID
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%.12s\t%s\n", r.ChangedAt.Format(vuln.DateLayout), r.ChangedBy, r.State, r.Identifier, asset, r.PackageName, r.Fingerprint, r.Reason)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/confidence"
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/platform"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/stream"
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// streamFlags are the prioritization flags prioritizer stream takes, as
+// each applies to one finding at a time. The others need every finding
+// at once: deduplication, sorting, reports, state and enrichment.
+var streamFlags = []string{
+	"input", "format", "output_csv", "output_mode", "encrypt", "quarantine", "custom_fields",
+	"batch_size", "flush_interval", "read_only", "quiet", "today", "config", "scoring_model", "sla", "profiles",
+	"confidence_policy", "demote", "remediation_hints", "remediation_templates", "renames", "assets",
+	"cvss", "temporal", "critical", "high", "medium", "low", "aws", "github", "fix", "transitive",
+}
+
+// runStream prioritizes a CSV export too large to hold in memory. Rows
+// stream from the reader through -workers scoring goroutines to the
+// -output_csv writer, with at most -buffer_size findings in between, and
+// are written in input order rather than priority order.
+func runStream(args []string, stdout, stderr io.Writer) error {
+	var (
+		set     *flag.FlagSet
+		buffer  int
+		workers int
+	)
+	opts, err := parseFlagsWith("prioritizer stream", args, stderr, func(fs *flag.FlagSet) {
+		set = fs
+		fs.IntVar(&buffer, "buffer_size", stream.DefaultBuffer, "hold at most `n` findings between reading and writing")
+		fs.IntVar(&workers, "workers", runtime.GOMAXPROCS(0), "score findings on `n` goroutines")
+	})
+	if err != nil {
+		return err
+	}
+	var unsupported []string
+	set.Visit(func(f *flag.Flag) {
+		if f.Name != "buffer_size" && f.Name != "workers" && !slices.Contains(streamFlags, f.Name) && !isFilterFlag(f.Name) {
+			unsupported = append(unsupported, "-"+f.Name)
+		}
+	})
+	switch {
+	case opts.github != nil:
+		return errors.New("stream: -github_repos alerts are pulled, not streamed; run without stream")
+	case len(unsupported) > 0:
+		return fmt.Errorf("stream: %s need every finding at once; run without stream", strings.Join(unsupported, ", "))
+	case opts.aws != nil:
+		return fmt.Errorf("stream: -input %s is pulled, not streamed; run without stream", opts.input)
+	case opts.outputCSV == "":
+		return errors.New("stream: -output_csv is required")
+	case buffer < 1:
+		return fmt.Errorf("stream: -buffer_size %d: want 1 or more", buffer)
+	case workers < 1:
+		return fmt.Errorf("stream: -workers %d: want 1 or more", workers)
+	}
+	model, err := scoring.NewScorer(opts.model, opts.weights, opts.today)
+	if err != nil {
+		return err
+	}
+	logger := newLogger(stderr, opts.quiet)
+	opts.files.stdout, opts.files.logger = stdout, logger
+
+	files, err := expandInputs(opts.inputs)
+	if err != nil {
+		return fmt.Errorf("stream: -input: %w", err)
+	}
+	if len(files) > 1 {
+		return fmt.Errorf("stream: -input %s names %d files; stream one export at a time, or run without stream", opts.input, len(files))
+	}
+	path := files[0]
+	var in io.Reader = os.Stdin
+	if path != stdio {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	r := bufio.NewReaderSize(in, ingest.DetectBytes)
+	format := opts.format
+	if format == "" {
+		head, err := r.Peek(ingest.DetectBytes)
+		if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+		format = ingest.DetectFormat(path, head)
+	}
+	if format != ingest.FormatCSV {
+		return fmt.Errorf("stream: %s is not CSV; run without stream", path)
+	}
+	src, err := ingest.NewCSVStream(r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if !opts.custom {
+		src.DropCustomFields()
+	}
+
+	out, err := createFile(opts.outputCSV, opts.files)
+	if err != nil {
+		return err
+	}
+	var (
+		qf         *outputFile
+		quarantine *ingest.Quarantine
+	)
+	if opts.quarantine != "" {
+		if qf, err = createFile(opts.quarantine, opts.files); err != nil {
+			out.Abort()
+			return err
+		}
+		if quarantine, err = ingest.NewQuarantine(qf, src.Header()); err != nil {
+			out.Abort()
+			qf.Abort()
+			return fmt.Errorf("writing %s: %w", opts.quarantine, err)
+		}
+	}
+	ctx := context.Background()
+	sink := output.NewCSVSink(out, weightProfileNames(opts.profiles), src.CustomColumns())
+	b := output.NewBatcher(ctx, sink, opts.batch)
+	p := &stream.Pipeline{
+		Process: func(vs []vuln.Vulnerability) ([]vuln.Vulnerability, error) {
+			return streamFinding(vs, opts, model, opts.confidence)
+		},
+		Write: b.Add,
+		Skip: func(e *ingest.RowError) error {
+			logger.Warn("skipped row", "input", path, "line", e.Line, "error", e.Err)
+			if quarantine == nil {
+				return nil
+			}
+			return quarantine.Write(e)
+		},
+		Workers: workers,
+		Buffer:  buffer,
+	}
+	stats, err := p.Run(ctx, src)
+	if cerr := b.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = sink.Close()
+	}
+	if err == nil {
+		err = out.Close()
+	} else {
+		out.Abort()
+	}
+	if quarantine != nil {
+		qerr := quarantine.Flush()
+		if err == nil && qerr == nil {
+			qerr = qf.Close()
+		} else {
+			qf.Abort()
+		}
+		if err == nil && qerr != nil {
+			return fmt.Errorf("writing %s: %w", opts.quarantine, qerr)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("streaming %s to %s: %w", path, opts.outputCSV, err)
+	}
+	logger.Info("streamed input", "path", path, "findings", stats.Read, "skipped", stats.Skipped, "written", stats.Written, "workers", workers, "buffer_size", buffer)
+	logger.Info("wrote CSV", "path", opts.outputCSV)
+	if quarantine != nil {
+		logger.Info("wrote quarantine", "path", opts.quarantine, "rows", stats.Skipped)
+	}
+	return nil
+}
+
+// streamFinding runs the stages of prioritize that read one finding at a
+// time over the one finding in vs, and returns it unless the filters drop
+// it. Platform details are read from the finding's own row, as the other
+// rows of its asset are not at hand.
+func streamFinding(vs []vuln.Vulnerability, opts *options, model scoring.Scorer, policy *confidence.Policy) ([]vuln.Vulnerability, error) {
+	if opts.renames != nil {
+		opts.renames.Apply(vs)
+	}
+	if opts.inventory != nil {
+		opts.inventory.Apply(vs)
+	}
+	platform.Apply(vs)
+	if opts.hints != nil {
+		if _, err := opts.hints.Apply(vs); err != nil {
+			return nil, fmt.Errorf("remediation templates: %w", err)
+		}
+	}
+	opts.sla.Apply(vs)
+	sla.Assess(vs, opts.today)
+	taxonomy.Apply(vs)
+	scoring.Prioritize(vs, model, scoring.DefaultTiers)
+	scoring.Project(vs, model)
+	config.ScoreProfiles(vs, opts.profiles, opts.today)
+	policy.Apply(vs)
+	if opts.demote {
+		policy.Demote(vs)
+		scoring.Rank(vs, scoring.DefaultTiers)
+	}
+	if opts.filter != nil {
+		vs = opts.filter.Select(vs)
+	}
+	return vs, nil
+}
+
+// isFilterFlag reports whether name is one of filterFlags.
+func isFilterFlag(name string) bool {
+	return slices.ContainsFunc(filterFlags, func(f struct{ name, param, usage string }) bool { return f.name == name })
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// terminalColumn is a column of the terminal table. cell formats the
+// finding at rank, counted from 1, and color, if set, returns the ANSI
+// color of its cell, or "" for none.
+type terminalColumn struct {
+	name  string
+	cell  func(rank int, v *vuln.Vulnerability) string
+	color func(v *vuln.Vulnerability) string
+}
+
+// ANSI colors of the terminal table. Every color sequence is as long as
+// ansiDefault, which colored columns start their other cells with, so that
+// the escapes do not upset the alignment of the table.
+const (
+	ansiRed     = "\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+	ansiDefault = "\x1b[39m"
+	ansiReset   = "\x1b[0m"
+)
+
+// severityColor colors Critical findings red and High ones yellow.
+func severityColor(v *vuln.Vulnerability) string {
+	switch v.Severity {
+	case vuln.Critical:
+		return ansiRed
+	case vuln.High:
+		return ansiYellow
+	}
+	return ""
+}
+
+// timeframeColor colors Immediate findings red and Urgent ones yellow.
+func timeframeColor(v *vuln.Vulnerability) string {
+	switch v.ActionTimeframe {
+	case "Immediate":
+		return ansiRed
+	case "Urgent":
+		return ansiYellow
+	}
+	return ""
+}
+
+// terminalColumns are the columns -columns selects from, in their default
+// order.
+var terminalColumns = []terminalColumn{
+	{"rank", func(rank int, _ *vuln.Vulnerability) string { return strconv.Itoa(rank) }, nil},
+	{"score", func(_ int, v *vuln.Vulnerability) string { return fmt.Sprintf("%.2f", v.PriorityScore) }, nil},
+	{"timeframe", func(_ int, v *vuln.Vulnerability) string { return v.ActionTimeframe }, timeframeColor},
+	{"severity", func(_ int, v *vuln.Vulnerability) string { return string(v.Severity) }, severityColor},
+	{"cvss", func(_ int, v *vuln.Vulnerability) string { return fmt.Sprintf("%.1f", v.CVSS) }, nil},
+	{"identifier", func(_ int, v *vuln.Vulnerability) string { return v.Identifier }, nil},
+	{"package", func(_ int, v *vuln.Vulnerability) string { return v.PackageName }, nil},
+	{"asset", func(_ int, v *vuln.Vulnerability) string { return v.AssetName }, nil},
+	{"due", func(_ int, v *vuln.Vulnerability) string { return v.DueDate.String() }, nil},
+}
+
+// terminalView selects the rows and columns of the terminal table.
+type terminalView struct {
+	top     int // rows per page; 0 for all
+	page    int // from 1
+	columns []terminalColumn
+	color   bool // color the severity and timeframe cells
+}
+
+// defaultView is the whole table.
+var defaultView = terminalView{page: 1, columns: terminalColumns}
+
+// parseColumns returns the terminal columns named in the comma-separated
+// list s, in its order.
+func parseColumns(s string) ([]terminalColumn, error) {
+	var cols []terminalColumn
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		i := slices.IndexFunc(terminalColumns, func(c terminalColumn) bool { return c.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column %q: want %s", name, strings.Join(columnNames(), ", "))
+		}
+		cols = append(cols, terminalColumns[i])
+	}
+	if len(cols) == 0 {
+		return nil, errors.New("no columns")
+	}
+	return cols, nil
+}
+
+// noColorEnv reports whether the NO_COLOR environment variable asks for
+// output without colors: it is set and not empty.
+func noColorEnv() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
+
+// isTerminal reports whether w is a terminal, as opposed to a pipe or a
+// file, which ANSI escapes would clutter.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func columnNames() []string {
+	names := make([]string, len(terminalColumns))
+	for i, c := range terminalColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+// printToTerminal prints the page of vs that view selects as an aligned
+// table, one row per finding, followed by a histogram of the priority
+// scores of all of vs in the bands starting at bounds and a summary of
+// their SLA breaches.
+func printToTerminal(w io.Writer, vs []vuln.Vulnerability, bounds []float64, view terminalView) {
+	from, to := 0, len(vs)
+	if view.top > 0 {
+		from = min((view.page-1)*view.top, len(vs))
+		to = min(from+view.top, len(vs))
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	cells := make([]string, len(view.columns))
+	for i, c := range view.columns {
+		cells[i] = strings.ToUpper(c.name)
+		if view.color && c.color != nil {
+			cells[i] = ansiDefault + cells[i] + ansiReset
+		}
+	}
+	fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	for i := from; i < to; i++ {
+		v := &vs[i]
+		for j, c := range view.columns {
+			cells[j] = c.cell(i+1, v)
+			if view.color && c.color != nil {
+				color := c.color(v)
+				if color == "" {
+					color = ansiDefault
+				}
+				cells[j] = color + cells[j] + ansiReset
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	tw.Flush()
+	if to-from < len(vs) {
+		pages := (len(vs) + view.top - 1) / view.top
+		if from == to {
+			fmt.Fprintf(w, "\nNo findings on page %d of %d.\n", view.page, pages)
+		} else {
+			fmt.Fprintf(w, "\nShowing findings %d-%d of %d (page %d of %d).\n", from+1, to, len(vs), view.page, pages)
+		}
+	}
+	if len(vs) > 0 {
+		h := scoring.NewHistogram(vs, bounds)
+		fmt.Fprintf(w, "\nPriority scores (mean %.2f):\n%s", h.Mean, h)
+	}
+	if s := sla.Summarize(vs); s.Tracked > 0 {
+		fmt.Fprintf(w, "\nSLA: %s\n", s)
+	}
+}
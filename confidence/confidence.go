@@ -0,0 +1,175 @@
+// Package confidence rates how likely each finding is to be real, from the
+// scanner's own confidence and policy heuristics, so likely false
+// positives can be demoted and high-scoring doubtful findings reviewed.
+package confidence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Rule caps the confidence of the findings it matches. Every pattern set
+// must match; an unset pattern matches anything.
+type Rule struct {
+	// Name is recorded in the noise reasons of matching findings.
+	Name string `json:"name"`
+	// Package, Asset and Identifier are regular expressions matched
+	// against the package name, the asset ID and the vulnerability
+	// identifier.
+	Package    string `json:"package,omitempty"`
+	Asset      string `json:"asset,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+	// Source matches the finding's source exactly.
+	Source string `json:"source,omitempty"`
+	// Confidence is the highest confidence, from 0 to 1, a matching
+	// finding keeps.
+	Confidence float64 `json:"confidence"`
+
+	pkg, asset, id *regexp.Regexp
+}
+
+// Policy is a set of heuristic rules and the thresholds that act on the
+// resulting confidence.
+type Policy struct {
+	// Threshold is the confidence below which a finding is likely noise.
+	Threshold float64 `json:"threshold"`
+	// ReviewScore is the priority score from which a finding below
+	// Threshold goes to the review queue.
+	ReviewScore float64 `json:"review_score"`
+	Rules       []Rule  `json:"rules"`
+}
+
+// Default returns the built-in policy. Its one rule targets kernel
+// packages found in container images: containers run the host's kernel,
+// so the image's kernel package is not what executes.
+func Default() *Policy {
+	p := &Policy{
+		Threshold:   0.5,
+		ReviewScore: 6,
+		Rules: []Rule{{
+			Name:       "kernel-in-container-image",
+			Package:    `^(linux|linux-image-.*|kernel)$`,
+			Asset:      `^arn:aws:ecr:`,
+			Confidence: 0.3,
+		}},
+	}
+	if err := p.compile(); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Load reads a JSON policy file over the defaults. Its rules, when given,
+// replace the built-in ones; an empty list disables them. Unknown keys are
+// rejected.
+func Load(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := Default()
+	defaults := p.Rules
+	p.Rules = nil
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if p.Rules == nil {
+		p.Rules = defaults
+	}
+	if err := p.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+func (p *Policy) compile() error {
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Confidence < 0 || r.Confidence > 1 {
+			return fmt.Errorf("rule %q: confidence %v out of range 0-1", r.Name, r.Confidence)
+		}
+		for _, f := range []struct {
+			src string
+			dst **regexp.Regexp
+		}{{r.Package, &r.pkg}, {r.Asset, &r.asset}, {r.Identifier, &r.id}} {
+			*f.dst = nil
+			if f.src == "" {
+				continue
+			}
+			re, err := regexp.Compile(f.src)
+			if err != nil {
+				return fmt.Errorf("rule %q: %w", r.Name, err)
+			}
+			*f.dst = re
+		}
+	}
+	return nil
+}
+
+// Matches reports whether r applies to v.
+func (r *Rule) Matches(v *vuln.Vulnerability) bool {
+	return (r.pkg == nil || r.pkg.MatchString(v.PackageName)) &&
+		(r.asset == nil || r.asset.MatchString(v.AssetID)) &&
+		(r.id == nil || r.id.MatchString(v.Identifier)) &&
+		(r.Source == "" || r.Source == v.Source)
+}
+
+// Apply sets the confidence of every finding: the scanner's confidence
+// from the input, or 1 when there is none, capped by each matching rule,
+// whose names are recorded as noise reasons. A scanner confidence of 0 is
+// treated as none. It returns the number of findings below Threshold.
+func (p *Policy) Apply(vs []vuln.Vulnerability) int {
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		if v.Confidence == 0 {
+			v.Confidence = 1
+		}
+		for j := range p.Rules {
+			r := &p.Rules[j]
+			if !r.Matches(v) {
+				continue
+			}
+			v.Confidence = min(v.Confidence, r.Confidence)
+			v.NoiseReasons = append(v.NoiseReasons, r.Name)
+		}
+		if v.Confidence < p.Threshold {
+			n++
+		}
+	}
+	return n
+}
+
+// ReviewQueue returns the findings below Threshold that score at least
+// ReviewScore, in the order of vs. They are likely noise by policy but
+// too severe to demote unseen.
+func (p *Policy) ReviewQueue(vs []vuln.Vulnerability) []vuln.Vulnerability {
+	var queue []vuln.Vulnerability
+	for i := range vs {
+		if vs[i].Confidence < p.Threshold && vs[i].PriorityScore >= p.ReviewScore {
+			queue = append(queue, vs[i])
+		}
+	}
+	return queue
+}
+
+// Demote scales the priority score and projected scores of every finding
+// below Threshold by its confidence, rounded to two decimals. Re-rank vs
+// afterwards.
+func (p *Policy) Demote(vs []vuln.Vulnerability) {
+	for i := range vs {
+		if v := &vs[i]; v.Confidence < p.Threshold {
+			for _, score := range []*float64{&v.PriorityScore, &v.ScoreIn30Days, &v.ScoreIn60Days, &v.ScoreIn90Days} {
+				*score = math.Round(*score*v.Confidence*100) / 100
+			}
+		}
+	}
+}
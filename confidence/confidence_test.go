@@ -0,0 +1,67 @@
+package confidence
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{PackageName: "linux", AssetID: "arn:aws:ecr:us-west-2:253710526682:repository/match-v1", PriorityScore: 7.2},
+		{PackageName: "linux", AssetID: "i-0abc", PriorityScore: 7.2}, // a host, whose kernel runs
+		{PackageName: "util-linux", AssetID: "arn:aws:ecr:us-west-2:253710526682:repository/match-v1", PriorityScore: 7.2},
+		{PackageName: "linux", AssetID: "arn:aws:ecr:us-west-2:253710526682:repository/match-v1", PriorityScore: 5.1},
+		{PackageName: "npm-ws", Confidence: 0.4, PriorityScore: 8}, // from the scanner
+	}
+	p := Default()
+	if n := p.Apply(vs); n != 3 {
+		t.Errorf("Apply = %d below threshold, want 3", n)
+	}
+	wantConf := []float64{0.3, 1, 1, 0.3, 0.4}
+	for i, want := range wantConf {
+		if vs[i].Confidence != want {
+			t.Errorf("finding %d: confidence %v, want %v", i, vs[i].Confidence, want)
+		}
+	}
+	if !reflect.DeepEqual(vs[0].NoiseReasons, []string{"kernel-in-container-image"}) || vs[1].NoiseReasons != nil {
+		t.Errorf("noise reasons = %v, %v", vs[0].NoiseReasons, vs[1].NoiseReasons)
+	}
+
+	queue := p.ReviewQueue(vs)
+	if len(queue) != 2 || queue[0].PriorityScore != 7.2 || queue[1].PackageName != "npm-ws" {
+		t.Errorf("review queue = %+v", queue)
+	}
+
+	p.Demote(vs)
+	wantScore := []float64{2.16, 7.2, 7.2, 1.53, 3.2}
+	for i, want := range wantScore {
+		if vs[i].PriorityScore != want {
+			t.Errorf("finding %d: demoted score %v, want %v", i, vs[i].PriorityScore, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	p, err := Load("testdata/policy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Threshold != 0.6 || p.ReviewScore != 6 || len(p.Rules) != 2 {
+		t.Fatalf("policy = %+v, want the file's rules and the default review score", p)
+	}
+	vs := []vuln.Vulnerability{
+		{PackageName: "npm-ws", Identifier: "GHSA-3h5v-q93c-6h6q", Source: "aws"},
+		{PackageName: "npm-ws", Identifier: "CVE-2024-37890", Source: "github"},
+		{PackageName: "linux", AssetID: "arn:aws:ecr:x", Source: "github"}, // built-in rule replaced
+	}
+	p.Apply(vs)
+	// Matching rules cap confidence; the lowest cap wins.
+	if vs[0].Confidence != 0.5 || !reflect.DeepEqual(vs[0].NoiseReasons, []string{"test-fixture", "aws-low"}) {
+		t.Errorf("finding 0 = %v %v", vs[0].Confidence, vs[0].NoiseReasons)
+	}
+	if vs[1].Confidence != 1 || vs[2].Confidence != 1 {
+		t.Errorf("confidences = %v, %v; want 1", vs[1].Confidence, vs[2].Confidence)
+	}
+}
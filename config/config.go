@@ -0,0 +1,227 @@
+// Package config loads scoring and SLA configuration files.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/jira"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Config is a scoring configuration. Fields left out of a file keep their
+// defaults.
+type Config struct {
+	// Model is the scoring model, one of scoring.Models; empty is
+	// scoring.ModelWeighted. Weights only apply to the weighted model.
+	Model   string          `json:"model,omitempty"`
+	Weights scoring.Weights `json:"weights"`
+	// SLA, when set, replaces each finding's due date with its first
+	// detected date plus the window for its severity.
+	SLA sla.Policy `json:"sla,omitempty"`
+	// Jira configures -jira; the JIRA_* environment variables override
+	// it. Keep the token in JIRA_API_TOKEN rather than the file.
+	Jira *jira.Config `json:"jira,omitempty"`
+}
+
+// Default returns the built-in configuration: default weights and no SLA
+// policy, so due dates come from the input.
+func Default() *Config {
+	return &Config{Weights: scoring.DefaultWeights()}
+}
+
+// Load reads a configuration file over the defaults. Files named .yaml or
+// .yml are YAML, with the same keys, and any other file JSON. Unknown keys
+// are rejected so that typos do not silently fall back to defaults.
+func Load(path string) (*Config, error) {
+	b, err := ReadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	defaults := cfg.Weights
+	// Decode the weight maps empty so that file keys can be canonicalised
+	// before they are merged over the defaults.
+	cfg.Weights.Severity, cfg.Weights.Source, cfg.Weights.Classification = nil, nil, nil
+	cfg.Weights.Criticality, cfg.Weights.Environment, cfg.Weights.Exploit = nil, nil, nil
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.normalize(defaults); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ReadDocument reads a JSON file, or a YAML file named .yaml or .yml as
+// the equivalent JSON, so that other files written in either take the
+// forms configuration files do.
+func ReadDocument(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		if b, err = yamlToJSON(b); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return b, nil
+}
+
+// normalize validates the model, canonicalises map keys, since files may
+// write "critical" or "AWS", merges the weight maps over defaults, and
+// validates the classification, criticality and environment factors,
+// normalization maximum, time buckets and SLA windows.
+func (c *Config) normalize(defaults scoring.Weights) error {
+	if c.Model != "" && !slices.Contains(scoring.Models, c.Model) {
+		return fmt.Errorf("model %q: want %s", c.Model, strings.Join(scoring.Models, ", "))
+	}
+	sources := make(map[string]float64)
+	for name, points := range defaults.Source {
+		sources[name] = points
+	}
+	for name, points := range c.Weights.Source {
+		sources[strings.ToLower(name)] = points
+	}
+	c.Weights.Source = sources
+
+	severity := make(map[vuln.Severity]float64)
+	for sev, points := range defaults.Severity {
+		severity[sev] = points
+	}
+	for name, points := range c.Weights.Severity {
+		sev, err := vuln.ParseSeverity(string(name))
+		if err != nil {
+			return fmt.Errorf("weights: %w", err)
+		}
+		severity[sev] = points
+	}
+	c.Weights.Severity = severity
+
+	classification := make(map[string]float64)
+	for name, factor := range defaults.Classification {
+		classification[name] = factor
+	}
+	for name, factor := range c.Weights.Classification {
+		class, err := vuln.ParseClassification(name)
+		if err != nil {
+			return fmt.Errorf("weights: %w", err)
+		}
+		if factor < 0 {
+			return fmt.Errorf("weights: negative factor for %s", class)
+		}
+		classification[class] = factor
+	}
+	c.Weights.Classification = classification
+
+	for _, f := range []struct {
+		weights  *map[string]float64
+		defaults map[string]float64
+		parse    func(string) (string, error)
+	}{
+		{&c.Weights.Criticality, defaults.Criticality, vuln.ParseCriticality},
+		{&c.Weights.Environment, defaults.Environment, vuln.ParseEnvironment},
+		{&c.Weights.Exploit, defaults.Exploit, vuln.ParseExploitMaturity},
+	} {
+		factors := maps.Clone(f.defaults)
+		if factors == nil {
+			factors = make(map[string]float64)
+		}
+		for name, factor := range *f.weights {
+			key, err := f.parse(name)
+			if err != nil {
+				return fmt.Errorf("weights: %w", err)
+			}
+			if factor < 0 {
+				return fmt.Errorf("weights: negative factor for %s", key)
+			}
+			factors[key] = factor
+		}
+		*f.weights = factors
+	}
+
+	if c.Weights.Class != nil {
+		classes := make(map[string]float64, len(c.Weights.Class))
+		for name, factor := range c.Weights.Class {
+			class, err := taxonomy.Parse(name)
+			if err != nil {
+				return fmt.Errorf("weights: %w", err)
+			}
+			if factor < 0 {
+				return fmt.Errorf("weights: negative factor for %s", class)
+			}
+			classes[class] = factor
+		}
+		c.Weights.Class = classes
+	}
+
+	if c.Weights.AttackVector != nil {
+		vectors := make(map[string]float64, len(c.Weights.AttackVector))
+		for name, factor := range c.Weights.AttackVector {
+			av, err := cvss.ParseAttackVector(name)
+			if err != nil {
+				return fmt.Errorf("weights: %w", err)
+			}
+			if factor < 0 {
+				return fmt.Errorf("weights: negative factor for %s", av)
+			}
+			vectors[av] = factor
+		}
+		c.Weights.AttackVector = vectors
+	}
+
+	if c.Weights.Reliability != nil {
+		reliability := make(map[string]float64, len(c.Weights.Reliability))
+		for name, factor := range c.Weights.Reliability {
+			if factor < 0 || factor > 1 {
+				return fmt.Errorf("weights: reliability %v for %s: want 0 to 1", factor, name)
+			}
+			reliability[strings.ToLower(strings.TrimSpace(name))] = factor
+		}
+		c.Weights.Reliability = reliability
+	}
+
+	if c.Weights.NormalizeMax < 0 {
+		return fmt.Errorf("weights: negative normalize_max %v", c.Weights.NormalizeMax)
+	}
+	overdue := 0
+	for _, b := range c.Weights.Time {
+		if b.WithinDays < 0 {
+			overdue++
+		}
+	}
+	if overdue > 1 {
+		return errors.New("weights: more than one overdue time bucket")
+	}
+
+	if c.SLA != nil {
+		policy := make(sla.Policy, len(c.SLA))
+		for name, days := range c.SLA {
+			sev, err := vuln.ParseSeverity(string(name))
+			if err != nil {
+				return fmt.Errorf("sla: %w", err)
+			}
+			if days < 0 {
+				return fmt.Errorf("sla: negative window for %s", sev)
+			}
+			policy[sev] = days
+		}
+		c.SLA = policy
+	}
+	return nil
+}
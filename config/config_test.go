@@ -0,0 +1,200 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load("testdata/proposed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := cfg.Weights
+	if w.Severity[vuln.Critical] != 6 || w.Severity[vuln.High] != 3 || len(w.Severity) != 4 {
+		t.Errorf("severity weights = %v", w.Severity)
+	}
+	if w.Source["aws"] != 2 || w.Source["github"] != 0.5 || w.Source["snyk"] != 0.75 {
+		t.Errorf("source weights = %v", w.Source)
+	}
+	if w.Classification["restricted"] != 2 || w.Classification["public"] != 0.75 {
+		t.Errorf("classification factors = %v", w.Classification)
+	}
+	if w.CVSS != 0.5 || len(w.Time) != 4 {
+		t.Errorf("unset weights lost their defaults: %+v", w)
+	}
+	if cfg.SLA[vuln.Critical] != 15 || cfg.SLA[vuln.High] != 30 || len(cfg.SLA) != 2 {
+		t.Errorf("SLA = %v", cfg.SLA)
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	fromYAML, err := Load("testdata/scoring.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := Load("testdata/scoring.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("YAML config = %+v\nJSON config = %+v", fromYAML, fromJSON)
+	}
+	w := fromYAML.Weights
+	if w.Source["wiz cloud"] != 1.25 || w.Source["snyk"] != 0.75 {
+		t.Errorf("source weights = %v", w.Source)
+	}
+	if len(w.Time) != 3 || w.Time[1] != (scoring.TimeBucket{WithinDays: 14, Points: 2}) {
+		t.Errorf("time buckets = %+v", w.Time)
+	}
+	if w.Max() != 16 {
+		t.Errorf("Max = %v, want normalize_max", w.Max())
+	}
+}
+
+func TestLoadClassFactors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "classes.yaml")
+	body := "weights:\n  class:\n    Remote Code Execution: 1.3\n    dos: 0.8\n"
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := cfg.Weights.Class; len(c) != 2 || c["rce"] != 1.3 || c["dos"] != 0.8 {
+		t.Errorf("class factors = %v", c)
+	}
+	if def := Default().Weights.Class; def != nil {
+		t.Errorf("default class factors = %v, want none", def)
+	}
+}
+
+func TestLoadAttackVectorFactors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vectors.yaml")
+	body := "weights:\n  attack_vector:\n    Network: 1.2\n    P: 0.6\n"
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if av := cfg.Weights.AttackVector; len(av) != 2 || av["network"] != 1.2 || av["physical"] != 0.6 {
+		t.Errorf("attack vector factors = %v", av)
+	}
+}
+
+func TestLoadModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssvc.yaml")
+	if err := os.WriteFile(path, []byte("model: ssvc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Model != scoring.ModelSSVC || cfg.Weights.CVSS != Default().Weights.CVSS {
+		t.Errorf("config = %+v", cfg)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	for name, body := range map[string]string{
+		"unknown key":          `{"wieghts": {}}`,
+		"unknown model":        `{"model": "epss"}`,
+		"unknown severity":     `{"sla": {"Severe": 3}}`,
+		"negative window":      `{"sla": {"Low": -1}}`,
+		"bad severity name":    `{"weights": {"severity": {"urgent": 1}}}`,
+		"bad classification":   `{"weights": {"classification": {"secret": 2}}}`,
+		"negative factor":      `{"weights": {"classification": {"public": -1}}}`,
+		"unknown class":        `{"weights": {"class": {"xss": 1.1}}}`,
+		"unknown criticality":  `{"weights": {"criticality": {"vital": 2}}}`,
+		"negative environment": `{"weights": {"environment": {"production": -1}}}`,
+		"unknown exploit":      `{"weights": {"exploit": {"functional": 1.2}}}`,
+		"unknown vector":       `{"weights": {"attack_vector": {"remote": 1.1}}}`,
+		"reliability above 1":  `{"weights": {"reliability": {"aws": 1.5}}}`,
+		"negative class":       `{"weights": {"class": {"dos": -0.5}}}`,
+		"negative max":         `{"weights": {"normalize_max": -5}}`,
+		"two overdue":          `{"weights": {"time": [{"within_days": -1, "points": 3}, {"within_days": -1, "points": 2}]}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "c.json")
+			if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+				t.Errorf("Load = %v, want error naming the file", err)
+			}
+		})
+	}
+}
+
+func TestLoadYAMLRejects(t *testing.T) {
+	for name, body := range map[string]string{
+		"unknown key":   "weights:\n  cvs: 1\n",
+		"tab indent":    "weights:\n\tcvss: 1\n",
+		"bad indent":    "weights:\n    cvss: 1\n  fix: 1\n",
+		"duplicate key": "weights:\n  cvss: 1\n  cvss: 2\n",
+		"not a mapping": "weights:\n  cvss 1\n",
+		"anchor":        "weights: &w\n  cvss: 1\n",
+		"block scalar":  "weights:\n  source: |\n    aws\n",
+		"two documents": "weights: {}\n---\nsla: {}\n",
+		"unclosed flow": "weights: {cvss: 1\n",
+		"string number": "weights:\n  cvss: high\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "c.yaml")
+			if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+				t.Errorf("Load = %v, want error naming the file", err)
+			}
+		})
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	ps, err := LoadProfiles("default, testdata/proposed.json,new=testdata/proposed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range ps {
+		names = append(names, p.Name)
+	}
+	if strings.Join(names, ",") != "default,proposed,new" {
+		t.Fatalf("profile names = %q", names)
+	}
+
+	// Critical is worth 6 points under the proposed weights, and its SLA
+	// makes the finding, detected 2025-01-01, due 15 days later.
+	v := vuln.Vulnerability{CVSS: 9.8, Severity: vuln.Critical, Source: "aws", FirstDetectedDate: vuln.NewDate(2025, 1, 1)}
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{v}
+	ScoreProfiles(vs, ps, now)
+	def := scoring.Weighted{Weights: scoring.DefaultWeights(), Now: now}.Score(v)
+	if got := vs[0].ProfileScores["default"]; got != def {
+		t.Errorf("default profile score = %v, want %v", got, def)
+	}
+	v.DueDate = vuln.NewDate(2025, 1, 16)
+	proposed := scoring.Weighted{Weights: ps[1].Config.Weights, Now: now}.Score(v)
+	if got := vs[0].ProfileScores["proposed"]; got != proposed || got == def {
+		t.Errorf("proposed profile score = %v, want %v", got, proposed)
+	}
+	if vs[0].PriorityScore != 0 || !vs[0].DueDate.IsZero() {
+		t.Errorf("ScoreProfiles changed the finding: %+v", vs[0])
+	}
+
+	if _, err := LoadProfiles("testdata/proposed.json,proposed=default"); err == nil {
+		t.Error("LoadProfiles accepted a repeated name")
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// DefaultProfile names the built-in configuration in a profile list.
+const DefaultProfile = "default"
+
+// Profile is a named configuration that findings are scored against
+// alongside the run's own weights, to compare scorings side by side.
+type Profile struct {
+	Name   string
+	Config *Config
+}
+
+// LoadProfiles loads a comma-separated list of profiles. Each entry is a
+// configuration file, named after its base name without the extension, a
+// "name=file" pair, or DefaultProfile for the built-in configuration.
+// Names must be unique.
+func LoadProfiles(list string) ([]Profile, error) {
+	var ps []Profile
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, path, named := strings.Cut(entry, "=")
+		if !named {
+			path = entry
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		p := Profile{Name: name}
+		if path == DefaultProfile {
+			p.Config = Default()
+		} else {
+			var err error
+			if p.Config, err = Load(path); err != nil {
+				return nil, err
+			}
+		}
+		if name == "" || seen[name] {
+			return nil, fmt.Errorf("profile %q: names must be unique and not empty", entry)
+		}
+		seen[name] = true
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// Score returns the priority score v gets under p at now: by p's weights,
+// with the due date p's SLA policy assigns, if any. With temporal
+// weights, v.TemporalCVSS must be set.
+func (p Profile) Score(v vuln.Vulnerability, now time.Time) float64 {
+	if due, ok := p.Config.SLA.DueDate(v); ok {
+		v.DueDate = due
+	}
+	return scoring.Weighted{Weights: p.Config.Weights, Now: now}.Score(v)
+}
+
+// ScoreProfiles sets every finding's ProfileScores to its score under
+// each of ps. Findings keep their PriorityScore and order.
+func ScoreProfiles(vs []vuln.Vulnerability, ps []Profile, now time.Time) {
+	if len(ps) == 0 {
+		return
+	}
+	for i := range vs {
+		scores := make(map[string]float64, len(ps))
+		for _, p := range ps {
+			scores[p.Name] = p.Score(vs[i], now)
+		}
+		vs[i].ProfileScores = scores
+	}
+}
@@ -0,0 +1,443 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// yamlToJSON converts a YAML configuration document to JSON, so that it
+// is decoded, and checked for unknown keys, exactly as a JSON file is.
+//
+// It reads the part of YAML that configuration files need: block mappings
+// and sequences, flow mappings and sequences such as
+// {within_days: 7, points: 2.5}, plain, single- and double-quoted scalars,
+// and comments. Anchors, aliases, tags, block scalars and multiple
+// documents are rejected rather than misread.
+func yamlToJSON(b []byte) ([]byte, error) {
+	lines, err := yamlLines(string(b))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return []byte("{}"), nil
+	}
+	p := &yamlParser{lines: lines}
+	v, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return json.Marshal(v)
+}
+
+// yamlLine is a line with content, without its indentation and comment.
+type yamlLine struct {
+	num    int // 1-based
+	indent int
+	text   string
+}
+
+func yamlLines(doc string) ([]yamlLine, error) {
+	var lines []yamlLine
+	for n, raw := range strings.Split(strings.TrimPrefix(doc, "\ufeff"), "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		text := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("line %d: tabs cannot indent YAML", n+1)
+		}
+		text = strings.TrimSpace(stripComment(text))
+		switch {
+		case text == "":
+			continue
+		case text == "---" && len(lines) == 0:
+			continue
+		case text == "---", text == "...":
+			return nil, fmt.Errorf("line %d: only one YAML document is supported", n+1)
+		}
+		lines = append(lines, yamlLine{num: n + 1, indent: len(raw) - len(strings.TrimLeft(raw, " ")), text: text})
+	}
+	return lines, nil
+}
+
+// stripComment cuts a comment, which starts at a # outside quotes that
+// begins the line or follows a space.
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'' && c == '\'':
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			quote = 0
+		case quote == '"' && c == '\\':
+			i++
+		case quote == '"' && c == '"':
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	num := p.lines[len(p.lines)-1].num
+	if p.i < len(p.lines) {
+		num = p.lines[p.i].num
+	}
+	return fmt.Errorf("line %d: %s", num, fmt.Sprintf(format, args...))
+}
+
+// block parses the mapping or sequence whose lines start at indent.
+func (p *yamlParser) block(indent int) (any, error) {
+	if isSeqItem(p.lines[p.i].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	seq := []any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isSeqItem(p.lines[p.i].text) {
+		l := &p.lines[p.i]
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		switch {
+		case rest == "":
+			p.i++
+			if p.i == len(p.lines) || p.lines[p.i].indent <= indent {
+				seq = append(seq, nil)
+				continue
+			}
+			v, err := p.block(p.lines[p.i].indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+		case isSeqItem(rest) || mappingKey(rest) >= 0:
+			// An item that starts a nested block on the dash's line, as in
+			// "- within_days: 7": the block is indented to where it starts.
+			l.indent += len(l.text) - len(rest)
+			l.text = rest
+			v, err := p.block(l.indent)
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, v)
+		default:
+			v, err := p.inline(rest)
+			if err != nil {
+				return nil, err
+			}
+			p.i++
+			seq = append(seq, v)
+		}
+	}
+	return seq, nil
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent {
+		l := p.lines[p.i]
+		if isSeqItem(l.text) {
+			return nil, p.errorf("sequence item in a mapping")
+		}
+		colon := mappingKey(l.text)
+		if colon < 0 {
+			return nil, p.errorf("want \"key: value\", got %q", l.text)
+		}
+		key, err := p.key(l.text[:colon])
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		rest := strings.TrimSpace(l.text[colon+1:])
+		p.i++
+		switch {
+		case rest != "":
+			if m[key], err = p.inlineAt(rest, p.i-1); err != nil {
+				return nil, err
+			}
+		case p.i < len(p.lines) && p.lines[p.i].indent > indent:
+			if m[key], err = p.block(p.lines[p.i].indent); err != nil {
+				return nil, err
+			}
+		case p.i < len(p.lines) && p.lines[p.i].indent == indent && isSeqItem(p.lines[p.i].text):
+			// A sequence may sit at its key's indentation.
+			if m[key], err = p.sequence(indent); err != nil {
+				return nil, err
+			}
+		default:
+			m[key] = nil
+		}
+	}
+	return m, nil
+}
+
+// mappingKey returns the index of the colon that ends the key of a
+// "key: value" line, or -1 when text is not one.
+func mappingKey(text string) int {
+	if text == "" || strings.ContainsRune("[{", rune(text[0])) {
+		return -1
+	}
+	if q := text[0]; q == '"' || q == '\'' {
+		end, err := quotedEnd(text)
+		if err != nil || end == len(text) || text[end] != ':' || end+1 < len(text) && text[end+1] != ' ' {
+			return -1
+		}
+		return end
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *yamlParser) key(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		return unquote(s)
+	}
+	if s == "" {
+		return "", p.errorf("empty key")
+	}
+	return s, nil
+}
+
+// inline parses the value on the current line and moves past it.
+func (p *yamlParser) inline(s string) (any, error) {
+	return p.inlineAt(s, p.i)
+}
+
+func (p *yamlParser) inlineAt(s string, line int) (any, error) {
+	fail := func(err error) error {
+		return fmt.Errorf("line %d: %v", p.lines[line].num, err)
+	}
+	switch s[0] {
+	case '|', '>':
+		return nil, fail(errors.New("block scalars are not supported"))
+	case '&', '*', '!':
+		return nil, fail(errors.New("anchors, aliases and tags are not supported"))
+	}
+	f := &flow{s: s}
+	v, err := f.value(false)
+	if err == nil {
+		f.space()
+		if f.pos < len(f.s) {
+			err = fmt.Errorf("unexpected %q after the value", f.s[f.pos:])
+		}
+	}
+	if err != nil {
+		return nil, fail(err)
+	}
+	return v, nil
+}
+
+// flow parses an inline value, which may be a flow collection.
+type flow struct {
+	s   string
+	pos int
+}
+
+func (f *flow) space() {
+	for f.pos < len(f.s) && f.s[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+// value parses a scalar or collection. In a collection, plain scalars end
+// at a comma or closing bracket.
+func (f *flow) value(inCollection bool) (any, error) {
+	f.space()
+	if f.pos == len(f.s) {
+		return nil, nil
+	}
+	switch f.s[f.pos] {
+	case '{':
+		return f.mapping()
+	case '[':
+		return f.sequence()
+	case '"', '\'':
+		end, err := quotedEnd(f.s[f.pos:])
+		if err != nil {
+			return nil, err
+		}
+		s, err := unquote(f.s[f.pos : f.pos+end])
+		f.pos += end
+		return s, err
+	}
+	start := f.pos
+	for f.pos < len(f.s) {
+		c := f.s[f.pos]
+		if inCollection && (c == ',' || c == ']' || c == '}') {
+			break
+		}
+		if inCollection && c == ':' && (f.pos+1 == len(f.s) || f.s[f.pos+1] == ' ') {
+			break
+		}
+		f.pos++
+	}
+	return scalar(strings.TrimSpace(f.s[start:f.pos])), nil
+}
+
+func (f *flow) sequence() (any, error) {
+	f.pos++ // [
+	seq := []any{}
+	for {
+		f.space()
+		if f.pos < len(f.s) && f.s[f.pos] == ']' {
+			f.pos++
+			return seq, nil
+		}
+		v, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, v)
+		if err := f.next(']'); err != nil {
+			return nil, err
+		}
+		if f.s[f.pos-1] == ']' {
+			return seq, nil
+		}
+	}
+}
+
+func (f *flow) mapping() (any, error) {
+	f.pos++ // {
+	m := map[string]any{}
+	for {
+		f.space()
+		if f.pos < len(f.s) && f.s[f.pos] == '}' {
+			f.pos++
+			return m, nil
+		}
+		key, err := f.key()
+		if err != nil {
+			return nil, err
+		}
+		f.space()
+		if f.pos == len(f.s) || f.s[f.pos] != ':' {
+			return nil, fmt.Errorf("want \":\" after the key %q", key)
+		}
+		f.pos++
+		v, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+		m[key] = v
+		if err := f.next('}'); err != nil {
+			return nil, err
+		}
+		if f.s[f.pos-1] == '}' {
+			return m, nil
+		}
+	}
+}
+
+// key parses a flow mapping key, which is always a string.
+func (f *flow) key() (string, error) {
+	if q := f.s[f.pos]; q == '"' || q == '\'' {
+		end, err := quotedEnd(f.s[f.pos:])
+		if err != nil {
+			return "", err
+		}
+		s, err := unquote(f.s[f.pos : f.pos+end])
+		f.pos += end
+		return s, err
+	}
+	start := f.pos
+	for f.pos < len(f.s) && f.s[f.pos] != ':' && f.s[f.pos] != ',' && f.s[f.pos] != '}' {
+		f.pos++
+	}
+	key := strings.TrimSpace(f.s[start:f.pos])
+	if key == "" {
+		return "", errors.New("empty key")
+	}
+	return key, nil
+}
+
+// next moves past the comma before the next entry, or the closing bracket.
+func (f *flow) next(closing byte) error {
+	f.space()
+	if f.pos < len(f.s) && (f.s[f.pos] == ',' || f.s[f.pos] == closing) {
+		f.pos++
+		return nil
+	}
+	return fmt.Errorf("want \",\" or %q", closing)
+}
+
+// quotedEnd returns the index just past the quoted scalar s starts with.
+func quotedEnd(s string) (int, error) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i + 1, nil
+		}
+	}
+	return 0, errors.New("unterminated quoted string")
+}
+
+// unquote returns the value of a quoted scalar. Double-quoted scalars take
+// JSON's escapes, and single-quoted ones escape a quote by doubling it.
+func unquote(s string) (string, error) {
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return strconv.Unquote(s)
+}
+
+var yamlNumber = regexp.MustCompile(`^[-+]?(\d+(\.\d*)?|\.\d+)([eE][-+]?\d+)?$`)
+
+// scalar resolves a plain scalar to null, a boolean, a number or a string,
+// as YAML 1.2's core schema does.
+func scalar(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlNumber.MatchString(s) {
+		f, err := strconv.ParseFloat(s, 64)
+		if err == nil {
+			return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+		}
+	}
+	return s
+}
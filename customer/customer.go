@@ -0,0 +1,145 @@
+// Package customer reads the contracts of a managed security service's
+// customers: which cloud accounts each customer owns, the remediation
+// windows its contract sets and the SLA adherence it was promised, so that
+// each customer's SLA attainment is measured against its own terms rather
+// than the provider's default policy.
+package customer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Customer is one customer and its contracted terms.
+type Customer struct {
+	Name string `json:"name"`
+	// Accounts are account IDs, as in 253710526682, or full
+	// Organization/Account values, as in acme-inc-Education.
+	Accounts []string `json:"accounts"`
+	// SLA is the contracted remediation window, in days from first
+	// detection, of each severity. Severities it leaves out keep the
+	// input's due dates.
+	SLA sla.Policy `json:"sla"`
+	// TargetAdherence is the contracted share of findings to remediate
+	// within their SLA, from 0 to 1, or 0 for none.
+	TargetAdherence float64 `json:"target_adherence,omitempty"`
+}
+
+// Customers is the customers of a customer file.
+type Customers struct {
+	Customers []Customer `json:"customers"`
+
+	owners map[string]int // account to the index of its customer
+}
+
+// Load reads a customer file, as YAML when it is named .yaml or .yml and
+// JSON otherwise:
+//
+//	customers:
+//	  - name: Acme Inc
+//	    accounts: ["253710526682", acme-inc-Education]
+//	    sla: {Critical: 15, High: 30}
+//	    target_adherence: 0.95
+//
+// Unknown keys are rejected, as are customers without a name or accounts,
+// names and accounts listed more than once, names with the same FileName,
+// unknown severities, negative windows and targets outside 0 to 1.
+func Load(file string) (*Customers, error) {
+	b, err := config.ReadDocument(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	cs := &Customers{}
+	if err := dec.Decode(cs); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	cs.owners = make(map[string]int)
+	names := make(map[string]string) // file name to customer name
+	for i := range cs.Customers {
+		c := &cs.Customers[i]
+		c.Name = strings.TrimSpace(c.Name)
+		switch {
+		case c.Name == "":
+			return nil, fmt.Errorf("%s: customer %d has no name", file, i+1)
+		case names[c.FileName()] == c.Name:
+			return nil, fmt.Errorf("%s: customer %s listed more than once", file, c.Name)
+		case names[c.FileName()] != "":
+			return nil, fmt.Errorf("%s: customers %s and %s share the file name %s", file, names[c.FileName()], c.Name, c.FileName())
+		case len(c.Accounts) == 0:
+			return nil, fmt.Errorf("%s: %s: no accounts", file, c.Name)
+		case c.TargetAdherence < 0 || c.TargetAdherence > 1:
+			return nil, fmt.Errorf("%s: %s: target_adherence %g: want 0 to 1", file, c.Name, c.TargetAdherence)
+		}
+		names[c.FileName()] = c.Name
+		for k, account := range c.Accounts {
+			account = strings.TrimSpace(account)
+			if account == "" {
+				return nil, fmt.Errorf("%s: %s: account %d is blank", file, c.Name, k+1)
+			}
+			if _, dup := cs.owners[account]; dup {
+				return nil, fmt.Errorf("%s: account %s listed more than once", file, account)
+			}
+			c.Accounts[k] = account
+			cs.owners[account] = i
+		}
+		policy := make(sla.Policy, len(c.SLA))
+		for name, days := range c.SLA {
+			sev, err := vuln.ParseSeverity(string(name))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: sla: %w", file, c.Name, err)
+			}
+			if days < 0 {
+				return nil, fmt.Errorf("%s: %s: sla: negative window for %s", file, c.Name, sev)
+			}
+			policy[sev] = days
+		}
+		c.SLA = policy
+	}
+	return cs, nil
+}
+
+// Owner returns the customer that owns v's account: the one listing its
+// Organization/Account value or the account ID before its colon. It
+// returns nil when no customer does.
+func (cs *Customers) Owner(v *vuln.Vulnerability) *Customer {
+	org := strings.TrimSpace(v.Organization)
+	if i, ok := cs.owners[org]; ok {
+		return &cs.Customers[i]
+	}
+	if id, _, ok := strings.Cut(org, ":"); ok {
+		if i, ok := cs.owners[strings.TrimSpace(id)]; ok {
+			return &cs.Customers[i]
+		}
+	}
+	return nil
+}
+
+// FileName returns a file name for c's reports, its name in lower case
+// with runs of other characters than letters and digits replaced by "-".
+func (c *Customer) FileName() string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(c.Name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "customer"
+	}
+	return b.String()
+}
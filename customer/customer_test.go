@@ -0,0 +1,69 @@
+package customer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const contracts = `customers:
+  - name: Acme Inc.
+    accounts: ["253710526682", " 111122223333 "]
+    sla: {critical: 7, High: 30}
+    target_adherence: 0.95
+  - name: Acme Education
+    accounts: [acme-inc-Education]
+`
+
+func TestLoad(t *testing.T) {
+	cs, err := Load(writeFile(t, "customers.yaml", contracts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme := &cs.Customers[0]
+	if acme.SLA[vuln.Critical] != 7 || acme.SLA[vuln.High] != 30 || len(acme.SLA) != 2 || acme.TargetAdherence != 0.95 {
+		t.Errorf("acme = %+v", acme)
+	}
+	if got := acme.FileName(); got != "acme-inc" {
+		t.Errorf("FileName = %q", got)
+	}
+	for org, want := range map[string]*Customer{
+		"253710526682: us-west-2": acme,
+		"111122223333":            acme,
+		" acme-inc-Education ":    &cs.Customers[1],
+		"444455556666: us-east-1": nil,
+		"":                        nil,
+	} {
+		if got := cs.Owner(&vuln.Vulnerability{Organization: org}); got != want {
+			t.Errorf("Owner(%q) = %v, want %v", org, got, want)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"no name":        "customers:\n  - accounts: [\"1\"]\n",
+		"no accounts":    "customers:\n  - name: a\n",
+		"blank account":  "customers:\n  - name: a\n    accounts: [\" \"]\n",
+		"customer twice": "customers:\n  - name: a\n    accounts: [\"1\"]\n  - name: a\n    accounts: [\"2\"]\n",
+		"same file name": "customers:\n  - name: Acme Inc\n    accounts: [\"1\"]\n  - name: acme-inc\n    accounts: [\"2\"]\n",
+		"account twice":  "customers:\n  - name: a\n    accounts: [\"1\"]\n  - name: b\n    accounts: [\"1\"]\n",
+		"bad severity":   "customers:\n  - name: a\n    accounts: [\"1\"]\n    sla: {Severe: 7}\n",
+		"negative sla":   "customers:\n  - name: a\n    accounts: [\"1\"]\n    sla: {High: -1}\n",
+		"percent target": "customers:\n  - name: a\n    accounts: [\"1\"]\n    target_adherence: 95\n",
+		"unknown key":    "customers:\n  - name: a\n    accounts: [\"1\"]\n    penalty: 100\n",
+	} {
+		if _, err := Load(writeFile(t, "customers.yaml", content)); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+}
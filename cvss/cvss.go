@@ -0,0 +1,170 @@
+// Package cvss parses CVSS v3.0, v3.1 and v4.0 vector strings and computes
+// their scores.
+//
+// A vector such as "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H" is parsed
+// into a Vector, whose individual metrics can be read with Metric and whose
+// scores are returned by Score and, for v3.x, TemporalScore.
+package cvss
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidVector is wrapped by every error Parse returns.
+var ErrInvalidVector = errors.New("cvss: invalid vector")
+
+// Supported CVSS versions.
+const (
+	V30 = "3.0"
+	V31 = "3.1"
+	V40 = "4.0"
+)
+
+// metricDef lists the values a metric accepts and whether the vector must
+// contain it.
+type metricDef struct {
+	values   []string
+	required bool
+}
+
+// Vector is a parsed CVSS vector.
+type Vector struct {
+	// Version is one of V30, V31 or V40.
+	Version string
+
+	metrics map[string]string
+}
+
+// Parse parses a CVSS vector string. The "CVSS:<version>/" prefix is
+// required. Every base metric of that version must be present. Optional
+// metrics may be omitted or set to "X" (Not Defined). Metrics may appear in
+// any order but only once.
+func Parse(s string) (*Vector, error) {
+	prefix, rest, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q has no metrics", ErrInvalidVector, s)
+	}
+
+	v := &Vector{metrics: make(map[string]string)}
+	var defs map[string]metricDef
+	switch prefix {
+	case "CVSS:3.0":
+		v.Version, defs = V30, v3Metrics
+	case "CVSS:3.1":
+		v.Version, defs = V31, v3Metrics
+	case "CVSS:4.0":
+		v.Version, defs = V40, v4Metrics
+	default:
+		return nil, fmt.Errorf("%w: unsupported prefix %q", ErrInvalidVector, prefix)
+	}
+
+	for _, part := range strings.Split(rest, "/") {
+		name, value, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed metric %q", ErrInvalidVector, part)
+		}
+		def, known := defs[name]
+		if !known {
+			return nil, fmt.Errorf("%w: unknown metric %q for CVSS %s", ErrInvalidVector, name, v.Version)
+		}
+		if _, dup := v.metrics[name]; dup {
+			return nil, fmt.Errorf("%w: metric %q repeated", ErrInvalidVector, name)
+		}
+		if !contains(def.values, value) {
+			return nil, fmt.Errorf("%w: invalid value %q for metric %q", ErrInvalidVector, value, name)
+		}
+		v.metrics[name] = value
+	}
+
+	for name, def := range defs {
+		if _, ok := v.metrics[name]; def.required && !ok {
+			return nil, fmt.Errorf("%w: missing base metric %q", ErrInvalidVector, name)
+		}
+	}
+
+	return v, nil
+}
+
+// Metric returns the value of the named metric (e.g. "AV"), or "X" when an
+// optional metric is absent from the vector.
+func (v *Vector) Metric(name string) string {
+	if value, ok := v.metrics[name]; ok {
+		return value
+	}
+	return "X"
+}
+
+// With returns a copy of the vector with the named metric set to value,
+// which must be one the metric accepts.
+func (v *Vector) With(name, value string) (*Vector, error) {
+	defs := v3Metrics
+	if v.Version == V40 {
+		defs = v4Metrics
+	}
+	def, known := defs[name]
+	if !known {
+		return nil, fmt.Errorf("%w: unknown metric %q for CVSS %s", ErrInvalidVector, name, v.Version)
+	}
+	if !contains(def.values, value) {
+		return nil, fmt.Errorf("%w: invalid value %q for metric %q", ErrInvalidVector, value, name)
+	}
+	w := &Vector{Version: v.Version, metrics: make(map[string]string, len(v.metrics)+1)}
+	for k, val := range v.metrics {
+		w.metrics[k] = val
+	}
+	w.metrics[name] = value
+	return w, nil
+}
+
+// Score returns the score of the vector, rounded to one decimal. For v3.x
+// this is the Base Score: temporal and environmental metrics are accepted
+// by Parse but do not affect it; see TemporalScore. For v4.0 the threat and
+// environmental metrics are part of the single scoring formula, so a vector
+// carrying them yields CVSS-BT, CVSS-BE or CVSS-BTE rather than CVSS-B.
+func (v *Vector) Score() float64 {
+	if v.Version == V40 {
+		return v.score40()
+	}
+	return v.score3()
+}
+
+// Attack vectors, by the name AttackVector gives the AV metric's values.
+const (
+	Network  = "network"
+	Adjacent = "adjacent"
+	Local    = "local"
+	Physical = "physical"
+)
+
+// attackVectors maps the AV metric's values, the same in v3.x and v4.0,
+// to their names.
+var attackVectors = map[string]string{"N": Network, "A": Adjacent, "L": Local, "P": Physical}
+
+// AttackVector returns the name of the vector's Attack Vector (AV) metric,
+// as in Network for "AV:N".
+func (v *Vector) AttackVector() string {
+	return attackVectors[v.Metric("AV")]
+}
+
+// ParseAttackVector returns the attack vector s names, in any case: its
+// name, such as "network", or its AV metric value, such as "N".
+func ParseAttackVector(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for value, name := range attackVectors {
+		if s == name || s == strings.ToLower(value) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown attack vector %q (want network, adjacent, local or physical)", s)
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package cvss_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   float64
+	}{
+		// v3.1, scores as published by NVD.
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H", 8.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:C/C:H/I:H/A:H", 9.9},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", 7.5},
+		{"CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:N/A:N", 5.9},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:N/I:N/A:H", 5.5},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:L/I:N/A:N", 3.3},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0.0},
+		// Metric order is not significant; temporal metrics do not change the base score.
+		{"CVSS:3.1/S:U/AV:N/AC:L/PR:N/UI:N/C:H/I:H/A:H/E:U/RL:O/RC:C", 9.8},
+		// v3.0 rounds with a plain ceiling.
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		// v4.0, scores from the FIRST reference calculator.
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", 10.0},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 9.3},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:H/SI:H/SA:H", 7.9},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", 0.0},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:P/VC:N/VI:H/VA:H/SC:N/SI:L/SA:L", 5.2},
+		{"CVSS:4.0/AV:P/AC:H/AT:P/PR:H/UI:A/VC:L/VI:N/VA:N/SC:N/SI:N/SA:N", 1.0},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H/E:U", 9.1},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H/MVI:L/MSA:S", 9.8},
+		{"CVSS:4.0/AV:N/AC:H/AT:N/PR:H/UI:N/VC:N/VI:N/VA:H/SC:H/SI:H/SA:H/CR:L/IR:L/AR:L", 5.8},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:P/VC:N/VI:H/VA:H/SC:N/SI:L/SA:L/E:P/CR:H/IR:M/AR:H/MAV:A/MAT:P/MPR:N/MVI:H/MVA:N/MSI:H/MSA:N/S:N/V:C/U:Amber", 4.7},
+		{"CVSS:4.0/AV:N/AC:L/AT:P/PR:L/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N/E:P/CR:X/IR:M/AR:X/MAV:N/MAC:H/MAT:X/MPR:L/MUI:X/MVC:L/MVI:N/MVA:H/MSC:L/MSI:S/MSA:S", 7.4},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:L/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N/E:X/CR:H/IR:M/AR:L/MAV:P/MAC:H/MAT:X/MPR:N/MUI:P/MVC:N/MVI:H/MVA:N/MSC:N/MSI:X/MSA:S/S:P/AU:X/R:A/V:X/RE:M/U:Amber", 5.4},
+		// Modified impacts all None score zero even though the base impacts do not.
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:H/UI:P/VC:H/VI:N/VA:L/SC:H/SI:N/SA:L/MVC:N/MVA:N/MSC:N/MSA:N", 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			v, err := cvss.Parse(tt.vector)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := v.Score(); got != tt.want {
+				t.Errorf("Score() = %.1f, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemporalScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		want   float64
+	}{
+		// Not Defined temporal metrics leave the base score.
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:X/RL:X/RC:X", 9.8},
+		// roundUp(9.8 x 0.94 x 0.95 x 1) = roundUp(8.7514)
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P/RL:O/RC:C", 8.8},
+		// roundUp(9.8 x 0.91 x 0.95 x 0.92) = roundUp(7.7942)
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:U/RL:O/RC:U", 7.8},
+		// roundUp(7.5 x 0.97 x 0.96 x 0.96) = roundUp(6.70464)
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H/E:F/RL:T/RC:R", 6.8},
+		// roundUp(8.8 x 1 x 0.97) = roundUp(8.536)
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H/E:H/RL:W", 8.6},
+		// roundUp(6.1 x 0.94 x 0.95) = roundUp(5.4473), with the v3.0 ceiling.
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N/E:P/RL:O", 5.5},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N/E:U/RL:O/RC:U", 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vector, func(t *testing.T) {
+			v, err := cvss.Parse(tt.vector)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := v.TemporalScore(); got != tt.want {
+				t.Errorf("TemporalScore() = %.1f, want %.1f", got, tt.want)
+			}
+		})
+	}
+
+	if got, err := cvss.Temporal(cvss.V31, 7.5, "X", "O", "X"); err != nil || got != 7.2 {
+		t.Errorf("Temporal(7.5, RL:O) = %v, %v; want 7.2", got, err)
+	}
+	if _, err := cvss.Temporal(cvss.V31, 7.5, "Z", "X", "X"); !errors.Is(err, cvss.ErrInvalidVector) {
+		t.Errorf("Temporal with an unknown value: err = %v", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		vector string
+	}{
+		{"empty", ""},
+		{"no prefix", "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+		{"unsupported version", "CVSS:2.0/AV:N/AC:L/Au:N/C:P/I:P/A:P"},
+		{"missing base metric", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H"},
+		{"repeated metric", "CVSS:3.1/AV:N/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+		{"invalid value", "CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+		{"unknown metric", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/AT:N"},
+		{"malformed metric", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A"},
+		{"invalid temporal value", "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:Z"},
+		{"v3 metric in v4", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:R/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"},
+		{"safety outside modified metrics", "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:S/SA:N"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cvss.Parse(tt.vector)
+			if !errors.Is(err, cvss.ErrInvalidVector) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidVector", tt.vector, err)
+			}
+		})
+	}
+}
+
+func TestMetric(t *testing.T) {
+	v, err := cvss.Parse("CVSS:3.1/AV:A/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != cvss.V31 {
+		t.Errorf("Version = %q, want %q", v.Version, cvss.V31)
+	}
+	for name, want := range map[string]string{"AV": "A", "E": "P", "CR": "X"} {
+		if got := v.Metric(name); got != want {
+			t.Errorf("Metric(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestMetricV40(t *testing.T) {
+	v, err := cvss.Parse("CVSS:4.0/AV:A/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/E:P")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != cvss.V40 {
+		t.Errorf("Version = %q, want %q", v.Version, cvss.V40)
+	}
+	for name, want := range map[string]string{"AV": "A", "E": "P", "CR": "X"} {
+		if got := v.Metric(name); got != want {
+			t.Errorf("Metric(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := v.AttackVector(); got != cvss.Adjacent {
+		t.Errorf("AttackVector() = %q, want %q", got, cvss.Adjacent)
+	}
+	w, err := v.With("E", "A")
+	if err != nil || w.Metric("E") != "A" || v.Metric("E") != "P" {
+		t.Errorf("With(E, A) = %v, %v; original E:%s", w, err, v.Metric("E"))
+	}
+	if _, err := v.With("E", "H"); !errors.Is(err, cvss.ErrInvalidVector) {
+		t.Errorf("With(E, H) error = %v", err)
+	}
+}
+
+func TestParseAttackVector(t *testing.T) {
+	for in, want := range map[string]string{"network": cvss.Network, "Local": cvss.Local, "P": cvss.Physical, " a ": cvss.Adjacent} {
+		if got, err := cvss.ParseAttackVector(in); err != nil || got != want {
+			t.Errorf("ParseAttackVector(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := cvss.ParseAttackVector("remote"); err == nil {
+		t.Error("ParseAttackVector(remote) accepted")
+	}
+}
@@ -0,0 +1,123 @@
+package cvss
+
+import (
+	"fmt"
+	"math"
+)
+
+// v3Metrics are the metrics of CVSS v3.0 and v3.1, which share a vector
+// format and differ only in how scores are rounded.
+var v3Metrics = map[string]metricDef{
+	// Base
+	"AV": {values: []string{"N", "A", "L", "P"}, required: true},
+	"AC": {values: []string{"L", "H"}, required: true},
+	"PR": {values: []string{"N", "L", "H"}, required: true},
+	"UI": {values: []string{"N", "R"}, required: true},
+	"S":  {values: []string{"U", "C"}, required: true},
+	"C":  {values: []string{"H", "L", "N"}, required: true},
+	"I":  {values: []string{"H", "L", "N"}, required: true},
+	"A":  {values: []string{"H", "L", "N"}, required: true},
+	// Temporal
+	"E":  {values: []string{"X", "H", "F", "P", "U"}},
+	"RL": {values: []string{"X", "U", "W", "T", "O"}},
+	"RC": {values: []string{"X", "C", "R", "U"}},
+	// Environmental
+	"CR":  {values: []string{"X", "H", "M", "L"}},
+	"IR":  {values: []string{"X", "H", "M", "L"}},
+	"AR":  {values: []string{"X", "H", "M", "L"}},
+	"MAV": {values: []string{"X", "N", "A", "L", "P"}},
+	"MAC": {values: []string{"X", "L", "H"}},
+	"MPR": {values: []string{"X", "N", "L", "H"}},
+	"MUI": {values: []string{"X", "N", "R"}},
+	"MS":  {values: []string{"X", "U", "C"}},
+	"MC":  {values: []string{"X", "H", "L", "N"}},
+	"MI":  {values: []string{"X", "H", "L", "N"}},
+	"MA":  {values: []string{"X", "H", "L", "N"}},
+}
+
+// Metric weights from section 7.4 of the CVSS v3.1 specification.
+var (
+	v3AttackVector     = map[string]float64{"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2}
+	v3AttackComplexity = map[string]float64{"L": 0.77, "H": 0.44}
+	v3UserInteraction  = map[string]float64{"N": 0.85, "R": 0.62}
+	v3Impact           = map[string]float64{"H": 0.56, "L": 0.22, "N": 0}
+	// Privileges Required weighs more when the scope changes.
+	v3PrivilegesUnchanged = map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}
+	v3PrivilegesChanged   = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}
+
+	// Temporal weights. Not Defined (X) weighs 1, like the highest value.
+	v3ExploitMaturity  = map[string]float64{"X": 1, "H": 1, "F": 0.97, "P": 0.94, "U": 0.91}
+	v3RemediationLevel = map[string]float64{"X": 1, "U": 1, "W": 0.97, "T": 0.96, "O": 0.95}
+	v3ReportConfidence = map[string]float64{"X": 1, "C": 1, "R": 0.96, "U": 0.92}
+)
+
+// score3 implements the v3.x Base Score equations (section 7.1).
+func (v *Vector) score3() float64 {
+	changed := v.metrics["S"] == "C"
+
+	iss := 1 - (1-v3Impact[v.metrics["C"]])*
+		(1-v3Impact[v.metrics["I"]])*
+		(1-v3Impact[v.metrics["A"]])
+
+	var impact float64
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	} else {
+		impact = 6.42 * iss
+	}
+	if impact <= 0 {
+		return 0
+	}
+
+	privileges := v3PrivilegesUnchanged
+	if changed {
+		privileges = v3PrivilegesChanged
+	}
+	exploitability := 8.22 *
+		v3AttackVector[v.metrics["AV"]] *
+		v3AttackComplexity[v.metrics["AC"]] *
+		privileges[v.metrics["PR"]] *
+		v3UserInteraction[v.metrics["UI"]]
+
+	score := impact + exploitability
+	if changed {
+		score *= 1.08
+	}
+	return roundUp(v.Version, math.Min(score, 10))
+}
+
+// TemporalScore returns the Temporal Score of the vector (section 7.2):
+// the Base Score scaled by Exploit Code Maturity (E), Remediation Level
+// (RL) and Report Confidence (RC), rounded up to one decimal. Metrics
+// absent from the vector are Not Defined and leave the score unchanged.
+func (v *Vector) TemporalScore() float64 {
+	score, _ := Temporal(v.Version, v.Score(), v.Metric("E"), v.Metric("RL"), v.Metric("RC"))
+	return score
+}
+
+// Temporal applies the temporal metric values e, rl and rc (such as "F",
+// "O" and "C", or "X" for Not Defined) to a version's base score, for
+// findings that carry a score but no vector. Unknown values are an error.
+func Temporal(version string, base float64, e, rl, rc string) (float64, error) {
+	we, ok1 := v3ExploitMaturity[e]
+	wrl, ok2 := v3RemediationLevel[rl]
+	wrc, ok3 := v3ReportConfidence[rc]
+	if !ok1 || !ok2 || !ok3 {
+		return 0, fmt.Errorf("%w: temporal metrics E:%s/RL:%s/RC:%s", ErrInvalidVector, e, rl, rc)
+	}
+	return roundUp(version, base*we*wrl*wrc), nil
+}
+
+// roundUp rounds up to one decimal. v3.1 (Appendix A) works on an integer
+// representation to avoid floating-point artifacts such as 4.000000001
+// rounding to 4.1; v3.0 uses a plain ceiling.
+func roundUp(version string, x float64) float64 {
+	if version == V30 {
+		return math.Ceil(x*10) / 10
+	}
+	i := math.Round(x * 100000)
+	if math.Mod(i, 10000) == 0 {
+		return i / 100000
+	}
+	return (math.Floor(i/10000) + 1) / 10
+}
@@ -0,0 +1,320 @@
+package cvss
+
+import (
+	"math"
+	"strings"
+)
+
+// v4Metrics are the metrics of CVSS v4.0: base, threat, environmental and
+// supplemental. Supplemental metrics are parsed but never affect the score.
+var v4Metrics = map[string]metricDef{
+	// Base
+	"AV": {values: []string{"N", "A", "L", "P"}, required: true},
+	"AC": {values: []string{"L", "H"}, required: true},
+	"AT": {values: []string{"N", "P"}, required: true},
+	"PR": {values: []string{"N", "L", "H"}, required: true},
+	"UI": {values: []string{"N", "P", "A"}, required: true},
+	"VC": {values: []string{"H", "L", "N"}, required: true},
+	"VI": {values: []string{"H", "L", "N"}, required: true},
+	"VA": {values: []string{"H", "L", "N"}, required: true},
+	"SC": {values: []string{"H", "L", "N"}, required: true},
+	"SI": {values: []string{"H", "L", "N"}, required: true},
+	"SA": {values: []string{"H", "L", "N"}, required: true},
+	// Threat
+	"E": {values: []string{"X", "A", "P", "U"}},
+	// Environmental
+	"CR":  {values: []string{"X", "H", "M", "L"}},
+	"IR":  {values: []string{"X", "H", "M", "L"}},
+	"AR":  {values: []string{"X", "H", "M", "L"}},
+	"MAV": {values: []string{"X", "N", "A", "L", "P"}},
+	"MAC": {values: []string{"X", "L", "H"}},
+	"MAT": {values: []string{"X", "N", "P"}},
+	"MPR": {values: []string{"X", "N", "L", "H"}},
+	"MUI": {values: []string{"X", "N", "P", "A"}},
+	"MVC": {values: []string{"X", "H", "L", "N"}},
+	"MVI": {values: []string{"X", "H", "L", "N"}},
+	"MVA": {values: []string{"X", "H", "L", "N"}},
+	"MSC": {values: []string{"X", "H", "L", "N"}},
+	"MSI": {values: []string{"X", "S", "H", "L", "N"}},
+	"MSA": {values: []string{"X", "S", "H", "L", "N"}},
+	// Supplemental
+	"S":  {values: []string{"X", "N", "P"}},
+	"AU": {values: []string{"X", "N", "Y"}},
+	"R":  {values: []string{"X", "A", "U", "I"}},
+	"V":  {values: []string{"X", "D", "C"}},
+	"RE": {values: []string{"X", "L", "M", "H"}},
+	"U":  {values: []string{"X", "Clear", "Green", "Amber", "Red"}},
+}
+
+// v4Levels orders each metric's values from most to least severe, in steps
+// of one. The severity distance between two values is the difference of
+// their levels.
+var v4Levels = map[string]map[string]int{
+	"AV": {"N": 0, "A": 1, "L": 2, "P": 3},
+	"PR": {"N": 0, "L": 1, "H": 2},
+	"UI": {"N": 0, "P": 1, "A": 2},
+	"AC": {"L": 0, "H": 1},
+	"AT": {"N": 0, "P": 1},
+	"VC": {"H": 0, "L": 1, "N": 2},
+	"VI": {"H": 0, "L": 1, "N": 2},
+	"VA": {"H": 0, "L": 1, "N": 2},
+	"SC": {"H": 1, "L": 2, "N": 3},
+	"SI": {"S": 0, "H": 1, "L": 2, "N": 3},
+	"SA": {"S": 0, "H": 1, "L": 2, "N": 3},
+	"CR": {"H": 0, "M": 1, "L": 2},
+	"IR": {"H": 0, "M": 1, "L": 2},
+	"AR": {"H": 0, "M": 1, "L": 2},
+}
+
+// v4MaxVectors holds, per equivalence class and level, the highest-severity
+// vectors of that level (tables 24 to 30 of the specification). EQ3 and EQ6
+// are scored jointly and keyed by eq3*10+eq6.
+var v4MaxVectors = struct {
+	eq1, eq2, eq4 [][]string
+	eq3eq6        map[int][]string
+}{
+	eq1: [][]string{
+		{"AV:N/PR:N/UI:N"},
+		{"AV:A/PR:N/UI:N", "AV:N/PR:L/UI:N", "AV:N/PR:N/UI:P"},
+		{"AV:P/PR:N/UI:N", "AV:A/PR:L/UI:P"},
+	},
+	eq2: [][]string{
+		{"AC:L/AT:N"},
+		{"AC:H/AT:N", "AC:L/AT:P"},
+	},
+	eq4: [][]string{
+		{"SC:H/SI:S/SA:S"},
+		{"SC:H/SI:H/SA:H"},
+		{"SC:L/SI:L/SA:L"},
+	},
+	eq3eq6: map[int][]string{
+		0:  {"VC:H/VI:H/VA:H/CR:H/IR:H/AR:H"},
+		1:  {"VC:H/VI:H/VA:L/CR:M/IR:M/AR:H", "VC:H/VI:H/VA:H/CR:M/IR:M/AR:M"},
+		10: {"VC:L/VI:H/VA:H/CR:H/IR:H/AR:H", "VC:H/VI:L/VA:H/CR:H/IR:H/AR:H"},
+		11: {
+			"VC:L/VI:H/VA:H/CR:H/IR:M/AR:M", "VC:H/VI:L/VA:H/CR:M/IR:H/AR:M",
+			"VC:L/VI:H/VA:L/CR:H/IR:M/AR:H", "VC:H/VI:L/VA:L/CR:M/IR:H/AR:H",
+			"VC:L/VI:L/VA:H/CR:H/IR:H/AR:M",
+		},
+		21: {"VC:L/VI:L/VA:L/CR:H/IR:H/AR:H"},
+	},
+}
+
+// v4MaxSeverity is the depth of each level: the largest severity distance a
+// vector can have from the level's highest-severity vectors, plus one.
+var v4MaxSeverity = struct {
+	eq1, eq2, eq4, eq5 []int
+	eq3eq6             map[int]int
+}{
+	eq1:    []int{1, 4, 5},
+	eq2:    []int{1, 2},
+	eq4:    []int{6, 5, 4},
+	eq5:    []int{1, 1, 1},
+	eq3eq6: map[int]int{0: 7, 1: 6, 10: 8, 11: 8, 21: 10},
+}
+
+// effective returns the value used for scoring: the modified (M-prefixed)
+// metric when defined, with the specification's defaults for E and the
+// security requirements.
+func (v *Vector) effective(name string) string {
+	switch name {
+	case "E":
+		if value := v.Metric("E"); value != "X" {
+			return value
+		}
+		return "A"
+	case "CR", "IR", "AR":
+		if value := v.Metric(name); value != "X" {
+			return value
+		}
+		return "H"
+	}
+	if value := v.Metric("M" + name); value != "X" {
+		return value
+	}
+	return v.metrics[name]
+}
+
+// macroVector returns the six equivalence-class levels of the vector
+// (section 8.2). Lower means more severe.
+func (v *Vector) macroVector() [6]int {
+	m := v.effective
+	var eq [6]int
+
+	switch {
+	case m("AV") == "N" && m("PR") == "N" && m("UI") == "N":
+		eq[0] = 0
+	case (m("AV") == "N" || m("PR") == "N" || m("UI") == "N") && m("AV") != "P":
+		eq[0] = 1
+	default:
+		eq[0] = 2
+	}
+
+	if m("AC") != "L" || m("AT") != "N" {
+		eq[1] = 1
+	}
+
+	switch {
+	case m("VC") == "H" && m("VI") == "H":
+		eq[2] = 0
+	case m("VC") == "H" || m("VI") == "H" || m("VA") == "H":
+		eq[2] = 1
+	default:
+		eq[2] = 2
+	}
+
+	switch {
+	case m("SI") == "S" || m("SA") == "S":
+		eq[3] = 0
+	case m("SC") == "H" || m("SI") == "H" || m("SA") == "H":
+		eq[3] = 1
+	default:
+		eq[3] = 2
+	}
+
+	switch m("E") {
+	case "P":
+		eq[4] = 1
+	case "U":
+		eq[4] = 2
+	}
+
+	if !(m("CR") == "H" && m("VC") == "H" ||
+		m("IR") == "H" && m("VI") == "H" ||
+		m("AR") == "H" && m("VA") == "H") {
+		eq[5] = 1
+	}
+
+	return eq
+}
+
+// score40 implements the CVSS v4.0 scoring algorithm (section 8): look up
+// the score of the vector's macrovector, then move it towards the next
+// lower macrovectors in proportion to how far the vector sits from the
+// highest-severity vectors of its own macrovector.
+func (v *Vector) score40() float64 {
+	m := v.effective
+	if m("VC") == "N" && m("VI") == "N" && m("VA") == "N" &&
+		m("SC") == "N" && m("SI") == "N" && m("SA") == "N" {
+		return 0
+	}
+
+	eq := v.macroVector()
+	value := v4Lookup[macroKey(eq)]
+
+	// lowerScore returns the score of eq with deltas applied, if that
+	// macrovector exists.
+	lowerScore := func(deltas ...[2]int) (float64, bool) {
+		next := eq
+		for _, d := range deltas {
+			next[d[0]] += d[1]
+		}
+		score, ok := v4Lookup[macroKey(next)]
+		return score, ok
+	}
+
+	lower1, ok1 := lowerScore([2]int{0, 1})
+	lower2, ok2 := lowerScore([2]int{1, 1})
+	lower4, ok4 := lowerScore([2]int{3, 1})
+	lower5, ok5 := lowerScore([2]int{4, 1})
+
+	// EQ3 and EQ6 move together: (2,0) does not exist, and from (0,0)
+	// both (0,1) and (1,0) are one step lower.
+	var lower36 float64
+	var ok36 bool
+	switch eq[2]*10 + eq[5] {
+	case 0:
+		left, okLeft := lowerScore([2]int{5, 1})
+		right, okRight := lowerScore([2]int{2, 1})
+		lower36, ok36 = math.Max(left, right), okLeft || okRight
+	case 1, 11:
+		lower36, ok36 = lowerScore([2]int{2, 1})
+	case 10:
+		lower36, ok36 = lowerScore([2]int{5, 1})
+	}
+
+	dist1, dist2, dist36, dist4 := v.severityDistances(eq)
+
+	var total float64
+	var n int
+	add := func(lower float64, ok bool, distance, depth int) {
+		if !ok {
+			return
+		}
+		total += (value - lower) * float64(distance) / float64(depth)
+		n++
+	}
+	add(lower1, ok1, dist1, v4MaxSeverity.eq1[eq[0]])
+	add(lower2, ok2, dist2, v4MaxSeverity.eq2[eq[1]])
+	add(lower36, ok36, dist36, v4MaxSeverity.eq3eq6[eq[2]*10+eq[5]])
+	add(lower4, ok4, dist4, v4MaxSeverity.eq4[eq[3]])
+	// EQ5 has a single metric, so its distance is always zero.
+	add(lower5, ok5, 0, v4MaxSeverity.eq5[eq[4]])
+
+	if n > 0 {
+		value -= total / float64(n)
+	}
+	value = math.Max(0, math.Min(value, 10))
+	return math.Round(value*10) / 10
+}
+
+// severityDistances finds the first highest-severity vector of the
+// macrovector that the vector does not exceed on any metric, and returns
+// the summed severity distances to it for EQ1, EQ2, EQ3+EQ6 and EQ4.
+func (v *Vector) severityDistances(eq [6]int) (int, int, int, int) {
+	distance := func(max string) ([]int, bool) {
+		var out []int
+		for _, part := range strings.Split(max, "/") {
+			name, maxValue, _ := strings.Cut(part, ":")
+			levels := v4Levels[name]
+			d := levels[v.effective(name)] - levels[maxValue]
+			if d < 0 {
+				return nil, false
+			}
+			out = append(out, d)
+		}
+		return out, true
+	}
+	sum := func(ds []int) int {
+		total := 0
+		for _, d := range ds {
+			total += d
+		}
+		return total
+	}
+
+	for _, max1 := range v4MaxVectors.eq1[eq[0]] {
+		d1, ok := distance(max1)
+		if !ok {
+			continue
+		}
+		for _, max2 := range v4MaxVectors.eq2[eq[1]] {
+			d2, ok := distance(max2)
+			if !ok {
+				continue
+			}
+			for _, max36 := range v4MaxVectors.eq3eq6[eq[2]*10+eq[5]] {
+				d36, ok := distance(max36)
+				if !ok {
+					continue
+				}
+				for _, max4 := range v4MaxVectors.eq4[eq[3]] {
+					d4, ok := distance(max4)
+					if !ok {
+						continue
+					}
+					return sum(d1), sum(d2), sum(d36), sum(d4)
+				}
+			}
+		}
+	}
+	return 0, 0, 0, 0
+}
+
+func macroKey(eq [6]int) string {
+	var b strings.Builder
+	for _, level := range eq {
+		b.WriteByte(byte('0' + level))
+	}
+	return b.String()
+}
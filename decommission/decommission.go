@@ -0,0 +1,109 @@
+// Package decommission excludes findings on retired assets. Scanners keep
+// reporting a server or image for a while after it is gone, and its
+// findings would otherwise count towards the fleet's risk until the
+// scanner's inventory catches up.
+package decommission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Modes of handling findings on decommissioned assets.
+const (
+	// Drop removes the findings from the run.
+	Drop = "drop"
+	// Tag keeps them, with StaleAsset set.
+	Tag = "tag"
+)
+
+// Modes are the handling modes, for flag help.
+var Modes = []string{Drop, Tag}
+
+// Asset is one decommissioned asset.
+type Asset struct {
+	// Asset is the asset's ID or name, as in the input.
+	Asset string `json:"asset"`
+	// Decommissioned is the day the asset was retired, as YYYY-MM-DD. An
+	// asset due to be retired later is not stale until then; one without
+	// a date is retired already.
+	Decommissioned string `json:"decommissioned,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+
+	retired vuln.Date
+}
+
+// List is the decommissioned assets.
+type List struct {
+	Assets []Asset `json:"assets"`
+}
+
+// Load reads a list of decommissioned assets, as JSON:
+//
+//	{"assets": [{"asset": "i-0abc123", "decommissioned": "2025-01-15", "reason": "replaced by i-0def456"}]}
+//
+// Unknown fields and assets without an ID are rejected, so a typo does not
+// leave an asset counted.
+func Load(path string) (*List, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	l := &List{}
+	if err := dec.Decode(l); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range l.Assets {
+		a := &l.Assets[i]
+		if strings.TrimSpace(a.Asset) == "" {
+			return nil, fmt.Errorf("%s: asset %d has no ID or name", path, i+1)
+		}
+		if a.retired, err = vuln.ParseDate(strings.TrimSpace(a.Decommissioned)); err != nil {
+			return nil, fmt.Errorf("%s: %s: invalid decommissioned date %q", path, a.Asset, a.Decommissioned)
+		}
+	}
+	return l, nil
+}
+
+// Stale reports whether v is on an asset of l retired by now, matching
+// the asset's ID or name.
+func (l *List) Stale(v *vuln.Vulnerability, now time.Time) bool {
+	today := vuln.NewDate(now.Year(), now.Month(), now.Day())
+	for _, a := range l.Assets {
+		id := strings.TrimSpace(a.Asset)
+		if id != strings.TrimSpace(v.AssetID) && id != strings.TrimSpace(v.AssetName) {
+			continue
+		}
+		if a.retired.IsZero() || !today.Before(a.retired.Time) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply handles the findings of vs on assets retired by now in mode: Drop
+// removes them and Tag sets their StaleAsset. It returns the findings left
+// and how many were stale.
+func (l *List) Apply(vs []vuln.Vulnerability, mode string, now time.Time) ([]vuln.Vulnerability, int) {
+	out := vs[:0]
+	stale := 0
+	for i := range vs {
+		if l.Stale(&vs[i], now) {
+			stale++
+			if mode == Drop {
+				continue
+			}
+			vs[i].StaleAsset = true
+		}
+		out = append(out, vs[i])
+	}
+	return out, stale
+}
@@ -0,0 +1,67 @@
+package decommission
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"typo.json":    `{"assets": [{"asset": "i-1", "decomissioned": "2025-01-15"}]}`,
+		"blank.json":   `{"assets": [{"asset": " "}]}`,
+		"baddate.json": `{"assets": [{"asset": "i-1", "decommissioned": "1/15/2025"}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+	if _, err := Load(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("missing list loaded")
+	}
+}
+
+func TestApply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "retired.json")
+	if err := os.WriteFile(path, []byte(`{"assets": [
+		{"asset": "464323181"},
+		{"asset": "match-v1", "decommissioned": "2025-02-01"},
+		{"asset": "sst-asset", "decommissioned": "2025-03-01", "reason": "not yet"}
+	]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := func() []vuln.Vulnerability {
+		return []vuln.Vulnerability{
+			{Identifier: "CVE-1", AssetName: "apply-api", AssetID: "464323181"},
+			{Identifier: "CVE-2", AssetName: "match-v1", AssetID: "arn:aws:ecr:us-west-2:253710526682:repository/match-v1"},
+			{Identifier: "CVE-3", AssetName: "sst-asset"},
+			{Identifier: "CVE-4", AssetName: "explore-api", AssetID: "615133206"},
+		}
+	}
+	now := time.Date(2025, time.February, 5, 9, 0, 0, 0, time.UTC)
+
+	kept, stale := l.Apply(findings(), Drop, now)
+	if stale != 2 || len(kept) != 2 || kept[0].Identifier != "CVE-3" || kept[1].Identifier != "CVE-4" {
+		t.Errorf("Drop: %d stale, kept %+v", stale, kept)
+	}
+	tagged, stale := l.Apply(findings(), Tag, now)
+	if stale != 2 || len(tagged) != 4 || !tagged[0].StaleAsset || !tagged[1].StaleAsset || tagged[2].StaleAsset || tagged[3].StaleAsset {
+		t.Errorf("Tag: %d stale, %+v", stale, tagged)
+	}
+	// Retired on the day itself.
+	if v := (vuln.Vulnerability{AssetName: "sst-asset"}); !l.Stale(&v, time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("asset not stale on its decommission date")
+	}
+}
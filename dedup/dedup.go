@@ -0,0 +1,51 @@
+// Package dedup merges repeated reports of one finding. A scanner that
+// runs on a schedule reports a vulnerable package again on every scan
+// while it stays installed, often under a new unique ID each time, so an
+// export that spans several scans lists one finding several times.
+package dedup
+
+import (
+	"github.com/VioletX-Dev/devsecops-test/merge"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Apply merges the findings of vs that share an identifier, asset ID,
+// package name and installed version, that is a fingerprint, into the
+// first of them. The merged finding keeps the earliest first detected
+// and due dates, takes any field it lacks from the later reports, and
+// counts the reports in Occurrences. A finding already merged counts its
+// own Occurrences, so deduplicating merged results again changes nothing.
+//
+// It returns the findings left, in input order, and how many were merged
+// into another. Every finding left has Occurrences of at least 1.
+func Apply(vs []vuln.Vulnerability) ([]vuln.Vulnerability, int) {
+	out := vs[:0]
+	kept := make(map[string]int) // fingerprint to index in out
+	merged := 0
+	for _, v := range vs {
+		n := max(v.Occurrences, 1)
+		fp := v.Fingerprint()
+		j, ok := kept[fp]
+		if !ok {
+			kept[fp] = len(out)
+			v.Occurrences = n
+			out = append(out, v)
+			continue
+		}
+		cur := &out[j]
+		cur.FirstDetectedDate = earliest(cur.FirstDetectedDate, v.FirstDetectedDate)
+		cur.DueDate = earliest(cur.DueDate, v.DueDate)
+		merge.FillBlanks(cur, &v)
+		cur.Occurrences += n
+		merged++
+	}
+	return out, merged
+}
+
+// earliest returns the earlier of two dates, ignoring a zero one.
+func earliest(a, b vuln.Date) vuln.Date {
+	if a.IsZero() || (!b.IsZero() && b.Before(a.Time)) {
+		return b
+	}
+	return a
+}
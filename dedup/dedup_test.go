@@ -0,0 +1,40 @@
+package dedup
+
+import (
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestApply(t *testing.T) {
+	scan := func(id string, first, due vuln.Date) vuln.Vulnerability {
+		return vuln.Vulnerability{UniqueID: id, AssetID: "i-0abc", Identifier: "CVE-2023-4911", PackageName: "glibc", InstalledVersion: "2.31-13", FirstDetectedDate: first, DueDate: due}
+	}
+	a := scan("a", vuln.NewDate(2025, 1, 10), vuln.NewDate(2025, 2, 10))
+	b := scan("b", vuln.NewDate(2024, 12, 1), vuln.NewDate(2025, 1, 1))
+	b.FixedVersion = "2.31-13+deb11u7"
+	c := scan("c", vuln.Date{}, vuln.Date{})
+	other := scan("other", vuln.NewDate(2025, 1, 1), vuln.Date{})
+	other.InstalledVersion = "2.31-13+deb11u5"
+
+	vs, merged := Apply([]vuln.Vulnerability{a, other, b, c})
+	if merged != 2 || len(vs) != 2 {
+		t.Fatalf("merged %d, left %d, want 2 and 2", merged, len(vs))
+	}
+	got := vs[0]
+	if got.UniqueID != "a" || got.Occurrences != 3 || got.FixedVersion != "2.31-13+deb11u7" {
+		t.Errorf("merged finding = %+v", got)
+	}
+	if got.FirstDetectedDate.String() != "2024-12-01" || got.DueDate.String() != "2025-01-01" {
+		t.Errorf("dates = %s, %s, want the earliest", got.FirstDetectedDate, got.DueDate)
+	}
+	if vs[1].UniqueID != "other" || vs[1].Occurrences != 1 {
+		t.Errorf("other version = %+v", vs[1])
+	}
+
+	// Merged results deduplicated again keep their counts.
+	again, merged := Apply(append(vs, b))
+	if merged != 1 || again[0].Occurrences != 4 {
+		t.Errorf("re-applied: merged %d, occurrences %d, want 1 and 4", merged, again[0].Occurrences)
+	}
+}
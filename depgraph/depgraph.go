@@ -0,0 +1,299 @@
+// Package depgraph reads project dependency graphs, from `go mod graph`
+// output or npm lockfiles, to tell the packages a project depends on
+// directly, which its team can upgrade, from transitive ones.
+package depgraph
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Graph holds the depth of every package a project depends on: 1 for
+// direct dependencies, 2 for their dependencies, and so on. Packages are
+// keyed by ecosystem and lower-cased name.
+type Graph struct {
+	Depth map[Package]int
+}
+
+// Package names a package within its ecosystem.
+type Package struct {
+	Ecosystem vuln.Ecosystem
+	Name      string
+}
+
+func newGraph() *Graph {
+	return &Graph{Depth: make(map[Package]int)}
+}
+
+// walk records the depths of the packages reachable from roots, by
+// breadth-first search over edges, in ecosystem eco. Shallower depths
+// already in g win.
+func (g *Graph) walk(eco vuln.Ecosystem, roots []string, edges map[string][]string) {
+	depth := 1
+	seen := make(map[string]bool)
+	for next := roots; len(next) > 0; depth++ {
+		var level []string
+		for _, name := range next {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			p := Package{eco, strings.ToLower(name)}
+			if d, ok := g.Depth[p]; !ok || depth < d {
+				g.Depth[p] = depth
+			}
+			level = append(level, edges[name]...)
+		}
+		next = level
+	}
+}
+
+// Parse reads a graph in either supported format: an npm lockfile, which
+// is a JSON object, or `go mod graph` output.
+func Parse(r io.Reader) (*Graph, error) {
+	br := bufio.NewReader(r)
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return nil, errors.New("empty dependency graph")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if unicode.IsSpace(rune(c)) {
+			continue
+		}
+		br.UnreadByte()
+		if c == '{' {
+			return ParseNPMLock(br)
+		}
+		return ParseGoModGraph(br)
+	}
+}
+
+// ParseGoModGraph reads `go mod graph` output: one "module@version
+// dependency@version" edge per line, where the main module has no
+// version. The go and toolchain pseudo-modules are ignored.
+func ParseGoModGraph(r io.Reader) (*Graph, error) {
+	edges := make(map[string][]string)
+	mains := make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: want \"module dependency\", got %q", line, sc.Text())
+		}
+		from, _, versioned := strings.Cut(fields[0], "@")
+		to, _, _ := strings.Cut(fields[1], "@")
+		if to == "go" || to == "toolchain" {
+			continue
+		}
+		if !versioned {
+			mains[from] = true
+		}
+		edges[from] = append(edges[from], to)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(mains) == 0 {
+		return nil, errors.New("go mod graph has no main module")
+	}
+	var roots []string
+	for from := range mains {
+		roots = append(roots, edges[from]...)
+	}
+	g := newGraph()
+	g.walk(vuln.EcosystemGo, roots, edges)
+	return g, nil
+}
+
+// npmLock is the part of a package-lock.json (lockfileVersion 2 or 3)
+// that records dependencies.
+type npmLock struct {
+	LockfileVersion int                   `json:"lockfileVersion"`
+	Packages        map[string]npmPackage `json:"packages"`
+}
+
+type npmPackage struct {
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// ParseNPMLock reads an npm package-lock.json. The project's direct
+// dependencies are those its root package, or any of its workspace
+// packages, declares, development dependencies included. Installed
+// packages are matched to dependencies by name, wherever they are nested.
+func ParseNPMLock(r io.Reader) (*Graph, error) {
+	var lock npmLock
+	if err := json.NewDecoder(r).Decode(&lock); err != nil {
+		return nil, err
+	}
+	if lock.LockfileVersion < 2 || lock.Packages == nil {
+		return nil, fmt.Errorf("npm lockfileVersion %d is not supported; regenerate it with npm 7 or later", lock.LockfileVersion)
+	}
+	edges := make(map[string][]string)
+	var roots []string
+	for path, p := range lock.Packages {
+		name, installed := installedName(path)
+		if installed {
+			for _, deps := range []map[string]string{p.Dependencies, p.OptionalDependencies, p.PeerDependencies} {
+				for dep := range deps {
+					edges[name] = append(edges[name], dep)
+				}
+			}
+			continue
+		}
+		// The root package ("") or a workspace package.
+		for _, deps := range []map[string]string{p.Dependencies, p.DevDependencies, p.OptionalDependencies, p.PeerDependencies} {
+			for dep := range deps {
+				roots = append(roots, dep)
+			}
+		}
+	}
+	g := newGraph()
+	g.walk(vuln.EcosystemNPM, roots, edges)
+	return g, nil
+}
+
+// installedName returns the package name of a lockfile packages key such
+// as "node_modules/a/node_modules/@scope/b", and whether the key is an
+// installed package rather than the root or a workspace.
+func installedName(path string) (string, bool) {
+	i := strings.LastIndex(path, "node_modules/")
+	if i < 0 {
+		return "", false
+	}
+	return path[i+len("node_modules/"):], true
+}
+
+// ParseFile reads the graph in the file at path.
+func ParseFile(path string) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	g, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return g, nil
+}
+
+// DepthOf returns how deep v's package is in g, or 0 when g does not
+// have it. A renamed package is also looked up under the name the input
+// reported, as a project's lockfile may not have caught up with it.
+func (g *Graph) DepthOf(v *vuln.Vulnerability) int {
+	eco := v.Ecosystem()
+	if d := g.Depth[Package{eco, strings.ToLower(v.EcosystemPackage())}]; d > 0 || v.RenamedFrom == "" {
+		return d
+	}
+	reported := &vuln.Vulnerability{PackageName: v.RenamedFrom}
+	return g.Depth[Package{eco, strings.ToLower(reported.EcosystemPackage())}]
+}
+
+// Graphs holds the dependency graph of each asset.
+type Graphs struct {
+	Mapping map[string]*Graph
+}
+
+// Load reads a JSON object mapping asset IDs or names to a graph file, or
+// to a list of them for assets with more than one ecosystem. Relative
+// paths are resolved against the mapping file's directory.
+func Load(path string) (*Graphs, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var files map[string]paths
+	if err := json.Unmarshal(b, &files); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	gs := &Graphs{Mapping: make(map[string]*Graph, len(files))}
+	for asset, list := range files {
+		g := newGraph()
+		for _, file := range list {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(path), file)
+			}
+			part, err := ParseFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %q: %w", path, asset, err)
+			}
+			for p, d := range part.Depth {
+				if cur, ok := g.Depth[p]; !ok || d < cur {
+					g.Depth[p] = d
+				}
+			}
+		}
+		gs.Mapping[asset] = g
+	}
+	return gs, nil
+}
+
+// paths is a JSON string or array of strings.
+type paths []string
+
+func (p *paths) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*p = paths{one}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(p))
+}
+
+// For returns the graph of the asset of v: the entry for its asset ID,
+// else its asset name.
+func (gs *Graphs) For(v *vuln.Vulnerability) (*Graph, bool) {
+	for _, k := range []string{v.AssetID, v.AssetName} {
+		if k == "" {
+			continue
+		}
+		if g, ok := gs.Mapping[k]; ok {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// Apply classifies every finding whose package is in its asset's graph
+// as a direct or transitive dependency, and returns how many of each it
+// found.
+func (gs *Graphs) Apply(vs []vuln.Vulnerability) (direct, transitive int) {
+	for i := range vs {
+		v := &vs[i]
+		g, ok := gs.For(v)
+		if !ok {
+			continue
+		}
+		depth := g.DepthOf(v)
+		switch {
+		case depth == 0:
+			continue
+		case depth == 1:
+			v.Dependency = vuln.DependencyDirect
+			direct++
+		default:
+			v.Dependency = vuln.DependencyTransitive
+			transitive++
+		}
+		v.DependencyDepth = depth
+	}
+	return direct, transitive
+}
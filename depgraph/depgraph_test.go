@@ -0,0 +1,92 @@
+package depgraph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestParseGoModGraph(t *testing.T) {
+	g, err := ParseFile("testdata/go-mod-graph.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]int{
+		"github.com/golang-jwt/jwt/v4": 1,
+		"golang.org/x/net":             1, // also required by x/crypto, deeper
+		"golang.org/x/crypto":          2,
+		"golang.org/x/sys":             3,
+		"go":                           0,
+		"example.com/webhooks":         0,
+	} {
+		if got := g.Depth[Package{vuln.EcosystemGo, name}]; got != want {
+			t.Errorf("depth of %s = %d, want %d", name, got, want)
+		}
+	}
+	if _, err := Parse(strings.NewReader("a@v1 b@v1\n")); err == nil {
+		t.Error("graph without a main module: want error")
+	}
+}
+
+func TestParseNPMLock(t *testing.T) {
+	g, err := ParseFile("testdata/package-lock.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]int{
+		"express":            1,
+		"vite":               1, // dev dependencies are the team's too
+		"cookie":             2, // nested under express
+		"ms":                 3,
+		"@esbuild/linux-x64": 2,
+		"follow-redirects":   2, // declared but not installed
+		"webhooks":           0,
+	} {
+		if got := g.Depth[Package{vuln.EcosystemNPM, name}]; got != want {
+			t.Errorf("depth of %s = %d, want %d", name, got, want)
+		}
+	}
+	if _, err := Parse(strings.NewReader(`{"lockfileVersion": 1, "dependencies": {}}`)); err == nil || !strings.Contains(err.Error(), "npm 7") {
+		t.Errorf("lockfile v1: err = %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	gs, err := Load("testdata/graphs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := []vuln.Vulnerability{
+		{AssetName: "acme-inc-webhooks", PackageName: "npm-express"},
+		{AssetName: "acme-inc-webhooks", PackageName: "npm-cookie"},
+		{AssetName: "acme-inc-webhooks", PackageName: "go-golang.org/x/crypto"},
+		{AssetName: "acme-inc-webhooks", PackageName: "npm-golang.org/x/crypto"}, // wrong ecosystem
+		{AssetID: "464322508", AssetName: "apply-frontend", PackageName: "npm-Axios"},
+		{AssetName: "apply-api", PackageName: "npm-express"}, // no graph
+		{AssetName: "acme-inc-webhooks", PackageName: "npm-@expressjs/cookie", RenamedFrom: "npm-cookie"},
+		{AssetID: "arn:aws:ecr:us-west-2:1:repository/match-v1", PackageName: "linux", Source: "aws"},
+	}
+	direct, transitive := gs.Apply(vs)
+	if direct != 2 || transitive != 3 {
+		t.Errorf("Apply = %d direct, %d transitive, want 2, 3", direct, transitive)
+	}
+	want := []struct {
+		dependency string
+		depth      int
+	}{
+		{vuln.DependencyDirect, 1},
+		{vuln.DependencyTransitive, 2},
+		{vuln.DependencyTransitive, 2},
+		{"", 0},
+		{vuln.DependencyDirect, 1},
+		{"", 0},
+		{vuln.DependencyTransitive, 2},
+		{"", 0},
+	}
+	for i, w := range want {
+		if vs[i].Dependency != w.dependency || vs[i].DependencyDepth != w.depth {
+			t.Errorf("%s on %s: %q at depth %d, want %q at %d", vs[i].PackageName, vs[i].AssetName, vs[i].Dependency, vs[i].DependencyDepth, w.dependency, w.depth)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package effort estimates the work of remediating each finding, in story
+// points, from whether it can be fixed, how far the fix is from the
+// installed version and how many assets need the same fix, so that
+// planners can weigh the risk a fix removes against what it costs.
+package effort
+
+import (
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/enrich"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Distance is how far a fixed version is from the installed one, by the
+// semantic version component that changes.
+type Distance string
+
+// Upgrade distances. Unknown is a fix whose distance the versions do not
+// tell, as when the installed version is only given as a range below the
+// fix.
+const (
+	Patch   Distance = "patch"
+	Minor   Distance = "minor"
+	Major   Distance = "major"
+	Unknown Distance = "unknown"
+)
+
+// distancePoints are the story points of upgrading one asset.
+var distancePoints = map[Distance]int{Patch: 1, Minor: 2, Unknown: 3, Major: 5}
+
+// noFixPoints is the estimate for a finding with no fix: a mitigation, or
+// tracking the risk until a fix is released.
+const noFixPoints = 8
+
+// Scale is the story point scale estimates are rounded up to.
+var Scale = []int{1, 2, 3, 5, 8, 13, 21}
+
+// UpgradeDistance returns the distance from installed to fixed. A version
+// may be a distribution package version, whose upstream part is compared,
+// and an installed version a dependency scanner's affected range, as in
+// ">= 7.0.0, < 7.5.10", whose lower bound is compared. Below 1.0.0 a minor
+// version change is major, as semantic versioning allows it to break.
+func UpgradeDistance(installed, fixed string) Distance {
+	from, to := numbers(comparable(installed)), numbers(comparable(fixed))
+	if len(from) == 0 || len(to) == 0 {
+		return Unknown
+	}
+	for len(from) < 3 {
+		from = append(from, 0)
+	}
+	for len(to) < 3 {
+		to = append(to, 0)
+	}
+	switch {
+	case to[0] != from[0]:
+		if to[0] < from[0] {
+			return Unknown
+		}
+		return Major
+	case to[1] != from[1]:
+		if to[1] < from[1] {
+			return Unknown
+		}
+		if to[0] == 0 {
+			return Major
+		}
+		return Minor
+	}
+	return Patch
+}
+
+// comparable returns the part of a version to compare: the upstream
+// version of a package version, the lower bound of a range, or "" for a
+// range without one.
+func comparable(s string) string {
+	if !strings.ContainsAny(s, "<>=") {
+		return enrich.UpstreamVersion(strings.TrimPrefix(strings.TrimSpace(s), "v"))
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if bound, ok := strings.CutPrefix(part, ">="); ok {
+			return strings.TrimPrefix(strings.TrimSpace(bound), "v")
+		}
+	}
+	return ""
+}
+
+// numbers returns the numeric components of a dotted version, as in
+// [5 0 0] for "5.0.0-alpha.0", stopping at the first that does not start
+// with a digit.
+func numbers(version string) []int {
+	var ns []int
+	for _, part := range strings.Split(version, ".") {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		n, err := strconv.Atoi(part[:end])
+		if err != nil {
+			break
+		}
+		ns = append(ns, n)
+		if end < len(part) {
+			break
+		}
+	}
+	return ns
+}
+
+// Estimate returns the story points of remediating v where the same fix
+// is needed on assets assets. A finding with a fixed version costs its
+// upgrade distance. A fixable OS package without one is taken to be a
+// distribution security update, which keeps the upstream version, so
+// costs a patch. Each doubling of the assets adds a point for rolling the
+// fix out, and the total is rounded up to Scale.
+func Estimate(v *vuln.Vulnerability, assets int) int {
+	points := noFixPoints
+	switch {
+	case v.HasFix():
+		points = distancePoints[UpgradeDistance(v.InstalledVersion, v.FixedVersion)]
+	case !fixable(v):
+	case v.Ecosystem() == vuln.EcosystemOSPkg:
+		points = distancePoints[Patch]
+	default:
+		points = distancePoints[Unknown]
+	}
+	if assets > 1 {
+		points += bits.Len(uint(assets - 1))
+	}
+	for _, p := range Scale {
+		if points <= p {
+			return p
+		}
+	}
+	return Scale[len(Scale)-1]
+}
+
+func fixable(v *vuln.Vulnerability) bool {
+	return strings.EqualFold(strings.TrimSpace(v.Fixability), "fixable")
+}
+
+// Apply sets the Effort of each finding in vs, counting the assets in vs
+// that need the same fix: the same package upgraded to the same fixed
+// version or, without one, the same installed package version updated.
+// Findings without a package are grouped by identifier.
+func Apply(vs []vuln.Vulnerability) {
+	assets := make(map[[3]string]map[string]bool)
+	keys := make([][3]string, len(vs))
+	for i := range vs {
+		v := &vs[i]
+		k := [3]string{strings.ToLower(strings.TrimSpace(v.PackageName)), strings.TrimSpace(v.FixedVersion)}
+		if k[0] == "" {
+			k[0] = strings.ToUpper(strings.TrimSpace(v.Identifier))
+		}
+		if !v.HasFix() {
+			k[2] = strings.TrimSpace(v.InstalledVersion)
+		}
+		keys[i] = k
+		if assets[k] == nil {
+			assets[k] = make(map[string]bool)
+		}
+		assets[k][v.AssetID] = true
+	}
+	for i := range vs {
+		vs[i].Effort = Estimate(&vs[i], len(assets[keys[i]]))
+	}
+}
+
+// Sort orders vs by action timeframe, most urgent first, and within one
+// by ascending effort, so that the cheapest fixes of each timeframe come
+// first. Findings of equal effort keep their order.
+func Sort(vs []vuln.Vulnerability) {
+	sort.SliceStable(vs, func(i, j int) bool {
+		a, b := &vs[i], &vs[j]
+		if a.RemediateWithinDays != b.RemediateWithinDays {
+			return a.RemediateWithinDays < b.RemediateWithinDays
+		}
+		return a.Effort < b.Effort
+	})
+}
@@ -0,0 +1,80 @@
+package effort
+
+import (
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestUpgradeDistance(t *testing.T) {
+	for _, tt := range []struct {
+		installed, fixed string
+		want             Distance
+	}{
+		{"1.7.3", "1.7.4", Patch},
+		{"v0.17.0", "0.31.0", Major},
+		{"0.1.7", "0.1.10", Patch},
+		{"4.17.1", "4.20.0", Minor},
+		{"2.1.0", "5.2.4", Major},
+		{">= 7.0.0, < 7.5.10", "7.5.10", Minor},
+		{">= 7.5.0, < 7.5.10", "7.5.10", Patch},
+		{">= 1.3.2, <= 1.7.3", "1.7.4", Minor},
+		{">= 5.0.0-alpha.0, < 5.94.0", "5.94.0", Minor},
+		{"2.31-13", "2.31-13+deb11u7", Patch},
+		{"1:1.1.1n-0+deb10u3", "1:1.1.1w-0+deb10u1", Patch},
+		{"< 3.0.3", "3.0.3", Unknown},
+		{"<= 4.1.392", "4.2.67", Unknown},
+		{"2.0.0", "1.9.9", Unknown},
+		{"latest", "1.0.0", Unknown},
+	} {
+		if got := UpgradeDistance(tt.installed, tt.fixed); got != tt.want {
+			t.Errorf("UpgradeDistance(%q, %q) = %s, want %s", tt.installed, tt.fixed, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	npm := func(asset, fixed string) vuln.Vulnerability {
+		return vuln.Vulnerability{AssetID: asset, PackageName: "npm-ws", InstalledVersion: ">= 7.0.0, < 7.5.10", FixedVersion: fixed, Fixability: "Fixable"}
+	}
+	deb := vuln.Vulnerability{AssetID: "i-1", Source: "aws", PackageName: "openssl", InstalledVersion: "1.1.1n-0+deb10u3", Fixability: "Fixable"}
+	unfixed := vuln.Vulnerability{AssetID: "i-1", PackageName: "libtiff", InstalledVersion: "4.1.0", Fixability: "Not fixable"}
+	major := vuln.Vulnerability{AssetID: "i-1", PackageName: "npm-ws", InstalledVersion: ">= 2.1.0, < 5.2.4", FixedVersion: "6.0.0", Fixability: "Fixable"}
+
+	vs := []vuln.Vulnerability{npm("a", "7.5.10"), npm("b", "7.5.10"), npm("c", "7.5.10"), npm("c", "7.5.10"), deb, unfixed, major}
+	Apply(vs)
+	// A minor upgrade on three assets: 2 + 2 points for the rollout,
+	// rounded up.
+	for i, want := range []int{5, 5, 5, 5, 1, 8, 5} {
+		if vs[i].Effort != want {
+			t.Errorf("vs[%d] (%s %s on %s) effort = %d, want %d", i, vs[i].PackageName, vs[i].FixedVersion, vs[i].AssetID, vs[i].Effort, want)
+		}
+	}
+
+	many := make([]vuln.Vulnerability, 40)
+	for i := range many {
+		many[i] = major
+		many[i].AssetID = string(rune('A' + i))
+	}
+	Apply(many)
+	if many[0].Effort != 13 {
+		t.Errorf("major upgrade on 40 assets: effort %d, want 13", many[0].Effort)
+	}
+}
+
+func TestSort(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{UniqueID: "urgent-hard", RemediateWithinDays: 7, Effort: 8},
+		{UniqueID: "urgent-easy", RemediateWithinDays: 7, Effort: 1},
+		{UniqueID: "planned-easy", RemediateWithinDays: 90, Effort: 1},
+		{UniqueID: "immediate-hard", RemediateWithinDays: 2, Effort: 13},
+		{UniqueID: "urgent-easy-2", RemediateWithinDays: 7, Effort: 1},
+	}
+	Sort(vs)
+	want := []string{"immediate-hard", "urgent-easy", "urgent-easy-2", "urgent-hard", "planned-easy"}
+	for i, id := range want {
+		if vs[i].UniqueID != id {
+			t.Errorf("vs[%d] = %s, want %s", i, vs[i].UniqueID, id)
+		}
+	}
+}
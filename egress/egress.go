@@ -0,0 +1,232 @@
+// Package egress configures the HTTP client the enrichment sources and
+// API integrations fetch through, for networks whose egress goes through
+// a proxy that intercepts TLS with its own certificate authority.
+package egress
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+)
+
+// Direct is the proxy of an endpoint that is reached without one.
+const Direct = "direct"
+
+// Config is the egress configuration. The zero Config is the default
+// client's: the proxy from HTTPS_PROXY, HTTP_PROXY and NO_PROXY, and the
+// system's certificate authorities.
+type Config struct {
+	// Proxy is the URL of the proxy to fetch through, or Direct. When it
+	// is empty the proxy environment variables apply.
+	Proxy string `json:"proxy,omitempty"`
+	// NoProxy are the hosts fetched without Proxy, as in NO_PROXY.
+	NoProxy []string `json:"no_proxy,omitempty"`
+	// CABundle is a PEM file of certificate authorities trusted in
+	// addition to the system's, such as the proxy's.
+	CABundle string `json:"ca_bundle,omitempty"`
+	// Endpoints override the settings for particular hosts.
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+}
+
+// Endpoint is the configuration of the hosts Host matches: a host name,
+// or "*." and a domain for the hosts under it. Settings left empty are
+// the Config's.
+type Endpoint struct {
+	Host     string `json:"host"`
+	Proxy    string `json:"proxy,omitempty"`
+	CABundle string `json:"ca_bundle,omitempty"`
+	// InsecureSkipVerify accepts any certificate the host presents. It is
+	// meant for an internal mirror with a self-signed certificate whose
+	// authority cannot be exported; prefer CABundle.
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+}
+
+// matches reports whether e applies to host.
+func (e *Endpoint) matches(host string) bool {
+	pattern := strings.ToLower(e.Host)
+	if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+domain)
+	}
+	return host == pattern
+}
+
+// Load reads the egress configuration from path, JSON or YAML as
+// config.Load reads configuration files, and validates it. Unknown keys
+// are rejected.
+func Load(path string) (*Config, error) {
+	b, err := config.ReadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func (c *Config) validate() error {
+	if _, err := proxyURL(c.Proxy); err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for i := range c.Endpoints {
+		e := &c.Endpoints[i]
+		host := strings.ToLower(e.Host)
+		if host == "" || strings.ContainsAny(host, "/:") || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			return fmt.Errorf("endpoint %d: host %q is not a host name or *.domain", i+1, e.Host)
+		}
+		if seen[host] {
+			return fmt.Errorf("endpoint %s: listed twice", e.Host)
+		}
+		seen[host] = true
+		if _, err := proxyURL(e.Proxy); err != nil {
+			return fmt.Errorf("endpoint %s: %w", e.Host, err)
+		}
+	}
+	return nil
+}
+
+// proxyURL parses a proxy setting, returning nil for "" and Direct.
+func proxyURL(s string) (*url.URL, error) {
+	if s == "" || s == Direct {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("proxy %q: want an http, https or socks5 URL, or %q", s, Direct)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %q: no host", s)
+	}
+	return u, nil
+}
+
+// Client returns an HTTP client that fetches as c configures, reading the
+// CA bundles it names.
+func (c *Config) Client() (*http.Client, error) {
+	base, err := c.transport(c.Proxy, c.CABundle, false)
+	if err != nil {
+		return nil, err
+	}
+	r := &router{base: base}
+	for i := range c.Endpoints {
+		e := &c.Endpoints[i]
+		proxy, bundle := e.Proxy, e.CABundle
+		if proxy == "" {
+			proxy = c.Proxy
+		}
+		if bundle == "" {
+			bundle = c.CABundle
+		}
+		t, err := c.transport(proxy, bundle, e.InsecureSkipVerify)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s: %w", e.Host, err)
+		}
+		r.endpoints = append(r.endpoints, e)
+		r.transports = append(r.transports, t)
+	}
+	return &http.Client{Transport: r}, nil
+}
+
+// transport returns a transport like the default one that fetches through
+// proxy and trusts bundle's authorities with the system's.
+func (c *Config) transport(proxy, bundle string, insecure bool) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	u, err := proxyURL(proxy)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case proxy == Direct:
+		t.Proxy = nil
+	case u != nil:
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			if c.bypass(req.URL.Hostname()) {
+				return nil, nil
+			}
+			return u, nil
+		}
+	}
+	if bundle != "" || insecure {
+		t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: insecure}
+	}
+	if bundle != "" {
+		pool, err := LoadCABundle(bundle)
+		if err != nil {
+			return nil, err
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return t, nil
+}
+
+// bypass reports whether host is one of NoProxy: the host itself, or a
+// domain it is under, with or without a leading dot or "*.".
+func (c *Config) bypass(host string) bool {
+	host = strings.ToLower(host)
+	for _, h := range c.NoProxy {
+		h = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(h, "*"), "."))
+		if h == "*" || host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoCertificates is returned for a CA bundle without a PEM certificate.
+var ErrNoCertificates = errors.New("no PEM certificates")
+
+// LoadCABundle returns the system's certificate authorities with those of
+// the PEM file at path added.
+func LoadCABundle(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoCertificates)
+	}
+	return pool, nil
+}
+
+// router sends each request through the transport of the first endpoint
+// matching its host, or base.
+type router struct {
+	base       *http.Transport
+	endpoints  []*Endpoint
+	transports []*http.Transport
+}
+
+func (r *router) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	for i, e := range r.endpoints {
+		if e.matches(host) {
+			return r.transports[i].RoundTrip(req)
+		}
+	}
+	return r.base.RoundTrip(req)
+}
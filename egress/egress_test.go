@@ -0,0 +1,167 @@
+package egress
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func get(t *testing.T, c *http.Client, u string) (string, error) {
+	t.Helper()
+	resp, err := c.Get(u)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	return string(b), err
+}
+
+func TestLoad(t *testing.T) {
+	c, err := Load(writeFile(t, "egress.yaml", `proxy: http://proxy.corp:3128
+no_proxy: [.corp, localhost]
+ca_bundle: /etc/ssl/corp-ca.pem
+endpoints:
+  - host: mirror.corp
+    proxy: direct
+    insecure_skip_verify: true
+  - host: "*.nist.gov"
+    ca_bundle: /etc/ssl/nist.pem
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Proxy != "http://proxy.corp:3128" || len(c.NoProxy) != 2 || len(c.Endpoints) != 2 || !c.Endpoints[0].InsecureSkipVerify {
+		t.Fatalf("config = %+v", c)
+	}
+
+	for name, content := range map[string]string{
+		"proxy scheme":   `{"proxy": "ftp://proxy.corp"}`,
+		"proxy host":     `{"proxy": "http://"}`,
+		"endpoint url":   `{"endpoints": [{"host": "https://nvd.nist.gov"}]}`,
+		"endpoint glob":  `{"endpoints": [{"host": "nvd.*.gov"}]}`,
+		"no host":        `{"endpoints": [{"proxy": "direct"}]}`,
+		"duplicate host": `{"endpoints": [{"host": "a.corp"}, {"host": "A.corp"}]}`,
+		"endpoint proxy": `{"endpoints": [{"host": "a.corp", "proxy": "corp"}]}`,
+		"unknown key":    `{"proxies": "http://proxy.corp"}`,
+	} {
+		if _, err := Load(writeFile(t, "egress.json", content)); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+}
+
+func TestClientCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+	bundle := writeFile(t, "ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})))
+
+	c, err := (&Config{}).Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(t, c, srv.URL); err == nil {
+		t.Error("untrusted certificate accepted")
+	}
+
+	c, err = (&Config{CABundle: bundle}).Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, err := get(t, c, srv.URL); err != nil || body != "ok" {
+		t.Errorf("with CA bundle: %q, %v", body, err)
+	}
+
+	// Only the endpoint skips verification.
+	c, err = (&Config{Endpoints: []Endpoint{{Host: "localhost", InsecureSkipVerify: true}}}).Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(srv.URL)
+	if body, err := get(t, c, "https://localhost:"+u.Port()); err != nil || body != "ok" {
+		t.Errorf("insecure endpoint: %q, %v", body, err)
+	}
+	if _, err := get(t, c, srv.URL); err == nil {
+		t.Error("verification skipped for another host")
+	}
+
+	if _, err := (&Config{CABundle: writeFile(t, "empty.pem", "not a certificate\n")}).Client(); !errors.Is(err, ErrNoCertificates) {
+		t.Errorf("bundle without certificates: %v", err)
+	}
+	if _, err := (&Config{Endpoints: []Endpoint{{Host: "a.corp", CABundle: filepath.Join(t.TempDir(), "missing.pem")}}}).Client(); err == nil {
+		t.Error("missing endpoint bundle accepted")
+	}
+}
+
+func TestClientProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "proxied %s", r.URL)
+	}))
+	defer proxy.Close()
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer origin.Close()
+	u, _ := url.Parse(origin.URL)
+
+	c, err := (&Config{
+		Proxy:     proxy.URL,
+		NoProxy:   []string{".internal"},
+		Endpoints: []Endpoint{{Host: "localhost", Proxy: Direct}},
+	}).Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, err := get(t, c, "http://feeds.example/kev.json"); err != nil || body != "proxied http://feeds.example/kev.json" {
+		t.Errorf("through proxy: %q, %v", body, err)
+	}
+	if body, err := get(t, c, "http://localhost:"+u.Port()); err != nil || body != "direct" {
+		t.Errorf("direct endpoint: %q, %v", body, err)
+	}
+
+	cfg := &Config{NoProxy: []string{".internal", "*.corp", "mirror.example"}}
+	for host, want := range map[string]bool{
+		"nvd.internal": true, "a.b.corp": true, "corp": true, "mirror.example": true,
+		"MIRROR.example": true, "nvd.nist.gov": false, "notcorp": false, "cdn.mirror.example": true,
+	} {
+		if got := cfg.bypass(host); got != want {
+			t.Errorf("bypass(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestEndpointMatches(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, host string
+		want          bool
+	}{
+		{"nvd.nist.gov", "nvd.nist.gov", true},
+		{"NVD.nist.gov", "nvd.nist.gov", true},
+		{"nvd.nist.gov", "services.nvd.nist.gov", false},
+		{"*.nist.gov", "services.nvd.nist.gov", true},
+		{"*.nist.gov", "nist.gov", false},
+	} {
+		e := &Endpoint{Host: tc.pattern}
+		if got := e.matches(tc.host); got != tc.want {
+			t.Errorf("%q matches %q = %v, want %v", tc.pattern, tc.host, got, tc.want)
+		}
+	}
+}
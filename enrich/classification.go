@@ -0,0 +1,64 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Classifications sets the data classification of findings from their
+// asset. Mapping keys are asset IDs or names; values are one of
+// vuln.Classifications.
+type Classifications struct {
+	Mapping map[string]string
+}
+
+// LoadClassifications reads a JSON object mapping asset IDs or names to
+// data classifications, as in {"i-0abc123": "restricted"}. Unknown
+// classifications are rejected, so a typo does not leave an asset scored
+// as unclassified.
+func LoadClassifications(path string) (*Classifications, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(b, &mapping); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c := &Classifications{Mapping: make(map[string]string, len(mapping))}
+	for asset, class := range mapping {
+		if c.Mapping[strings.TrimSpace(asset)], err = vuln.ParseClassification(class); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, asset, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *Classifications) Name() string { return "classifications" }
+
+// Enrich sets the data classification of every finding whose asset ID or
+// name is mapped, the ID taking precedence. Classifications from the
+// input are kept. It counts the findings it classified.
+func (c *Classifications) Enrich(_ context.Context, vs []vuln.Vulnerability) (int, error) {
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		if v.DataClassification != "" {
+			continue
+		}
+		class, ok := c.Mapping[strings.TrimSpace(v.AssetID)]
+		if !ok {
+			class, ok = c.Mapping[strings.TrimSpace(v.AssetName)]
+		}
+		if ok {
+			v.DataClassification = class
+			n++
+		}
+	}
+	return n, nil
+}
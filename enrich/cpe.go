@@ -0,0 +1,214 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// CPEMatch discovers CVEs that apply to the OS packages of host findings
+// but that the scanner did not report. It builds a CPE from each
+// package's name and upstream version and asks the NVD CVE API 2.0 for
+// the CVEs whose configurations match it. Each CPE is requested once.
+//
+// Matching is by upstream version, so it cannot see fixes a distribution
+// backports: discovered findings are leads to confirm, not scanner
+// results.
+type CPEMatch struct {
+	// BaseURL is the CVE API endpoint, NVDURL by default.
+	BaseURL string
+	Client  *http.Client
+	// APIKey, when set, is sent with each request; see NVD.
+	APIKey string
+	// Interval is the delay between requests.
+	Interval time.Duration
+}
+
+// packageInstance identifies one installed package on one asset.
+type packageInstance struct {
+	assetID, name, version string
+}
+
+// Discover returns a finding for each CVE NVD matches to a host package
+// in vs that vs does not already report for it, marked Discovered. The
+// finding takes the asset and package fields of the package's first
+// finding in vs, and its score, vector, severity, CWEs and description
+// from NVD.
+func (c *CPEMatch) Discover(ctx context.Context, vs []vuln.Vulnerability) ([]vuln.Vulnerability, error) {
+	reported := make(map[packageInstance]map[string]bool)
+	first := make(map[packageInstance]int)
+	instances := make(map[string][]packageInstance) // by CPE
+	var order []string
+	for i := range vs {
+		v := &vs[i]
+		if v.Ecosystem() != vuln.EcosystemOSPkg {
+			continue
+		}
+		name := CPE(v.PackageName, v.InstalledVersion)
+		if name == "" {
+			continue
+		}
+		pi := packageInstance{v.AssetID, v.PackageName, v.InstalledVersion}
+		if reported[pi] == nil {
+			reported[pi] = make(map[string]bool)
+			first[pi] = i
+			if _, seen := instances[name]; !seen {
+				order = append(order, name)
+			}
+			instances[name] = append(instances[name], pi)
+		}
+		reported[pi][strings.ToUpper(strings.TrimSpace(v.Identifier))] = true
+	}
+
+	var found []vuln.Vulnerability
+	requests := 0
+	for _, name := range order {
+		cves, err := c.match(ctx, name, &requests)
+		if err != nil {
+			return found, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, pi := range instances[name] {
+			base := &vs[first[pi]]
+			for i := range cves {
+				cve := &cves[i]
+				if reported[pi][cve.ID] {
+					continue
+				}
+				d := vuln.Vulnerability{
+					AssetName:        base.AssetName,
+					AssetID:          base.AssetID,
+					Organization:     base.Organization,
+					Region:           base.Region,
+					Source:           base.Source,
+					PackageName:      base.PackageName,
+					InstalledVersion: base.InstalledVersion,
+					Identifier:       cve.ID,
+					Title:            cve.description(),
+					Description:      cve.description(),
+					Severity:         vuln.Low,
+					Discovered:       true,
+				}
+				if m, ok := cve.baseMetric(); ok {
+					d.Severity = m.severity()
+				}
+				cve.fill(&d)
+				found = append(found, d)
+			}
+		}
+	}
+	return found, nil
+}
+
+// match returns every CVE NVD matches to the CPE name, following pages.
+// requests counts the requests made, to space them by Interval.
+func (c *CPEMatch) match(ctx context.Context, name string, requests *int) ([]nvdCVE, error) {
+	var cves []nvdCVE
+	for {
+		if *requests > 0 && c.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.Interval):
+			}
+		}
+		*requests++
+		page, err := getNVD(ctx, c.Client, c.BaseURL, c.APIKey, url.Values{
+			"virtualMatchString": {name},
+			"startIndex":         {strconv.Itoa(len(cves))},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range page.Vulnerabilities {
+			cves = append(cves, v.CVE)
+		}
+		if len(page.Vulnerabilities) == 0 || len(cves) >= page.TotalResults {
+			return cves, nil
+		}
+	}
+}
+
+// cpeProducts maps distribution source package names to the NVD's
+// product names where they differ.
+var cpeProducts = map[string]string{
+	"linux":         "linux_kernel",
+	"tiff":          "libtiff",
+	"xz-utils":      "xz",
+	"libzstd":       "zstandard",
+	"krb5":          "kerberos_5",
+	"icu":           "international_components_for_unicode",
+	"exim4":         "exim",
+	"sqlite3":       "sqlite",
+	"glib2.0":       "glib",
+	"gnutls28":      "gnutls",
+	"gnupg2":        "gnupg",
+	"libgcrypt20":   "libgcrypt",
+	"libtasn1-6":    "libtasn1",
+	"openjpeg2":     "openjpeg",
+	"pcre3":         "pcre",
+	"libuv1":        "libuv",
+	"cyrus-sasl2":   "cyrus-sasl",
+	"gcc-8":         "gcc",
+	"gcc-12":        "gcc",
+	"python2.7":     "python",
+	"python3.7":     "python",
+	"python3.10":    "python",
+	"postgresql-11": "postgresql",
+	"mariadb-10.3":  "mariadb",
+}
+
+// CPE returns the CPE 2.3 name matching any vendor's product named for
+// the OS package, at the package's upstream version, or "" when the
+// version has no upstream part.
+func CPE(pkg, version string) string {
+	pkg = strings.ToLower(strings.TrimSpace(pkg))
+	up := UpstreamVersion(version)
+	if pkg == "" || up == "" {
+		return ""
+	}
+	if p, ok := cpeProducts[pkg]; ok {
+		pkg = p
+	}
+	return "cpe:2.3:*:*:" + cpeEscape(pkg) + ":" + cpeEscape(up) + ":*:*:*:*:*:*:*"
+}
+
+// UpstreamVersion returns the upstream part of a distribution package
+// version: without the epoch, the distribution revision and any
+// repackaging suffix, as in "1.1.1n" for "1:1.1.1n-0+deb10u3" and "4.1.0"
+// for "4.1.0+git191117-2~deb10u2". It returns "" for versions that do not
+// start with a digit, such as dependency scanners' ranges.
+func UpstreamVersion(version string) string {
+	v := strings.TrimSpace(version)
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		v = v[i+1:]
+	}
+	if i := strings.LastIndexByte(v, '-'); i > 0 {
+		v = v[:i]
+	}
+	if i := strings.IndexAny(v, "+~"); i >= 0 {
+		v = v[:i]
+	}
+	if v == "" || v[0] < '0' || v[0] > '9' {
+		return ""
+	}
+	return v
+}
+
+// cpeEscape quotes the characters a CPE 2.3 formatted string component
+// may not contain unquoted.
+func cpeEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
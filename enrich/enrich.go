@@ -0,0 +1,129 @@
+// Package enrich augments findings with threat intelligence: EPSS exploit
+// probabilities, CISA KEV membership, public exploits in the Exploit
+// Database and Metasploit, NVD CVSS scores and the CVEs of Windows KB
+// updates.
+package enrich
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/tracing"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Default feed locations.
+const (
+	EPSSURL = "https://epss.empiricalsecurity.com/epss_scores-current.csv.gz"
+	KEVURL  = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+	NVDURL  = "https://services.nvd.nist.gov/rest/json/cves/2.0"
+)
+
+// An Enricher adds data from one source to findings in place.
+type Enricher interface {
+	// Name identifies the source in logs and run manifests.
+	Name() string
+	// Enrich updates vs and returns how many findings it changed.
+	Enrich(ctx context.Context, vs []vuln.Vulnerability) (int, error)
+}
+
+// Stat is the outcome of one enricher.
+type Stat struct {
+	Name     string
+	Enriched int
+}
+
+// Run applies each enricher to vs in order and stops at the first error.
+// Each enricher's run is a span of the trace ctx carries, if any.
+func Run(ctx context.Context, vs []vuln.Vulnerability, enrichers []Enricher) ([]Stat, error) {
+	stats := make([]Stat, 0, len(enrichers))
+	for _, e := range enrichers {
+		ctx, span := tracing.Start(ctx, "enrich "+e.Name(), tracing.Int("prioritizer.findings", len(vs)))
+		n, err := e.Enrich(ctx, vs)
+		span.SetAttributes(tracing.Int("prioritizer.enriched", n))
+		span.End(err)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		stats = append(stats, Stat{Name: e.Name(), Enriched: n})
+	}
+	return stats, nil
+}
+
+var cvePattern = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
+
+// cveID returns v's identifier in canonical upper case, or "" when it is
+// not a CVE ID.
+func cveID(v *vuln.Vulnerability) string {
+	id := strings.ToUpper(strings.TrimSpace(v.Identifier))
+	if !cvePattern.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+// cveIDs returns the CVEs v is about: its identifier when that is a CVE
+// ID, and otherwise its related CVEs, as resolved for a KB update.
+func cveIDs(v *vuln.Vulnerability) []string {
+	if id := cveID(v); id != "" {
+		return []string{id}
+	}
+	return v.RelatedCVEs
+}
+
+// open opens src, an http(s) URL or a file path, decompressing gzip
+// content.
+func open(ctx context.Context, client *http.Client, src string) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := httpClient(client).Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("GET %s: %s", src, resp.Status)
+		}
+		rc = resp.Body
+	} else {
+		f, err := os.Open(src)
+		if err != nil {
+			return nil, err
+		}
+		rc = f
+	}
+
+	br := bufio.NewReader(rc)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("%s: %w", src, err)
+		}
+		return readCloser{zr, rc}, nil
+	}
+	return readCloser{br, rc}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func httpClient(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
@@ -0,0 +1,372 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func findings() []vuln.Vulnerability {
+	return []vuln.Vulnerability{
+		{Identifier: "CVE-2024-4367", CVSS: 8.8},
+		{Identifier: "cve-2020-1752", CVSS: 0},
+		{Identifier: "CVE-2020-1752", CVSS: 0},
+		{Identifier: "GHSA-xxxx-yyyy-zzzz"},
+	}
+}
+
+func TestEPSS(t *testing.T) {
+	for _, src := range []string{"testdata/epss.csv", "testdata/epss.csv.gz"} {
+		vs := findings()
+		n, err := (&EPSS{Source: src}).Enrich(context.Background(), vs)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		if n != 3 || vs[0].EPSS != 0.95821 || vs[0].EPSSPercentile != 0.99512 || vs[1].EPSS != 0.00102 || vs[3].EPSS != 0 {
+			t.Errorf("%s: enriched %d: %+v", src, n, vs)
+		}
+	}
+}
+
+func TestKEVOverHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer srv.Close()
+
+	vs := findings()
+	stats, err := Run(context.Background(), vs, []Enricher{&KEV{Source: srv.URL + "/kev.json"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 1 || stats[0].Enriched != 1 || !vs[0].KEV || vs[1].KEV {
+		t.Errorf("stats %+v, findings %+v", stats, vs)
+	}
+
+	_, err = Run(context.Background(), vs, []Enricher{&KEV{Source: srv.URL + "/missing.json"}})
+	if err == nil {
+		t.Error("missing feed: want error")
+	}
+}
+
+func TestExploits(t *testing.T) {
+	vs := findings()
+	n, err := (&ExploitDB{Source: "testdata/files_exploits.csv"}).Enrich(context.Background(), vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || vs[0].Exploit != vuln.ExploitPoC || vs[1].Exploit != vuln.ExploitPoC || vs[3].Exploit != "" {
+		t.Errorf("ExploitDB: enriched %d: %+v", n, vs)
+	}
+	// Only an exploit module weaponizes a CVE, and the Exploit Database
+	// does not lower what Metasploit set.
+	stats, err := Run(context.Background(), vs, []Enricher{&Metasploit{Source: "testdata/modules_metadata_base.json"}, &ExploitDB{Source: "testdata/files_exploits.csv"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats[0].Enriched != 1 || stats[1].Enriched != 0 || vs[0].Exploit != vuln.ExploitWeaponized || vs[1].Exploit != vuln.ExploitPoC {
+		t.Errorf("Metasploit: stats %+v, findings %+v", stats, vs)
+	}
+
+	if _, err := ReadExploitDB(strings.NewReader("id,file,description\n1,a.c,x\n")); err == nil {
+		t.Error("ExploitDB without codes: want error")
+	}
+}
+
+func TestNVD(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("cveId")
+		requests = append(requests, id)
+		fmt.Fprint(w, `{"vulnerabilities": [{"cve": {"id": "`+id+`", "metrics": {
+			"cvssMetricV2": [{"type": "Primary", "cvssData": {"baseScore": 5.0}}],
+			"cvssMetricV31": [
+				{"type": "Secondary", "cvssData": {"baseScore": 9.8}},
+				{"type": "Primary", "cvssData": {"baseScore": 7.5}}
+			]}}}]}`)
+	}))
+	defer srv.Close()
+
+	vs := findings()
+	vs[0].Description = "PDF.js arbitrary JavaScript execution"
+	n, err := (&NVD{BaseURL: srv.URL}).Enrich(context.Background(), vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both spellings of CVE-2020-1752 share one request; CVE-2024-4367
+	// already has a score and description.
+	if len(requests) != 1 || requests[0] != "CVE-2020-1752" {
+		t.Errorf("requests = %v", requests)
+	}
+	if n != 2 || vs[1].CVSS != 7.5 || vs[2].CVSSSource != "nvd" || vs[0].CVSS != 8.8 || vs[0].CVSSSource != "" {
+		t.Errorf("enriched %d: %+v", n, vs)
+	}
+}
+
+func TestNVDFill(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("cveId")
+		requests = append(requests, id+" "+r.Header.Get("apiKey"))
+		if id == "CVE-2024-0001" {
+			fmt.Fprint(w, `{"totalResults": 0, "vulnerabilities": []}`)
+			return
+		}
+		fmt.Fprint(w, `{"vulnerabilities": [{"cve": {"id": "`+id+`",
+			"descriptions": [{"lang": "es", "value": "Servidor"}, {"lang": "en", "value": "ws crashes on too many headers"}],
+			"weaknesses": [
+				{"type": "Secondary", "description": [{"lang": "en", "value": "CWE-476"}, {"lang": "en", "value": "NVD-CWE-noinfo"}]},
+				{"type": "Primary", "description": [{"lang": "en", "value": "CWE-476"}, {"lang": "en", "value": "CWE-400"}]}
+			],
+			"metrics": {"cvssMetricV31": [{"type": "Primary", "cvssData": {"baseScore": 7.5, "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"}}]}}}]}`)
+	}))
+	defer srv.Close()
+
+	findings := func() []vuln.Vulnerability {
+		return []vuln.Vulnerability{
+			{Identifier: "CVE-2024-37890", CVSS: 0},
+			{Identifier: "CVE-2024-37890", CVSS: 8.2},
+			{Identifier: "CVE-2024-0001"},
+			{Identifier: "CVE-2024-4068", CVSS: 7.5, Description: "braces memory exhaustion"},
+		}
+	}
+	cache := filepath.Join(t.TempDir(), "nvd")
+	nvd := &NVD{BaseURL: srv.URL, APIKey: "secret", CacheDir: cache}
+	vs := findings()
+	n, err := nvd.Enrich(context.Background(), vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CVE-2024-4068 has a score and description, so is not looked up.
+	if got := strings.Join(requests, ","); got != "CVE-2024-37890 secret,CVE-2024-0001 secret" {
+		t.Errorf("requests = %s", got)
+	}
+	v := vs[0]
+	if n != 2 || v.CVSS != 7.5 || v.CVSSSource != "nvd" || v.CVSSVector != "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H" ||
+		v.Description != "ws crashes on too many headers" || strings.Join(v.CWEs, ",") != "CWE-476,CWE-400" {
+		t.Errorf("enriched %d, first %+v", n, v)
+	}
+	// The input's own score is kept, and a vector for another score is not
+	// taken.
+	if v := vs[1]; v.CVSS != 8.2 || v.CVSSSource != "" || v.CVSSVector != "" || v.Description == "" || len(v.CWEs) != 2 {
+		t.Errorf("second %+v", v)
+	}
+
+	// Both records, the missing one included, come from the cache.
+	requests = nil
+	vs = findings()
+	if n, err := nvd.Enrich(context.Background(), vs); err != nil || n != 2 || len(requests) != 0 || vs[0].CVSS != 7.5 {
+		t.Errorf("cached: enriched %d, %v, requests %v", n, err, requests)
+	}
+	// Complete mode also looks up scored, described findings.
+	vs = findings()
+	complete := &NVD{BaseURL: srv.URL, CacheDir: cache, Complete: true}
+	if _, err := complete.Enrich(context.Background(), vs); err != nil || len(requests) != 1 || requests[0] != "CVE-2024-4068 " {
+		t.Errorf("complete: %v, requests %v", err, requests)
+	}
+	if v := vs[3]; v.CVSSVector == "" || len(v.CWEs) != 2 || v.Description != "braces memory exhaustion" {
+		t.Errorf("complete: %+v", v)
+	}
+
+	// Expired and unreadable entries are fetched again.
+	requests = nil
+	if err := os.WriteFile(filepath.Join(cache, "CVE-2024-0001.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	expired := &NVD{BaseURL: srv.URL, CacheDir: cache, CacheTTL: time.Nanosecond}
+	if _, err := expired.Enrich(context.Background(), findings()); err != nil || len(requests) != 2 {
+		t.Errorf("expired: %v, requests %v", err, requests)
+	}
+	if entries, _ := os.ReadDir(cache); len(entries) != 3 {
+		t.Errorf("cache holds %d entries, want 3", len(entries))
+	}
+}
+
+func TestUpstreamVersion(t *testing.T) {
+	for in, want := range map[string]string{
+		"4.19.181-1":                "4.19.181",
+		"1:1.1.1n-0+deb10u3":        "1.1.1n",
+		"4.1.0+git191117-2~deb10u2": "4.1.0",
+		"5.34.0-3ubuntu1.3":         "5.34.0",
+		"252.19-1~deb12u1":          "252.19",
+		"<= 4.1.392":                "",
+		"":                          "",
+	} {
+		if got := UpstreamVersion(in); got != want {
+			t.Errorf("UpstreamVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got, want := CPE("linux", "4.19.181-1"), "cpe:2.3:*:*:linux_kernel:4.19.181:*:*:*:*:*:*:*"; got != want {
+		t.Errorf("CPE = %q, want %q", got, want)
+	}
+}
+
+func TestCPEMatch(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cpe, start := r.URL.Query().Get("virtualMatchString"), r.URL.Query().Get("startIndex")
+		requests = append(requests, cpe+"@"+start)
+		if cpe != "cpe:2.3:*:*:curl:7.64.0:*:*:*:*:*:*:*" {
+			fmt.Fprint(w, `{"totalResults": 0, "vulnerabilities": []}`)
+			return
+		}
+		// Two pages: the reported CVE and a new one, then one without metrics.
+		if start == "0" {
+			fmt.Fprint(w, `{"totalResults": 3, "vulnerabilities": [
+				{"cve": {"id": "CVE-2023-27533"}},
+				{"cve": {"id": "CVE-2023-38545", "descriptions": [{"lang": "en", "value": "SOCKS5 heap overflow"}],
+					"metrics": {"cvssMetricV31": [{"type": "Primary", "cvssData": {"baseScore": 9.8, "baseSeverity": "CRITICAL"}}]}}}
+			]}`)
+			return
+		}
+		fmt.Fprint(w, `{"totalResults": 3, "vulnerabilities": [{"cve": {"id": "CVE-2024-0001"}}]}`)
+	}))
+	defer srv.Close()
+
+	vs := []vuln.Vulnerability{
+		{Identifier: "CVE-2023-27533", Source: "aws", AssetID: "img-1", PackageName: "curl", InstalledVersion: "7.64.0-4+deb10u2"},
+		{Identifier: "CVE-2023-27533", Source: "aws", AssetID: "img-2", PackageName: "curl", InstalledVersion: "7.64.0-4+deb10u2"},
+		{Identifier: "CVE-2019-1010022", Source: "aws", AssetID: "img-1", PackageName: "glibc", InstalledVersion: "2.28-10"},
+		{Identifier: "CVE-2024-4367", Source: "github", AssetID: "42", PackageName: "npm-pdfjs-dist", InstalledVersion: "<= 4.1.392"},
+	}
+	found, err := (&CPEMatch{BaseURL: srv.URL}).Discover(context.Background(), vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One request per CPE and page; the npm package has no CPE.
+	if len(requests) != 3 {
+		t.Errorf("requests = %v", requests)
+	}
+	// Two new CVEs on each of the two images with curl.
+	if len(found) != 4 {
+		t.Fatalf("discovered %d findings: %+v", len(found), found)
+	}
+	d := found[0]
+	if d.Identifier != "CVE-2023-38545" || d.AssetID != "img-1" || !d.Discovered || d.CVSS != 9.8 || d.Severity != vuln.Critical || d.CVSSSource != "nvd" || d.Description != "SOCKS5 heap overflow" {
+		t.Errorf("discovered %+v", d)
+	}
+	if d := found[1]; d.Identifier != "CVE-2024-0001" || d.CVSS != 0 || d.Severity != vuln.Low || d.CVSSSource != "" {
+		t.Errorf("unscored discovery %+v", d)
+	}
+	if found[2].AssetID != "img-2" {
+		t.Errorf("second image's discovery %+v", found[2])
+	}
+}
+
+func TestRegions(t *testing.T) {
+	r, err := LoadRegions("testdata/regions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ org, want string }{
+		{"acme-inc-Education", "NA"},
+		{"253710526682: us-west-2", "NA-prod"}, // account ID
+		{"111111111111: eu-west-1", "EMEA"},    // cloud region
+		{"222222222222: ap-southeast-2", "ap-southeast-2"},
+		{"someone-else", Unassigned},
+	}
+	for _, tt := range tests {
+		if got := r.Region(tt.org); got != tt.want {
+			t.Errorf("Region(%q) = %q, want %q", tt.org, got, tt.want)
+		}
+	}
+
+	vs := []vuln.Vulnerability{{Organization: "acme-inc-Education"}, {Organization: "someone-else"}}
+	if n, err := r.Enrich(context.Background(), vs); err != nil || n != 1 || vs[0].Region != "NA" || vs[1].Region != Unassigned {
+		t.Errorf("Enrich = %d, %v: %+v", n, err, vs)
+	}
+}
+
+func TestClassifications(t *testing.T) {
+	c, err := LoadClassifications("testdata/classifications.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := []vuln.Vulnerability{
+		// The asset ID is mapped before the name.
+		{AssetName: "acme-inc-customer-api", AssetID: "747459862"},
+		{AssetName: "explore-web", AssetID: "615136306"},
+		{AssetName: "explore-web", DataClassification: vuln.ClassConfidential},
+		{AssetName: "apply-api", AssetID: "464323181"},
+	}
+	n, err := c.Enrich(context.Background(), vs)
+	if err != nil || n != 2 {
+		t.Fatalf("Enrich = %d, %v", n, err)
+	}
+	for i, want := range []string{vuln.ClassRestricted, vuln.ClassPublic, vuln.ClassConfidential, ""} {
+		if vs[i].DataClassification != want {
+			t.Errorf("finding %d classified %q, want %q", i, vs[i].DataClassification, want)
+		}
+	}
+
+	bad := filepath.Join(t.TempDir(), "classes.json")
+	if err := os.WriteFile(bad, []byte(`{"apply-api": "secret"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadClassifications(bad); err == nil || !strings.Contains(err.Error(), "apply-api") {
+		t.Errorf("LoadClassifications(secret) = %v", err)
+	}
+}
+
+func TestMSRC(t *testing.T) {
+	var filters []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filters = append(filters, r.URL.Query().Get("$filter"))
+		switch {
+		case r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `{"value": [{"cveNumber": "CVE-2020-1752", "severity": "Critical", "baseScore": "9.8"}]}`)
+		case strings.Contains(r.URL.RawQuery, "5034441"):
+			fmt.Fprintf(w, `{"value": [
+				{"cveNumber": "CVE-2024-4367", "severity": "Important", "baseScore": 7.8},
+				{"cveNumber": "cve-2024-4367", "severity": "Moderate", "baseScore": 5.5}
+			], "@odata.nextLink": "%s?page=2"}`, srv.URL)
+		default:
+			fmt.Fprint(w, `{"value": []}`)
+		}
+	}))
+	defer srv.Close()
+
+	vs := []vuln.Vulnerability{
+		{Identifier: "KB5034441", Severity: vuln.Low},
+		{Identifier: "KB5034441", Severity: vuln.Critical, CVSS: 6.1},
+		{Identifier: "KB890830", Severity: vuln.Medium},
+		{Identifier: "CVE-2024-4367"},
+	}
+	n, err := (&MSRC{BaseURL: srv.URL}).Enrich(context.Background(), vs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One lookup per KB, the first following its next page.
+	if len(filters) != 3 || filters[0] != "kbArticles/any(kb:kb/articleName eq '5034441')" {
+		t.Errorf("filters = %q", filters)
+	}
+	if n != 2 {
+		t.Errorf("enriched %d, want 2", n)
+	}
+	if a := vs[0]; strings.Join(a.RelatedCVEs, ",") != "CVE-2020-1752,CVE-2024-4367" || a.CVSS != 9.8 || a.CVSSSource != "msrc" || a.Severity != vuln.Critical {
+		t.Errorf("KB finding %+v", a)
+	}
+	// A scored finding keeps its score; severity never goes down.
+	if b := vs[1]; b.CVSS != 6.1 || b.CVSSSource != "" || b.Severity != vuln.Critical || len(b.RelatedCVEs) != 2 {
+		t.Errorf("scored KB finding %+v", b)
+	}
+	if c := vs[2]; c.RelatedCVEs != nil || c.Severity != vuln.Medium {
+		t.Errorf("unknown KB %+v", c)
+	}
+
+	// The related CVEs feed the CVE-keyed enrichers.
+	stats, err := Run(context.Background(), vs[:1], []Enricher{&EPSS{Source: "testdata/epss.csv"}, &KEV{Source: "testdata/kev.json"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vs[0].EPSS != 0.95821 || !vs[0].KEV {
+		t.Errorf("EPSS and KEV via related CVEs: %+v %+v", vs[0], stats)
+	}
+}
@@ -0,0 +1,104 @@
+package enrich
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// EPSSScore is a CVE's score in the FIRST Exploit Prediction Scoring
+// System: the probability of exploitation in the next 30 days and its
+// percentile among all scored CVEs.
+type EPSSScore struct {
+	Probability float64
+	Percentile  float64
+}
+
+// EPSS sets the EPSS probability and percentile of CVE findings from a
+// FIRST EPSS scores file.
+type EPSS struct {
+	// Source is the scores file: a URL or path, optionally gzipped.
+	Source string
+	Client *http.Client
+}
+
+func (e *EPSS) Name() string { return "epss" }
+
+func (e *EPSS) Enrich(ctx context.Context, vs []vuln.Vulnerability) (int, error) {
+	rc, err := open(ctx, e.Client, e.Source)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	scores, err := ReadEPSS(rc)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", e.Source, err)
+	}
+
+	n := 0
+	for i := range vs {
+		// A KB update takes the score of its most likely exploited CVE.
+		var best EPSSScore
+		found := false
+		for _, id := range cveIDs(&vs[i]) {
+			if s, ok := scores[id]; ok && (!found || s.Probability > best.Probability) {
+				best, found = s, true
+			}
+		}
+		if !found {
+			continue
+		}
+		vs[i].EPSS, vs[i].EPSSPercentile = best.Probability, best.Percentile
+		n++
+	}
+	return n, nil
+}
+
+// ReadEPSS reads a FIRST EPSS scores file: a "#model_version:..." comment
+// line followed by CSV with cve, epss and percentile columns.
+func ReadEPSS(r io.Reader) (map[string]EPSSScore, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	cveCol, ok1 := col["cve"]
+	epssCol, ok2 := col["epss"]
+	pctCol, ok3 := col["percentile"]
+	if !ok1 || !ok2 || !ok3 {
+		return nil, errors.New("header must have cve, epss and percentile columns")
+	}
+
+	scores := make(map[string]EPSSScore)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return scores, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		p, err := strconv.ParseFloat(record[epssCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid epss %q", line, record[epssCol])
+		}
+		pct, err := strconv.ParseFloat(record[pctCol], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid percentile %q", line, record[pctCol])
+		}
+		scores[strings.ToUpper(record[cveCol])] = EPSSScore{Probability: p, Percentile: pct}
+	}
+}
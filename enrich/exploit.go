@@ -0,0 +1,155 @@
+package enrich
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Public exploit indexes: the Exploit Database's list of its entries and
+// the metadata of the Metasploit Framework's modules.
+const (
+	ExploitDBURL  = "https://gitlab.com/exploit-database/exploitdb/-/raw/main/files_exploits.csv"
+	MetasploitURL = "https://raw.githubusercontent.com/rapid7/metasploit-framework/master/db/modules_metadata_base.json"
+)
+
+// ExploitDB sets the exploit maturity of CVE findings with an entry in the
+// Exploit Database to a proof of concept, unless a more mature exploit is
+// known.
+type ExploitDB struct {
+	// Source is the database's files_exploits.csv: a URL or path.
+	Source string
+	Client *http.Client
+}
+
+func (e *ExploitDB) Name() string { return "exploitdb" }
+
+func (e *ExploitDB) Enrich(ctx context.Context, vs []vuln.Vulnerability) (int, error) {
+	rc, err := open(ctx, e.Client, e.Source)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	ids, err := ReadExploitDB(rc)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", e.Source, err)
+	}
+	return setExploit(vs, ids, vuln.ExploitPoC), nil
+}
+
+// ReadExploitDB reads the Exploit Database's files_exploits.csv and
+// returns the set of CVE IDs its entries list in their codes column,
+// which separates them from other codes with semicolons.
+func ReadExploitDB(r io.Reader) (map[string]bool, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	codes := -1
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), "codes") {
+			codes = i
+		}
+	}
+	if codes < 0 {
+		return nil, errors.New("no codes column")
+	}
+	ids := make(map[string]bool)
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return ids, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if codes >= len(rec) {
+			continue
+		}
+		for _, code := range strings.Split(rec[codes], ";") {
+			if id := strings.ToUpper(strings.TrimSpace(code)); cvePattern.MatchString(id) {
+				ids[id] = true
+			}
+		}
+	}
+}
+
+// Metasploit sets the exploit maturity of CVE findings that a Metasploit
+// exploit module targets to weaponized. Auxiliary and post-exploitation
+// modules, which scan or follow up, do not count.
+type Metasploit struct {
+	// Source is the framework's db/modules_metadata_base.json: a URL or
+	// path.
+	Source string
+	Client *http.Client
+}
+
+func (m *Metasploit) Name() string { return "metasploit" }
+
+func (m *Metasploit) Enrich(ctx context.Context, vs []vuln.Vulnerability) (int, error) {
+	rc, err := open(ctx, m.Client, m.Source)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	ids, err := ReadMetasploit(rc)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", m.Source, err)
+	}
+	return setExploit(vs, ids, vuln.ExploitWeaponized), nil
+}
+
+// ReadMetasploit reads the Metasploit module metadata JSON, an object of
+// modules by path, and returns the set of CVE IDs its exploit modules
+// reference.
+func ReadMetasploit(r io.Reader) (map[string]bool, error) {
+	var modules map[string]struct {
+		Type       string   `json:"type"`
+		References []string `json:"references"`
+	}
+	if err := json.NewDecoder(r).Decode(&modules); err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool)
+	for _, m := range modules {
+		if m.Type != "exploit" {
+			continue
+		}
+		for _, ref := range m.References {
+			if id := strings.ToUpper(strings.TrimSpace(ref)); cvePattern.MatchString(id) {
+				ids[id] = true
+			}
+		}
+	}
+	return ids, nil
+}
+
+// setExploit raises the exploit maturity of the findings of vs about a
+// CVE in ids to maturity, and returns how many it raised.
+func setExploit(vs []vuln.Vulnerability, ids map[string]bool, maturity string) int {
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		if !vuln.MoreMatureExploit(maturity, v.Exploit) {
+			continue
+		}
+		for _, id := range cveIDs(v) {
+			if ids[id] {
+				v.Exploit = maturity
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
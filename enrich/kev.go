@@ -0,0 +1,64 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// KEV flags CVE findings listed in the CISA Known Exploited
+// Vulnerabilities catalog.
+type KEV struct {
+	// Source is the catalog JSON: a URL or path.
+	Source string
+	Client *http.Client
+}
+
+func (k *KEV) Name() string { return "kev" }
+
+func (k *KEV) Enrich(ctx context.Context, vs []vuln.Vulnerability) (int, error) {
+	rc, err := open(ctx, k.Client, k.Source)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	catalog, err := ReadKEV(rc)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", k.Source, err)
+	}
+
+	n := 0
+	for i := range vs {
+		for _, id := range cveIDs(&vs[i]) {
+			if catalog[id] {
+				vs[i].KEV = true
+				n++
+				break
+			}
+		}
+	}
+	return n, nil
+}
+
+// ReadKEV reads the CISA KEV catalog JSON and returns the set of CVE IDs it
+// lists.
+func ReadKEV(r io.Reader) (map[string]bool, error) {
+	var catalog struct {
+		Vulnerabilities []struct {
+			CVEID string `json:"cveID"`
+		} `json:"vulnerabilities"`
+	}
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(catalog.Vulnerabilities))
+	for _, v := range catalog.Vulnerabilities {
+		ids[strings.ToUpper(strings.TrimSpace(v.CVEID))] = true
+	}
+	return ids, nil
+}
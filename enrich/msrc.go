@@ -0,0 +1,166 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// MSRCURL is the Microsoft Security Updates Guide affected-product API.
+const MSRCURL = "https://api.msrc.microsoft.com/sug/v2.0/en-US/affectedProduct"
+
+// MSRCInterval is the delay between MSRC requests. The API publishes no
+// rate limit; one request a second keeps a run polite.
+const MSRCInterval = time.Second
+
+// MSRC resolves Windows patch findings, identified by KB article rather
+// than CVE, to the CVEs the update addresses, from the Microsoft Security
+// Response Center's Security Updates Guide. Each KB is requested once.
+//
+// A finding gets the CVEs as RelatedCVEs, the highest CVSS base score
+// among them when its own CVSS is blank or zero, and the highest MSRC
+// severity when that is above its own. Severities never go down.
+type MSRC struct {
+	// BaseURL is the API endpoint, MSRCURL by default.
+	BaseURL string
+	Client  *http.Client
+	// Interval is the delay between requests.
+	Interval time.Duration
+}
+
+func (m *MSRC) Name() string { return "msrc" }
+
+// msrcUpdate is what the guide says about one KB.
+type msrcUpdate struct {
+	cves     []string
+	score    float64
+	severity vuln.Severity
+}
+
+func (m *MSRC) Enrich(ctx context.Context, vs []vuln.Vulnerability) (int, error) {
+	byKB := make(map[string][]int)
+	var order []string
+	for i := range vs {
+		kb := vs[i].KB()
+		if kb == "" {
+			continue
+		}
+		if _, seen := byKB[kb]; !seen {
+			order = append(order, kb)
+		}
+		byKB[kb] = append(byKB[kb], i)
+	}
+
+	enriched := 0
+	for i, kb := range order {
+		if i > 0 && m.Interval > 0 {
+			select {
+			case <-ctx.Done():
+				return enriched, ctx.Err()
+			case <-time.After(m.Interval):
+			}
+		}
+		u, err := m.lookup(ctx, kb)
+		if err != nil {
+			return enriched, fmt.Errorf("%s: %w", kb, err)
+		}
+		if len(u.cves) == 0 {
+			continue
+		}
+		for _, j := range byKB[kb] {
+			v := &vs[j]
+			v.RelatedCVEs = u.cves
+			if v.CVSS == 0 && u.score > 0 {
+				v.CVSS, v.CVSSSource = u.score, "msrc"
+			}
+			if u.severity != "" && u.severity.Rank() < v.Severity.Rank() {
+				v.Severity = u.severity
+			}
+			enriched++
+		}
+	}
+	return enriched, nil
+}
+
+// msrcPage is one page of the affected-product API's OData response.
+type msrcPage struct {
+	Value []struct {
+		CVENumber string          `json:"cveNumber"`
+		Severity  string          `json:"severity"`
+		BaseScore json.RawMessage `json:"baseScore"`
+	} `json:"value"`
+	NextLink string `json:"@odata.nextLink"`
+}
+
+// lookup collects the CVEs, highest score and highest severity the guide
+// lists for kb, following pages.
+func (m *MSRC) lookup(ctx context.Context, kb string) (msrcUpdate, error) {
+	base := m.BaseURL
+	if base == "" {
+		base = MSRCURL
+	}
+	next := base + "?" + url.Values{"$filter": {"kbArticles/any(kb:kb/articleName eq '" + strings.TrimPrefix(kb, "KB") + "')"}}.Encode()
+	var u msrcUpdate
+	seen := make(map[string]bool)
+	for next != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return u, err
+		}
+		resp, err := httpClient(m.Client).Do(req)
+		if err != nil {
+			return u, err
+		}
+		var page msrcPage
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return u, fmt.Errorf("MSRC: %s", resp.Status)
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return u, fmt.Errorf("MSRC: %w", err)
+		}
+		for _, p := range page.Value {
+			id := strings.ToUpper(strings.TrimSpace(p.CVENumber))
+			if cvePattern.MatchString(id) && !seen[id] {
+				seen[id] = true
+				u.cves = append(u.cves, id)
+			}
+			// The score is a number or a numeric string.
+			if s, err := strconv.ParseFloat(strings.Trim(string(p.BaseScore), `"`), 64); err == nil && s > u.score && s <= 10 {
+				u.score = s
+			}
+			if sev, ok := msrcSeverity(p.Severity); ok && (u.severity == "" || sev.Rank() < u.severity.Rank()) {
+				u.severity = sev
+			}
+		}
+		next = page.NextLink
+	}
+	sort.Strings(u.cves)
+	return u, nil
+}
+
+// msrcSeverity maps the MSRC severity rating to a severity: Important is
+// High and Moderate is Medium.
+func msrcSeverity(s string) (vuln.Severity, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "critical":
+		return vuln.Critical, true
+	case "important":
+		return vuln.High, true
+	case "moderate":
+		return vuln.Medium, true
+	case "low":
+		return vuln.Low, true
+	}
+	return "", false
+}
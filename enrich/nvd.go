@@ -0,0 +1,354 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// NVDInterval is the delay between NVD requests that keeps an
+// unauthenticated client within the public rate limit of five requests in
+// a rolling 30 seconds. NVDKeyInterval keeps a client with an API key
+// within its limit of fifty.
+const (
+	NVDInterval    = 6 * time.Second
+	NVDKeyInterval = 600 * time.Millisecond
+)
+
+// NVDCacheTTL is how long a cached NVD record is used before it is
+// fetched again.
+const NVDCacheTTL = 24 * time.Hour
+
+// NVD fills in the CVSS base score, vector, CWEs and description of CVE
+// findings from the NVD CVE API 2.0, where the input left them blank. A
+// finding is looked up when its CVSS is blank or zero or its description
+// is blank, and each CVE is requested once. A vector is only filled in
+// when CVSS is the NVD's, so the two agree.
+type NVD struct {
+	// BaseURL is the CVE API endpoint, NVDURL by default.
+	BaseURL string
+	Client  *http.Client
+	// APIKey, when set, is sent with each request, for the higher rate
+	// limit of NVDKeyInterval.
+	APIKey string
+	// Interval is the delay between requests.
+	Interval time.Duration
+	// CacheDir, when set, is a directory keeping each record fetched, so
+	// that later runs read records younger than CacheTTL from it instead
+	// of the API.
+	CacheDir string
+	CacheTTL time.Duration
+	// Complete also looks up findings that have a score and description,
+	// to fill in their vectors and CWEs.
+	Complete bool
+}
+
+func (n *NVD) Name() string { return "nvd" }
+
+// wants reports whether v is missing anything n fills in.
+func (n *NVD) wants(v *vuln.Vulnerability) bool {
+	if v.CVSS == 0 || strings.TrimSpace(v.Description) == "" {
+		return true
+	}
+	return n.Complete && (v.CVSSVector == "" || len(v.CWEs) == 0)
+}
+
+func (n *NVD) Enrich(ctx context.Context, vs []vuln.Vulnerability) (int, error) {
+	missing := make(map[string][]int)
+	var order []string
+	for i := range vs {
+		id := cveID(&vs[i])
+		if id == "" || !n.wants(&vs[i]) {
+			continue
+		}
+		if _, seen := missing[id]; !seen {
+			order = append(order, id)
+		}
+		missing[id] = append(missing[id], i)
+	}
+
+	enriched, requests := 0, 0
+	for _, id := range order {
+		cve, err := n.lookup(ctx, id, &requests)
+		if err != nil {
+			return enriched, fmt.Errorf("%s: %w", id, err)
+		}
+		if cve == nil {
+			continue
+		}
+		for _, j := range missing[id] {
+			if cve.fill(&vs[j]) {
+				enriched++
+			}
+		}
+	}
+	return enriched, nil
+}
+
+// fill sets the fields of v that are blank from c, and reports whether it
+// set any.
+func (c *nvdCVE) fill(v *vuln.Vulnerability) bool {
+	changed := false
+	m, ok := c.baseMetric()
+	if ok && v.CVSS == 0 && m.CVSSData.BaseScore > 0 {
+		v.CVSS, v.CVSSSource = m.CVSSData.BaseScore, "nvd"
+		changed = true
+	}
+	if vector := m.CVSSData.VectorString; ok && v.CVSSVector == "" && v.CVSS == m.CVSSData.BaseScore && vector != "" {
+		if _, err := cvss.Parse(vector); err == nil {
+			v.CVSSVector = vector
+			changed = true
+		}
+	}
+	if d := c.description(); strings.TrimSpace(v.Description) == "" && d != "" {
+		v.Description = d
+		changed = true
+	}
+	if cwes := c.cwes(); len(v.CWEs) == 0 && len(cwes) > 0 {
+		v.CWEs = cwes
+		changed = true
+	}
+	return changed
+}
+
+// nvdMetric is one CVSS metric entry of an NVD CVE record. Version 2
+// metrics carry the severity outside cvssData.
+type nvdMetric struct {
+	Type     string `json:"type"`
+	CVSSData struct {
+		BaseScore    float64 `json:"baseScore"`
+		BaseSeverity string  `json:"baseSeverity"`
+		VectorString string  `json:"vectorString"`
+	} `json:"cvssData"`
+	BaseSeverity string `json:"baseSeverity"`
+}
+
+// nvdCVE is a CVE record of the NVD CVE API 2.0.
+type nvdCVE struct {
+	ID           string `json:"id"`
+	Descriptions []struct {
+		Lang  string `json:"lang"`
+		Value string `json:"value"`
+	} `json:"descriptions"`
+	Metrics struct {
+		V31 []nvdMetric `json:"cvssMetricV31"`
+		V30 []nvdMetric `json:"cvssMetricV30"`
+		V40 []nvdMetric `json:"cvssMetricV40"`
+		V2  []nvdMetric `json:"cvssMetricV2"`
+	} `json:"metrics"`
+	Weaknesses []struct {
+		Type        string `json:"type"`
+		Description []struct {
+			Lang  string `json:"lang"`
+			Value string `json:"value"`
+		} `json:"description"`
+	} `json:"weaknesses"`
+}
+
+// nvdPage is one page of an NVD CVE API 2.0 response.
+type nvdPage struct {
+	ResultsPerPage  int `json:"resultsPerPage"`
+	StartIndex      int `json:"startIndex"`
+	TotalResults    int `json:"totalResults"`
+	Vulnerabilities []struct {
+		CVE nvdCVE `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+// baseMetric returns the metric c is scored by: v3.1, then v3.0, v4.0 and
+// v2, and the NVD's primary metric within a version.
+func (c *nvdCVE) baseMetric() (nvdMetric, bool) {
+	m := c.Metrics
+	for _, metrics := range [][]nvdMetric{m.V31, m.V30, m.V40, m.V2} {
+		if len(metrics) == 0 {
+			continue
+		}
+		best := metrics[0]
+		for _, metric := range metrics {
+			if metric.Type == "Primary" {
+				best = metric
+				break
+			}
+		}
+		return best, true
+	}
+	return nvdMetric{}, false
+}
+
+// description returns c's English description.
+func (c *nvdCVE) description() string {
+	for _, d := range c.Descriptions {
+		if d.Lang == "en" {
+			return d.Value
+		}
+	}
+	return ""
+}
+
+// cwes returns the CWE IDs of c's weaknesses, as in "CWE-79", primary
+// ones first and each once. The NVD's placeholders for a weakness it could
+// not pin down, NVD-CWE-Other and NVD-CWE-noinfo, are left out.
+func (c *nvdCVE) cwes() []string {
+	var ids []string
+	for _, primary := range []bool{true, false} {
+		for _, w := range c.Weaknesses {
+			if (w.Type == "Primary") != primary {
+				continue
+			}
+			for _, d := range w.Description {
+				if id := strings.ToUpper(d.Value); strings.HasPrefix(id, "CWE-") && !slices.Contains(ids, id) {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// severity returns the severity NVD rates m with, counting None as Low.
+func (m nvdMetric) severity() vuln.Severity {
+	s := m.CVSSData.BaseSeverity
+	if s == "" {
+		s = m.BaseSeverity
+	}
+	if sev, err := vuln.ParseSeverity(s); err == nil {
+		return sev
+	}
+	return vuln.Low
+}
+
+// getNVD requests params from the CVE API at base, NVDURL by default,
+// with the API key apiKey unless it is empty.
+func getNVD(ctx context.Context, client *http.Client, base, apiKey string, params url.Values) (*nvdPage, error) {
+	if base == "" {
+		base = NVDURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		req.Header.Set("apiKey", apiKey)
+	}
+	resp, err := httpClient(client).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NVD: %s", resp.Status)
+	}
+	var page nvdPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("NVD: %w", err)
+	}
+	return &page, nil
+}
+
+// lookup returns the NVD record of id, or nil when the NVD has none, from
+// the cache when it holds a fresh copy. requests counts the requests made,
+// to space them by Interval.
+func (n *NVD) lookup(ctx context.Context, id string, requests *int) (*nvdCVE, error) {
+	if cve, ok := n.cached(id); ok {
+		return cve, nil
+	}
+	if *requests > 0 && n.Interval > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(n.Interval):
+		}
+	}
+	*requests++
+	page, err := getNVD(ctx, n.Client, n.BaseURL, n.APIKey, url.Values{"cveId": {id}})
+	if err != nil {
+		return nil, err
+	}
+	var cve *nvdCVE
+	if len(page.Vulnerabilities) > 0 {
+		cve = &page.Vulnerabilities[0].CVE
+	}
+	if err := n.store(id, cve); err != nil {
+		return nil, err
+	}
+	return cve, nil
+}
+
+// nvdCacheEntry is a cached NVD record. CVE is nil for an ID the NVD has
+// no record of, so that it is not asked again until the entry expires.
+type nvdCacheEntry struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	CVE       *nvdCVE   `json:"cve"`
+}
+
+// cachePath returns the cache file of id. IDs are CVE IDs, so safe as
+// file names.
+func (n *NVD) cachePath(id string) string {
+	return filepath.Join(n.CacheDir, id+".json")
+}
+
+// cached returns the cached record of id, if the cache holds one younger
+// than CacheTTL, NVDCacheTTL by default. An unreadable entry is a miss, to
+// be fetched again and overwritten.
+func (n *NVD) cached(id string) (*nvdCVE, bool) {
+	if n.CacheDir == "" {
+		return nil, false
+	}
+	b, err := os.ReadFile(n.cachePath(id))
+	if err != nil {
+		return nil, false
+	}
+	var e nvdCacheEntry
+	if json.Unmarshal(b, &e) != nil {
+		return nil, false
+	}
+	ttl := n.CacheTTL
+	if ttl == 0 {
+		ttl = NVDCacheTTL
+	}
+	if time.Since(e.FetchedAt) >= ttl {
+		return nil, false
+	}
+	return e.CVE, true
+}
+
+// store caches the record of id, writing a temporary file and renaming it
+// over the entry so that a concurrent run never reads half of one.
+func (n *NVD) store(id string, cve *nvdCVE) error {
+	if n.CacheDir == "" {
+		return nil
+	}
+	b, err := json.Marshal(nvdCacheEntry{FetchedAt: time.Now().UTC(), CVE: cve})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(n.CacheDir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(n.CacheDir, ".tmp-"+id+"-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), n.cachePath(id))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("nvd cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Unassigned is the region of findings no mapping entry covers.
+const Unassigned = "unassigned"
+
+// cloudRegion matches the account and cloud region in AWS organization
+// values such as "253710526682: us-west-2".
+var cloudRegion = regexp.MustCompile(`^\s*([^:]+?)\s*:\s*([a-z]{2}(?:-gov)?-[a-z]+-\d)\s*$`)
+
+// Regions attributes findings to regions from their Organization/Account.
+// Mapping keys may be a full organization value, an account ID, or a cloud
+// region; values are the region to report, such as "us-west-2" or "EMEA".
+type Regions struct {
+	Mapping map[string]string
+}
+
+// LoadRegions reads a JSON object mapping organizations, accounts or cloud
+// regions to regions.
+func LoadRegions(path string) (*Regions, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mapping map[string]string
+	if err := json.Unmarshal(b, &mapping); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Regions{Mapping: mapping}, nil
+}
+
+func (r *Regions) Name() string { return "regions" }
+
+// Enrich sets the region of every finding and counts those attributed to
+// a region other than Unassigned.
+func (r *Regions) Enrich(_ context.Context, vs []vuln.Vulnerability) (int, error) {
+	n := 0
+	for i := range vs {
+		vs[i].Region = r.Region(vs[i].Organization)
+		if vs[i].Region != Unassigned {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Region returns the region for an Organization/Account value. It tries
+// the full value, then the account ID and the cloud region it embeds; a
+// cloud region missing from the mapping is its own region.
+func (r *Regions) Region(org string) string {
+	org = strings.TrimSpace(org)
+	if region, ok := r.Mapping[org]; ok {
+		return region
+	}
+	m := cloudRegion.FindStringSubmatch(org)
+	if m == nil {
+		return Unassigned
+	}
+	account, cloud := m[1], m[2]
+	if region, ok := r.Mapping[account]; ok {
+		return region
+	}
+	if region, ok := r.Mapping[cloud]; ok {
+		return region
+	}
+	return cloud
+}
@@ -0,0 +1,230 @@
+// Package eol detects assets whose operating system is past the end of
+// its public security support, so that no patches will come for their
+// packages, and flags them.
+package eol
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Release is an operating system release and the last day it receives
+// public security updates: Debian LTS, Ubuntu standard support and Amazon
+// Linux support. Paid extended support is not counted.
+type Release struct {
+	Distro      string
+	Version     string
+	SupportEnds vuln.Date
+}
+
+// Name returns the release's display name, as in "Debian 10".
+func (r Release) Name() string {
+	return r.Distro + " " + r.Version
+}
+
+// Releases are the known releases.
+var Releases = []Release{
+	{"Debian", "8", vuln.NewDate(2020, time.June, 30)},
+	{"Debian", "9", vuln.NewDate(2022, time.June, 30)},
+	{"Debian", "10", vuln.NewDate(2024, time.June, 30)},
+	{"Debian", "11", vuln.NewDate(2026, time.August, 31)},
+	{"Debian", "12", vuln.NewDate(2028, time.June, 30)},
+	{"Ubuntu", "16.04", vuln.NewDate(2021, time.April, 30)},
+	{"Ubuntu", "18.04", vuln.NewDate(2023, time.May, 31)},
+	{"Ubuntu", "20.04", vuln.NewDate(2025, time.May, 31)},
+	{"Ubuntu", "22.04", vuln.NewDate(2027, time.April, 30)},
+	{"Ubuntu", "24.04", vuln.NewDate(2029, time.April, 30)},
+	{"Amazon Linux", "2", vuln.NewDate(2026, time.June, 30)},
+}
+
+// Lookup returns the release an OS name such as "Debian 10" or
+// "ubuntu 22.04" names, ignoring case.
+func Lookup(os string) (Release, bool) {
+	os = strings.Join(strings.Fields(os), " ")
+	for _, r := range Releases {
+		if strings.EqualFold(os, r.Name()) {
+			return r, true
+		}
+	}
+	return Release{}, false
+}
+
+// versionMarkers find the release in the revision of an OS package
+// version: "+deb10u3" and "~deb10u1" are Debian 10 security updates,
+// "~22.04" and "ubuntu0.22.04.1" Ubuntu 22.04 builds, and ".amzn2"
+// Amazon Linux 2 packages.
+var versionMarkers = []struct {
+	distro  string
+	pattern *regexp.Regexp
+}{
+	{"Debian", regexp.MustCompile(`[+~]deb(\d+)u\d`)},
+	{"Ubuntu", regexp.MustCompile(`~(\d{2}\.\d{2})|ubuntu\d+\.(\d{2}\.\d{2})`)},
+	{"Amazon Linux", regexp.MustCompile(`\.amzn(\d+)`)},
+}
+
+// Detect returns the OS release v's package version was built for, or ""
+// when the version does not tell.
+func Detect(v *vuln.Vulnerability) string {
+	for _, m := range versionMarkers {
+		if sub := m.pattern.FindStringSubmatch(v.InstalledVersion); sub != nil {
+			for _, s := range sub[1:] {
+				if s != "" {
+					return m.distro + " " + s
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Asset is an asset's detected OS and its support status.
+type Asset struct {
+	AssetID   string `json:"asset_id"`
+	AssetName string `json:"asset_name"`
+	// OS is the release, as in "Debian 10".
+	OS string `json:"os"`
+	// SupportEnds is zero for releases not in Releases.
+	SupportEnds vuln.Date `json:"support_ends"`
+	EndOfLife   bool      `json:"end_of_life"`
+	// Findings counts the asset's findings; NoFix those without a fixed
+	// version.
+	Findings int `json:"findings"`
+	NoFix    int `json:"no_fix"`
+}
+
+// Assets returns the OS of every asset in vs it can tell, by asset ID:
+// the finding's OS when the input has one, else the release most of its
+// package versions were built for. EndOfLife is set for releases whose
+// support ended before now. Assets are sorted end-of-life first, then by
+// name.
+func Assets(vs []vuln.Vulnerability, now time.Time) []Asset {
+	type tally struct {
+		asset Asset
+		votes map[string]int
+	}
+	byID := make(map[string]*tally)
+	var order []string
+	for i := range vs {
+		v := &vs[i]
+		t, ok := byID[v.AssetID]
+		if !ok {
+			t = &tally{asset: Asset{AssetID: v.AssetID, AssetName: v.AssetName}, votes: make(map[string]int)}
+			byID[v.AssetID] = t
+			order = append(order, v.AssetID)
+		}
+		if !isEOLFinding(v) {
+			t.asset.Findings++
+			if !v.HasFix() {
+				t.asset.NoFix++
+			}
+		}
+		// An OS from the input outvotes any number of package versions.
+		if os := strings.TrimSpace(v.OS); os != "" {
+			t.votes[os] += len(vs)
+		} else if os := Detect(v); os != "" {
+			t.votes[os]++
+		}
+	}
+
+	today := vuln.NewDate(now.Year(), now.Month(), now.Day())
+	var assets []Asset
+	for _, id := range order {
+		t := byID[id]
+		best := ""
+		for os, n := range t.votes {
+			if n > t.votes[best] || n == t.votes[best] && os < best {
+				best = os
+			}
+		}
+		if best == "" {
+			continue
+		}
+		a := t.asset
+		a.OS = best
+		if r, ok := Lookup(best); ok {
+			a.OS, a.SupportEnds = r.Name(), r.SupportEnds
+			a.EndOfLife = r.SupportEnds.Before(today.Time)
+		}
+		assets = append(assets, a)
+	}
+	sort.SliceStable(assets, func(i, j int) bool {
+		if assets[i].EndOfLife != assets[j].EndOfLife {
+			return assets[i].EndOfLife
+		}
+		return assets[i].AssetName < assets[j].AssetName
+	})
+	return assets
+}
+
+// Identifier returns the identifier of the finding Apply adds for an
+// asset on release os, as in "EOL-DEBIAN-10".
+func Identifier(os string) string {
+	return "EOL-" + strings.ToUpper(strings.ReplaceAll(os, " ", "-"))
+}
+
+func isEOLFinding(v *vuln.Vulnerability) bool {
+	return strings.HasPrefix(strings.ToUpper(v.Identifier), "EOL-")
+}
+
+// Apply sets the OS of every finding on an asset Assets detects one for,
+// and OSEndOfLife on those past support, and returns a finding for each
+// end-of-life asset that vs does not already have: a High severity
+// finding due on the day support ended, since no updates will fix the
+// asset's findings until its OS is upgraded.
+func Apply(vs []vuln.Vulnerability, now time.Time) []vuln.Vulnerability {
+	assets := Assets(vs, now)
+	byID := make(map[string]*Asset, len(assets))
+	for i := range assets {
+		byID[assets[i].AssetID] = &assets[i]
+	}
+	first := make(map[string]*vuln.Vulnerability)
+	present := make(map[string]bool)
+	for i := range vs {
+		v := &vs[i]
+		a, ok := byID[v.AssetID]
+		if !ok {
+			continue
+		}
+		v.OS, v.OSEndOfLife = a.OS, a.EndOfLife
+		if first[v.AssetID] == nil {
+			first[v.AssetID] = v
+		}
+		if isEOLFinding(v) {
+			present[v.AssetID] = true
+		}
+	}
+
+	var added []vuln.Vulnerability
+	for i := range assets {
+		a := &assets[i]
+		if !a.EndOfLife || present[a.AssetID] {
+			continue
+		}
+		base := first[a.AssetID]
+		i := strings.LastIndexByte(a.OS, ' ')
+		distro, version := a.OS[:i], a.OS[i+1:]
+		added = append(added, vuln.Vulnerability{
+			AssetName:        base.AssetName,
+			AssetID:          base.AssetID,
+			Organization:     base.Organization,
+			Region:           base.Region,
+			Source:           base.Source,
+			Identifier:       Identifier(a.OS),
+			Title:            a.OS + " is past the end of security support",
+			Description:      fmt.Sprintf("Public security support for %s ended on %s. No security updates are published for its packages, so the asset's %d findings without a fixed version will not get one.", a.OS, a.SupportEnds, a.NoFix),
+			PackageName:      strings.ToLower(strings.ReplaceAll(distro, " ", "-")),
+			InstalledVersion: version,
+			Remediation:      "Upgrade to a supported " + distro + " release.",
+			Severity:         vuln.High,
+			DueDate:          a.SupportEnds,
+			OS:               a.OS,
+			OSEndOfLife:      true,
+		})
+	}
+	return added
+}
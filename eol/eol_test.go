@@ -0,0 +1,72 @@
+package eol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestDetect(t *testing.T) {
+	for version, want := range map[string]string{
+		"1.1.1n-0+deb10u3":          "Debian 10",
+		"4.1.0+git191117-2~deb10u2": "Debian 10",
+		"2.36.1-8+deb12u1":          "Debian 12",
+		"12.3.0-1ubuntu1~22.04":     "Ubuntu 22.04",
+		"3.0.2-0ubuntu1.10":         "",
+		"2.7.18-1ubuntu0.20.04.1":   "Ubuntu 20.04",
+		"1.0.2k-24.amzn2.0.7":       "Amazon Linux 2",
+		"1.2.3":                     "",
+		">=4.17.0 <4.17.21":         "",
+	} {
+		if got := Detect(&vuln.Vulnerability{InstalledVersion: version}); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestAssets(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{AssetID: "a", AssetName: "old", InstalledVersion: "1.1.1n-0+deb10u3"},
+		{AssetID: "a", AssetName: "old", InstalledVersion: "2.36.1-8+deb12u1", FixedVersion: "2.36.1-8+deb12u2"},
+		{AssetID: "a", AssetName: "old", InstalledVersion: "7.64.0-4+deb10u9"},
+		{AssetID: "b", AssetName: "new", InstalledVersion: "1.1.1n-0+deb10u3", OS: "ubuntu 22.04"},
+		{AssetID: "c", AssetName: "app", InstalledVersion: "4.17.20"},
+	}
+	now := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+	got := Assets(vs, now)
+	if len(got) != 2 {
+		t.Fatalf("Assets = %+v, want two assets", got)
+	}
+	if a := got[0]; a.AssetID != "a" || a.OS != "Debian 10" || !a.EndOfLife || a.Findings != 3 || a.NoFix != 2 {
+		t.Errorf("Assets[0] = %+v, want EoL Debian 10 with 3 findings, 2 without a fix", a)
+	}
+	if a := got[1]; a.AssetID != "b" || a.OS != "Ubuntu 22.04" || a.EndOfLife {
+		t.Errorf("Assets[1] = %+v, want the input's supported Ubuntu 22.04", a)
+	}
+}
+
+func TestApply(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{AssetID: "a", AssetName: "old", Source: "aws", InstalledVersion: "1.0.2k-24.amzn2.0.7"},
+		{AssetID: "b", AssetName: "new", Source: "aws", InstalledVersion: "2.36.1-8+deb12u1"},
+	}
+	now := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	added := Apply(vs, now)
+	if len(added) != 1 {
+		t.Fatalf("Apply added %+v, want one finding", added)
+	}
+	e := added[0]
+	if e.Identifier != "EOL-AMAZON-LINUX-2" || e.AssetID != "a" || e.PackageName != "amazon-linux" || e.InstalledVersion != "2" ||
+		e.Severity != vuln.High || e.DueDate != vuln.NewDate(2026, time.June, 30) || !e.OSEndOfLife {
+		t.Errorf("added %+v", e)
+	}
+	if vs[0].OS != "Amazon Linux 2" || !vs[0].OSEndOfLife || vs[1].OS != "Debian 12" || vs[1].OSEndOfLife {
+		t.Errorf("Apply set OS %q %v and %q %v", vs[0].OS, vs[0].OSEndOfLife, vs[1].OS, vs[1].OSEndOfLife)
+	}
+
+	// An input that already has the finding does not get another.
+	if again := Apply(append(vs, added...), now); len(again) != 0 {
+		t.Errorf("second Apply added %+v", again)
+	}
+}
@@ -0,0 +1,293 @@
+// Package fixtures generates sample exports for each supported input
+// profile: valid findings, optionally mixed with deliberately broken
+// rows and with the schema drift real exports show over time, so the
+// whole pipeline can be checked against known answers.
+package fixtures
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Column is one column of a profile: its header, the field ingestion
+// maps it to, and how a finding is written in it.
+type Column struct {
+	Header string
+	Field  ingest.Field
+	Value  func(v *vuln.Vulnerability) string
+}
+
+// Profile is an input layout the tool reads.
+type Profile struct {
+	Name        string
+	Description string
+	Columns     []Column
+}
+
+// Profiles are the supported input profiles.
+var Profiles = []*Profile{
+	{
+		Name:        "vendor",
+		Description: "the vulnerability management vendor's export, with M/D/YYYY dates",
+		Columns: []Column{
+			{"Unique ID", ingest.FieldUniqueID, func(v *vuln.Vulnerability) string { return v.UniqueID }},
+			{"Asset name", ingest.FieldAssetName, func(v *vuln.Vulnerability) string { return v.AssetName }},
+			{"Asset id", ingest.FieldAssetID, func(v *vuln.Vulnerability) string { return v.AssetID }},
+			{"Organization/Account", ingest.FieldOrganization, func(v *vuln.Vulnerability) string { return v.Organization }},
+			{"Identifier", ingest.FieldIdentifier, func(v *vuln.Vulnerability) string { return v.Identifier }},
+			{"Source", ingest.FieldSource, func(v *vuln.Vulnerability) string { return v.Source }},
+			{"CVSS", ingest.FieldCVSS, func(v *vuln.Vulnerability) string { return strconv.FormatFloat(v.CVSS, 'f', -1, 64) }},
+			{"Title", ingest.FieldTitle, func(v *vuln.Vulnerability) string { return v.Title }},
+			{"Description", ingest.FieldDescription, func(v *vuln.Vulnerability) string { return v.Description }},
+			{"Package Name", ingest.FieldPackageName, func(v *vuln.Vulnerability) string { return v.PackageName }},
+			{"Installed Version", ingest.FieldInstalledVersion, func(v *vuln.Vulnerability) string { return v.InstalledVersion }},
+			{"Fixed Version", ingest.FieldFixedVersion, func(v *vuln.Vulnerability) string { return v.FixedVersion }},
+			{"Remediation", ingest.FieldRemediation, func(v *vuln.Vulnerability) string { return v.Remediation }},
+			{"Severity", ingest.FieldSeverity, func(v *vuln.Vulnerability) string { return string(v.Severity) }},
+			{"Due date", ingest.FieldDueDate, func(v *vuln.Vulnerability) string { return usDate(v.DueDate) }},
+			{"First detected date", ingest.FieldFirstDetectedDate, func(v *vuln.Vulnerability) string { return usDate(v.FirstDetectedDate) }},
+			{"Fixability", ingest.FieldFixability, func(v *vuln.Vulnerability) string { return v.Fixability }},
+		},
+	},
+	{
+		Name:        "brief",
+		Description: "the column names of the assessment brief, with ISO dates",
+		Columns: []Column{
+			{"CVE_Number", ingest.FieldIdentifier, func(v *vuln.Vulnerability) string { return v.Identifier }},
+			{"Description", ingest.FieldDescription, func(v *vuln.Vulnerability) string { return v.Description }},
+			{"CVSS_Score", ingest.FieldCVSS, func(v *vuln.Vulnerability) string { return strconv.FormatFloat(v.CVSS, 'f', 1, 64) }},
+			{"Severity", ingest.FieldSeverity, func(v *vuln.Vulnerability) string { return string(v.Severity) }},
+			{"Affected_Package", ingest.FieldPackageName, func(v *vuln.Vulnerability) string { return v.PackageName }},
+			{"Fixed_Package_Version", ingest.FieldFixedVersion, func(v *vuln.Vulnerability) string { return v.FixedVersion }},
+			{"Discovery_Date", ingest.FieldFirstDetectedDate, func(v *vuln.Vulnerability) string { return v.FirstDetectedDate.String() }},
+			{"Audit_Due_Date", ingest.FieldDueDate, func(v *vuln.Vulnerability) string { return v.DueDate.String() }},
+			{"Source", ingest.FieldSource, func(v *vuln.Vulnerability) string { return v.Source }},
+		},
+	},
+	{
+		Name:        "results",
+		Description: "the tool's own CSV output, as read by merge and enrich",
+		Columns:     resultColumns(),
+	},
+}
+
+// resultColumns maps output.Columns to the fields they are read back as.
+func resultColumns() []Column {
+	cols := make([]Column, len(output.Columns))
+	for i, c := range output.Columns {
+		cols[i] = Column{Header: c.Header, Field: ingest.FieldFor(c.Header), Value: c.Value}
+	}
+	return cols
+}
+
+// Lookup returns the profile called name.
+func Lookup(name string) (*Profile, bool) {
+	for _, p := range Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func usDate(d vuln.Date) string {
+	if d.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d/%d", d.Month(), d.Day(), d.Year())
+}
+
+// Defect kinds, each a reason ingestion rejects a row for.
+const (
+	MissingIdentifier = "missing identifier"
+	InvalidCVSS       = "invalid CVSS"
+	CVSSOutOfRange    = "CVSS out of range"
+	UnknownSeverity   = "unknown severity"
+	InvalidDueDate    = "invalid due date"
+	WrongFieldCount   = "wrong field count"
+)
+
+// Defects lists the defect kinds in the order broken rows cycle through
+// them.
+var Defects = []string{MissingIdentifier, InvalidCVSS, CVSSOutOfRange, UnknownSeverity, InvalidDueDate, WrongFieldCount}
+
+// Options configures Generate.
+type Options struct {
+	// Rows is the number of valid findings; Broken is the number of
+	// broken rows mixed in among them.
+	Rows, Broken int
+	// Seed makes the output reproducible.
+	Seed uint64
+	// Evolve drifts the schema as exports do between versions: columns
+	// are reordered, headers respelled and unknown columns added.
+	Evolve bool
+}
+
+// Fixture describes a generated export.
+type Fixture struct {
+	// Findings are the valid findings, in file order, holding only the
+	// fields the profile has columns for.
+	Findings []vuln.Vulnerability
+	// Broken records each broken row's line and defect kind.
+	Broken []BrokenRow
+}
+
+// BrokenRow is a deliberately broken row of a fixture.
+type BrokenRow struct {
+	Line   int    `json:"line"`
+	Defect string `json:"defect"`
+}
+
+// Generate writes an export in profile p to w and describes it.
+func Generate(w io.Writer, p *Profile, opts Options) (*Fixture, error) {
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	cols := p.Columns
+	if opts.Evolve {
+		cols = evolve(rng, cols)
+	}
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Header
+	}
+
+	// Spread the broken rows evenly through the valid ones.
+	total := opts.Rows + opts.Broken
+	broken := make(map[int]string, opts.Broken)
+	for i := 0; i < opts.Broken; i++ {
+		broken[(i*total+total/2)/max(opts.Broken, 1)] = Defects[i%len(Defects)]
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	f := &Fixture{}
+	line := 2 // the line the next row starts on
+	for row := 0; row < total; row++ {
+		v := randomFinding(rng, row+1)
+		record := make([]string, len(cols))
+		for i, c := range cols {
+			if c.Value != nil {
+				record[i] = c.Value(&v)
+			}
+		}
+		if defect, ok := broken[row]; ok {
+			record = breakRecord(record, cols, defect)
+			f.Broken = append(f.Broken, BrokenRow{Line: line, Defect: defect})
+		} else {
+			f.Findings = append(f.Findings, project(v, cols))
+		}
+		if err := cw.Write(record); err != nil {
+			return nil, err
+		}
+		line++
+		for _, cell := range record {
+			line += strings.Count(cell, "\n")
+		}
+	}
+	cw.Flush()
+	return f, cw.Error()
+}
+
+// project returns v with only the fields cols carry.
+func project(v vuln.Vulnerability, cols []Column) vuln.Vulnerability {
+	has := make(map[ingest.Field]bool, len(cols))
+	for _, c := range cols {
+		has[c.Field] = true
+	}
+	var p vuln.Vulnerability
+	for _, f := range []struct {
+		field    ingest.Field
+		dst, src *string
+	}{
+		{ingest.FieldUniqueID, &p.UniqueID, &v.UniqueID},
+		{ingest.FieldAssetName, &p.AssetName, &v.AssetName},
+		{ingest.FieldAssetID, &p.AssetID, &v.AssetID},
+		{ingest.FieldOrganization, &p.Organization, &v.Organization},
+		{ingest.FieldIdentifier, &p.Identifier, &v.Identifier},
+		{ingest.FieldSource, &p.Source, &v.Source},
+		{ingest.FieldTitle, &p.Title, &v.Title},
+		{ingest.FieldDescription, &p.Description, &v.Description},
+		{ingest.FieldPackageName, &p.PackageName, &v.PackageName},
+		{ingest.FieldInstalledVersion, &p.InstalledVersion, &v.InstalledVersion},
+		{ingest.FieldFixedVersion, &p.FixedVersion, &v.FixedVersion},
+		{ingest.FieldRemediation, &p.Remediation, &v.Remediation},
+		{ingest.FieldFixability, &p.Fixability, &v.Fixability},
+	} {
+		if has[f.field] {
+			*f.dst = *f.src
+		}
+	}
+	if has[ingest.FieldCVSS] {
+		p.CVSS = v.CVSS
+	}
+	if has[ingest.FieldSeverity] {
+		p.Severity = v.Severity
+	}
+	if has[ingest.FieldDueDate] {
+		p.DueDate = v.DueDate
+	}
+	if has[ingest.FieldFirstDetectedDate] {
+		p.FirstDetectedDate = v.FirstDetectedDate
+	}
+	return p
+}
+
+// breakRecord applies defect to record.
+func breakRecord(record []string, cols []Column, defect string) []string {
+	set := func(field ingest.Field, value string) {
+		for i, c := range cols {
+			if c.Field == field {
+				record[i] = value
+			}
+		}
+	}
+	switch defect {
+	case MissingIdentifier:
+		set(ingest.FieldIdentifier, "")
+	case InvalidCVSS:
+		set(ingest.FieldCVSS, "high")
+	case CVSSOutOfRange:
+		set(ingest.FieldCVSS, "11.5")
+	case UnknownSeverity:
+		set(ingest.FieldSeverity, "Severe")
+	case InvalidDueDate:
+		set(ingest.FieldDueDate, "31/31/2025")
+	case WrongFieldCount:
+		record = record[:len(record)-1]
+	}
+	return record
+}
+
+// unknownColumns are columns no profile has, added by Evolve.
+var unknownColumns = []Column{
+	{Header: "Scanner Build", Value: func(*vuln.Vulnerability) string { return "2025.02.1" }},
+	{Header: "Ticket"},
+}
+
+// evolve returns cols reordered, with respelled headers and unknown
+// columns added.
+func evolve(rng *rand.Rand, cols []Column) []Column {
+	out := append(append([]Column(nil), cols...), unknownColumns...)
+	rng.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	for i := range out {
+		if out[i].Field == "" {
+			continue
+		}
+		switch rng.IntN(3) {
+		case 0:
+			out[i].Header = strings.ToUpper(strings.NewReplacer(" ", "_", "/", "_").Replace(out[i].Header))
+		case 1:
+			out[i].Header = strings.ToLower(out[i].Header)
+		}
+	}
+	return out
+}
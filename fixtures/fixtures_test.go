@@ -0,0 +1,83 @@
+package fixtures
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+)
+
+func TestGenerateRoundTrip(t *testing.T) {
+	for _, p := range Profiles {
+		for _, evolve := range []bool{false, true} {
+			var buf bytes.Buffer
+			fx, err := Generate(&buf, p, Options{Rows: 150, Broken: 18, Seed: 42, Evolve: evolve})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fx.Findings) != 150 || len(fx.Broken) != 18 {
+				t.Fatalf("%s: generated %d valid and %d broken rows, want 150 and 18", p.Name, len(fx.Findings), len(fx.Broken))
+			}
+
+			res, err := ingest.ReadCSV(&buf)
+			if err != nil {
+				t.Fatalf("%s evolve=%v: %v", p.Name, evolve, err)
+			}
+			if evolve != (len(res.CustomColumns) > 0) {
+				t.Errorf("%s evolve=%v: custom columns %q", p.Name, evolve, res.CustomColumns)
+			}
+			res.DropCustomFields()
+			if !reflect.DeepEqual(res.Vulns, fx.Findings) {
+				t.Errorf("%s evolve=%v: read findings differ from the generated ones", p.Name, evolve)
+			}
+			if len(res.Skipped) != len(fx.Broken) {
+				t.Fatalf("%s evolve=%v: skipped %d rows, want %d", p.Name, evolve, len(res.Skipped), len(fx.Broken))
+			}
+			for i, s := range res.Skipped {
+				if b := fx.Broken[i]; s.Line != b.Line {
+					t.Errorf("%s evolve=%v: skipped line %d (%v), want line %d (%s)", p.Name, evolve, s.Line, s.Err, b.Line, b.Defect)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	p, _ := Lookup("vendor")
+	gen := func(seed uint64) string {
+		var buf bytes.Buffer
+		if _, err := Generate(&buf, p, Options{Rows: 20, Broken: 3, Seed: seed, Evolve: true}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	if gen(7) != gen(7) {
+		t.Error("the same seed generated different exports")
+	}
+	if gen(7) == gen(8) {
+		t.Error("different seeds generated the same export")
+	}
+}
+
+func TestGenerateEvolvedHeader(t *testing.T) {
+	p, _ := Lookup("vendor")
+	var buf bytes.Buffer
+	if _, err := Generate(&buf, p, Options{Rows: 1, Seed: 3, Evolve: true}); err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := strings.Cut(buf.String(), "\n")
+	var documented []string
+	for _, c := range p.Columns {
+		documented = append(documented, c.Header)
+	}
+	if header == strings.Join(documented, ",") {
+		t.Errorf("evolved header is the documented one: %s", header)
+	}
+	for _, want := range []string{"Scanner Build", "Ticket"} {
+		if !strings.Contains(header, want) {
+			t.Errorf("evolved header lacks %q: %s", want, header)
+		}
+	}
+}
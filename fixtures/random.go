@@ -0,0 +1,93 @@
+package fixtures
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// pkg is a package a finding can affect, with the versions it is seen at.
+type pkg struct {
+	name, installed, fixed string
+}
+
+// The pools below resemble the sample export: AWS findings are OS
+// packages of container images, GitHub findings npm and Go modules of
+// repositories.
+var (
+	awsAssets = []string{"match-v1", "apply-backend", "billing-worker"}
+	awsPkgs   = []pkg{
+		{"linux", "4.19.181-1", ""},
+		{"tiff", "4.1.0+git191117-2~deb10u2", ""},
+		{"glibc", "2.28-10", ""},
+		{"openssl", "1.1.1n-0+deb10u3", "1.1.1n-0+deb10u6"},
+		{"curl", "7.64.0-4+deb10u2", "7.64.0-4+deb10u9"},
+		{"systemd", "252.19-1~deb12u1", ""},
+		{"perl", "5.34.0-3ubuntu1.3", ""},
+	}
+	githubAssets = []string{"apply-frontend", "acme-inc-webhooks", "docs-site"}
+	githubPkgs   = []pkg{
+		{"npm-pdfjs-dist", "<= 4.1.392", "4.2.67"},
+		{"npm-cookie", "< 0.7.0", "0.7.0"},
+		{"npm-braces", "< 3.0.3", "3.0.3"},
+		{"go-golang.org/x/net", "< 0.23.0", "0.23.0"},
+		{"go-google.golang.org/grpc", ">= 1.58.0, < 1.58.3", "1.58.3"},
+	}
+	// descriptions include the cells CSV writers must quote.
+	descriptions = []string{
+		"A heap out-of-bounds write can be exploited to achieve local privilege escalation.",
+		"Processing crafted input causes excessive memory use, leading to a denial of service.",
+		"### Impact\nA malicious file executes \"arbitrary\" JavaScript.\n\n### Patches\nUpgrade, or set `isEvalSupported` to false.",
+		"Cookies are parsed leniently, so names, paths and domains may contain illegal characters.",
+	}
+	// slaDays is the remediation window per severity the due dates are
+	// generated with.
+	slaDays = map[vuln.Severity]int{vuln.Critical: 15, vuln.High: 30, vuln.Medium: 90, vuln.Low: 180}
+	// epoch is the earliest first detected date.
+	epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// randomFinding returns the n-th finding of a fixture.
+func randomFinding(rng *rand.Rand, n int) vuln.Vulnerability {
+	v := vuln.Vulnerability{
+		UniqueID:   fmt.Sprint(n),
+		Identifier: fmt.Sprintf("CVE-%d-%d", 2016+rng.IntN(9), 1000+rng.IntN(49000)),
+		// One decimal, from 0.1 to 10.0.
+		CVSS:        float64(1+rng.IntN(100)) / 10,
+		Description: pick(rng, descriptions),
+	}
+	var p pkg
+	if rng.IntN(5) < 4 {
+		v.Source = "aws"
+		v.AssetName = pick(rng, awsAssets)
+		v.AssetID = "arn:aws:ecr:us-west-2:253710526682:repository/" + v.AssetName
+		v.Organization = "253710526682: us-west-2"
+		p = pick(rng, awsPkgs)
+		v.Title = v.Description
+	} else {
+		v.Source = "github"
+		v.AssetName = pick(rng, githubAssets)
+		v.AssetID = fmt.Sprint(100000000 + rng.IntN(900000000))
+		v.Organization = "acme-inc-Education"
+		p = pick(rng, githubPkgs)
+		v.Title = fmt.Sprintf("%s vulnerable to %s", p.name, v.Identifier)
+	}
+	v.PackageName, v.InstalledVersion, v.FixedVersion = p.name, p.installed, p.fixed
+	if v.HasFix() {
+		v.Remediation = fmt.Sprintf("Update %s from %s to %s.", p.name, p.installed, p.fixed)
+	}
+	// The export marks every finding fixable, with or without a version.
+	v.Fixability = "Fixable"
+	v.Severity = vuln.CVSSSeverity(v.CVSS)
+	first := epoch.AddDate(0, 0, rng.IntN(400))
+	v.FirstDetectedDate = vuln.NewDate(first.Year(), first.Month(), first.Day())
+	due := first.AddDate(0, 0, slaDays[v.Severity])
+	v.DueDate = vuln.NewDate(due.Year(), due.Month(), due.Day())
+	return v
+}
+
+func pick[T any](rng *rand.Rand, s []T) T {
+	return s[rng.IntN(len(s))]
+}
@@ -0,0 +1,42 @@
+package gate
+
+import "github.com/VioletX-Dev/devsecops-test/vuln"
+
+// Baseline modes: Fail fails the gate on findings a baseline does not
+// have, and Warn only logs them.
+const (
+	Fail = "fail"
+	Warn = "warn"
+)
+
+// BaselineModes lists the baseline modes, the default first.
+var BaselineModes = []string{Fail, Warn}
+
+// Baseline is the set of findings of an approved run, by fingerprint, that
+// a later run may report again without failing its gate.
+type Baseline map[string]bool
+
+// NewBaseline returns the baseline of the findings vs, as a run wrote
+// them with -output_json or -output_csv.
+func NewBaseline(vs []vuln.Vulnerability) Baseline {
+	b := make(Baseline, len(vs))
+	for i := range vs {
+		b[vs[i].Fingerprint()] = true
+	}
+	return b
+}
+
+// New returns the findings of vs that b does not have, in the order of vs.
+// Findings whose workflow state closes them, or that a waiver suppresses,
+// are not new, as they pass Check.
+func (b Baseline) New(vs []vuln.Vulnerability) []vuln.Vulnerability {
+	var added []vuln.Vulnerability
+	for i := range vs {
+		v := &vs[i]
+		if Exempt(v) || b[v.Fingerprint()] {
+			continue
+		}
+		added = append(added, *v)
+	}
+	return added
+}
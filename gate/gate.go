@@ -0,0 +1,94 @@
+// Package gate fails a CI run when findings exceed a severity or score
+// threshold, so that the tool can block a merge or deploy rather than only
+// report.
+package gate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// ExitCode is the exit status of a run that fails its gate, distinct from
+// the status of a run that fails with an error.
+const ExitCode = 3
+
+// Thresholds are the limits a run must stay below. A finding fails the
+// gate if it reaches either.
+type Thresholds struct {
+	// Severity, when set, fails findings of this severity or higher.
+	Severity vuln.Severity
+	// Score, when positive, fails findings whose priority score is at
+	// least Score.
+	Score float64
+}
+
+// Enabled reports whether t has a threshold.
+func (t Thresholds) Enabled() bool {
+	return t.Severity != "" || t.Score > 0
+}
+
+// closed are the workflow states of findings that no longer fail a gate:
+// fixed, accepted, or no longer reported.
+var closed = map[string]bool{state.Resolved: true, state.RiskAccepted: true, state.Stale: true}
+
+// Exempt reports whether v passes every gate: whether its workflow state
+// closes it or a waiver suppresses it.
+func Exempt(v *vuln.Vulnerability) bool {
+	return closed[v.State] || v.Suppressed
+}
+
+// Check returns the findings of vs that fail t, in the order of vs.
+// Exempt findings pass.
+func (t Thresholds) Check(vs []vuln.Vulnerability) []vuln.Vulnerability {
+	var failed []vuln.Vulnerability
+	for i := range vs {
+		v := &vs[i]
+		if Exempt(v) {
+			continue
+		}
+		if t.Severity != "" && v.Severity.Rank() <= t.Severity.Rank() || t.Score > 0 && v.PriorityScore >= t.Score {
+			failed = append(failed, *v)
+		}
+	}
+	return failed
+}
+
+// Error is the error of a run whose findings failed its gate.
+type Error struct {
+	Thresholds Thresholds
+	Failed     int
+	// Baseline is set when only findings a Baseline does not have were
+	// checked.
+	Baseline bool
+	// Policy is set when the findings failed because a policy denied
+	// them; see package rego.
+	Policy bool
+}
+
+func (e *Error) Error() string {
+	var limits []string
+	if e.Thresholds.Severity != "" {
+		limits = append(limits, fmt.Sprintf("at or above %s severity", e.Thresholds.Severity))
+	}
+	if e.Thresholds.Score > 0 {
+		limits = append(limits, "scoring "+strconv.FormatFloat(e.Thresholds.Score, 'f', -1, 64)+" or more")
+	}
+	noun := "findings"
+	if e.Failed == 1 {
+		noun = "finding"
+	}
+	if e.Baseline {
+		noun += " not in the baseline"
+	}
+	if e.Policy {
+		return fmt.Sprintf("gate failed: %d %s denied by policy", e.Failed, noun)
+	}
+	if len(limits) == 0 {
+		return fmt.Sprintf("gate failed: %d %s", e.Failed, noun)
+	}
+	return fmt.Sprintf("gate failed: %d %s %s", e.Failed, noun, strings.Join(limits, " or "))
+}
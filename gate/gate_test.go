@@ -0,0 +1,77 @@
+package gate
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestCheck(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{UniqueID: "critical", Severity: vuln.Critical, PriorityScore: 7.5},
+		{UniqueID: "high", Severity: vuln.High, PriorityScore: 8.2},
+		{UniqueID: "medium", Severity: vuln.Medium, PriorityScore: 5},
+		{UniqueID: "accepted", Severity: vuln.Critical, PriorityScore: 9.5, State: state.RiskAccepted},
+		{UniqueID: "in-progress", Severity: vuln.Critical, PriorityScore: 9.5, State: state.InProgress},
+		{UniqueID: "waived", Severity: vuln.Critical, PriorityScore: 9.5, Suppressed: true},
+	}
+	for _, tt := range []struct {
+		t    Thresholds
+		want []string
+	}{
+		{Thresholds{}, nil},
+		{Thresholds{Severity: vuln.Critical}, []string{"critical", "in-progress"}},
+		{Thresholds{Severity: vuln.High}, []string{"critical", "high", "in-progress"}},
+		{Thresholds{Score: 8}, []string{"high", "in-progress"}},
+		{Thresholds{Severity: vuln.Critical, Score: 8}, []string{"critical", "high", "in-progress"}},
+	} {
+		var got []string
+		for _, v := range tt.t.Check(vs) {
+			got = append(got, v.UniqueID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%+v: failed %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	for _, tt := range []struct {
+		err  Error
+		want string
+	}{
+		{Error{Thresholds: Thresholds{Severity: vuln.Critical}, Failed: 51}, "gate failed: 51 findings at or above Critical severity"},
+		{Error{Thresholds: Thresholds{Score: 8.5}, Failed: 1}, "gate failed: 1 finding scoring 8.5 or more"},
+		{Error{Thresholds: Thresholds{Severity: vuln.High, Score: 8}, Failed: 3}, "gate failed: 3 findings at or above High severity or scoring 8 or more"},
+		{Error{Failed: 2, Baseline: true}, "gate failed: 2 findings not in the baseline"},
+		{Error{Thresholds: Thresholds{Severity: vuln.Critical}, Failed: 1, Baseline: true}, "gate failed: 1 finding not in the baseline at or above Critical severity"},
+	} {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	approved := []vuln.Vulnerability{
+		{Identifier: "CVE-2024-1", AssetID: "a", PackageName: "curl", InstalledVersion: "7.88.1"},
+		{Identifier: "CVE-2024-2", AssetID: "a", PackageName: "curl", InstalledVersion: "7.88.1"},
+	}
+	b := NewBaseline(approved)
+	vs := []vuln.Vulnerability{
+		{UniqueID: "known", Identifier: "cve-2024-1", AssetID: "a", PackageName: "curl", InstalledVersion: "7.88.1"},
+		{UniqueID: "other asset", Identifier: "CVE-2024-1", AssetID: "b", PackageName: "curl", InstalledVersion: "7.88.1"},
+		{UniqueID: "new", Identifier: "CVE-2024-3", AssetID: "a", PackageName: "curl", InstalledVersion: "7.88.1"},
+		{UniqueID: "resolved", Identifier: "CVE-2024-4", State: state.Resolved},
+		{UniqueID: "waived", Identifier: "CVE-2024-5", Suppressed: true},
+	}
+	var got []string
+	for _, v := range b.New(vs) {
+		got = append(got, v.UniqueID)
+	}
+	if want := []string{"other asset", "new"}; !slices.Equal(got, want) {
+		t.Errorf("new findings %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,274 @@
+// Package githubalerts pulls the open Dependabot and code scanning alerts
+// of GitHub repositories and organizations through the GitHub REST API, so
+// a run needs no export. Alerts are read as for ingest.ReadDependabot and
+// ingest.ReadCodeScanning, a page at a time.
+package githubalerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+)
+
+// DefaultBaseURL is the REST API of github.com.
+const DefaultBaseURL = "https://api.github.com"
+
+// Kind is a kind of alert.
+type Kind string
+
+// Kinds of alert.
+const (
+	Dependabot   Kind = "dependabot"
+	CodeScanning Kind = "code_scanning"
+)
+
+// Kinds are every kind of alert, pulled in this order.
+var Kinds = []Kind{Dependabot, CodeScanning}
+
+// ParseKinds parses comma-separated kinds of alert, as in
+// "dependabot,code_scanning".
+func ParseKinds(s string) ([]Kind, error) {
+	var kinds []Kind
+	for _, f := range strings.Split(s, ",") {
+		switch k := Kind(strings.ToLower(strings.TrimSpace(f))); k {
+		case Dependabot, CodeScanning:
+			kinds = append(kinds, k)
+		default:
+			return nil, fmt.Errorf("unknown alert kind %q: want %s or %s", f, Dependabot, CodeScanning)
+		}
+	}
+	return kinds, nil
+}
+
+// Target is a repository, or every repository of an organization when
+// Repo is "".
+type Target struct {
+	Owner, Repo string
+}
+
+func (t Target) String() string {
+	if t.Repo == "" {
+		return t.Owner
+	}
+	return t.Owner + "/" + t.Repo
+}
+
+// ParseTargets parses comma-separated repositories, as owner/repo, and
+// organizations, as in "acme-inc,octo-org/web".
+func ParseTargets(s string) ([]Target, error) {
+	var targets []Target
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		owner, repo, _ := strings.Cut(f, "/")
+		if owner == "" || strings.Contains(repo, "/") || strings.HasSuffix(f, "/") {
+			return nil, fmt.Errorf("GitHub target %q: want owner/repo or an organization", f)
+		}
+		targets = append(targets, Target{Owner: owner, Repo: repo})
+	}
+	return targets, nil
+}
+
+// pageSize is how many alerts a page asks for, the most the API allows.
+const pageSize = 100
+
+// Client pulls alerts with a token.
+type Client struct {
+	Token string
+	// BaseURL is the REST API's, DefaultBaseURL when "": GitHub
+	// Enterprise Server's is https://HOST/api/v3.
+	BaseURL string
+	Client  *http.Client
+}
+
+// Part is the alerts of one kind of one target.
+type Part struct {
+	Target Target
+	Kind   Kind
+	*ingest.Result
+	Pages int
+}
+
+// Name names p, as in "github:octo-org/web dependabot".
+func (p Part) Name() string {
+	return "github:" + p.Target.String() + " " + string(p.Kind)
+}
+
+// Alerts are the alerts of a pull, in a Part for each target and kind.
+type Alerts struct {
+	Parts  []Part
+	SHA256 string // checksum of the response bodies, in order
+	Bytes  int64  // their size
+}
+
+// Pull reads every open alert of kinds of targets, following the pages to
+// the last. Skipped alerts are numbered by their position in their Part.
+// A repository without code scanning has no code scanning alerts.
+func (c *Client) Pull(ctx context.Context, targets []Target, kinds []Kind) (*Alerts, error) {
+	out := &Alerts{}
+	sum := sha256.New()
+	get := func(u string) ([]byte, string, error) {
+		body, next, err := c.get(ctx, u)
+		if err != nil {
+			return nil, "", err
+		}
+		sum.Write(body)
+		out.Bytes += int64(len(body))
+		return body, next, nil
+	}
+	for _, t := range targets {
+		base := "/orgs/" + url.PathEscape(t.Owner)
+		var repo *ingest.GitHubRepository
+		if t.Repo != "" {
+			// A repository's alerts do not name it.
+			base = "/repos/" + url.PathEscape(t.Owner) + "/" + url.PathEscape(t.Repo)
+			body, _, err := get(c.url(base))
+			if err != nil {
+				return nil, err
+			}
+			repo = new(ingest.GitHubRepository)
+			if err := json.Unmarshal(body, repo); err != nil {
+				return nil, fmt.Errorf("GitHub repository %s: %w", t, err)
+			}
+		}
+		for _, k := range kinds {
+			part, err := c.pull(t, k, repo, c.url(base+"/"+strings.ReplaceAll(string(k), "_", "-")+"/alerts?state=open&per_page="+strconv.Itoa(pageSize)), get)
+			if err != nil {
+				return nil, err
+			}
+			out.Parts = append(out.Parts, part)
+		}
+	}
+	out.SHA256 = hex.EncodeToString(sum.Sum(nil))
+	return out, nil
+}
+
+func (c *Client) pull(t Target, k Kind, repo *ingest.GitHubRepository, u string, get func(string) ([]byte, string, error)) (Part, error) {
+	part := Part{Target: t, Kind: k, Result: &ingest.Result{}}
+	read := 0 // alerts on earlier pages
+	seen := make(map[string]bool)
+	for u != "" {
+		body, next, err := get(u)
+		var apiErr *apiError
+		if k == CodeScanning && errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound && strings.Contains(strings.ToLower(apiErr.message), "no analysis found") {
+			break
+		}
+		if err != nil {
+			return Part{}, err
+		}
+		part.Pages++
+		var res *ingest.Result
+		if k == CodeScanning {
+			res, err = ingest.ReadCodeScanning(bytes.NewReader(body), repo)
+		} else {
+			res, err = ingest.ReadDependabot(bytes.NewReader(body), repo)
+		}
+		if err != nil {
+			return Part{}, fmt.Errorf("GitHub %s: %w", t, err)
+		}
+		part.Header = res.Header
+		part.Vulns = append(part.Vulns, res.Vulns...)
+		for _, e := range res.Skipped {
+			e.Line += read
+			part.Skipped = append(part.Skipped, e)
+		}
+		read += len(res.Vulns) + len(res.Skipped)
+
+		if seen[next] {
+			return Part{}, fmt.Errorf("GitHub %s %s: next page repeated after %d pages", t, k, part.Pages)
+		}
+		seen[next] = true
+		u = next
+	}
+	return part, nil
+}
+
+func (c *Client) url(path string) string {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
+
+// get fetches u, returning its body and the URL of the next page, or ""
+// on the last.
+func (c *Client) get(ctx context.Context, u string) ([]byte, string, error) {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("GitHub GET %s: %w", req.URL.Path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", &apiError{path: req.URL.Path, status: resp.StatusCode, message: errorMessage(resp.Header, body)}
+	}
+	return body, nextPage(resp.Header.Get("Link")), nil
+}
+
+// apiError is a response other than 200 OK.
+type apiError struct {
+	path    string
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("GitHub GET %s: %d %s: %s", e.path, e.status, http.StatusText(e.status), e.message)
+}
+
+// errorMessage returns the message of an error response, with when the
+// rate limit resets if it was reached.
+func errorMessage(h http.Header, body []byte) string {
+	var out struct {
+		Message string `json:"message"`
+	}
+	msg := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &out) == nil && out.Message != "" {
+		msg = out.Message
+	}
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			msg += fmt.Sprintf(" (rate limit resets at %s)", time.Unix(reset, 0).UTC().Format(time.RFC3339))
+		}
+	}
+	return msg
+}
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// nextPage returns the next page's URL in a Link header, or "".
+func nextPage(link string) string {
+	if m := nextLink.FindStringSubmatch(link); m != nil {
+		return m[1]
+	}
+	return ""
+}
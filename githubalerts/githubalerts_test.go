@@ -0,0 +1,119 @@
+package githubalerts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPull(t *testing.T) {
+	alert := func(n int, repo string) string {
+		return fmt.Sprintf(`{"number": %d, "html_url": "https://github.com/%s/security/dependabot/%d", "created_at": "2025-02-01T10:00:00Z",
+			"dependency": {"package": {"ecosystem": "npm", "name": "braces"}, "relationship": "transitive"},
+			"security_advisory": {"ghsa_id": "GHSA-grv7-fg5c-xmjg", "cve_id": "CVE-2024-4068", "severity": "high"},
+			"security_vulnerability": {"severity": "high", "vulnerable_version_range": "< 3.0.3", "first_patched_version": {"identifier": "3.0.3"}}%s}`,
+			n, repo, n, map[bool]string{true: `, "repository": {"id": 1, "name": "web", "full_name": "octo-org/web", "owner": {"login": "octo-org"}}`}[repo == "octo-org/web"])
+	}
+	var srv *httptest.Server
+	var requests []string
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		if r.Header.Get("Authorization") != "Bearer ghp_token" || r.Header.Get("Accept") != "application/vnd.github+json" || r.Header.Get("X-GitHub-Api-Version") == "" {
+			t.Errorf("headers %v", r.Header)
+		}
+		switch r.URL.Path {
+		case "/api/v3/orgs/octo-org/dependabot/alerts":
+			if r.URL.Query().Get("after") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/api/v3/orgs/octo-org/dependabot/alerts?state=open&per_page=100&after=Y3Vyc29y>; rel="next"`, srv.URL))
+				io.WriteString(w, "["+alert(1, "octo-org/web")+`, {"number": 2}]`)
+				return
+			}
+			io.WriteString(w, "["+alert(3, "octo-org/web")+"]")
+		case "/api/v3/repos/acme/api":
+			io.WriteString(w, `{"id": 77, "name": "api", "full_name": "acme/api", "owner": {"login": "acme"}}`)
+		case "/api/v3/repos/acme/api/dependabot/alerts":
+			io.WriteString(w, "["+alert(9, "acme/api")+"]")
+		default:
+			t.Errorf("GET %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	targets, err := ParseTargets("octo-org, acme/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{Token: "ghp_token", BaseURL: srv.URL + "/api/v3/"}
+	got, err := c.Pull(context.Background(), targets, []Kind{Dependabot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 4 || requests[0] != "/api/v3/orgs/octo-org/dependabot/alerts?state=open&per_page=100" || got.Bytes == 0 || len(got.SHA256) != 64 {
+		t.Errorf("requests %v, %d bytes, sha256 %q", requests, got.Bytes, got.SHA256)
+	}
+	if len(got.Parts) != 2 {
+		t.Fatalf("parts %+v", got.Parts)
+	}
+	org, repo := got.Parts[0], got.Parts[1]
+	if org.Name() != "github:octo-org dependabot" || org.Pages != 2 || len(org.Vulns) != 2 || org.Vulns[1].UniqueID != "https://github.com/octo-org/web/security/dependabot/3" || org.Vulns[0].AssetName != "web" {
+		t.Errorf("organization %s: %d pages, findings %+v", org.Name(), org.Pages, org.Vulns)
+	}
+	if len(org.Skipped) != 1 || org.Skipped[0].Line != 2 {
+		t.Errorf("skipped %+v", org.Skipped)
+	}
+	// A repository's alerts are its own.
+	if repo.Name() != "github:acme/api dependabot" || len(repo.Vulns) != 1 || repo.Vulns[0].AssetID != "77" || repo.Vulns[0].Organization != "acme" {
+		t.Errorf("repository %s: findings %+v", repo.Name(), repo.Vulns)
+	}
+}
+
+func TestPullCodeScanning(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/acme/docs":
+			io.WriteString(w, `{"id": 78, "name": "docs", "full_name": "acme/docs", "owner": {"login": "acme"}}`)
+		case "/repos/acme/docs/code-scanning/alerts":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "no analysis found", "documentation_url": "https://docs.github.com/rest"}`)
+		case "/repos/acme/docs/dependabot/alerts":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1738749600")
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `{"message": "API rate limit exceeded for user ID 1."}`)
+		}
+	}))
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+	targets := []Target{{Owner: "acme", Repo: "docs"}}
+
+	// Without code scanning, no alerts.
+	got, err := c.Pull(context.Background(), targets, []Kind{CodeScanning})
+	if err != nil || len(got.Parts) != 1 || len(got.Parts[0].Vulns) != 0 || got.Parts[0].Pages != 0 {
+		t.Errorf("Pull(code_scanning) = %+v, %v", got, err)
+	}
+	_, err = c.Pull(context.Background(), targets, []Kind{Dependabot})
+	want := "GitHub GET /repos/acme/docs/dependabot/alerts: 403 Forbidden: API rate limit exceeded for user ID 1. (rate limit resets at 2025-02-05T10:00:00Z)"
+	if err == nil || err.Error() != want {
+		t.Errorf("error %v, want %s", err, want)
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	got, err := ParseTargets("acme-inc-Education,octo-org/web")
+	if err != nil || len(got) != 2 || got[0] != (Target{Owner: "acme-inc-Education"}) || got[1].String() != "octo-org/web" {
+		t.Errorf("ParseTargets = %+v, %v", got, err)
+	}
+	for _, s := range []string{"", "octo-org/", "octo-org/web/main", "/web"} {
+		if _, err := ParseTargets(s); err == nil || !strings.Contains(err.Error(), "want owner/repo") {
+			t.Errorf("ParseTargets(%q) = %v", s, err)
+		}
+	}
+	if _, err := ParseKinds("dependabot,secret_scanning"); err == nil {
+		t.Error("ParseKinds(secret_scanning): no error")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/VioletX-Dev/devsecops-test
+
+go 1.22
@@ -0,0 +1,168 @@
+// Package gsheets writes prioritized findings to a Google Sheet, one
+// worksheet per severity, through the Sheets API v4.
+package gsheets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// BaseURL is the Sheets API endpoint.
+const BaseURL = "https://sheets.googleapis.com/v4/spreadsheets"
+
+// Writer replaces the contents of a spreadsheet's severity worksheets.
+type Writer struct {
+	Account       *ServiceAccount
+	SpreadsheetID string
+	// BaseURL overrides the Sheets API endpoint, for tests.
+	BaseURL string
+	Client  *http.Client
+}
+
+// Write writes vs, in order, to one worksheet per severity, named after
+// the severity and created if missing. Every severity's worksheet is
+// cleared first, so findings that were fixed since the last run disappear.
+// Values are written as entered (RAW), so cells starting with "=" are
+// never evaluated as formulas.
+func (w *Writer) Write(ctx context.Context, vs []vuln.Vulnerability) error {
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	token, err := w.Account.token(ctx, client, time.Now())
+	if err != nil {
+		return err
+	}
+	api := &api{client: client, token: token, base: w.base() + "/" + url.PathEscape(w.SpreadsheetID)}
+
+	existing, err := api.sheetTitles(ctx)
+	if err != nil {
+		return err
+	}
+	var add []map[string]any
+	for _, sev := range vuln.Severities {
+		if !existing[string(sev)] {
+			add = append(add, map[string]any{"addSheet": map[string]any{"properties": map[string]any{"title": string(sev)}}})
+		}
+	}
+	if len(add) > 0 {
+		if err := api.post(ctx, ":batchUpdate", map[string]any{"requests": add}); err != nil {
+			return err
+		}
+	}
+
+	var ranges []string
+	var data []map[string]any
+	for _, sev := range vuln.Severities {
+		r := quoteSheet(string(sev))
+		ranges = append(ranges, r)
+		data = append(data, map[string]any{"range": r + "!A1", "values": rows(vs, sev)})
+	}
+	if err := api.post(ctx, "/values:batchClear", map[string]any{"ranges": ranges}); err != nil {
+		return err
+	}
+	return api.post(ctx, "/values:batchUpdate", map[string]any{"valueInputOption": "RAW", "data": data})
+}
+
+func (w *Writer) base() string {
+	if w.BaseURL != "" {
+		return strings.TrimSuffix(w.BaseURL, "/")
+	}
+	return BaseURL
+}
+
+// rows returns the header row and the rows of the findings with severity
+// sev, with the CSV output's columns.
+func rows(vs []vuln.Vulnerability, sev vuln.Severity) [][]string {
+	header := make([]string, len(output.Columns))
+	for i, c := range output.Columns {
+		header[i] = c.Header
+	}
+	out := [][]string{header}
+	for i := range vs {
+		if vs[i].Severity != sev {
+			continue
+		}
+		row := make([]string, len(output.Columns))
+		for j, c := range output.Columns {
+			row[j] = c.Value(&vs[i])
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+// quoteSheet quotes a sheet title for A1 notation.
+func quoteSheet(title string) string {
+	return "'" + strings.ReplaceAll(title, "'", "''") + "'"
+}
+
+type api struct {
+	client *http.Client
+	token  string
+	base   string
+}
+
+func (a *api) sheetTitles(ctx context.Context) (map[string]bool, error) {
+	var body struct {
+		Sheets []struct {
+			Properties struct {
+				Title string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+	if err := a.do(ctx, http.MethodGet, "?fields=sheets.properties.title", nil, &body); err != nil {
+		return nil, err
+	}
+	titles := make(map[string]bool)
+	for _, s := range body.Sheets {
+		titles[s.Properties.Title] = true
+	}
+	return titles, nil
+}
+
+func (a *api) post(ctx context.Context, path string, body any) error {
+	return a.do(ctx, http.MethodPost, path, body, nil)
+}
+
+func (a *api) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.base+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("sheets API %s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
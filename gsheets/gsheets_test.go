@@ -0,0 +1,179 @@
+package gsheets
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// fakeSheets is a Sheets API and token endpoint that records the
+// requests it receives.
+type fakeSheets struct {
+	t       *testing.T
+	pub     *rsa.PublicKey
+	titles  []string
+	added   []string
+	cleared []string
+	written map[string][][]string
+	input   string
+}
+
+func (f *fakeSheets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		f.checkAssertion(r.FormValue("assertion"))
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "tok"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer tok" {
+		http.Error(w, "unauthenticated", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Requests []struct {
+			AddSheet struct {
+				Properties struct{ Title string } `json:"properties"`
+			} `json:"addSheet"`
+		} `json:"requests"`
+		Ranges           []string `json:"ranges"`
+		ValueInputOption string   `json:"valueInputOption"`
+		Data             []struct {
+			Range  string     `json:"range"`
+			Values [][]string `json:"values"`
+		} `json:"data"`
+	}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.t.Errorf("%s: %v", r.URL.Path, err)
+		}
+	}
+	switch r.URL.Path {
+	case "/sheet-1":
+		var sheets []map[string]any
+		for _, t := range f.titles {
+			sheets = append(sheets, map[string]any{"properties": map[string]string{"title": t}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"sheets": sheets})
+		return
+	case "/sheet-1:batchUpdate":
+		for _, req := range body.Requests {
+			f.added = append(f.added, req.AddSheet.Properties.Title)
+		}
+	case "/sheet-1/values:batchClear":
+		f.cleared = body.Ranges
+	case "/sheet-1/values:batchUpdate":
+		f.input = body.ValueInputOption
+		f.written = make(map[string][][]string)
+		for _, d := range body.Data {
+			f.written[d.Range] = d.Values
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func (f *fakeSheets) checkAssertion(jwt string) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		f.t.Fatalf("assertion %q is not a JWT", jwt)
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(f.pub, crypto.SHA256, sum[:], sig); err != nil {
+		f.t.Errorf("assertion signature: %v", err)
+	}
+	var claims struct{ Iss, Scope string }
+	b, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	json.Unmarshal(b, &claims)
+	if claims.Iss != "bot@example.iam.gserviceaccount.com" || claims.Scope != Scope {
+		f.t.Errorf("claims = %+v", claims)
+	}
+}
+
+func writeKey(t *testing.T, tokenURI string) (string, *rsa.PublicKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "bot@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    tokenURI,
+	})
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path, &key.PublicKey
+}
+
+func TestWrite(t *testing.T) {
+	fake := &fakeSheets{t: t, titles: []string{"Sheet1", "High"}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	path, pub := writeKey(t, srv.URL+"/token")
+	fake.pub = pub
+	sa, err := LoadServiceAccount(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vs := []vuln.Vulnerability{
+		{UniqueID: "1", Identifier: "CVE-2024-4367", Severity: vuln.High, Description: "=HYPERLINK(\"x\")"},
+		{UniqueID: "2", Identifier: "CVE-2020-1752", Severity: vuln.Low},
+		{UniqueID: "3", Identifier: "CVE-2021-3999", Severity: vuln.High},
+	}
+	w := &Writer{Account: sa, SpreadsheetID: "sheet-1", BaseURL: srv.URL, Client: srv.Client()}
+	if err := w.Write(context.Background(), vs); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(fake.added, ",") != "Critical,Medium,Low" {
+		t.Errorf("added sheets %v, want the missing severities", fake.added)
+	}
+	if strings.Join(fake.cleared, ",") != "'Critical','High','Medium','Low'" {
+		t.Errorf("cleared %v", fake.cleared)
+	}
+	if fake.input != "RAW" {
+		t.Errorf("valueInputOption = %q, want RAW", fake.input)
+	}
+	high := fake.written["'High'!A1"]
+	if len(high) != 3 || high[0][0] != "Unique ID" || high[1][0] != "1" || high[2][0] != "3" {
+		t.Errorf("High sheet = %v", high)
+	}
+	if got := fake.written["'Critical'!A1"]; len(got) != 1 {
+		t.Errorf("Critical sheet = %v, want header only", got)
+	}
+	if got := fake.written["'Low'!A1"]; len(got) != 2 || got[1][0] != "2" {
+		t.Errorf("Low sheet = %v", got)
+	}
+}
+
+func TestLoadServiceAccountRejectsOtherKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.json")
+	os.WriteFile(path, []byte(`{"type":"authorized_user","client_id":"x"}`), 0o600)
+	if _, err := LoadServiceAccount(path); err == nil {
+		t.Error("want error for a non-service-account key")
+	}
+}
@@ -0,0 +1,131 @@
+// Package hierarchy places cloud accounts in an organization hierarchy:
+// each account reports to a business unit and each business unit to a
+// division. Reports roll findings up through it, so a division head sees
+// the division's numbers and a business unit's lead those of its
+// accounts, without either adding up hundreds of account rows.
+package hierarchy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Unassigned is the business unit and division of accounts the hierarchy
+// does not list.
+const Unassigned = "unassigned"
+
+// Levels names the levels of a Path, from the top.
+var Levels = []string{"division", "business_unit", "account"}
+
+// Division is a division and the business units that report to it.
+type Division struct {
+	Name          string         `json:"name"`
+	BusinessUnits []BusinessUnit `json:"business_units"`
+}
+
+// BusinessUnit is a business unit and its accounts.
+type BusinessUnit struct {
+	Name string `json:"name"`
+	// Accounts are account IDs, as in 253710526682, or full
+	// Organization/Account values, as in acme-inc-Education.
+	Accounts []string `json:"accounts"`
+}
+
+// Hierarchy is the divisions of a hierarchy file.
+type Hierarchy struct {
+	Divisions []Division `json:"divisions"`
+
+	units map[string]unit // account to its business unit and division
+}
+
+type unit struct{ division, businessUnit string }
+
+// Load reads a hierarchy file, as YAML when it is named .yaml or .yml and
+// JSON otherwise:
+//
+//	divisions:
+//	  - name: Consumer
+//	    business_units:
+//	      - name: Education
+//	        accounts: ["253710526682", acme-inc-Education]
+//
+// Unknown keys are rejected, as are divisions, business units and accounts
+// without a name, business units without accounts, and names listed more
+// than once, so that every account has one place in the hierarchy.
+func Load(file string) (*Hierarchy, error) {
+	b, err := config.ReadDocument(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	h := &Hierarchy{}
+	if err := dec.Decode(h); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	h.units = make(map[string]unit)
+	divisions, units := make(map[string]bool), make(map[string]bool)
+	for i := range h.Divisions {
+		d := &h.Divisions[i]
+		d.Name = strings.TrimSpace(d.Name)
+		switch {
+		case d.Name == "":
+			return nil, fmt.Errorf("%s: division %d has no name", file, i+1)
+		case divisions[d.Name]:
+			return nil, fmt.Errorf("%s: division %s listed more than once", file, d.Name)
+		}
+		divisions[d.Name] = true
+		for j := range d.BusinessUnits {
+			u := &d.BusinessUnits[j]
+			u.Name = strings.TrimSpace(u.Name)
+			switch {
+			case u.Name == "":
+				return nil, fmt.Errorf("%s: %s: business unit %d has no name", file, d.Name, j+1)
+			case units[u.Name]:
+				return nil, fmt.Errorf("%s: business unit %s listed more than once", file, u.Name)
+			case len(u.Accounts) == 0:
+				return nil, fmt.Errorf("%s: %s: no accounts", file, u.Name)
+			}
+			units[u.Name] = true
+			for k, account := range u.Accounts {
+				account = strings.TrimSpace(account)
+				if account == "" {
+					return nil, fmt.Errorf("%s: %s: account %d is blank", file, u.Name, k+1)
+				}
+				if _, dup := h.units[account]; dup {
+					return nil, fmt.Errorf("%s: account %s listed more than once", file, account)
+				}
+				u.Accounts[k] = account
+				h.units[account] = unit{d.Name, u.Name}
+			}
+		}
+	}
+	return h, nil
+}
+
+// Path returns the division, business unit and account of v, as Levels
+// names them. The account is the Organization/Account value, or the
+// account ID before its colon, that the hierarchy lists, else the whole
+// value, or Unassigned when blank, in the Unassigned business unit and
+// division.
+func (h *Hierarchy) Path(v *vuln.Vulnerability) []string {
+	org := strings.TrimSpace(v.Organization)
+	if u, ok := h.units[org]; ok {
+		return []string{u.division, u.businessUnit, org}
+	}
+	if id, _, ok := strings.Cut(org, ":"); ok {
+		id = strings.TrimSpace(id)
+		if u, ok := h.units[id]; ok {
+			return []string{u.division, u.businessUnit, id}
+		}
+	}
+	if org == "" {
+		org = Unassigned
+	}
+	return []string{Unassigned, Unassigned, org}
+}
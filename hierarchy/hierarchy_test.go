@@ -0,0 +1,63 @@
+package hierarchy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const org = `divisions:
+  - name: Consumer
+    business_units:
+      - name: Education
+        accounts: [acme-inc-Education, "111122223333"]
+  - name: Platform
+    business_units:
+      - name: Matching
+        accounts: ["253710526682"]
+`
+
+func TestLoad(t *testing.T) {
+	h, err := Load(writeFile(t, "org.yaml", org))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for org, want := range map[string][]string{
+		"acme-inc-Education":      {"Consumer", "Education", "acme-inc-Education"},
+		"253710526682: us-west-2": {"Platform", "Matching", "253710526682"},
+		" 111122223333 ":          {"Consumer", "Education", "111122223333"},
+		"444455556666: us-east-1": {Unassigned, Unassigned, "444455556666: us-east-1"},
+		"":                        {Unassigned, Unassigned, Unassigned},
+	} {
+		if got := h.Path(&vuln.Vulnerability{Organization: org}); !reflect.DeepEqual(got, want) {
+			t.Errorf("Path(%q) = %q, want %q", org, got, want)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"no division name": "divisions:\n  - business_units:\n      - name: a\n        accounts: [\"1\"]\n",
+		"no unit name":     "divisions:\n  - name: d\n    business_units:\n      - accounts: [\"1\"]\n",
+		"no accounts":      "divisions:\n  - name: d\n    business_units:\n      - name: a\n",
+		"blank account":    "divisions:\n  - name: d\n    business_units:\n      - name: a\n        accounts: [\" \"]\n",
+		"division twice":   "divisions:\n  - name: d\n  - name: d\n",
+		"unit twice":       "divisions:\n  - name: d\n    business_units:\n      - name: a\n        accounts: [\"1\"]\n  - name: e\n    business_units:\n      - name: a\n        accounts: [\"2\"]\n",
+		"account twice":    "divisions:\n  - name: d\n    business_units:\n      - name: a\n        accounts: [\"1\"]\n      - name: b\n        accounts: [\"1\"]\n",
+		"unknown key":      "divisions:\n  - name: d\n    head: someone\n",
+	} {
+		if _, err := Load(writeFile(t, "org.yaml", content)); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Package history records the inputs the tool has processed, by checksum,
+// so that a retried run of an input already processed can be skipped
+// instead of creating its tickets twice.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Run is one processed input.
+type Run struct {
+	InputSHA256 string `json:"input_sha256"`
+	Input       string `json:"input"`
+	// Shard is the -shard processed, "i/N", if any. Each shard of an
+	// input is processed separately.
+	Shard       string    `json:"shard,omitempty"`
+	ToolVersion string    `json:"tool_version,omitempty"`
+	ProcessedAt time.Time `json:"processed_at"`
+	Findings    int       `json:"findings"`
+}
+
+// Store holds the processed runs, oldest first.
+type Store struct {
+	Runs []Run `json:"runs"`
+}
+
+// Load reads a store saved with Save. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Store{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes s to path as indented JSON.
+func (s *Store) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Find returns the latest run of the input with checksum sum and shard.
+func (s *Store) Find(sum, shard string) (Run, bool) {
+	for i := len(s.Runs) - 1; i >= 0; i-- {
+		if r := s.Runs[i]; r.InputSHA256 == sum && r.Shard == shard {
+			return r, true
+		}
+	}
+	return Run{}, false
+}
+
+// Add records r as the latest run.
+func (s *Store) Add(r Run) {
+	s.Runs = append(s.Runs, r)
+}
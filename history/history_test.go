@@ -0,0 +1,39 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	s, err := Load(path)
+	if err != nil || len(s.Runs) != 0 {
+		t.Fatalf("Load(missing) = %+v, %v", s, err)
+	}
+	first := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	s.Add(Run{InputSHA256: "abc", Input: "export.csv", ProcessedAt: first, Findings: 3})
+	s.Add(Run{InputSHA256: "abc", Input: "export.csv", Shard: "0/2", ProcessedAt: first.Add(time.Hour)})
+	s.Add(Run{InputSHA256: "abc", Input: "copy.csv", ProcessedAt: first.Add(2 * time.Hour), Findings: 3})
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := s.Find("abc", ""); !ok || r.Input != "copy.csv" {
+		t.Errorf(`Find("abc", "") = %+v, %v; want the latest unsharded run`, r, ok)
+	}
+	if r, ok := s.Find("abc", "0/2"); !ok || !r.ProcessedAt.Equal(first.Add(time.Hour)) {
+		t.Errorf(`Find("abc", "0/2") = %+v, %v`, r, ok)
+	}
+	if _, ok := s.Find("abc", "1/2"); ok {
+		t.Error("Find found an unprocessed shard")
+	}
+	if _, ok := s.Find("def", ""); ok {
+		t.Error("Find found an unprocessed input")
+	}
+}
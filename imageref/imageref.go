@@ -0,0 +1,313 @@
+// Package imageref identifies container image assets by content digest
+// rather than by tag. A tag such as "api:latest" moves from image to
+// image, and one image is often pushed under several tags, so scanners
+// report the same vulnerable image once per tag. Keyed by digest, those
+// reports collapse into one finding that lists the tags pointing at it.
+package imageref
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// digestPattern matches a digest as scanners and registries write it: an
+// algorithm, a colon and hex, in either case.
+var digestPattern = regexp.MustCompile(`^(?i)(sha256|sha384|sha512):([0-9a-f]+)$`)
+
+// digestLengths gives the hex length of each algorithm's digest.
+var digestLengths = map[string]int{"sha256": 64, "sha384": 96, "sha512": 128}
+
+// Digest returns the canonical form of an image digest, lower case as in
+// "sha256:4be4...", and whether s is one. A bare 64-digit hex string is
+// taken as a SHA-256 digest, as some scanners write image IDs that way.
+func Digest(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) == 64 && !strings.Contains(s, ":") {
+		s = "sha256:" + s
+	}
+	m := digestPattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	alg, hex := strings.ToLower(m[1]), strings.ToLower(m[2])
+	if len(hex) != digestLengths[alg] {
+		return "", false
+	}
+	return alg + ":" + hex, true
+}
+
+// Ref is a parsed image reference.
+type Ref struct {
+	// Repository is the registry and path, as in
+	// "docker.io/library/nginx" or
+	// "253710526682.dkr.ecr.us-west-2.amazonaws.com/hasura".
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// defaultRegistry is the registry of references that name none.
+const defaultRegistry = "docker.io"
+
+// Parse parses an image reference such as "nginx:1.27",
+// "ghcr.io/acme/api@sha256:..." or "api:v2@sha256:...", or an ECR
+// repository ARN. References without a registry are on Docker Hub, and
+// single-name Docker Hub repositories are under "library/", so "nginx"
+// and "docker.io/library/nginx" are one repository. It reports false for
+// strings that are not references, including those with an invalid digest.
+func Parse(s string) (Ref, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, " \t") {
+		return Ref{}, false
+	}
+	if strings.HasPrefix(s, "arn:") {
+		return parseARN(s)
+	}
+	var r Ref
+	if name, digest, ok := strings.Cut(s, "@"); ok {
+		if r.Digest, ok = Digest(digest); !ok {
+			return Ref{}, false
+		}
+		s = name
+	}
+	if i := strings.LastIndexByte(s, ':'); i > strings.LastIndexByte(s, '/') {
+		s, r.Tag = s[:i], s[i+1:]
+		if r.Tag == "" {
+			return Ref{}, false
+		}
+	}
+	if s == "" {
+		return Ref{}, false
+	}
+	registry, path, ok := strings.Cut(s, "/")
+	if !ok || !strings.ContainsAny(registry, ".:") && registry != "localhost" {
+		registry, path = defaultRegistry, s
+	}
+	if registry == defaultRegistry && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	r.Repository = strings.ToLower(registry) + "/" + strings.ToLower(path)
+	return r, true
+}
+
+// parseARN parses an ECR repository ARN,
+// "arn:aws:ecr:<region>:<account>:repository/<name>", into the
+// repository's registry URI.
+func parseARN(s string) (Ref, bool) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[2] != "ecr" {
+		return Ref{}, false
+	}
+	name, ok := strings.CutPrefix(parts[5], "repository/")
+	if !ok || name == "" || parts[3] == "" || parts[4] == "" {
+		return Ref{}, false
+	}
+	return Ref{Repository: parts[4] + ".dkr.ecr." + parts[3] + ".amazonaws.com/" + strings.ToLower(name)}, true
+}
+
+// String returns the reference's canonical form: the repository with the
+// digest when there is one, else the tag.
+func (r Ref) String() string {
+	switch {
+	case r.Digest != "":
+		return r.Repository + "@" + r.Digest
+	case r.Tag != "":
+		return r.Repository + ":" + r.Tag
+	}
+	return r.Repository
+}
+
+// Name returns the repository as people write it: without the registry
+// and "library/" for Docker Hub repositories, as in "nginx".
+func (r Ref) Name() string {
+	if name, ok := strings.CutPrefix(r.Repository, defaultRegistry+"/"); ok {
+		return strings.TrimPrefix(name, "library/")
+	}
+	return r.Repository
+}
+
+// refOf returns the image reference a finding's asset is, merged with its
+// ImageDigest and ImageTags, and whether its asset is an image at all: an
+// asset ID or name that is a reference with a digest, or any finding with
+// an image digest or tags.
+func refOf(v *vuln.Vulnerability) (Ref, bool) {
+	for _, s := range []string{v.AssetID, v.AssetName} {
+		if r, ok := Parse(s); ok && r.Digest != "" {
+			if v.ImageDigest != "" {
+				r.Digest = v.ImageDigest
+			}
+			return r, true
+		}
+	}
+	if v.ImageDigest == "" && len(v.ImageTags) == 0 {
+		return Ref{}, false
+	}
+	for _, s := range []string{v.AssetID, v.AssetName} {
+		if r, ok := Parse(s); ok {
+			r.Digest = v.ImageDigest
+			return r, true
+		}
+	}
+	return Ref{}, false
+}
+
+// Apply makes the digest the identity of every image finding in vs. An
+// image finding's AssetID becomes its canonical reference,
+// "<repository>@<digest>", and its ImageDigest and ImageTags are set;
+// ImageTags lists every tag of the input that refers to the digest. A
+// finding that names a tag but no digest takes the digest another finding
+// reports for that tag. Asset names that are references lose their tag
+// or digest. Findings that then share a fingerprint are tag aliases of
+// one another and are collapsed into the first, with the earliest first
+// detected date.
+//
+// It returns the findings left and how many were collapsed. Findings
+// whose asset is not an image, or whose digest is not known, are
+// unchanged.
+func Apply(vs []vuln.Vulnerability) ([]vuln.Vulnerability, int) {
+	refs := make([]Ref, len(vs))
+	image := make([]bool, len(vs))
+	tagDigests := make(map[string]string) // by "<repository>:<tag>"
+	for i := range vs {
+		refs[i], image[i] = refOf(&vs[i])
+		if !image[i] || refs[i].Digest == "" {
+			continue
+		}
+		for _, tag := range tagsOf(&vs[i], refs[i]) {
+			tagDigests[refs[i].Repository+":"+tag] = refs[i].Digest
+		}
+	}
+
+	tags := make(map[string]map[string]bool) // by canonical reference
+	for i := range vs {
+		r := &refs[i]
+		if !image[i] {
+			continue
+		}
+		if r.Digest == "" {
+			for _, tag := range tagsOf(&vs[i], *r) {
+				if d := tagDigests[r.Repository+":"+tag]; d != "" {
+					r.Digest = d
+					break
+				}
+			}
+		}
+		if r.Digest == "" {
+			image[i] = false
+			continue
+		}
+		id := Ref{Repository: r.Repository, Digest: r.Digest}.String()
+		if tags[id] == nil {
+			tags[id] = make(map[string]bool)
+		}
+		for _, tag := range tagsOf(&vs[i], *r) {
+			tags[id][tag] = true
+		}
+	}
+
+	out := vs[:0]
+	kept := make(map[string]int) // fingerprint to index in out
+	collapsed := 0
+	for i := range vs {
+		v := vs[i]
+		if image[i] {
+			r := refs[i]
+			id := Ref{Repository: r.Repository, Digest: r.Digest}.String()
+			v.AssetID, v.ImageDigest, v.ImageTags = id, r.Digest, sortedKeys(tags[id])
+			if n, ok := Parse(v.AssetName); ok && (n.Tag != "" || n.Digest != "") {
+				v.AssetName = n.Name()
+			}
+			fp := v.Fingerprint()
+			if j, ok := kept[fp]; ok {
+				if d := v.FirstDetectedDate; !d.IsZero() && (out[j].FirstDetectedDate.IsZero() || d.Before(out[j].FirstDetectedDate.Time)) {
+					out[j].FirstDetectedDate = d
+				}
+				collapsed++
+				continue
+			}
+			kept[fp] = len(out)
+		}
+		out = append(out, v)
+	}
+	return out, collapsed
+}
+
+// tagsOf returns the tags a finding names for its image.
+func tagsOf(v *vuln.Vulnerability, r Ref) []string {
+	if r.Tag == "" {
+		return v.ImageTags
+	}
+	return append([]string{r.Tag}, v.ImageTags...)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Image is one vulnerable image and the tags that refer to it.
+type Image struct {
+	Repository string   `json:"repository"`
+	Digest     string   `json:"digest"`
+	Tags       []string `json:"tags"`
+	// Findings counts the image's open findings; Critical and High those
+	// of each severity.
+	Findings int `json:"findings"`
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+}
+
+// Images returns the images of the image findings in vs, after Apply,
+// most critical findings first, then most findings, then by repository.
+// Resolved and risk-accepted findings are not counted, and images with
+// only those are left out.
+func Images(vs []vuln.Vulnerability) []Image {
+	byID := make(map[string]*Image)
+	var order []string
+	for i := range vs {
+		v := &vs[i]
+		if v.ImageDigest == "" || v.State == state.Resolved || v.State == state.RiskAccepted {
+			continue
+		}
+		img, ok := byID[v.AssetID]
+		if !ok {
+			repo, _, _ := strings.Cut(v.AssetID, "@")
+			img = &Image{Repository: repo, Digest: v.ImageDigest, Tags: v.ImageTags}
+			byID[v.AssetID] = img
+			order = append(order, v.AssetID)
+		}
+		img.Findings++
+		switch v.Severity {
+		case vuln.Critical:
+			img.Critical++
+		case vuln.High:
+			img.High++
+		}
+	}
+	images := make([]Image, len(order))
+	for i, id := range order {
+		images[i] = *byID[id]
+	}
+	sort.SliceStable(images, func(i, j int) bool {
+		a, b := images[i], images[j]
+		if a.Critical != b.Critical {
+			return a.Critical > b.Critical
+		}
+		if a.Findings != b.Findings {
+			return a.Findings > b.Findings
+		}
+		return a.Repository < b.Repository
+	})
+	return images
+}
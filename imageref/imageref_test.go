@@ -0,0 +1,122 @@
+package imageref
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+const (
+	hexA = "4be4aa5d16a227b5b1e9075b6ff3bd4dd2e200543e3a1eb3e2e55bb57e4ae1f4"
+	hexB = "0d3cbd5e09bdb7052c45b2f5bfe4019128035f9ab7d1d30a61f1d2f903b1ee8e"
+)
+
+func TestDigest(t *testing.T) {
+	for s, want := range map[string]string{
+		"sha256:" + hexA:                   "sha256:" + hexA,
+		" SHA256:" + strings.ToUpper(hexA): "sha256:" + hexA,
+		hexA:                               "sha256:" + hexA, // bare image ID
+		"sha512:" + hexA + hexA:            "sha512:" + hexA + hexA,
+		"sha256:" + hexA[:12]:              "", // short form
+		"sha512:" + hexA:                   "", // wrong length
+		"md5:" + hexA:                      "",
+		"sha256:" + hexA[:63] + "g":        "",
+		"latest":                           "",
+	} {
+		got, ok := Digest(s)
+		if got != want || ok != (want != "") {
+			t.Errorf("Digest(%q) = %q, %v, want %q", s, got, ok, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Ref
+	}{
+		{"nginx", Ref{Repository: "docker.io/library/nginx"}},
+		{"docker.io/library/nginx:1.27", Ref{Repository: "docker.io/library/nginx", Tag: "1.27"}},
+		{"acme/API:v2@SHA256:" + hexA, Ref{Repository: "docker.io/acme/api", Tag: "v2", Digest: "sha256:" + hexA}},
+		{"ghcr.io/acme/api@sha256:" + hexA, Ref{Repository: "ghcr.io/acme/api", Digest: "sha256:" + hexA}},
+		{"localhost:5000/api:dev", Ref{Repository: "localhost:5000/api", Tag: "dev"}},
+		{"arn:aws:ecr:us-west-2:253710526682:repository/hasura", Ref{Repository: "253710526682.dkr.ecr.us-west-2.amazonaws.com/hasura"}},
+	}
+	for _, tt := range tests {
+		if got, ok := Parse(tt.in); !ok || got != tt.want {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", tt.in, got, ok, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "api@sha256:abc", "api:", "two words", "arn:aws:s3:::bucket"} {
+		if r, ok := Parse(bad); ok {
+			t.Errorf("Parse(%q) = %+v, want failure", bad, r)
+		}
+	}
+	if r, _ := Parse("nginx:1.27@sha256:" + hexA); r.String() != "docker.io/library/nginx@sha256:"+hexA || r.Name() != "nginx" {
+		t.Errorf("String() = %q, Name() = %q", r.String(), r.Name())
+	}
+}
+
+func TestApply(t *testing.T) {
+	arn := "arn:aws:ecr:us-west-2:253710526682:repository/api"
+	repo := "253710526682.dkr.ecr.us-west-2.amazonaws.com/api"
+	vs := []vuln.Vulnerability{
+		// One image under two tags, reported once per tag.
+		{Identifier: "CVE-1", AssetName: "api", AssetID: arn, ImageDigest: "sha256:" + hexA, ImageTags: []string{"latest"}, FirstDetectedDate: vuln.NewDate(2025, 1, 9)},
+		{Identifier: "CVE-1", AssetName: "api", AssetID: arn, ImageDigest: "sha256:" + hexA, ImageTags: []string{"v1.4"}, FirstDetectedDate: vuln.NewDate(2025, 1, 2)},
+		// A tag without a digest takes the digest reported for it.
+		{Identifier: "CVE-2", AssetName: "api", AssetID: arn, ImageTags: []string{"latest"}},
+		// Another image of the repository.
+		{Identifier: "CVE-1", AssetName: "api", AssetID: arn, ImageDigest: "sha256:" + hexB, ImageTags: []string{"v1.3"}},
+		// A reference in the asset name.
+		{Identifier: "CVE-3", AssetName: "nginx:1.27@sha256:" + hexB},
+		// A tag nothing resolves, and an asset that is not an image.
+		{Identifier: "CVE-4", AssetName: "api", AssetID: arn, ImageTags: []string{"dev"}},
+		{Identifier: "CVE-5", AssetName: "apply-api", AssetID: "464323181"},
+	}
+	out, collapsed := Apply(vs)
+	if collapsed != 1 || len(out) != 6 {
+		t.Fatalf("Apply collapsed %d, left %d: %+v", collapsed, len(out), out)
+	}
+	a := out[0]
+	if a.AssetID != repo+"@sha256:"+hexA || strings.Join(a.ImageTags, ",") != "latest,v1.4" || a.FirstDetectedDate != vuln.NewDate(2025, 1, 2) {
+		t.Errorf("collapsed finding %+v", a)
+	}
+	if b := out[1]; b.AssetID != a.AssetID || b.ImageDigest != "sha256:"+hexA || strings.Join(b.ImageTags, ",") != "latest,v1.4" {
+		t.Errorf("tag-only finding %+v", b)
+	}
+	if c := out[2]; c.AssetID != repo+"@sha256:"+hexB || strings.Join(c.ImageTags, ",") != "v1.3" {
+		t.Errorf("second image %+v", c)
+	}
+	if d := out[3]; d.AssetID != "docker.io/library/nginx@sha256:"+hexB || d.AssetName != "nginx" || strings.Join(d.ImageTags, ",") != "1.27" {
+		t.Errorf("reference asset name %+v", d)
+	}
+	if e := out[4]; e.AssetID != arn || e.ImageDigest != "" {
+		t.Errorf("unresolved tag %+v", e)
+	}
+	if f := out[5]; f.AssetID != "464323181" || f.ImageDigest != "" {
+		t.Errorf("non-image asset %+v", f)
+	}
+}
+
+func TestImages(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{AssetID: "r/a@sha256:" + hexA, ImageDigest: "sha256:" + hexA, ImageTags: []string{"latest"}, Severity: vuln.High},
+		{AssetID: "r/a@sha256:" + hexA, ImageDigest: "sha256:" + hexA, ImageTags: []string{"latest"}, Severity: vuln.Low},
+		{AssetID: "r/b@sha256:" + hexB, ImageDigest: "sha256:" + hexB, Severity: vuln.Critical},
+		{AssetID: "r/b@sha256:" + hexB, ImageDigest: "sha256:" + hexB, Severity: vuln.Critical, State: state.Resolved},
+		{AssetID: "464323181", Severity: vuln.Critical},
+	}
+	images := Images(vs)
+	if len(images) != 2 {
+		t.Fatalf("Images = %+v", images)
+	}
+	if b := images[0]; b.Repository != "r/b" || b.Findings != 1 || b.Critical != 1 {
+		t.Errorf("first image %+v, want r/b with one critical", b)
+	}
+	if a := images[1]; a.Repository != "r/a" || a.Digest != "sha256:"+hexA || a.Tags[0] != "latest" || a.Findings != 2 || a.High != 1 {
+		t.Errorf("second image %+v", a)
+	}
+}
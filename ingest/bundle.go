@@ -0,0 +1,165 @@
+package ingest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// MaxBundleBytes is the most a bundle may hold uncompressed, so that a
+// small archive cannot exhaust memory.
+const MaxBundleBytes = 2 << 30
+
+// Member is one file of an input bundle.
+type Member struct {
+	// Name is the file's path in the archive.
+	Name string
+	Data []byte
+}
+
+// ReadBundle returns the files of the size-byte ZIP or gzipped tar
+// archive ra, in archive order. Directories, links and hidden files, such
+// as the __MACOSX and ._ entries of archives made on macOS, are left out.
+func ReadBundle(ra io.ReaderAt, size int64) ([]Member, error) {
+	var magic [2]byte
+	if _, err := ra.ReadAt(magic[:], 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if magic == [2]byte{0x1f, 0x8b} {
+		return readTarGz(io.NewSectionReader(ra, 0, size))
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, fmt.Errorf("bundle is neither a ZIP nor a gzipped tar archive: %w", err)
+	}
+	var members []Member
+	var total int64
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() || hidden(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		data, err := readMember(rc, &total)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		members = append(members, Member{f.Name, data})
+	}
+	return members, nil
+}
+
+func readTarGz(r io.Reader) ([]Member, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	var members []Member
+	var total int64
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return members, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg || hidden(h.Name) {
+			continue
+		}
+		data, err := readMember(tr, &total)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", h.Name, err)
+		}
+		members = append(members, Member{h.Name, data})
+	}
+}
+
+// readMember reads a member, adding its size to total and failing once
+// total passes MaxBundleBytes.
+func readMember(r io.Reader, total *int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxBundleBytes-*total+1))
+	if err != nil {
+		return nil, err
+	}
+	if *total += int64(len(data)); *total > MaxBundleBytes {
+		return nil, fmt.Errorf("bundle holds more than %d bytes uncompressed", MaxBundleBytes)
+	}
+	return data, nil
+}
+
+func hidden(name string) bool {
+	for _, part := range strings.Split(path.Clean(name), "/") {
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoMembers is MergeMembers' error for a bundle without files.
+var ErrNoMembers = errors.New("bundle holds no files")
+
+// MergeMembers returns the results of a bundle's members, named by names,
+// as one result. Its header and custom columns are those of the members,
+// each column once in the order first read, and each skipped row's record
+// is laid out under that header and names its member, unless it names one
+// already, as when a bundle is merged with pulled alerts.
+func MergeMembers(names []string, parts []*Result) (*Result, error) {
+	if len(parts) == 0 {
+		return nil, ErrNoMembers
+	}
+	res := &Result{}
+	columns := make(map[string]int)
+	custom := make(map[string]bool)
+	for i, part := range parts {
+		at := make([]int, len(part.Header))
+		for j, h := range part.Header {
+			k, ok := columns[h]
+			if !ok {
+				k = len(res.Header)
+				columns[h] = k
+				res.Header = append(res.Header, h)
+			}
+			at[j] = k
+		}
+		for _, c := range part.CustomColumns {
+			if !custom[c] {
+				custom[c] = true
+				res.CustomColumns = append(res.CustomColumns, c)
+			}
+		}
+		res.Vulns = append(res.Vulns, part.Vulns...)
+		for _, s := range part.Skipped {
+			if s.Member == "" {
+				s.Member = names[i]
+			}
+			if s.Record != nil {
+				record := make([]string, 0, len(s.Record))
+				for j, cell := range s.Record {
+					k := len(res.Header) + j - len(at)
+					if j < len(at) {
+						k = at[j]
+					}
+					for len(record) <= k {
+						record = append(record, "")
+					}
+					record[k] = cell
+				}
+				s.Record = record
+			}
+			res.Skipped = append(res.Skipped, s)
+		}
+	}
+	return res, nil
+}
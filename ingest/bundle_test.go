@@ -0,0 +1,108 @@
+package ingest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// bundleFiles are the entries of the test bundles, in archive order. The
+// directory and the hidden files are left out of the members.
+var bundleFiles = []struct{ name, data string }{
+	{"nightly/", ""},
+	{"nightly/export.csv", "Identifier,Severity\nCVE-2024-1,High\n"},
+	{"nightly/.DS_Store", "junk"},
+	{"__MACOSX/nightly/._export.csv", "junk"},
+	{"nightly/trivy.json", `{"SchemaVersion": 2}`},
+}
+
+func TestReadBundle(t *testing.T) {
+	var zb bytes.Buffer
+	zw := zip.NewWriter(&zb)
+	for _, f := range bundleFiles {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f.data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var tb bytes.Buffer
+	gz := gzip.NewWriter(&tb)
+	tw := tar.NewWriter(gz)
+	for _, f := range bundleFiles {
+		h := &tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+		if f.data == "" {
+			h.Typeflag, h.Mode = tar.TypeDir, 0o755
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.data))
+	}
+	tw.Close()
+	gz.Close()
+
+	for name, b := range map[string][]byte{"zip": zb.Bytes(), "tar.gz": tb.Bytes()} {
+		members, err := ReadBundle(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got []string
+		for _, m := range members {
+			got = append(got, m.Name+"="+string(m.Data))
+		}
+		want := []string{"nightly/export.csv=" + bundleFiles[1].data, "nightly/trivy.json=" + bundleFiles[4].data}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: members %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err := ReadBundle(bytes.NewReader([]byte("Identifier\n")), 11); err == nil {
+		t.Error("ReadBundle read a CSV as a bundle")
+	}
+}
+
+func TestMergeMembers(t *testing.T) {
+	csv := &Result{
+		Header:        []string{"Identifier", "Severity", "Team"},
+		CustomColumns: []string{"Team"},
+		Vulns:         make([]vuln.Vulnerability, 2),
+		Skipped:       []RowError{{Line: 3, Record: []string{"CVE-2024-2", "Severe", "web"}, Err: errors.New(`unknown severity "Severe"`)}},
+	}
+	trivy := &Result{
+		Header:  trivyHeader,
+		Vulns:   make([]vuln.Vulnerability, 1),
+		Skipped: []RowError{{Line: 2, Record: []string{"alpine", "", "musl", "1.2.3", "HIGH"}, Err: errors.New("missing identifier")}},
+	}
+	res, err := MergeMembers([]string{"export.csv", "trivy.json"}, []*Result{csv, trivy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantHeader := []string{"Identifier", "Severity", "Team", "Target", "VulnerabilityID", "PkgName", "InstalledVersion"}
+	if !slices.Equal(res.Header, wantHeader) || !slices.Equal(res.CustomColumns, []string{"Team"}) || len(res.Vulns) != 3 {
+		t.Fatalf("merged header %q, custom columns %q and %d findings", res.Header, res.CustomColumns, len(res.Vulns))
+	}
+	if len(res.Skipped) != 2 {
+		t.Fatalf("skipped %d rows, want 2", len(res.Skipped))
+	}
+	if s := res.Skipped[1]; !slices.Equal(s.Record, []string{"", "HIGH", "", "alpine", "", "musl", "1.2.3"}) || s.Error() != "trivy.json line 2: missing identifier" {
+		t.Errorf("skipped Trivy result laid out as %q, %q", s.Record, s.Error())
+	}
+	if csv.Skipped[0].Member != "" {
+		t.Error("MergeMembers changed a member's result")
+	}
+
+	if _, err := MergeMembers(nil, nil); !errors.Is(err, ErrNoMembers) {
+		t.Errorf("empty bundle: %v", err)
+	}
+}
@@ -0,0 +1,712 @@
+// Package ingest reads scanner exports into vuln.Vulnerability records.
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/imageref"
+	"github.com/VioletX-Dev/devsecops-test/platform"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Field identifies a Vulnerability attribute an input column can map to.
+type Field string
+
+// Fields recognised in CSV headers.
+const (
+	FieldUniqueID          Field = "unique_id"
+	FieldAssetName         Field = "asset_name"
+	FieldAssetID           Field = "asset_id"
+	FieldOrganization      Field = "organization"
+	FieldIdentifier        Field = "identifier"
+	FieldSource            Field = "source"
+	FieldCVSS              Field = "cvss"
+	FieldTitle             Field = "title"
+	FieldDescription       Field = "description"
+	FieldPackageName       Field = "package_name"
+	FieldInstalledVersion  Field = "installed_version"
+	FieldFixedVersion      Field = "fixed_version"
+	FieldRemediation       Field = "remediation"
+	FieldSeverity          Field = "severity"
+	FieldDueDate           Field = "due_date"
+	FieldFirstDetectedDate Field = "first_detected_date"
+	FieldFixability        Field = "fixability"
+	FieldConfidence        Field = "confidence"
+	FieldCVSSVector        Field = "cvss_vector"
+	FieldTeam              Field = "team"
+	FieldOS                Field = "os"
+	FieldOSFamily          Field = "os_family"
+	FieldOSVersion         Field = "os_version"
+	FieldArch              Field = "arch"
+	FieldImageDigest       Field = "image_digest"
+	FieldImageTags         Field = "image_tags"
+	FieldOccurrences       Field = "occurrences"
+	FieldInstances         Field = "instances"
+	FieldClassification    Field = "data_classification"
+	FieldCriticality       Field = "criticality"
+	FieldEnvironment       Field = "environment"
+	FieldOwner             Field = "asset_owner"
+
+	// Enrichment and computed fields, present when reading the tool's own
+	// CSV output.
+	FieldEPSS                Field = "epss"
+	FieldEPSSPercentile      Field = "epss_percentile"
+	FieldKEV                 Field = "kev"
+	FieldExploit             Field = "exploit_maturity"
+	FieldCVSSSource          Field = "cvss_source"
+	FieldRelatedCVEs         Field = "related_cves"
+	FieldCWEs                Field = "cwes"
+	FieldClass               Field = "class"
+	FieldRegion              Field = "region"
+	FieldRenamedFrom         Field = "renamed_from"
+	FieldDiscovered          Field = "discovered"
+	FieldStaleAsset          Field = "stale_asset"
+	FieldSuppressed          Field = "suppressed"
+	FieldWaiver              Field = "waiver"
+	FieldOSEndOfLife         Field = "os_end_of_life"
+	FieldDependency          Field = "dependency"
+	FieldState               Field = "state"
+	FieldTicket              Field = "ticket"
+	FieldDependencyDepth     Field = "dependency_depth"
+	FieldNextPatch           Field = "next_patch_opportunity"
+	FieldEmergencyChange     Field = "emergency_change"
+	FieldNoiseReasons        Field = "noise_reasons"
+	FieldEffort              Field = "effort"
+	FieldSLAStatus           Field = "sla_status"
+	FieldDaysOverdue         Field = "days_overdue"
+	FieldBreachesSLAOn       Field = "breaches_sla_on"
+	FieldInstanceCount       Field = "instance_count" // len(Instances), so not kept
+	FieldTemporalCVSS        Field = "temporal_cvss"
+	FieldPriorityScore       Field = "priority_score"
+	FieldActionTimeframe     Field = "action_timeframe"
+	FieldRemediateWithinDays Field = "remediate_within_days"
+	FieldScoreIn30Days       Field = "score_in_30_days"
+	FieldScoreIn60Days       Field = "score_in_60_days"
+	FieldScoreIn90Days       Field = "score_in_90_days"
+)
+
+// headerAliases maps normalised header names (see normalizeHeader) to
+// fields. It covers the vendor export and the column names used in the
+// assessment brief.
+var headerAliases = map[string]Field{
+	"uniqueid":             FieldUniqueID,
+	"id":                   FieldUniqueID,
+	"assetname":            FieldAssetName,
+	"asset":                FieldAssetName,
+	"assetid":              FieldAssetID,
+	"organizationaccount":  FieldOrganization,
+	"organization":         FieldOrganization,
+	"account":              FieldOrganization,
+	"identifier":           FieldIdentifier,
+	"cvenumber":            FieldIdentifier,
+	"cve":                  FieldIdentifier,
+	"cveid":                FieldIdentifier,
+	"kb":                   FieldIdentifier,
+	"kbarticle":            FieldIdentifier,
+	"source":               FieldSource,
+	"cvss":                 FieldCVSS,
+	"cvssscore":            FieldCVSS,
+	"cvssvector":           FieldCVSSVector,
+	"vector":               FieldCVSSVector,
+	"vectorstring":         FieldCVSSVector,
+	"os":                   FieldOS,
+	"operatingsystem":      FieldOS,
+	"osfamily":             FieldOSFamily,
+	"platform":             FieldOSFamily,
+	"distro":               FieldOSFamily,
+	"osversion":            FieldOSVersion,
+	"osrelease":            FieldOSVersion,
+	"arch":                 FieldArch,
+	"architecture":         FieldArch,
+	"imagedigest":          FieldImageDigest,
+	"digest":               FieldImageDigest,
+	"imagetag":             FieldImageTags,
+	"imagetags":            FieldImageTags,
+	"tags":                 FieldImageTags,
+	"title":                FieldTitle,
+	"description":          FieldDescription,
+	"packagename":          FieldPackageName,
+	"affectedpackage":      FieldPackageName,
+	"package":              FieldPackageName,
+	"renamedfrom":          FieldRenamedFrom,
+	"installedversion":     FieldInstalledVersion,
+	"fixedversion":         FieldFixedVersion,
+	"fixedpackageversion":  FieldFixedVersion,
+	"remediation":          FieldRemediation,
+	"severity":             FieldSeverity,
+	"duedate":              FieldDueDate,
+	"auditduedate":         FieldDueDate,
+	"firstdetecteddate":    FieldFirstDetectedDate,
+	"discoverydate":        FieldFirstDetectedDate,
+	"fixability":           FieldFixability,
+	"confidence":           FieldConfidence,
+	"scannerconfidence":    FieldConfidence,
+	"epss":                 FieldEPSS,
+	"epsspercentile":       FieldEPSSPercentile,
+	"kev":                  FieldKEV,
+	"exploitmaturity":      FieldExploit,
+	"cvsssource":           FieldCVSSSource,
+	"relatedcves":          FieldRelatedCVEs,
+	"cwe":                  FieldCWEs,
+	"cwes":                 FieldCWEs,
+	"cweid":                FieldCWEs,
+	"cweids":               FieldCWEs,
+	"class":                FieldClass,
+	"vulnclass":            FieldClass,
+	"vulnerabilityclass":   FieldClass,
+	"region":               FieldRegion,
+	"team":                 FieldTeam,
+	"ownerteam":            FieldTeam,
+	"owner":                FieldTeam,
+	"dataclassification":   FieldClassification,
+	"classification":       FieldClassification,
+	"datasensitivity":      FieldClassification,
+	"criticality":          FieldCriticality,
+	"assetcriticality":     FieldCriticality,
+	"businesscriticality":  FieldCriticality,
+	"environment":          FieldEnvironment,
+	"env":                  FieldEnvironment,
+	"assetowner":           FieldOwner,
+	"discovered":           FieldDiscovered,
+	"staleasset":           FieldStaleAsset,
+	"suppressed":           FieldSuppressed,
+	"waiver":               FieldWaiver,
+	"osendoflife":          FieldOSEndOfLife,
+	"nextpatchopportunity": FieldNextPatch,
+	"emergencychange":      FieldEmergencyChange,
+	"dependency":           FieldDependency,
+	"state":                FieldState,
+	"status":               FieldState,
+	"ticket":               FieldTicket,
+	"jiraissue":            FieldTicket,
+	"issuekey":             FieldTicket,
+	"dependencydepth":      FieldDependencyDepth,
+	"occurrences":          FieldOccurrences,
+	"instanceids":          FieldInstances,
+	"instances":            FieldInstanceCount,
+	"noisereasons":         FieldNoiseReasons,
+	"effort":               FieldEffort,
+	"slastatus":            FieldSLAStatus,
+	"daysoverdue":          FieldDaysOverdue,
+	"breachesslaon":        FieldBreachesSLAOn,
+	"temporalcvss":         FieldTemporalCVSS,
+	"priorityscore":        FieldPriorityScore,
+	"actiontimeframe":      FieldActionTimeframe,
+	"remediatewithindays":  FieldRemediateWithinDays,
+	"scorein30days":        FieldScoreIn30Days,
+	"scorein60days":        FieldScoreIn60Days,
+	"scorein90days":        FieldScoreIn90Days,
+}
+
+// requiredFields must be present in the header for a file to be read. A
+// CVSS Vector column stands in for the CVSS column; see missingFields.
+var requiredFields = []Field{FieldIdentifier, FieldSeverity, FieldCVSS}
+
+// missingFields returns the requiredFields a header lacks, given whether
+// it maps a column to each field.
+func missingFields(mapped func(Field) bool) []Field {
+	var missing []Field
+	for _, field := range requiredFields {
+		if !mapped(field) && !(field == FieldCVSS && mapped(FieldCVSSVector)) {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// dateLayouts are tried in order when parsing date cells. The date-time
+// layout is how ReadXLSX writes date cells with a time of day.
+var dateLayouts = []string{"1/2/2006", vuln.DateLayout, "2006-01-02 15:04:05"}
+
+// RowError describes an input row that was skipped.
+type RowError struct {
+	// Line is the 1-based line on which the row starts.
+	Line int
+	// Record holds the row's cells as read, or nil when the row could not
+	// be split into cells at all.
+	Record []string
+	Err    error
+	// Member is the bundle member the row was read from, if any.
+	Member string
+}
+
+func (e *RowError) Error() string {
+	if e.Member != "" {
+		return fmt.Sprintf("%s line %d: %v", e.Member, e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// Result is the outcome of reading one input.
+type Result struct {
+	// Header is the input's header row as read.
+	Header []string
+	// CustomColumns are the headers of the columns that map to no field,
+	// in input order. Their cells are kept in Vulnerability.CustomFields.
+	CustomColumns []string
+	Vulns         []vuln.Vulnerability
+	Skipped       []RowError
+
+	custom   []int          // column index of each of CustomColumns
+	profiles map[string]int // column index of each weight profile's score
+}
+
+// ReadCSV reads a CSV export with a header row. Rows with missing or
+// malformed required data are skipped and reported in Result.Skipped;
+// an error is returned only when the input cannot be read at all.
+func ReadCSV(r io.Reader) (*Result, error) {
+	s, err := NewCSVStream(r)
+	if err != nil {
+		return nil, err
+	}
+	res := s.res
+	for {
+		v, err := s.Next()
+		if err == io.EOF {
+			return res, nil
+		}
+		var rerr *RowError
+		if errors.As(err, &rerr) {
+			res.Skipped = append(res.Skipped, *rerr)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res.Vulns = append(res.Vulns, v)
+	}
+}
+
+// newResult starts a Result for an input with header, returning the
+// column index of every recognised field.
+func newResult(header []string) (*Result, map[Field]int, error) {
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	columns, err := mapHeader(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := &Result{Header: header}
+	seen := make(map[string]bool)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if _, ok := headerAliases[normalizeHeader(name)]; ok || name == "" || seen[name] {
+			continue
+		}
+		if profile, ok := profileColumn(name); ok {
+			if res.profiles == nil {
+				res.profiles = make(map[string]int)
+			}
+			if _, dup := res.profiles[profile]; !dup {
+				res.profiles[profile] = i
+			}
+			continue
+		}
+		seen[name] = true
+		res.CustomColumns = append(res.CustomColumns, name)
+		res.custom = append(res.custom, i)
+	}
+	return res, columns, nil
+}
+
+// add parses the record starting on line and appends it to Vulns, or to
+// Skipped if it is invalid.
+func (res *Result) add(line int, record []string, columns map[Field]int) {
+	v, err := res.parse(line, record, columns)
+	if err != nil {
+		res.Skipped = append(res.Skipped, *err)
+		return
+	}
+	res.Vulns = append(res.Vulns, v)
+}
+
+// parse parses the record starting on line, with the weight profile
+// scores and custom fields of the columns res found in the header.
+func (res *Result) parse(line int, record []string, columns map[Field]int) (vuln.Vulnerability, *RowError) {
+	v, err := parseRecord(record, columns)
+	if err != nil {
+		return v, &RowError{Line: line, Record: record, Err: err}
+	}
+	for profile, i := range res.profiles {
+		if i >= len(record) || strings.TrimSpace(record[i]) == "" {
+			continue
+		}
+		score, err := strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+		if err != nil {
+			return v, &RowError{Line: line, Record: record, Err: fmt.Errorf("priority score (%s): invalid number %q", profile, record[i])}
+		}
+		if v.ProfileScores == nil {
+			v.ProfileScores = make(map[string]float64)
+		}
+		v.ProfileScores[profile] = score
+	}
+	for j, i := range res.custom {
+		if i >= len(record) {
+			break
+		}
+		if cell := strings.TrimSpace(record[i]); cell != "" {
+			if v.CustomFields == nil {
+				v.CustomFields = make(map[string]string)
+			}
+			v.CustomFields[res.CustomColumns[j]] = cell
+		}
+	}
+	return v, nil
+}
+
+// DropCustomFields discards the custom columns and every finding's
+// CustomFields.
+func (res *Result) DropCustomFields() {
+	res.CustomColumns, res.custom = nil, nil
+	for i := range res.Vulns {
+		res.Vulns[i].CustomFields = nil
+	}
+}
+
+// profileColumn reports whether header names a weight profile's score
+// column, as written by the output package: "Priority Score (name)".
+func profileColumn(header string) (name string, ok bool) {
+	const prefix = "priority score ("
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) || !strings.HasSuffix(header, ")") {
+		return "", false
+	}
+	name = strings.TrimSpace(header[len(prefix) : len(header)-1])
+	return name, name != ""
+}
+
+// mapHeader resolves each header cell to a field and returns the column
+// index of every recognised field.
+func mapHeader(header []string) (map[Field]int, error) {
+	columns := make(map[Field]int)
+	for i, name := range header {
+		field, ok := headerAliases[normalizeHeader(name)]
+		if !ok {
+			continue
+		}
+		if _, dup := columns[field]; !dup {
+			columns[field] = i
+		}
+	}
+	var missing []string
+	for _, field := range missingFields(func(f Field) bool { _, ok := columns[f]; return ok }) {
+		missing = append(missing, string(field))
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("header is missing required columns: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+// FieldFor returns the field a header cell maps to, or "" for a column
+// read as a custom field.
+func FieldFor(header string) Field {
+	return headerAliases[normalizeHeader(header)]
+}
+
+// normalizeHeader lowercases name and drops everything but letters and
+// digits, so "Organization/Account", "organization_account" and
+// "OrganizationAccount" all match.
+func normalizeHeader(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func parseRecord(record []string, columns map[Field]int) (vuln.Vulnerability, error) {
+	get := func(f Field) string {
+		if i, ok := columns[f]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	v := vuln.Vulnerability{
+		UniqueID:         get(FieldUniqueID),
+		AssetName:        get(FieldAssetName),
+		AssetID:          get(FieldAssetID),
+		Organization:     get(FieldOrganization),
+		Identifier:       get(FieldIdentifier),
+		Source:           get(FieldSource),
+		Title:            get(FieldTitle),
+		Description:      get(FieldDescription),
+		PackageName:      get(FieldPackageName),
+		RenamedFrom:      get(FieldRenamedFrom),
+		InstalledVersion: get(FieldInstalledVersion),
+		FixedVersion:     get(FieldFixedVersion),
+		Remediation:      get(FieldRemediation),
+		Fixability:       get(FieldFixability),
+		Team:             get(FieldTeam),
+		Owner:            get(FieldOwner),
+		CVSSVector:       get(FieldCVSSVector),
+		CVSSSource:       get(FieldCVSSSource),
+		Region:           get(FieldRegion),
+		OS:               get(FieldOS),
+		OSVersion:        get(FieldOSVersion),
+		State:            get(FieldState),
+		Ticket:           get(FieldTicket),
+		Waiver:           get(FieldWaiver),
+		ActionTimeframe:  get(FieldActionTimeframe),
+	}
+	if v.Identifier == "" {
+		return v, errors.New("missing identifier")
+	}
+	if kb, ok := vuln.ParseKB(v.Identifier); ok {
+		v.Identifier = kb
+	}
+
+	if s := get(FieldCVSS); s != "" {
+		score, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid CVSS %q", s)
+		}
+		if score < 0 || score > 10 {
+			return v, fmt.Errorf("CVSS %v out of range 0-10", score)
+		}
+		v.CVSS = score
+	}
+	if v.CVSSVector != "" {
+		vec, err := cvss.Parse(v.CVSSVector)
+		if err != nil {
+			return v, err
+		}
+		// A vector without a score is scored, so that its finding ranks.
+		if get(FieldCVSS) == "" {
+			v.CVSS = vec.Score()
+		}
+	}
+
+	sev, err := vuln.ParseSeverity(get(FieldSeverity))
+	switch {
+	case err == nil:
+		v.Severity = sev
+	case get(FieldSeverity) == "" && v.CVSSVector != "":
+		v.Severity = vuln.CVSSSeverity(v.CVSS)
+	default:
+		return v, err
+	}
+
+	if v.DueDate, err = parseDate(get(FieldDueDate)); err != nil {
+		return v, fmt.Errorf("invalid due date: %w", err)
+	}
+	if v.FirstDetectedDate, err = parseDate(get(FieldFirstDetectedDate)); err != nil {
+		return v, fmt.Errorf("invalid first detected date: %w", err)
+	}
+	if v.NextPatchOpportunity, err = parseDate(get(FieldNextPatch)); err != nil {
+		return v, fmt.Errorf("invalid next patch opportunity: %w", err)
+	}
+	if v.BreachesSLAOn, err = parseDate(get(FieldBreachesSLAOn)); err != nil {
+		return v, fmt.Errorf("invalid breaches SLA on date: %w", err)
+	}
+
+	for _, f := range []struct {
+		field Field
+		dst   *float64
+	}{
+		{FieldEPSS, &v.EPSS},
+		{FieldEPSSPercentile, &v.EPSSPercentile},
+		{FieldTemporalCVSS, &v.TemporalCVSS},
+		{FieldPriorityScore, &v.PriorityScore},
+		{FieldScoreIn30Days, &v.ScoreIn30Days},
+		{FieldScoreIn60Days, &v.ScoreIn60Days},
+		{FieldScoreIn90Days, &v.ScoreIn90Days},
+	} {
+		if s := get(f.field); s != "" {
+			if *f.dst, err = strconv.ParseFloat(s, 64); err != nil {
+				return v, fmt.Errorf("invalid %s %q", f.field, s)
+			}
+		}
+	}
+	for _, f := range []struct {
+		field Field
+		dst   *bool
+	}{
+		{FieldKEV, &v.KEV},
+		{FieldDiscovered, &v.Discovered},
+		{FieldStaleAsset, &v.StaleAsset},
+		{FieldSuppressed, &v.Suppressed},
+		{FieldOSEndOfLife, &v.OSEndOfLife},
+		{FieldEmergencyChange, &v.EmergencyChange},
+	} {
+		if s := get(f.field); s != "" {
+			if *f.dst, err = strconv.ParseBool(s); err != nil {
+				return v, fmt.Errorf("invalid %s %q", f.field, s)
+			}
+		}
+	}
+	if s := get(FieldConfidence); s != "" {
+		if v.Confidence, err = parseConfidence(s); err != nil {
+			return v, err
+		}
+	}
+	if s := get(FieldImageDigest); s != "" {
+		d, ok := imageref.Digest(s)
+		if !ok {
+			return v, fmt.Errorf("invalid image digest %q", s)
+		}
+		v.ImageDigest = d
+	}
+	if s := get(FieldImageTags); s != "" {
+		v.ImageTags = strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' || r == ' ' })
+	}
+	if s := get(FieldRelatedCVEs); s != "" {
+		v.RelatedCVEs = strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool { return r == ';' || r == ',' || r == ' ' })
+	}
+	if s := get(FieldCWEs); s != "" {
+		if v.CWEs, err = parseCWEs(s); err != nil {
+			return v, err
+		}
+	}
+	if s := get(FieldOSFamily); s != "" {
+		if v.OSFamily, err = platform.ParseFamily(s); err != nil {
+			return v, err
+		}
+	}
+	if s := get(FieldArch); s != "" {
+		if v.Arch, err = platform.ParseArch(s); err != nil {
+			return v, err
+		}
+	}
+	if s := get(FieldClass); s != "" {
+		if v.Class, err = taxonomy.Parse(s); err != nil {
+			return v, err
+		}
+	}
+	if s := get(FieldNoiseReasons); s != "" {
+		for _, reason := range strings.Split(s, ";") {
+			v.NoiseReasons = append(v.NoiseReasons, strings.TrimSpace(reason))
+		}
+	}
+	if s := get(FieldClassification); s != "" {
+		if v.DataClassification, err = vuln.ParseClassification(s); err != nil {
+			return v, err
+		}
+	}
+	if s := get(FieldCriticality); s != "" {
+		if v.Criticality, err = vuln.ParseCriticality(s); err != nil {
+			return v, err
+		}
+	}
+	if s := get(FieldEnvironment); s != "" {
+		if v.Environment, err = vuln.ParseEnvironment(s); err != nil {
+			return v, err
+		}
+	}
+	if s := get(FieldExploit); s != "" {
+		if v.Exploit, err = vuln.ParseExploitMaturity(s); err != nil {
+			return v, err
+		}
+	}
+	switch s := strings.ToLower(get(FieldDependency)); s {
+	case "", vuln.DependencyDirect, vuln.DependencyTransitive:
+		v.Dependency = s
+	default:
+		return v, fmt.Errorf("invalid dependency %q", s)
+	}
+	if s := get(FieldDependencyDepth); s != "" {
+		if v.DependencyDepth, err = strconv.Atoi(s); err != nil {
+			return v, fmt.Errorf("invalid dependency_depth %q", s)
+		}
+	}
+	if s := get(FieldInstances); s != "" {
+		v.Instances = strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' || r == ' ' })
+	}
+	if s := get(FieldOccurrences); s != "" {
+		if v.Occurrences, err = strconv.Atoi(s); err != nil {
+			return v, fmt.Errorf("invalid occurrences %q", s)
+		}
+	}
+	if s := get(FieldEffort); s != "" {
+		if v.Effort, err = strconv.Atoi(s); err != nil {
+			return v, fmt.Errorf("invalid effort %q", s)
+		}
+	}
+	switch s := strings.ToLower(get(FieldSLAStatus)); s {
+	case "", sla.StatusWithin, sla.StatusBreached:
+		v.SLAStatus = s
+	default:
+		return v, fmt.Errorf("invalid sla_status %q", s)
+	}
+	if s := get(FieldDaysOverdue); s != "" {
+		if v.DaysOverdue, err = strconv.Atoi(s); err != nil {
+			return v, fmt.Errorf("invalid days_overdue %q", s)
+		}
+	}
+	if s := get(FieldRemediateWithinDays); s != "" {
+		if v.RemediateWithinDays, err = strconv.Atoi(s); err != nil {
+			return v, fmt.Errorf("invalid remediate_within_days %q", s)
+		}
+	}
+	return v, nil
+}
+
+// confidenceLevels maps scanner confidence words to confidences.
+var confidenceLevels = map[string]float64{
+	"high": 1, "certain": 1,
+	"medium": 0.6, "firm": 0.6,
+	"low": 0.3, "tentative": 0.3,
+}
+
+// parseConfidence parses a scanner confidence: a fraction from 0 to 1, a
+// percentage above 1 (with or without "%"), or a level such as "High" or
+// "Tentative".
+func parseConfidence(s string) (float64, error) {
+	if c, ok := confidenceLevels[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+	pct := strings.HasSuffix(s, "%")
+	c, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+	if err != nil || c < 0 || c > 100 {
+		return 0, fmt.Errorf("invalid confidence %q", s)
+	}
+	if pct || c > 1 {
+		c /= 100
+	}
+	return c, nil
+}
+
+// parseCWEs parses a list of CWE IDs, as in "CWE-79; CWE-89" or "79,89",
+// separated by semicolons, commas or spaces. The NVD's placeholders for an
+// unknown weakness, such as NVD-CWE-noinfo, are dropped.
+func parseCWEs(s string) ([]string, error) {
+	var ids []string
+	for _, f := range strings.FieldsFunc(strings.ToUpper(s), func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+		if strings.HasPrefix(f, "NVD-CWE-") {
+			continue
+		}
+		n := strings.TrimPrefix(f, "CWE-")
+		if _, err := strconv.ParseUint(n, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid CWE %q", f)
+		}
+		ids = append(ids, "CWE-"+n)
+	}
+	return ids, nil
+}
+
+// parseDate parses a date cell in any of dateLayouts, dropping any time of
+// day. An empty cell is the zero Date.
+func parseDate(s string) (vuln.Date, error) {
+	if s == "" {
+		return vuln.Date{}, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return vuln.NewDate(t.Year(), t.Month(), t.Day()), nil
+		}
+	}
+	return vuln.Date{}, fmt.Errorf("unrecognised date %q", s)
+}
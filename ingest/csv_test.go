@@ -0,0 +1,295 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestReadCSVExport(t *testing.T) {
+	f, err := os.Open("testdata/findings.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	res, err := ReadCSV(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Skipped) != 0 {
+		t.Fatalf("skipped %v", res.Skipped)
+	}
+	if len(res.Vulns) != 3 {
+		t.Fatalf("read %d findings, want 3", len(res.Vulns))
+	}
+
+	v := res.Vulns[0]
+	if v.UniqueID != "1" || v.Identifier != "CVE-2024-4367" || v.Source != "github" {
+		t.Errorf("unexpected identity fields: %+v", v)
+	}
+	if v.CVSS != 8.8 || v.Severity != vuln.High {
+		t.Errorf("CVSS, Severity = %v, %v; want 8.8, High", v.CVSS, v.Severity)
+	}
+	if want := vuln.NewDate(2024, 6, 6); !v.DueDate.Equal(want.Time) {
+		t.Errorf("DueDate = %v, want %v", v.DueDate, want)
+	}
+	if want := vuln.NewDate(2024, 5, 7); !v.FirstDetectedDate.Equal(want.Time) {
+		t.Errorf("FirstDetectedDate = %v, want %v", v.FirstDetectedDate, want)
+	}
+	if !strings.Contains(v.Description, "\n") {
+		t.Error("multi-line description was not preserved")
+	}
+	if v.FixedVersion != "4.2.67" || !v.HasFix() {
+		t.Errorf("FixedVersion = %q", v.FixedVersion)
+	}
+
+	if aws := res.Vulns[1]; aws.Source != "aws" || aws.HasFix() {
+		t.Errorf("AWS row: Source = %q, FixedVersion = %q", aws.Source, aws.FixedVersion)
+	}
+}
+
+func TestReadCSVBriefHeaders(t *testing.T) {
+	in := "CVE_Number,Description,CVSS_Score,Severity,Affected_Package,Fixed_Package_Version,Discovery_Date,Audit_Due_Date,Source\n" +
+		"CVE-2024-0001,desc,7.5,high,openssl,3.0.14,2024-05-01,2024-06-01,AWS\n"
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 1 {
+		t.Fatalf("read %d findings, skipped %v", len(res.Vulns), res.Skipped)
+	}
+	v := res.Vulns[0]
+	if v.Identifier != "CVE-2024-0001" || v.PackageName != "openssl" || v.FixedVersion != "3.0.14" || v.Severity != vuln.High {
+		t.Errorf("unexpected mapping: %+v", v)
+	}
+	if v.DueDate.String() != "2024-06-01" || v.FirstDetectedDate.String() != "2024-05-01" {
+		t.Errorf("dates = %v, %v", v.DueDate, v.FirstDetectedDate)
+	}
+}
+
+func TestReadCSVCustomFields(t *testing.T) {
+	in := "Identifier,Cost Center,CVSS,Severity,Patch Window,,Cost Center\n" +
+		"CVE-2024-0001,CC-42,7.5,High,Sun 02:00,x,ignored\n" +
+		"CVE-2024-0002,,5,Low,Sat 22:00,,\n"
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(res.CustomColumns, ","); got != "Cost Center,Patch Window" {
+		t.Errorf("CustomColumns = %q; unnamed and repeated columns should be dropped", got)
+	}
+	if len(res.Vulns) != 2 {
+		t.Fatalf("read %d findings, skipped %v", len(res.Vulns), res.Skipped)
+	}
+	if got := res.Vulns[0].CustomFields; len(got) != 2 || got["Cost Center"] != "CC-42" || got["Patch Window"] != "Sun 02:00" {
+		t.Errorf("row 1 CustomFields = %v", got)
+	}
+	if got := res.Vulns[1].CustomFields; len(got) != 1 || got["Patch Window"] != "Sat 22:00" {
+		t.Errorf("row 2 CustomFields = %v, want the empty cell omitted", got)
+	}
+
+	res.DropCustomFields()
+	if res.CustomColumns != nil || res.Vulns[0].CustomFields != nil {
+		t.Errorf("after DropCustomFields: %v, %v", res.CustomColumns, res.Vulns[0].CustomFields)
+	}
+}
+
+func TestReadCSVVector(t *testing.T) {
+	in := "Identifier,CVSS,Severity,CVSS Vector\n" +
+		"CVE-1,9.8,Critical,CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P\n" +
+		"CVE-2,9.8,Critical,CVSS:3.1/AV:N/E:P\n" // missing base metrics
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 1 || res.Vulns[0].CVSSVector != "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H/E:P" {
+		t.Fatalf("read %+v", res.Vulns)
+	}
+	if len(res.Skipped) != 1 || !errors.Is(res.Skipped[0].Err, cvss.ErrInvalidVector) {
+		t.Errorf("skipped %v, want the CVE-2 row as an invalid vector", res.Skipped)
+	}
+}
+
+func TestReadCSVVectorOnly(t *testing.T) {
+	in := "Identifier,Severity,Vector\n" +
+		"CVE-1,High,CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H\n" +
+		"CVE-2,,CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N\n" +
+		"CVE-3,,\n" // neither a severity nor a vector
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 || len(res.Skipped) != 1 {
+		t.Fatalf("read %+v, skipped %v", res.Vulns, res.Skipped)
+	}
+	if v := res.Vulns[0]; v.CVSS != 7.5 || v.Severity != vuln.High {
+		t.Errorf("v3.1 row: CVSS %v, severity %s", v.CVSS, v.Severity)
+	}
+	if v := res.Vulns[1]; v.CVSS != 9.3 || v.Severity != vuln.Critical {
+		t.Errorf("v4.0 row: CVSS %v, severity %s", v.CVSS, v.Severity)
+	}
+	if _, err := ReadCSV(strings.NewReader("Identifier,Severity\nCVE-1,High\n")); err == nil {
+		t.Error("header without CVSS or vector accepted")
+	}
+}
+
+func TestReadCSVKB(t *testing.T) {
+	in := "KB Article,CVSS,Severity,Related CVEs\n" +
+		"kb 5034441,,High,cve-2024-21302; CVE-2024-20666\n" +
+		"CVE-2024-1,5.0,Medium,\n"
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 {
+		t.Fatalf("read %+v, skipped %v", res.Vulns, res.Skipped)
+	}
+	if v := res.Vulns[0]; v.Identifier != "KB5034441" || strings.Join(v.RelatedCVEs, ",") != "CVE-2024-21302,CVE-2024-20666" {
+		t.Errorf("KB row read as %q %q", v.Identifier, v.RelatedCVEs)
+	}
+	if v := res.Vulns[1]; v.Identifier != "CVE-2024-1" || v.RelatedCVEs != nil {
+		t.Errorf("CVE row read as %q %q", v.Identifier, v.RelatedCVEs)
+	}
+}
+
+func TestReadCSVClassification(t *testing.T) {
+	in := "Identifier,CVSS,Severity,Data Classification\n" +
+		"CVE-1,5.0,High,Restricted\n" +
+		"CVE-2,5.0,High,\n" +
+		"CVE-3,5.0,High,secret\n"
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 || res.Vulns[0].DataClassification != vuln.ClassRestricted || res.Vulns[1].DataClassification != "" {
+		t.Errorf("read %+v", res.Vulns)
+	}
+	if len(res.Skipped) != 1 || !strings.Contains(res.Skipped[0].Err.Error(), "unknown data classification") {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
+
+func TestReadCSVClassAndCWEs(t *testing.T) {
+	in := "Identifier,CVSS,Severity,Vulnerability Class,CWE IDs\n" +
+		"CVE-1,5.0,High,Remote Code Execution,\"CWE-94; 78\"\n" +
+		"CVE-2,5.0,High,,NVD-CWE-noinfo\n" +
+		"CVE-3,5.0,High,xss,\n" +
+		"CVE-4,5.0,High,dos,CWE-four\n"
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 || res.Vulns[0].Class != "rce" || strings.Join(res.Vulns[0].CWEs, ",") != "CWE-94,CWE-78" ||
+		res.Vulns[1].Class != "" || res.Vulns[1].CWEs != nil {
+		t.Errorf("read %+v", res.Vulns)
+	}
+	if len(res.Skipped) != 2 || !strings.Contains(res.Skipped[0].Err.Error(), "unknown vulnerability class") ||
+		!strings.Contains(res.Skipped[1].Err.Error(), "invalid CWE") {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
+
+func TestReadCSVPlatform(t *testing.T) {
+	in := "Identifier,CVSS,Severity,OS Family,OS Version,Architecture\n" +
+		"CVE-1,5.0,High,Amazon Linux,2023,arm64\n" +
+		"CVE-2,5.0,High,,,\n" +
+		"CVE-3,5.0,High,BeOS,5,\n" +
+		"CVE-4,5.0,High,debian,12,sparc\n"
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 || res.Vulns[0].OSFamily != "amzn" || res.Vulns[0].OSVersion != "2023" || res.Vulns[0].Arch != "aarch64" ||
+		res.Vulns[1].OSFamily != "" || res.Vulns[1].Arch != "" {
+		t.Errorf("read %+v", res.Vulns)
+	}
+	if len(res.Skipped) != 2 || !strings.Contains(res.Skipped[0].Err.Error(), "unknown OS family") ||
+		!strings.Contains(res.Skipped[1].Err.Error(), "unknown architecture") {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
+
+func TestReadCSVSkipsMalformedRows(t *testing.T) {
+	in := "Identifier,CVSS,Severity,Due date\n" +
+		"CVE-1,5.0,Medium,3/7/2025\n" +
+		",5.0,Medium,3/7/2025\n" + // missing identifier
+		"CVE-3,abc,High,3/7/2025\n" + // bad CVSS
+		"CVE-4,11,High,3/7/2025\n" + // CVSS out of range
+		"CVE-5,5.0,Severe,3/7/2025\n" + // unknown severity
+		"CVE-6,5.0,Low,31/31/2025\n" + // bad date
+		"CVE-7,5.0,Low\n" + // wrong field count
+		"CVE-8,,Low,\n" // blank CVSS and date are allowed
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, v := range res.Vulns {
+		got = append(got, v.Identifier)
+	}
+	if strings.Join(got, ",") != "CVE-1,CVE-8" {
+		t.Errorf("kept %v, want [CVE-1 CVE-8]", got)
+	}
+
+	wantLines := []int{3, 4, 5, 6, 7, 8}
+	if len(res.Skipped) != len(wantLines) {
+		t.Fatalf("skipped %d rows, want %d: %v", len(res.Skipped), len(wantLines), res.Skipped)
+	}
+	for i, s := range res.Skipped {
+		if s.Line != wantLines[i] {
+			t.Errorf("skipped[%d].Line = %d, want %d (%v)", i, s.Line, wantLines[i], s.Err)
+		}
+		if len(s.Record) == 0 {
+			t.Errorf("skipped[%d] has no record", i)
+		}
+	}
+}
+
+func TestReadCSVMissingColumns(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("Identifier,Title\nCVE-1,x\n"))
+	if err == nil || !strings.Contains(err.Error(), "cvss") || !strings.Contains(err.Error(), "severity") {
+		t.Errorf("err = %v, want missing cvss and severity", err)
+	}
+	if _, err := ReadCSV(strings.NewReader("")); err == nil {
+		t.Error("empty input: want error")
+	}
+}
+
+func TestWriteQuarantine(t *testing.T) {
+	in := "Identifier,CVSS,Severity,Description\n" +
+		"CVE-1,5.0,Medium,ok\n" +
+		"CVE-2,5.0,Severe,\"first line\nsecond, line\"\n" + // unknown severity, multi-line cell
+		"CVE-3,5.0\n" // wrong field count
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	if err := WriteQuarantine(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	got, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("quarantine is not valid CSV: %v\n%s", err, buf.String())
+	}
+	if len(got) != 3 {
+		t.Fatalf("quarantine has %d rows, want header + 2:\n%s", len(got), buf.String())
+	}
+	if h := got[0]; len(h) != 5 || h[4] != QuarantineReasonColumn {
+		t.Errorf("header = %q", h)
+	}
+	if r := got[1]; r[3] != "first line\nsecond, line" || !strings.Contains(r[4], "line 3") || !strings.Contains(r[4], "Severe") {
+		t.Errorf("row 1 = %q", r)
+	}
+	if r := got[2]; len(r) != 5 || r[0] != "CVE-3" || r[2] != "" || !strings.Contains(r[4], "line 5") {
+		t.Errorf("row 2 = %q", r)
+	}
+}
@@ -0,0 +1,103 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Input formats.
+const (
+	FormatCSV   = "csv"
+	FormatXLSX  = "xlsx"
+	FormatSARIF = "sarif"
+	FormatTrivy = "trivy"
+	FormatGrype = "grype"
+	// FormatInspector is an Amazon Inspector ListFindings response, as
+	// aws inspector2 list-findings prints it.
+	FormatInspector = "inspector"
+	// FormatSecurityHub is a Security Hub GetFindings response, as aws
+	// securityhub get-findings prints it.
+	FormatSecurityHub = "securityhub"
+	// FormatBundle is a ZIP or gzipped tar archive of inputs in any of
+	// the other formats.
+	FormatBundle = "bundle"
+)
+
+// Formats are the input formats, for flag help.
+var Formats = []string{FormatCSV, FormatXLSX, FormatSARIF, FormatTrivy, FormatGrype, FormatInspector, FormatSecurityHub, FormatBundle}
+
+// DetectBytes is how much of an input DetectFormat needs.
+const DetectBytes = 64 << 10
+
+// DetectFormat returns the format of the input named name from its
+// extension, or else its first bytes, up to DetectBytes of them. Files
+// named .xlsx are FormatXLSX, .sarif and .sarif.json FormatSARIF, and
+// .zip, .tar.gz and .tgz FormatBundle. Otherwise a gzip stream is
+// FormatBundle, a ZIP archive FormatXLSX, and a JSON object FormatTrivy
+// when it has Trivy's SchemaVersion or ArtifactName, FormatSARIF when it
+// has a SARIF $schema or version and runs, FormatGrype when it has
+// Grype's matches, and FormatInspector or FormatSecurityHub when it has
+// Inspector's findings, with their findingArn, or Security Hub's Findings,
+// with their ProductArn. Any other input is FormatCSV. It returns "" for
+// JSON it does not recognise.
+func DetectFormat(name string, head []byte) string {
+	switch name = strings.ToLower(name); {
+	case strings.HasSuffix(name, ".xlsx"):
+		return FormatXLSX
+	case strings.HasSuffix(name, ".sarif"), strings.HasSuffix(name, ".sarif.json"):
+		return FormatSARIF
+	case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatBundle
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return FormatBundle
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return FormatXLSX
+	}
+	head = bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\ufeff")), " \t\r\n")
+	if len(head) == 0 || head[0] != '{' {
+		return FormatCSV
+	}
+	keys := topLevelKeys(head)
+	switch {
+	case keys["SchemaVersion"] != nil || keys["ArtifactName"] != nil:
+		return FormatTrivy
+	case bytes.Contains(keys["$schema"], []byte("sarif")), keys["version"] != nil && keys["runs"] != nil:
+		return FormatSARIF
+	case keys["matches"] != nil:
+		return FormatGrype
+	case keys["findings"] != nil && bytes.Contains(head, []byte(`"findingArn"`)):
+		return FormatInspector
+	case keys["Findings"] != nil && bytes.Contains(head, []byte(`"ProductArn"`)):
+		return FormatSecurityHub
+	}
+	return ""
+}
+
+// topLevelKeys returns the members of the JSON object head starts with,
+// as far as head holds them. A member cut off by the end of head has an
+// empty value.
+func topLevelKeys(head []byte) map[string]json.RawMessage {
+	keys := make(map[string]json.RawMessage)
+	dec := json.NewDecoder(bytes.NewReader(head))
+	if _, err := dec.Token(); err != nil {
+		return keys
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		key, ok := t.(string)
+		if err != nil || !ok {
+			break
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			// A value cut off by the end of head: the key is there.
+			keys[key] = json.RawMessage{}
+			break
+		}
+		keys[key] = value
+	}
+	return keys
+}
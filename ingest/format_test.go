@@ -0,0 +1,71 @@
+package ingest
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	sarif, err := os.ReadFile("testdata/codeql.sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trivy, err := os.ReadFile("testdata/trivy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grype, err := os.ReadFile("testdata/grype.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inspector, err := os.ReadFile("testdata/inspector.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	securityHub, err := os.ReadFile("testdata/securityhub.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tt := range map[string]struct {
+		head string
+		want string
+	}{
+		"CSV":             {"Unique ID,Asset name\n1,a\n", FormatCSV},
+		"CSV with BOM":    {"\ufeffIdentifier,CVSS\n", FormatCSV},
+		"workbook":        {"PK\x03\x04\x14\x00", FormatXLSX},
+		"gzip":            {"\x1f\x8b\x08\x00", FormatBundle},
+		"SARIF":           {string(sarif), FormatSARIF},
+		"SARIF no schema": {`{"version": "2.1.0", "runs": [`, FormatSARIF},
+		"Trivy":           {string(trivy), FormatTrivy},
+		// Only the start of a long report is read.
+		"Trivy prefix": {string(trivy[:120]), FormatTrivy},
+		"Grype":        {string(grype), FormatGrype},
+		"Grype prefix": {string(grype[:200]), FormatGrype},
+		"Inspector":    {string(inspector), FormatInspector},
+		"Security Hub": {string(securityHub), FormatSecurityHub},
+		"other JSON":   {`{"findings": []}`, ""},
+	} {
+		if got := DetectFormat("input", []byte(tt.head)); got != tt.want {
+			t.Errorf("%s: DetectFormat = %q, want %q", name, got, tt.want)
+		}
+	}
+	// The extension decides first, so a broken workbook is reported as one.
+	for name, want := range map[string]string{
+		"Export.XLSX":        FormatXLSX,
+		"codeql.sarif":       FormatSARIF,
+		"semgrep.sarif.json": FormatSARIF,
+		"export.csv":         FormatCSV,
+		"nightly.zip":        FormatBundle,
+		"nightly.tar.gz":     FormatBundle,
+		"nightly.TGZ":        FormatBundle,
+	} {
+		if got := DetectFormat(name, []byte("Identifier,CVSS\n")); got != want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if !strings.HasPrefix(string(trivy), `{
+  "SchemaVersion"`) {
+		t.Error("testdata/trivy.json does not start as Trivy writes reports")
+	}
+}
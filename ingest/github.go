@@ -0,0 +1,330 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// dependabotHeader and codeScanningHeader are the Result.Header of
+// Dependabot and code scanning alerts: the fields a skipped alert is
+// quarantined with.
+var (
+	dependabotHeader   = []string{"alert", "advisory", "package", "severity"}
+	codeScanningHeader = []string{"alert", "rule", "location", "severity"}
+)
+
+// githubSource is the Source of Dependabot alerts, as in the GitHub rows
+// of an export.
+const githubSource = "github"
+
+// GitHubRepository is a repository as the GitHub REST API describes it.
+// An organization's alerts name theirs; a repository's alerts do not.
+type GitHubRepository struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// githubPrefixes maps Dependabot's ecosystems to the package-name prefixes
+// that mark them; see vuln.Vulnerability.Ecosystem.
+var githubPrefixes = map[string]string{"npm": "npm-", "pip": "pip-", "go": "go-", "maven": "maven-"}
+
+type dependabotAlert struct {
+	Number     int               `json:"number"`
+	HTMLURL    string            `json:"html_url"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Repository *GitHubRepository `json:"repository"`
+	Dependency struct {
+		Package struct {
+			Ecosystem string `json:"ecosystem"`
+			Name      string `json:"name"`
+		} `json:"package"`
+		Relationship string `json:"relationship"`
+	} `json:"dependency"`
+	Advisory struct {
+		GHSAID      string `json:"ghsa_id"`
+		CVEID       string `json:"cve_id"`
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Severity    string `json:"severity"`
+		Identifiers []struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		} `json:"identifiers"`
+		CVSS           githubCVSS `json:"cvss"`
+		CVSSSeverities struct {
+			V3 githubCVSS `json:"cvss_v3"`
+			V4 githubCVSS `json:"cvss_v4"`
+		} `json:"cvss_severities"`
+		CWEs []struct {
+			ID string `json:"cwe_id"`
+		} `json:"cwes"`
+	} `json:"security_advisory"`
+	Vulnerability struct {
+		Severity            string `json:"severity"`
+		VulnerableRange     string `json:"vulnerable_version_range"`
+		FirstPatchedVersion *struct {
+			Identifier string `json:"identifier"`
+		} `json:"first_patched_version"`
+	} `json:"security_vulnerability"`
+}
+
+type githubCVSS struct {
+	Score  float64 `json:"score"`
+	Vector string  `json:"vector_string"`
+}
+
+// ReadDependabot reads Dependabot alerts, as the GitHub REST API lists
+// them for a repository or an organization, with one finding per alert.
+// repo is the repository of alerts that do not name theirs.
+//
+//   - UniqueID is the alert's URL. Identifier is the advisory's CVE, or
+//     else its GHSA ID, and Source "github".
+//   - AssetName and AssetID are the repository's name and numeric ID, and
+//     Organization its owner, as in the GitHub rows of an export.
+//   - PackageName is the dependency, prefixed with its ecosystem for npm,
+//     pip, Go and Maven packages, as in "npm-braces". InstalledVersion is
+//     the vulnerable version range, as Dependabot does not report the
+//     installed version, and FixedVersion the first patched version.
+//   - CVSS and CVSSVector come from the newest CVSS version the advisory
+//     has, and Severity from the vulnerability, else the advisory.
+//   - FirstDetectedDate is the day the alert was created, and Dependency
+//     the dependency's relationship, direct or transitive, when known.
+//
+// Alerts without an advisory ID or a package, or with a severity GitHub
+// does not define, are skipped, with RowError.Line holding their 1-based
+// position.
+func ReadDependabot(r io.Reader, repo *GitHubRepository) (*Result, error) {
+	var alerts []dependabotAlert
+	if err := json.NewDecoder(r).Decode(&alerts); err != nil {
+		return nil, fmt.Errorf("Dependabot alerts: %w", err)
+	}
+	res := &Result{Header: dependabotHeader}
+	for i := range alerts {
+		a := &alerts[i]
+		v, err := dependabotFinding(a, firstRepo(a.Repository, repo))
+		if err != nil {
+			res.Skipped = append(res.Skipped, RowError{Line: i + 1, Record: []string{a.HTMLURL, firstNonEmpty(a.Advisory.CVEID, a.Advisory.GHSAID), a.Dependency.Package.Name, a.Advisory.Severity}, Err: err})
+			continue
+		}
+		res.Vulns = append(res.Vulns, v)
+	}
+	return res, nil
+}
+
+func dependabotFinding(a *dependabotAlert, repo *GitHubRepository) (vuln.Vulnerability, error) {
+	adv := &a.Advisory
+	v := vuln.Vulnerability{
+		UniqueID:         a.HTMLURL,
+		Identifier:       firstNonEmpty(adv.CVEID, adv.GHSAID),
+		Source:           githubSource,
+		Title:            firstNonEmpty(adv.Summary, firstLine(adv.Description)),
+		Description:      adv.Description,
+		PackageName:      strings.TrimSpace(a.Dependency.Package.Name),
+		InstalledVersion: strings.TrimSpace(a.Vulnerability.VulnerableRange),
+	}
+	if v.Identifier == "" {
+		return v, errors.New("missing advisory ID")
+	}
+	if v.PackageName == "" {
+		return v, errors.New("missing package")
+	}
+	v.PackageName = githubPrefixes[strings.ToLower(a.Dependency.Package.Ecosystem)] + v.PackageName
+	setRepository(&v, repo)
+	if v.UniqueID == "" && repo != nil {
+		v.UniqueID = fmt.Sprintf("%s/security/dependabot/%d", repo.FullName, a.Number)
+	}
+	isCVE := strings.HasPrefix(strings.ToUpper(v.Identifier), "CVE-")
+	for _, id := range adv.Identifiers {
+		if !isCVE && strings.EqualFold(id.Type, "CVE") {
+			v.RelatedCVEs = append(v.RelatedCVEs, strings.ToUpper(strings.TrimSpace(id.Value)))
+		}
+	}
+	if p := a.Vulnerability.FirstPatchedVersion; p != nil && strings.TrimSpace(p.Identifier) != "" {
+		v.FixedVersion = strings.TrimSpace(p.Identifier)
+		v.Fixability = "Fixable"
+		v.Remediation = v.UpgradeRemediation()
+	}
+	for _, c := range []githubCVSS{adv.CVSSSeverities.V4, adv.CVSSSeverities.V3, adv.CVSS} {
+		if c.Score > 0 {
+			if c.Score > 10 {
+				return v, fmt.Errorf("CVSS %v out of range 0-10", c.Score)
+			}
+			v.CVSS, v.CVSSVector = c.Score, strings.TrimSpace(c.Vector)
+			break
+		}
+	}
+	s, err := vuln.ParseSeverity(firstNonEmpty(a.Vulnerability.Severity, adv.Severity))
+	if err != nil {
+		return v, err
+	}
+	v.Severity = s
+	for _, c := range adv.CWEs {
+		if id := githubCWE(c.ID); id != "" {
+			v.CWEs = append(v.CWEs, id)
+		}
+	}
+	if rel := strings.ToLower(a.Dependency.Relationship); rel == vuln.DependencyDirect || rel == vuln.DependencyTransitive {
+		v.Dependency = rel
+	}
+	v.FirstDetectedDate = githubDate(a.CreatedAt)
+	return v, nil
+}
+
+type codeScanningAlert struct {
+	Number     int               `json:"number"`
+	HTMLURL    string            `json:"html_url"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Repository *GitHubRepository `json:"repository"`
+	Rule       struct {
+		ID                    string   `json:"id"`
+		Name                  string   `json:"name"`
+		Severity              string   `json:"severity"`
+		SecuritySeverityLevel string   `json:"security_severity_level"`
+		Description           string   `json:"description"`
+		FullDescription       string   `json:"full_description"`
+		Help                  string   `json:"help"`
+		Tags                  []string `json:"tags"`
+	} `json:"rule"`
+	Tool struct {
+		Name string `json:"name"`
+	} `json:"tool"`
+	Instance struct {
+		Message struct {
+			Text string `json:"text"`
+		} `json:"message"`
+		Location struct {
+			Path string `json:"path"`
+		} `json:"location"`
+	} `json:"most_recent_instance"`
+}
+
+// ReadCodeScanning reads code scanning alerts, as the GitHub REST API
+// lists them for a repository or an organization, with one finding per
+// alert, read as for ReadSARIF: Identifier is the rule ID, Source the
+// tool's name in lower case and PackageName the file of the alert's most
+// recent instance. The asset is the repository, as for ReadDependabot, and
+// UniqueID the alert's URL. Severity is the rule's security severity, or
+// else follows from its level as in SARIF: error is High, warning Medium,
+// note Low. CWEs come from the rule's tags, and FirstDetectedDate is the
+// day the alert was created.
+//
+// Alerts without a rule ID, or with a severity GitHub does not define,
+// are skipped, with RowError.Line holding their 1-based position.
+func ReadCodeScanning(r io.Reader, repo *GitHubRepository) (*Result, error) {
+	var alerts []codeScanningAlert
+	if err := json.NewDecoder(r).Decode(&alerts); err != nil {
+		return nil, fmt.Errorf("code scanning alerts: %w", err)
+	}
+	res := &Result{Header: codeScanningHeader}
+	for i := range alerts {
+		a := &alerts[i]
+		v, err := codeScanningFinding(a, firstRepo(a.Repository, repo))
+		if err != nil {
+			res.Skipped = append(res.Skipped, RowError{Line: i + 1, Record: []string{a.HTMLURL, a.Rule.ID, a.Instance.Location.Path, firstNonEmpty(a.Rule.SecuritySeverityLevel, a.Rule.Severity)}, Err: err})
+			continue
+		}
+		res.Vulns = append(res.Vulns, v)
+	}
+	return res, nil
+}
+
+func codeScanningFinding(a *codeScanningAlert, repo *GitHubRepository) (vuln.Vulnerability, error) {
+	v := vuln.Vulnerability{
+		UniqueID:    a.HTMLURL,
+		Identifier:  strings.TrimSpace(a.Rule.ID),
+		Source:      strings.ToLower(strings.TrimSpace(a.Tool.Name)),
+		Title:       firstNonEmpty(a.Rule.Description, a.Rule.Name, firstLine(a.Instance.Message.Text)),
+		Description: firstNonEmpty(a.Instance.Message.Text, a.Rule.FullDescription),
+		PackageName: a.Instance.Location.Path,
+		Remediation: strings.TrimSpace(a.Rule.Help),
+	}
+	if v.Identifier == "" {
+		return v, errors.New("missing rule ID")
+	}
+	setRepository(&v, repo)
+	if v.UniqueID == "" && repo != nil {
+		v.UniqueID = fmt.Sprintf("%s/security/code-scanning/%d", repo.FullName, a.Number)
+	}
+	if level := a.Rule.SecuritySeverityLevel; level != "" {
+		s, err := vuln.ParseSeverity(level)
+		if err != nil {
+			return v, err
+		}
+		v.Severity = s
+	} else {
+		switch level := firstNonEmpty(a.Rule.Severity, "warning"); level {
+		case "error":
+			v.Severity = vuln.High
+		case "warning":
+			v.Severity = vuln.Medium
+		case "note", "none":
+			v.Severity = vuln.Low
+		default:
+			return v, fmt.Errorf("invalid severity %q", level)
+		}
+	}
+	for _, tag := range a.Rule.Tags {
+		if id, ok := strings.CutPrefix(tag, "external/cwe/"); ok {
+			if id = githubCWE(id); id != "" {
+				v.CWEs = append(v.CWEs, id)
+			}
+		}
+	}
+	v.FirstDetectedDate = githubDate(a.CreatedAt)
+	return v, nil
+}
+
+// firstRepo returns the first repository that is set.
+func firstRepo(repos ...*GitHubRepository) *GitHubRepository {
+	for _, r := range repos {
+		if r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// setRepository makes repo v's asset: its name and numeric ID, owned by
+// the organization or user that owns it.
+func setRepository(v *vuln.Vulnerability, repo *GitHubRepository) {
+	if repo == nil {
+		return
+	}
+	v.AssetName = repo.Name
+	if repo.ID > 0 {
+		v.AssetID = strconv.FormatInt(repo.ID, 10)
+	} else {
+		v.AssetID = repo.FullName
+	}
+	v.Organization = repo.Owner.Login
+}
+
+// githubCWE normalises a CWE ID as GitHub writes them, as in "CWE-79" or
+// "cwe-079", to "CWE-79", or returns "" if id is not one.
+func githubCWE(id string) string {
+	n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(id)), "CWE-"), 10, 32)
+	if err != nil {
+		return ""
+	}
+	return "CWE-" + strconv.FormatUint(n, 10)
+}
+
+// githubDate returns the day of t, or the zero Date for the zero time.
+func githubDate(t time.Time) vuln.Date {
+	if t.IsZero() {
+		return vuln.Date{}
+	}
+	t = t.UTC()
+	return vuln.NewDate(t.Year(), t.Month(), t.Day())
+}
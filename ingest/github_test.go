@@ -0,0 +1,90 @@
+package ingest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestReadDependabot(t *testing.T) {
+	f, err := os.Open("testdata/dependabot.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := ReadDependabot(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 {
+		t.Fatalf("read %d findings: %+v", len(res.Vulns), res.Vulns)
+	}
+
+	// As the GitHub rows of an export.
+	pdf := res.Vulns[0]
+	if pdf.UniqueID != "https://github.com/acme-inc-Education/apply-frontend/security/dependabot/42" || pdf.Identifier != "CVE-2024-4367" || pdf.Source != "github" || pdf.Severity != vuln.High {
+		t.Errorf("finding %+v", pdf)
+	}
+	if pdf.AssetName != "apply-frontend" || pdf.AssetID != "464322508" || pdf.Organization != "acme-inc-Education" {
+		t.Errorf("repository %+v", pdf)
+	}
+	if pdf.PackageName != "npm-pdfjs-dist" || pdf.InstalledVersion != "<= 4.1.392" || pdf.FixedVersion != "4.2.67" || pdf.Fixability != "Fixable" ||
+		pdf.Remediation != "Update npm-pdfjs-dist from <= 4.1.392 to 4.2.67." || pdf.Dependency != vuln.DependencyDirect {
+		t.Errorf("package %+v", pdf)
+	}
+	if pdf.CVSS != 8.6 || !strings.HasPrefix(pdf.CVSSVector, "CVSS:4.0/") || strings.Join(pdf.CWEs, ",") != "CWE-754" || pdf.FirstDetectedDate != vuln.NewDate(2024, 5, 8) || len(pdf.RelatedCVEs) != 0 {
+		t.Errorf("advisory %+v", pdf)
+	}
+
+	// Without a CVE ID, the GHSA ID, with the CVE it lists related.
+	jinja := res.Vulns[1]
+	if jinja.Identifier != "GHSA-q2x7-8rv6-6q7h" || strings.Join(jinja.RelatedCVEs, ",") != "CVE-2025-27516" || jinja.PackageName != "pip-jinja2" || jinja.Ecosystem() != vuln.EcosystemPip {
+		t.Errorf("GHSA %+v", jinja)
+	}
+	if jinja.UniqueID != "acme-inc-Education/course-api/security/dependabot/7" || jinja.FixedVersion != "" || jinja.Fixability != "" || jinja.Dependency != vuln.DependencyTransitive || jinja.CVSS != 5.4 || jinja.Severity != vuln.Medium {
+		t.Errorf("unpatched %+v", jinja)
+	}
+
+	if len(res.Skipped) != 1 || res.Skipped[0].Line != 3 || len(res.Skipped[0].Record) != len(res.Header) || res.Skipped[0].Err.Error() != "missing package" {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
+
+func TestReadCodeScanning(t *testing.T) {
+	f, err := os.Open("testdata/codescanning.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	repo := &GitHubRepository{ID: 464322508, Name: "apply-frontend", FullName: "acme-inc-Education/apply-frontend"}
+	repo.Owner.Login = "acme-inc-Education"
+	res, err := ReadCodeScanning(f, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 {
+		t.Fatalf("read %d findings: %+v", len(res.Vulns), res.Vulns)
+	}
+
+	path := res.Vulns[0]
+	if path.Identifier != "js/path-injection" || path.Source != "codeql" || path.Severity != vuln.High || path.PackageName != "server/routes/download.js" {
+		t.Errorf("finding %+v", path)
+	}
+	if path.AssetName != "apply-frontend" || path.AssetID != "464322508" || path.Organization != "acme-inc-Education" || path.FirstDetectedDate != vuln.NewDate(2025, 1, 14) {
+		t.Errorf("repository %+v", path)
+	}
+	if path.Title != "Uncontrolled data used in path expression" || path.Description != "This path depends on a user-provided value." ||
+		!strings.HasPrefix(path.Remediation, "# Uncontrolled data") || strings.Join(path.CWEs, ",") != "CWE-22,CWE-23" {
+		t.Errorf("rule %+v", path)
+	}
+	// Without a security severity, the level decides.
+	if unused := res.Vulns[1]; unused.Severity != vuln.Low || len(unused.CWEs) != 0 {
+		t.Errorf("note %+v", unused)
+	}
+
+	if len(res.Skipped) != 1 || res.Skipped[0].Line != 3 || res.Skipped[0].Err.Error() != "missing rule ID" {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
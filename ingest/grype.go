@@ -0,0 +1,233 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/imageref"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// grypeHeader is the Result.Header of a Grype input: the fields a skipped
+// match is quarantined with.
+var grypeHeader = []string{"vulnerability", "artifact", "version", "severity"}
+
+// grypeReport is the subset of a Grype JSON report that findings are read
+// from.
+type grypeReport struct {
+	Matches []grypeMatch `json:"matches"`
+	Source  struct {
+		Type   string          `json:"type"`
+		Target json.RawMessage `json:"target"`
+	} `json:"source"`
+	Distro struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"distro"`
+	Descriptor struct {
+		Name      string `json:"name"`
+		Timestamp string `json:"timestamp"`
+	} `json:"descriptor"`
+}
+
+// grypeImage is the target of an image source. Directory and file sources
+// have their path as the target instead.
+type grypeImage struct {
+	UserInput      string   `json:"userInput"`
+	ManifestDigest string   `json:"manifestDigest"`
+	Tags           []string `json:"tags"`
+	RepoDigests    []string `json:"repoDigests"`
+	Architecture   string   `json:"architecture"`
+}
+
+type grypeMatch struct {
+	Vulnerability struct {
+		grypeRecord
+		Severity string `json:"severity"`
+		Fix      struct {
+			Versions []string `json:"versions"`
+			State    string   `json:"state"`
+		} `json:"fix"`
+	} `json:"vulnerability"`
+	RelatedVulnerabilities []grypeRecord `json:"relatedVulnerabilities"`
+	Artifact               struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Type    string `json:"type"`
+	} `json:"artifact"`
+}
+
+// grypeRecord is a vulnerability record, of the match or related to it.
+type grypeRecord struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	CVSS        []struct {
+		Version string `json:"version"`
+		Vector  string `json:"vector"`
+		Metrics struct {
+			BaseScore float64 `json:"baseScore"`
+		} `json:"metrics"`
+	} `json:"cvss"`
+}
+
+// grypePrefixes maps Grype's language package types to the package-name
+// prefixes that mark their ecosystem; see vuln.Vulnerability.Ecosystem.
+var grypePrefixes = map[string]string{
+	"npm": "npm-", "go-module": "go-", "python": "pip-", "java-archive": "maven-",
+}
+
+// ReadGrype reads a Grype JSON report (grype -o json), with one finding per
+// match. A report of a Syft SBOM (grype sbom:sbom.json) reads the same way.
+//
+//   - Identifier is the vulnerability ID, and Source "grype". A GitHub
+//     advisory or distribution ID has the CVEs of its related
+//     vulnerabilities as RelatedCVEs.
+//   - AssetName and AssetID are the scanned image as given to Grype, or the
+//     directory or file. An image's ImageDigest and ImageTags come from its
+//     repository digests and tags, so its findings are keyed by digest; see
+//     package imageref.
+//   - PackageName is the artifact's name, prefixed with its ecosystem for
+//     language packages, as in "npm-axios", and InstalledVersion its
+//     version. FixedVersion is the first fix version of a fixed match.
+//   - CVSS and CVSSVector come from the vulnerability's newest CVSS version,
+//     else its related vulnerabilities'. A version with a vector but no
+//     score gets the vector's.
+//   - Severity is Grype's; Negligible is Low, and Unknown follows from the
+//     CVSS score.
+//   - FirstDetectedDate is the day of the scan.
+//   - OS, OSFamily and OSVersion come from the distro, and an image's Arch
+//     from its architecture.
+//
+// Matches without a vulnerability ID or with an unknown severity are
+// skipped, with RowError.Line holding their 1-based position in the report.
+// An error is returned only when the report cannot be read at all.
+func ReadGrype(r io.Reader) (*Result, error) {
+	var rep grypeReport
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("Grype: %w", err)
+	}
+	if rep.Descriptor.Name != "" && rep.Descriptor.Name != FormatGrype {
+		return nil, fmt.Errorf("Grype: report written by %q", rep.Descriptor.Name)
+	}
+	var (
+		asset, digest string
+		tags          []string
+		image         grypeImage
+	)
+	if json.Unmarshal(rep.Source.Target, &asset) != nil && json.Unmarshal(rep.Source.Target, &image) == nil {
+		asset = image.UserInput
+		for _, d := range append(image.RepoDigests, image.ManifestDigest) {
+			if i := strings.LastIndexByte(d, '@'); i >= 0 {
+				d = d[i+1:]
+			}
+			if digest, _ = imageref.Digest(d); digest != "" {
+				break
+			}
+		}
+		tags = repoTags(image.Tags)
+	}
+	var detected vuln.Date
+	if t, err := time.Parse(time.RFC3339, rep.Descriptor.Timestamp); err == nil {
+		detected = vuln.NewDate(t.Year(), t.Month(), t.Day())
+	}
+
+	res := &Result{Header: grypeHeader}
+	for i := range rep.Matches {
+		m := &rep.Matches[i]
+		v, err := grypeFinding(m)
+		if err != nil {
+			res.Skipped = append(res.Skipped, RowError{Line: i + 1, Record: []string{m.Vulnerability.ID, m.Artifact.Name, m.Artifact.Version, m.Vulnerability.Severity}, Err: err})
+			continue
+		}
+		v.UniqueID = fmt.Sprintf("%s-%d", FormatGrype, i+1)
+		v.AssetName, v.AssetID = asset, asset
+		if rep.Source.Type == "image" {
+			v.ImageDigest, v.ImageTags = digest, tags
+		}
+		v.FirstDetectedDate = detected
+		setPlatform(&v, rep.Distro.Name, rep.Distro.Version, image.Architecture)
+		res.Vulns = append(res.Vulns, v)
+	}
+	return res, nil
+}
+
+// grypeFinding maps one match.
+func grypeFinding(m *grypeMatch) (vuln.Vulnerability, error) {
+	gv := &m.Vulnerability
+	v := vuln.Vulnerability{
+		Identifier:       strings.TrimSpace(gv.ID),
+		Source:           FormatGrype,
+		Title:            firstLine(gv.Description),
+		Description:      gv.Description,
+		PackageName:      grypePrefixes[m.Artifact.Type] + m.Artifact.Name,
+		InstalledVersion: m.Artifact.Version,
+	}
+	if v.Identifier == "" {
+		return v, errors.New("missing vulnerability ID")
+	}
+	if kb, ok := vuln.ParseKB(v.Identifier); ok {
+		v.Identifier = kb
+	}
+	isCVE := strings.HasPrefix(strings.ToUpper(v.Identifier), "CVE-")
+	for _, rel := range m.RelatedVulnerabilities {
+		if v.Description == "" {
+			v.Description = rel.Description
+		}
+		if id := strings.ToUpper(strings.TrimSpace(rel.ID)); !isCVE && strings.HasPrefix(id, "CVE-") {
+			v.RelatedCVEs = append(v.RelatedCVEs, id)
+		}
+	}
+	v.Title = firstNonEmpty(v.Title, firstLine(v.Description), v.Identifier)
+	if gv.Fix.State == "fixed" && len(gv.Fix.Versions) > 0 {
+		v.FixedVersion = gv.Fix.Versions[0]
+		v.Remediation = v.UpgradeRemediation()
+		v.Fixability = "Fixable"
+	}
+
+	records := append([]grypeRecord{gv.grypeRecord}, m.RelatedVulnerabilities...)
+	for _, rec := range records {
+		newest := ""
+		for _, c := range rec.CVSS {
+			score := c.Metrics.BaseScore
+			// A vector this tool cannot read is left out rather than
+			// losing the finding, and one without a score is scored.
+			vec, err := cvss.Parse(c.Vector)
+			if err == nil && score == 0 {
+				score = vec.Score()
+			}
+			if score > 0 && c.Version > newest {
+				newest = c.Version
+				v.CVSS = score
+				v.CVSSVector = ""
+				if err == nil {
+					v.CVSSVector = c.Vector
+				}
+			}
+		}
+		if newest != "" {
+			break
+		}
+	}
+	if v.CVSS < 0 || v.CVSS > 10 {
+		return v, fmt.Errorf("CVSS %v out of range 0-10", v.CVSS)
+	}
+
+	switch sev := strings.TrimSpace(gv.Severity); {
+	case strings.EqualFold(sev, "Unknown"), sev == "":
+		v.Severity = vuln.CVSSSeverity(v.CVSS)
+	case strings.EqualFold(sev, "Negligible"):
+		v.Severity = vuln.Low
+	default:
+		s, err := vuln.ParseSeverity(sev)
+		if err != nil {
+			return v, err
+		}
+		v.Severity = s
+	}
+	return v, nil
+}
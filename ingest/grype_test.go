@@ -0,0 +1,82 @@
+package ingest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestReadGrype(t *testing.T) {
+	f, err := os.Open("testdata/grype.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := ReadGrype(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 4 {
+		t.Fatalf("read %d findings: %+v", len(res.Vulns), res.Vulns)
+	}
+
+	net := res.Vulns[0]
+	if net.Identifier != "GHSA-qppj-fm5r-hxr3" || strings.Join(net.RelatedCVEs, ",") != "CVE-2023-44487" || net.Source != "grype" || net.Severity != vuln.High {
+		t.Errorf("advisory %+v", net)
+	}
+	if net.PackageName != "go-golang.org/x/net" || net.Ecosystem() != vuln.EcosystemGo || net.FixedVersion != "0.17.0" || net.Remediation != "Update go-golang.org/x/net from v0.10.0 to 0.17.0." {
+		t.Errorf("go module %+v", net)
+	}
+	if net.AssetName != "253710526682.dkr.ecr.us-west-2.amazonaws.com/hasura:v2.36.1" || net.ImageDigest != "sha256:0d3cbd5e09bdb7052c45b2f5bfe4019128035f9ab7d1d30a61f1d2f903b1ee8e" ||
+		strings.Join(net.ImageTags, ",") != "v2.36.1" || net.FirstDetectedDate != vuln.NewDate(2025, 2, 4) || net.UniqueID != "grype-1" {
+		t.Errorf("asset %+v", net)
+	}
+	if net.OS != "Debian 12" || net.OSFamily != "debian" || net.OSVersion != "12" || net.Arch != "x86_64" {
+		t.Errorf("platform %q %q %q %q", net.OS, net.OSFamily, net.OSVersion, net.Arch)
+	}
+	// The CVE's own record has no score, so the NVD's newest version is used.
+	if glibc := res.Vulns[1]; glibc.CVSS != 8.8 || glibc.CVSSVector != "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H" || glibc.RelatedCVEs != nil || glibc.Ecosystem() != vuln.EcosystemOSPkg || !strings.HasPrefix(glibc.Description, "The iconv()") {
+		t.Errorf("glibc %+v", glibc)
+	}
+	if tar := res.Vulns[2]; tar.Severity != vuln.Low || tar.CVSS != 10 || tar.CVSSVector != "" || tar.HasFix() {
+		t.Errorf("negligible %+v", tar)
+	}
+	if expat := res.Vulns[3]; expat.Severity != vuln.High || expat.Title != "CVE-2023-52425" {
+		t.Errorf("unknown severity %+v", expat)
+	}
+
+	if len(res.Skipped) != 2 || res.Skipped[0].Line != 5 || res.Skipped[1].Line != 6 || len(res.Skipped[1].Record) != len(res.Header) {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
+
+func TestReadGrypeDirectory(t *testing.T) {
+	res, err := ReadGrype(strings.NewReader(`{"matches": [{"vulnerability": {"id": "CVE-2024-4067", "severity": "Medium"}, "artifact": {"name": "micromatch", "version": "4.0.5", "type": "npm"}}], "source": {"type": "directory", "target": "./web"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := res.Vulns[0]; v.AssetName != "./web" || v.AssetID != "./web" || v.ImageDigest != "" || v.PackageName != "npm-micromatch" {
+		t.Errorf("directory finding %+v", v)
+	}
+	if _, err := ReadGrype(strings.NewReader(`{"matches": [], "descriptor": {"name": "osv-scanner"}}`)); err == nil {
+		t.Error("ReadGrype accepted another tool's report")
+	}
+}
+
+func TestReadGrypeVectorOnly(t *testing.T) {
+	res, err := ReadGrype(strings.NewReader(`{"matches": [{"vulnerability": {"id": "CVE-2024-0001", "severity": "Unknown", "cvss": [
+		{"version": "3.1", "vector": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", "metrics": {"baseScore": 7.5}},
+		{"version": "4.0", "vector": "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", "metrics": {}}
+	]}, "artifact": {"name": "curl", "version": "7.88.1", "type": "deb"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 1 {
+		t.Fatalf("read %d findings", len(res.Vulns))
+	}
+	if v := res.Vulns[0]; v.CVSS != 9.3 || !strings.HasPrefix(v.CVSSVector, "CVSS:4.0/") || v.Severity != vuln.Critical {
+		t.Errorf("CVSS %v %q, severity %s", v.CVSS, v.CVSSVector, v.Severity)
+	}
+}
@@ -0,0 +1,245 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column types guessed by Inspect.
+const (
+	TypeEmpty   = "empty"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+	TypeText    = "text"
+)
+
+// DateFormat is a date layout Inspect can recognise.
+type DateFormat struct {
+	// Name is the layout as users write it, such as "M/D/YYYY".
+	Name string
+	// Layout is the time.Parse layout.
+	Layout string
+}
+
+// dateFormats are tried by Inspect. M/D/YYYY is listed before D/M/YYYY, so
+// it is the guess when every value fits both.
+var dateFormats = []DateFormat{
+	{"M/D/YYYY", "1/2/2006"},
+	{"D/M/YYYY", "2/1/2006"},
+	{"YYYY-MM-DD", "2006-01-02"},
+	{"YYYY/MM/DD", "2006/01/02"},
+	{"DD.MM.YYYY", "02.01.2006"},
+	{"DD-Mon-YYYY", "02-Jan-2006"},
+	{"RFC 3339", time.RFC3339},
+}
+
+// ColumnInfo is what Inspect found out about one input column.
+type ColumnInfo struct {
+	Index  int    `json:"index"`
+	Header string `json:"header"`
+	Type   string `json:"type"`
+	// DateFormats are the formats every value fits, best guess first, for
+	// date columns. More than one means the order of day and month is
+	// ambiguous in the sample.
+	DateFormats []string `json:"date_formats,omitempty"`
+	// DateSupported reports whether ReadCSV parses the guessed format.
+	DateSupported bool `json:"date_supported,omitempty"`
+	// Field is the field the header maps to, or "" if none.
+	Field Field `json:"field,omitempty"`
+	// Candidates are fields with a similar name, for unmapped headers.
+	Candidates []Field `json:"candidates,omitempty"`
+	// Empty counts blank cells in the sample.
+	Empty int `json:"empty"`
+}
+
+// Schema is the outcome of Inspect.
+type Schema struct {
+	Columns []ColumnInfo `json:"columns"`
+	// Preview holds the first rows, as read.
+	Preview [][]string `json:"preview"`
+	// Sampled is the number of rows the guesses are based on.
+	Sampled int `json:"sampled"`
+	// Malformed counts sampled rows that could not be parsed as CSV.
+	Malformed int `json:"malformed"`
+	// Missing lists required fields no header maps to.
+	Missing []Field `json:"missing,omitempty"`
+}
+
+// Inspect reads the header and up to sample rows of a CSV export and
+// guesses each column's type, date format and field mapping, keeping the
+// first preview rows.
+func Inspect(r io.Reader, preview, sample int) (*Schema, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("input is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	s := &Schema{}
+	values := make([][]string, len(header))
+	for s.Sampled < sample {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		s.Sampled++
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return nil, err
+			}
+			s.Malformed++
+			continue
+		}
+		if len(s.Preview) < preview {
+			s.Preview = append(s.Preview, record)
+		}
+		for i := range header {
+			cell := ""
+			if i < len(record) {
+				cell = strings.TrimSpace(record[i])
+			}
+			values[i] = append(values[i], cell)
+		}
+	}
+
+	mapped := make(map[Field]bool)
+	for i, name := range header {
+		c := ColumnInfo{Index: i, Header: name}
+		c.Type, c.DateFormats, c.Empty = guessType(values[i])
+		if len(c.DateFormats) > 0 {
+			c.DateSupported = supportedDateFormat(c.DateFormats[0])
+		}
+		if field, ok := headerAliases[normalizeHeader(name)]; ok && !mapped[field] {
+			c.Field = field
+			mapped[field] = true
+		} else if !ok {
+			c.Candidates = candidateFields(name)
+		}
+		s.Columns = append(s.Columns, c)
+	}
+	s.Missing = missingFields(func(f Field) bool { return mapped[f] })
+	return s, nil
+}
+
+// guessType returns the narrowest type every non-empty value fits, the date
+// formats they fit for date columns, and the number of empty values.
+func guessType(values []string) (typ string, formats []string, empty int) {
+	isInt, isNum, isBool := true, true, true
+	dates := append([]DateFormat(nil), dateFormats...)
+	for _, v := range values {
+		if v == "" {
+			empty++
+			continue
+		}
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			isInt = false
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			isNum = false
+		}
+		if _, err := strconv.ParseBool(strings.ToLower(v)); err != nil {
+			isBool = false
+		}
+		kept := dates[:0]
+		for _, f := range dates {
+			if _, err := time.Parse(f.Layout, v); err == nil {
+				kept = append(kept, f)
+			}
+		}
+		dates = kept
+	}
+
+	switch {
+	case empty == len(values):
+		return TypeEmpty, nil, empty
+	case isInt:
+		return TypeInteger, nil, empty
+	case isNum:
+		return TypeNumber, nil, empty
+	case isBool:
+		return TypeBoolean, nil, empty
+	case len(dates) > 0:
+		for _, f := range dates {
+			formats = append(formats, f.Name)
+		}
+		return TypeDate, formats, empty
+	}
+	return TypeText, nil, empty
+}
+
+// supportedDateFormat reports whether ReadCSV parses dates in the named
+// format.
+func supportedDateFormat(name string) bool {
+	for _, f := range dateFormats {
+		if f.Name != name {
+			continue
+		}
+		for _, layout := range dateLayouts {
+			if layout == f.Layout {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// candidateFields returns the fields whose aliases resemble header: one
+// contains the other, or they are within a small edit distance.
+func candidateFields(header string) []Field {
+	h := normalizeHeader(header)
+	if h == "" {
+		return nil
+	}
+	seen := make(map[Field]bool)
+	for alias, field := range headerAliases {
+		if len(alias) < 3 {
+			continue
+		}
+		if strings.Contains(h, alias) || strings.Contains(alias, h) || editDistance(h, alias) <= 2 {
+			seen[field] = true
+		}
+	}
+	fields := make([]Field, 0, len(seen))
+	for f := range seen {
+		fields = append(fields, f)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i] < fields[j] })
+	return fields
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,88 @@
+package ingest
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInspectExport(t *testing.T) {
+	f, err := os.Open("testdata/findings.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	s, err := Inspect(f, 2, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Sampled != 3 || len(s.Preview) != 2 || len(s.Columns) != 17 || len(s.Missing) != 0 {
+		t.Fatalf("schema = %+v", s)
+	}
+	byHeader := map[string]ColumnInfo{}
+	for _, c := range s.Columns {
+		byHeader[c.Header] = c
+	}
+	tests := []struct {
+		header, typ string
+		field       Field
+	}{
+		{"Unique ID", TypeInteger, FieldUniqueID},
+		{"CVSS", TypeNumber, FieldCVSS},
+		{"Due date", TypeDate, FieldDueDate},
+		{"Description", TypeText, FieldDescription},
+	}
+	for _, tt := range tests {
+		c := byHeader[tt.header]
+		if c.Type != tt.typ || c.Field != tt.field {
+			t.Errorf("%s: type %s field %s, want %s %s", tt.header, c.Type, c.Field, tt.typ, tt.field)
+		}
+	}
+	if due := byHeader["Due date"]; due.DateFormats[0] != "M/D/YYYY" || !due.DateSupported {
+		t.Errorf("Due date formats = %v, supported %v", due.DateFormats, due.DateSupported)
+	}
+}
+
+func TestInspectGuesses(t *testing.T) {
+	in := "Vuln Ref,Severity,Score,Found On,Patched,Seen,Notes\n" +
+		"CVE-1,High,7.5,13/01/2025,true,2025-01-13,\n" +
+		"CVE-2,Low,3,02/01/2025,false,2025-01-14,\n"
+	s, err := Inspect(strings.NewReader(in), 10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols := s.Columns
+	if cols[2].Type != TypeNumber || cols[4].Type != TypeBoolean || cols[6].Type != TypeEmpty || cols[6].Empty != 2 {
+		t.Errorf("types = %+v", cols)
+	}
+	// 13/01 rules out M/D/YYYY.
+	if !reflect.DeepEqual(cols[3].DateFormats, []string{"D/M/YYYY"}) || cols[3].DateSupported {
+		t.Errorf("Found On = %+v", cols[3])
+	}
+	if !reflect.DeepEqual(cols[5].DateFormats, []string{"YYYY-MM-DD"}) || !cols[5].DateSupported {
+		t.Errorf("Seen = %+v", cols[5])
+	}
+	// "Vuln Ref" and "Score" are not aliases, so CVSS and identifier are
+	// reported missing; "Score" resembles cvss_score, priority_score and
+	// the projected scores.
+	if !reflect.DeepEqual(s.Missing, []Field{FieldIdentifier, FieldCVSS}) {
+		t.Errorf("missing = %v", s.Missing)
+	}
+	if cols[1].Field != FieldSeverity {
+		t.Errorf("Severity maps to %q", cols[1].Field)
+	}
+	if !reflect.DeepEqual(cols[2].Candidates, []Field{FieldCVSS, FieldPriorityScore, FieldScoreIn30Days, FieldScoreIn60Days, FieldScoreIn90Days}) {
+		t.Errorf("Score candidates = %v", cols[2].Candidates)
+	}
+}
+
+func TestInspectAmbiguousDates(t *testing.T) {
+	s, err := Inspect(strings.NewReader("Due\n2/3/2025\n4/5/2025\n"), 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Columns[0].DateFormats; !reflect.DeepEqual(got, []string{"M/D/YYYY", "D/M/YYYY"}) {
+		t.Errorf("formats = %v", got)
+	}
+}
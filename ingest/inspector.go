@@ -0,0 +1,513 @@
+package ingest
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/imageref"
+	"github.com/VioletX-Dev/devsecops-test/platform"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// awsHeader is the Result.Header of an Inspector or Security Hub input: the
+// fields a skipped finding is quarantined with.
+var awsHeader = []string{"finding", "vulnerability", "package", "severity"}
+
+// awsSource is the Source of Inspector and Security Hub findings, as in
+// the Inspector rows of an export.
+const awsSource = "aws"
+
+// inspectorPage is an Amazon Inspector ListFindings response, as the API
+// returns it and aws inspector2 list-findings prints it.
+type inspectorPage struct {
+	Findings []struct {
+		FindingArn       string  `json:"findingArn"`
+		AwsAccountID     string  `json:"awsAccountId"`
+		Type             string  `json:"type"`
+		Title            string  `json:"title"`
+		Description      string  `json:"description"`
+		Severity         string  `json:"severity"`
+		FirstObservedAt  awsTime `json:"firstObservedAt"`
+		InspectorScore   float64 `json:"inspectorScore"`
+		FixAvailable     string  `json:"fixAvailable"`
+		ExploitAvailable string  `json:"exploitAvailable"`
+		Remediation      struct {
+			Recommendation struct {
+				Text string `json:"text"`
+			} `json:"recommendation"`
+		} `json:"remediation"`
+		Resources []struct {
+			Type    string            `json:"type"`
+			ID      string            `json:"id"`
+			Region  string            `json:"region"`
+			Tags    map[string]string `json:"tags"`
+			Details struct {
+				Ec2 *struct {
+					Platform string `json:"platform"`
+				} `json:"awsEc2Instance"`
+				Ecr *struct {
+					RepositoryName string   `json:"repositoryName"`
+					ImageHash      string   `json:"imageHash"`
+					ImageTags      []string `json:"imageTags"`
+					Platform       string   `json:"platform"`
+					Architecture   string   `json:"architecture"`
+				} `json:"awsEcrContainerImage"`
+				Lambda *struct {
+					FunctionName string `json:"functionName"`
+				} `json:"awsLambdaFunction"`
+			} `json:"details"`
+		} `json:"resources"`
+		Details *struct {
+			VulnerabilityID string `json:"vulnerabilityId"`
+			Cvss            []struct {
+				BaseScore     float64 `json:"baseScore"`
+				ScoringVector string  `json:"scoringVector"`
+				Source        string  `json:"source"`
+				Version       string  `json:"version"`
+			} `json:"cvss"`
+			RelatedVulnerabilities []string `json:"relatedVulnerabilities"`
+			VulnerablePackages     []struct {
+				Name           string `json:"name"`
+				Version        string `json:"version"`
+				Release        string `json:"release"`
+				Epoch          int    `json:"epoch"`
+				Arch           string `json:"arch"`
+				FixedInVersion string `json:"fixedInVersion"`
+				PackageManager string `json:"packageManager"`
+				Remediation    string `json:"remediation"`
+			} `json:"vulnerablePackages"`
+		} `json:"packageVulnerabilityDetails"`
+	} `json:"findings"`
+	NextToken string `json:"nextToken"`
+}
+
+// securityHubPage is a Security Hub GetFindings response, of findings in
+// the AWS Security Finding Format (ASFF), as the API returns it and aws
+// securityhub get-findings prints it.
+type securityHubPage struct {
+	Findings []struct {
+		ID              string  `json:"Id"`
+		AwsAccountID    string  `json:"AwsAccountId"`
+		Region          string  `json:"Region"`
+		Title           string  `json:"Title"`
+		Description     string  `json:"Description"`
+		FirstObservedAt awsTime `json:"FirstObservedAt"`
+		CreatedAt       awsTime `json:"CreatedAt"`
+		Severity        struct {
+			Label string `json:"Label"`
+		} `json:"Severity"`
+		Remediation struct {
+			Recommendation struct {
+				Text string `json:"Text"`
+			} `json:"Recommendation"`
+		} `json:"Remediation"`
+		Resources []struct {
+			Type    string            `json:"Type"`
+			ID      string            `json:"Id"`
+			Region  string            `json:"Region"`
+			Tags    map[string]string `json:"Tags"`
+			Details struct {
+				Ecr *struct {
+					RepositoryName string   `json:"RepositoryName"`
+					ImageDigest    string   `json:"ImageDigest"`
+					ImageTags      []string `json:"ImageTags"`
+					Architecture   string   `json:"Architecture"`
+				} `json:"AwsEcrContainerImage"`
+				Lambda *struct {
+					FunctionName string `json:"FunctionName"`
+				} `json:"AwsLambdaFunction"`
+			} `json:"Details"`
+		} `json:"Resources"`
+		Vulnerabilities []struct {
+			ID   string `json:"Id"`
+			Cvss []struct {
+				BaseScore  float64 `json:"BaseScore"`
+				BaseVector string  `json:"BaseVector"`
+				Source     string  `json:"Source"`
+				Version    string  `json:"Version"`
+			} `json:"Cvss"`
+			RelatedVulnerabilities []string `json:"RelatedVulnerabilities"`
+			FixAvailable           string   `json:"FixAvailable"`
+			VulnerablePackages     []struct {
+				Name           string `json:"Name"`
+				Version        string `json:"Version"`
+				Release        string `json:"Release"`
+				Epoch          string `json:"Epoch"`
+				Architecture   string `json:"Architecture"`
+				FixedInVersion string `json:"FixedInVersion"`
+				PackageManager string `json:"PackageManager"`
+				Remediation    string `json:"Remediation"`
+			} `json:"VulnerablePackages"`
+		} `json:"Vulnerabilities"`
+	} `json:"Findings"`
+	NextToken string `json:"NextToken"`
+}
+
+// awsTime is an AWS timestamp: seconds since the epoch, as REST JSON APIs
+// such as Inspector's send them, or an ISO 8601 string, as Security Hub and
+// the AWS CLI write them.
+type awsTime struct{ time.Time }
+
+func (t *awsTime) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		var err error
+		t.Time, err = time.Parse(time.RFC3339Nano, s)
+		return err
+	}
+	secs, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s", b)
+	}
+	t.Time = time.Unix(0, int64(secs*float64(time.Second))).UTC()
+	return nil
+}
+
+// awsFinding is a package vulnerability finding of Inspector or Security
+// Hub, in the fields both have.
+type awsFinding struct {
+	id, account, region  string
+	title, description   string
+	severity             string
+	firstObserved        time.Time
+	score                float64 // Inspector's score, used without a CVSS score
+	fixAvailable         string
+	recommendation       string
+	resourceType         string // "ec2", "ecr" or "lambda", or "" for other resources
+	resourceID, resource string // the asset ID and name
+	digest, os, arch     string
+	tags                 []string
+	vulnerabilityID      string
+	cvss                 []awsCVSS
+	related              []string
+	packages             []awsPackage
+	err                  error // why the finding is skipped
+}
+
+type awsCVSS struct {
+	version, vector, source string
+	score                   float64
+}
+
+type awsPackage struct {
+	name, version, release, epoch, arch string
+	fixed, manager, remediation         string
+}
+
+// awsPrefixes maps Inspector's language package managers to the
+// package-name prefixes that mark their ecosystem; see
+// vuln.Vulnerability.Ecosystem. Other packages are OS packages.
+var awsPrefixes = map[string]string{
+	"NPM": "npm-", "NODEPKG": "npm-", "YARN": "npm-",
+	"PIP": "pip-", "PYTHONPKG": "pip-", "PIPENV": "pip-", "POETRY": "pip-",
+	"GOBINARY": "go-", "GOMOD": "go-",
+	"JAR": "maven-", "POM": "maven-", "GRADLE": "maven-",
+}
+
+// ReadInspector reads Amazon Inspector findings, as a ListFindings
+// response, with one finding per vulnerable package of each package
+// vulnerability finding.
+//
+//   - UniqueID is the finding ARN, with the package name after a # when the
+//     finding has several packages. Identifier is the vulnerability ID, and
+//     Source "aws".
+//   - AssetName and AssetID are an ECR image's repository name and ARN, an
+//     EC2 instance's Name tag and ID, or a Lambda function's name and ARN.
+//     An image's ImageDigest and ImageTags come from the image, so its
+//     findings are keyed by digest; see package imageref. Organization is
+//     the account and region, as in "253710526682: us-west-2".
+//   - PackageName is the package's name, prefixed with its ecosystem for
+//     language packages, as in "npm-axios", and InstalledVersion its
+//     version, with the epoch and release. FixedVersion is the version it
+//     is fixed in, and Remediation the package's remediation, or else the
+//     finding's recommendation.
+//   - CVSS and CVSSVector come from the newest CVSS version, the NVD's
+//     first, else the Inspector score.
+//   - Severity is Inspector's; Informational is Low, and Untriaged follows
+//     from the CVSS score.
+//   - FirstDetectedDate is the day the finding was first observed.
+//   - OS, OSFamily and OSVersion come from the instance's or image's
+//     platform, and Arch from the package.
+//
+// Findings that are not package vulnerabilities, or lack a vulnerability
+// ID, are skipped, with RowError.Line holding their 1-based position.
+func ReadInspector(r io.Reader) (*Result, error) {
+	var page inspectorPage
+	if err := json.NewDecoder(r).Decode(&page); err != nil {
+		return nil, fmt.Errorf("Inspector: %w", err)
+	}
+	var fs []awsFinding
+	for _, in := range page.Findings {
+		f := awsFinding{
+			id: in.FindingArn, account: in.AwsAccountID, title: in.Title, description: in.Description,
+			severity: in.Severity, firstObserved: in.FirstObservedAt.Time, score: in.InspectorScore,
+			fixAvailable: in.FixAvailable, recommendation: in.Remediation.Recommendation.Text,
+		}
+		if len(in.Resources) > 0 {
+			res := in.Resources[0]
+			f.region, f.resourceID, f.resource = res.Region, res.ID, res.ID
+			switch d := res.Details; {
+			case d.Ecr != nil:
+				f.resourceType = "ecr"
+				f.resource, f.digest, f.tags, f.os, f.arch = d.Ecr.RepositoryName, d.Ecr.ImageHash, d.Ecr.ImageTags, d.Ecr.Platform, d.Ecr.Architecture
+			case d.Ec2 != nil:
+				f.resourceType, f.os = "ec2", d.Ec2.Platform
+			case d.Lambda != nil:
+				f.resourceType, f.resource = "lambda", d.Lambda.FunctionName
+			}
+			if name := res.Tags["Name"]; name != "" && f.resourceType == "ec2" {
+				f.resource = name
+			}
+		}
+		if d := in.Details; d == nil {
+			f.err = fmt.Errorf("not a package vulnerability finding (type %s)", in.Type)
+		} else {
+			f.vulnerabilityID, f.related = d.VulnerabilityID, d.RelatedVulnerabilities
+			for _, c := range d.Cvss {
+				f.cvss = append(f.cvss, awsCVSS{version: c.Version, vector: c.ScoringVector, source: c.Source, score: c.BaseScore})
+			}
+			for _, p := range d.VulnerablePackages {
+				epoch := ""
+				if p.Epoch > 0 {
+					epoch = strconv.Itoa(p.Epoch)
+				}
+				f.packages = append(f.packages, awsPackage{
+					name: p.Name, version: p.Version, release: p.Release, epoch: epoch, arch: p.Arch,
+					fixed: p.FixedInVersion, manager: p.PackageManager, remediation: p.Remediation,
+				})
+			}
+		}
+		fs = append(fs, f)
+	}
+	return awsResult(fs), nil
+}
+
+// ReadSecurityHub reads Security Hub findings in the AWS Security Finding
+// Format, as a GetFindings response, with one finding per vulnerable
+// package of each vulnerability of a finding. They are read as for
+// ReadInspector, from the same fields of the format, and the severity is
+// the finding's severity label. A finding first observed at no time was
+// first detected when it was created.
+//
+// Findings without vulnerabilities are skipped, with RowError.Line holding
+// their 1-based position.
+func ReadSecurityHub(r io.Reader) (*Result, error) {
+	var page securityHubPage
+	if err := json.NewDecoder(r).Decode(&page); err != nil {
+		return nil, fmt.Errorf("Security Hub: %w", err)
+	}
+	var fs []awsFinding
+	for _, in := range page.Findings {
+		f := awsFinding{
+			id: in.ID, account: in.AwsAccountID, region: in.Region, title: in.Title, description: in.Description,
+			severity: in.Severity.Label, firstObserved: in.FirstObservedAt.Time, recommendation: in.Remediation.Recommendation.Text,
+		}
+		if f.firstObserved.IsZero() {
+			f.firstObserved = in.CreatedAt.Time
+		}
+		if len(in.Resources) > 0 {
+			res := in.Resources[0]
+			f.region = cmp.Or(res.Region, f.region)
+			f.resourceID, f.resource = res.ID, res.ID
+			switch d := res.Details; {
+			case d.Ecr != nil:
+				f.resourceType = "ecr"
+				f.resource, f.digest, f.tags, f.arch = d.Ecr.RepositoryName, d.Ecr.ImageDigest, d.Ecr.ImageTags, d.Ecr.Architecture
+			case res.Type == "AwsEc2Instance":
+				// ASFF names an instance by its ARN, Inspector by its ID.
+				f.resourceType = "ec2"
+				if _, id, ok := strings.Cut(res.ID, ":instance/"); ok {
+					f.resourceID, f.resource = id, id
+				}
+				if name := res.Tags["Name"]; name != "" {
+					f.resource = name
+				}
+			case d.Lambda != nil:
+				f.resourceType, f.resource = "lambda", d.Lambda.FunctionName
+			}
+		}
+		if len(in.Vulnerabilities) == 0 {
+			f.err = errors.New("not a vulnerability finding")
+			fs = append(fs, f)
+			continue
+		}
+		for _, v := range in.Vulnerabilities {
+			f := f
+			f.vulnerabilityID, f.related, f.fixAvailable = v.ID, v.RelatedVulnerabilities, v.FixAvailable
+			f.cvss, f.packages = nil, nil
+			for _, c := range v.Cvss {
+				f.cvss = append(f.cvss, awsCVSS{version: c.Version, vector: c.BaseVector, source: c.Source, score: c.BaseScore})
+			}
+			for _, p := range v.VulnerablePackages {
+				epoch := p.Epoch
+				if epoch == "0" {
+					epoch = ""
+				}
+				f.packages = append(f.packages, awsPackage{
+					name: p.Name, version: p.Version, release: p.Release, epoch: epoch, arch: p.Architecture,
+					fixed: p.FixedInVersion, manager: p.PackageManager, remediation: p.Remediation,
+				})
+			}
+			fs = append(fs, f)
+		}
+	}
+	return awsResult(fs), nil
+}
+
+// awsResult maps fs, in order, with one finding per package.
+func awsResult(fs []awsFinding) *Result {
+	res := &Result{Header: awsHeader}
+	for i := range fs {
+		f := &fs[i]
+		packages := f.packages
+		if len(packages) == 0 {
+			packages = []awsPackage{{}}
+		}
+		for _, p := range packages {
+			v, err := f.vuln(p)
+			if err != nil {
+				res.Skipped = append(res.Skipped, RowError{Line: i + 1, Record: []string{f.id, f.vulnerabilityID, p.name, f.severity}, Err: err})
+				continue
+			}
+			if len(f.packages) > 1 {
+				v.UniqueID += "#" + p.name
+			}
+			res.Vulns = append(res.Vulns, v)
+		}
+	}
+	return res
+}
+
+// vuln maps f's finding of package p.
+func (f *awsFinding) vuln(p awsPackage) (vuln.Vulnerability, error) {
+	if f.err != nil {
+		return vuln.Vulnerability{}, f.err
+	}
+	v := vuln.Vulnerability{
+		UniqueID:    f.id,
+		AssetName:   f.resource,
+		AssetID:     f.resourceID,
+		Identifier:  strings.TrimSpace(f.vulnerabilityID),
+		Source:      awsSource,
+		Title:       firstNonEmpty(f.title, firstLine(f.description), f.vulnerabilityID),
+		Description: f.description,
+		PackageName: awsPrefixes[strings.ToUpper(p.manager)] + p.name,
+	}
+	if v.Identifier == "" {
+		return v, errors.New("missing vulnerability ID")
+	}
+	if f.account != "" {
+		v.Organization = f.account
+		if f.region != "" {
+			v.Organization += ": " + f.region
+		}
+	}
+	if f.resourceType == "ecr" {
+		// An image's resource ID is its repository's ARN and digest; the
+		// image is keyed by digest, and the asset is the repository.
+		if i := strings.Index(v.AssetID, "/sha256:"); i >= 0 {
+			v.AssetID = v.AssetID[:i]
+		}
+		v.ImageDigest, _ = imageref.Digest(f.digest)
+		v.ImageTags = f.tags
+	}
+	v.InstalledVersion = p.version
+	if p.release != "" {
+		v.InstalledVersion += "-" + p.release
+	}
+	if p.epoch != "" && v.InstalledVersion != "" {
+		v.InstalledVersion = p.epoch + ":" + v.InstalledVersion
+	}
+	if fixed := strings.TrimSpace(p.fixed); fixed != "" && !strings.EqualFold(fixed, "NotAvailable") {
+		v.FixedVersion = fixed
+	}
+	switch strings.ToUpper(f.fixAvailable) {
+	case "YES", "PARTIAL":
+		v.Fixability = "Fixable"
+	case "":
+		if v.FixedVersion != "" {
+			v.Fixability = "Fixable"
+		}
+	}
+	v.Remediation = firstNonEmpty(p.remediation, f.recommendation)
+	if v.Remediation == "" && v.FixedVersion != "" {
+		v.Remediation = v.UpgradeRemediation()
+	}
+	isCVE := strings.HasPrefix(strings.ToUpper(v.Identifier), "CVE-")
+	for _, id := range f.related {
+		if id = strings.ToUpper(strings.TrimSpace(id)); !isCVE && strings.HasPrefix(id, "CVE-") {
+			v.RelatedCVEs = append(v.RelatedCVEs, id)
+		}
+	}
+
+	var best *awsCVSS
+	for i := range f.cvss {
+		c := &f.cvss[i]
+		if c.score <= 0 {
+			continue
+		}
+		if best == nil || c.version > best.version || c.version == best.version && strings.EqualFold(c.source, "NVD") && !strings.EqualFold(best.source, "NVD") {
+			best = c
+		}
+	}
+	if best != nil {
+		v.CVSS = best.score
+		if vec := strings.TrimSpace(best.vector); vec != "" {
+			if !strings.HasPrefix(vec, "CVSS:") && best.version >= "3" {
+				vec = "CVSS:" + best.version + "/" + vec
+			}
+			if _, err := cvss.Parse(vec); err == nil {
+				v.CVSSVector = vec
+			}
+		}
+	} else {
+		v.CVSS = f.score
+	}
+	if v.CVSS < 0 || v.CVSS > 10 {
+		return v, fmt.Errorf("CVSS %v out of range 0-10", v.CVSS)
+	}
+
+	switch sev := strings.TrimSpace(f.severity); {
+	case strings.EqualFold(sev, "Untriaged"), sev == "":
+		v.Severity = vuln.CVSSSeverity(v.CVSS)
+	case strings.EqualFold(sev, "Informational"):
+		v.Severity = vuln.Low
+	default:
+		s, err := vuln.ParseSeverity(sev)
+		if err != nil {
+			return v, err
+		}
+		v.Severity = s
+	}
+	if !f.firstObserved.IsZero() {
+		t := f.firstObserved.UTC()
+		v.FirstDetectedDate = vuln.NewDate(t.Year(), t.Month(), t.Day())
+	}
+	family, version := awsPlatform(f.os)
+	setPlatform(&v, family, version, cmp.Or(p.arch, f.arch))
+	return v, nil
+}
+
+// awsPlatform splits an Inspector platform such as "UBUNTU_22_04" or
+// "AMAZON_LINUX_2023" into its family and version.
+func awsPlatform(p string) (family, version string) {
+	family, version, ok := platform.Split(strings.ReplaceAll(p, "_", " "))
+	if !ok {
+		return "", ""
+	}
+	return family, strings.ReplaceAll(version, " ", ".")
+}
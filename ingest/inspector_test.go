@@ -0,0 +1,103 @@
+package ingest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestReadInspector(t *testing.T) {
+	f, err := os.Open("testdata/inspector.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := ReadInspector(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 4 {
+		t.Fatalf("read %d findings: %+v", len(res.Vulns), res.Vulns)
+	}
+
+	libc := res.Vulns[0]
+	if libc.UniqueID != "arn:aws:inspector2:us-west-2:253710526682:finding/0b3c5bbf2a6c4b1e9d8f7a6b5c4d3e2f#libc6" || libc.Identifier != "CVE-2024-33601" || libc.Source != "aws" || libc.Severity != vuln.Medium {
+		t.Errorf("finding %+v", libc)
+	}
+	if libc.AssetName != "hasura" || libc.AssetID != "arn:aws:ecr:us-west-2:253710526682:repository/hasura" || libc.Organization != "253710526682: us-west-2" ||
+		libc.ImageDigest != "sha256:0d3cbd5e09bdb7052c45b2f5bfe4019128035f9ab7d1d30a61f1d2f903b1ee8e" || strings.Join(libc.ImageTags, ",") != "v2.36.1,latest" {
+		t.Errorf("image %+v", libc)
+	}
+	if libc.PackageName != "libc6" || libc.InstalledVersion != "2.35-0ubuntu3.6" || libc.FixedVersion != "0:2.35-0ubuntu3.8" || libc.Remediation != "apt-get update && apt-get upgrade" || libc.Fixability != "Fixable" {
+		t.Errorf("package %+v", libc)
+	}
+	// The NVD's score of the newest version wins over the vendor's.
+	if libc.CVSS != 5.9 || libc.CVSSVector != "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:H" || libc.FirstDetectedDate != vuln.NewDate(2024, 6, 5) {
+		t.Errorf("CVSS %v %q, first detected %s", libc.CVSS, libc.CVSSVector, libc.FirstDetectedDate)
+	}
+	if libc.OSFamily != "ubuntu" || libc.OSVersion != "22.04" || libc.Arch != "x86_64" {
+		t.Errorf("platform %q %q %q %q", libc.OS, libc.OSFamily, libc.OSVersion, libc.Arch)
+	}
+	if bin := res.Vulns[1]; bin.UniqueID != libc.UniqueID[:strings.Index(libc.UniqueID, "#")]+"#libc-bin" || bin.FixedVersion != "" || bin.Remediation != "None Provided" {
+		t.Errorf("second package %+v", bin)
+	}
+
+	crypto := res.Vulns[2]
+	if crypto.PackageName != "go-golang.org/x/crypto" || crypto.Ecosystem() != vuln.EcosystemGo || crypto.Severity != vuln.Critical || crypto.FirstDetectedDate != vuln.NewDate(2024, 12, 12) {
+		t.Errorf("go module %+v", crypto)
+	}
+	if crypto.AssetName != "acme-inc-lambda" || crypto.AssetID != "arn:aws:lambda:us-west-2:253710526682:function:acme-inc-lambda:$LATEST" || crypto.ImageDigest != "" {
+		t.Errorf("function %+v", crypto)
+	}
+
+	// Untriaged, with no CVSS score, follows the Inspector score.
+	ssl := res.Vulns[3]
+	if ssl.AssetName != "bastion" || ssl.AssetID != "i-0123456789abcdef0" || ssl.InstalledVersion != "1:1.0.2k-24.amzn2.0.4" || ssl.Fixability != "" {
+		t.Errorf("instance %+v", ssl)
+	}
+	if ssl.CVSS != 7.8 || ssl.Severity != vuln.High || strings.Join(ssl.RelatedCVEs, ",") != "CVE-2024-2961" || ssl.OSFamily != "amzn" {
+		t.Errorf("advisory %+v", ssl)
+	}
+
+	if len(res.Skipped) != 1 || res.Skipped[0].Line != 4 || len(res.Skipped[0].Record) != len(res.Header) {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
+
+func TestReadSecurityHub(t *testing.T) {
+	f, err := os.Open("testdata/securityhub.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := ReadSecurityHub(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 {
+		t.Fatalf("read %d findings: %+v", len(res.Vulns), res.Vulns)
+	}
+
+	glibc := res.Vulns[0]
+	if glibc.AssetName != "bastion" || glibc.AssetID != "i-0123456789abcdef0" || glibc.Organization != "253710526682: us-west-2" || glibc.Identifier != "CVE-2024-2961" {
+		t.Errorf("instance %+v", glibc)
+	}
+	if glibc.InstalledVersion != "2.26-64.amzn2.0.19" || glibc.Remediation != "yum update glibc" || glibc.CVSS != 8.8 || glibc.Severity != vuln.High || glibc.FirstDetectedDate != vuln.NewDate(2025, 1, 1) {
+		t.Errorf("package %+v", glibc)
+	}
+
+	runc := res.Vulns[1]
+	if runc.AssetName != "api" || runc.AssetID != "arn:aws:ecr:us-west-2:253710526682:repository/api" || runc.ImageDigest == "" || runc.PackageName != "go-github.com/opencontainers/runc" {
+		t.Errorf("image %+v", runc)
+	}
+	// Created, never observed: first detected when created.
+	if runc.Severity != vuln.Critical || runc.FirstDetectedDate != vuln.NewDate(2024, 11, 20) || runc.Remediation != "Update go-github.com/opencontainers/runc from v1.1.5 to 1.1.12." {
+		t.Errorf("finding %+v", runc)
+	}
+
+	if len(res.Skipped) != 1 || res.Skipped[0].Line != 3 {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
@@ -0,0 +1,61 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// QuarantineReasonColumn is the column WriteQuarantine appends to the
+// input header.
+const QuarantineReasonColumn = "error_reason"
+
+// WriteQuarantine writes the skipped rows of res to w as CSV, so that data
+// owners can correct and resubmit them. Each row is written as it was read,
+// padded to the header's width, followed by the reason it was skipped.
+// Multi-line cells are re-quoted by csv.Writer and survive the round trip.
+// A row that could not be split into cells at all is written as empty
+// cells with its reason.
+func WriteQuarantine(w io.Writer, res *Result) error {
+	q, err := NewQuarantine(w, res.Header)
+	if err != nil {
+		return err
+	}
+	for i := range res.Skipped {
+		if err := q.Write(&res.Skipped[i]); err != nil {
+			return err
+		}
+	}
+	return q.Flush()
+}
+
+// Quarantine writes skipped rows as WriteQuarantine does, one at a time,
+// for inputs read with a CSVStream.
+type Quarantine struct {
+	cw     *csv.Writer
+	header []string
+}
+
+// NewQuarantine writes the quarantine header for an input with header
+// to w.
+func NewQuarantine(w io.Writer, header []string) (*Quarantine, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append(append([]string(nil), header...), QuarantineReasonColumn)); err != nil {
+		return nil, err
+	}
+	return &Quarantine{cw: cw, header: header}, nil
+}
+
+// Write writes the skipped row s.
+func (q *Quarantine) Write(s *RowError) error {
+	row := append([]string(nil), s.Record...)
+	for len(row) < len(q.header) {
+		row = append(row, "")
+	}
+	return q.cw.Write(append(row, s.Error()))
+}
+
+// Flush writes any buffered rows to the underlying writer.
+func (q *Quarantine) Flush() error {
+	q.cw.Flush()
+	return q.cw.Error()
+}
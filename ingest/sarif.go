@@ -0,0 +1,241 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// sarifHeader is the Result.Header of a SARIF input: the result fields a
+// skipped result is quarantined with.
+var sarifHeader = []string{"ruleId", "message", "location"}
+
+// sarifLog is the subset of a SARIF 2.1.0 log that findings are read from.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver     sarifComponent   `json:"driver"`
+		Extensions []sarifComponent `json:"extensions"`
+	} `json:"tool"`
+	Invocations []struct {
+		StartTimeUTC string `json:"startTimeUtc"`
+	} `json:"invocations"`
+	VersionControlProvenance []struct {
+		RepositoryURI string `json:"repositoryUri"`
+	} `json:"versionControlProvenance"`
+	AutomationDetails struct {
+		ID string `json:"id"`
+	} `json:"automationDetails"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifComponent struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+	Help             sarifMessage `json:"help"`
+	Default          struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+	Properties struct {
+		SecuritySeverity string `json:"security-severity"`
+		Precision        string `json:"precision"`
+	} `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex *int   `json:"ruleIndex"`
+	Rule      struct {
+		ID    string `json:"id"`
+		Index *int   `json:"index"`
+	} `json:"rule"`
+	Level     string       `json:"level"`
+	Message   sarifMessage `json:"message"`
+	GUID      string       `json:"guid"`
+	Locations []struct {
+		PhysicalLocation struct {
+			ArtifactLocation struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+		} `json:"physicalLocation"`
+	} `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+// ReadSARIF reads a SARIF 2.1.0 log, as CodeQL, Semgrep and other
+// scanners write, with one finding per result:
+//
+//   - Identifier is the rule ID and Source the tool's name, lower case.
+//   - AssetName and AssetID are the run's repository, from its version
+//     control provenance or else its automation ID.
+//   - PackageName is the file of the result's first location, so a rule
+//     is one finding per file however many lines it flags.
+//   - CVSS is the rule's security-severity property, and Severity follows
+//     from it as on GitHub code scanning: 9.0 and up Critical, 7.0 High,
+//     4.0 Medium, else Low. Without one, the level decides: error is High,
+//     warning Medium, and note and none Low.
+//   - Title is the rule's short description, Description the result's
+//     message, Remediation the rule's help, and Confidence the rule's
+//     precision.
+//
+// Results without a rule ID are skipped, with RowError.Line holding the
+// result's 1-based position in the log and the record sarifHeader's
+// fields. An error is returned only when the log cannot be read at all.
+func ReadSARIF(r io.Reader) (*Result, error) {
+	var log sarifLog
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, fmt.Errorf("SARIF: %w", err)
+	}
+	if log.Version != "2.1.0" {
+		return nil, fmt.Errorf("SARIF: unsupported version %q (want 2.1.0)", log.Version)
+	}
+	res := &Result{Header: sarifHeader}
+	n := 0
+	for _, run := range log.Runs {
+		rules := make(map[string]*sarifRule)
+		for _, c := range append([]sarifComponent{run.Tool.Driver}, run.Tool.Extensions...) {
+			for i := range c.Rules {
+				rules[c.Rules[i].ID] = &c.Rules[i]
+			}
+		}
+		assetID := run.AutomationDetails.ID
+		if len(run.VersionControlProvenance) > 0 && run.VersionControlProvenance[0].RepositoryURI != "" {
+			assetID = run.VersionControlProvenance[0].RepositoryURI
+		}
+		var detected vuln.Date
+		if len(run.Invocations) > 0 {
+			if t, err := time.Parse(time.RFC3339, run.Invocations[0].StartTimeUTC); err == nil {
+				detected = vuln.NewDate(t.Year(), t.Month(), t.Day())
+			}
+		}
+
+		for i := range run.Results {
+			n++
+			sr := &run.Results[i]
+			uri := ""
+			if len(sr.Locations) > 0 {
+				uri = sr.Locations[0].PhysicalLocation.ArtifactLocation.URI
+			}
+			v, err := sarifFinding(sr, run.Tool.Driver.Rules, rules, uri)
+			if err != nil {
+				res.Skipped = append(res.Skipped, RowError{Line: n, Record: []string{sr.RuleID, sr.Message.Text, uri}, Err: err})
+				continue
+			}
+			v.Source = strings.ToLower(run.Tool.Driver.Name)
+			v.AssetID, v.AssetName = assetID, strings.TrimSuffix(path.Base(strings.TrimSuffix(assetID, "/")), ".git")
+			if assetID == "" {
+				v.AssetName = ""
+			}
+			v.FirstDetectedDate = detected
+			if v.UniqueID == "" {
+				v.UniqueID = fmt.Sprintf("%s-%d", FormatSARIF, n)
+			}
+			res.Vulns = append(res.Vulns, v)
+		}
+	}
+	return res, nil
+}
+
+// sarifFinding maps one result, looking its rule up by ID or by index
+// into the driver's rules.
+func sarifFinding(sr *sarifResult, driverRules []sarifRule, rules map[string]*sarifRule, uri string) (vuln.Vulnerability, error) {
+	id := sr.RuleID
+	if id == "" {
+		id = sr.Rule.ID
+	}
+	var rule *sarifRule
+	for _, idx := range []*int{sr.RuleIndex, sr.Rule.Index} {
+		if idx != nil && *idx >= 0 && *idx < len(driverRules) {
+			rule = &driverRules[*idx]
+			break
+		}
+	}
+	if rule == nil {
+		rule = rules[id]
+	}
+	if id == "" && rule != nil {
+		id = rule.ID
+	}
+	if id == "" {
+		return vuln.Vulnerability{}, errors.New("missing rule ID")
+	}
+	if rule == nil {
+		rule = &sarifRule{}
+	}
+
+	v := vuln.Vulnerability{
+		UniqueID:    sr.GUID,
+		Identifier:  id,
+		Title:       firstNonEmpty(rule.ShortDescription.Text, rule.Name, firstLine(sr.Message.Text)),
+		Description: firstNonEmpty(sr.Message.Text, rule.FullDescription.Text),
+		PackageName: uri,
+		Remediation: rule.Help.Text,
+	}
+	if v.UniqueID == "" {
+		v.UniqueID = sr.PartialFingerprints["primaryLocationLineHash"]
+	}
+	if s := strings.TrimSpace(rule.Properties.SecuritySeverity); s != "" {
+		score, err := strconv.ParseFloat(s, 64)
+		if err != nil || score < 0 || score > 10 {
+			return v, fmt.Errorf("invalid security-severity %q", s)
+		}
+		v.CVSS = score
+	}
+	if v.CVSS > 0 {
+		v.Severity = vuln.CVSSSeverity(v.CVSS)
+	} else {
+		level := firstNonEmpty(sr.Level, rule.Default.Level, "warning")
+		switch level {
+		case "error":
+			v.Severity = vuln.High
+		case "warning":
+			v.Severity = vuln.Medium
+		case "note", "none":
+			v.Severity = vuln.Low
+		default:
+			return v, fmt.Errorf("invalid level %q", level)
+		}
+	}
+	if p := rule.Properties.Precision; p != "" {
+		if c, err := parseConfidence(strings.TrimPrefix(p, "very-")); err == nil {
+			v.Confidence = c
+		}
+	}
+	return v, nil
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestReadSARIF(t *testing.T) {
+	f, err := os.Open("testdata/codeql.sarif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := ReadSARIF(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 3 {
+		t.Fatalf("read %d findings: %+v", len(res.Vulns), res.Vulns)
+	}
+
+	sqli := res.Vulns[0]
+	if sqli.Identifier != "js/sql-injection" || sqli.Source != "codeql" || sqli.CVSS != 8.8 || sqli.Severity != vuln.High {
+		t.Errorf("SQL injection %+v", sqli)
+	}
+	if sqli.AssetName != "apply-api" || sqli.AssetID != "https://github.com/acme-inc/apply-api" || sqli.PackageName != "src/db.js" {
+		t.Errorf("SQL injection location %q %q %q", sqli.AssetName, sqli.AssetID, sqli.PackageName)
+	}
+	if sqli.Title != "Database query built from user-controlled sources" || sqli.Remediation != "Use a parameterized query." ||
+		sqli.Confidence != 1 || sqli.UniqueID != "5e1f3c2a9b7d0e41:1" || sqli.FirstDetectedDate != vuln.NewDate(2025, 2, 4) {
+		t.Errorf("SQL injection details %+v", sqli)
+	}
+	// No security-severity: the rule's default level decides.
+	if unused := res.Vulns[1]; unused.Severity != vuln.Low || unused.CVSS != 0 || unused.Confidence != 1 || unused.UniqueID != "sarif-2" {
+		t.Errorf("unused variable %+v", unused)
+	}
+	// A rule of an extension, referenced by rule.id; its score outranks
+	// the result's level.
+	if xss := res.Vulns[2]; xss.Identifier != "js/xss" || xss.Severity != vuln.Critical || xss.Title != "Client-side cross-site scripting" {
+		t.Errorf("XSS %+v", xss)
+	}
+
+	if len(res.Skipped) != 2 || res.Skipped[0].Line != 4 || res.Skipped[1].Line != 5 ||
+		!strings.Contains(res.Skipped[1].Err.Error(), `invalid level "fatal"`) || len(res.Skipped[1].Record) != len(res.Header) {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
+
+func TestReadSARIFVersion(t *testing.T) {
+	if _, err := ReadSARIF(strings.NewReader(`{"version": "2.0.0", "runs": []}`)); err == nil || !strings.Contains(err.Error(), "unsupported version") {
+		t.Errorf("ReadSARIF(2.0.0) = %v", err)
+	}
+}
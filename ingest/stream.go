@@ -0,0 +1,66 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// CSVStream reads a CSV export a row at a time, for inputs too large to
+// hold in memory. Rows are read as ReadCSV reads them.
+type CSVStream struct {
+	cr      *csv.Reader
+	res     *Result // the header's columns; Vulns and Skipped stay empty
+	columns map[Field]int
+}
+
+// NewCSVStream reads the header row of the CSV export r and returns a
+// stream of its findings.
+func NewCSVStream(r io.Reader) (*CSVStream, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("input is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	res, columns, err := newResult(header)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVStream{cr: cr, res: res, columns: columns}, nil
+}
+
+// Header returns the input's header row as read.
+func (s *CSVStream) Header() []string { return s.res.Header }
+
+// CustomColumns returns the headers of the columns that map to no field,
+// as Result.CustomColumns.
+func (s *CSVStream) CustomColumns() []string { return s.res.CustomColumns }
+
+// DropCustomFields stops the stream reading the custom columns.
+func (s *CSVStream) DropCustomFields() { s.res.DropCustomFields() }
+
+// Next returns the finding of the next row. A row that is skipped is
+// returned as a *RowError, after which the stream goes on; at the end of
+// the input the error is io.EOF. Any other error ends the stream.
+func (s *CSVStream) Next() (vuln.Vulnerability, error) {
+	record, err := s.cr.Read()
+	if err != nil {
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			return vuln.Vulnerability{}, &RowError{Line: perr.StartLine, Record: record, Err: perr.Err}
+		}
+		return vuln.Vulnerability{}, err
+	}
+	line, _ := s.cr.FieldPos(0)
+	v, rerr := s.res.parse(line, record, s.columns)
+	if rerr != nil {
+		return v, rerr
+	}
+	return v, nil
+}
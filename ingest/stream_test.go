@@ -0,0 +1,47 @@
+package ingest
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCSVStream(t *testing.T) {
+	const in = "\ufeffIdentifier,CVSS,Severity,Owner Notes\nCVE-1,5.0,Medium,keep\nCVE-2,5.0,Severe,\nCVE-3,9.8,Critical,\n"
+	s, err := NewCSVStream(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Header()[0] != "Identifier" || !reflect.DeepEqual(s.CustomColumns(), []string{"Owner Notes"}) {
+		t.Errorf("header %q, custom columns %q", s.Header(), s.CustomColumns())
+	}
+	v, err := s.Next()
+	if err != nil || v.Identifier != "CVE-1" || v.CustomFields["Owner Notes"] != "keep" {
+		t.Fatalf("first row = %+v, %v", v, err)
+	}
+	var rerr *RowError
+	if _, err := s.Next(); !errors.As(err, &rerr) || rerr.Line != 3 {
+		t.Fatalf("second row error = %v", err)
+	}
+	s.DropCustomFields()
+	if v, err := s.Next(); err != nil || v.CVSS != 9.8 || v.CustomFields != nil {
+		t.Fatalf("third row = %+v, %v", v, err)
+	}
+	if _, err := s.Next(); err != io.EOF {
+		t.Errorf("at end: %v", err)
+	}
+
+	// The stream reads what ReadCSV reads.
+	res, err := ReadCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 || len(res.Skipped) != 1 || res.Skipped[0].Line != 3 {
+		t.Errorf("ReadCSV = %d findings, skipped %+v", len(res.Vulns), res.Skipped)
+	}
+	if _, err := NewCSVStream(strings.NewReader("Title\nx\n")); err == nil {
+		t.Error("header without required columns accepted")
+	}
+}
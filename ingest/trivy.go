@@ -0,0 +1,237 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/imageref"
+	"github.com/VioletX-Dev/devsecops-test/platform"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// trivyHeader is the Result.Header of a Trivy input: the fields a skipped
+// vulnerability is quarantined with.
+var trivyHeader = []string{"Target", "VulnerabilityID", "PkgName", "InstalledVersion", "Severity"}
+
+// trivyReport is the subset of a Trivy JSON report (schema version 2) that
+// findings are read from.
+type trivyReport struct {
+	SchemaVersion int       `json:"SchemaVersion"`
+	CreatedAt     time.Time `json:"CreatedAt"`
+	ArtifactName  string    `json:"ArtifactName"`
+	ArtifactType  string    `json:"ArtifactType"`
+	Metadata      struct {
+		OS struct {
+			Family string `json:"Family"`
+			Name   string `json:"Name"`
+		} `json:"OS"`
+		ImageConfig struct {
+			Architecture string `json:"architecture"`
+		} `json:"ImageConfig"`
+		RepoTags    []string `json:"RepoTags"`
+		RepoDigests []string `json:"RepoDigests"`
+	} `json:"Metadata"`
+	Results []struct {
+		Target          string      `json:"Target"`
+		Class           string      `json:"Class"`
+		Type            string      `json:"Type"`
+		Vulnerabilities []trivyVuln `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+type trivyVuln struct {
+	VulnerabilityID  string               `json:"VulnerabilityID"`
+	PkgName          string               `json:"PkgName"`
+	InstalledVersion string               `json:"InstalledVersion"`
+	FixedVersion     string               `json:"FixedVersion"`
+	Severity         string               `json:"Severity"`
+	SeveritySource   string               `json:"SeveritySource"`
+	Title            string               `json:"Title"`
+	Description      string               `json:"Description"`
+	CVSS             map[string]trivyCVSS `json:"CVSS"`
+}
+
+type trivyCVSS struct {
+	V3Vector string  `json:"V3Vector"`
+	V3Score  float64 `json:"V3Score"`
+	V2Score  float64 `json:"V2Score"`
+}
+
+// trivyPrefixes maps Trivy's language package types to the package-name
+// prefixes that mark their ecosystem; see vuln.Vulnerability.Ecosystem.
+var trivyPrefixes = map[string]string{
+	"npm": "npm-", "yarn": "npm-", "pnpm": "npm-", "node-pkg": "npm-",
+	"gomod": "go-", "gobinary": "go-",
+	"pip": "pip-", "pipenv": "pip-", "poetry": "pip-", "python-pkg": "pip-",
+	"jar": "maven-", "pom": "maven-", "gradle": "maven-",
+}
+
+// ReadTrivy reads a Trivy JSON report, with one finding per entry of
+// Results[].Vulnerabilities:
+//
+//   - Identifier is the VulnerabilityID, and Source "trivy".
+//   - AssetName and AssetID are the ArtifactName. A container image's
+//     ImageDigest and ImageTags come from its repository digests and tags,
+//     so its findings are keyed by digest; see package imageref.
+//   - PackageName is the PkgName, prefixed with its ecosystem for language
+//     packages, as in "npm-axios"; InstalledVersion and FixedVersion are
+//     Trivy's.
+//   - CVSS and CVSSVector come from the CVSS entry of the SeveritySource,
+//     else NVD's, else the highest: the v3 score, or the v2 score without
+//     one, or the vector's score without either.
+//   - Severity is Trivy's; UNKNOWN follows from the CVSS score.
+//   - FirstDetectedDate is the day the report was created.
+//   - OS, OSFamily and OSVersion come from the detected OS, and Arch from
+//     the image configuration; see setPlatform.
+//
+// Vulnerabilities without an ID or with an unknown severity are skipped,
+// with RowError.Line holding their 1-based position in the report. An
+// error is returned only when the report cannot be read at all.
+func ReadTrivy(r io.Reader) (*Result, error) {
+	var rep trivyReport
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("Trivy: %w", err)
+	}
+	if rep.SchemaVersion != 2 {
+		return nil, fmt.Errorf("Trivy: unsupported schema version %d (want 2)", rep.SchemaVersion)
+	}
+	var digest string
+	if len(rep.Metadata.RepoDigests) > 0 {
+		_, d, _ := strings.Cut(rep.Metadata.RepoDigests[0], "@")
+		digest, _ = imageref.Digest(d)
+	}
+	tags := repoTags(rep.Metadata.RepoTags)
+	var detected vuln.Date
+	if !rep.CreatedAt.IsZero() {
+		detected = vuln.NewDate(rep.CreatedAt.Year(), rep.CreatedAt.Month(), rep.CreatedAt.Day())
+	}
+
+	res := &Result{Header: trivyHeader}
+	n := 0
+	for _, result := range rep.Results {
+		prefix := ""
+		if result.Class == "lang-pkgs" {
+			prefix = trivyPrefixes[result.Type]
+		}
+		for i := range result.Vulnerabilities {
+			n++
+			tv := &result.Vulnerabilities[i]
+			v, err := trivyFinding(tv, prefix)
+			if err != nil {
+				res.Skipped = append(res.Skipped, RowError{Line: n, Record: []string{result.Target, tv.VulnerabilityID, tv.PkgName, tv.InstalledVersion, tv.Severity}, Err: err})
+				continue
+			}
+			v.UniqueID = fmt.Sprintf("%s-%d", FormatTrivy, n)
+			v.AssetName, v.AssetID = rep.ArtifactName, rep.ArtifactName
+			if rep.ArtifactType == "container_image" {
+				v.ImageDigest, v.ImageTags = digest, tags
+			}
+			v.FirstDetectedDate = detected
+			setPlatform(&v, rep.Metadata.OS.Family, rep.Metadata.OS.Name, rep.Metadata.ImageConfig.Architecture)
+			res.Vulns = append(res.Vulns, v)
+		}
+	}
+	return res, nil
+}
+
+// repoTags returns the tags of the image references refs, as in "v1.4"
+// for "acme/api:v1.4".
+func repoTags(refs []string) []string {
+	var tags []string
+	for _, ref := range refs {
+		if i := strings.LastIndexByte(ref, ':'); i > strings.LastIndexByte(ref, '/') {
+			tags = append(tags, ref[i+1:])
+		}
+	}
+	return tags
+}
+
+// setPlatform sets v's platform from a scanner's detected OS family,
+// version and architecture. A family or architecture the platform package
+// does not know is left out, and only the first word of the version is
+// kept, as in "2" for Amazon Linux "2 (Karoo)".
+func setPlatform(v *vuln.Vulnerability, family, version, arch string) {
+	if f, err := platform.ParseFamily(family); err == nil {
+		v.OSFamily = f
+		if fields := strings.Fields(version); len(fields) > 0 {
+			v.OSVersion = fields[0]
+		}
+		v.OS = platform.Name(v.OSFamily, v.OSVersion)
+	}
+	if a, err := platform.ParseArch(arch); err == nil {
+		v.Arch = a
+	}
+}
+
+// trivyFinding maps one vulnerability.
+func trivyFinding(tv *trivyVuln, prefix string) (vuln.Vulnerability, error) {
+	v := vuln.Vulnerability{
+		Identifier:       strings.TrimSpace(tv.VulnerabilityID),
+		Source:           FormatTrivy,
+		Title:            firstNonEmpty(tv.Title, tv.VulnerabilityID),
+		Description:      tv.Description,
+		PackageName:      prefix + tv.PkgName,
+		InstalledVersion: tv.InstalledVersion,
+		FixedVersion:     tv.FixedVersion,
+	}
+	if v.Identifier == "" {
+		return v, errors.New("missing VulnerabilityID")
+	}
+	if kb, ok := vuln.ParseKB(v.Identifier); ok {
+		v.Identifier = kb
+	}
+	if v.FixedVersion != "" {
+		v.Remediation = v.UpgradeRemediation()
+		v.Fixability = "Fixable"
+	}
+
+	score, ok := tv.CVSS[tv.SeveritySource]
+	if !ok {
+		score, ok = tv.CVSS["nvd"]
+	}
+	if !ok {
+		sources := make([]string, 0, len(tv.CVSS))
+		for src := range tv.CVSS {
+			sources = append(sources, src)
+		}
+		sort.Strings(sources)
+		for _, src := range sources {
+			s := tv.CVSS[src]
+			if s.V3Score > score.V3Score || score.V3Score == 0 && s.V2Score > score.V2Score {
+				score = s
+			}
+		}
+	}
+	v.CVSS = score.V3Score
+	if v.CVSS == 0 {
+		v.CVSS = score.V2Score
+	}
+	if v.CVSS < 0 || v.CVSS > 10 {
+		return v, fmt.Errorf("CVSS %v out of range 0-10", v.CVSS)
+	}
+	// A vector this tool cannot read is left out rather than losing the
+	// finding.
+	if vec, err := cvss.Parse(score.V3Vector); err == nil {
+		v.CVSSVector = score.V3Vector
+		if v.CVSS == 0 {
+			v.CVSS = vec.Score()
+		}
+	}
+
+	if strings.EqualFold(tv.Severity, "UNKNOWN") || tv.Severity == "" {
+		v.Severity = vuln.CVSSSeverity(v.CVSS)
+		return v, nil
+	}
+	sev, err := vuln.ParseSeverity(tv.Severity)
+	if err != nil {
+		return v, err
+	}
+	v.Severity = sev
+	return v, nil
+}
@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestReadTrivy(t *testing.T) {
+	f, err := os.Open("testdata/trivy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	res, err := ReadTrivy(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 3 {
+		t.Fatalf("read %d findings: %+v", len(res.Vulns), res.Vulns)
+	}
+
+	ssh := res.Vulns[0]
+	if ssh.Identifier != "CVE-2024-6387" || ssh.Source != "trivy" || ssh.PackageName != "openssh-client" || ssh.FixedVersion != "1:8.9p1-3ubuntu0.10" || ssh.Severity != vuln.High {
+		t.Errorf("openssh %+v", ssh)
+	}
+	// The severity source has no CVSS entry, so NVD's is used.
+	if ssh.CVSS != 8.1 || ssh.CVSSVector != "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:H/I:H/A:H" || ssh.Ecosystem() != vuln.EcosystemOSPkg {
+		t.Errorf("openssh score %v %q", ssh.CVSS, ssh.CVSSVector)
+	}
+	if ssh.AssetName != "253710526682.dkr.ecr.us-west-2.amazonaws.com/hasura:v2.36.1" || ssh.ImageDigest != "sha256:4be4aa5d16a227b5b1e9075b6ff3bd4dd2e200543e3a1eb3e2e55bb57e4ae1f4" ||
+		strings.Join(ssh.ImageTags, ",") != "v2.36.1,latest" || ssh.FirstDetectedDate != vuln.NewDate(2025, 2, 4) || ssh.UniqueID != "trivy-1" {
+		t.Errorf("openssh asset %+v", ssh)
+	}
+	if ssh.OS != "Ubuntu 22.04" || ssh.OSFamily != "ubuntu" || ssh.OSVersion != "22.04" || ssh.Arch != "x86_64" {
+		t.Errorf("openssh platform %q %q %q %q", ssh.OS, ssh.OSFamily, ssh.OSVersion, ssh.Arch)
+	}
+	if gcc := res.Vulns[1]; gcc.Severity != vuln.Medium || gcc.CVSS != 4.8 || gcc.CVSSVector == "" || gcc.HasFix() || gcc.Remediation != "" {
+		t.Errorf("unknown severity %+v", gcc)
+	}
+	if braces := res.Vulns[2]; braces.PackageName != "npm-braces" || braces.Ecosystem() != vuln.EcosystemNPM || braces.Remediation != "Update npm-braces from 3.0.2 to 3.0.3." {
+		t.Errorf("npm package %+v", braces)
+	}
+
+	if len(res.Skipped) != 2 || res.Skipped[0].Line != 4 || res.Skipped[1].Line != 5 || len(res.Skipped[1].Record) != len(res.Header) {
+		t.Errorf("skipped %v", res.Skipped)
+	}
+}
+
+func TestReadTrivySchemaVersion(t *testing.T) {
+	if _, err := ReadTrivy(strings.NewReader(`{"SchemaVersion": 1, "Results": []}`)); err == nil || !strings.Contains(err.Error(), "schema version") {
+		t.Errorf("ReadTrivy(schema 1) = %v", err)
+	}
+}
@@ -0,0 +1,383 @@
+package ingest
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxXLSXPart bounds the decompressed size of any one part of a workbook,
+// so that a crafted file cannot exhaust memory.
+const maxXLSXPart = 512 << 20
+
+// ReadXLSX reads one worksheet of an XLSX workbook: the sheet named sheet,
+// or the first sheet when sheet is "". The first row is the header, and rows
+// are mapped and validated as in ReadCSV, with RowError.Line holding the
+// spreadsheet row number. Cells formatted as dates are read as dates, not
+// as Excel serial numbers.
+func ReadXLSX(r io.ReaderAt, size int64, sheet string) (*Result, error) {
+	rows, err := readSheet(r, size, sheet)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("sheet is empty")
+	}
+	res, columns, err := newResult(rows[0].cells)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows[1:] {
+		if blank(row.cells) {
+			continue
+		}
+		res.add(row.num, row.cells, columns)
+	}
+	return res, nil
+}
+
+type sheetRow struct {
+	num   int
+	cells []string
+}
+
+func blank(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// workbook holds the parts of an XLSX package needed to read cell text.
+type workbook struct {
+	files   map[string]*zip.File
+	strings []string
+	// dateStyles marks the cell style indexes with a date number format.
+	dateStyles map[int]bool
+	date1904   bool
+}
+
+func readSheet(r io.ReaderAt, size int64, sheet string) ([]sheetRow, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not an XLSX workbook: %w", err)
+	}
+	wb := &workbook{files: make(map[string]*zip.File)}
+	for _, f := range zr.File {
+		wb.files[f.Name] = f
+	}
+
+	target, err := wb.sheetPart(sheet)
+	if err != nil {
+		return nil, err
+	}
+	if err := wb.readSharedStrings(); err != nil {
+		return nil, err
+	}
+	if err := wb.readStyles(); err != nil {
+		return nil, err
+	}
+
+	var ws struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R  string   `xml:"r,attr"`
+				T  string   `xml:"t,attr"`
+				S  int      `xml:"s,attr"`
+				V  string   `xml:"v"`
+				Is richText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := wb.decode(target, &ws); err != nil {
+		return nil, err
+	}
+
+	rows := make([]sheetRow, 0, len(ws.Rows))
+	for i, row := range ws.Rows {
+		num := row.R
+		if num == 0 {
+			num = i + 1
+		}
+		var cells []string
+		for _, c := range row.Cells {
+			col := len(cells)
+			if c.R != "" {
+				if col, err = columnIndex(c.R); err != nil {
+					return nil, fmt.Errorf("row %d: %w", num, err)
+				}
+			}
+			for len(cells) < col {
+				cells = append(cells, "")
+			}
+			text, err := wb.cellText(c.T, c.S, c.V, c.Is.text())
+			if err != nil {
+				return nil, fmt.Errorf("cell %s: %w", c.R, err)
+			}
+			if col < len(cells) {
+				cells[col] = text
+			} else {
+				cells = append(cells, text)
+			}
+		}
+		rows = append(rows, sheetRow{num: num, cells: cells})
+	}
+	return rows, nil
+}
+
+// sheetPart returns the package path of the named or first worksheet.
+func (wb *workbook) sheetPart(name string) (string, error) {
+	var book struct {
+		Pr struct {
+			Date1904 string `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := wb.decode("xl/workbook.xml", &book); err != nil {
+		return "", err
+	}
+	wb.date1904 = book.Pr.Date1904 == "1" || book.Pr.Date1904 == "true"
+
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := wb.decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+
+	var names []string
+	for _, s := range book.Sheets {
+		names = append(names, s.Name)
+		if name != "" && s.Name != name {
+			continue
+		}
+		for _, rel := range rels.Rels {
+			if rel.ID != s.ID {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				return strings.TrimPrefix(rel.Target, "/"), nil
+			}
+			return path.Join("xl", rel.Target), nil
+		}
+		return "", fmt.Errorf("sheet %q has no worksheet part", s.Name)
+	}
+	if name == "" {
+		return "", errors.New("workbook has no sheets")
+	}
+	return "", fmt.Errorf("no sheet %q; sheets are %s", name, strings.Join(names, ", "))
+}
+
+// richText is a shared or inline string: plain text or formatted runs.
+// Phonetic runs (rPh) are not part of the text.
+type richText struct {
+	T    *string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (rt richText) text() string {
+	if rt.T != nil {
+		return *rt.T
+	}
+	var b strings.Builder
+	for _, r := range rt.Runs {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+func (wb *workbook) readSharedStrings() error {
+	if wb.files["xl/sharedStrings.xml"] == nil {
+		return nil
+	}
+	var sst struct {
+		Items []richText `xml:"si"`
+	}
+	if err := wb.decode("xl/sharedStrings.xml", &sst); err != nil {
+		return err
+	}
+	wb.strings = make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		wb.strings[i] = si.text()
+	}
+	return nil
+}
+
+func (wb *workbook) readStyles() error {
+	wb.dateStyles = make(map[int]bool)
+	if wb.files["xl/styles.xml"] == nil {
+		return nil
+	}
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		Xfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := wb.decode("xl/styles.xml", &styles); err != nil {
+		return err
+	}
+	custom := make(map[int]string)
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = f.Code
+	}
+	for i, xf := range styles.Xfs {
+		if code, ok := custom[xf.NumFmtID]; ok {
+			wb.dateStyles[i] = isDateFormat(code)
+		} else {
+			wb.dateStyles[i] = builtinDateFormat(xf.NumFmtID)
+		}
+	}
+	return nil
+}
+
+// builtinDateFormat reports whether a built-in number format ID is a date
+// or date-time format.
+func builtinDateFormat(id int) bool {
+	return id >= 14 && id <= 22 || id >= 27 && id <= 36 || id >= 45 && id <= 47 || id >= 50 && id <= 58
+}
+
+// isDateFormat reports whether a custom number format code shows a date:
+// it has a day or year token outside quoted text, escapes and [...]
+// sections.
+func isDateFormat(code string) bool {
+	inQuote, inBracket := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inQuote:
+			inQuote = c != '"'
+		case inBracket:
+			inBracket = c != ']'
+		case c == '"':
+			inQuote = true
+		case c == '[':
+			inBracket = true
+		case c == '\\':
+			i++
+		case c == ';':
+			// Only the first section applies to positive numbers.
+			return false
+		default:
+			switch c | 0x20 {
+			case 'd', 'y':
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cellText returns a cell's value as text.
+func (wb *workbook) cellText(typ string, style int, v, inline string) (string, error) {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(wb.strings) {
+			return "", fmt.Errorf("invalid shared string index %q", v)
+		}
+		return wb.strings[i], nil
+	case "inlineStr":
+		return inline, nil
+	case "b":
+		if v == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case "str", "e", "d":
+		return v, nil
+	}
+	if v == "" {
+		return "", nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid number %q", v)
+	}
+	if wb.dateStyles[style] {
+		return wb.serialDate(f), nil
+	}
+	// Excel writes binary floats in full, as in 7.0999999999999996; the
+	// shortest round-tripping form is what the user typed.
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
+
+// serialDate converts an Excel serial date to YYYY-MM-DD, with the time of
+// day when it is not midnight.
+func (wb *workbook) serialDate(serial float64) string {
+	// Day 0 is 1899-12-30 rather than 12-31 because Excel counts the
+	// nonexistent 1900-02-29.
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if wb.date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	secs := math.Round((serial - days) * 86400)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(secs) * time.Second)
+	if secs == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// columnIndex returns the 0-based column of a cell reference like "AB12".
+func columnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, c := range ref {
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A'+1)
+		n++
+	}
+	if n == 0 || n > 3 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+// decode unmarshals the package part name into v.
+func (wb *workbook) decode(name string, v any) error {
+	f := wb.files[name]
+	if f == nil {
+		return fmt.Errorf("workbook has no %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	lr := &io.LimitedReader{R: rc, N: maxXLSXPart + 1}
+	if err := xml.NewDecoder(lr).Decode(v); err != nil {
+		if lr.N <= 0 {
+			return fmt.Errorf("%s is larger than %d bytes", name, maxXLSXPart)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
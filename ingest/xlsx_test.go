@@ -0,0 +1,125 @@
+package ingest
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// buildXLSX returns a minimal workbook with the given parts, plus the
+// workbook, relationships and styles parts every test needs. sheets are
+// worksheet XML keyed by sheet name, in order.
+func buildXLSX(t *testing.T, date1904 bool, sharedStrings string, sheets ...[2]string) []byte {
+	t.Helper()
+	var wbSheets, rels strings.Builder
+	parts := map[string]string{}
+	for i, s := range sheets {
+		id := string(rune('1' + i))
+		wbSheets.WriteString(`<sheet name="` + s[0] + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		rels.WriteString(`<Relationship Id="rId` + id + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + id + `.xml"/>`)
+		parts["xl/worksheets/sheet"+id+".xml"] = `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + s[1] + `</sheetData></worksheet>`
+	}
+	pr := ""
+	if date1904 {
+		pr = `<workbookPr date1904="1"/>`
+	}
+	parts["xl/workbook.xml"] = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		pr + `<sheets>` + wbSheets.String() + `</sheets></workbook>`
+	parts["xl/_rels/workbook.xml.rels"] = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + rels.String() + `</Relationships>`
+	// Style 1 is a custom m/d/yyyy format, style 2 the built-in date
+	// format 14, style 3 a quoted literal that only looks like a date.
+	parts["xl/styles.xml"] = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts><numFmt numFmtId="164" formatCode="m/d/yyyy"/><numFmt numFmtId="165" formatCode="0.0&quot; days&quot;"/></numFmts>` +
+		`<cellXfs><xf numFmtId="0"/><xf numFmtId="164"/><xf numFmtId="14"/><xf numFmtId="165"/></cellXfs></styleSheet>`
+	if sharedStrings != "" {
+		parts["xl/sharedStrings.xml"] = `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` + sharedStrings + `</sst>`
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const findingsSheet = `
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c><c r="F1" t="s"><v>5</v></c></row>
+<row r="2"><c r="A2" t="s"><v>6</v></c><c r="B2"><v>7.0999999999999996</v></c><c r="C2" t="inlineStr"><is><t>High</t></is></c><c r="D2" s="1"><v>45726</v></c><c r="E2" s="2"><v>45658.5</v></c><c r="F2" t="s"><v>7</v></c></row>
+<row r="3"><c r="A3" t="str"><v>CVE-2024-2</v></c><c r="C3" t="inlineStr"><is><t>Low</t></is></c><c r="F3" s="3"><v>12</v></c></row>
+<row r="5"><c r="A5" t="inlineStr"><is><t>CVE-2024-3</t></is></c><c r="B5"><v>5</v></c><c r="C5" t="inlineStr"><is><t>Severe</t></is></c></row>
+<row r="6"><c r="A6" t="inlineStr"><is><t></t></is></c></row>`
+
+// Index 7 is rich text whose phonetic run is not part of the value.
+const findingsStrings = `<si><t>CVE Number</t></si><si><t>CVSS Score</t></si><si><t>Severity</t></si>` +
+	`<si><t>Audit Due Date</t></si><si><t>Discovery Date</t></si><si><t>Title</t></si><si><t>CVE-2024-1</t></si>` +
+	`<si><r><t>Heap </t></r><r><rPr><b/></rPr><t>overflow</t></r><rPh><t>ignored</t></rPh></si>`
+
+func TestReadXLSX(t *testing.T) {
+	b := buildXLSX(t, false, findingsStrings,
+		[2]string{"Summary", `<row r="1"><c r="A1" t="inlineStr"><is><t>not findings</t></is></c></row>`},
+		[2]string{"Findings", findingsSheet})
+	res, err := ReadXLSX(bytes.NewReader(b), int64(len(b)), "Findings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Vulns) != 2 {
+		t.Fatalf("read %d findings, skipped %v", len(res.Vulns), res.Skipped)
+	}
+	v := res.Vulns[0]
+	if v.Identifier != "CVE-2024-1" || v.CVSS != 7.1 || v.Severity != vuln.High || v.Title != "Heap overflow" {
+		t.Errorf("finding = %+v", v)
+	}
+	// 45726 is 2025-03-10; 45658.5 is noon on 2025-01-01, truncated to the day.
+	if v.DueDate != vuln.NewDate(2025, 3, 10) || v.FirstDetectedDate != vuln.NewDate(2025, 1, 1) {
+		t.Errorf("dates = %v, %v", v.DueDate, v.FirstDetectedDate)
+	}
+	// A quoted " days" suffix is not a date format.
+	if w := res.Vulns[1]; w.Identifier != "CVE-2024-2" || w.Title != "12" || w.CVSS != 0 {
+		t.Errorf("sparse row = %+v", w)
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0].Line != 5 || !strings.Contains(res.Skipped[0].Err.Error(), "Severe") {
+		t.Errorf("skipped = %+v", res.Skipped)
+	}
+
+	// Without a name, the first sheet is read; it lacks required columns.
+	if _, err := ReadXLSX(bytes.NewReader(b), int64(len(b)), ""); err == nil || !strings.Contains(err.Error(), "missing required columns") {
+		t.Errorf("first sheet: err = %v", err)
+	}
+	if _, err := ReadXLSX(bytes.NewReader(b), int64(len(b)), "Nope"); err == nil || !strings.Contains(err.Error(), "Summary, Findings") {
+		t.Errorf("unknown sheet: err = %v", err)
+	}
+}
+
+func TestReadXLSXDate1904(t *testing.T) {
+	sheet := `<row r="1"><c r="A1" t="inlineStr"><is><t>Identifier</t></is></c><c r="B1" t="inlineStr"><is><t>Severity</t></is></c>` +
+		`<c r="C1" t="inlineStr"><is><t>CVSS</t></is></c><c r="D1" t="inlineStr"><is><t>Due date</t></is></c></row>` +
+		`<row r="2"><c r="A2" t="inlineStr"><is><t>CVE-1</t></is></c><c r="B2" t="inlineStr"><is><t>Low</t></is></c>` +
+		`<c r="C2"><v>1</v></c><c r="D2" s="2"><v>44264</v></c></row>`
+	b := buildXLSX(t, true, "", [2]string{"Sheet1", sheet})
+	res, err := ReadXLSX(bytes.NewReader(b), int64(len(b)), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// In the 1904 system, serial 44264 is 2025-03-10.
+	if len(res.Vulns) != 1 || res.Vulns[0].DueDate != vuln.NewDate(2025, 3, 10) {
+		t.Errorf("vulns = %+v, skipped %v", res.Vulns, res.Skipped)
+	}
+}
+
+func TestReadXLSXNotAWorkbook(t *testing.T) {
+	b := []byte("Identifier,CVSS,Severity\n")
+	if _, err := ReadXLSX(bytes.NewReader(b), int64(len(b)), ""); err == nil {
+		t.Error("CSV bytes: want error")
+	}
+}
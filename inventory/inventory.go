@@ -0,0 +1,142 @@
+// Package inventory sets the business context of findings from an asset
+// inventory: how critical each asset is to the business, the environment
+// it runs in and who owns it. Scoring weighs findings on business-critical
+// production assets above those on a developer's sandbox, and the owner
+// tells whoever triages a finding whom to hand it to.
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Asset is the business context of one asset.
+type Asset struct {
+	// Asset is the asset's ID or name, as in the input, or a glob pattern
+	// asset names or IDs match, as in prod-*.
+	Asset string `json:"asset"`
+	// Criticality is one of vuln.Criticalities and Environment one of
+	// vuln.Environments, or another name for one, as in production.
+	Criticality string `json:"criticality,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+}
+
+// Inventory is the assets of an inventory file.
+type Inventory struct {
+	Assets []Asset `json:"assets"`
+}
+
+// Load reads an asset inventory, as YAML when it is named .yaml or .yml
+// and JSON otherwise:
+//
+//	assets:
+//	  - asset: match-frontend
+//	    criticality: business_critical
+//	    environment: prod
+//	    owner: payments-oncall@example.com
+//	  - asset: "*-staging"
+//	    environment: staging
+//
+// Unknown keys, criticalities and environments are rejected, so a typo
+// does not leave an asset weighted as if it had none, as are assets
+// without an ID or name and entries that set nothing.
+func Load(file string) (*Inventory, error) {
+	b, err := config.ReadDocument(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	inv := &Inventory{}
+	if err := dec.Decode(inv); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i := range inv.Assets {
+		a := &inv.Assets[i]
+		a.Asset, a.Owner = strings.TrimSpace(a.Asset), strings.TrimSpace(a.Owner)
+		switch {
+		case a.Asset == "":
+			return nil, fmt.Errorf("%s: asset %d has no ID or name", file, i+1)
+		case a.Criticality == "" && a.Environment == "" && a.Owner == "":
+			return nil, fmt.Errorf("%s: %s: sets no criticality, environment or owner", file, a.Asset)
+		}
+		if _, err := path.Match(a.Asset, ""); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", file, a.Asset, err)
+		}
+		if a.Criticality != "" {
+			if a.Criticality, err = vuln.ParseCriticality(a.Criticality); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", file, a.Asset, err)
+			}
+		}
+		if a.Environment != "" {
+			if a.Environment, err = vuln.ParseEnvironment(a.Environment); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", file, a.Asset, err)
+			}
+		}
+	}
+	return inv, nil
+}
+
+// Find returns the inventory entry of v's asset: the one naming its ID,
+// else its name, else the first pattern either matches. It returns nil
+// when the inventory has none.
+func (inv *Inventory) Find(v *vuln.Vulnerability) *Asset {
+	id, name := strings.TrimSpace(v.AssetID), strings.TrimSpace(v.AssetName)
+	for _, key := range []string{id, name} {
+		if key == "" {
+			continue
+		}
+		for i := range inv.Assets {
+			if inv.Assets[i].Asset == key {
+				return &inv.Assets[i]
+			}
+		}
+	}
+	for i := range inv.Assets {
+		a := &inv.Assets[i]
+		if !strings.ContainsAny(a.Asset, "*?[") {
+			continue
+		}
+		for _, key := range []string{name, id} {
+			if ok, _ := path.Match(a.Asset, key); ok && key != "" {
+				return a
+			}
+		}
+	}
+	return nil
+}
+
+// Apply sets the criticality, environment and owner of every finding of
+// vs on an asset in the inventory. Values from the input are kept. It
+// counts the findings whose asset it found.
+func (inv *Inventory) Apply(vs []vuln.Vulnerability) int {
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		a := inv.Find(v)
+		if a == nil {
+			continue
+		}
+		n++
+		for _, f := range []struct {
+			dst *string
+			src string
+		}{
+			{&v.Criticality, a.Criticality},
+			{&v.Environment, a.Environment},
+			{&v.Owner, a.Owner},
+		} {
+			if *f.dst == "" {
+				*f.dst = f.src
+			}
+		}
+	}
+	return n
+}
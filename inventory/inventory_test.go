@@ -0,0 +1,91 @@
+package inventory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const assets = `# Maintained by the platform team.
+assets:
+  - asset: match-frontend
+    criticality: Business-Critical
+    environment: production
+    owner: payments-oncall@example.com
+  - asset: "*-staging"
+    environment: stage
+  - asset: i-0abc123
+    criticality: low
+  - asset: "match-*"
+    criticality: high
+    environment: prod
+`
+
+func TestLoad(t *testing.T) {
+	inv, err := Load(writeFile(t, "assets.yaml", assets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := inv.Assets[0]; a.Criticality != vuln.CriticalityBusinessCritical || a.Environment != vuln.EnvProduction || a.Owner != "payments-oncall@example.com" {
+		t.Errorf("match-frontend = %+v", a)
+	}
+	if a := inv.Assets[1]; a.Environment != vuln.EnvStaging {
+		t.Errorf("*-staging = %+v", a)
+	}
+
+	for name, content := range map[string]string{
+		"no asset":       "assets:\n  - criticality: high\n",
+		"sets nothing":   "assets:\n  - asset: web-1\n",
+		"criticality":    "assets:\n  - asset: web-1\n    criticality: vital\n",
+		"environment":    "assets:\n  - asset: web-1\n    environment: moon\n",
+		"unknown key":    "assets:\n  - asset: web-1\n    tier: 1\n",
+		"bad pattern":    "assets:\n  - asset: \"web-[\"\n    owner: ops\n",
+		"not a document": "assets: 7\n",
+	} {
+		if _, err := Load(writeFile(t, "assets.yaml", content)); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+	if _, err := Load(writeFile(t, "assets.json", `{"assets": [{"asset": "web-1", "owner": "ops"}]}`)); err != nil {
+		t.Errorf("JSON: %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	inv, err := Load(writeFile(t, "assets.yaml", assets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := []vuln.Vulnerability{
+		{AssetName: "match-frontend", AssetID: "464292713"},
+		{AssetName: "match-v1", AssetID: "i-0abc123"},              // the ID wins over the pattern
+		{AssetName: "match-api", Environment: vuln.EnvDevelopment}, // the input wins
+		{AssetName: "billing-staging"},
+		{AssetName: "hasura"},
+	}
+	if n := inv.Apply(vs); n != 4 {
+		t.Errorf("Apply = %d, want 4", n)
+	}
+	for i, want := range []struct{ criticality, environment, owner string }{
+		{vuln.CriticalityBusinessCritical, vuln.EnvProduction, "payments-oncall@example.com"},
+		{vuln.CriticalityLow, "", ""},
+		{vuln.CriticalityHigh, vuln.EnvDevelopment, ""},
+		{"", vuln.EnvStaging, ""},
+		{"", "", ""},
+	} {
+		if v := vs[i]; v.Criticality != want.criticality || v.Environment != want.environment || v.Owner != want.owner {
+			t.Errorf("%s: criticality %q, environment %q, owner %q; want %+v", v.AssetName, v.Criticality, v.Environment, v.Owner, want)
+		}
+	}
+}
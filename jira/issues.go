@@ -0,0 +1,277 @@
+package jira
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Status categories, as the Jira API keys them. Every status of a
+// workflow is in one.
+const (
+	CategoryToDo       = "new"
+	CategoryInProgress = "indeterminate"
+	CategoryDone       = "done"
+)
+
+// categories are the status categories by the names CSV exports give them.
+var categories = map[string]string{"to do": CategoryToDo, "in progress": CategoryInProgress, "done": CategoryDone}
+
+// Issue is the status of one Jira issue.
+type Issue struct {
+	Key    string
+	Status string
+	// StatusCategory is one of the categories, or "" when the export
+	// leaves it out.
+	StatusCategory string
+	// Resolution is "" for an unresolved issue.
+	Resolution string
+	// Text is the summary and description, which name the fingerprints
+	// of the findings the issue is for.
+	Text    string
+	Updated time.Time
+}
+
+// updatedLayouts are the layouts of the Updated column of CSV exports,
+// which follow the site's date format.
+var updatedLayouts = []string{"02/Jan/06 3:04 PM", "02/Jan/06 15:04", "2006-01-02 15:04", "2006-01-02T15:04:05.000-0700", time.RFC3339}
+
+// ReadCSV reads issues from a Jira CSV export, as Jira writes it from an
+// issue search: the Issue key and Status columns are required, and the
+// Status Category, Resolution, Summary, Description and Updated
+// columns are read when there.
+func ReadCSV(r io.Reader) ([]Issue, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Jira CSV: reading header: %w", err)
+	}
+	col := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, ok := col[h]; !ok {
+			col[h] = i
+		}
+	}
+	for _, name := range []string{"issue key", "status"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("Jira CSV: no %q column", name)
+		}
+	}
+	field := func(row []string, name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	var issues []Issue
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return issues, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Jira CSV: %w", err)
+		}
+		is := Issue{
+			Key: field(row, "issue key"), Status: field(row, "status"),
+			StatusCategory: categories[strings.ToLower(field(row, "status category"))],
+			Resolution:     field(row, "resolution"),
+			Text:           field(row, "summary") + "\n" + field(row, "description"),
+		}
+		if is.Key == "" {
+			return nil, fmt.Errorf("Jira CSV: line %d: no issue key", line)
+		}
+		if strings.EqualFold(is.Resolution, "Unresolved") {
+			is.Resolution = ""
+		}
+		for _, layout := range updatedLayouts {
+			if t, err := time.Parse(layout, field(row, "updated")); err == nil {
+				is.Updated = t
+				break
+			}
+		}
+		issues = append(issues, is)
+	}
+}
+
+// Issues returns the project's issues with Label, done or not, with their
+// status.
+func (s *Syncer) Issues(ctx context.Context) ([]Issue, error) {
+	api := &api{client: s.Client, cfg: s.Config}
+	if api.client == nil {
+		api.client = http.DefaultClient
+	}
+	jql := fmt.Sprintf("project = %s AND labels = %s ORDER BY updated ASC", quoteJQL(s.Config.Project), quoteJQL(Label))
+	var issues []Issue
+	for start := 0; ; {
+		var page struct {
+			Total  int `json:"total"`
+			Issues []struct {
+				Key    string `json:"key"`
+				Fields struct {
+					Status struct {
+						Name           string `json:"name"`
+						StatusCategory struct {
+							Key string `json:"key"`
+						} `json:"statusCategory"`
+					} `json:"status"`
+					Resolution *struct {
+						Name string `json:"name"`
+					} `json:"resolution"`
+					Summary     string `json:"summary"`
+					Description string `json:"description"`
+					Updated     string `json:"updated"`
+				} `json:"fields"`
+			} `json:"issues"`
+		}
+		req := map[string]any{
+			"jql": jql, "startAt": start, "maxResults": searchPage,
+			"fields": []string{"status", "resolution", "summary", "description", "updated"},
+		}
+		if err := api.do(ctx, http.MethodPost, "/search", req, &page); err != nil {
+			return nil, err
+		}
+		for _, p := range page.Issues {
+			f := p.Fields
+			is := Issue{
+				Key: p.Key, Status: f.Status.Name, StatusCategory: f.Status.StatusCategory.Key,
+				Text: f.Summary + "\n" + f.Description,
+			}
+			if f.Resolution != nil {
+				is.Resolution = f.Resolution.Name
+			}
+			is.Updated, _ = time.Parse("2006-01-02T15:04:05.000-0700", f.Updated)
+			issues = append(issues, is)
+		}
+		start += len(page.Issues)
+		if len(page.Issues) == 0 || start >= page.Total {
+			return issues, nil
+		}
+	}
+}
+
+// fixedResolutions and wontFixResolutions are the resolutions of issues
+// whose findings were fixed, and of those that will not be. Other
+// resolutions, as in Duplicate or Cannot Reproduce, say nothing of the
+// finding.
+var (
+	fixedResolutions   = map[string]bool{"done": true, "fixed": true, "resolved": true, "complete": true, "completed": true}
+	wontFixResolutions = map[string]bool{"won't fix": true, "won't do": true, "wont fix": true, "wont do": true, "risk accepted": true}
+)
+
+// doneStatuses and inProgressStatuses are the statuses of the default
+// workflows, for exports without status categories.
+var (
+	doneStatuses       = map[string]bool{"done": true, "closed": true, "resolved": true}
+	inProgressStatuses = map[string]bool{"in progress": true, "in review": true, "in development": true}
+)
+
+// State returns the workflow state the findings of is are in, or "" when
+// it says nothing of them: the state overrides gives its resolution or
+// else its status, by lower-case name, if any; otherwise resolved for a
+// fixed or done resolution, risk accepted for won't fix or won't do, and,
+// for an unresolved issue, resolved when its status is done and in
+// progress when it is in progress.
+func (is *Issue) State(overrides map[string]string) string {
+	res, status := strings.ReplaceAll(strings.ToLower(is.Resolution), "’", "'"), strings.ToLower(is.Status)
+	if st, ok := overrides[res]; ok && res != "" {
+		return st
+	}
+	if st, ok := overrides[status]; ok {
+		return st
+	}
+	switch {
+	case fixedResolutions[res]:
+		return state.Resolved
+	case wontFixResolutions[res]:
+		return state.RiskAccepted
+	case res != "":
+		return ""
+	case is.StatusCategory == CategoryDone || is.StatusCategory == "" && doneStatuses[status]:
+		return state.Resolved
+	case is.StatusCategory == CategoryInProgress || is.StatusCategory == "" && inProgressStatuses[status]:
+		return state.InProgress
+	}
+	return ""
+}
+
+// ParseStates parses overrides of the states of resolutions and statuses,
+// as in "Duplicate=resolved,Blocked=in_progress", by lower-case name.
+func ParseStates(s string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, st, ok := strings.Cut(pair, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q: want resolution or status=state", pair)
+		}
+		parsed, err := state.ParseState(st)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		overrides[name] = parsed
+	}
+	return overrides, nil
+}
+
+// fingerprintPattern matches the fingerprints ticket descriptions give.
+var fingerprintPattern = regexp.MustCompile(`\b[0-9a-f]{64}\b`)
+
+// Link is a finding and the issue that tracks it.
+type Link struct {
+	Finding *vuln.Vulnerability
+	Issue   *Issue
+}
+
+// Links returns the findings of vs that issues track, one per fingerprint
+// in vs order, each with the most recently updated issue that tracks it:
+// one whose text names its fingerprint, as the tickets of -output_tickets
+// do, or whose key is its Ticket, as set by Sync.
+func Links(issues []Issue, vs []vuln.Vulnerability) []Link {
+	byFingerprint := make(map[string]*Issue)
+	byKey := make(map[string]*Issue)
+	newer := func(m map[string]*Issue, k string, is *Issue) {
+		if cur, ok := m[k]; !ok || !is.Updated.Before(cur.Updated) {
+			m[k] = is
+		}
+	}
+	for i := range issues {
+		is := &issues[i]
+		newer(byKey, is.Key, is)
+		for _, fp := range fingerprintPattern.FindAllString(is.Text, -1) {
+			newer(byFingerprint, fp, is)
+		}
+	}
+	var links []Link
+	seen := make(map[string]bool)
+	for i := range vs {
+		v := &vs[i]
+		fp := v.Fingerprint()
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		is := byFingerprint[fp]
+		if t := byKey[strings.TrimSpace(v.Ticket)]; t != nil && (is == nil || t.Updated.After(is.Updated)) {
+			is = t
+		}
+		if is != nil {
+			links = append(links, Link{v, is})
+		}
+	}
+	return links
+}
@@ -0,0 +1,124 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestReadCSV(t *testing.T) {
+	export := "\ufeffSummary,Issue key,Issue id,Status,Resolution,Labels,Labels,Description,Updated\n" +
+		"CVE-2024-4068 in npm-braces on apply-api,SEC-1,10001,Done,Fixed,security,github,\"Unique ID 3, fingerprint 47c259e4.\",05/Feb/25 10:30 AM\n" +
+		"CVE-2022-2216 in npm-parse-url,SEC-2,10002,In Review,Unresolved,,,,\n"
+	issues, err := ReadCSV(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Issue{
+		{Key: "SEC-1", Status: "Done", Resolution: "Fixed", Text: "CVE-2024-4068 in npm-braces on apply-api\nUnique ID 3, fingerprint 47c259e4.", Updated: time.Date(2025, 2, 5, 10, 30, 0, 0, time.UTC)},
+		{Key: "SEC-2", Status: "In Review", Text: "CVE-2022-2216 in npm-parse-url\n"},
+	}
+	if len(issues) != 2 || issues[0] != want[0] || issues[1] != want[1] {
+		t.Errorf("issues:\n got %+v\nwant %+v", issues, want)
+	}
+	for _, bad := range []string{"Summary,Status\nx,Done\n", "Issue key,Status\n,Done\n", ""} {
+		if _, err := ReadCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadCSV(%q): want error", bad)
+		}
+	}
+}
+
+func TestIssueState(t *testing.T) {
+	overrides, err := ParseStates("Duplicate=resolved, Blocked = in-progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		is   Issue
+		want string
+	}{
+		{Issue{Status: "Done", StatusCategory: CategoryDone, Resolution: "Done"}, state.Resolved},
+		{Issue{Status: "Closed", Resolution: "Fixed"}, state.Resolved},
+		{Issue{Status: "Closed", Resolution: "Won’t Fix"}, state.RiskAccepted},
+		{Issue{Status: "Closed", Resolution: "Won't Do"}, state.RiskAccepted},
+		{Issue{Status: "Closed", Resolution: "Cannot Reproduce"}, ""},
+		{Issue{Status: "Closed", Resolution: "Duplicate"}, state.Resolved},
+		{Issue{Status: "Shipped", StatusCategory: CategoryDone}, state.Resolved},
+		{Issue{Status: "Closed"}, state.Resolved},
+		{Issue{Status: "Fixing", StatusCategory: CategoryInProgress}, state.InProgress},
+		{Issue{Status: "In Review"}, state.InProgress},
+		{Issue{Status: "blocked", StatusCategory: CategoryToDo}, state.InProgress},
+		{Issue{Status: "To Do", StatusCategory: CategoryToDo}, ""},
+		{Issue{Status: "Backlog"}, ""},
+	} {
+		if got := tc.is.State(overrides); got != tc.want {
+			t.Errorf("%s (%s): state %q, want %q", tc.is.Status, tc.is.Resolution, got, tc.want)
+		}
+	}
+	for _, bad := range []string{"Duplicate", "=resolved", "Duplicate=closed"} {
+		if _, err := ParseStates(bad); err == nil {
+			t.Errorf("ParseStates(%q): want error", bad)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{Identifier: "CVE-2024-4068", AssetID: "464323181", PackageName: "npm-braces", InstalledVersion: "< 3.0.3"},
+		{Identifier: "CVE-2024-4068", AssetID: "464323181", PackageName: "npm-braces", InstalledVersion: "< 3.0.3"},
+		{Identifier: "CVE-2022-2216", AssetID: "464323168", PackageName: "npm-parse-url", Ticket: "SEC-7"},
+		{Identifier: "CVE-2023-4911", AssetID: "464322508", PackageName: "glibc"},
+	}
+	fp := vs[0].Fingerprint()
+	day := func(d int) time.Time { return time.Date(2025, 2, d, 0, 0, 0, 0, time.UTC) }
+	issues := []Issue{
+		{Key: "SEC-1", Status: "Done", Text: "fingerprint " + fp + ".", Updated: day(3)},
+		{Key: "SEC-4", Status: "To Do", Text: "Reopened: fingerprint " + fp + ".", Updated: day(5)},
+		{Key: "SEC-7", Status: "In Progress", Text: "2 vulnerabilities on explore-web", Updated: day(4)},
+		{Key: "SEC-9", Status: "Done", Text: "fingerprint " + strings.Repeat("0", 64)},
+	}
+	links := Links(issues, vs)
+	if len(links) != 2 || links[0].Finding != &vs[0] || links[0].Issue.Key != "SEC-4" || links[1].Finding != &vs[2] || links[1].Issue.Key != "SEC-7" {
+		t.Errorf("links = %+v", links)
+	}
+}
+
+func TestIssues(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if jql := body["jql"].(string); jql != `project = "SEC" AND labels = "vuln-prioritizer" ORDER BY updated ASC` {
+			t.Errorf("jql = %s", jql)
+		}
+		pages := []string{
+			`{"key": "SEC-1", "fields": {"status": {"name": "Done", "statusCategory": {"key": "done"}}, "resolution": {"name": "Won't Fix"}, "summary": "CVE-2024-4068 in npm-braces", "description": "Most urgent", "updated": "2025-02-05T10:30:00.000+0000"}}`,
+			`{"key": "SEC-2", "fields": {"status": {"name": "In Progress", "statusCategory": {"key": "indeterminate"}}, "resolution": null, "summary": "3 vulnerabilities on hasura"}}`,
+		}
+		start := int(body["startAt"].(float64))
+		w.Write([]byte(`{"total": 2, "issues": [` + pages[start] + `]}`))
+	}))
+	defer srv.Close()
+	s := &Syncer{Config: &Config{URL: srv.URL, Token: "secret", Project: "SEC"}}
+	issues, err := s.Issues(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Issue{
+		{Key: "SEC-1", Status: "Done", StatusCategory: CategoryDone, Resolution: "Won't Fix", Text: "CVE-2024-4068 in npm-braces\nMost urgent", Updated: time.Date(2025, 2, 5, 10, 30, 0, 0, time.UTC)},
+		{Key: "SEC-2", Status: "In Progress", StatusCategory: CategoryInProgress, Text: "3 vulnerabilities on hasura\n"},
+	}
+	if len(issues) != 2 || !issues[0].Updated.Equal(want[0].Updated) || issues[1] != want[1] {
+		t.Fatalf("issues:\n got %+v\nwant %+v", issues, want)
+	}
+	issues[0].Updated = want[0].Updated
+	if issues[0] != want[0] {
+		t.Errorf("issue = %+v, want %+v", issues[0], want[0])
+	}
+}
@@ -0,0 +1,371 @@
+// Package jira creates and updates Jira issues for prioritized findings
+// through the Jira REST API v2, one issue per asset or package. Each
+// issue carries a label naming its group, so a later run updates the
+// issue it created before instead of filing a duplicate.
+package jira
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/ticket"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Groupings: one issue per asset, or per package across assets.
+const (
+	ByAsset   = "asset"
+	ByPackage = "package"
+)
+
+// Groupings lists the groupings, the default first.
+var Groupings = []string{ByAsset, ByPackage}
+
+// Label is the label of every issue the tool manages. The issue of a
+// group also has the group's own label; see GroupLabel.
+const Label = "vuln-prioritizer"
+
+// DefaultMinScore is the priority score from which findings get an issue:
+// that of the Urgent tier.
+const DefaultMinScore = 7.0
+
+// summaryMax is the length limit of Jira's Summary field.
+const summaryMax = 255
+
+// Config is a Jira connection and what to file in it. It is the "jira"
+// section of a configuration file, and the JIRA_* environment variables
+// override it; see Env.
+type Config struct {
+	// URL is the Jira site, as in https://acme.atlassian.net.
+	URL string `json:"url"`
+	// User and Token authenticate with an API token, as for Jira Cloud.
+	// A Token without a User is sent as a bearer personal access token,
+	// as for Jira Data Center.
+	User  string `json:"user,omitempty"`
+	Token string `json:"token,omitempty"`
+	// Project is the key of the project issues are created in.
+	Project string `json:"project"`
+	// IssueType defaults to ticket.IssueType.
+	IssueType string `json:"issue_type,omitempty"`
+	// GroupBy is one of Groupings; empty is ByAsset.
+	GroupBy string `json:"group_by,omitempty"`
+	// MinScore is the priority score from which findings get an issue;
+	// zero is DefaultMinScore.
+	MinScore float64 `json:"min_score,omitempty"`
+	// Labels are added to every issue, after Label.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Env sets the fields of c that the environment variables JIRA_URL,
+// JIRA_USER, JIRA_API_TOKEN and JIRA_PROJECT, looked up with getenv, are
+// set for.
+func (c *Config) Env(getenv func(string) string) {
+	for name, field := range map[string]*string{
+		"JIRA_URL":       &c.URL,
+		"JIRA_USER":      &c.User,
+		"JIRA_API_TOKEN": &c.Token,
+		"JIRA_PROJECT":   &c.Project,
+	} {
+		if s := getenv(name); s != "" {
+			*field = s
+		}
+	}
+}
+
+// Validate checks that c names a site, a project and credentials, and
+// fills in the defaults.
+func (c *Config) Validate() error {
+	switch {
+	case c.URL == "":
+		return errors.New("no Jira URL: set JIRA_URL or url in the jira section of -config")
+	case c.Project == "":
+		return errors.New("no Jira project: set JIRA_PROJECT or project in the jira section of -config")
+	case c.Token == "":
+		return errors.New("no Jira API token: set JIRA_API_TOKEN or token in the jira section of -config")
+	case c.GroupBy != "" && !slices.Contains(Groupings, c.GroupBy):
+		return fmt.Errorf("group_by %q: want %s", c.GroupBy, strings.Join(Groupings, " or "))
+	case c.MinScore < 0 || c.MinScore > 10:
+		return fmt.Errorf("min_score %v: want a score from 0 to 10", c.MinScore)
+	}
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("Jira URL %q: want an http(s) URL", c.URL)
+	}
+	if c.GroupBy == "" {
+		c.GroupBy = ByAsset
+	}
+	if c.MinScore == 0 {
+		c.MinScore = DefaultMinScore
+	}
+	if c.IssueType == "" {
+		c.IssueType = ticket.IssueType
+	}
+	return nil
+}
+
+// Group is the findings one issue is for.
+type Group struct {
+	// Key is the asset or package the findings share.
+	Key      string
+	Findings []*vuln.Vulnerability // highest score first
+}
+
+// Groups returns the groups of the open findings of vs (see ticket.IsOpen)
+// whose priority score is at least minScore, by asset or package, in the
+// order of their highest scores. Findings without a package are grouped
+// by identifier instead.
+func Groups(vs []vuln.Vulnerability, by string, minScore float64) []Group {
+	index := make(map[string]int)
+	var groups []Group
+	for i := range vs {
+		v := &vs[i]
+		if v.PriorityScore < minScore || !ticket.IsOpen(v) {
+			continue
+		}
+		key := groupKey(v, by)
+		j, ok := index[key]
+		if !ok {
+			j = len(groups)
+			index[key] = j
+			groups = append(groups, Group{Key: key})
+		}
+		groups[j].Findings = append(groups[j].Findings, v)
+	}
+	for _, g := range groups {
+		slices.SortStableFunc(g.Findings, func(a, b *vuln.Vulnerability) int { return cmp.Compare(b.PriorityScore, a.PriorityScore) })
+	}
+	slices.SortStableFunc(groups, func(a, b Group) int {
+		return cmp.Compare(b.Findings[0].PriorityScore, a.Findings[0].PriorityScore)
+	})
+	return groups
+}
+
+func groupKey(v *vuln.Vulnerability, by string) string {
+	if by == ByPackage {
+		if p := strings.TrimSpace(v.PackageName); p != "" {
+			return p
+		}
+		return v.Identifier
+	}
+	if a := strings.TrimSpace(v.AssetName); a != "" {
+		return a
+	}
+	return strings.TrimSpace(v.AssetID)
+}
+
+// GroupLabel returns the label that marks the issue of the group key by
+// the grouping by. Labels cannot hold spaces and are limited in length,
+// so it is a hash of the two.
+func GroupLabel(by, key string) string {
+	sum := sha256.Sum256([]byte(by + "\x00" + key))
+	return Label + "-" + hex.EncodeToString(sum[:6])
+}
+
+// Result counts what Sync did.
+type Result struct {
+	Groups   int `json:"groups"`
+	Findings int `json:"findings"`
+	Created  int `json:"created"`
+	Updated  int `json:"updated"`
+}
+
+// Syncer files findings in Jira.
+type Syncer struct {
+	Config *Config // validated
+	Client *http.Client
+}
+
+// Sync creates an issue for each of the Groups of vs that has no open
+// issue yet, updates the open issue of the others to the findings of this
+// run, and sets the Ticket of each grouped finding of vs to its issue
+// key. Issues whose status category is Done are left alone, so a group
+// that comes back after its issue was closed gets a new one.
+func (s *Syncer) Sync(ctx context.Context, vs []vuln.Vulnerability) (Result, error) {
+	cfg := s.Config
+	groups := Groups(vs, cfg.GroupBy, cfg.MinScore)
+	res := Result{Groups: len(groups)}
+	if len(groups) == 0 {
+		return res, nil
+	}
+	api := &api{client: s.Client, cfg: cfg}
+	if api.client == nil {
+		api.client = http.DefaultClient
+	}
+	existing, err := api.openIssues(ctx)
+	if err != nil {
+		return res, err
+	}
+	for _, g := range groups {
+		label := GroupLabel(cfg.GroupBy, g.Key)
+		fields := s.fields(g, label)
+		key, ok := existing[label]
+		if ok {
+			if err := api.do(ctx, http.MethodPut, "/issue/"+url.PathEscape(key), map[string]any{"fields": fields}, nil); err != nil {
+				return res, err
+			}
+			res.Updated++
+		} else {
+			fields["project"] = map[string]string{"key": cfg.Project}
+			fields["issuetype"] = map[string]string{"name": cfg.IssueType}
+			var created struct {
+				Key string `json:"key"`
+			}
+			if err := api.do(ctx, http.MethodPost, "/issue", map[string]any{"fields": fields}, &created); err != nil {
+				return res, err
+			}
+			if created.Key == "" {
+				return res, fmt.Errorf("Jira API: no key for the created issue of %s", g.Key)
+			}
+			key = created.Key
+			res.Created++
+		}
+		for _, v := range g.Findings {
+			v.Ticket = key
+		}
+		res.Findings += len(g.Findings)
+	}
+	return res, nil
+}
+
+// fields returns the issue fields of g other than its project and type,
+// which cannot be updated: its summary, description, labels, the priority
+// of its most urgent finding and its earliest due date.
+func (s *Syncer) fields(g Group, label string) map[string]any {
+	top := g.Findings[0]
+	var summary string
+	switch {
+	case len(g.Findings) == 1:
+		summary = ticket.Summary(top)
+	case s.Config.GroupBy == ByPackage:
+		summary = fmt.Sprintf("%d vulnerabilities in %s", len(g.Findings), g.Key)
+	default:
+		summary = fmt.Sprintf("%d vulnerabilities on %s", len(g.Findings), g.Key)
+	}
+	if r := []rune(summary); len(r) > summaryMax {
+		summary = string(r[:summaryMax-1]) + "…"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Findings with a priority score of %s or more, highest first:\n\n", strconv.FormatFloat(s.Config.MinScore, 'f', -1, 64))
+	var due vuln.Date
+	for _, v := range g.Findings {
+		fmt.Fprintf(&b, "* %s — score %.2f, %s", ticket.Summary(v), v.PriorityScore, v.ActionTimeframe)
+		if !v.DueDate.IsZero() {
+			fmt.Fprintf(&b, ", due %s", v.DueDate)
+			if due.IsZero() || v.DueDate.Before(due.Time) {
+				due = v.DueDate
+			}
+		}
+		if v.HasFix() {
+			fmt.Fprintf(&b, ", fixed in %s", strings.TrimSpace(v.FixedVersion))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nMost urgent:\n\n" + ticket.Description(top))
+
+	labels := append([]string{Label, label}, s.Config.Labels...)
+	fields := map[string]any{
+		"summary":     summary,
+		"description": b.String(),
+		"labels":      labels,
+	}
+	if p := ticket.JiraPriority(top.ActionTimeframe); p != "" {
+		fields["priority"] = map[string]string{"name": p}
+	}
+	if !due.IsZero() {
+		fields["duedate"] = due.String()
+	}
+	return fields
+}
+
+// searchPage is how many issues openIssues asks for at a time.
+const searchPage = 100
+
+type api struct {
+	client *http.Client
+	cfg    *Config
+}
+
+// openIssues returns the keys of the project's issues with Label that are
+// not done, by their group label.
+func (a *api) openIssues(ctx context.Context) (map[string]string, error) {
+	jql := fmt.Sprintf("project = %s AND labels = %s AND statusCategory != Done ORDER BY created ASC", quoteJQL(a.cfg.Project), quoteJQL(Label))
+	keys := make(map[string]string)
+	for start := 0; ; {
+		var page struct {
+			Total  int `json:"total"`
+			Issues []struct {
+				Key    string `json:"key"`
+				Fields struct {
+					Labels []string `json:"labels"`
+				} `json:"fields"`
+			} `json:"issues"`
+		}
+		req := map[string]any{"jql": jql, "startAt": start, "maxResults": searchPage, "fields": []string{"labels"}}
+		if err := a.do(ctx, http.MethodPost, "/search", req, &page); err != nil {
+			return nil, err
+		}
+		for _, issue := range page.Issues {
+			for _, l := range issue.Fields.Labels {
+				if strings.HasPrefix(l, Label+"-") {
+					// The oldest issue of a group wins.
+					if _, ok := keys[l]; !ok {
+						keys[l] = issue.Key
+					}
+				}
+			}
+		}
+		start += len(page.Issues)
+		if len(page.Issues) == 0 || start >= page.Total {
+			return keys, nil
+		}
+	}
+}
+
+// quoteJQL quotes s as a JQL string.
+func quoteJQL(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (a *api) do(ctx context.Context, method, path string, in, out any) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	u := strings.TrimSuffix(a.cfg.URL, "/") + "/rest/api/2" + path
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	if a.cfg.User != "" {
+		req.SetBasicAuth(a.cfg.User, a.cfg.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+a.cfg.Token)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("Jira API %s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
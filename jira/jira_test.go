@@ -0,0 +1,203 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// fakeJira serves the issue and search endpoints Sync uses from memory.
+type fakeJira struct {
+	mu     sync.Mutex
+	issues map[string]map[string]any // fields by key
+	done   map[string]bool
+	order  []string
+}
+
+func newFakeJira(t *testing.T) (*fakeJira, *httptest.Server) {
+	f := &fakeJira{issues: make(map[string]map[string]any), done: make(map[string]bool)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "bot@acme.example" || token != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/search":
+			if jql := body["jql"].(string); !strings.HasPrefix(jql, `project = "SEC" AND labels = "vuln-prioritizer"`) {
+				t.Errorf("jql = %s", jql)
+			}
+			// One issue a page, to exercise paging.
+			var open []string
+			for _, key := range f.order {
+				if !f.done[key] {
+					open = append(open, key)
+				}
+			}
+			start := int(body["startAt"].(float64))
+			var issues []map[string]any
+			if start < len(open) {
+				key := open[start]
+				issues = append(issues, map[string]any{"key": key, "fields": map[string]any{"labels": f.issues[key]["labels"]}})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"total": len(open), "issues": issues})
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			key := "SEC-" + strconv.Itoa(len(f.order)+1)
+			f.issues[key] = body["fields"].(map[string]any)
+			f.order = append(f.order, key)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"key": key})
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/rest/api/2/issue/"):
+			key := strings.TrimPrefix(r.URL.Path, "/rest/api/2/issue/")
+			fields, ok := f.issues[key]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			for k, v := range body["fields"].(map[string]any) {
+				fields[k] = v
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func findings() []vuln.Vulnerability {
+	return []vuln.Vulnerability{
+		{Identifier: "CVE-1", AssetName: "web", PackageName: "openssl", PriorityScore: 9, ActionTimeframe: "Immediate", DueDate: vuln.NewDate(2025, 3, 1)},
+		{Identifier: "CVE-2", AssetName: "web", PackageName: "zlib", PriorityScore: 7.5, ActionTimeframe: "Urgent", DueDate: vuln.NewDate(2025, 2, 20)},
+		{Identifier: "CVE-3", AssetName: "db", PackageName: "openssl", PriorityScore: 8, ActionTimeframe: "Urgent"},
+		{Identifier: "CVE-4", AssetName: "db", PackageName: "zlib", PriorityScore: 5, ActionTimeframe: "Scheduled"},
+		{Identifier: "CVE-5", AssetName: "api", PackageName: "curl", PriorityScore: 9.5, ActionTimeframe: "Immediate", State: state.Resolved},
+	}
+}
+
+func TestGroups(t *testing.T) {
+	vs := findings()
+	key := func(groups []Group) []string {
+		var keys []string
+		for _, g := range groups {
+			var ids []string
+			for _, v := range g.Findings {
+				ids = append(ids, v.Identifier)
+			}
+			keys = append(keys, g.Key+":"+strings.Join(ids, ","))
+		}
+		return keys
+	}
+	// CVE-4 scores too low and CVE-5 is resolved.
+	if got, want := key(Groups(vs, ByAsset, 7)), []string{"web:CVE-1,CVE-2", "db:CVE-3"}; !slices.Equal(got, want) {
+		t.Errorf("by asset: %q, want %q", got, want)
+	}
+	if got, want := key(Groups(vs, ByPackage, 5)), []string{"openssl:CVE-1,CVE-3", "zlib:CVE-2,CVE-4"}; !slices.Equal(got, want) {
+		t.Errorf("by package: %q, want %q", got, want)
+	}
+	if GroupLabel(ByAsset, "web") == GroupLabel(ByPackage, "web") || strings.ContainsAny(GroupLabel(ByAsset, "prod web"), " ") {
+		t.Errorf("group labels %s, %s", GroupLabel(ByAsset, "web"), GroupLabel(ByPackage, "web"))
+	}
+}
+
+func TestSync(t *testing.T) {
+	fake, srv := newFakeJira(t)
+	cfg := &Config{URL: srv.URL, User: "bot@acme.example", Token: "secret", Project: "SEC", Labels: []string{"team-red"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	s := &Syncer{Config: cfg, Client: srv.Client()}
+	sync := func() ([]string, Result) {
+		t.Helper()
+		vs := findings()
+		res, err := s.Sync(context.Background(), vs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tickets []string
+		for _, v := range vs {
+			tickets = append(tickets, v.Ticket)
+		}
+		return tickets, res
+	}
+
+	tickets, res := sync()
+	if want := []string{"SEC-1", "SEC-1", "SEC-2", "", ""}; !slices.Equal(tickets, want) {
+		t.Errorf("tickets = %q, want %q", tickets, want)
+	}
+	if res != (Result{Groups: 2, Findings: 3, Created: 2}) {
+		t.Errorf("first run: %+v", res)
+	}
+	web := fake.issues["SEC-1"]
+	if web["summary"] != "2 vulnerabilities on web" || web["duedate"] != "2025-02-20" ||
+		web["priority"].(map[string]any)["name"] != "Highest" || web["issuetype"].(map[string]any)["name"] != "Task" {
+		t.Errorf("SEC-1 fields %v", web)
+	}
+	if labels := web["labels"].([]any); len(labels) != 3 || labels[0] != Label || labels[1] != GroupLabel(ByAsset, "web") || labels[2] != "team-red" {
+		t.Errorf("SEC-1 labels %v", labels)
+	}
+	if d := web["description"].(string); !strings.Contains(d, "* CVE-1 in openssl on web — score 9.00, Immediate, due 2025-03-01\n* CVE-2") {
+		t.Errorf("SEC-1 description:\n%s", d)
+	}
+	if db := fake.issues["SEC-2"]; db["summary"] != "CVE-3 in openssl on db" || db["duedate"] != nil {
+		t.Errorf("SEC-2 fields %v", db)
+	}
+
+	// A second run updates the issues rather than filing them again.
+	tickets, res = sync()
+	if want := []string{"SEC-1", "SEC-1", "SEC-2", "", ""}; !slices.Equal(tickets, want) || res != (Result{Groups: 2, Findings: 3, Updated: 2}) {
+		t.Errorf("second run: tickets %q, %+v", tickets, res)
+	}
+
+	// A group whose issue was closed gets a new one.
+	fake.done["SEC-2"] = true
+	tickets, res = sync()
+	if want := []string{"SEC-1", "SEC-1", "SEC-3", "", ""}; !slices.Equal(tickets, want) || res != (Result{Groups: 2, Findings: 3, Created: 1, Updated: 1}) {
+		t.Errorf("after closing SEC-2: tickets %q, %+v", tickets, res)
+	}
+
+	cfg.Token = "wrong"
+	if _, err := s.Sync(context.Background(), findings()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("bad token: err = %v", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	env := map[string]string{"JIRA_URL": "https://acme.atlassian.net", "JIRA_API_TOKEN": "secret"}
+	cfg := &Config{URL: "https://old.example", Project: "SEC", MinScore: 8}
+	cfg.Env(func(name string) string { return env[name] })
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.URL != env["JIRA_URL"] || cfg.Token != "secret" || cfg.Project != "SEC" || cfg.GroupBy != ByAsset || cfg.MinScore != 8 || cfg.IssueType != "Task" {
+		t.Errorf("config = %+v", cfg)
+	}
+	for _, bad := range []Config{
+		{Project: "SEC", Token: "t"},
+		{URL: "https://acme.atlassian.net", Token: "t"},
+		{URL: "https://acme.atlassian.net", Project: "SEC"},
+		{URL: "acme.atlassian.net", Project: "SEC", Token: "t"},
+		{URL: "https://acme.atlassian.net", Project: "SEC", Token: "t", GroupBy: "team"},
+		{URL: "https://acme.atlassian.net", Project: "SEC", Token: "t", MinScore: 11},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil", bad)
+		}
+	}
+}
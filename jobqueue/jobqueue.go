@@ -0,0 +1,220 @@
+// Package jobqueue keeps outbound integration actions, such as webhook
+// posts, in a durable on-disk queue. Actions that fail are retried with
+// backoff and, after too many attempts, moved to a dead-letter list, so an
+// outage of the receiving service neither loses them nor fails the run.
+package jobqueue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxAttempts is the number of failed deliveries after which a job
+// is dead-lettered, when the queue does not set one.
+const DefaultMaxAttempts = 8
+
+// Backoff is the delay after a job's first failed delivery. It doubles
+// with each further failure, up to MaxBackoff.
+const (
+	Backoff    = time.Minute
+	MaxBackoff = time.Hour
+)
+
+// Job is one queued action: a body delivered to a target.
+type Job struct {
+	ID string `json:"id"`
+	// Kind names the integration that queued the job, as in "notify".
+	Kind string `json:"kind"`
+	// Target is an http(s) URL the body is POSTed to, or a file it is
+	// appended to as a line.
+	Target string `json:"target"`
+	// Body is kept as a string, not embedded JSON, so that saving the
+	// queue indented does not reformat it.
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	// Attempts counts the failed deliveries; NextAttempt is when the job
+	// may be tried again.
+	Attempts    int       `json:"attempts,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Queue holds the pending jobs, oldest first, and the dead letters.
+type Queue struct {
+	// MaxAttempts is the number of failed deliveries after which a job is
+	// dead-lettered; zero means DefaultMaxAttempts.
+	MaxAttempts int   `json:"max_attempts,omitempty"`
+	NextID      int   `json:"next_id"`
+	Pending     []Job `json:"pending"`
+	Dead        []Job `json:"dead,omitempty"`
+}
+
+// Load reads a queue saved with Save. A missing file is an empty queue.
+func Load(path string) (*Queue, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Queue{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue{}
+	if err := json.Unmarshal(b, q); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return q, nil
+}
+
+// Save writes q to path as indented JSON. It writes a temporary file and
+// renames it over path, so a crash never leaves a truncated queue.
+func (q *Queue) Save(path string) error {
+	b, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Enqueue adds a job delivering body to target and returns it.
+func (q *Queue) Enqueue(kind, target string, body []byte, now time.Time) Job {
+	q.NextID++
+	j := Job{ID: "job-" + strconv.Itoa(q.NextID), Kind: kind, Target: target, Body: string(body), CreatedAt: now}
+	q.Pending = append(q.Pending, j)
+	return j
+}
+
+// Stats counts the outcome of a Process call.
+type Stats struct {
+	Delivered    int
+	Failed       int // will be retried
+	DeadLettered int
+	Waiting      int // not yet due for a retry
+}
+
+// Process delivers every pending job due at now, in order. A job that
+// fails is retried after a backoff, or moved to the dead letters once it
+// has failed MaxAttempts times. It stops early, leaving the rest pending,
+// when ctx is done.
+func (q *Queue) Process(ctx context.Context, client *http.Client, now time.Time) Stats {
+	maxAttempts := q.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	var stats Stats
+	pending := q.Pending[:0]
+	for _, j := range q.Pending {
+		if ctx.Err() != nil || now.Before(j.NextAttempt) {
+			stats.Waiting++
+			pending = append(pending, j)
+			continue
+		}
+		err := Deliver(ctx, client, j.Target, []byte(j.Body))
+		if err == nil {
+			stats.Delivered++
+			continue
+		}
+		j.Attempts++
+		j.LastError = err.Error()
+		if j.Attempts >= maxAttempts {
+			stats.DeadLettered++
+			q.Dead = append(q.Dead, j)
+			continue
+		}
+		stats.Failed++
+		j.NextAttempt = now.Add(backoff(j.Attempts))
+		pending = append(pending, j)
+	}
+	q.Pending = pending
+	return stats
+}
+
+// backoff returns the delay after the nth failed delivery.
+func backoff(n int) time.Duration {
+	d := Backoff
+	for i := 1; i < n && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, MaxBackoff)
+}
+
+// Retry moves the dead letters with the given IDs, or all of them when
+// none are given, back to the pending jobs with their attempts reset. It
+// returns how many it moved.
+func (q *Queue) Retry(ids ...string) int {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	dead := q.Dead[:0]
+	n := 0
+	for _, j := range q.Dead {
+		if len(ids) > 0 && !want[j.ID] {
+			dead = append(dead, j)
+			continue
+		}
+		j.Attempts, j.NextAttempt, j.LastError = 0, time.Time{}, ""
+		q.Pending = append(q.Pending, j)
+		n++
+	}
+	q.Dead = dead
+	return n
+}
+
+// Deliver POSTs body as JSON to target when it is an http(s) URL, and
+// otherwise appends it to the file target as a line. A response other than
+// 2xx is an error.
+func Deliver(ctx context.Context, client *http.Client, target string, body []byte) error {
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if client == nil {
+			client = http.DefaultClient
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("POST %s: %s", target, resp.Status)
+		}
+		return nil
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(body, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
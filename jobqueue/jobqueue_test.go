@@ -0,0 +1,105 @@
+package jobqueue
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessRetriesAndDeadLetters(t *testing.T) {
+	up := false
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		b := make([]byte, r.ContentLength)
+		r.Body.Read(b)
+		received = append(received, string(b))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	now := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	q := &Queue{MaxAttempts: 3}
+	q.Enqueue("notify", srv.URL, []byte(`{"n":1}`), now)
+	q.Enqueue("notify", srv.URL+"/other", []byte(`{"n":2}`), now)
+
+	if s := q.Process(ctx, srv.Client(), now); s != (Stats{Failed: 2}) {
+		t.Fatalf("first attempt = %+v", s)
+	}
+	j := q.Pending[0]
+	if j.Attempts != 1 || !j.NextAttempt.Equal(now.Add(Backoff)) || !strings.Contains(j.LastError, "502") {
+		t.Errorf("failed job = %+v", j)
+	}
+	// Nothing is due before the backoff ends.
+	if s := q.Process(ctx, srv.Client(), now.Add(30*time.Second)); s != (Stats{Waiting: 2}) {
+		t.Errorf("within the backoff = %+v", s)
+	}
+	now = now.Add(Backoff)
+	if s := q.Process(ctx, srv.Client(), now); s != (Stats{Failed: 2}) || !q.Pending[0].NextAttempt.Equal(now.Add(2*Backoff)) {
+		t.Errorf("second attempt = %+v, next attempt %v", s, q.Pending[0].NextAttempt)
+	}
+	now = now.Add(2 * Backoff)
+	if s := q.Process(ctx, srv.Client(), now); s != (Stats{DeadLettered: 2}) || len(q.Pending) != 0 || len(q.Dead) != 2 {
+		t.Fatalf("third attempt = %+v, %d pending, %d dead", s, len(q.Pending), len(q.Dead))
+	}
+
+	if n := q.Retry("job-2"); n != 1 || len(q.Dead) != 1 || q.Pending[0].ID != "job-2" || q.Pending[0].Attempts != 0 {
+		t.Errorf("Retry(job-2) = %d; pending %+v, dead %+v", n, q.Pending, q.Dead)
+	}
+	up = true
+	if s := q.Process(ctx, srv.Client(), now); s != (Stats{Delivered: 1}) || len(received) != 1 || received[0] != `{"n":2}` {
+		t.Errorf("after recovery = %+v, received %q", s, received)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for n, want := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 7: MaxBackoff, 20: MaxBackoff} {
+		if got := backoff(n); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestSaveLoadAndFileTarget(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "queue.json")
+	q, err := Load(path)
+	if err != nil || len(q.Pending) != 0 {
+		t.Fatalf("Load(missing) = %+v, %v", q, err)
+	}
+	out := filepath.Join(dir, "out.jsonl")
+	now := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	q.Enqueue("notify", out, []byte(`{"n":1}`), now)
+	q.Enqueue("notify", out, []byte(`{"n":2}`), now)
+	if err := q.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if q, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Pending) != 2 || q.NextID != 2 || q.Pending[1].ID != "job-2" {
+		t.Fatalf("loaded %+v", q)
+	}
+	if s := q.Process(context.Background(), nil, now); s.Delivered != 2 {
+		t.Errorf("Process = %+v", s)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil || string(b) != "{\"n\":1}\n{\"n\":2}\n" {
+		t.Errorf("file target = %q, %v", b, err)
+	}
+	// Save leaves no temporary file behind.
+	if err := q.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("directory holds %d files, want the queue and the output", len(entries))
+	}
+}
@@ -0,0 +1,94 @@
+// Package locale formats the dates and decimal numbers of the reports
+// people read, the HTML, XLSX and PDF outputs, as readers in one country
+// write them: 05.02.2025 and 7,25 in Germany, 02/05/2025 and 7.25 in the
+// United States. CSV and JSON outputs, which programs read, keep the
+// canonical ISO 8601 dates and decimal points whatever the locale. A nil
+// Locale is that canonical format.
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Locale is how one locale writes dates and decimal numbers.
+type Locale struct {
+	// Name is the locale's identifier, as in de-DE.
+	Name string
+	// DateLayout is the layout of a date, as time.Format takes it.
+	DateLayout string
+	// Decimal separates the integer part of a number from its fraction.
+	Decimal string
+}
+
+// locales are the locales Parse knows, by identifier.
+var locales = map[string]Locale{
+	"de-CH": {"de-CH", "02.01.2006", "."},
+	"de-DE": {"de-DE", "02.01.2006", ","},
+	"en-GB": {"en-GB", "02/01/2006", "."},
+	"en-US": {"en-US", "01/02/2006", "."},
+	"es-ES": {"es-ES", "02/01/2006", ","},
+	"fr-FR": {"fr-FR", "02/01/2006", ","},
+	"it-IT": {"it-IT", "02/01/2006", ","},
+	"ja-JP": {"ja-JP", "2006/01/02", "."},
+	"nl-NL": {"nl-NL", "02-01-2006", ","},
+	"pl-PL": {"pl-PL", "02.01.2006", ","},
+	"pt-BR": {"pt-BR", "02/01/2006", ","},
+	"sv-SE": {"sv-SE", "2006-01-02", ","},
+}
+
+// Names returns the identifiers of the locales Parse knows, sorted.
+func Names() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse returns the locale with identifier s, ignoring case and accepting
+// "_" for "-", as in de_DE.
+func Parse(s string) (*Locale, error) {
+	norm := strings.ReplaceAll(strings.TrimSpace(s), "_", "-")
+	for name, l := range locales {
+		if strings.EqualFold(norm, name) {
+			return &l, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown locale %q (want one of %s)", s, strings.Join(Names(), ", "))
+}
+
+// Date formats d, or returns "" when it is zero.
+func (l *Locale) Date(d vuln.Date) string {
+	if l == nil || d.IsZero() {
+		return d.String()
+	}
+	return d.Format(l.DateLayout)
+}
+
+// Float formats f with prec digits after its decimal separator.
+func (l *Locale) Float(f float64, prec int) string {
+	s := strconv.FormatFloat(f, 'f', prec, 64)
+	if l == nil || l.Decimal == "." {
+		return s
+	}
+	return strings.Replace(s, ".", l.Decimal, 1)
+}
+
+// SpreadsheetDate returns the number format code of dates in spreadsheet
+// cells, as in dd.mm.yyyy. Spreadsheets store numbers as numbers and
+// show their decimal separators as the reader's own settings say, so
+// only dates need a format.
+func (l *Locale) SpreadsheetDate() string {
+	if l == nil {
+		return "yyyy-mm-dd"
+	}
+	// A "/" in a format code stands for the reader's date separator
+	// unless escaped.
+	return strings.NewReplacer("2006", "yyyy", "01", "mm", "02", "dd", "/", `\/`).Replace(l.DateLayout)
+}
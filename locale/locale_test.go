@@ -0,0 +1,45 @@
+package locale
+
+import (
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestLocale(t *testing.T) {
+	due := vuln.NewDate(2025, 2, 5)
+	for _, tc := range []struct {
+		name, date, score, spreadsheet string
+	}{
+		{"de-DE", "05.02.2025", "7,25", "dd.mm.yyyy"},
+		{"de_ch", "05.02.2025", "7.25", "dd.mm.yyyy"},
+		{"en-US", "02/05/2025", "7.25", `mm\/dd\/yyyy`},
+		{" FR-fr ", "05/02/2025", "7,25", `dd\/mm\/yyyy`},
+		{"sv-SE", "2025-02-05", "7,25", "yyyy-mm-dd"},
+	} {
+		l, err := Parse(tc.name)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.name, err)
+		}
+		if got := l.Date(due); got != tc.date {
+			t.Errorf("%s: Date = %s, want %s", tc.name, got, tc.date)
+		}
+		if got := l.Float(7.254, 2); got != tc.score {
+			t.Errorf("%s: Float = %s, want %s", tc.name, got, tc.score)
+		}
+		if got := l.SpreadsheetDate(); got != tc.spreadsheet {
+			t.Errorf("%s: SpreadsheetDate = %s, want %s", tc.name, got, tc.spreadsheet)
+		}
+		if got := l.Date(vuln.Date{}); got != "" {
+			t.Errorf("%s: zero Date = %q", tc.name, got)
+		}
+	}
+
+	var canonical *Locale
+	if canonical.Date(due) != "2025-02-05" || canonical.Float(-0.5, 1) != "-0.5" || canonical.SpreadsheetDate() != "yyyy-mm-dd" {
+		t.Error("a nil Locale is not canonical")
+	}
+	if _, err := Parse("klingon"); err == nil {
+		t.Error("parsed an unknown locale")
+	}
+}
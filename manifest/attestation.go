@@ -0,0 +1,124 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/canonical"
+)
+
+// Attestation types: an in-toto statement with a SLSA provenance
+// predicate, and the build type of a prioritization run.
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+	PredicateType = "https://slsa.dev/provenance/v1"
+	BuildType     = "https://github.com/VioletX-Dev/devsecops-test/run/v1"
+)
+
+// Statement is an in-toto attestation that a run produced its subjects.
+// It is unsigned; sign it with the pipeline's own tooling, such as cosign
+// attest-blob.
+type Statement struct {
+	Type          string       `json:"_type"`
+	Subject       []Descriptor `json:"subject"`
+	PredicateType string       `json:"predicateType"`
+	Predicate     Provenance   `json:"predicate"`
+}
+
+// Descriptor is an in-toto resource descriptor: a file and its digests.
+type Descriptor struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Provenance is a SLSA v1 provenance predicate.
+type Provenance struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+// BuildDefinition is what a run was asked to do: its configuration and
+// inputs.
+type BuildDefinition struct {
+	BuildType            string       `json:"buildType"`
+	ExternalParameters   Parameters   `json:"externalParameters"`
+	ResolvedDependencies []Descriptor `json:"resolvedDependencies"`
+}
+
+// Parameters are a run's external parameters. ConfigSHA256 is the
+// manifest's config_sha256, so a verifier can check it against the
+// checksums of approved configurations.
+type Parameters struct {
+	Config       any    `json:"config,omitempty"`
+	ConfigSHA256 string `json:"config_sha256"`
+	Shard        string `json:"shard,omitempty"`
+}
+
+// RunDetails identifies the tool that ran and when.
+type RunDetails struct {
+	Builder  Builder   `json:"builder"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// Builder is the tool that produced the subjects.
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// Metadata holds the run's timings.
+type Metadata struct {
+	StartedOn  time.Time `json:"startedOn"`
+	FinishedOn time.Time `json:"finishedOn"`
+}
+
+// Attestation returns the provenance of the run m describes, with m's
+// outputs and the extra files as subjects and its inputs as resolved
+// dependencies. builder identifies the tool, and config is the
+// configuration m.ConfigSHA256 was taken over. Call it after Finish; the
+// statement records the run's start and end unless timestamps is false,
+// so that runs on the same input and configuration attest equally.
+func (m *Manifest) Attestation(builder string, config any, timestamps bool, extra ...File) *Statement {
+	p := Provenance{
+		BuildDefinition: BuildDefinition{
+			BuildType:            BuildType,
+			ExternalParameters:   Parameters{Config: config, ConfigSHA256: m.ConfigSHA256, Shard: m.Shard},
+			ResolvedDependencies: descriptors(m.Inputs),
+		},
+		RunDetails: RunDetails{Builder: Builder{ID: builder, Version: map[string]string{"prioritizer": m.ToolVersion}}},
+	}
+	if timestamps {
+		p.RunDetails.Metadata = &Metadata{StartedOn: m.StartedAt, FinishedOn: m.finishedAt}
+	}
+	return &Statement{
+		Type:          StatementType,
+		Subject:       descriptors(append(append([]File(nil), m.Outputs...), extra...)),
+		PredicateType: PredicateType,
+		Predicate:     p,
+	}
+}
+
+func descriptors(files []File) []Descriptor {
+	ds := make([]Descriptor, 0, len(files))
+	for _, f := range files {
+		ds = append(ds, Descriptor{Name: f.Path, Digest: map[string]string{"sha256": f.SHA256}})
+	}
+	return ds
+}
+
+// WriteFile writes s to path as indented JSON, or canonical JSON, with
+// permissions perm.
+func (s *Statement) WriteFile(path string, perm os.FileMode, canon bool) error {
+	var b []byte
+	var err error
+	if canon {
+		b, err = canonical.Marshal(s)
+	} else if b, err = json.MarshalIndent(s, "", "  "); err == nil {
+		b = append(b, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b, perm)
+}
@@ -0,0 +1,52 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttestation(t *testing.T) {
+	m := New("v1.2.3")
+	m.ConfigSHA256, m.Shard = "c0ffee", "1/2"
+	m.Inputs = []File{{Path: "export.csv", SHA256: "aaaa"}}
+	m.Outputs = []File{{Path: "out.json", SHA256: "bbbb"}}
+	m.Finish()
+
+	st := m.Attestation("https://example.com/prioritizer", map[string]int{"cvss": 1}, true, File{Path: FileName, SHA256: "cccc"})
+	if st.Type != StatementType || st.PredicateType != PredicateType || len(st.Subject) != 2 ||
+		st.Subject[0].Name != "out.json" || st.Subject[1].Digest["sha256"] != "cccc" {
+		t.Errorf("statement = %+v", st)
+	}
+	b := st.Predicate.BuildDefinition
+	if b.ExternalParameters.ConfigSHA256 != "c0ffee" || b.ExternalParameters.Shard != "1/2" ||
+		len(b.ResolvedDependencies) != 1 || b.ResolvedDependencies[0].Digest["sha256"] != "aaaa" {
+		t.Errorf("build definition = %+v", b)
+	}
+	r := st.Predicate.RunDetails
+	if r.Builder.Version["prioritizer"] != "v1.2.3" || r.Metadata == nil || r.Metadata.FinishedOn.Before(r.Metadata.StartedOn) {
+		t.Errorf("run details = %+v", r)
+	}
+	// Appending the extra subject must not write into m.Outputs.
+	if len(m.Outputs) != 1 {
+		t.Errorf("outputs = %+v", m.Outputs)
+	}
+
+	path := filepath.Join(t.TempDir(), "provenance.json")
+	if err := m.Attestation("https://example.com/prioritizer", nil, false).WriteFile(path, 0o644, true); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "startedOn") {
+		t.Errorf("timestamps written without timestamps:\n%s", data)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil || doc["_type"] != StatementType {
+		t.Errorf("statement = %s, %v", data, err)
+	}
+}
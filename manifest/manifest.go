@@ -0,0 +1,264 @@
+// Package manifest records what a run read, how it was configured, how
+// long each stage took and what it wrote, for reproducibility and
+// pipeline bookkeeping.
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/canonical"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+)
+
+// FileName is the manifest's file name when written next to the outputs.
+const FileName = "run-manifest.json"
+
+// File is an input or output file and its checksum.
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// Stage is the wall-clock duration and CPU time of one pipeline stage.
+type Stage struct {
+	Name       string  `json:"name"`
+	DurationMS float64 `json:"duration_ms"`
+	CPUMS      float64 `json:"cpu_ms"`
+}
+
+// Rows counts findings through the run.
+type Rows struct {
+	Read    int `json:"read"`
+	Skipped int `json:"skipped"`
+	// Suppressed counts findings dropped as known false positives, Stale
+	// those on decommissioned assets, dropped or tagged.
+	Suppressed int `json:"suppressed,omitempty"`
+	Stale      int `json:"stale,omitempty"`
+	// Waived counts findings covered by an unexpired waiver, dropped or
+	// marked.
+	Waived int `json:"waived,omitempty"`
+	// Discovered counts findings added by CPE matching, EndOfLife those
+	// added for assets whose OS is past support.
+	Discovered int `json:"discovered,omitempty"`
+	EndOfLife  int `json:"end_of_life,omitempty"`
+	// Collapsed counts findings dropped as tag aliases of another on the
+	// same image digest.
+	Collapsed int `json:"collapsed,omitempty"`
+	// Duplicates counts findings merged into an earlier report of the
+	// same finding with -dedup.
+	Duplicates int `json:"duplicates,omitempty"`
+	// Grouped counts findings merged into another instance's report of
+	// the same finding on a logical asset with -group_assets.
+	Grouped int `json:"grouped,omitempty"`
+	// Filtered counts findings left out by a filtering flag, such as
+	// -severity.
+	Filtered int `json:"filtered,omitempty"`
+	// Withheld counts findings left out of the distributed reports by
+	// -max_per_team.
+	Withheld int `json:"withheld,omitempty"`
+	Written  int `json:"written"`
+}
+
+// Manifest describes one run.
+type Manifest struct {
+	ToolVersion  string    `json:"tool_version"`
+	StartedAt    time.Time `json:"started_at"`
+	ConfigSHA256 string    `json:"config_sha256"`
+	// Shard is the -shard the run processed, "i/N", if any.
+	Shard  string `json:"shard,omitempty"`
+	Inputs []File `json:"inputs"`
+	Rows   Rows   `json:"rows"`
+	// SLA summarises the SLA breaches among the findings written, when
+	// any has a due date.
+	SLA *sla.Summary `json:"sla,omitempty"`
+	// Scores counts the priority scores of the findings written by band,
+	// when any is written.
+	Scores  *scoring.Histogram `json:"scores,omitempty"`
+	Stages  []Stage            `json:"stages"`
+	Outputs []File             `json:"outputs"`
+	// Resources is set by Finish.
+	Resources Resources `json:"resources"`
+
+	startCPU   time.Duration
+	finishedAt time.Time
+}
+
+// Resources summarises what a run used.
+type Resources struct {
+	DurationMS float64 `json:"duration_ms"`
+	CPUMS      float64 `json:"cpu_ms"`
+	// PeakRSSBytes is the peak resident set size of the process so far,
+	// which spans earlier runs in a long-running process. It is zero
+	// where the platform does not report it.
+	PeakRSSBytes int64 `json:"peak_rss_bytes,omitempty"`
+	// RowsPerSec is the input rows read per second of the run.
+	RowsPerSec float64 `json:"rows_per_sec"`
+}
+
+// New starts a manifest for a run beginning now.
+func New(version string) *Manifest {
+	cpu, _ := usage()
+	return &Manifest{ToolVersion: version, StartedAt: time.Now().UTC(), startCPU: cpu}
+}
+
+// Finish records the run's resource usage up to now in m.Resources.
+func (m *Manifest) Finish() {
+	cpu, peak := usage()
+	m.finishedAt = time.Now().UTC()
+	elapsed := m.finishedAt.Sub(m.StartedAt)
+	m.Resources = Resources{
+		DurationMS:   milliseconds(elapsed),
+		CPUMS:        milliseconds(cpu - m.startCPU),
+		PeakRSSBytes: peak,
+	}
+	if elapsed > 0 {
+		m.Resources.RowsPerSec = math.Round(float64(m.Rows.Read)/elapsed.Seconds()*10) / 10
+	}
+}
+
+// StartStage begins timing a stage; call the returned function when the
+// stage ends.
+func (m *Manifest) StartStage(name string) (end func()) {
+	start := time.Now()
+	startCPU, _ := usage()
+	return func() {
+		cpu, _ := usage()
+		m.Stages = append(m.Stages, Stage{
+			Name:       name,
+			DurationMS: milliseconds(time.Since(start)),
+			CPUMS:      milliseconds(cpu - startCPU),
+		})
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// AddInput records the checksum of an input file.
+func (m *Manifest) AddInput(path string) error {
+	f, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+	m.Inputs = append(m.Inputs, f)
+	return nil
+}
+
+// AddOutput records the checksum of an output file.
+func (m *Manifest) AddOutput(path string) error {
+	f, err := HashFile(path)
+	if err != nil {
+		return err
+	}
+	m.Outputs = append(m.Outputs, f)
+	return nil
+}
+
+// HashFile returns path's SHA-256 checksum and size.
+func HashFile(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Path: path, SHA256: hex.EncodeToString(h.Sum(nil)), Bytes: n}, nil
+}
+
+// HashJSON returns the SHA-256 checksum of v's JSON encoding. encoding/json
+// sorts map keys, so equal configurations hash equally.
+func HashJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// WriteFile writes m to path as indented JSON, with permissions perm.
+func (m *Manifest) WriteFile(path string, perm os.FileMode) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, append(b, '\n'), perm)
+}
+
+// WriteCanonicalFile writes m to path as canonical JSON (see package
+// canonical), with permissions perm. Without timestamps, the start time,
+// stage timings and resource usage, which differ on every run, are left
+// out, so runs on the same input and configuration write equal manifests.
+func (m *Manifest) WriteCanonicalFile(path string, perm os.FileMode, timestamps bool) error {
+	var doc any = m
+	if !timestamps {
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var fields map[string]any
+		if err := dec.Decode(&fields); err != nil {
+			return err
+		}
+		delete(fields, "started_at")
+		delete(fields, "resources")
+		stages, _ := fields["stages"].([]any)
+		for _, stage := range stages {
+			if stage, ok := stage.(map[string]any); ok {
+				delete(stage, "duration_ms")
+				delete(stage, "cpu_ms")
+			}
+		}
+		doc = fields
+	}
+	b, err := canonical.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, b, perm)
+}
+
+// writeFile writes b to path with permissions perm, through a temporary
+// file renamed over it, so a failed write leaves no partial manifest.
+func writeFile(path string, b []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// CreateTemp makes the file 0600; perm applies whatever the umask.
+	err = tmp.Chmod(perm)
+	if err == nil {
+		_, err = tmp.Write(b)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+package manifest
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	if err := os.WriteFile(in, []byte("abc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New("v1.2.3")
+	end := m.StartStage("ingest")
+	end()
+	if err := m.AddInput(in); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddOutput(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("AddOutput of a missing file: want error")
+	}
+	m.Rows = Rows{Read: 3, Skipped: 1, Written: 2}
+	m.Finish()
+	if m.Resources.DurationMS <= 0 || m.Resources.RowsPerSec <= 0 {
+		t.Errorf("resources = %+v", m.Resources)
+	}
+
+	path := filepath.Join(dir, FileName)
+	if err := m.WriteFile(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Manifest
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	// SHA-256 of "abc".
+	const abc = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if len(got.Inputs) != 1 || got.Inputs[0].SHA256 != abc || got.Inputs[0].Bytes != 3 {
+		t.Errorf("inputs = %+v", got.Inputs)
+	}
+	if got.ToolVersion != "v1.2.3" || got.Rows.Written != 2 || len(got.Stages) != 1 || got.Stages[0].Name != "ingest" {
+		t.Errorf("manifest = %+v", got)
+	}
+}
+
+func TestHashJSONStable(t *testing.T) {
+	a, err := HashJSON(map[string]int{"x": 1, "y": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := HashJSON(map[string]int{"y": 2, "x": 1})
+	c, _ := HashJSON(map[string]int{"x": 1, "y": 3})
+	if a != b || a == c {
+		t.Errorf("hashes: %s %s %s", a, b, c)
+	}
+}
+
+func TestWriteCanonicalFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(timestamps bool) string {
+		m := New("v1.2.3")
+		m.StartStage("ingest")()
+		m.Rows = Rows{Read: 3, Written: 3}
+		m.Finish()
+		path := filepath.Join(dir, FileName)
+		if err := m.WriteCanonicalFile(path, 0o644, timestamps); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	a, b := write(false), write(false)
+	if a != b {
+		t.Errorf("manifests of equal runs differ:\n%s\n%s", a, b)
+	}
+	for _, key := range []string{"started_at", "resources", "duration_ms", "cpu_ms"} {
+		if strings.Contains(a, key) {
+			t.Errorf("manifest without timestamps has %s:\n%s", key, a)
+		}
+	}
+	if !strings.Contains(a, `"name": "ingest"`) || !strings.Contains(a, `"written": 3`) {
+		t.Errorf("manifest:\n%s", a)
+	}
+	if with := write(true); !strings.Contains(with, `"started_at"`) || !strings.Contains(with, `"duration_ms"`) {
+		t.Errorf("manifest with timestamps:\n%s", with)
+	}
+}
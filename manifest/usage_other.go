@@ -0,0 +1,10 @@
+//go:build !unix
+
+package manifest
+
+import "time"
+
+// usage reports nothing on platforms without getrusage.
+func usage() (cpu time.Duration, peakRSS int64) {
+	return 0, 0
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package manifest
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// usage returns the CPU time, user and system, the process has used and
+// its peak resident set size in bytes.
+func usage() (cpu time.Duration, peakRSS int64) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, 0
+	}
+	cpu = time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+	peakRSS = int64(ru.Maxrss)
+	// Darwin reports bytes; other Unixes report kilobytes.
+	if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+		peakRSS *= 1024
+	}
+	return cpu, peakRSS
+}
@@ -0,0 +1,208 @@
+// Package merge combines partial result sets, such as shard outputs or
+// files from separate teams, into one.
+package merge
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Rule decides which copy of a finding wins when partial results disagree.
+type Rule string
+
+// Conflict resolution rules.
+const (
+	// Highest keeps the copy with the highest priority score, so a merge
+	// never lowers a finding's urgency.
+	Highest Rule = "highest"
+	// First keeps the copy from the earliest input.
+	First Rule = "first"
+	// Last keeps the copy from the latest input, for overlaying corrected
+	// results.
+	Last Rule = "last"
+)
+
+// Rules lists the valid rules.
+var Rules = []Rule{Highest, First, Last}
+
+// ParseRule parses a rule name.
+func ParseRule(s string) (Rule, error) {
+	for _, r := range Rules {
+		if string(r) == s {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unknown conflict rule %q", s)
+}
+
+// Stats summarises a merge.
+type Stats struct {
+	// Read is the number of findings across all inputs.
+	Read int
+	// Duplicates is the number of copies dropped because an input already
+	// held the same finding.
+	Duplicates int
+	// Conflicts is the number of findings whose copies differed.
+	Conflicts int
+}
+
+// key identifies one reported finding: the same vendor row in several
+// inputs shares its unique ID and fingerprint.
+type key struct {
+	uniqueID, fingerprint string
+}
+
+// Merge concatenates parts, keeps one copy of each finding chosen by rule,
+// fills the winner's blank fields from the other copies, and sorts the
+// result by priority.
+func Merge(parts [][]vuln.Vulnerability, rule Rule) ([]vuln.Vulnerability, Stats) {
+	var stats Stats
+	index := make(map[key]int)
+	conflicted := make(map[int]bool)
+	var out []vuln.Vulnerability
+	for _, part := range parts {
+		for _, v := range part {
+			stats.Read++
+			k := key{v.UniqueID, v.Fingerprint()}
+			i, dup := index[k]
+			if !dup {
+				index[k] = len(out)
+				out = append(out, v)
+				continue
+			}
+			stats.Duplicates++
+			cur := &out[i]
+			if !equal(cur, &v) {
+				conflicted[i] = true
+			}
+			if wins(&v, cur, rule) {
+				v, *cur = *cur, v
+			}
+			FillBlanks(cur, &v)
+		}
+	}
+	stats.Conflicts = len(conflicted)
+	scoring.Sort(out)
+	return out, stats
+}
+
+// wins reports whether the new copy replaces the current one under rule.
+func wins(next, cur *vuln.Vulnerability, rule Rule) bool {
+	switch rule {
+	case Last:
+		return true
+	case Highest:
+		return next.PriorityScore > cur.PriorityScore
+	}
+	return false
+}
+
+// FillBlanks copies into dst the fields that dst lacks and src has.
+func FillBlanks(dst, src *vuln.Vulnerability) {
+	for _, f := range []struct{ dst, src *string }{
+		{&dst.Title, &src.Title},
+		{&dst.Description, &src.Description},
+		{&dst.RenamedFrom, &src.RenamedFrom},
+		{&dst.FixedVersion, &src.FixedVersion},
+		{&dst.Remediation, &src.Remediation},
+		{&dst.Team, &src.Team},
+		{&dst.CVSSVector, &src.CVSSVector},
+		{&dst.CVSSSource, &src.CVSSSource},
+		{&dst.Region, &src.Region},
+		{&dst.DataClassification, &src.DataClassification},
+		{&dst.Criticality, &src.Criticality},
+		{&dst.Environment, &src.Environment},
+		{&dst.Owner, &src.Owner},
+		{&dst.OS, &src.OS},
+		{&dst.OSFamily, &src.OSFamily},
+		{&dst.OSVersion, &src.OSVersion},
+		{&dst.Arch, &src.Arch},
+		{&dst.ImageDigest, &src.ImageDigest},
+		{&dst.State, &src.State},
+		{&dst.Waiver, &src.Waiver},
+		{&dst.Class, &src.Class},
+	} {
+		if *f.dst == "" {
+			*f.dst = *f.src
+		}
+	}
+	if dst.RelatedCVEs == nil {
+		dst.RelatedCVEs = src.RelatedCVEs
+	}
+	if dst.CWEs == nil {
+		dst.CWEs = src.CWEs
+	}
+	if dst.ImageTags == nil {
+		dst.ImageTags = src.ImageTags
+	}
+	if dst.Instances == nil {
+		dst.Instances = src.Instances
+	}
+	if dst.EPSS == 0 {
+		dst.EPSS, dst.EPSSPercentile = src.EPSS, src.EPSSPercentile
+	}
+	dst.KEV = dst.KEV || src.KEV
+	if vuln.MoreMatureExploit(src.Exploit, dst.Exploit) {
+		dst.Exploit = src.Exploit
+	}
+	dst.OSEndOfLife = dst.OSEndOfLife || src.OSEndOfLife
+	// A finding the scanner reported in any part was not only discovered.
+	dst.Discovered = dst.Discovered && src.Discovered
+	dst.StaleAsset = dst.StaleAsset || src.StaleAsset
+	dst.Suppressed = dst.Suppressed || src.Suppressed
+	if dst.DueDate.IsZero() {
+		dst.DueDate = src.DueDate
+	}
+	if dst.FirstDetectedDate.IsZero() {
+		dst.FirstDetectedDate = src.FirstDetectedDate
+	}
+	if dst.Occurrences == 0 {
+		dst.Occurrences = src.Occurrences
+	}
+	if dst.Dependency == "" {
+		dst.Dependency, dst.DependencyDepth = src.Dependency, src.DependencyDepth
+	}
+	if dst.NextPatchOpportunity.IsZero() {
+		dst.NextPatchOpportunity, dst.EmergencyChange = src.NextPatchOpportunity, src.EmergencyChange
+	}
+	if dst.ProfileScores == nil {
+		dst.ProfileScores = src.ProfileScores
+	}
+	copied := false
+	for name, value := range src.CustomFields {
+		if _, ok := dst.CustomFields[name]; ok {
+			continue
+		}
+		// The map may be shared with the caller's input; copy before writing.
+		if !copied {
+			dst.CustomFields = maps.Clone(dst.CustomFields)
+			if dst.CustomFields == nil {
+				dst.CustomFields = make(map[string]string)
+			}
+			copied = true
+		}
+		dst.CustomFields[name] = value
+	}
+}
+
+// equal reports whether a and b are the same in every field, comparing
+// dates by instant.
+func equal(a, b *vuln.Vulnerability) bool {
+	if !a.DueDate.Equal(b.DueDate.Time) || !a.FirstDetectedDate.Equal(b.FirstDetectedDate.Time) ||
+		!a.NextPatchOpportunity.Equal(b.NextPatchOpportunity.Time) {
+		return false
+	}
+	if !maps.Equal(a.CustomFields, b.CustomFields) {
+		return false
+	}
+	x, y := *a, *b
+	for _, v := range []*vuln.Vulnerability{&x, &y} {
+		v.DueDate, v.FirstDetectedDate, v.NextPatchOpportunity = vuln.Date{}, vuln.Date{}, vuln.Date{}
+		v.CustomFields = nil
+	}
+	return reflect.DeepEqual(x, y)
+}
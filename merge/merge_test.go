@@ -0,0 +1,66 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestMergeRules(t *testing.T) {
+	teamA := []vuln.Vulnerability{
+		{UniqueID: "1", Identifier: "CVE-1", PriorityScore: 5, Region: "NA"},
+		{UniqueID: "2", Identifier: "CVE-2", PriorityScore: 3},
+	}
+	teamB := []vuln.Vulnerability{
+		{UniqueID: "1", Identifier: "CVE-1", PriorityScore: 7, EPSS: 0.4, KEV: true}, // conflicting copy
+		{UniqueID: "2", Identifier: "CVE-2", PriorityScore: 3},                       // identical copy
+		{UniqueID: "3", Identifier: "CVE-1", PriorityScore: 4},                       // another row, kept
+	}
+
+	tests := []struct {
+		rule  Rule
+		score float64
+	}{
+		{Highest, 7},
+		{First, 5},
+		{Last, 7},
+	}
+	for _, tt := range tests {
+		got, stats := Merge([][]vuln.Vulnerability{teamA, teamB}, tt.rule)
+		if stats != (Stats{Read: 5, Duplicates: 2, Conflicts: 1}) {
+			t.Errorf("%s: stats = %+v", tt.rule, stats)
+		}
+		if len(got) != 3 || got[0].UniqueID != "1" {
+			t.Fatalf("%s: merged = %+v", tt.rule, got)
+		}
+		// The winner keeps its score and gains the fields only the other
+		// copy had.
+		if v := got[0]; v.PriorityScore != tt.score || v.Region != "NA" || v.EPSS != 0.4 || !v.KEV {
+			t.Errorf("%s: merged finding = %+v", tt.rule, v)
+		}
+	}
+}
+
+func TestMergeCustomFields(t *testing.T) {
+	a := []vuln.Vulnerability{{UniqueID: "1", Identifier: "CVE-1", PriorityScore: 7, CustomFields: map[string]string{"Cost Center": "CC-42"}}}
+	b := []vuln.Vulnerability{{UniqueID: "1", Identifier: "CVE-1", PriorityScore: 5, CustomFields: map[string]string{"Cost Center": "CC-7", "Patch Window": "Sun"}}}
+	got, stats := Merge([][]vuln.Vulnerability{a, b}, Highest)
+	if stats.Conflicts != 1 {
+		t.Errorf("stats = %+v, want differing custom fields to conflict", stats)
+	}
+	if f := got[0].CustomFields; len(f) != 2 || f["Cost Center"] != "CC-42" || f["Patch Window"] != "Sun" {
+		t.Errorf("merged CustomFields = %v", f)
+	}
+	if len(a[0].CustomFields) != 1 {
+		t.Errorf("input modified: %v", a[0].CustomFields)
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	if r, err := ParseRule("last"); err != nil || r != Last {
+		t.Errorf("ParseRule(last) = %v, %v", r, err)
+	}
+	if _, err := ParseRule("newest"); err == nil {
+		t.Error("ParseRule(newest): want error")
+	}
+}
@@ -0,0 +1,193 @@
+package notify
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Chat services a run summary is posted to through an incoming webhook.
+const (
+	Slack = "slack"
+	Teams = "teams"
+)
+
+// ChatServices lists the chat services.
+var ChatServices = []string{Slack, Teams}
+
+// Summary limits.
+const (
+	// TopPriorities is how many of the highest priority findings a
+	// summary lists.
+	TopPriorities = 5
+	// NewCriticalListed is how many new Critical findings a summary lists
+	// by name; it counts the rest.
+	NewCriticalListed = 10
+)
+
+// Summary is what a run tells a chat channel: its open findings, the
+// Critical ones that are new, the SLA breaches and the top priorities.
+type Summary struct {
+	Date     vuln.Date `json:"date"`
+	Findings int       `json:"findings"`
+	// NewCritical are the Critical findings first detected on Date or the
+	// day before, in priority order, so that a daily run reports each
+	// once whenever in the day it runs.
+	NewCritical []Item      `json:"new_critical"`
+	SLA         sla.Summary `json:"sla"`
+	Top         []Item      `json:"top"`
+}
+
+// Summarize summarizes vs as on today. Only open findings count, as for
+// Notify.
+func Summarize(vs []vuln.Vulnerability, today time.Time) Summary {
+	s := Summary{Date: vuln.NewDate(today.Year(), today.Month(), today.Day())}
+	var current []vuln.Vulnerability
+	for i := range vs {
+		if v := &vs[i]; open(v) {
+			current = append(current, *v)
+		}
+	}
+	slices.SortStableFunc(current, func(a, b vuln.Vulnerability) int {
+		return cmp.Compare(b.PriorityScore, a.PriorityScore)
+	})
+	s.Findings = len(current)
+	s.SLA = sla.Summarize(current)
+	for i := range current {
+		v := &current[i]
+		if v.Severity == vuln.Critical && !v.FirstDetectedDate.IsZero() && v.FirstDetectedDate.DaysUntil(today) >= -1 {
+			s.NewCritical = append(s.NewCritical, newItem(v))
+		}
+		if len(s.Top) < TopPriorities {
+			s.Top = append(s.Top, newItem(v))
+		}
+	}
+	return s
+}
+
+// section is a titled part of a summary, as lines of plain text.
+type section struct {
+	title string
+	lines []string
+}
+
+func (s Summary) title() string {
+	return fmt.Sprintf("Vulnerability summary for %s: %d open findings", s.Date, s.Findings)
+}
+
+func (s Summary) sections() []section {
+	newCritical := section{title: fmt.Sprintf("New Critical findings (%d)", len(s.NewCritical))}
+	for _, it := range s.NewCritical[:min(len(s.NewCritical), NewCriticalListed)] {
+		newCritical.lines = append(newCritical.lines, "• "+it.line())
+	}
+	if n := len(s.NewCritical) - NewCriticalListed; n > 0 {
+		newCritical.lines = append(newCritical.lines, fmt.Sprintf("and %d more", n))
+	}
+	if len(s.NewCritical) == 0 {
+		newCritical.lines = []string{"None since yesterday."}
+	}
+	top := section{title: fmt.Sprintf("Top %d priorities", len(s.Top))}
+	for i, it := range s.Top {
+		top.lines = append(top.lines, fmt.Sprintf("%d. %s", i+1, it.line()))
+	}
+	return []section{
+		newCritical,
+		{title: "SLA breaches", lines: []string{s.SLA.String()}},
+		top,
+	}
+}
+
+// line describes it in a line, as in "CVE-2024-45337 in
+// go-golang.org/x/crypto on acme-inc-lambda: score 9.34, Immediate, due
+// 2025-01-13".
+func (it Item) line() string {
+	var b strings.Builder
+	b.WriteString(it.Identifier)
+	if it.PackageName != "" {
+		b.WriteString(" in " + it.PackageName)
+	}
+	if it.AssetName != "" {
+		b.WriteString(" on " + it.AssetName)
+	}
+	fmt.Fprintf(&b, ": score %.2f, %s", it.PriorityScore, it.ActionTimeframe)
+	if !it.DueDate.IsZero() {
+		b.WriteString(", due " + it.DueDate.String())
+	}
+	return b.String()
+}
+
+// Payload returns the webhook message for s on service: Slack blocks in
+// mrkdwn, or a Teams message with an Adaptive Card, which both Workflows
+// and the older connector webhooks accept.
+func (s Summary) Payload(service string) ([]byte, error) {
+	switch service {
+	case Slack:
+		blocks := []any{map[string]any{"type": "header", "text": map[string]any{"type": "plain_text", "text": s.title()}}}
+		for _, sec := range s.sections() {
+			text := "*" + sec.title + "*\n" + slackEscape(strings.Join(sec.lines, "\n"))
+			blocks = append(blocks, map[string]any{"type": "section", "text": map[string]any{"type": "mrkdwn", "text": text}})
+		}
+		return json.Marshal(map[string]any{"text": s.title(), "blocks": blocks})
+	case Teams:
+		body := []any{map[string]any{"type": "TextBlock", "size": "Medium", "weight": "Bolder", "wrap": true, "text": s.title()}}
+		for _, sec := range s.sections() {
+			body = append(body,
+				map[string]any{"type": "TextBlock", "weight": "Bolder", "spacing": "Medium", "wrap": true, "text": sec.title},
+				map[string]any{"type": "TextBlock", "wrap": true, "spacing": "None", "text": strings.Join(sec.lines, "\n\n")},
+			)
+		}
+		card := map[string]any{
+			"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+			"type":    "AdaptiveCard", "version": "1.4", "body": body,
+		}
+		return json.Marshal(map[string]any{
+			"type":        "message",
+			"attachments": []any{map[string]any{"contentType": "application/vnd.microsoft.card.adaptive", "content": card}},
+		})
+	}
+	return nil, fmt.Errorf("unknown chat service %q (want %s)", service, strings.Join(ChatServices, " or "))
+}
+
+// slackEscape escapes the characters Slack reads as markup in text.
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+// Post posts s to the incoming webhook at hook on service. Errors leave
+// out hook, as a webhook URL is its own credential.
+func (s Summary) Post(ctx context.Context, client *http.Client, service, hook string) error {
+	b, err := s.Payload(service)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("%s webhook: invalid URL", service)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("%s webhook: %w", service, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s webhook: %s", service, resp.Status)
+	}
+	return nil
+}
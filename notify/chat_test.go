@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestSummarize(t *testing.T) {
+	today := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{
+		{Identifier: "CVE-1", Severity: vuln.Critical, PriorityScore: 9, FirstDetectedDate: vuln.NewDate(2025, 2, 4), DueDate: vuln.NewDate(2025, 2, 1)},
+		{Identifier: "CVE-2", Severity: vuln.Critical, PriorityScore: 8, FirstDetectedDate: vuln.NewDate(2025, 2, 3)},
+		{Identifier: "CVE-3", Severity: vuln.Critical, PriorityScore: 9.5, FirstDetectedDate: vuln.NewDate(2025, 2, 5), State: state.Resolved},
+		{Identifier: "CVE-4", Severity: vuln.High, PriorityScore: 7, FirstDetectedDate: vuln.NewDate(2025, 2, 5), DueDate: vuln.NewDate(2025, 3, 1)},
+		{Identifier: "CVE-5", Severity: vuln.Low, PriorityScore: 2},
+		{Identifier: "CVE-6", Severity: vuln.Medium, PriorityScore: 5},
+		{Identifier: "CVE-7", Severity: vuln.Medium, PriorityScore: 6},
+		{Identifier: "CVE-8", Severity: vuln.Low, PriorityScore: 1, Suppressed: true},
+	}
+	vs[0].SLAStatus, vs[0].DaysOverdue = "breached", 4
+	vs[3].SLAStatus = "within_sla"
+	s := Summarize(vs, today)
+	ids := func(items []Item) string {
+		var ids []string
+		for _, it := range items {
+			ids = append(ids, it.Identifier)
+		}
+		return strings.Join(ids, ",")
+	}
+	// CVE-2 was first detected before yesterday, and CVE-3 and CVE-8 are
+	// closed.
+	if s.Findings != 6 || ids(s.NewCritical) != "CVE-1" || ids(s.Top) != "CVE-1,CVE-2,CVE-4,CVE-7,CVE-6" {
+		t.Errorf("summary: %d findings, new %s, top %s", s.Findings, ids(s.NewCritical), ids(s.Top))
+	}
+	if s.SLA.Breached != 1 || s.SLA.Tracked != 2 || s.Date.String() != "2025-02-05" {
+		t.Errorf("summary: %+v", s)
+	}
+}
+
+func TestSummaryPost(t *testing.T) {
+	s := Summary{
+		Date:        vuln.NewDate(2025, 2, 5),
+		Findings:    2,
+		NewCritical: []Item{{Identifier: "CVE-1", PackageName: "openssl", AssetName: "web", PriorityScore: 9, ActionTimeframe: "Immediate", DueDate: vuln.NewDate(2025, 2, 12)}},
+		Top:         []Item{{Identifier: "CVE-1", PackageName: "openssl", AssetName: "web", PriorityScore: 9, ActionTimeframe: "Immediate"}, {Identifier: "CVE-<2>", PriorityScore: 5, ActionTimeframe: "Planned"}},
+	}
+	bodies := make(map[string]map[string]any)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/down" {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type %q", r.Header.Get("Content-Type"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(b, &body); err != nil {
+			t.Error(err)
+		}
+		bodies[strings.TrimPrefix(r.URL.Path, "/")] = body
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	for _, service := range ChatServices {
+		if err := s.Post(ctx, srv.Client(), service, srv.URL+"/"+service); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	slack, _ := json.Marshal(bodies[Slack]["blocks"])
+	for _, want := range []string{
+		`"type":"header"`, "Vulnerability summary for 2025-02-05: 2 open findings",
+		`*New Critical findings (1)*\n• CVE-1 in openssl on web: score 9.00, Immediate, due 2025-02-12`,
+		`*SLA breaches*\nNo finding is past its due date.`,
+		`*Top 2 priorities*\n1. CVE-1 in openssl on web: score 9.00, Immediate\n2. CVE-\u0026lt;2\u0026gt;: score 5.00, Planned`,
+	} {
+		if !strings.Contains(string(slack), want) {
+			t.Errorf("Slack blocks lack %s:\n%s", want, slack)
+		}
+	}
+	attachments := bodies[Teams]["attachments"].([]any)
+	card := attachments[0].(map[string]any)
+	if bodies[Teams]["type"] != "message" || card["contentType"] != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("Teams message %v", bodies[Teams])
+	}
+	if b, _ := json.Marshal(card["content"]); !strings.Contains(string(b), `"text":"1. CVE-1 in openssl on web: score 9.00, Immediate\n\n2. CVE-\u003c2\u003e: score 5.00, Planned"`) {
+		t.Errorf("Teams card:\n%s", b)
+	}
+
+	// Errors leave out the URL, which is the webhook's credential.
+	err := s.Post(ctx, srv.Client(), Slack, srv.URL+"/down")
+	if err == nil || !strings.Contains(err.Error(), "503") || strings.Contains(err.Error(), srv.URL) {
+		t.Errorf("failed post: %v", err)
+	}
+	err = s.Post(ctx, srv.Client(), Teams, "http://127.0.0.1:0/secret")
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("unreachable webhook: %v", err)
+	}
+	if _, err := s.Payload("discord"); err == nil {
+		t.Error("Payload(discord) = nil error")
+	}
+}
@@ -0,0 +1,320 @@
+// Package notify batches notifications about new findings into digests,
+// one per channel and period, instead of a message per run. Critical
+// findings in the KEV catalog bypass the digest and are sent at once.
+// Summaries of a run's findings can also be posted to Slack and Microsoft
+// Teams incoming webhooks.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/jobqueue"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Message kinds.
+const (
+	Digest    = "digest"
+	Immediate = "immediate"
+)
+
+// Channel is one notification destination.
+type Channel struct {
+	Name string `json:"name"`
+	// Target is an http(s) URL that messages are POSTed to as JSON, or a
+	// file they are appended to as JSON lines.
+	Target string `json:"target"`
+	// Digest is how often queued findings are sent: "hourly" (the
+	// default), "daily" or a duration such as "30m".
+	Digest string `json:"digest,omitempty"`
+	// MinSeverity, when set, leaves out less severe findings.
+	MinSeverity vuln.Severity `json:"min_severity,omitempty"`
+
+	period time.Duration
+}
+
+// Config lists the channels.
+type Config struct {
+	Channels []Channel `json:"channels"`
+}
+
+// Load reads a JSON channel configuration. Unknown keys are rejected.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c := &Config{}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := c.normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// normalize validates the channels and parses their digest periods and
+// severities.
+func (c *Config) normalize() error {
+	if len(c.Channels) == 0 {
+		return errors.New("no channels")
+	}
+	seen := make(map[string]bool)
+	for i := range c.Channels {
+		ch := &c.Channels[i]
+		if ch.Name == "" || seen[ch.Name] {
+			return fmt.Errorf("channel %d: names must be unique and not empty", i+1)
+		}
+		seen[ch.Name] = true
+		if ch.Target == "" {
+			return fmt.Errorf("channel %s: no target", ch.Name)
+		}
+		var err error
+		if ch.period, err = ParsePeriod(ch.Digest); err != nil {
+			return fmt.Errorf("channel %s: %w", ch.Name, err)
+		}
+		if ch.MinSeverity != "" {
+			if ch.MinSeverity, err = vuln.ParseSeverity(string(ch.MinSeverity)); err != nil {
+				return fmt.Errorf("channel %s: %w", ch.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ParsePeriod parses a digest period: "hourly", "daily" or a positive
+// duration. Empty is hourly.
+func ParsePeriod(s string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "hourly":
+		return time.Hour, nil
+	case "daily":
+		return 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid digest %q (want hourly, daily or a duration)", s)
+	}
+	return d, nil
+}
+
+// Item is a finding as notified.
+type Item struct {
+	Fingerprint     string        `json:"fingerprint"`
+	Identifier      string        `json:"identifier"`
+	AssetName       string        `json:"asset_name"`
+	PackageName     string        `json:"package_name"`
+	Severity        vuln.Severity `json:"severity"`
+	KEV             bool          `json:"kev,omitempty"`
+	PriorityScore   float64       `json:"priority_score"`
+	ActionTimeframe string        `json:"action_timeframe"`
+	DueDate         vuln.Date     `json:"due_date"`
+}
+
+func newItem(v *vuln.Vulnerability) Item {
+	return Item{
+		Fingerprint: v.Fingerprint(), Identifier: v.Identifier, AssetName: v.AssetName, PackageName: v.PackageName,
+		Severity: v.Severity, KEV: v.KEV, PriorityScore: v.PriorityScore, ActionTimeframe: v.ActionTimeframe, DueDate: v.DueDate,
+	}
+}
+
+// Message is one notification sent to a channel.
+type Message struct {
+	Channel  string    `json:"channel"`
+	Kind     string    `json:"kind"`
+	SentAt   time.Time `json:"sent_at"`
+	Findings []Item    `json:"findings"`
+}
+
+// ChannelState is what a channel has been told.
+type ChannelState struct {
+	// Notified holds the fingerprints of the findings queued or sent.
+	Notified map[string]bool `json:"notified"`
+	// Pending are the findings queued for the next digest, in priority
+	// order.
+	Pending    []Item    `json:"pending,omitempty"`
+	LastDigest time.Time `json:"last_digest,omitempty"`
+}
+
+// State holds every channel's state by channel name.
+type State struct {
+	Channels map[string]*ChannelState `json:"channels"`
+}
+
+// NewState returns an empty state.
+func NewState() *State {
+	return &State{Channels: make(map[string]*ChannelState)}
+}
+
+// LoadState reads a state saved with Save. A missing file is an empty
+// state.
+func LoadState(path string) (*State, error) {
+	s := NewState()
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Channels == nil {
+		s.Channels = make(map[string]*ChannelState)
+	}
+	return s, nil
+}
+
+// Save writes s to path as indented JSON.
+func (s *State) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Stats counts the findings a Notify call handled.
+type Stats struct {
+	Queued    int // added to a digest queue
+	Immediate int // sent at once
+	Digests   int // digests sent
+	Sent      int // findings in the digests sent
+}
+
+// Notifier tells each channel about the findings it has not been told
+// about. State must not be nil; use NewState or LoadState.
+type Notifier struct {
+	Config *Config
+	State  *State
+	Client *http.Client
+	// Queue, when set, takes the messages to deliver instead of sending
+	// them, so that a channel outage is retried by the queue.
+	Queue *jobqueue.Queue
+}
+
+// Notify queues each finding in vs a channel has not been told about, and
+// sends each channel's digest when its period has passed since the last
+// one. Critical findings in the KEV catalog are sent at once instead.
+// Resolved and risk-accepted findings, and those on decommissioned assets,
+// are not notified. A finding that
+// leaves vs is forgotten, so it is notified again if it comes back.
+//
+// A message that fails to send is retried on the next call; the first
+// error is returned after every channel has been tried.
+func (n *Notifier) Notify(ctx context.Context, vs []vuln.Vulnerability, now time.Time) (Stats, error) {
+	var (
+		stats    Stats
+		firstErr error
+	)
+	// Findings closed in the workflow or waived leave the queues like
+	// findings that leave vs.
+	current := make(map[string]bool, len(vs))
+	for i := range vs {
+		if v := &vs[i]; open(v) {
+			current[v.Fingerprint()] = true
+		}
+	}
+	for i := range n.Config.Channels {
+		ch := &n.Config.Channels[i]
+		cs := n.State.Channels[ch.Name]
+		if cs == nil {
+			cs = &ChannelState{}
+			n.State.Channels[ch.Name] = cs
+		}
+		if cs.Notified == nil {
+			cs.Notified = make(map[string]bool)
+		}
+		for fp := range cs.Notified {
+			if !current[fp] {
+				delete(cs.Notified, fp)
+			}
+		}
+		pending := cs.Pending[:0]
+		for _, it := range cs.Pending {
+			if current[it.Fingerprint] {
+				pending = append(pending, it)
+			}
+		}
+		cs.Pending = pending
+
+		var immediate []Item
+		for j := range vs {
+			v := &vs[j]
+			if ch.MinSeverity != "" && v.Severity.Rank() > ch.MinSeverity.Rank() {
+				continue
+			}
+			it := newItem(v)
+			if !current[it.Fingerprint] || cs.Notified[it.Fingerprint] {
+				continue
+			}
+			cs.Notified[it.Fingerprint] = true
+			if v.Severity == vuln.Critical && v.KEV {
+				immediate = append(immediate, it)
+			} else {
+				cs.Pending = append(cs.Pending, it)
+				stats.Queued++
+			}
+		}
+
+		if len(immediate) > 0 {
+			if err := n.send(ctx, ch, Message{Channel: ch.Name, Kind: Immediate, SentAt: now, Findings: immediate}); err != nil {
+				for _, it := range immediate {
+					delete(cs.Notified, it.Fingerprint)
+				}
+				if firstErr == nil {
+					firstErr = err
+				}
+			} else {
+				stats.Immediate += len(immediate)
+			}
+		}
+		if len(cs.Pending) > 0 && now.Sub(cs.LastDigest) >= ch.period {
+			if err := n.send(ctx, ch, Message{Channel: ch.Name, Kind: Digest, SentAt: now, Findings: cs.Pending}); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			stats.Digests++
+			stats.Sent += len(cs.Pending)
+			cs.Pending, cs.LastDigest = nil, now
+		}
+	}
+	return stats, firstErr
+}
+
+// open reports whether v is still to be fixed: not resolved or risk
+// accepted in the workflow, waived or on a decommissioned asset.
+func open(v *vuln.Vulnerability) bool {
+	return v.State != state.Resolved && v.State != state.RiskAccepted && !v.StaleAsset && !v.Suppressed
+}
+
+// send delivers m to ch's target, or queues it when n has a Queue.
+func (n *Notifier) send(ctx context.Context, ch *Channel, m Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if n.Queue != nil {
+		n.Queue.Enqueue("notify", ch.Target, b, m.SentAt)
+		return nil
+	}
+	if err := jobqueue.Deliver(ctx, n.Client, ch.Target, b); err != nil {
+		return fmt.Errorf("channel %s: %w", ch.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestLoadRejects(t *testing.T) {
+	for name, body := range map[string]string{
+		"no channels":   `{"channels": []}`,
+		"no target":     `{"channels": [{"name": "a"}]}`,
+		"repeated name": `{"channels": [{"name": "a", "target": "x"}, {"name": "a", "target": "y"}]}`,
+		"bad digest":    `{"channels": [{"name": "a", "target": "x", "digest": "weekly"}]}`,
+		"bad severity":  `{"channels": [{"name": "a", "target": "x", "min_severity": "Severe"}]}`,
+		"unknown key":   `{"channels": [{"name": "a", "target": "x", "digets": "daily"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "channels.json")
+			if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(path); err == nil || !strings.Contains(err.Error(), path) {
+				t.Errorf("Load = %v, want error naming the file", err)
+			}
+		})
+	}
+}
+
+func readMessages(t *testing.T, path string) []Message {
+	t.Helper()
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var ms []Message
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var m Message
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+func TestNotifyDigests(t *testing.T) {
+	dir := t.TempDir()
+	hourly := filepath.Join(dir, "hourly.jsonl")
+	daily := filepath.Join(dir, "daily.jsonl")
+	cfg := &Config{Channels: []Channel{
+		{Name: "team", Target: hourly},
+		{Name: "leads", Target: daily, Digest: "daily", MinSeverity: vuln.High},
+	}}
+	if err := cfg.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	n := &Notifier{Config: cfg, State: NewState()}
+	ctx := context.Background()
+	start := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+
+	vs := []vuln.Vulnerability{
+		{Identifier: "CVE-1", AssetID: "a", Severity: vuln.High},
+		{Identifier: "CVE-2", AssetID: "a", Severity: vuln.Low},
+		{Identifier: "CVE-3", AssetID: "a", Severity: vuln.Critical, KEV: true},
+	}
+	stats, err := n.Notify(ctx, vs, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each channel sends the KEV finding at once and its first digest.
+	if stats != (Stats{Queued: 3, Immediate: 2, Digests: 2, Sent: 3}) {
+		t.Errorf("first cycle stats = %+v", stats)
+	}
+
+	// Findings that arrive within the period wait for the next digest;
+	// findings already sent are not sent again.
+	vs = append(vs, vuln.Vulnerability{Identifier: "CVE-4", AssetID: "a", Severity: vuln.Medium},
+		vuln.Vulnerability{Identifier: "CVE-5", AssetID: "a", Severity: vuln.Critical})
+	for i, now := range []time.Time{start.Add(20 * time.Minute), start.Add(40 * time.Minute)} {
+		if stats, err = n.Notify(ctx, vs, now); err != nil {
+			t.Fatal(err)
+		}
+		if stats.Digests != 0 || (i == 0) != (stats.Queued == 3) {
+			t.Errorf("cycle %d within the hour: %+v", i+2, stats)
+		}
+	}
+	if stats, err = n.Notify(ctx, vs, start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if stats != (Stats{Digests: 1, Sent: 2}) {
+		t.Errorf("after an hour: %+v", stats)
+	}
+
+	team := readMessages(t, hourly)
+	if len(team) != 3 || team[0].Kind != Immediate || team[1].Kind != Digest || team[2].Kind != Digest {
+		t.Fatalf("team messages = %+v", team)
+	}
+	if got := team[2].Findings; len(got) != 2 || got[0].Identifier != "CVE-4" || got[1].Identifier != "CVE-5" {
+		t.Errorf("second hourly digest = %+v", got)
+	}
+	leads := readMessages(t, daily)
+	if len(leads) != 2 || len(leads[1].Findings) != 1 || leads[1].Findings[0].Identifier != "CVE-1" {
+		t.Errorf("lead messages = %+v, want the KEV finding and a digest of the High one", leads)
+	}
+	if q := n.State.Channels["leads"].Pending; len(q) != 1 || q[0].Identifier != "CVE-5" {
+		t.Errorf("daily queue = %+v", q)
+	}
+
+	// A resolved finding leaves the queue.
+	vs[4].State = state.Resolved
+	if _, err := n.Notify(ctx, vs, start.Add(25*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if leads := readMessages(t, daily); len(leads) != 2 {
+		t.Errorf("sent a digest of resolved findings: %+v", leads[2:])
+	}
+}
+
+func TestNotifyRetriesFailedWebhook(t *testing.T) {
+	var bodies []Message
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var m Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Error(err)
+		}
+		bodies = append(bodies, m)
+	}))
+	defer srv.Close()
+
+	cfg := &Config{Channels: []Channel{{Name: "hook", Target: srv.URL}}}
+	if err := cfg.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	n := &Notifier{Config: cfg, State: NewState(), Client: srv.Client()}
+	vs := []vuln.Vulnerability{
+		{Identifier: "CVE-1", Severity: vuln.Critical, KEV: true},
+		{Identifier: "CVE-2", Severity: vuln.High},
+	}
+	now := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	if _, err := n.Notify(context.Background(), vs, now); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("Notify = %v, want the webhook's status", err)
+	}
+	fail = false
+	if _, err := n.Notify(context.Background(), vs, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 2 || bodies[0].Kind != Immediate || bodies[0].Findings[0].Identifier != "CVE-1" ||
+		bodies[1].Kind != Digest || bodies[1].Findings[0].Identifier != "CVE-2" {
+		t.Errorf("webhook received %+v", bodies)
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	s, err := LoadState(path)
+	if err != nil || len(s.Channels) != 0 {
+		t.Fatalf("LoadState(missing) = %+v, %v", s, err)
+	}
+	last := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	s.Channels["team"] = &ChannelState{Notified: map[string]bool{"fp": true}, Pending: []Item{{Fingerprint: "fp", Identifier: "CVE-1"}}, LastDigest: last}
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = LoadState(path); err != nil {
+		t.Fatal(err)
+	}
+	cs := s.Channels["team"]
+	if cs == nil || !cs.Notified["fp"] || len(cs.Pending) != 1 || !cs.LastDigest.Equal(last) {
+		t.Errorf("loaded %+v", cs)
+	}
+}
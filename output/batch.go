@@ -0,0 +1,134 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// A Sink receives findings in batches. Implementations for slow
+// destinations, such as a network database or object store, should return
+// only once the batch is durable.
+type Sink interface {
+	WriteBatch(ctx context.Context, batch []vuln.Vulnerability) error
+}
+
+// BatchOptions configure a Batcher.
+type BatchOptions struct {
+	// Size is the number of findings per batch.
+	Size int
+	// FlushInterval bounds how long a partial batch waits before it is
+	// written. Zero waits for a full batch.
+	FlushInterval time.Duration
+	// Queue is the number of findings buffered ahead of the sink, two
+	// batches by default. Add blocks while the queue is full.
+	Queue int
+}
+
+// DefaultBatchOptions suit file and local database sinks.
+var DefaultBatchOptions = BatchOptions{Size: 500, FlushInterval: time.Second}
+
+// Batcher writes findings to a Sink in batches from a separate goroutine.
+// The bounded queue applies back-pressure: producers block rather than
+// buffer without limit when the sink falls behind.
+type Batcher struct {
+	sink  Sink
+	opts  BatchOptions
+	queue chan vuln.Vulnerability
+	// done is closed when the writer goroutine exits; err is then set.
+	done chan struct{}
+	err  error
+}
+
+// ErrBatcherClosed is returned by Add after Close.
+var ErrBatcherClosed = errors.New("batcher closed")
+
+// NewBatcher starts a Batcher writing to sink. ctx bounds every
+// WriteBatch call.
+func NewBatcher(ctx context.Context, sink Sink, opts BatchOptions) *Batcher {
+	if opts.Size <= 0 {
+		opts.Size = DefaultBatchOptions.Size
+	}
+	if opts.Queue <= 0 {
+		opts.Queue = 2 * opts.Size
+	}
+	b := &Batcher{
+		sink:  sink,
+		opts:  opts,
+		queue: make(chan vuln.Vulnerability, opts.Queue),
+		done:  make(chan struct{}),
+	}
+	go b.run(ctx)
+	return b
+}
+
+// Add queues v, blocking while the queue is full. It returns the sink's
+// error if an earlier batch failed.
+func (b *Batcher) Add(ctx context.Context, v vuln.Vulnerability) error {
+	select {
+	case <-b.done:
+		if b.err != nil {
+			return b.err
+		}
+		return ErrBatcherClosed
+	default:
+	}
+	select {
+	case b.queue <- v:
+		return nil
+	case <-b.done:
+		return b.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes any queued findings, waits for the sink and returns the
+// first error it reported. Add must not be called concurrently with Close.
+func (b *Batcher) Close() error {
+	close(b.queue)
+	<-b.done
+	return b.err
+}
+
+func (b *Batcher) run(ctx context.Context) {
+	defer close(b.done)
+	var tick <-chan time.Time
+	if b.opts.FlushInterval > 0 {
+		t := time.NewTicker(b.opts.FlushInterval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	batch := make([]vuln.Vulnerability, 0, b.opts.Size)
+	flush := func() bool {
+		if len(batch) == 0 {
+			return true
+		}
+		if err := b.sink.WriteBatch(ctx, batch); err != nil {
+			b.err = err
+			return false
+		}
+		batch = batch[:0]
+		return true
+	}
+	for {
+		select {
+		case v, ok := <-b.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, v)
+			if len(batch) == b.opts.Size && !flush() {
+				return
+			}
+		case <-tick:
+			if !flush() {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// recordingSink records batch sizes and can be made to block or fail.
+type recordingSink struct {
+	mu      sync.Mutex
+	sizes   []int
+	release chan struct{}
+	err     error
+}
+
+func (s *recordingSink) WriteBatch(ctx context.Context, batch []vuln.Vulnerability) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sizes = append(s.sizes, len(batch))
+	return s.err
+}
+
+func TestBatcherBatches(t *testing.T) {
+	sink := &recordingSink{}
+	b := NewBatcher(context.Background(), sink, BatchOptions{Size: 3})
+	for i := 0; i < 7; i++ {
+		if err := b.Add(context.Background(), vuln.Vulnerability{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.sizes) != 3 || sink.sizes[0] != 3 || sink.sizes[1] != 3 || sink.sizes[2] != 1 {
+		t.Errorf("batch sizes = %v, want [3 3 1]", sink.sizes)
+	}
+}
+
+func TestBatcherFlushInterval(t *testing.T) {
+	sink := &recordingSink{}
+	b := NewBatcher(context.Background(), sink, BatchOptions{Size: 100, FlushInterval: 10 * time.Millisecond})
+	defer b.Close()
+	if err := b.Add(context.Background(), vuln.Vulnerability{}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		sink.mu.Lock()
+		n := len(sink.sizes)
+		sink.mu.Unlock()
+		if n == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("partial batch was not flushed by the interval")
+}
+
+func TestBatcherBackPressure(t *testing.T) {
+	sink := &recordingSink{release: make(chan struct{})}
+	b := NewBatcher(context.Background(), sink, BatchOptions{Size: 2, Queue: 2})
+
+	// One batch is held by the blocked sink and two findings fill the
+	// queue; the next Add must block until the sink catches up.
+	for i := 0; i < 4; i++ {
+		if err := b.Add(context.Background(), vuln.Vulnerability{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Add(ctx, vuln.Vulnerability{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Add on a full queue = %v, want deadline exceeded", err)
+	}
+	close(sink.release)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatcherSinkError(t *testing.T) {
+	boom := errors.New("boom")
+	sink := &recordingSink{err: boom}
+	b := NewBatcher(context.Background(), sink, BatchOptions{Size: 1})
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = b.Add(context.Background(), vuln.Vulnerability{})
+		time.Sleep(time.Millisecond)
+	}
+	if !errors.Is(err, boom) {
+		t.Errorf("Add after a failed batch = %v, want boom", err)
+	}
+	if err := b.Close(); !errors.Is(err, boom) {
+		t.Errorf("Close = %v, want boom", err)
+	}
+}
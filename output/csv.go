@@ -0,0 +1,238 @@
+// Package output writes prioritized vulnerabilities in the supported
+// output formats.
+package output
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Column is one CSV output column.
+type Column struct {
+	Header string
+	Value  func(v *vuln.Vulnerability) string
+}
+
+// Columns are the CSV output columns: the input export's columns, in its
+// order and with its header names, followed by the enrichment and computed
+// fields.
+var Columns = []Column{
+	{"Unique ID", func(v *vuln.Vulnerability) string { return v.UniqueID }},
+	{"Asset name", func(v *vuln.Vulnerability) string { return v.AssetName }},
+	{"Asset id", func(v *vuln.Vulnerability) string { return v.AssetID }},
+	{"Organization/Account", func(v *vuln.Vulnerability) string { return v.Organization }},
+	{"Identifier", func(v *vuln.Vulnerability) string { return v.Identifier }},
+	{"Source", func(v *vuln.Vulnerability) string { return v.Source }},
+	{"CVSS", func(v *vuln.Vulnerability) string { return formatFloat(v.CVSS) }},
+	{"Title", func(v *vuln.Vulnerability) string { return v.Title }},
+	{"Description", func(v *vuln.Vulnerability) string { return v.Description }},
+	{"Package Name", func(v *vuln.Vulnerability) string { return v.PackageName }},
+	{"Installed Version", func(v *vuln.Vulnerability) string { return v.InstalledVersion }},
+	{"Fixed Version", func(v *vuln.Vulnerability) string { return v.FixedVersion }},
+	{"Remediation", func(v *vuln.Vulnerability) string { return v.Remediation }},
+	{"Severity", func(v *vuln.Vulnerability) string { return string(v.Severity) }},
+	{"Due date", func(v *vuln.Vulnerability) string { return v.DueDate.String() }},
+	{"First detected date", func(v *vuln.Vulnerability) string { return v.FirstDetectedDate.String() }},
+	{"Fixability", func(v *vuln.Vulnerability) string { return v.Fixability }},
+	{"EPSS", func(v *vuln.Vulnerability) string { return formatOptionalFloat(v.EPSS) }},
+	{"EPSS Percentile", func(v *vuln.Vulnerability) string { return formatOptionalFloat(v.EPSSPercentile) }},
+	{"KEV", func(v *vuln.Vulnerability) string { return strconv.FormatBool(v.KEV) }},
+	{"Exploit Maturity", func(v *vuln.Vulnerability) string { return v.Exploit }},
+	{"CVSS Source", func(v *vuln.Vulnerability) string { return v.CVSSSource }},
+	{"Team", func(v *vuln.Vulnerability) string { return v.Team }},
+	{"CVSS Vector", func(v *vuln.Vulnerability) string { return v.CVSSVector }},
+	{"Related CVEs", func(v *vuln.Vulnerability) string { return strings.Join(v.RelatedCVEs, "; ") }},
+	{"CWEs", func(v *vuln.Vulnerability) string { return strings.Join(v.CWEs, "; ") }},
+	{"Class", func(v *vuln.Vulnerability) string { return v.Class }},
+	{"Region", func(v *vuln.Vulnerability) string { return v.Region }},
+	{"Data Classification", func(v *vuln.Vulnerability) string { return v.DataClassification }},
+	{"Criticality", func(v *vuln.Vulnerability) string { return v.Criticality }},
+	{"Environment", func(v *vuln.Vulnerability) string { return v.Environment }},
+	{"Asset Owner", func(v *vuln.Vulnerability) string { return v.Owner }},
+	{"Image Digest", func(v *vuln.Vulnerability) string { return v.ImageDigest }},
+	{"Image Tags", func(v *vuln.Vulnerability) string { return strings.Join(v.ImageTags, "; ") }},
+	{"Occurrences", func(v *vuln.Vulnerability) string { return formatOptionalInt(v.Occurrences) }},
+	{"Instances", func(v *vuln.Vulnerability) string { return formatOptionalInt(len(v.Instances)) }},
+	{"Instance IDs", func(v *vuln.Vulnerability) string { return strings.Join(v.Instances, "; ") }},
+	{"OS", func(v *vuln.Vulnerability) string { return v.OS }},
+	{"OS End Of Life", func(v *vuln.Vulnerability) string { return strconv.FormatBool(v.OSEndOfLife) }},
+	{"OS Family", func(v *vuln.Vulnerability) string { return v.OSFamily }},
+	{"OS Version", func(v *vuln.Vulnerability) string { return v.OSVersion }},
+	{"Architecture", func(v *vuln.Vulnerability) string { return v.Arch }},
+	{"Discovered", func(v *vuln.Vulnerability) string { return strconv.FormatBool(v.Discovered) }},
+	{"Stale Asset", func(v *vuln.Vulnerability) string { return strconv.FormatBool(v.StaleAsset) }},
+	{"Suppressed", func(v *vuln.Vulnerability) string { return strconv.FormatBool(v.Suppressed) }},
+	{"Waiver", func(v *vuln.Vulnerability) string { return v.Waiver }},
+	{"State", func(v *vuln.Vulnerability) string { return v.State }},
+	{"Ticket", func(v *vuln.Vulnerability) string { return v.Ticket }},
+	{"Dependency", func(v *vuln.Vulnerability) string { return v.Dependency }},
+	{"Dependency Depth", func(v *vuln.Vulnerability) string { return formatOptionalInt(v.DependencyDepth) }},
+	{"Renamed From", func(v *vuln.Vulnerability) string { return v.RenamedFrom }},
+	{"Next Patch Opportunity", func(v *vuln.Vulnerability) string { return v.NextPatchOpportunity.String() }},
+	{"Emergency Change", func(v *vuln.Vulnerability) string { return strconv.FormatBool(v.EmergencyChange) }},
+	{"Confidence", func(v *vuln.Vulnerability) string { return formatOptionalFloat(v.Confidence) }},
+	{"Noise Reasons", func(v *vuln.Vulnerability) string { return strings.Join(v.NoiseReasons, "; ") }},
+	{"Effort", func(v *vuln.Vulnerability) string { return formatOptionalInt(v.Effort) }},
+	{"SLA Status", func(v *vuln.Vulnerability) string { return v.SLAStatus }},
+	{"Days Overdue", func(v *vuln.Vulnerability) string { return formatOptionalInt(v.DaysOverdue) }},
+	{"Breaches SLA On", func(v *vuln.Vulnerability) string { return v.BreachesSLAOn.String() }},
+	{"Temporal CVSS", func(v *vuln.Vulnerability) string { return formatFloat(v.TemporalCVSS) }},
+	{"Priority Score", func(v *vuln.Vulnerability) string { return formatFloat(v.PriorityScore) }},
+	{"Action Timeframe", func(v *vuln.Vulnerability) string { return v.ActionTimeframe }},
+	{"Remediate Within Days", func(v *vuln.Vulnerability) string { return strconv.Itoa(v.RemediateWithinDays) }},
+	{"Score In 30 Days", func(v *vuln.Vulnerability) string { return formatFloat(v.ScoreIn30Days) }},
+	{"Score In 60 Days", func(v *vuln.Vulnerability) string { return formatFloat(v.ScoreIn60Days) }},
+	{"Score In 90 Days", func(v *vuln.Vulnerability) string { return formatFloat(v.ScoreIn90Days) }},
+}
+
+// WriteCSV writes vs as CSV with a header row, followed by a score column
+// for each weight profile (see ProfileColumns) and a column for each
+// custom field any finding has (see CustomColumns).
+func WriteCSV(w io.Writer, vs []vuln.Vulnerability) error {
+	s := NewCSVSink(w, ProfileColumns(vs), CustomColumns(vs))
+	if err := s.WriteBatch(context.Background(), vs); err != nil {
+		return err
+	}
+	return s.Close()
+}
+
+// ProfileColumns returns the names of the weight profiles any of vs was
+// scored against, sorted.
+func ProfileColumns(vs []vuln.Vulnerability) []string {
+	return sortedKeys(vs, func(v *vuln.Vulnerability) []string { return mapKeys(v.ProfileScores) })
+}
+
+// ProfileHeader returns the header of the score column for the profile
+// named name.
+func ProfileHeader(name string) string {
+	return "Priority Score (" + name + ")"
+}
+
+// CustomColumns returns the names of the custom fields set on any of vs,
+// sorted.
+func CustomColumns(vs []vuln.Vulnerability) []string {
+	return sortedKeys(vs, func(v *vuln.Vulnerability) []string { return mapKeys(v.CustomFields) })
+}
+
+// sortedKeys returns the distinct names keys returns for any of vs,
+// sorted.
+func sortedKeys(vs []vuln.Vulnerability, keys func(*vuln.Vulnerability) []string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for i := range vs {
+		for _, name := range keys(&vs[i]) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// CSVSink is a Sink writing CSV with a header row. Each batch is flushed
+// to the underlying writer.
+type CSVSink struct {
+	cw          *csv.Writer
+	profiles    []string
+	custom      []string
+	row         []string
+	wroteHeader bool
+}
+
+// NewCSVSink returns a CSVSink writing to w. After Columns, it writes a
+// score column for each of the weight profile names in profiles, then a
+// column for each of the custom field names in custom, in order; the
+// header must be known up front because the first batch may not include
+// every field.
+func NewCSVSink(w io.Writer, profiles, custom []string) *CSVSink {
+	return &CSVSink{cw: csv.NewWriter(w), profiles: profiles, custom: custom, row: make([]string, len(Columns)+len(profiles)+len(custom))}
+}
+
+func (s *CSVSink) WriteBatch(_ context.Context, batch []vuln.Vulnerability) error {
+	if err := s.writeHeader(); err != nil {
+		return err
+	}
+	for i := range batch {
+		for j, c := range Columns {
+			s.row[j] = c.Value(&batch[i])
+		}
+		n := len(Columns)
+		for _, name := range s.profiles {
+			s.row[n] = ""
+			if score, ok := batch[i].ProfileScores[name]; ok {
+				s.row[n] = formatFloat(score)
+			}
+			n++
+		}
+		for _, name := range s.custom {
+			s.row[n] = batch[i].CustomFields[name]
+			n++
+		}
+		if err := s.cw.Write(s.row); err != nil {
+			return err
+		}
+	}
+	s.cw.Flush()
+	return s.cw.Error()
+}
+
+// Close writes the header if no batch was written and flushes the output.
+// It does not close the underlying writer.
+func (s *CSVSink) Close() error {
+	if err := s.writeHeader(); err != nil {
+		return err
+	}
+	s.cw.Flush()
+	return s.cw.Error()
+}
+
+func (s *CSVSink) writeHeader() error {
+	if s.wroteHeader {
+		return nil
+	}
+	s.wroteHeader = true
+	header := make([]string, 0, len(s.row))
+	for _, c := range Columns {
+		header = append(header, c.Header)
+	}
+	for _, name := range s.profiles {
+		header = append(header, ProfileHeader(name))
+	}
+	return s.cw.Write(append(header, s.custom...))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatOptionalFloat formats f, or "" when f is zero (not enriched).
+func formatOptionalFloat(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return formatFloat(f)
+}
+
+// formatOptionalInt formats n, or "" when n is zero (not set).
+func formatOptionalInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/VioletX-Dev/devsecops-test/canonical"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// WriteJSON writes vs as an indented JSON array.
+func WriteJSON(w io.Writer, vs []vuln.Vulnerability) error {
+	if vs == nil {
+		vs = []vuln.Vulnerability{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(vs)
+}
+
+// WriteCanonicalJSON writes vs as a canonical JSON array (see package
+// canonical) in fingerprint order rather than priority order, so a finding
+// whose score changes stays where it was and consecutive results diff
+// line by line. ReadJSON reads it like WriteJSON's output.
+func WriteCanonicalJSON(w io.Writer, vs []vuln.Vulnerability) error {
+	sorted := slices.Clone(vs)
+	if sorted == nil {
+		sorted = []vuln.Vulnerability{}
+	}
+	slices.SortStableFunc(sorted, func(a, b vuln.Vulnerability) int {
+		return cmp.Or(cmp.Compare(a.Fingerprint(), b.Fingerprint()), cmp.Compare(a.UniqueID, b.UniqueID))
+	})
+	b, err := canonical.Marshal(sorted)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// ReadJSON reads a JSON array written by WriteJSON.
+func ReadJSON(r io.Reader) ([]vuln.Vulnerability, error) {
+	var vs []vuln.Vulnerability
+	if err := json.NewDecoder(r).Decode(&vs); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// WriteJSONL writes vs as JSON Lines: one compact JSON object per finding
+// and line, in order, so that a log pipeline or a bulk loader such as
+// Elasticsearch's or BigQuery's can take the findings one at a time.
+func WriteJSONL(w io.Writer, vs []vuln.Vulnerability) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for i := range vs {
+		if err := enc.Encode(&vs[i]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadJSONL reads JSON Lines written by WriteJSONL. Blank lines are
+// skipped.
+func ReadJSONL(r io.Reader) ([]vuln.Vulnerability, error) {
+	dec := json.NewDecoder(r)
+	vs := []vuln.Vulnerability{}
+	for {
+		var v vuln.Vulnerability
+		err := dec.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			return vs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("finding %d: %w", len(vs)+1, err)
+		}
+		vs = append(vs, v)
+	}
+}
@@ -0,0 +1,230 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+var sample = []vuln.Vulnerability{
+	{
+		UniqueID: "1", AssetName: "apply-frontend", Identifier: "CVE-2024-4367", Source: "github",
+		CVSS: 8.8, CVSSVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:H/A:H/E:P", TemporalCVSS: 8.3, Description: "### Impact\nline two", Severity: vuln.High,
+		DueDate: vuln.NewDate(2024, 6, 6), FirstDetectedDate: vuln.NewDate(2024, 5, 7),
+		EPSS: 0.0123, EPSSPercentile: 0.85, KEV: true, Region: "NA",
+		Dependency: vuln.DependencyTransitive, DependencyDepth: 2,
+		NextPatchOpportunity: vuln.NewDate(2024, 6, 15), EmergencyChange: true,
+		Confidence: 0.3, NoiseReasons: []string{"kernel-in-container-image", "dev-dependency"},
+		PriorityScore: 7.41, ActionTimeframe: "Urgent", RemediateWithinDays: 7,
+		ProfileScores: map[string]float64{"proposed": 8.02, "current": 7.41},
+		CustomFields:  map[string]string{"Patch Window": "Sun 02:00", "Cost Center": "CC-42"},
+	},
+	{UniqueID: "2", Identifier: "CVE-2020-1752", Source: "aws", CVSS: 7, Severity: vuln.High},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, sample); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2", len(rows))
+	}
+	get := func(row int, header string) string {
+		for i, h := range rows[0] {
+			if h == header {
+				return rows[row][i]
+			}
+		}
+		t.Fatalf("no column %q", header)
+		return ""
+	}
+	if got := get(1, "Description"); got != "### Impact\nline two" {
+		t.Errorf("Description = %q", got)
+	}
+	if got := get(1, "Due date"); got != "2024-06-06" {
+		t.Errorf("Due date = %q", got)
+	}
+	if get(1, "Priority Score") != "7.41" || get(1, "Action Timeframe") != "Urgent" {
+		t.Errorf("computed columns = %v", rows[1])
+	}
+	if got := get(2, "Due date"); got != "" {
+		t.Errorf("zero date = %q, want empty", got)
+	}
+	want := []string{"Priority Score (current)", "Priority Score (proposed)", "Cost Center", "Patch Window"}
+	if got := rows[0][len(Columns):]; !reflect.DeepEqual(got, want) {
+		t.Errorf("extra columns = %q, want profile scores then custom fields, sorted, after the standard ones", got)
+	}
+	if get(1, "Priority Score (proposed)") != "8.02" || get(2, "Priority Score (proposed)") != "" {
+		t.Errorf("Priority Score (proposed) = %q, %q", get(1, "Priority Score (proposed)"), get(2, "Priority Score (proposed)"))
+	}
+	if get(1, "Cost Center") != "CC-42" || get(2, "Cost Center") != "" {
+		t.Errorf("Cost Center = %q, %q", get(1, "Cost Center"), get(2, "Cost Center"))
+	}
+}
+
+// TestCSVRoundTrip checks that ingest reads the tool's own CSV output back,
+// which the enrich subcommand relies on.
+func TestCSVRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, sample); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ingest.ReadCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Skipped) != 0 {
+		t.Fatalf("skipped %v", res.Skipped)
+	}
+	if !reflect.DeepEqual(res.Vulns, sample) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", res.Vulns, sample)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, sample); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sample) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, sample)
+	}
+}
+
+func TestWriteJSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("got %q, want []", buf.String())
+	}
+}
+
+func TestJSONLRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONL(&buf, sample); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(sample) || !strings.HasPrefix(lines[0], "{") || !strings.HasSuffix(lines[0], "}") {
+		t.Fatalf("want one object per line:\n%s", buf.String())
+	}
+	got, err := ReadJSONL(strings.NewReader(buf.String() + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sample) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, sample)
+	}
+
+	buf.Reset()
+	if err := WriteJSONL(&buf, nil); err != nil || buf.Len() != 0 {
+		t.Errorf("no findings wrote %q, %v", buf.String(), err)
+	}
+	if _, err := ReadJSONL(strings.NewReader(lines[0] + "\n{\"cvss\": \"high\"}\n")); err == nil || !strings.Contains(err.Error(), "finding 2") {
+		t.Errorf("bad line: %v", err)
+	}
+}
+
+func TestWriteCanonicalJSON(t *testing.T) {
+	reversed := slices.Clone(sample)
+	slices.Reverse(reversed)
+	var a, b bytes.Buffer
+	if err := WriteCanonicalJSON(&a, sample); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCanonicalJSON(&b, reversed); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() {
+		t.Errorf("output depends on the findings' order:\n%s\n%s", a.String(), b.String())
+	}
+	if !strings.Contains(a.String(), "\n    \"asset_id\": ") || strings.Index(a.String(), `"asset_id"`) > strings.Index(a.String(), `"asset_name"`) {
+		t.Errorf("keys not sorted:\n%s", a.String())
+	}
+	got, err := ReadJSON(&a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(sample) {
+		t.Fatalf("read back %d findings, want %d", len(got), len(sample))
+	}
+	for _, v := range got {
+		i := slices.IndexFunc(sample, func(s vuln.Vulnerability) bool { return s.UniqueID == v.UniqueID })
+		if i < 0 || !reflect.DeepEqual(v, sample[i]) {
+			t.Errorf("round trip of %s: %+v", v.UniqueID, v)
+		}
+	}
+}
+
+func TestValidateFindings(t *testing.T) {
+	if err := ValidateFindings(sample); err != nil {
+		t.Errorf("ValidateFindings(sample): %v", err)
+	}
+	bad := slices.Clone(sample)
+	bad[0].CVSS = 11
+	bad[1].Severity = "Severe"
+	bad[1].ImageDigest = "latest"
+	err := ValidateFindings(bad)
+	if err == nil {
+		t.Fatal("ValidateFindings accepted invalid findings")
+	}
+	for _, want := range []string{
+		"finding 1 (unique ID 1): /cvss: 11 is above the maximum 10",
+		"finding 2 (unique ID 2): /severity: Severe is not one of",
+		`finding 2 (unique ID 2): /image_digest: "latest" does not match`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q lacks %q", err, want)
+		}
+	}
+}
+
+// TestFindingSchemaProperties checks that the schema describes every
+// member a finding is written with, and only those.
+func TestFindingSchemaProperties(t *testing.T) {
+	var doc struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(FindingSchema, &doc); err != nil {
+		t.Fatal(err)
+	}
+	typ := reflect.TypeOf(vuln.Vulnerability{})
+	var required []string
+	for i := 0; i < typ.NumField(); i++ {
+		name, opts, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if _, ok := doc.Properties[name]; !ok {
+			t.Errorf("schema has no property %q", name)
+		}
+		delete(doc.Properties, name)
+		if opts != "omitempty" {
+			required = append(required, name)
+		}
+	}
+	for name := range doc.Properties {
+		t.Errorf("schema property %q is not a finding field", name)
+	}
+	slices.Sort(required)
+	slices.Sort(doc.Required)
+	if !slices.Equal(required, doc.Required) {
+		t.Errorf("schema requires %v, want %v", doc.Required, required)
+	}
+}
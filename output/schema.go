@@ -0,0 +1,200 @@
+package output
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// FindingSchema is the JSON Schema (draft 2020-12) of one finding as
+// WriteJSON writes it, for consumers to generate types from and validate
+// payloads with.
+//
+//go:embed finding.schema.json
+var FindingSchema []byte
+
+// schema is the part of JSON Schema that FindingSchema uses.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Pattern              string             `json:"pattern"`
+	Format               string             `json:"format"`
+	Defs                 map[string]*schema `json:"$defs"`
+
+	pattern    *regexp.Regexp
+	additional *schema // nil allows any additional property
+	closed     bool    // additionalProperties is false
+}
+
+var findingSchema = mustCompile(FindingSchema)
+
+// mustCompile parses a schema and resolves its references into $defs.
+func mustCompile(b []byte) *schema {
+	root := &schema{}
+	if err := json.Unmarshal(b, root); err != nil {
+		panic(err)
+	}
+	var compile func(s *schema) *schema
+	compile = func(s *schema) *schema {
+		if s == nil {
+			return nil
+		}
+		if name, ok := strings.CutPrefix(s.Ref, "#/$defs/"); ok {
+			def, ok := root.Defs[name]
+			if !ok {
+				panic("output: unresolved schema reference " + s.Ref)
+			}
+			return compile(def)
+		}
+		if s.Pattern != "" && s.pattern == nil {
+			s.pattern = regexp.MustCompile(s.Pattern)
+		}
+		switch strings.TrimSpace(string(s.AdditionalProperties)) {
+		case "", "true":
+		case "false":
+			s.closed = true
+		default:
+			s.additional = &schema{}
+			if err := json.Unmarshal(s.AdditionalProperties, s.additional); err != nil {
+				panic(err)
+			}
+			s.additional = compile(s.additional)
+		}
+		for name, p := range s.Properties {
+			s.Properties[name] = compile(p)
+		}
+		s.Items = compile(s.Items)
+		return s
+	}
+	return compile(root)
+}
+
+// ValidateFindings checks the JSON encoding of each of vs against
+// FindingSchema. The error names every mismatch, as in
+// `finding 3 (unique ID 1a2b): /cvss: 11 is above the maximum 10`.
+func ValidateFindings(vs []vuln.Vulnerability) error {
+	var errs []error
+	for i := range vs {
+		b, err := json.Marshal(&vs[i])
+		if err != nil {
+			return err
+		}
+		var doc any
+		if err := json.Unmarshal(b, &doc); err != nil {
+			return err
+		}
+		var problems []string
+		findingSchema.check("", doc, &problems)
+		for _, p := range problems {
+			errs = append(errs, fmt.Errorf("finding %d (unique ID %s): %s", i+1, vs[i].UniqueID, p))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// check appends to problems how v, at the JSON pointer path, does not
+// match s.
+func (s *schema) check(path string, v any, problems *[]string) {
+	fail := func(format string, args ...any) {
+		at := path
+		if at == "" {
+			at = "/"
+		}
+		*problems = append(*problems, at+": "+fmt.Sprintf(format, args...))
+	}
+	// Enums hold strings only, so comparing with == cannot panic.
+	if s.Enum != nil && !slices.Contains(s.Enum, v) {
+		fail("%v is not one of %v", v, s.Enum)
+		return
+	}
+	switch s.Type {
+	case "":
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("want an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fail("missing required %q", name)
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			p, ok := s.Properties[name]
+			switch {
+			case ok:
+				p.check(path+"/"+name, obj[name], problems)
+			case s.additional != nil:
+				s.additional.check(path+"/"+name, obj[name], problems)
+			case s.closed:
+				fail("unknown property %q", name)
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			fail("want an array")
+			return
+		}
+		if s.Items != nil {
+			for i, e := range arr {
+				s.Items.check(fmt.Sprintf("%s/%d", path, i), e, problems)
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("want a string")
+			return
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			fail("%q does not match %s", str, s.Pattern)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				fail("%q is not an RFC 3339 date-time", str)
+			}
+		}
+	case "number", "integer":
+		n, ok := v.(float64)
+		if !ok {
+			fail("want a number")
+			return
+		}
+		if s.Type == "integer" && n != math.Trunc(n) {
+			fail("%v is not an integer", n)
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("%v is below the minimum %v", n, *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("%v is above the maximum %v", n, *s.Maximum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("want a boolean")
+		}
+	}
+}
@@ -0,0 +1,496 @@
+package output
+
+import (
+	"archive/zip"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// XLSX sheet names. Findings is the first sheet, so a workbook the tool
+// wrote reads back as input.
+const (
+	FindingsSheet = "Findings"
+	SummarySheet  = "Summary"
+)
+
+// cellKind is how an XLSX cell stores a column's text.
+type cellKind int
+
+const (
+	textCell cellKind = iota
+	numberCell
+	dateCell
+	boolCell
+)
+
+// xlsxKinds types the Findings columns that are not text. The rest,
+// including IDs that look like numbers, are text, so Excel keeps them as
+// they are.
+var xlsxKinds = map[string]cellKind{
+	"CVSS":                   numberCell,
+	"Due date":               dateCell,
+	"First detected date":    dateCell,
+	"EPSS":                   numberCell,
+	"EPSS Percentile":        numberCell,
+	"KEV":                    boolCell,
+	"Occurrences":            numberCell,
+	"Instances":              numberCell,
+	"OS End Of Life":         boolCell,
+	"Discovered":             boolCell,
+	"Stale Asset":            boolCell,
+	"Suppressed":             boolCell,
+	"Dependency Depth":       numberCell,
+	"Next Patch Opportunity": dateCell,
+	"Emergency Change":       boolCell,
+	"Confidence":             numberCell,
+	"Effort":                 numberCell,
+	"Days Overdue":           numberCell,
+	"Breaches SLA On":        dateCell,
+	"Temporal CVSS":          numberCell,
+	"Priority Score":         numberCell,
+	"Remediate Within Days":  numberCell,
+	"Score In 30 Days":       numberCell,
+	"Score In 60 Days":       numberCell,
+	"Score In 90 Days":       numberCell,
+}
+
+// Cell styles, as indexes into the cellXfs of xlsxStyles.
+const (
+	styleDefault = iota
+	styleHeader
+	styleDate
+	styleTitle
+)
+
+// severityFills are the fill and font colours of the Findings rows of each
+// severity, as indexes into the dxfs of xlsxStyles.
+var severityFills = []struct {
+	severity    vuln.Severity
+	fill, color string
+}{
+	{vuln.Critical, "FFC7CE", "9C0006"},
+	{vuln.High, "F8CBAD", "843C0C"},
+	{vuln.Medium, "FFEB9C", "9C5700"},
+	{vuln.Low, "DDEBF7", "1F4E78"},
+}
+
+// maxCellText is the most characters an Excel cell holds.
+const maxCellText = 32767
+
+// WriteXLSX writes vs as an XLSX workbook of two sheets. The Findings
+// sheet has the CSV columns, with the profile score and custom field
+// columns named by profiles and custom after them, a frozen header row
+// with filters, and its rows coloured by severity with conditional
+// formatting. The Summary sheet counts the findings by severity and action
+// timeframe, and by asset and severity, as a pivot table of them would.
+// A theme, when t is not nil, sets the font, the header and severity
+// colours, the logo at the top right of the Summary sheet and the date
+// format.
+func WriteXLSX(w io.Writer, vs []vuln.Vulnerability, profiles, custom []string, t *theme.Theme) error {
+	logo := t.LogoImage()
+	zw := zip.NewWriter(w)
+	type part struct {
+		name  string
+		write func(io.Writer) error
+	}
+	parts := []part{
+		{"[Content_Types].xml", writeString(xlsxContentTypes(logo))},
+		{"_rels/.rels", writeString(xlsxRootRels)},
+		{"xl/workbook.xml", func(w io.Writer) error { return writeWorkbook(w, len(Columns)+len(profiles)+len(custom), len(vs)) }},
+		{"xl/_rels/workbook.xml.rels", writeString(xlsxWorkbookRels)},
+		{"xl/styles.xml", writeString(xlsxStyles(t))},
+		{"xl/worksheets/sheet1.xml", func(w io.Writer) error { return writeFindingsSheet(w, vs, profiles, custom) }},
+		{"xl/worksheets/sheet2.xml", func(w io.Writer) error { return writeSummarySheet(w, vs, logo != nil) }},
+	}
+	if logo != nil {
+		media := "logo." + strings.TrimPrefix(logo.Type, "image/")
+		parts = append(parts,
+			part{"xl/worksheets/_rels/sheet2.xml.rels", writeString(xlsxSummaryRels)},
+			part{"xl/drawings/drawing1.xml", writeString(xlsxLogoDrawing(logo))},
+			part{"xl/drawings/_rels/drawing1.xml.rels", writeString(fmt.Sprintf(xlsxDrawingRels, media))},
+			part{"xl/media/" + media, func(w io.Writer) error { _, err := w.Write(logo.Data); return err }},
+		)
+	}
+	for _, p := range parts {
+		pw, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if err := p.write(pw); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeString(s string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// xlsxContentTypes returns the content types of the workbook's parts,
+// with those of the logo's when there is one.
+func xlsxContentTypes(logo *theme.Image) string {
+	var media, drawing string
+	if logo != nil {
+		media = `<Default Extension="` + strings.TrimPrefix(logo.Type, "image/") + `" ContentType="` + logo.Type + `"/>`
+		drawing = `<Override PartName="/xl/drawings/drawing1.xml" ContentType="application/vnd.openxmlformats-officedocument.drawing+xml"/>`
+	}
+	return xmlHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` + media +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		drawing + `</Types>`
+}
+
+const xlsxRootRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbookRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>` +
+	`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// The logo's relationships: the Summary sheet's to its drawing, and the
+// drawing's to the image, whose file name fills in %s.
+const (
+	xlsxSummaryRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/drawing" Target="../drawings/drawing1.xml"/>` +
+		`</Relationships>`
+	xlsxDrawingRels = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="../media/%s"/>` +
+		`</Relationships>`
+)
+
+// Logo placement on the Summary sheet: the column it starts in, right of
+// the tables, and its height, in EMUs of which a pixel is 9525.
+const (
+	logoColumn = 7
+	logoHeight = 48 * 9525
+)
+
+// xlsxLogoDrawing returns the drawing of the logo, at its aspect ratio.
+func xlsxLogoDrawing(logo *theme.Image) string {
+	cy := logoHeight
+	cx := cy
+	if logo.Height > 0 {
+		cx = cy * logo.Width / logo.Height
+	}
+	return fmt.Sprintf(xmlHeader+`<xdr:wsDr xmlns:xdr="http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<xdr:oneCellAnchor><xdr:from><xdr:col>%d</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>0</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:from><xdr:ext cx="%d" cy="%d"/>`+
+		`<xdr:pic><xdr:nvPicPr><xdr:cNvPr id="2" name="Logo"/><xdr:cNvPicPr><a:picLocks noChangeAspect="1"/></xdr:cNvPicPr></xdr:nvPicPr>`+
+		`<xdr:blipFill><a:blip r:embed="rId1"/><a:stretch><a:fillRect/></a:stretch></xdr:blipFill>`+
+		`<xdr:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></xdr:spPr></xdr:pic>`+
+		`<xdr:clientData/></xdr:oneCellAnchor></xdr:wsDr>`, logoColumn, cx, cy, cx, cy)
+}
+
+// xlsxStyles returns the stylesheet: the cell styles named by the style
+// constants, and a conditional format for each of severityFills, in the
+// colours of t when it sets them, with dates as its locale writes them.
+func xlsxStyles(t *theme.Theme) string {
+	var dxfs strings.Builder
+	for _, f := range severityFills {
+		fill, color := f.fill, f.color
+		if c := t.Severity(f.severity, ""); c != "" {
+			fill, color = c[1:], theme.Contrast(c)[1:]
+		}
+		fmt.Fprintf(&dxfs, `<dxf><font><color rgb="FF%s"/></font><fill><patternFill patternType="solid"><bgColor rgb="FF%s"/></patternFill></fill></dxf>`, color, fill)
+	}
+	font, header, headerFont, titleFont := "Calibri", "D9D9D9", "", ""
+	if t != nil {
+		if f := fontFamily(t.Font); f != "" {
+			font = escapeCell(f)
+		}
+		if t.Primary != "" {
+			header = t.Primary[1:]
+			headerFont = `<color rgb="FF` + t.PrimaryText[1:] + `"/>`
+			titleFont = `<color rgb="FF` + t.Primary[1:] + `"/>`
+		}
+	}
+	return xmlHeader + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="1"><numFmt numFmtId="164" formatCode="` + escapeCell(t.Formats().SpreadsheetDate()) + `"/></numFmts>` +
+		`<fonts count="3"><font><sz val="11"/><name val="` + font + `"/></font><font><b/><sz val="11"/>` + headerFont + `<name val="` + font + `"/></font><font><b/><sz val="14"/>` + titleFont + `<name val="` + font + `"/></font></fonts>` +
+		`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+		`<fill><patternFill patternType="solid"><fgColor rgb="FF` + header + `"/></patternFill></fill></fills>` +
+		`<borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="0" fontId="2" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`<dxfs count="` + strconv.Itoa(len(severityFills)) + `">` + dxfs.String() + `</dxfs>` +
+		`</styleSheet>`
+}
+
+// writeWorkbook writes the workbook part for a Findings sheet of cols
+// columns and rows findings, naming its filter range as Excel does.
+func writeWorkbook(w io.Writer, cols, rows int) error {
+	_, err := fmt.Fprintf(w, xmlHeader+`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/><sheet name="%s" sheetId="2" r:id="rId2"/></sheets>`+
+		`<definedNames><definedName name="_xlnm._FilterDatabase" localSheetId="0" hidden="1">%s!$A$1:$%s$%d</definedName></definedNames>`+
+		`</workbook>`, FindingsSheet, SummarySheet, FindingsSheet, columnName(cols-1), rows+1)
+	return err
+}
+
+// sheetWriter writes the rows of a worksheet's sheetData.
+type sheetWriter struct {
+	w   io.Writer
+	row int
+	err error
+}
+
+func (s *sheetWriter) printf(format string, args ...any) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, args...)
+	}
+}
+
+// cell is one worksheet cell: text of kind, in style.
+type cell struct {
+	text  string
+	kind  cellKind
+	style int
+}
+
+// writeRow writes cells as the next row; blank cells are left out.
+func (s *sheetWriter) writeRow(cells ...cell) {
+	s.row++
+	s.printf(`<row r="%d">`, s.row)
+	for i, c := range cells {
+		if c.text == "" {
+			continue
+		}
+		ref := columnName(i) + strconv.Itoa(s.row)
+		style := ""
+		if c.style != styleDefault {
+			style = ` s="` + strconv.Itoa(c.style) + `"`
+		}
+		switch c.kind {
+		case numberCell:
+			if _, err := strconv.ParseFloat(c.text, 64); err == nil {
+				s.printf(`<c r="%s"%s><v>%s</v></c>`, ref, style, c.text)
+				continue
+			}
+		case dateCell:
+			if t, err := time.Parse(time.DateOnly, c.text); err == nil {
+				s.printf(`<c r="%s" s="%d"><v>%d</v></c>`, ref, styleDate, serialDay(t))
+				continue
+			}
+		case boolCell:
+			if b, err := strconv.ParseBool(c.text); err == nil {
+				v := 0
+				if b {
+					v = 1
+				}
+				s.printf(`<c r="%s"%s t="b"><v>%d</v></c>`, ref, style, v)
+				continue
+			}
+		}
+		s.printf(`<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escapeCell(c.text))
+	}
+	s.printf(`</row>`)
+}
+
+// serialDay returns the Excel serial number of the day of t, counting from
+// 1899-12-30 as Excel does in the 1900 date system.
+func serialDay(t time.Time) int {
+	return int(t.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+// escapeCell escapes s for a cell's XML text, replacing characters XML
+// cannot hold, and truncates it to what a cell holds.
+func escapeCell(s string) string {
+	if utf8.RuneCountInString(s) > maxCellText {
+		s = string([]rune(s)[:maxCellText])
+	}
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// fontFamily returns the first family of a list of font families, as in
+// "Open Sans" of "'Open Sans', sans-serif", since a workbook names one.
+func fontFamily(list string) string {
+	first, _, _ := strings.Cut(list, ",")
+	return strings.Trim(strings.TrimSpace(first), `'"`)
+}
+
+// columnName returns the letters of the 0-based column i, as in "A" or
+// "AB".
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func writeFindingsSheet(w io.Writer, vs []vuln.Vulnerability, profiles, custom []string) error {
+	ncols := len(Columns) + len(profiles) + len(custom)
+	last := columnName(ncols - 1)
+	s := &sheetWriter{w: w}
+	s.printf(xmlHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	s.printf(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	s.printf(`<sheetData>`)
+	row := make([]cell, ncols)
+	severityCol := 0
+	for i, c := range Columns {
+		row[i] = cell{text: c.Header, style: styleHeader}
+		if c.Header == "Severity" {
+			severityCol = i
+		}
+	}
+	for i, name := range profiles {
+		row[len(Columns)+i] = cell{text: ProfileHeader(name), style: styleHeader}
+	}
+	for i, name := range custom {
+		row[len(Columns)+len(profiles)+i] = cell{text: name, style: styleHeader}
+	}
+	s.writeRow(row...)
+	for i := range vs {
+		v := &vs[i]
+		for j, c := range Columns {
+			row[j] = cell{text: c.Value(v), kind: xlsxKinds[c.Header]}
+		}
+		n := len(Columns)
+		for _, name := range profiles {
+			row[n] = cell{kind: numberCell}
+			if score, ok := v.ProfileScores[name]; ok {
+				row[n].text = formatFloat(score)
+			}
+			n++
+		}
+		for _, name := range custom {
+			row[n] = cell{text: v.CustomFields[name]}
+			n++
+		}
+		s.writeRow(row...)
+	}
+	s.printf(`</sheetData>`)
+	s.printf(`<autoFilter ref="A1:%s%d"/>`, last, len(vs)+1)
+	s.printf(`<conditionalFormatting sqref="A2:%s%d">`, last, max(len(vs), 1)+1)
+	for i, f := range severityFills {
+		formula := fmt.Sprintf(`$%s2="%s"`, columnName(severityCol), f.severity)
+		s.printf(`<cfRule type="expression" dxfId="%d" priority="%d"><formula>%s</formula></cfRule>`, i, i+1, escapeCell(formula))
+	}
+	s.printf(`</conditionalFormatting></worksheet>`)
+	return s.err
+}
+
+// pivot counts findings by a row key and a column key.
+type pivot struct {
+	rows, cols []string
+	counts     map[[2]string]int
+}
+
+func newPivot(cols []string) *pivot {
+	return &pivot{cols: cols, counts: make(map[[2]string]int)}
+}
+
+func (p *pivot) add(row, col string) {
+	if !slices.Contains(p.rows, row) {
+		p.rows = append(p.rows, row)
+	}
+	if !slices.Contains(p.cols, col) {
+		p.cols = append(p.cols, col)
+	}
+	p.counts[[2]string{row, col}]++
+}
+
+// write writes p as a table titled title, headed by corner over its row
+// keys, with a Total column and row.
+func (p *pivot) write(s *sheetWriter, title, corner string) {
+	s.writeRow(cell{text: title, style: styleTitle})
+	header := []cell{{text: corner, style: styleHeader}}
+	for _, c := range p.cols {
+		header = append(header, cell{text: c, style: styleHeader})
+	}
+	s.writeRow(append(header, cell{text: "Total", style: styleHeader})...)
+	colTotals := make([]int, len(p.cols)+1)
+	for _, r := range p.rows {
+		cells := []cell{{text: r}}
+		total := 0
+		for i, c := range p.cols {
+			n := p.counts[[2]string{r, c}]
+			cells = append(cells, cell{text: strconv.Itoa(n), kind: numberCell})
+			total += n
+			colTotals[i] += n
+		}
+		colTotals[len(p.cols)] += total
+		s.writeRow(append(cells, cell{text: strconv.Itoa(total), kind: numberCell})...)
+	}
+	cells := []cell{{text: "Total", style: styleHeader}}
+	for _, n := range colTotals {
+		cells = append(cells, cell{text: strconv.Itoa(n), kind: numberCell, style: styleHeader})
+	}
+	s.writeRow(cells...)
+}
+
+// writeSummarySheet writes the Summary sheet: a table of the findings of vs
+// by severity, most severe first, and action timeframe, most urgent first,
+// then one of the findings on each asset by severity, assets with the most
+// findings first.
+func writeSummarySheet(w io.Writer, vs []vuln.Vulnerability, logo bool) error {
+	var severities []string
+	for _, sev := range vuln.Severities {
+		severities = append(severities, string(sev))
+	}
+	byTimeframe := newPivot(nil)
+	byTimeframe.rows = slices.Clone(severities)
+	byAsset := newPivot(slices.Clone(severities))
+	within := make(map[string]int) // days to remediate by timeframe
+	for i := range vs {
+		v := &vs[i]
+		tf := cmp.Or(v.ActionTimeframe, "None")
+		if d, ok := within[tf]; !ok || v.RemediateWithinDays < d {
+			within[tf] = v.RemediateWithinDays
+		}
+		byTimeframe.add(string(v.Severity), tf)
+		byAsset.add(cmp.Or(v.AssetName, v.AssetID), string(v.Severity))
+	}
+	slices.SortStableFunc(byTimeframe.cols, func(a, b string) int {
+		return cmp.Or(cmp.Compare(within[a], within[b]), cmp.Compare(a, b))
+	})
+	assetTotal := func(a string) int {
+		n := 0
+		for _, c := range byAsset.cols {
+			n += byAsset.counts[[2]string{a, c}]
+		}
+		return n
+	}
+	slices.SortStableFunc(byAsset.rows, func(a, b string) int {
+		return cmp.Or(cmp.Compare(assetTotal(b), assetTotal(a)), cmp.Compare(a, b))
+	})
+
+	s := &sheetWriter{w: w}
+	s.printf(xmlHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	s.printf(`<cols><col min="1" max="1" width="40" customWidth="1"/></cols><sheetData>`)
+	byTimeframe.write(s, "Findings by severity and action timeframe", "Severity")
+	s.writeRow()
+	byAsset.write(s, "Findings by asset and severity", "Asset")
+	s.printf(`</sheetData>`)
+	if logo {
+		s.printf(`<drawing r:id="rId1"/>`)
+	}
+	s.printf(`</worksheet>`)
+	return s.err
+}
@@ -0,0 +1,181 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestXLSXRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, sample, ProfileColumns(sample), CustomColumns(sample), nil); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ingest.ReadXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Skipped) != 0 {
+		t.Fatalf("skipped %v", res.Skipped)
+	}
+	if !reflect.DeepEqual(res.Vulns, sample) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", res.Vulns, sample)
+	}
+
+	findings := xlsxPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	for _, want := range []string{
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`,
+		`<autoFilter ref="A1:` + columnName(len(Columns)+3) + `3"/>`,
+		`<cfRule type="expression" dxfId="0" priority="1"><formula>$N2=&#34;Critical&#34;</formula></cfRule>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">1</t></is></c>`,
+		`<c r="N2" t="inlineStr"><is><t xml:space="preserve">High</t></is></c><c r="O2" s="2"><v>45449</v></c>`,
+	} {
+		if !strings.Contains(findings, want) {
+			t.Errorf("Findings sheet lacks %s", want)
+		}
+	}
+}
+
+func TestXLSXSummary(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{AssetName: "web", Severity: vuln.Critical, ActionTimeframe: "Immediate", RemediateWithinDays: 2},
+		{AssetName: "web", Severity: vuln.High, ActionTimeframe: "Immediate", RemediateWithinDays: 2},
+		{AssetName: "db", Severity: vuln.High, ActionTimeframe: "Planned", RemediateWithinDays: 90},
+		{AssetID: "i-0abc", Severity: vuln.Low, ActionTimeframe: "Planned", RemediateWithinDays: 90},
+		{AssetName: "web", Severity: vuln.Low, ActionTimeframe: "Scheduled", RemediateWithinDays: 30},
+	}
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, vs, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				V  string `xml:"v"`
+				Is string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(xlsxPart(t, buf.Bytes(), "xl/worksheets/sheet2.xml")), &sheet); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range sheet.Rows {
+		var cells []string
+		for _, c := range r.Cells {
+			cells = append(cells, c.V+c.Is)
+		}
+		got = append(got, strings.Join(cells, ","))
+	}
+	want := []string{
+		"Findings by severity and action timeframe",
+		"Severity,Immediate,Scheduled,Planned,Total",
+		"Critical,1,0,0,1",
+		"High,1,0,1,2",
+		"Medium,0,0,0,0",
+		"Low,0,1,1,2",
+		"Total,2,1,2,5",
+		"",
+		"Findings by asset and severity",
+		"Asset,Critical,High,Medium,Low,Total",
+		"web,1,1,0,1,3",
+		"db,0,1,0,0,1",
+		"i-0abc,0,0,0,1,1",
+		"Total,1,2,0,2,5",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summary sheet:\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestXLSXTheme(t *testing.T) {
+	dir := t.TempDir()
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewNRGBA(image.Rect(0, 0, 90, 30))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logo.png"), logo.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "theme.yaml")
+	if err := os.WriteFile(path, []byte("font: \"'Open Sans', sans-serif\"\nprimary: \"#1F3864\"\nlogo: logo.png\nseverities: {Critical: \"#7B0000\"}\nlocale: de-DE\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	th, err := theme.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, sample, nil, nil, th); err != nil {
+		t.Fatal(err)
+	}
+	// The logo does not get in the way of reading the workbook back.
+	if _, err := ingest.ReadXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()), ""); err != nil {
+		t.Fatal(err)
+	}
+	styles := xlsxPart(t, buf.Bytes(), "xl/styles.xml")
+	for _, want := range []string{
+		`<name val="Open Sans"/>`,
+		`<font><b/><sz val="11"/><color rgb="FFFFFFFF"/><name val="Open Sans"/></font>`,
+		`<fgColor rgb="FF1F3864"/>`,
+		// The theme's Critical, with white text; High keeps the default.
+		`<dxf><font><color rgb="FFFFFFFF"/></font><fill><patternFill patternType="solid"><bgColor rgb="FF7B0000"/></patternFill></fill></dxf>`,
+		`<bgColor rgb="FFF8CBAD"/>`,
+		`<numFmt numFmtId="164" formatCode="dd.mm.yyyy"/>`,
+	} {
+		if !strings.Contains(styles, want) {
+			t.Errorf("styles lack %s", want)
+		}
+	}
+	if summary := xlsxPart(t, buf.Bytes(), "xl/worksheets/sheet2.xml"); !strings.HasSuffix(summary, `</sheetData><drawing r:id="rId1"/></worksheet>`) {
+		t.Errorf("Summary sheet has no drawing")
+	}
+	if drawing := xlsxPart(t, buf.Bytes(), "xl/drawings/drawing1.xml"); !strings.Contains(drawing, `<xdr:ext cx="1371600" cy="457200"/>`) {
+		t.Errorf("logo is not 144x48 pixels:\n%s", drawing)
+	}
+	if types := xlsxPart(t, buf.Bytes(), "[Content_Types].xml"); !strings.Contains(types, `<Default Extension="png" ContentType="image/png"/>`) {
+		t.Errorf("content types lack PNG")
+	}
+	if got := xlsxPart(t, buf.Bytes(), "xl/media/logo.png"); got != logo.String() {
+		t.Error("logo is not embedded as it is")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %q, want %q", i, got, want)
+		}
+	}
+}
+
+// xlsxPart returns the named part of the workbook b.
+func xlsxPart(t *testing.T, b []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	part, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(part)
+}
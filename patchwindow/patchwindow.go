@@ -0,0 +1,221 @@
+// Package patchwindow schedules remediation around each asset's patch
+// window, flagging findings that are due before the next window opens.
+package patchwindow
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Default is the mapping key whose window applies to assets with no entry
+// of their own.
+const Default = "*"
+
+type kind int
+
+const (
+	daily kind = iota
+	weekly
+	nthWeekday // the nth (or last) weekday of each month
+	dayOfMonth // a fixed day of each month
+)
+
+// last is the ordinal of "last Friday".
+const last = -1
+
+// Window is a recurring patch window, with day granularity.
+type Window struct {
+	kind    kind
+	weekday time.Weekday
+	n       int // ordinal for nthWeekday, or the day for dayOfMonth
+	spec    string
+}
+
+var (
+	weekdays = map[string]time.Weekday{
+		"sunday": time.Sunday, "sun": time.Sunday,
+		"monday": time.Monday, "mon": time.Monday,
+		"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+		"wednesday": time.Wednesday, "wed": time.Wednesday,
+		"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+		"friday": time.Friday, "fri": time.Friday,
+		"saturday": time.Saturday, "sat": time.Saturday,
+	}
+	ordinals = map[string]int{"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "last": last}
+	// filler words carry no meaning in a spec.
+	filler = map[string]bool{"every": true, "each": true, "of": true, "the": true, "on": true}
+	// numericOrdinal matches "3rd", "15th" and plain "15".
+	numericOrdinal = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+	// timeOfDay matches a time such as "02:00", which is accepted and
+	// ignored: windows are scheduled by day.
+	timeOfDay = regexp.MustCompile(`^\d{1,2}:\d{2}$`)
+)
+
+// Parse parses a patch window spec such as "daily", "every Sunday",
+// "Saturday weekly", "3rd Saturday monthly", "last Friday of the month" or
+// "15th monthly". Matching is case-insensitive; a time of day is ignored.
+func Parse(spec string) (Window, error) {
+	w := Window{spec: strings.TrimSpace(spec)}
+	var (
+		day, ordinal          int
+		hasDay, hasOrdinal    bool
+		isDaily, isWeekly     bool
+		isMonthly, hasWeekday bool
+	)
+	for _, tok := range strings.Fields(strings.ToLower(strings.NewReplacer(",", " ", "-", " ").Replace(spec))) {
+		switch {
+		case filler[tok] || timeOfDay.MatchString(tok):
+		case tok == "daily" || tok == "day":
+			isDaily = true
+		case tok == "weekly" || tok == "week":
+			isWeekly = true
+		case tok == "monthly" || tok == "month":
+			isMonthly = true
+		case ordinals[tok] != 0:
+			ordinal, hasOrdinal = ordinals[tok], true
+		case numericOrdinal.MatchString(tok):
+			day, _ = strconv.Atoi(numericOrdinal.FindStringSubmatch(tok)[1])
+			hasDay = true
+		default:
+			wd, ok := weekdays[strings.TrimSuffix(tok, "s")]
+			if !ok {
+				wd, ok = weekdays[tok]
+			}
+			if !ok || hasWeekday {
+				return Window{}, fmt.Errorf("patch window %q: unexpected %q", spec, tok)
+			}
+			w.weekday, hasWeekday = wd, true
+		}
+	}
+	if hasDay && hasOrdinal {
+		return Window{}, fmt.Errorf("patch window %q: two ordinals", spec)
+	}
+	if hasDay && hasWeekday {
+		// "3rd Saturday": a small number before a weekday is an ordinal.
+		if day < 1 || day > 5 {
+			return Window{}, fmt.Errorf("patch window %q: no %dth weekday in a month", spec, day)
+		}
+		ordinal, hasOrdinal, hasDay = day, true, false
+	}
+	switch {
+	case isDaily && !hasWeekday && !hasDay && !hasOrdinal && !isWeekly && !isMonthly:
+		w.kind = daily
+	case hasWeekday && !hasOrdinal && !isMonthly:
+		w.kind = weekly
+	case hasWeekday && hasOrdinal && !isWeekly:
+		w.kind, w.n = nthWeekday, ordinal
+	case hasDay && !hasWeekday && !isWeekly:
+		if day < 1 || day > 31 {
+			return Window{}, fmt.Errorf("patch window %q: no day %d in a month", spec, day)
+		}
+		w.kind, w.n = dayOfMonth, day
+	default:
+		return Window{}, fmt.Errorf("patch window %q: want daily, a weekday, or an nth weekday or day of the month", spec)
+	}
+	return w, nil
+}
+
+// String returns the spec w was parsed from.
+func (w Window) String() string { return w.spec }
+
+// Next returns the first day of the window on or after the day of from.
+func (w Window) Next(from time.Time) vuln.Date {
+	d := vuln.NewDate(from.Year(), from.Month(), from.Day())
+	switch w.kind {
+	case weekly:
+		return vuln.Date{Time: d.AddDate(0, 0, (int(w.weekday)-int(d.Weekday())+7)%7)}
+	case nthWeekday, dayOfMonth:
+		// A fifth weekday, or a 31st, is missing from some months but
+		// occurs at least once in any 12.
+		for i := 0; i <= 12; i++ {
+			first := vuln.NewDate(d.Year(), d.Month()+time.Month(i), 1)
+			if day, ok := w.inMonth(first); ok && !day.Before(d.Time) {
+				return day
+			}
+		}
+	}
+	return d
+}
+
+// inMonth returns the window's day in the month starting on first, if the
+// month has one.
+func (w Window) inMonth(first vuln.Date) (vuln.Date, bool) {
+	var day vuln.Date
+	switch {
+	case w.kind == dayOfMonth:
+		day = vuln.Date{Time: first.AddDate(0, 0, w.n-1)}
+	case w.n == last:
+		next := first.AddDate(0, 1, 0)
+		day = vuln.Date{Time: next.AddDate(0, 0, -1-(int(next.Weekday())-int(w.weekday)+6)%7)}
+	default:
+		offset := (int(w.weekday) - int(first.Weekday()) + 7) % 7
+		day = vuln.Date{Time: first.AddDate(0, 0, offset+7*(w.n-1))}
+	}
+	return day, day.Month() == first.Month()
+}
+
+// Windows maps assets, by asset ID or name, to their patch window.
+type Windows struct {
+	Mapping map[string]Window
+}
+
+// Load reads a JSON object mapping asset IDs or names, or Default, to
+// patch window specs.
+func Load(path string) (*Windows, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var specs map[string]string
+	if err := json.Unmarshal(b, &specs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	ws := &Windows{Mapping: make(map[string]Window, len(specs))}
+	for asset, spec := range specs {
+		if ws.Mapping[asset], err = Parse(spec); err != nil {
+			return nil, fmt.Errorf("%s: %q: %w", path, asset, err)
+		}
+	}
+	return ws, nil
+}
+
+// For returns the window of the asset of v: the entry for its asset ID,
+// else its asset name, else Default.
+func (ws *Windows) For(v *vuln.Vulnerability) (Window, bool) {
+	for _, k := range []string{v.AssetID, v.AssetName, Default} {
+		if k == "" {
+			continue
+		}
+		if w, ok := ws.Mapping[k]; ok {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// Apply sets the next patch opportunity at or after now of every finding
+// whose asset has a window, and flags it for emergency change when it is
+// due before that window. It returns the number of findings flagged.
+func (ws *Windows) Apply(vs []vuln.Vulnerability, now time.Time) int {
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		w, ok := ws.For(v)
+		if !ok {
+			continue
+		}
+		v.NextPatchOpportunity = w.Next(now)
+		v.EmergencyChange = !v.DueDate.IsZero() && v.DueDate.Before(v.NextPatchOpportunity.Time)
+		if v.EmergencyChange {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package patchwindow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestNext(t *testing.T) {
+	from := time.Date(2025, 2, 5, 15, 4, 0, 0, time.UTC) // a Wednesday
+	tests := []struct {
+		spec, want string
+	}{
+		{"daily", "2025-02-05"},
+		{"every Sunday", "2025-02-09"},
+		{"Sun 02:00", "2025-02-09"},
+		{"Wednesday weekly", "2025-02-05"},
+		{"Saturdays", "2025-02-08"},
+		{"3rd Saturday monthly", "2025-02-15"},
+		{"2nd Tuesday", "2025-02-11"},
+		{"first Monday of the month", "2025-03-03"}, // February's has passed
+		{"last Friday of the month", "2025-02-28"},
+		{"5th Saturday monthly", "2025-03-29"}, // February has four
+		{"31st monthly", "2025-03-31"},
+		{"4th", "2025-03-04"},
+	}
+	for _, tt := range tests {
+		w, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := w.Next(from).String(); got != tt.want {
+			t.Errorf("%q: Next = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "sometimes", "6th Saturday", "32nd monthly", "Saturday Sunday", "3rd Saturday weekly"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q): want error", spec)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	ws, err := Load("testdata/windows.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := []vuln.Vulnerability{
+		{AssetID: "i-0a1b2c3d4e5f60718", AssetName: "apply-frontend", DueDate: vuln.NewDate(2025, 2, 7)},
+		{AssetName: "apply-frontend", DueDate: vuln.NewDate(2025, 2, 20)},
+		{AssetName: "other", DueDate: vuln.NewDate(2025, 2, 27)},
+		{AssetName: "other"},
+	}
+	n := ws.Apply(vs, time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC))
+	want := []struct {
+		next      string
+		emergency bool
+	}{
+		{"2025-02-09", true}, // the asset ID wins over the name
+		{"2025-02-15", false},
+		{"2025-02-28", true}, // the default window
+		{"2025-02-28", false},
+	}
+	for i, w := range want {
+		if got := vs[i]; got.NextPatchOpportunity.String() != w.next || got.EmergencyChange != w.emergency {
+			t.Errorf("finding %d: next %s, emergency %v; want %s, %v", i, got.NextPatchOpportunity, got.EmergencyChange, w.next, w.emergency)
+		}
+	}
+	if n != 2 {
+		t.Errorf("flagged %d, want 2", n)
+	}
+}
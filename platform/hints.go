@@ -0,0 +1,151 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"text/template"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// DefaultTemplates are the remediation templates of each package manager,
+// as text/template sources executed on a Hint.
+var DefaultTemplates = map[string]string{
+	Apt:           `Update {{.Package}}{{with .Fixed}} to {{.}}{{end}}: apt-get update && apt-get install --only-upgrade {{.Package}}{{with .Arch}}:{{.}}{{end}}{{with .Fixed}}={{.}}{{end}}`,
+	Yum:           `Update {{.Package}}{{with .Fixed}} to {{.}}{{end}}: yum update {{.Package}}{{with .Fixed}}-{{.}}{{end}}{{with .Arch}}.{{.}}{{end}}`,
+	Dnf:           `Update {{.Package}}{{with .Fixed}} to {{.}}{{end}}: dnf upgrade {{.Package}}{{with .Fixed}}-{{.}}{{end}}{{with .Arch}}.{{.}}{{end}}`,
+	Zypper:        `Update {{.Package}}{{with .Fixed}} to {{.}}{{end}}: zypper update {{.Package}}`,
+	Apk:           `Update {{.Package}}{{with .Fixed}} to {{.}}{{end}}: apk add --upgrade {{.Package}}{{with .Fixed}}={{.}}{{end}}`,
+	Choco:         `Update {{.Package}}{{with .Fixed}} to {{.}}{{end}}: choco upgrade {{.Package}} -y{{with .Fixed}} --version {{.}}{{end}}`,
+	WindowsUpdate: `Install {{.KB}} from Windows Update or the Microsoft Update Catalog.`,
+}
+
+// Hint is what a remediation template is executed on.
+type Hint struct {
+	// Package is the package name without an ecosystem prefix or
+	// architecture qualifier.
+	Package   string
+	Installed string
+	// Fixed is the fixed version, or "" when only the latest update is
+	// known to fix the finding.
+	Fixed string
+	// KB is the KB article of a Windows update.
+	KB string
+	// OS is the release, as in "Debian 12", and Arch the architecture in
+	// the package manager's naming: "amd64" for apt, "x86_64" for yum.
+	OS, Arch string
+}
+
+// Templates are the parsed remediation templates, by package manager.
+type Templates map[string]*template.Template
+
+// ParseTemplates parses the template sources srcs, by package manager,
+// over DefaultTemplates.
+func ParseTemplates(srcs map[string]string) (Templates, error) {
+	all := maps.Clone(DefaultTemplates)
+	for m, src := range srcs {
+		if _, ok := DefaultTemplates[m]; !ok {
+			return nil, fmt.Errorf("unknown package manager %q (want %s)", m, strings.Join(Managers, ", "))
+		}
+		all[m] = src
+	}
+	t := make(Templates, len(all))
+	for m, src := range all {
+		tmpl, err := template.New(m).Parse(src)
+		if err != nil {
+			return nil, err
+		}
+		// A field Hint lacks fails on any finding, so fail now.
+		if err := tmpl.Execute(io.Discard, Hint{}); err != nil {
+			return nil, err
+		}
+		t[m] = tmpl
+	}
+	return t, nil
+}
+
+// LoadTemplates reads template sources by package manager from path, JSON
+// or YAML as config.Load reads configuration files, as in
+//
+//	apt: "sudo apt-get install --only-upgrade {{.Package}}"
+//
+// Package managers the file leaves out keep their DefaultTemplates.
+func LoadTemplates(path string) (Templates, error) {
+	b, err := config.ReadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	var srcs map[string]string
+	if err := json.Unmarshal(b, &srcs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	t, err := ParseTemplates(srcs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// hint returns what manager's template is executed on for v.
+func hint(v *vuln.Vulnerability, manager string) Hint {
+	h := Hint{
+		Package:   v.EcosystemPackage(),
+		Installed: strings.TrimSpace(v.InstalledVersion),
+		Fixed:     strings.TrimSpace(v.FixedVersion),
+		KB:        v.KB(),
+		OS:        v.OS,
+		Arch:      v.Arch,
+	}
+	h.Package, _, _ = strings.Cut(h.Package, ":")
+	if manager == Apt {
+		h.Arch = debianArch[v.Arch]
+	}
+	return h
+}
+
+// Remediation returns the remediation instruction for v on its platform,
+// or "" when v has no Manager. Findings without a fixed version that are
+// not fixable, or are on an end-of-life OS, get none: no update will fix
+// them.
+func (t Templates) Remediation(v *vuln.Vulnerability) (string, error) {
+	m := Manager(v)
+	if m == "" || t[m] == nil {
+		return "", nil
+	}
+	if !v.HasFix() && (v.OSEndOfLife || v.Fixability != "" && !strings.EqualFold(strings.TrimSpace(v.Fixability), "fixable")) {
+		return "", nil
+	}
+	var b bytes.Buffer
+	if err := t[m].Execute(&b, hint(v, m)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Apply sets the Remediation of each finding in vs to its platform's,
+// and returns how many it set. Only remediations the input left blank, or
+// a scanner's generic vuln.Vulnerability.UpgradeRemediation, are
+// replaced.
+func (t Templates) Apply(vs []vuln.Vulnerability) (int, error) {
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		if r := strings.TrimSpace(v.Remediation); r != "" && r != v.UpgradeRemediation() {
+			continue
+		}
+		r, err := t.Remediation(v)
+		if err != nil {
+			return n, err
+		}
+		if r != "" {
+			v.Remediation = r
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,299 @@
+// Package platform normalises the operating system family, version and
+// architecture of the assets findings are on, and turns them into
+// remediation instructions for the asset's own package manager: an apt
+// command on Debian, yum or dnf on Amazon Linux and Red Hat, choco on
+// Windows.
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// OS families, named by their os-release ID.
+const (
+	Debian   = "debian"
+	Ubuntu   = "ubuntu"
+	Amazon   = "amzn"
+	RHEL     = "rhel"
+	CentOS   = "centos"
+	Rocky    = "rocky"
+	Alma     = "almalinux"
+	Oracle   = "ol"
+	Fedora   = "fedora"
+	SLES     = "sles"
+	OpenSUSE = "opensuse"
+	Alpine   = "alpine"
+	Windows  = "windows"
+)
+
+// families are the known families and their display names, as in the OS
+// names eol.Lookup reads.
+var families = []struct{ id, name string }{
+	{Debian, "Debian"},
+	{Ubuntu, "Ubuntu"},
+	{Amazon, "Amazon Linux"},
+	{RHEL, "Red Hat Enterprise Linux"},
+	{CentOS, "CentOS"},
+	{Rocky, "Rocky Linux"},
+	{Alma, "AlmaLinux"},
+	{Oracle, "Oracle Linux"},
+	{Fedora, "Fedora"},
+	{SLES, "SUSE Linux Enterprise Server"},
+	{OpenSUSE, "openSUSE"},
+	{Alpine, "Alpine Linux"},
+	{Windows, "Windows"},
+}
+
+// familyAliases are the other names ParseFamily accepts, normalised as
+// normalize leaves them.
+var familyAliases = map[string]string{
+	"amazon":        Amazon,
+	"redhat":        RHEL,
+	"oracle":        Oracle,
+	"oraclelinux":   Oracle,
+	"alma":          Alma,
+	"suse":          SLES,
+	"opensuseleap":  OpenSUSE,
+	"alpine":        Alpine,
+	"windowsserver": Windows,
+	"win":           Windows,
+}
+
+// normalize lower-cases s and drops spaces, hyphens and underscores.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(s)))
+}
+
+// ParseFamily returns the family s names: its ID or display name, in any
+// case, or a common other name such as "Amazon" or "Red Hat".
+func ParseFamily(s string) (string, error) {
+	n := normalize(s)
+	for _, f := range families {
+		if n == f.id || n == normalize(f.name) {
+			return f.id, nil
+		}
+	}
+	if id, ok := familyAliases[n]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("unknown OS family %q", s)
+}
+
+// Split returns the family and version of an OS release name such as
+// "Debian 10", "Amazon Linux 2" or "Windows Server 2019", or ok false
+// when it names no known family.
+func Split(os string) (family, version string, ok bool) {
+	fields := strings.Fields(os)
+	for k := len(fields); k > 0; k-- {
+		if f, err := ParseFamily(strings.Join(fields[:k], " ")); err == nil {
+			return f, strings.Join(fields[k:], " "), true
+		}
+	}
+	return "", "", false
+}
+
+// Name returns the release name of family's version, as in "Debian 10",
+// in the form eol.Lookup reads: a Debian point release such as "10.13"
+// is named by its major version.
+func Name(family, version string) string {
+	name := family
+	for _, f := range families {
+		if f.id == family {
+			name = f.name
+		}
+	}
+	if family == Debian {
+		version, _, _ = strings.Cut(version, ".")
+	}
+	if version == "" {
+		return name
+	}
+	return name + " " + version
+}
+
+// Architectures, by their kernel names.
+const (
+	X86_64  = "x86_64"
+	AArch64 = "aarch64"
+	I686    = "i686"
+	ARMv7   = "armv7"
+	PPC64LE = "ppc64le"
+	S390X   = "s390x"
+)
+
+// archAliases maps the names distributions and container images give
+// architectures, normalised, to the kernel names.
+var archAliases = map[string]string{
+	"x8664": X86_64, "amd64": X86_64, "x64": X86_64,
+	"aarch64": AArch64, "arm64": AArch64,
+	"i686": I686, "i586": I686, "i386": I686, "386": I686, "x86": I686,
+	"armv7": ARMv7, "armv7l": ARMv7, "armhf": ARMv7, "arm": ARMv7,
+	"ppc64le": PPC64LE, "ppc64el": PPC64LE,
+	"s390x": S390X,
+}
+
+// ParseArch returns the architecture s names, as in "x86_64" for "amd64".
+func ParseArch(s string) (string, error) {
+	if a, ok := archAliases[normalize(s)]; ok {
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown architecture %q", s)
+}
+
+// debianArch are the Debian names of the architectures, which apt uses.
+var debianArch = map[string]string{X86_64: "amd64", AArch64: "arm64", I686: "i386", ARMv7: "armhf", PPC64LE: "ppc64el", S390X: "s390x"}
+
+// DetectArch returns the architecture v's package is built for, or ""
+// when it does not tell: the ":amd64" of a Debian package name, or the
+// ".x86_64" at the end of an RPM package or version.
+func DetectArch(v *vuln.Vulnerability) string {
+	if _, arch, ok := strings.Cut(v.PackageName, ":"); ok {
+		if a, err := ParseArch(arch); err == nil {
+			return a
+		}
+	}
+	for _, s := range []string{v.InstalledVersion, v.PackageName} {
+		if i := strings.LastIndexByte(s, '.'); i >= 0 {
+			switch suffix := s[i+1:]; suffix {
+			case X86_64, AArch64, I686, PPC64LE, S390X:
+				return suffix
+			}
+		}
+	}
+	return ""
+}
+
+// Apply fills in the platform of the findings in vs and returns how many
+// it changed. OSFamily and OSVersion come from the OS, as the input gives
+// it or eol.Apply detects it, and the OS from them when the input gives
+// only those. Windows updates are on Windows. Arch, when the input does
+// not give it, is the architecture most packages on the asset are built
+// for.
+func Apply(vs []vuln.Vulnerability) int {
+	arches := make(map[string]map[string]int)
+	for i := range vs {
+		if a := DetectArch(&vs[i]); a != "" {
+			id := vs[i].AssetID
+			if arches[id] == nil {
+				arches[id] = make(map[string]int)
+			}
+			arches[id][a]++
+		}
+	}
+
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		before := [4]string{v.OS, v.OSFamily, v.OSVersion, v.Arch}
+		if v.OS == "" && v.OSFamily != "" {
+			v.OS = Name(v.OSFamily, v.OSVersion)
+		}
+		if family, version, ok := Split(v.OS); ok && v.OSFamily == "" {
+			v.OSFamily, v.OSVersion = family, version
+		}
+		if v.OSFamily == "" && v.Ecosystem() == vuln.EcosystemWindows {
+			v.OSFamily = Windows
+		}
+		if v.Arch == "" {
+			v.Arch = mostVoted(arches[v.AssetID])
+		}
+		if [4]string{v.OS, v.OSFamily, v.OSVersion, v.Arch} != before {
+			n++
+		}
+	}
+	return n
+}
+
+// mostVoted returns the key with the most votes, the first in order among
+// ties, or "" for none.
+func mostVoted(votes map[string]int) string {
+	keys := make([]string, 0, len(votes))
+	for k := range votes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	best := ""
+	for _, k := range keys {
+		if votes[k] > votes[best] {
+			best = k
+		}
+	}
+	return best
+}
+
+// Package managers Manager returns. WindowsUpdate installs the KB updates
+// of Windows findings.
+const (
+	Apt           = "apt"
+	Yum           = "yum"
+	Dnf           = "dnf"
+	Zypper        = "zypper"
+	Apk           = "apk"
+	Choco         = "choco"
+	WindowsUpdate = "windows_update"
+)
+
+// Managers lists the package managers.
+var Managers = []string{Apt, Yum, Dnf, Zypper, Apk, Choco, WindowsUpdate}
+
+// Manager returns the package manager that updates v's package on its
+// platform, or "" when v is not an OS package or its family is unknown.
+// Amazon Linux 2023 and Red Hat 8 and later releases use dnf, earlier
+// ones yum, which later releases keep as an alias when the version is
+// unknown.
+func Manager(v *vuln.Vulnerability) string {
+	switch v.Ecosystem() {
+	case vuln.EcosystemWindows:
+		return WindowsUpdate
+	case vuln.EcosystemOSPkg:
+	default:
+		return ""
+	}
+	major := majorVersion(v.OSVersion)
+	switch v.OSFamily {
+	case Debian, Ubuntu:
+		return Apt
+	case Amazon:
+		if major >= 2022 {
+			return Dnf
+		}
+		return Yum
+	case RHEL, CentOS, Rocky, Alma, Oracle:
+		if major >= 8 {
+			return Dnf
+		}
+		return Yum
+	case Fedora:
+		return Dnf
+	case SLES, OpenSUSE:
+		return Zypper
+	case Alpine:
+		return Apk
+	case Windows:
+		return Choco
+	}
+	return ""
+}
+
+// majorVersion returns the leading number of version, as in 8 for "8.9"
+// or 2023 for "2023.3", or 0 when it has none.
+func majorVersion(version string) int {
+	end := 0
+	version = strings.TrimSpace(version)
+	for end < len(version) && version[end] >= '0' && version[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(version[:end])
+	return n
+}
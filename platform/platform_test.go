@@ -0,0 +1,208 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestParseFamily(t *testing.T) {
+	for in, want := range map[string]string{
+		"debian":         Debian,
+		"Ubuntu":         Ubuntu,
+		"Amazon Linux":   Amazon,
+		"amazon":         Amazon,
+		"amzn":           Amazon,
+		"Red Hat":        RHEL,
+		"Windows Server": Windows,
+		"opensuse-leap":  OpenSUSE,
+	} {
+		if got, err := ParseFamily(in); err != nil || got != want {
+			t.Errorf("ParseFamily(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFamily("BeOS"); err == nil {
+		t.Error("ParseFamily(BeOS) accepted")
+	}
+}
+
+func TestSplitAndName(t *testing.T) {
+	for _, tc := range []struct{ os, family, version string }{
+		{"Debian 10", Debian, "10"},
+		{"Amazon Linux 2", Amazon, "2"},
+		{"Windows Server 2019", Windows, "2019"},
+		{"alpine", Alpine, ""},
+	} {
+		if f, v, ok := Split(tc.os); !ok || f != tc.family || v != tc.version {
+			t.Errorf("Split(%q) = %q, %q, %v", tc.os, f, v, ok)
+		}
+	}
+	if _, _, ok := Split("Plan 9"); ok {
+		t.Error("Split(Plan 9) ok")
+	}
+	for _, tc := range []struct{ family, version, want string }{
+		{Debian, "10.13", "Debian 10"},
+		{Ubuntu, "22.04", "Ubuntu 22.04"},
+		{Amazon, "2", "Amazon Linux 2"},
+		{Alpine, "", "Alpine Linux"},
+	} {
+		if got := Name(tc.family, tc.version); got != tc.want {
+			t.Errorf("Name(%q, %q) = %q, want %q", tc.family, tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestArch(t *testing.T) {
+	for in, want := range map[string]string{"amd64": X86_64, "x86_64": X86_64, "ARM64": AArch64, "armhf": ARMv7, "i386": I686} {
+		if got, err := ParseArch(in); err != nil || got != want {
+			t.Errorf("ParseArch(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, tc := range []struct {
+		v    vuln.Vulnerability
+		want string
+	}{
+		{vuln.Vulnerability{PackageName: "libc6:arm64", InstalledVersion: "2.36-9"}, AArch64},
+		{vuln.Vulnerability{PackageName: "openssl-libs", InstalledVersion: "1:1.0.2k-24.amzn2.0.4.x86_64"}, X86_64},
+		{vuln.Vulnerability{PackageName: "maven-org.apache.logging.log4j:log4j-core", InstalledVersion: "2.14.1"}, ""},
+		{vuln.Vulnerability{PackageName: "curl", InstalledVersion: "7.88.1-10+deb12u5"}, ""},
+	} {
+		if got := DetectArch(&tc.v); got != tc.want {
+			t.Errorf("DetectArch(%s %s) = %q, want %q", tc.v.PackageName, tc.v.InstalledVersion, got, tc.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{AssetID: "a", OS: "Debian 12", PackageName: "curl"},
+		{AssetID: "a", PackageName: "libc6:amd64"},
+		{AssetID: "b", OSFamily: Amazon, OSVersion: "2023", Arch: AArch64},
+		{AssetID: "c", Identifier: "KB5034441"},
+		{AssetID: "d", PackageName: "npm-axios"},
+	}
+	if n := Apply(vs); n != 4 {
+		t.Errorf("Apply = %d, want 4", n)
+	}
+	if v := vs[0]; v.OSFamily != Debian || v.OSVersion != "12" || v.Arch != X86_64 {
+		t.Errorf("from OS: %+v", v)
+	}
+	if v := vs[1]; v.OS != "" || v.Arch != X86_64 {
+		t.Errorf("same asset: %+v", v)
+	}
+	if v := vs[2]; v.OS != "Amazon Linux 2023" || v.Arch != AArch64 {
+		t.Errorf("from family: %+v", v)
+	}
+	if v := vs[3]; v.OSFamily != Windows {
+		t.Errorf("Windows update: %+v", v)
+	}
+}
+
+func TestManager(t *testing.T) {
+	for _, tc := range []struct {
+		v    vuln.Vulnerability
+		want string
+	}{
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: Ubuntu}, Apt},
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: Amazon, OSVersion: "2"}, Yum},
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: Amazon, OSVersion: "2023.3"}, Dnf},
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: RHEL, OSVersion: "7.9"}, Yum},
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: Rocky, OSVersion: "9.3"}, Dnf},
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: SLES, OSVersion: "15"}, Zypper},
+		{vuln.Vulnerability{PackageName: "busybox", InstalledVersion: "1.36.1-r15", OSFamily: Alpine}, Apk},
+		{vuln.Vulnerability{PackageName: "7zip", Source: "aws", OSFamily: Windows}, Choco},
+		{vuln.Vulnerability{Identifier: "KB5034441", Source: "aws"}, WindowsUpdate},
+		{vuln.Vulnerability{PackageName: "npm-axios", Source: "github", OSFamily: Debian}, ""},
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws"}, ""},
+	} {
+		if got := Manager(&tc.v); got != tc.want {
+			t.Errorf("Manager(%+v) = %q, want %q", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestRemediation(t *testing.T) {
+	tmpl, err := ParseTemplates(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		v    vuln.Vulnerability
+		want string
+	}{
+		{vuln.Vulnerability{PackageName: "openssl", Source: "aws", OSFamily: Ubuntu, Arch: X86_64, FixedVersion: "3.0.2-0ubuntu1.18"},
+			"Update openssl to 3.0.2-0ubuntu1.18: apt-get update && apt-get install --only-upgrade openssl:amd64=3.0.2-0ubuntu1.18"},
+		{vuln.Vulnerability{PackageName: "libc6:arm64", Source: "aws", OSFamily: Debian, Fixability: "Fixable"},
+			"Update libc6: apt-get update && apt-get install --only-upgrade libc6"},
+		{vuln.Vulnerability{PackageName: "openssl-libs", Source: "aws", OSFamily: Amazon, OSVersion: "2", Arch: X86_64, FixedVersion: "1:1.0.2k-25.amzn2.0.1"},
+			"Update openssl-libs to 1:1.0.2k-25.amzn2.0.1: yum update openssl-libs-1:1.0.2k-25.amzn2.0.1.x86_64"},
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: Alma, OSVersion: "9"},
+			"Update curl: dnf upgrade curl"},
+		{vuln.Vulnerability{PackageName: "7zip", Source: "aws", OSFamily: Windows, FixedVersion: "24.07"},
+			"Update 7zip to 24.07: choco upgrade 7zip -y --version 24.07"},
+		{vuln.Vulnerability{Identifier: "KB5034441", Source: "aws"},
+			"Install KB5034441 from Windows Update or the Microsoft Update Catalog."},
+		// No update will come for these.
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: Debian, Fixability: "Not fixable"}, ""},
+		{vuln.Vulnerability{PackageName: "curl", Source: "aws", OSFamily: Debian, OSEndOfLife: true}, ""},
+		{vuln.Vulnerability{PackageName: "npm-axios", Source: "github", OSFamily: Debian}, ""},
+	} {
+		if got, err := tmpl.Remediation(&tc.v); err != nil || got != tc.want {
+			t.Errorf("Remediation(%+v) = %q, %v, want %q", tc.v, got, err, tc.want)
+		}
+	}
+}
+
+func TestTemplatesApply(t *testing.T) {
+	tmpl, err := ParseTemplates(map[string]string{Apt: "sudo apt-get install --only-upgrade {{.Package}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := []vuln.Vulnerability{
+		{PackageName: "curl", Source: "aws", OSFamily: Debian},
+		{PackageName: "curl", Source: "aws", OSFamily: Debian, Remediation: "Rebuild the image."},
+		{PackageName: "curl", Source: "trivy", OSFamily: Debian, InstalledVersion: "7.88.1-10+deb12u4", FixedVersion: "7.88.1-10+deb12u5"},
+		{PackageName: "npm-axios", Source: "github", OSFamily: Debian},
+	}
+	vs[2].Remediation = vs[2].UpgradeRemediation()
+	if n, err := tmpl.Apply(vs); err != nil || n != 2 {
+		t.Fatalf("Apply = %d, %v", n, err)
+	}
+	if vs[0].Remediation != "sudo apt-get install --only-upgrade curl" || vs[1].Remediation != "Rebuild the image." ||
+		vs[2].Remediation != "sudo apt-get install --only-upgrade curl" || vs[3].Remediation != "" {
+		t.Errorf("remediations %q, %q, %q, %q", vs[0].Remediation, vs[1].Remediation, vs[2].Remediation, vs[3].Remediation)
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tmpl, err := LoadTemplates(write("templates.yaml", "choco: \"winget upgrade {{.Package}}\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := vuln.Vulnerability{PackageName: "7zip", Source: "aws", OSFamily: Windows}
+	if got, _ := tmpl.Remediation(&v); got != "winget upgrade 7zip" {
+		t.Errorf("choco = %q", got)
+	}
+	if tmpl[Apt] == nil {
+		t.Error("default apt template dropped")
+	}
+	for name, content := range map[string]string{
+		"manager": `{"pacman": "pacman -S {{.Package}}"}`,
+		"syntax":  `{"apt": "apt-get install {{.Package"}`,
+		"field":   `{"apt": "apt-get install {{.Name}}"}`,
+	} {
+		if _, err := LoadTemplates(write(name+".json", content)); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+}
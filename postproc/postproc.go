@@ -0,0 +1,116 @@
+// Package postproc runs a team's own steps over the prioritized findings
+// of a run, before they are written out: external commands that read and
+// write the findings as JSON, and Go plugins. Steps run in a chain, each
+// receiving the findings the one before it returned.
+package postproc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"plugin"
+	"runtime"
+
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Step is one post-processor.
+type Step interface {
+	// Name identifies the step in logs and errors.
+	Name() string
+	// Process returns the findings the next step, or the outputs, get.
+	Process(ctx context.Context, vs []vuln.Vulnerability) ([]vuln.Vulnerability, error)
+}
+
+// Run passes vs through steps in order and returns what the last one
+// returned. It stops at the first step that fails.
+func Run(ctx context.Context, vs []vuln.Vulnerability, steps []Step) ([]vuln.Vulnerability, error) {
+	for _, s := range steps {
+		var err error
+		if vs, err = s.Process(ctx, vs); err != nil {
+			return nil, fmt.Errorf("post-processor %s: %w", s.Name(), err)
+		}
+	}
+	return vs, nil
+}
+
+// Command is an external command, run by the shell: sh -c, or cmd /C on
+// Windows. It reads the findings from standard input as a JSON array, as
+// written with -output_json. A command that writes a JSON array of
+// findings to standard output replaces the findings with it; one that
+// writes nothing, such as an upload, passes them on unchanged.
+type Command struct {
+	Line string
+	// Stderr receives the command's standard error.
+	Stderr io.Writer
+}
+
+// Name implements Step.
+func (c *Command) Name() string { return fmt.Sprintf("%q", c.Line) }
+
+// Process implements Step. A command that exits with an error fails the
+// step.
+func (c *Command) Process(ctx context.Context, vs []vuln.Vulnerability) ([]vuln.Vulnerability, error) {
+	var in, out bytes.Buffer
+	if err := output.WriteJSON(&in, vs); err != nil {
+		return nil, err
+	}
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, shell, flag, c.Line)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = &in, &out, c.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out.Bytes())) == 0 {
+		return vs, nil
+	}
+	processed, err := output.ReadJSON(&out)
+	if err != nil {
+		return nil, fmt.Errorf("reading the findings it wrote: %w", err)
+	}
+	return processed, nil
+}
+
+// PluginSymbol is the function a Go plugin exports as a post-processor:
+//
+//	func PostProcess(ctx context.Context, vs []vuln.Vulnerability) ([]vuln.Vulnerability, error)
+const PluginSymbol = "PostProcess"
+
+// Plugin is a Go plugin (go build -buildmode=plugin) exporting
+// PluginSymbol. It must be built with the Go version and module version of
+// this tool; plugins are only supported on Linux, FreeBSD and macOS.
+type Plugin struct {
+	Path    string
+	process func(context.Context, []vuln.Vulnerability) ([]vuln.Vulnerability, error)
+}
+
+// OpenPlugin loads the plugin at path.
+func OpenPlugin(path string) (*Plugin, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	process, ok := sym.(func(context.Context, []vuln.Vulnerability) ([]vuln.Vulnerability, error))
+	if !ok {
+		return nil, fmt.Errorf("plugin %s: %s is a %T, not a post-processor", path, PluginSymbol, sym)
+	}
+	return &Plugin{Path: path, process: process}, nil
+}
+
+// Name implements Step.
+func (p *Plugin) Name() string { return p.Path }
+
+// Process implements Step.
+func (p *Plugin) Process(ctx context.Context, vs []vuln.Vulnerability) ([]vuln.Vulnerability, error) {
+	return p.process(ctx, vs)
+}
@@ -0,0 +1,68 @@
+package postproc
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/output"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands are written for sh")
+	}
+	seen := filepath.Join(t.TempDir(), "seen.json")
+	vs := []vuln.Vulnerability{
+		{UniqueID: "1", Identifier: "CVE-2024-3094", Severity: vuln.Critical, PriorityScore: 9.5},
+		{UniqueID: "2", Identifier: "CVE-2023-4911", Severity: vuln.High, PriorityScore: 7.1},
+	}
+	var stderr strings.Builder
+	steps := []Step{
+		// Writes nothing, so the findings pass on unchanged.
+		&Command{Line: "cat > " + seen + "; echo uploaded >&2", Stderr: &stderr},
+		// Replaces the findings with the first one.
+		&Command{Line: `cat >/dev/null; echo '[{"unique_id": "1", "identifier": "CVE-2024-3094", "severity": "Critical", "priority_score": 9.5}]'`, Stderr: &stderr},
+	}
+	got, err := Run(context.Background(), vs, steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].UniqueID != "1" || got[0].PriorityScore != 9.5 {
+		t.Errorf("findings = %+v", got)
+	}
+	if stderr.String() != "uploaded\n" {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+	f, err := os.Open(seen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if piped, err := output.ReadJSON(f); err != nil || len(piped) != 2 {
+		t.Errorf("first step read %d findings, %v; want both", len(piped), err)
+	}
+
+	for name, line := range map[string]string{
+		"exit status": "exit 3",
+		"output":      "echo not json",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Run(context.Background(), vs, []Step{&Command{Line: line, Stderr: io.Discard}})
+			if err == nil || !strings.Contains(err.Error(), `post-processor "`+line+`"`) {
+				t.Errorf("Run = %v, want an error naming the step", err)
+			}
+		})
+	}
+}
+
+func TestOpenPlugin(t *testing.T) {
+	if _, err := OpenPlugin(filepath.Join(t.TempDir(), "missing.so")); err == nil {
+		t.Error("OpenPlugin opened a missing file")
+	}
+}
@@ -0,0 +1,95 @@
+// Package quota caps how many findings of each team and severity go into
+// the reports distributed to teams, keeping the highest-priority ones, and
+// summarizes the findings left out, so that a team gets a list it can
+// work through rather than a dump it ignores.
+package quota
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Team returns the team that owns v: its Team, or else its organization
+// or account.
+func Team(v *vuln.Vulnerability) string {
+	if t := strings.TrimSpace(v.Team); t != "" {
+		return t
+	}
+	return strings.TrimSpace(v.Organization)
+}
+
+// Withheld summarizes the findings of one team and severity left out of
+// the reports.
+type Withheld struct {
+	Team     string        `json:"team"`
+	Severity vuln.Severity `json:"severity"`
+	Findings int           `json:"findings"`
+	Included int           `json:"included"`
+	Withheld int           `json:"withheld"`
+	// TopScore is the highest priority score of the withheld findings.
+	TopScore float64 `json:"top_score"`
+}
+
+type group struct {
+	team string
+	sev  vuln.Severity
+}
+
+// Apply returns the findings of vs that fit in max per team and severity,
+// the highest priority scores of each first, in the order of vs. The
+// summary lists the teams and severities with findings withheld, by team
+// and then severity.
+func Apply(vs []vuln.Vulnerability, max int) ([]vuln.Vulnerability, []Withheld) {
+	groups := make(map[group][]int)
+	for i := range vs {
+		g := group{Team(&vs[i]), vs[i].Severity}
+		groups[g] = append(groups[g], i)
+	}
+	keep := make([]bool, len(vs))
+	var withheld []Withheld
+	for g, idx := range groups {
+		sort.SliceStable(idx, func(a, b int) bool { return vs[idx[a]].PriorityScore > vs[idx[b]].PriorityScore })
+		for _, i := range idx[:min(max, len(idx))] {
+			keep[i] = true
+		}
+		if len(idx) > max {
+			withheld = append(withheld, Withheld{
+				Team: g.team, Severity: g.sev, Findings: len(idx), Included: max, Withheld: len(idx) - max,
+				TopScore: vs[idx[max]].PriorityScore,
+			})
+		}
+	}
+	sort.Slice(withheld, func(i, j int) bool {
+		a, b := withheld[i], withheld[j]
+		if a.Team != b.Team {
+			return a.Team < b.Team
+		}
+		return a.Severity.Rank() < b.Severity.Rank()
+	})
+	kept := make([]vuln.Vulnerability, 0, len(vs))
+	for i := range vs {
+		if keep[i] {
+			kept = append(kept, vs[i])
+		}
+	}
+	return kept, withheld
+}
+
+// WriteCSV writes the summary as CSV with a header row.
+func WriteCSV(w io.Writer, withheld []Withheld) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Team", "Severity", "Findings", "Included", "Withheld", "Top Withheld Score"})
+	for _, s := range withheld {
+		cw.Write([]string{
+			s.Team, string(s.Severity), strconv.Itoa(s.Findings), strconv.Itoa(s.Included), strconv.Itoa(s.Withheld),
+			strconv.FormatFloat(s.TopScore, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,52 @@
+package quota
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestApply(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{UniqueID: "a1", Organization: "acct", Severity: vuln.High, PriorityScore: 6},
+		{UniqueID: "a2", Organization: "acct", Severity: vuln.High, PriorityScore: 8},
+		{UniqueID: "a3", Organization: "acct", Severity: vuln.Low, PriorityScore: 2},
+		{UniqueID: "p1", Organization: "acct", Team: "payments", Severity: vuln.High, PriorityScore: 7},
+		{UniqueID: "a4", Organization: "acct", Severity: vuln.High, PriorityScore: 7},
+		{UniqueID: "a5", Organization: "acct", Severity: vuln.Critical, PriorityScore: 9},
+		{UniqueID: "a6", Organization: "acct", Severity: vuln.Critical, PriorityScore: 9.5},
+		{UniqueID: "a7", Organization: "acct", Severity: vuln.Critical, PriorityScore: 8.5},
+	}
+	kept, withheld := Apply(vs, 2)
+	var ids []string
+	for _, v := range kept {
+		ids = append(ids, v.UniqueID)
+	}
+	if want := []string{"a2", "a3", "p1", "a4", "a5", "a6"}; !slices.Equal(ids, want) {
+		t.Errorf("kept %v, want %v", ids, want)
+	}
+	want := []Withheld{
+		{Team: "acct", Severity: vuln.Critical, Findings: 3, Included: 2, Withheld: 1, TopScore: 8.5},
+		{Team: "acct", Severity: vuln.High, Findings: 3, Included: 2, Withheld: 1, TopScore: 6},
+	}
+	if !slices.Equal(withheld, want) {
+		t.Errorf("withheld %+v, want %+v", withheld, want)
+	}
+
+	if kept, withheld := Apply(vs, 3); len(kept) != len(vs) || withheld != nil {
+		t.Errorf("Apply(vs, 3) kept %d and withheld %v, want all and none", len(kept), withheld)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteCSV(&b, []Withheld{{Team: "acct", Severity: vuln.High, Findings: 380, Included: 50, Withheld: 330, TopScore: 6.1}}); err != nil {
+		t.Fatal(err)
+	}
+	want := "Team,Severity,Findings,Included,Withheld,Top Withheld Score\nacct,High,380,50,330,6.10\n"
+	if b.String() != want {
+		t.Errorf("WriteCSV = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,272 @@
+// Package rego evaluates suppressions, escalations and gate decisions
+// written as OPA Rego policies, so that teams can reuse the policy
+// language they maintain for other systems. Policies are evaluated by the
+// opa command line tool, which keeps the tool free of the OPA SDK and its
+// dependencies; any OPA release that has "opa eval" runs them the same.
+//
+// A policy is a Rego module in package prioritizer. It reads the run's
+// findings from input.findings, each as in -output_json plus its
+// fingerprint, and the run's date from input.today, and may define three
+// objects keyed by finding fingerprint:
+//
+//	suppress[f.fingerprint] := reason     marks the finding suppressed
+//	escalate[f.fingerprint] := timeframe  raises it to an action timeframe
+//	deny[f.fingerprint] := reason         fails the run's gate on it
+package rego
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/gate"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// DefaultBinary is the opa command looked up on the PATH.
+const DefaultBinary = "opa"
+
+// Query is the document policies are evaluated for.
+const Query = "data.prioritizer"
+
+// Decisions are what the policies decided, by finding fingerprint.
+type Decisions struct {
+	Suppress map[string]string `json:"suppress"` // the reason
+	Escalate map[string]string `json:"escalate"` // the action timeframe
+	Deny     map[string]string `json:"deny"`     // the reason
+}
+
+// Evaluator evaluates policies with the opa command Binary. It holds the
+// policies as Load read them, so that a policy file edited afterwards,
+// even into one that does not compile, changes nothing until it is loaded
+// again.
+type Evaluator struct {
+	Binary   string   // DefaultBinary if empty
+	Policies []string // the files and directories loaded
+	files    []file
+}
+
+// file is a policy or data file read from path, written at name under
+// the directory of the Policies entry root.
+type file struct {
+	root int
+	name string
+	path string
+	data []byte
+}
+
+// Load reads the policies at paths, each a Rego file or a directory of
+// them. Directories are read as opa reads them: their .rego files and
+// their JSON and YAML data files, at any depth.
+func Load(binary string, paths []string) (*Evaluator, error) {
+	e := &Evaluator{Binary: binary, Policies: paths}
+	for i, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			b, err := os.ReadFile(root)
+			if err != nil {
+				return nil, err
+			}
+			e.files = append(e.files, file{i, filepath.Base(root), root, b})
+			continue
+		}
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !dataExts[strings.ToLower(filepath.Ext(path))] {
+				return err
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			name, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			e.files = append(e.files, file{i, name, path, b})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// dataExts are the extensions of the files opa loads from a directory.
+var dataExts = map[string]bool{".rego": true, ".json": true, ".yaml": true, ".yml": true}
+
+// Check evaluates the policies over no findings, to tell whether they
+// compile.
+func (e *Evaluator) Check(ctx context.Context, tiers []scoring.Tier) error {
+	_, err := e.Evaluate(ctx, nil, time.Now(), tiers)
+	return err
+}
+
+// input is the document policies read as input.
+type input struct {
+	Findings []finding `json:"findings"`
+	Today    string    `json:"today"`
+}
+
+type finding struct {
+	vuln.Vulnerability
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Evaluate evaluates the policies over vs as on today. Escalations to
+// timeframes that are not among tiers are an error, as a typo would
+// otherwise escalate nothing.
+func (e *Evaluator) Evaluate(ctx context.Context, vs []vuln.Vulnerability, today time.Time, tiers []scoring.Tier) (*Decisions, error) {
+	in := input{Findings: make([]finding, len(vs)), Today: today.Format(vuln.DateLayout)}
+	for i := range vs {
+		in.Findings[i] = finding{vs[i], vs[i].Fingerprint()}
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	binary := e.Binary
+	if binary == "" {
+		binary = DefaultBinary
+	}
+	dir, err := os.MkdirTemp("", "prioritizer-rego-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	args := []string{"eval", "--format", "json", "--stdin-input"}
+	for i := range e.Policies {
+		root := filepath.Join(dir, strconv.Itoa(i))
+		if err := os.Mkdir(root, 0o700); err != nil {
+			return nil, err
+		}
+		args = append(args, "--data", root)
+	}
+	// Errors name the files opa read; replace them with the loaded ones.
+	var paths []string
+	for _, f := range e.files {
+		path := filepath.Join(dir, strconv.Itoa(f.root), f.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, f.data, 0o600); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path, f.path)
+	}
+	cmd := exec.CommandContext(ctx, binary, append(args, Query)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(b), &stdout, &stderr
+	runErr := cmd.Run()
+
+	var out struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+		Errors []struct {
+			Message  string `json:"message"`
+			Location *struct {
+				File string `json:"file"`
+				Row  int    `json:"row"`
+			} `json:"location"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil || len(out.Errors) > 0 || runErr != nil {
+		var msgs []string
+		for _, e := range out.Errors {
+			if e.Location != nil {
+				msgs = append(msgs, fmt.Sprintf("%s:%d: %s", e.Location.File, e.Location.Row, e.Message))
+			} else {
+				msgs = append(msgs, e.Message)
+			}
+		}
+		if len(msgs) == 0 {
+			msgs = append(msgs, strings.TrimSpace(stderr.String()))
+		}
+		if runErr == nil {
+			runErr = err
+		}
+		msg := strings.NewReplacer(paths...).Replace(strings.Join(msgs, "; "))
+		return nil, fmt.Errorf("opa eval: %w: %s", runErr, msg)
+	}
+	d := &Decisions{}
+	// A query that no policy defines has no result.
+	if len(out.Result) > 0 && len(out.Result[0].Expressions) > 0 {
+		if err := json.Unmarshal(out.Result[0].Expressions[0].Value, d); err != nil {
+			return nil, fmt.Errorf("%s: want objects of strings keyed by fingerprint: %w", Query, err)
+		}
+	}
+	for fp, timeframe := range d.Escalate {
+		if _, ok := tier(timeframe, tiers); !ok {
+			return nil, fmt.Errorf("%s.escalate[%q]: unknown action timeframe %q", Query, fp, timeframe)
+		}
+	}
+	return d, nil
+}
+
+func tier(name string, tiers []scoring.Tier) (scoring.Tier, bool) {
+	for _, t := range tiers {
+		if strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+	return scoring.Tier{}, false
+}
+
+// Applied counts the findings Apply changed.
+type Applied struct {
+	Suppressed int
+	Escalated  int
+}
+
+// Apply marks the findings of vs that d suppresses as Suppressed, with
+// the reason as their Waiver, and raises the priority score of each it
+// escalates to at least the minimum of the timeframe among tiers, then
+// re-ranks vs. Escalation never lowers a finding's timeframe.
+func (d *Decisions) Apply(vs []vuln.Vulnerability, tiers []scoring.Tier) Applied {
+	var a Applied
+	for i := range vs {
+		v := &vs[i]
+		fp := v.Fingerprint()
+		if reason, ok := d.Suppress[fp]; ok && !v.Suppressed {
+			v.Suppressed, v.Waiver = true, reason
+			a.Suppressed++
+		}
+		if timeframe, ok := d.Escalate[fp]; ok {
+			if t, _ := tier(timeframe, tiers); v.PriorityScore < t.MinScore {
+				v.PriorityScore = t.MinScore
+				a.Escalated++
+			}
+		}
+	}
+	if a.Escalated > 0 {
+		scoring.Rank(vs, tiers)
+	}
+	return a
+}
+
+// Denied returns the findings of vs that d denies, in the order of vs,
+// but for those that pass every gate; see gate.Exempt.
+func (d *Decisions) Denied(vs []vuln.Vulnerability) []vuln.Vulnerability {
+	var denied []vuln.Vulnerability
+	for i := range vs {
+		if _, ok := d.Deny[vs[i].Fingerprint()]; ok && !gate.Exempt(&vs[i]) {
+			denied = append(denied, vs[i])
+		}
+	}
+	return denied
+}
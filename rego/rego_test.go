@@ -0,0 +1,164 @@
+package rego
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+var today = time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+
+func findings() []vuln.Vulnerability {
+	return []vuln.Vulnerability{
+		{Identifier: "CVE-1", AssetName: "prod-web", PackageName: "openssl", KEV: true, PriorityScore: 6.5, ActionTimeframe: "Urgent"},
+		{Identifier: "CVE-2", AssetName: "test-web", PackageName: "openssl", KEV: true, PriorityScore: 9, ActionTimeframe: "Immediate"},
+		{Identifier: "CVE-3", AssetName: "prod-db", PackageName: "zlib", PriorityScore: 7, ActionTimeframe: "Urgent"},
+	}
+}
+
+// fakeOPA writes an opa command that records its arguments, input and
+// first policy directory in dir, then runs script.
+func fakeOPA(t *testing.T, script string) (binary, dir string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake opa is written for sh")
+	}
+	dir = t.TempDir()
+	body := "#!/bin/sh\nd=" + dir + "\necho \"$@\" > $d/args\ncat > $d/input.json\ncp -R \"$6\" $d/policies\n" + script + "\n"
+	binary = filepath.Join(dir, "opa")
+	if err := os.WriteFile(binary, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return binary, dir
+}
+
+// writePolicy writes body to the policy file name in a new directory.
+func writePolicy(t *testing.T, name, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEvaluate(t *testing.T) {
+	vs := findings()
+	fp := vs[0].Fingerprint()
+	bin, dir := fakeOPA(t, `echo '{"result": [{"expressions": [{"value": {"escalate": {"`+fp+`": "immediate"}, "deny": {"`+fp+`": "exploited"}}, "text": "data.prioritizer"}]}]}'`)
+	policy := writePolicy(t, "a.rego", "package prioritizer\n")
+	lib := filepath.Dir(writePolicy(t, "lib/sub/data.json", "{}"))
+	e, err := Load(bin, []string{policy, filepath.Dir(lib)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An edit after Load changes nothing.
+	if err := os.WriteFile(policy, []byte("package broken {"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := e.Evaluate(context.Background(), vs, today, scoring.DefaultTiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Escalate[fp] != "immediate" || d.Deny[fp] != "exploited" || len(d.Suppress) != 0 {
+		t.Errorf("decisions = %+v", d)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if f := strings.Fields(string(args)); len(f) != 9 || strings.Join(f[:4], " ") != "eval --format json --stdin-input" ||
+		f[4] != "--data" || filepath.Base(f[5]) != "0" || f[6] != "--data" || filepath.Base(f[7]) != "1" || f[8] != Query {
+		t.Errorf("args = %s", args)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "policies", "a.rego")); string(b) != "package prioritizer\n" {
+		t.Errorf("evaluated policy %q, want the one loaded", b)
+	}
+	var in struct {
+		Findings []map[string]any `json:"findings"`
+		Today    string           `json:"today"`
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "input.json"))
+	if err := json.Unmarshal(b, &in); err != nil {
+		t.Fatal(err)
+	}
+	if len(in.Findings) != 3 || in.Findings[0]["fingerprint"] != fp || in.Findings[0]["identifier"] != "CVE-1" || in.Today != "2025-02-05" {
+		t.Errorf("input = %s", b)
+	}
+
+	for name, tc := range map[string]struct {
+		script string
+		want   string
+	}{
+		"undefined":         {`echo '{}'`, ""},
+		"compile error":     {`printf '{"errors": [{"message": "rego_parse_error: unexpected eof", "location": {"file": "%s/a.rego", "row": 3}}]}' "$6"; exit 1`, policy + ":3: rego_parse_error"},
+		"not strings":       {`echo '{"result": [{"expressions": [{"value": {"deny": ["x"]}}]}]}'`, "want objects of strings"},
+		"unknown timeframe": {`echo '{"result": [{"expressions": [{"value": {"escalate": {"x": "Soon"}}}]}]}'`, `unknown action timeframe "Soon"`},
+		"garbled":           {`echo no json; exit 2`, "exit status 2"},
+	} {
+		bin, _ := fakeOPA(t, tc.script)
+		e.Binary = bin
+		err := e.Check(context.Background(), scoring.DefaultTiers)
+		if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("%s: err = %v, want %q", name, err, tc.want)
+		}
+	}
+	if _, err := Load(bin, []string{filepath.Join(dir, "missing.rego")}); err == nil {
+		t.Error("Load of a missing policy succeeded")
+	}
+}
+
+func TestApply(t *testing.T) {
+	vs := findings()
+	fps := []string{vs[0].Fingerprint(), vs[1].Fingerprint(), vs[2].Fingerprint()}
+	d := &Decisions{
+		Suppress: map[string]string{fps[1]: "test asset"},
+		// CVE-3 is Urgent already, so Planned does not lower it.
+		Escalate: map[string]string{fps[0]: "Immediate", fps[2]: "Planned"},
+		Deny:     map[string]string{fps[0]: "exploited", fps[1]: "exploited"},
+	}
+	if a := d.Apply(vs, scoring.DefaultTiers); a != (Applied{Suppressed: 1, Escalated: 1}) {
+		t.Errorf("Apply = %+v", a)
+	}
+	// Apply re-ranks: CVE-2 at 9, then CVE-1 escalated to 8, then CVE-3.
+	if vs[1].Identifier != "CVE-1" || vs[1].PriorityScore != 8 || vs[1].ActionTimeframe != "Immediate" || vs[2].ActionTimeframe != "Urgent" {
+		t.Errorf("ranked %+v", vs)
+	}
+	if !vs[0].Suppressed || vs[0].Waiver != "test asset" {
+		t.Errorf("CVE-2 not suppressed: %+v", vs[0])
+	}
+	// The suppressed CVE-2 passes the gate.
+	if denied := d.Denied(vs); len(denied) != 1 || denied[0].Identifier != "CVE-1" {
+		t.Errorf("Denied = %+v", denied)
+	}
+}
+
+// TestPolicy evaluates testdata/policy.rego with the opa on the PATH.
+func TestPolicy(t *testing.T) {
+	if _, err := exec.LookPath(DefaultBinary); err != nil {
+		t.Skip("opa is not installed")
+	}
+	vs := findings()
+	e, err := Load(DefaultBinary, []string{"testdata/policy.rego"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := e.Evaluate(context.Background(), vs, today, scoring.DefaultTiers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Apply(vs, scoring.DefaultTiers)
+	denied := d.Denied(vs)
+	if len(denied) != 1 || denied[0].Identifier != "CVE-1" || d.Deny[denied[0].Fingerprint()] != "CVE-1 is known to be exploited" || vs[1].PriorityScore != 8 {
+		t.Errorf("decisions %+v, denied %+v", d, denied)
+	}
+}
@@ -0,0 +1,19 @@
+package prioritizer
+
+# Findings on test assets are accepted.
+suppress[f.fingerprint] := "test asset" if {
+	some f in input.findings
+	startswith(f.asset_name, "test-")
+}
+
+# Exploited findings are handled at once, and block the release until
+# they are.
+escalate[f.fingerprint] := "Immediate" if {
+	some f in input.findings
+	f.kev
+}
+
+deny[f.fingerprint] := sprintf("%s is known to be exploited", [f.identifier]) if {
+	some f in input.findings
+	f.kev
+}
@@ -0,0 +1,161 @@
+// Package rename maps packages that were renamed or relocated to their
+// current names. A project that moves from babel-core to @babel/core, or
+// from github.com/dgrijalva/jwt-go to github.com/golang-jwt/jwt, is
+// reported under both names while its assets catch up, and scanners that
+// have not caught up keep the old one, so the same component would be
+// counted twice.
+package rename
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// ecosystems are the ecosystems a rename may name.
+var ecosystems = []vuln.Ecosystem{vuln.EcosystemNPM, vuln.EcosystemPip, vuln.EcosystemGo, vuln.EcosystemMaven, vuln.EcosystemOSPkg}
+
+// Package is one renamed package.
+type Package struct {
+	// Ecosystem is the ecosystem of both names, as in npm or go.
+	Ecosystem vuln.Ecosystem `json:"ecosystem"`
+	// From is the old name and To the current one, without ecosystem
+	// prefixes. A Go module's packages and major versions move with it,
+	// so github.com/dgrijalva/jwt-go/v4 becomes github.com/golang-jwt/jwt/v4.
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Reason says why, for whoever reads the file next.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Map is the renamed packages of a renames file.
+type Map struct {
+	Packages []Package `json:"packages"`
+
+	to map[key]string // lower-cased From to To
+}
+
+// key names a package within its ecosystem, lower-cased.
+type key struct {
+	ecosystem vuln.Ecosystem
+	name      string
+}
+
+// Load reads a renames file, as YAML when it is named .yaml or .yml and
+// JSON otherwise:
+//
+//	packages:
+//	  - ecosystem: npm
+//	    from: babel-core
+//	    to: "@babel/core"
+//	  - ecosystem: go
+//	    from: github.com/dgrijalva/jwt-go
+//	    to: github.com/golang-jwt/jwt
+//
+// A package renamed again is followed to its latest name. Unknown keys and
+// ecosystems are rejected, as are renames without both names, a package
+// renamed twice and renames that lead back to where they started.
+func Load(file string) (*Map, error) {
+	b, err := config.ReadDocument(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	m := &Map{}
+	if err := dec.Decode(m); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	m.to = make(map[key]string, len(m.Packages))
+	for i := range m.Packages {
+		p := &m.Packages[i]
+		p.Ecosystem = vuln.Ecosystem(strings.ToLower(strings.TrimSpace(string(p.Ecosystem))))
+		p.From, p.To = strings.TrimSpace(p.From), strings.TrimSpace(p.To)
+		switch {
+		case p.From == "" || p.To == "":
+			return nil, fmt.Errorf("%s: rename %d needs both from and to", file, i+1)
+		case !slices.Contains(ecosystems, p.Ecosystem):
+			return nil, fmt.Errorf("%s: %s: unknown ecosystem %q", file, p.From, p.Ecosystem)
+		case strings.EqualFold(p.From, p.To):
+			return nil, fmt.Errorf("%s: %s: renamed to itself", file, p.From)
+		case p.Ecosystem == vuln.EcosystemGo && strings.HasPrefix(strings.ToLower(p.To), strings.ToLower(p.From)+"/"):
+			return nil, fmt.Errorf("%s: %s: moved into its own module path", file, p.From)
+		}
+		k := key{p.Ecosystem, strings.ToLower(p.From)}
+		if _, dup := m.to[k]; dup {
+			return nil, fmt.Errorf("%s: %s: renamed more than once", file, p.From)
+		}
+		m.to[k] = p.To
+	}
+	for _, p := range m.Packages {
+		if _, ok := m.current(p.Ecosystem, p.From); !ok {
+			return nil, fmt.Errorf("%s: %s: renames lead back to it", file, p.From)
+		}
+	}
+	return m, nil
+}
+
+// Current returns the current name of the package name in ecosystem eco:
+// name itself unless it, or for Go the module it is in, was renamed.
+func (m *Map) Current(eco vuln.Ecosystem, name string) string {
+	current, _ := m.current(eco, name)
+	return current
+}
+
+// current follows the renames of name. It reports false when they go
+// on for more steps than there are renames, that is for a cycle.
+func (m *Map) current(eco vuln.Ecosystem, name string) (string, bool) {
+	for range len(m.to) + 1 {
+		next, ok := m.rename(eco, name)
+		if !ok {
+			return name, true
+		}
+		name = next
+	}
+	return name, false
+}
+
+// rename renames name once, matching Go modules by path prefix.
+func (m *Map) rename(eco vuln.Ecosystem, name string) (string, bool) {
+	if to, ok := m.to[key{eco, strings.ToLower(name)}]; ok {
+		return to, true
+	}
+	if eco != vuln.EcosystemGo {
+		return "", false
+	}
+	for i := strings.LastIndexByte(name, '/'); i > 0; i = strings.LastIndexByte(name[:i], '/') {
+		if to, ok := m.to[key{eco, strings.ToLower(name[:i])}]; ok {
+			return to + name[i:], true
+		}
+	}
+	return "", false
+}
+
+// Apply renames the package of every finding of vs under an old name to
+// its current one, keeping the ecosystem prefix, as in npm-@babel/core for
+// npm-babel-core, and sets RenamedFrom to the name the input reported.
+// Findings renamed before keep their RenamedFrom. It counts the findings
+// it renamed.
+func (m *Map) Apply(vs []vuln.Vulnerability) int {
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		pkg := v.EcosystemPackage()
+		current := m.Current(v.Ecosystem(), pkg)
+		if current == pkg {
+			continue
+		}
+		name := strings.TrimSpace(v.PackageName)
+		if v.RenamedFrom == "" {
+			v.RenamedFrom = name
+		}
+		v.PackageName = name[:len(name)-len(pkg)] + current
+		n++
+	}
+	return n
+}
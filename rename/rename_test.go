@@ -0,0 +1,110 @@
+package rename
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/dedup"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const renames = `packages:
+  - ecosystem: npm
+    from: babel-core
+    to: "@babel/core"
+  - ecosystem: go
+    from: github.com/dgrijalva/jwt-go
+    to: github.com/form3tech-oss/jwt-go
+    reason: forked after the original was archived
+  - ecosystem: go
+    from: github.com/form3tech-oss/jwt-go
+    to: github.com/golang-jwt/jwt
+  - ecosystem: Maven
+    from: javax.mail:mail
+    to: com.sun.mail:javax.mail
+`
+
+func TestLoad(t *testing.T) {
+	m, err := Load(writeFile(t, "renames.yaml", renames))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		eco        vuln.Ecosystem
+		name, want string
+	}{
+		{vuln.EcosystemNPM, "Babel-Core", "@babel/core"},
+		{vuln.EcosystemNPM, "babel-core-cli", "babel-core-cli"},
+		{vuln.EcosystemPip, "babel-core", "babel-core"},
+		{vuln.EcosystemGo, "github.com/dgrijalva/jwt-go", "github.com/golang-jwt/jwt"},
+		{vuln.EcosystemGo, "github.com/dgrijalva/jwt-go/v4/request", "github.com/golang-jwt/jwt/v4/request"},
+		{vuln.EcosystemGo, "github.com/dgrijalva/jwt-go-extra", "github.com/dgrijalva/jwt-go-extra"},
+		{vuln.EcosystemMaven, "javax.mail:mail", "com.sun.mail:javax.mail"},
+	} {
+		if got := m.Current(tt.eco, tt.name); got != tt.want {
+			t.Errorf("Current(%s, %s) = %s, want %s", tt.eco, tt.name, got, tt.want)
+		}
+	}
+
+	for name, content := range map[string]string{
+		"no to":       "packages:\n  - ecosystem: npm\n    from: request\n",
+		"ecosystem":   "packages:\n  - ecosystem: cargo\n    from: a\n    to: b\n",
+		"itself":      "packages:\n  - ecosystem: npm\n    from: a\n    to: A\n",
+		"twice":       "packages:\n  - ecosystem: npm\n    from: a\n    to: b\n  - ecosystem: npm\n    from: a\n    to: c\n",
+		"cycle":       "packages:\n  - ecosystem: npm\n    from: a\n    to: b\n  - ecosystem: npm\n    from: b\n    to: a\n",
+		"module path": "packages:\n  - ecosystem: go\n    from: example.com/a\n    to: example.com/a/v2\n",
+		"go cycle":    "packages:\n  - ecosystem: go\n    from: example.com/a\n    to: example.com/b/x\n  - ecosystem: go\n    from: example.com/b\n    to: example.com/a/y\n",
+		"unknown key": "packages:\n  - ecosystem: npm\n    from: a\n    to: b\n    since: 2024\n",
+	} {
+		if _, err := Load(writeFile(t, "renames.yaml", content)); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	m, err := Load(writeFile(t, "renames.yaml", renames))
+	if err != nil {
+		t.Fatal(err)
+	}
+	finding := func(id, pkg string) vuln.Vulnerability {
+		return vuln.Vulnerability{UniqueID: id, AssetID: "match-v1", Identifier: "CVE-2020-26160", PackageName: pkg, InstalledVersion: "3.2.0"}
+	}
+	vs := []vuln.Vulnerability{
+		finding("old", "go-github.com/dgrijalva/jwt-go"),
+		finding("new", "go-github.com/golang-jwt/jwt"),
+		finding("npm", "npm-babel-core"),
+		finding("other", "npm-axios"),
+	}
+	if n := m.Apply(vs); n != 2 {
+		t.Errorf("Apply = %d, want 2", n)
+	}
+	if v := vs[0]; v.PackageName != "go-github.com/golang-jwt/jwt" || v.RenamedFrom != "go-github.com/dgrijalva/jwt-go" {
+		t.Errorf("old = %s from %q", v.PackageName, v.RenamedFrom)
+	}
+	if v := vs[2]; v.PackageName != "npm-@babel/core" || v.RenamedFrom != "npm-babel-core" {
+		t.Errorf("npm = %s from %q", v.PackageName, v.RenamedFrom)
+	}
+	if vs[1].RenamedFrom != "" || vs[3].RenamedFrom != "" {
+		t.Errorf("renamed current packages: %+v", vs)
+	}
+	if n := m.Apply(vs); n != 0 {
+		t.Errorf("Apply again = %d, want 0", n)
+	}
+
+	// The old and new names are one finding once renamed.
+	left, merged := dedup.Apply(vs)
+	if merged != 1 || len(left) != 3 || left[0].Occurrences != 2 {
+		t.Errorf("dedup merged %d, left %d: %+v", merged, len(left), left)
+	}
+}
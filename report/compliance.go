@@ -0,0 +1,270 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Band is the SLA adherence of the findings of one severity. Every
+// finding is within its SLA, breached it or risk accepted: a resolved
+// finding by its resolution date, an open one by the report date.
+type Band struct {
+	// Severity is the band's severity, or "Total".
+	Severity string `json:"severity"`
+	// SLADays is the remediation window of the SLA policy, if it has one
+	// for the severity.
+	SLADays      int `json:"sla_days,omitempty"`
+	Findings     int `json:"findings"`
+	WithinSLA    int `json:"within_sla"`
+	Breached     int `json:"breached"`
+	RiskAccepted int `json:"risk_accepted"`
+	Open         int `json:"open"`
+	Resolved     int `json:"resolved"`
+	// AverageDaysToFix is the mean number of days from first detection
+	// to resolution of the resolved findings with a first detected date,
+	// or nil when there are none.
+	AverageDaysToFix *float64 `json:"average_days_to_fix"`
+	// Adherence is the share of findings not risk accepted that are
+	// within their SLA, from 0 to 1, or nil when all are.
+	Adherence *float64 `json:"adherence"`
+
+	fixed, fixDays int
+}
+
+// Source is one store or file a compliance report was generated from.
+type Source struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Contract is the customer whose findings a compliance report covers and
+// the SLA adherence the customer was promised.
+type Contract struct {
+	Customer string   `json:"customer"`
+	Accounts []string `json:"accounts"`
+	// TargetAdherence is the contracted adherence, from 0 to 1, or nil
+	// for none.
+	TargetAdherence *float64 `json:"target_adherence,omitempty"`
+	// TargetMet reports whether the total adherence reaches the target,
+	// or is nil without a target or an adherence to compare it with.
+	TargetMet *bool `json:"target_met,omitempty"`
+}
+
+// Compliance is SLA adherence by severity, as audit evidence.
+type Compliance struct {
+	AsOf vuln.Date `json:"as_of"`
+	// Contract is set when the report covers one customer's findings.
+	Contract *Contract `json:"contract,omitempty"`
+	Bands    []Band    `json:"bands"`
+	Total    Band      `json:"total"`
+	// Untracked counts state records whose finding is not in the input,
+	// so whose severity and due date are unknown.
+	Untracked int      `json:"untracked,omitempty"`
+	Evidence  []Source `json:"evidence,omitempty"`
+}
+
+// NewCompliance reports the SLA adherence of vs, with the workflow states
+// in states, at now. A finding's due date is its DueDate, so policy only
+// names the windows; apply it to vs first. A finding resolved on or
+// before its due date is within its SLA, and how long it took is
+// measured to the date it was recorded as resolved. vs should hold each
+// fingerprint once.
+func NewCompliance(vs []vuln.Vulnerability, states *state.Store, policy sla.Policy, now time.Time) *Compliance {
+	c := &Compliance{AsOf: vuln.NewDate(now.Year(), now.Month(), now.Day())}
+	bands := make(map[vuln.Severity]*Band)
+	for _, sev := range vuln.Severities {
+		bands[sev] = &Band{Severity: string(sev), SLADays: policy[sev]}
+	}
+	seen := make(map[string]bool)
+	for i := range vs {
+		v := &vs[i]
+		b, ok := bands[v.Severity]
+		if !ok {
+			continue
+		}
+		fp := v.Fingerprint()
+		seen[fp] = true
+		b.Findings++
+		r := states.Records[fp]
+		switch {
+		case r != nil && r.State == state.RiskAccepted:
+			b.RiskAccepted++
+		case r != nil && r.State == state.Resolved:
+			b.Resolved++
+			if !v.FirstDetectedDate.IsZero() {
+				b.fixed++
+				b.fixDays += max(-v.FirstDetectedDate.DaysUntil(r.ChangedAt), 0)
+			}
+			b.count(sla.Breached(v.DueDate, r.ChangedAt))
+		default:
+			b.Open++
+			b.count(sla.Breached(v.DueDate, now))
+		}
+	}
+	for fp := range states.Records {
+		if !seen[fp] {
+			c.Untracked++
+		}
+	}
+
+	c.Total.Severity = "Total"
+	t := &c.Total
+	for _, sev := range vuln.Severities {
+		b := bands[sev]
+		t.Findings += b.Findings
+		t.WithinSLA += b.WithinSLA
+		t.Breached += b.Breached
+		t.RiskAccepted += b.RiskAccepted
+		t.Open += b.Open
+		t.Resolved += b.Resolved
+		t.fixed += b.fixed
+		t.fixDays += b.fixDays
+		b.finish()
+		c.Bands = append(c.Bands, *b)
+	}
+	t.finish()
+	return c
+}
+
+// SetContract marks c as a report on customer's findings in accounts,
+// under a contracted total adherence of target, from 0 to 1, or 0 for
+// none. Every state record belongs to some customer, and records whose
+// finding is not in the input cannot be told apart, so none are counted
+// as untracked.
+func (c *Compliance) SetContract(customer string, accounts []string, target float64) {
+	c.Contract = &Contract{Customer: customer, Accounts: accounts}
+	c.Untracked = 0
+	if target <= 0 {
+		return
+	}
+	c.Contract.TargetAdherence = &target
+	if c.Total.Adherence != nil {
+		met := *c.Total.Adherence >= target
+		c.Contract.TargetMet = &met
+	}
+}
+
+func (b *Band) count(breached bool) {
+	if breached {
+		b.Breached++
+	} else {
+		b.WithinSLA++
+	}
+}
+
+func (b *Band) finish() {
+	if b.fixed > 0 {
+		avg := round2(float64(b.fixDays) / float64(b.fixed))
+		b.AverageDaysToFix = &avg
+	}
+	if n := b.WithinSLA + b.Breached; n > 0 {
+		// To a tenth of a percent.
+		adherence := math.Round(float64(b.WithinSLA)/float64(n)*1000) / 1000
+		b.Adherence = &adherence
+	}
+}
+
+// complianceHeader is the column order of the evidence table.
+var complianceHeader = []string{"Severity", "SLA Days", "Findings", "Within SLA", "Breached", "Risk Accepted", "Open", "Resolved", "Average Days To Fix", "Adherence"}
+
+// cells returns b's row of the evidence table, with "-" for an SLA
+// window, average or adherence it does not have.
+func (b *Band) cells() []string {
+	window, avg, adherence := "-", "-", "-"
+	if b.SLADays > 0 {
+		window = strconv.Itoa(b.SLADays)
+	}
+	if b.AverageDaysToFix != nil {
+		avg = strconv.FormatFloat(*b.AverageDaysToFix, 'f', 1, 64)
+	}
+	if b.Adherence != nil {
+		adherence = strconv.FormatFloat(*b.Adherence*100, 'f', 1, 64) + "%"
+	}
+	return []string{
+		b.Severity, window, strconv.Itoa(b.Findings), strconv.Itoa(b.WithinSLA), strconv.Itoa(b.Breached),
+		strconv.Itoa(b.RiskAccepted), strconv.Itoa(b.Open), strconv.Itoa(b.Resolved), avg, adherence,
+	}
+}
+
+// WriteText writes c as a titled evidence document: the report date and,
+// for a customer, its accounts, an aligned table of the bands and their
+// total, whether a contracted target was met, and the sources.
+func (c *Compliance) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SLA COMPLIANCE REPORT")
+	if c.Contract != nil {
+		fmt.Fprintf(tw, "Customer %s\nAccounts %s\n", c.Contract.Customer, strings.Join(c.Contract.Accounts, ", "))
+	}
+	fmt.Fprintf(tw, "As of %s\n\n", c.AsOf)
+	row := func(cells []string) {
+		for i, cell := range cells {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, cell)
+		}
+		fmt.Fprintln(tw)
+	}
+	row(complianceHeader)
+	for i := range c.Bands {
+		row(c.Bands[i].cells())
+	}
+	row(c.Total.cells())
+	if k := c.Contract; k != nil && k.TargetAdherence != nil {
+		target := strconv.FormatFloat(*k.TargetAdherence*100, 'f', 1, 64) + "%"
+		switch {
+		case k.TargetMet == nil:
+			fmt.Fprintf(tw, "\nContracted adherence %s: no findings to measure.\n", target)
+		case *k.TargetMet:
+			fmt.Fprintf(tw, "\nContracted adherence %s: met.\n", target)
+		default:
+			fmt.Fprintf(tw, "\nContracted adherence %s: missed.\n", target)
+		}
+	}
+	if c.Untracked > 0 {
+		fmt.Fprintf(tw, "\n%d tracked findings are not in the input and are not counted.\n", c.Untracked)
+	}
+	writeEvidence(tw, c.Evidence)
+	return tw.Flush()
+}
+
+// writeEvidence writes the EVIDENCE section of sources, if any, to tw.
+func writeEvidence(tw io.Writer, sources []Source) {
+	if len(sources) == 0 {
+		return
+	}
+	fmt.Fprintln(tw, "\nEVIDENCE")
+	for _, s := range sources {
+		sum := s.SHA256
+		if sum == "" {
+			sum = "-"
+		} else {
+			sum = "sha256:" + sum
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, s.Path, sum, s.Detail)
+	}
+}
+
+// WriteCSV writes c's bands and their total as CSV with a header row.
+func (c *Compliance) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(complianceHeader)
+	for i := range c.Bands {
+		cw.Write(c.Bands[i].cells())
+	}
+	cw.Write(c.Total.cells())
+	cw.Flush()
+	return cw.Error()
+}
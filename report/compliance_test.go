@@ -0,0 +1,69 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestCompliance(t *testing.T) {
+	now := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+	finding := func(id string, sev vuln.Severity, first, due vuln.Date) vuln.Vulnerability {
+		return vuln.Vulnerability{AssetID: "i-0abc", Identifier: id, PackageName: "glibc", Severity: sev, FirstDetectedDate: first, DueDate: due}
+	}
+	vs := []vuln.Vulnerability{
+		finding("CVE-1", vuln.Critical, vuln.NewDate(2025, 1, 1), vuln.NewDate(2025, 1, 16)), // fixed in 10 days
+		finding("CVE-2", vuln.Critical, vuln.NewDate(2025, 1, 1), vuln.NewDate(2025, 1, 16)), // fixed in 20 days, late
+		finding("CVE-3", vuln.Critical, vuln.NewDate(2025, 1, 1), vuln.NewDate(2025, 1, 16)), // open, overdue
+		finding("CVE-4", vuln.Critical, vuln.NewDate(2025, 1, 25), vuln.NewDate(2025, 2, 9)), // open, not yet due
+		finding("CVE-5", vuln.High, vuln.NewDate(2024, 11, 1), vuln.NewDate(2024, 12, 1)),    // risk accepted
+		finding("CVE-6", vuln.Low, vuln.Date{}, vuln.Date{}),                                 // resolved, no dates
+	}
+	states := state.New()
+	resolve := func(i int, to string, at time.Time) {
+		states.Commit(states.Plan(vs[i:i+1], to, "test", "auditor", at))
+	}
+	resolve(0, state.Resolved, time.Date(2025, 1, 11, 15, 0, 0, 0, time.UTC))
+	resolve(1, state.Resolved, time.Date(2025, 1, 21, 9, 0, 0, 0, time.UTC))
+	resolve(4, state.RiskAccepted, now)
+	resolve(5, state.Resolved, now)
+	gone := vuln.Vulnerability{Identifier: "CVE-9", AssetID: "i-0def"}
+	states.Commit(states.Plan([]vuln.Vulnerability{gone}, state.Resolved, "test", "auditor", now))
+
+	c := NewCompliance(vs, states, sla.Policy{vuln.Critical: 15, vuln.High: 30}, now)
+	crit := c.Bands[0]
+	if crit.Severity != "Critical" || crit.SLADays != 15 || crit.Findings != 4 || crit.WithinSLA != 2 || crit.Breached != 2 ||
+		crit.Open != 2 || crit.Resolved != 2 || crit.AverageDaysToFix == nil || *crit.AverageDaysToFix != 15 || *crit.Adherence != 0.5 {
+		t.Errorf("critical band = %+v", crit)
+	}
+	if high := c.Bands[1]; high.RiskAccepted != 1 || high.Adherence != nil || high.AverageDaysToFix != nil {
+		t.Errorf("high band = %+v", high)
+	}
+	if low := c.Bands[3]; low.SLADays != 0 || low.Resolved != 1 || low.WithinSLA != 1 || low.AverageDaysToFix != nil {
+		t.Errorf("low band = %+v", low)
+	}
+	if c.Total.Findings != 6 || c.Total.WithinSLA != 3 || c.Total.Breached != 2 || c.Total.RiskAccepted != 1 || *c.Total.Adherence != 0.6 || c.Untracked != 1 {
+		t.Errorf("total = %+v, untracked %d", c.Total, c.Untracked)
+	}
+
+	var text strings.Builder
+	if err := c.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"As of 2025-02-05", "Critical  15        4         2           2         0              2     2         15.0                 50.0%", "Medium    -         0         0           0         0              0     0         -                    -", "1 tracked findings are not in the input"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text lacks %q:\n%s", want, text.String())
+		}
+	}
+	var csv strings.Builder
+	if err := c.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(csv.String()), "\n"); len(lines) != 6 || lines[5] != "Total,-,6,3,2,1,2,3,15.0,60.0%" {
+		t.Errorf("csv:\n%s", csv.String())
+	}
+}
@@ -0,0 +1,209 @@
+package report
+
+import (
+	"encoding/csv"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Cell aggregates the findings of one package on one asset.
+type Cell struct {
+	Findings    int           `json:"findings"`
+	MaxSeverity vuln.Severity `json:"max_severity"`
+	MaxScore    float64       `json:"max_score"`
+}
+
+func (c *Cell) add(v *vuln.Vulnerability) {
+	if c.Findings == 0 || v.Severity.Rank() < c.MaxSeverity.Rank() {
+		c.MaxSeverity = v.Severity
+	}
+	if c.Findings == 0 || v.PriorityScore > c.MaxScore {
+		c.MaxScore = v.PriorityScore
+	}
+	c.Findings++
+}
+
+// HeatmapRow is one asset's row: its totals across all packages, and one
+// cell per heatmap package, nil where the asset has no findings in it.
+type HeatmapRow struct {
+	Asset string  `json:"asset"`
+	Total Cell    `json:"total"`
+	Cells []*Cell `json:"cells"`
+}
+
+// Heatmap is a matrix of assets by their most affected packages.
+type Heatmap struct {
+	Packages []string     `json:"packages"`
+	Rows     []HeatmapRow `json:"rows"`
+	// Summaries, when set, head the HTML page as its executive summary.
+	Summaries []Summary `json:"summaries,omitempty"`
+}
+
+// NewHeatmap builds the heatmap of vs with the top packages, by finding
+// count, as columns, or every package when top is 0. Rows are ordered by
+// descending maximum score, then finding count, so hot spots come first.
+// Findings are keyed by asset name, or asset ID when the name is blank;
+// vs must already be scored.
+func NewHeatmap(vs []vuln.Vulnerability, top int) *Heatmap {
+	counts := make(map[string]int)
+	for i := range vs {
+		counts[vs[i].PackageName]++
+	}
+	pkgs := make([]string, 0, len(counts))
+	for p := range counts {
+		pkgs = append(pkgs, p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool {
+		if counts[pkgs[i]] != counts[pkgs[j]] {
+			return counts[pkgs[i]] > counts[pkgs[j]]
+		}
+		return pkgs[i] < pkgs[j]
+	})
+	if top > 0 && len(pkgs) > top {
+		pkgs = pkgs[:top]
+	}
+	col := make(map[string]int, len(pkgs))
+	for i, p := range pkgs {
+		col[p] = i
+	}
+
+	h := &Heatmap{Packages: pkgs}
+	index := make(map[string]int)
+	for i := range vs {
+		v := &vs[i]
+		asset := v.AssetName
+		if asset == "" {
+			asset = v.AssetID
+		}
+		r, ok := index[asset]
+		if !ok {
+			r = len(h.Rows)
+			index[asset] = r
+			h.Rows = append(h.Rows, HeatmapRow{Asset: asset, Cells: make([]*Cell, len(pkgs))})
+		}
+		row := &h.Rows[r]
+		row.Total.add(v)
+		if c, ok := col[v.PackageName]; ok {
+			if row.Cells[c] == nil {
+				row.Cells[c] = &Cell{}
+			}
+			row.Cells[c].add(v)
+		}
+	}
+	sort.SliceStable(h.Rows, func(i, j int) bool {
+		a, b := &h.Rows[i].Total, &h.Rows[j].Total
+		if a.MaxScore != b.MaxScore {
+			return a.MaxScore > b.MaxScore
+		}
+		if a.Findings != b.Findings {
+			return a.Findings > b.Findings
+		}
+		return h.Rows[i].Asset < h.Rows[j].Asset
+	})
+	return h
+}
+
+// WriteCSV writes h with a row per asset: its finding count and maximum
+// score across all packages, then the maximum score in each package
+// column, blank where the asset has no findings in the package.
+func (h *Heatmap) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"Asset", "Findings", "Max Score"}, h.Packages...)); err != nil {
+		return err
+	}
+	for _, row := range h.Rows {
+		rec := []string{row.Asset, strconv.Itoa(row.Total.Findings), formatScore(row.Total.MaxScore)}
+		for _, c := range row.Cells {
+			s := ""
+			if c != nil {
+				s = formatScore(c.MaxScore)
+			}
+			rec = append(rec, s)
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// severityColors are the cell backgrounds of the HTML heatmap, by maximum
+// severity.
+var severityColors = map[vuln.Severity]string{
+	vuln.Critical: "#b2182b",
+	vuln.High:     "#ef8a62",
+	vuln.Medium:   "#fddbc7",
+	vuln.Low:      "#f7f7f7",
+}
+
+// heatmapHTML is cloned for each page, so its color and formatting
+// functions can be replaced with the theme's.
+var heatmapHTML = template.Must(template.New("heatmap").Funcs(template.FuncMap{
+	"color":     func(c *Cell) template.CSS { return pageTheme{}.color(c.MaxSeverity) },
+	"score":     pageTheme{}.score,
+	"summaries": SummariesHTML,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Theme.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; font-size: 12px; }
+th, td { border: 1px solid #ccc; padding: 4px 6px; text-align: center; }
+th.pkg { writing-mode: vertical-rl; transform: rotate(180deg); white-space: nowrap; }
+th.asset { text-align: left; white-space: nowrap; }
+.legend span { display: inline-block; padding: 2px 8px; margin-right: 4px; border: 1px solid #ccc; }
+.summaries p { max-width: 50em; }
+{{.Theme.CSS}}</style>
+</head>
+<body>
+{{with .Theme.Logo}}<img class="logo" src="{{.}}" alt="">
+{{end}}<h1>{{.Theme.Title}}</h1>
+{{with .Summaries}}{{summaries .}}{{end}}<p class="legend">Cells show the highest priority score, colored by the highest severity:
+{{range .Legend}}<span style="background: {{.Color}}">{{.Severity}}</span>{{end}}</p>
+<table>
+<tr><th>Asset</th><th>Findings</th><th>Max score</th>{{range .Packages}}<th class="pkg">{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr><th class="asset">{{.Asset}}</th><td>{{.Total.Findings}}</td><td style="background: {{color .Total}}">{{score .Total.MaxScore}}</td>
+{{- range .Cells}}{{if .}}<td style="background: {{color .}}" title="{{.Findings}} findings, max {{.MaxSeverity}}">{{score .MaxScore}}</td>{{else}}<td></td>{{end}}{{end}}</tr>
+{{end}}</table>
+{{with .Theme.Footer}}<footer>{{.}}</footer>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes h as a self-contained HTML page, with cells colored by
+// the maximum severity and showing the maximum score, after h's summaries
+// if it has any. A theme, when t is not nil, brands the page and formats
+// its scores.
+func (h *Heatmap) WriteHTML(w io.Writer, t *theme.Theme) error {
+	pt := newPageTheme(t, "Vulnerability heatmap")
+	type legend struct {
+		Severity vuln.Severity
+		Color    template.CSS
+	}
+	var l []legend
+	for _, sev := range vuln.Severities {
+		l = append(l, legend{sev, pt.color(sev)})
+	}
+	page, err := heatmapHTML.Clone()
+	if err != nil {
+		return err
+	}
+	color := func(c *Cell) template.CSS { return pt.color(c.MaxSeverity) }
+	return page.Funcs(template.FuncMap{"color": color, "score": pt.score, "summaries": pt.summaries}).Execute(w, struct {
+		*Heatmap
+		Legend []legend
+		Theme  pageTheme
+	}{h, l, pt})
+}
+
+func formatScore(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+var fleet = []vuln.Vulnerability{
+	{AssetName: "match-v1", PackageName: "linux", Severity: vuln.High, PriorityScore: 6.2},
+	{AssetName: "match-v1", PackageName: "linux", Severity: vuln.Medium, PriorityScore: 7.5},
+	{AssetName: "match-v1", PackageName: "curl", Severity: vuln.Low, PriorityScore: 3},
+	{AssetName: "match-v1", PackageName: "expat", Severity: vuln.Critical, PriorityScore: 8.1},
+	{AssetName: "hasura", PackageName: "curl", Severity: vuln.Critical, PriorityScore: 9},
+	{AssetID: "i-0abc", PackageName: "npm-ws", Severity: vuln.High, PriorityScore: 9},
+}
+
+func TestHeatmap(t *testing.T) {
+	h := NewHeatmap(fleet, 2)
+	if !reflect.DeepEqual(h.Packages, []string{"curl", "linux"}) {
+		t.Fatalf("packages = %v, want the two with most findings", h.Packages)
+	}
+	var assets []string
+	for _, r := range h.Rows {
+		assets = append(assets, r.Asset)
+	}
+	// hasura and i-0abc tie on max score 9; the blank name falls back to
+	// the asset ID.
+	if !reflect.DeepEqual(assets, []string{"hasura", "i-0abc", "match-v1"}) {
+		t.Fatalf("rows = %v", assets)
+	}
+	m := h.Rows[2]
+	if m.Total != (Cell{Findings: 4, MaxSeverity: vuln.Critical, MaxScore: 8.1}) {
+		t.Errorf("match-v1 total = %+v; it includes packages outside the columns", m.Total)
+	}
+	// The max severity and max score of a cell may come from different
+	// findings.
+	if *m.Cells[1] != (Cell{Findings: 2, MaxSeverity: vuln.High, MaxScore: 7.5}) {
+		t.Errorf("match-v1 linux = %+v", *m.Cells[1])
+	}
+	if h.Rows[1].Cells[0] != nil {
+		t.Errorf("i-0abc curl = %+v, want nil", h.Rows[1].Cells[0])
+	}
+
+	var buf strings.Builder
+	if err := h.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Asset", "Findings", "Max Score", "curl", "linux"},
+		{"hasura", "1", "9.00", "9.00", ""},
+		{"i-0abc", "1", "9.00", "", ""},
+		{"match-v1", "4", "8.10", "3.00", "7.50"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("CSV = %q", rows)
+	}
+}
+
+func TestHeatmapHTML(t *testing.T) {
+	vs := append([]vuln.Vulnerability{{AssetName: "<script>x</script>", PackageName: "curl", Severity: vuln.Low}}, fleet...)
+	var buf strings.Builder
+	if err := NewHeatmap(vs, 0).WriteHTML(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	if strings.Contains(page, "<script>") {
+		t.Error("asset name is not escaped")
+	}
+	if !strings.Contains(page, `<td style="background: #b2182b" title="1 findings, max Critical">9.00</td>`) {
+		t.Errorf("no Critical cell for hasura curl:\n%s", page)
+	}
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// WriteMarkdown writes a short Markdown summary of vs on the reference
+// date now, for a pull request comment or wiki page: the findings and SLA
+// breaches by severity, the top highest-priority findings, and the top
+// most overdue findings. vs must already be scored and sorted, as
+// scoring.Prioritize leaves them.
+func WriteMarkdown(w io.Writer, vs []vuln.Vulnerability, top int, now time.Time) error {
+	o := NewOverview(vs, top, now)
+	breached := make(map[vuln.Severity]int)
+	var overdue []*vuln.Vulnerability
+	for i := range vs {
+		if v := &vs[i]; sla.Breached(v.DueDate, now) {
+			breached[v.Severity]++
+			overdue = append(overdue, v)
+		}
+	}
+	sort.SliceStable(overdue, func(i, j int) bool { return overdue[i].DueDate.Before(overdue[j].DueDate.Time) })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Vulnerability summary\n\n")
+	fmt.Fprintf(&b, "As of %s: **%s** on %s", o.AsOf, plural(o.Total.Findings, "finding"), plural(len(o.Assets), "asset"))
+	if len(o.Tiers) > 0 && o.Tiers[0].Count > 0 {
+		fmt.Fprintf(&b, ", %d to remediate within %d days (%s)", o.Tiers[0].Count, o.Tiers[0].WithinDays, o.Tiers[0].Name)
+	}
+	b.WriteString(".\n\n")
+	b.WriteString("| Severity | Findings | Past due |\n| --- | ---: | ---: |\n")
+	for _, c := range o.Total.Severities {
+		fmt.Fprintf(&b, "| %s | %d | %d |\n", c.Severity, c.Count, breached[c.Severity])
+	}
+	fmt.Fprintf(&b, "| **Total** | **%d** | **%d** |\n", o.Total.Findings, len(overdue))
+
+	if len(o.Top) > 0 {
+		fmt.Fprintf(&b, "\n### Top %d priorities\n\n", len(o.Top))
+		b.WriteString("| Score | Timeframe | Identifier | Severity | Asset | Package | Fixed version |\n| ---: | --- | --- | --- | --- | --- | --- |\n")
+		for i := range o.Top {
+			v := &o.Top[i]
+			id := mdCell(v.Identifier)
+			if v.KEV {
+				id += " (KEV)"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", formatScore(v.PriorityScore), v.ActionTimeframe, id, v.Severity,
+				mdCell(ByAsset(v)), mdCell(v.PackageName), mdCell(v.FixedVersion))
+		}
+	}
+
+	b.WriteString("\n### SLA breaches\n\n")
+	if len(overdue) == 0 {
+		b.WriteString("No finding is past its due date.\n")
+	} else {
+		if len(overdue) == 1 {
+			b.WriteString("1 finding is past its due date")
+		} else {
+			fmt.Fprintf(&b, "%d findings are past their due date", len(overdue))
+		}
+		if len(overdue) > top {
+			fmt.Fprintf(&b, "; the %d most overdue", top)
+			overdue = overdue[:top]
+		}
+		b.WriteString(":\n\n| Due | Days overdue | Identifier | Severity | Asset | Package |\n| --- | ---: | --- | --- | --- | --- |\n")
+		for _, v := range overdue {
+			fmt.Fprintf(&b, "| %s | %d | %s | %s | %s | %s |\n", v.DueDate, -v.DueDate.DaysUntil(now), mdCell(v.Identifier), v.Severity,
+				mdCell(ByAsset(v)), mdCell(v.PackageName))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mdEscaper escapes text for a Markdown table cell: the column separator,
+// and the characters GitHub would read as HTML or formatting.
+var mdEscaper = strings.NewReplacer(
+	"|", `\|`, `\`, `\\`, "<", "&lt;", ">", "&gt;", "&", "&amp;",
+	"*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+	"\r\n", " ", "\n", " ",
+)
+
+// mdCell escapes s for a Markdown table cell, or "-" when it is empty.
+func mdCell(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return mdEscaper.Replace(s)
+}
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	now := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{
+		{AssetName: "hasura", Identifier: "CVE-2024-3094", PackageName: "xz-utils", Severity: vuln.Critical, PriorityScore: 9.5, ActionTimeframe: "Immediate", KEV: true, FixedVersion: "5.6.1", DueDate: vuln.NewDate(2025, 2, 1)},
+		{AssetName: "match-v1", Identifier: "CVE-2018-25011", PackageName: "lib|webp", Severity: vuln.Critical, PriorityScore: 8.55, ActionTimeframe: "Immediate", DueDate: vuln.NewDate(2025, 1, 1)},
+		{AssetName: "hasura", Identifier: "CVE-2023-4911", PackageName: "glibc", Severity: vuln.High, PriorityScore: 7.1, ActionTimeframe: "Urgent", DueDate: vuln.NewDate(2025, 3, 1)},
+	}
+	var b strings.Builder
+	if err := WriteMarkdown(&b, vs, 1, now); err != nil {
+		t.Fatal(err)
+	}
+	md := b.String()
+	for _, want := range []string{
+		"As of 2025-02-05: **3 findings** on 2 assets, 2 to remediate within 2 days (Immediate).\n",
+		"| Critical | 2 | 2 |\n| High | 1 | 0 |\n",
+		"| **Total** | **3** | **2** |\n",
+		"### Top 1 priorities\n",
+		"| 9.50 | Immediate | CVE-2024-3094 (KEV) | Critical | hasura | xz-utils | 5.6.1 |\n",
+		"2 findings are past their due date; the 1 most overdue:\n",
+		"| 2025-01-01 | 35 | CVE-2018-25011 | Critical | match-v1 | lib\\|webp |\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, md)
+		}
+	}
+
+	b.Reset()
+	if err := WriteMarkdown(&b, vs[2:], 10, now); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(b.String(), "### SLA breaches\n\nNo finding is past its due date.\n") || strings.Contains(b.String(), "to remediate within") {
+		t.Errorf("markdown without breaches:\n%s", b.String())
+	}
+}
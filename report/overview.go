@@ -0,0 +1,211 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Overview is the content of the HTML report: a summary of all findings,
+// the highest-priority ones and a breakdown per asset.
+type Overview struct {
+	AsOf vuln.Date `json:"as_of"`
+	// Total summarises every finding, as "The fleet".
+	Total Summary `json:"total"`
+	// Tiers counts the findings in each action timeframe, most urgent
+	// first.
+	Tiers []TierCount `json:"tiers"`
+	// Top are the highest-priority findings.
+	Top []vuln.Vulnerability `json:"top"`
+	// Assets summarises each asset, ordered as Summaries orders them.
+	Assets []Summary `json:"assets"`
+}
+
+// TierCount is the number of findings in one action timeframe.
+type TierCount struct {
+	Name       string `json:"name"`
+	WithinDays int    `json:"within_days"`
+	Count      int    `json:"count"`
+}
+
+// NewOverview builds the overview of vs, with its top highest-priority
+// findings, on the reference date now. vs must already be scored and
+// sorted, as scoring.Prioritize leaves them.
+func NewOverview(vs []vuln.Vulnerability, top int, now time.Time) *Overview {
+	o := &Overview{AsOf: vuln.NewDate(now.Year(), now.Month(), now.Day())}
+	all := make([]*vuln.Vulnerability, len(vs))
+	counts := make(map[string]int)
+	for i := range vs {
+		all[i] = &vs[i]
+		counts[vs[i].ActionTimeframe]++
+	}
+	o.Total = summarize("The fleet", all, topRisksPerAsset, now)
+	for _, t := range scoring.DefaultTiers {
+		o.Tiers = append(o.Tiers, TierCount{t.Name, t.WithinDays, counts[t.Name]})
+	}
+	o.Top = vs[:min(top, len(vs))]
+	o.Assets = Summaries(vs, ByAsset, topRisksPerAsset, now)
+	return o
+}
+
+// topRisksPerAsset is how many top risks each summary of the overview
+// names.
+const topRisksPerAsset = 3
+
+// overviewHTML is cloned for each page, so its color and formatting
+// functions can be replaced with the theme's.
+var overviewHTML = template.Must(template.New("overview").Funcs(template.FuncMap{
+	"color":     pageTheme{}.color,
+	"score":     pageTheme{}.score,
+	"date":      pageTheme{}.date,
+	"narrative": pageTheme{}.narrative,
+	"segments":  segments,
+	"maxFindings": func(ss []Summary) int {
+		m := 0
+		for _, s := range ss {
+			m = max(m, s.Findings)
+		}
+		return m
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Theme.Title}} {{date .AsOf}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; font-size: 13px; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+td.n { text-align: right; }
+p { max-width: 50em; }
+svg text { font-size: 12px; }
+details { margin-bottom: 0.5em; }
+{{.Theme.CSS}}</style>
+</head>
+<body>
+{{with .Theme.Logo}}<img class="logo" src="{{.}}" alt="">
+{{end}}<h1>{{.Theme.Title}}</h1>
+<p>As of {{date .AsOf}}. {{narrative .Total}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Findings</th><td class="n">{{.Total.Findings}}</td></tr>
+<tr><th>Fixable</th><td class="n">{{.Total.Fixable}}</td></tr>
+<tr><th>Known exploited</th><td class="n">{{.Total.KEV}}</td></tr>
+<tr><th>Past due date</th><td class="n">{{.Total.Overdue}}</td></tr>
+<tr><th>Assets</th><td class="n">{{len .Assets}}</td></tr>
+</table>
+<table>
+<tr><th>Action timeframe</th><th>Remediate within</th><th>Findings</th></tr>
+{{range .Tiers}}<tr><td>{{.Name}}</td><td class="n">{{.WithinDays}} days</td><td class="n">{{.Count}}</td></tr>
+{{end}}</table>
+
+<h2>Severity distribution</h2>
+<svg role="img" aria-label="Findings by severity" width="{{.Chart.Width}}" height="{{.Chart.Height}}">
+{{range .Chart.Bars}}<text x="0" y="{{.Y}}" dy="14">{{.Severity}}</text>
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="18" style="fill: {{color .Severity}}; stroke: #999"><title>{{.Count}} {{.Severity}}</title></rect>
+<text x="{{.End}}" y="{{.Y}}" dx="6" dy="14">{{.Count}}</text>
+{{end}}</svg>
+
+<h2>Top {{len .Top}} priority findings</h2>
+<table>
+<tr><th>Score</th><th>Timeframe</th><th>Identifier</th><th>Severity</th><th>Asset</th><th>Package</th><th>Installed</th><th>Fixed</th><th>Due</th></tr>
+{{range .Top}}<tr><td class="n">{{score .PriorityScore}}</td><td>{{.ActionTimeframe}}</td><td>{{.Identifier}}{{if .KEV}} (KEV){{end}}</td><td style="background: {{color .Severity}}">{{.Severity}}</td><td>{{if .AssetName}}{{.AssetName}}{{else}}{{.AssetID}}{{end}}</td><td>{{.PackageName}}</td><td>{{.InstalledVersion}}</td><td>{{.FixedVersion}}</td><td>{{date .DueDate}}</td></tr>
+{{end}}</table>
+
+<h2>Assets</h2>
+{{$most := maxFindings .Assets}}<table>
+<tr><th>Asset</th><th>Findings</th><th>Fixable</th><th>Past due</th><th>Top score</th><th>By severity</th></tr>
+{{range .Assets}}<tr><td>{{.Key}}</td><td class="n">{{.Findings}}</td><td class="n">{{.Fixable}}</td><td class="n">{{.Overdue}}</td><td class="n">{{with .TopRisks}}{{score (index . 0).PriorityScore}}{{end}}</td>
+<td><svg role="img" aria-label="{{.Key}} findings by severity" width="{{$.Chart.BarWidth}}" height="12">{{range segments .Severities $most}}<rect x="{{.X}}" y="0" width="{{.Width}}" height="12" style="fill: {{color .Severity}}; stroke: #999"><title>{{.Count}} {{.Severity}}</title></rect>{{end}}</svg></td></tr>
+{{end}}</table>
+{{range .Assets}}<details>
+<summary>{{.Key}}</summary>
+<p>{{narrative .}}</p>
+</details>
+{{end}}{{with .Theme.Footer}}<footer>{{.}}</footer>
+{{end}}</body>
+</html>
+`))
+
+// chart is the layout of the severity distribution: a bar per severity,
+// after its label, with the count after the bar.
+type chart struct {
+	Width, Height, BarWidth int
+	Bars                    []bar
+}
+
+// bar is one severity's bar of the chart, or its part of a stacked bar.
+type bar struct {
+	SeverityCount
+	X, Y, Width int
+}
+
+// End is where the bar ends.
+func (b bar) End() int { return b.X + b.Width }
+
+// Chart dimensions, in pixels: the label column, the longest bar and the
+// height of a bar's row.
+const (
+	labelWidth = 70
+	chartWidth = 400
+	rowHeight  = 24
+)
+
+// bars lays out the severity distribution of counts, the longest bar
+// showing the highest count.
+func bars(counts []SeverityCount) chart {
+	c := chart{Width: labelWidth + chartWidth + 60, Height: rowHeight * len(counts), BarWidth: chartWidth}
+	most := 0
+	for _, n := range counts {
+		most = max(most, n.Count)
+	}
+	for i, n := range counts {
+		c.Bars = append(c.Bars, bar{n, labelWidth, i * rowHeight, scale(n.Count, most)})
+	}
+	return c
+}
+
+// segments lays out a stacked bar of the severity counts, scaled so that
+// most findings fill the chart width.
+func segments(counts []SeverityCount, most int) []bar {
+	var bs []bar
+	x := 0
+	for _, n := range counts {
+		if w := scale(n.Count, most); w > 0 {
+			bs = append(bs, bar{n, x, 0, w})
+			x += w
+		}
+	}
+	return bs
+}
+
+// scale returns the length of a bar for n of at most most.
+func scale(n, most int) int {
+	if most == 0 {
+		return 0
+	}
+	return n * chartWidth / most
+}
+
+// WriteHTML writes o as a self-contained HTML page, with inline styles
+// and SVG charts and no external resources, so that it can be shared as
+// one file. A theme, when t is not nil, brands the page and formats its
+// dates and scores.
+func (o *Overview) WriteHTML(w io.Writer, t *theme.Theme) error {
+	pt := newPageTheme(t, "Vulnerability report")
+	page, err := overviewHTML.Clone()
+	if err != nil {
+		return err
+	}
+	funcs := template.FuncMap{"color": pt.color, "score": pt.score, "date": pt.date, "narrative": pt.narrative}
+	return page.Funcs(funcs).Execute(w, struct {
+		*Overview
+		Chart chart
+		Theme pageTheme
+	}{o, bars(o.Total.Severities), pt})
+}
@@ -0,0 +1,105 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestOverview(t *testing.T) {
+	now := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{
+		{AssetName: "hasura", Identifier: "CVE-2024-3094", PackageName: "xz-utils", Severity: vuln.Critical, PriorityScore: 9.5, ActionTimeframe: "Immediate", KEV: true, FixedVersion: "5.6.1"},
+		{AssetName: "match-v1", Identifier: "CVE-2018-25011", PackageName: "libwebp", Severity: vuln.Critical, PriorityScore: 8.55, ActionTimeframe: "Immediate"},
+		{AssetName: "hasura", Identifier: "CVE-2023-4911", PackageName: "glibc", Severity: vuln.High, PriorityScore: 7.1, ActionTimeframe: "Urgent", DueDate: vuln.NewDate(2025, 1, 1)},
+		{AssetName: "hasura", Identifier: "CVE-2022-3996", PackageName: "<openssl>", Severity: vuln.Low, PriorityScore: 2, ActionTimeframe: "Planned"},
+	}
+	o := NewOverview(vs, 2, now)
+	if len(o.Top) != 2 || o.Top[1].Identifier != "CVE-2018-25011" || len(o.Assets) != 2 || o.Assets[0].Key != "hasura" {
+		t.Fatalf("overview = %+v", o)
+	}
+	if o.Total.Findings != 4 || o.Total.Overdue != 1 || o.Tiers[0].Name != "Immediate" || o.Tiers[0].Count != 2 {
+		t.Errorf("total %+v, tiers %+v", o.Total, o.Tiers)
+	}
+	if !strings.HasPrefix(o.Total.Narrative, "The fleet has 4 findings: 2 critical, 1 high and 1 low.") {
+		t.Errorf("narrative = %s", o.Total.Narrative)
+	}
+	if got := segments(o.Assets[0].Severities, 3); len(got) != 3 || got[2].X != 266 || got[2].End() != 399 {
+		t.Errorf("hasura segments = %+v", got)
+	}
+
+	var page strings.Builder
+	if err := o.WriteHTML(&page, nil); err != nil {
+		t.Fatal(err)
+	}
+	html := page.String()
+	for _, want := range []string{
+		"<title>Vulnerability report 2025-02-05</title>",
+		`<rect x="70" y="0" width="400" height="18" style="fill: #b2182b; stroke: #999"><title>2 Critical</title></rect>`,
+		`<rect x="70" y="48" width="0" height="18"`,
+		"<h2>Top 2 priority findings</h2>",
+		"<td>CVE-2024-3094 (KEV)</td>",
+		"<summary>match-v1</summary>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	if strings.Contains(html, "<openssl>") || strings.Contains(html, "<script") || strings.Contains(html, "http") {
+		t.Errorf("page is not escaped or not self-contained:\n%s", html)
+	}
+}
+
+func TestOverviewTheme(t *testing.T) {
+	vs := []vuln.Vulnerability{{AssetName: "hasura", Identifier: "CVE-2024-3094", Severity: vuln.Critical, PriorityScore: 9.5, ActionTimeframe: "Immediate"}}
+	var page strings.Builder
+	if err := NewOverview(vs, 1, time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)).WriteHTML(&page, loadTheme(t)); err != nil {
+		t.Fatal(err)
+	}
+	html := page.String()
+	for _, want := range []string{
+		"<title>Acme posture report 2025-02-05</title>",
+		"<h1>Acme posture report</h1>",
+		"body { font-family: Georgia, serif; }",
+		"th { background: #1F3864; color: #FFFFFF; }",
+		`<img class="logo" src="data:image/png;base64,iVBOR`,
+		`<td style="background: #FF0000">Critical</td>`,
+		"<footer>Confidential (Acme)</footer>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("themed page lacks %q", want)
+		}
+	}
+}
+
+func TestOverviewLocale(t *testing.T) {
+	vs := []vuln.Vulnerability{{AssetName: "hasura", Identifier: "CVE-2023-4911", PackageName: "glibc", Severity: vuln.High, PriorityScore: 7.1, ActionTimeframe: "Urgent", DueDate: vuln.NewDate(2025, 1, 1)}}
+	th := &theme.Theme{}
+	if err := th.SetLocale("de-DE"); err != nil {
+		t.Fatal(err)
+	}
+	o := NewOverview(vs, 1, time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC))
+	var page strings.Builder
+	if err := o.WriteHTML(&page, th); err != nil {
+		t.Fatal(err)
+	}
+	html := page.String()
+	for _, want := range []string{
+		"<title>Vulnerability report 05.02.2025</title>",
+		"<p>As of 05.02.2025. The fleet has 1 finding",
+		"(score 7,10, Urgent)",
+		`<td class="n">7,10</td>`,
+		"<td>01.01.2025</td>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	// The JSON a program reads keeps the canonical formats.
+	if !strings.Contains(o.Total.Narrative, "(score 7.10, Urgent)") {
+		t.Errorf("narrative = %s", o.Total.Narrative)
+	}
+}
@@ -0,0 +1,567 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // logo formats
+	_ "image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// PDF page geometry, in points: A4 portrait, its margins, and where the
+// footer goes.
+const (
+	pdfWidth   = 595.0
+	pdfHeight  = 842.0
+	pdfMargin  = 40.0
+	pdfFooterY = pdfHeight - 25
+	pdfContent = pdfWidth - 2*pdfMargin
+)
+
+// Default PDF colours, where a theme sets none: the table headers, as in
+// the XLSX workbook, their borders and secondary text.
+const (
+	pdfHeaderFill = "#D9D9D9"
+	pdfBorder     = "#CCCCCC"
+	pdfGrey       = "#666666"
+)
+
+// WritePDF writes o as a paged PDF document, A4 portrait, with the
+// sections of the HTML report: the summary, the action timeframes, the
+// severity distribution, the top findings and the assets, tables
+// continuing on the next page with their header. A theme, when t is not
+// nil, sets the title, the logo, the footer, the header and severity
+// colours, and the locale of dates and scores.
+func (o *Overview) WritePDF(w io.Writer, t *theme.Theme) error {
+	d := &pdfDoc{theme: t, title: "Vulnerability report", header: pdfHeaderFill, headerText: "#000000", heading: "#000000"}
+	if t != nil {
+		if t.Title != "" {
+			d.title = t.Title
+		}
+		if t.Primary != "" {
+			d.header, d.headerText, d.heading = t.Primary, t.PrimaryText, t.Primary
+		}
+	}
+	if logo := t.LogoImage(); logo != nil {
+		img, err := pdfImage(logo)
+		if err != nil {
+			return fmt.Errorf("logo: %w", err)
+		}
+		d.logo = img
+	}
+	d.newPage()
+
+	// The title block, beside the logo.
+	x := pdfMargin
+	if d.logo != nil {
+		lw := 36 * float64(d.logo.width) / float64(max(d.logo.height, 1))
+		d.image(pdfMargin, d.y, lw, 36)
+		x += lw + 12
+	}
+	d.text(x, d.y+18, 18, true, d.heading, d.title)
+	loc := t.Formats()
+	d.text(x, d.y+32, 10, false, pdfGrey, "As of "+loc.Date(o.AsOf))
+	d.y += 48
+	d.paragraph(narrativeIn(&o.Total, loc), 10)
+
+	d.section("Summary")
+	d.table([]pdfColumn{{"", 120, false}, {"", 80, true}}, [][]pdfCell{
+		{{Text: "Findings", Bold: true}, {Text: strconv.Itoa(o.Total.Findings)}},
+		{{Text: "Fixable", Bold: true}, {Text: strconv.Itoa(o.Total.Fixable)}},
+		{{Text: "Known exploited", Bold: true}, {Text: strconv.Itoa(o.Total.KEV)}},
+		{{Text: "Past due date", Bold: true}, {Text: strconv.Itoa(o.Total.Overdue)}},
+		{{Text: "Assets", Bold: true}, {Text: strconv.Itoa(len(o.Assets))}},
+	})
+	tiers := make([][]pdfCell, len(o.Tiers))
+	for i, tc := range o.Tiers {
+		tiers[i] = []pdfCell{{Text: tc.Name}, {Text: strconv.Itoa(tc.WithinDays) + " days"}, {Text: strconv.Itoa(tc.Count)}}
+	}
+	d.table([]pdfColumn{{"Action timeframe", 120, false}, {"Remediate within", 100, true}, {"Findings", 80, true}}, tiers)
+
+	d.section("Severity distribution")
+	most := 0
+	for _, c := range o.Total.Severities {
+		most = max(most, c.Count)
+	}
+	for _, c := range o.Total.Severities {
+		d.need(18)
+		d.text(pdfMargin, d.y+10, 9, false, "#000000", string(c.Severity))
+		bw := 0.0
+		if most > 0 {
+			bw = 300 * float64(c.Count) / float64(most)
+		}
+		d.rect(pdfMargin+60, d.y, bw, 12, d.severity(c.Severity), "#999999")
+		d.text(pdfMargin+60+bw+6, d.y+10, 9, false, "#000000", strconv.Itoa(c.Count))
+		d.y += 18
+	}
+
+	d.section(fmt.Sprintf("Top %d priority findings", len(o.Top)))
+	top := make([][]pdfCell, len(o.Top))
+	for i := range o.Top {
+		v := &o.Top[i]
+		id := v.Identifier
+		if v.KEV {
+			id += " (KEV)"
+		}
+		asset := v.AssetName
+		if asset == "" {
+			asset = v.AssetID
+		}
+		top[i] = []pdfCell{
+			{Text: loc.Float(v.PriorityScore, 2)}, {Text: v.ActionTimeframe}, {Text: id},
+			{Text: string(v.Severity), Fill: d.severity(v.Severity)},
+			{Text: asset}, {Text: v.PackageName}, {Text: v.FixedVersion}, {Text: loc.Date(v.DueDate)},
+		}
+	}
+	d.table([]pdfColumn{
+		{"Score", 34, true}, {"Timeframe", 56, false}, {"Identifier", 96, false}, {"Severity", 48, false},
+		{"Asset", 90, false}, {"Package", 80, false}, {"Fixed", 61, false}, {"Due", 50, false},
+	}, top)
+
+	d.section("Assets")
+	mostAsset := 0
+	for _, s := range o.Assets {
+		mostAsset = max(mostAsset, s.Findings)
+	}
+	assets := make([][]pdfCell, len(o.Assets))
+	for i, s := range o.Assets {
+		score := ""
+		if len(s.TopRisks) > 0 {
+			score = loc.Float(s.TopRisks[0].PriorityScore, 2)
+		}
+		assets[i] = []pdfCell{
+			{Text: s.Key}, {Text: strconv.Itoa(s.Findings)}, {Text: strconv.Itoa(s.Fixable)}, {Text: strconv.Itoa(s.Overdue)},
+			{Text: score}, {Bars: s.Severities, Most: mostAsset},
+		}
+	}
+	d.table([]pdfColumn{
+		{"Asset", 175, false}, {"Findings", 55, true}, {"Fixable", 55, true}, {"Past due", 55, true},
+		{"Top score", 55, true}, {"By severity", 120, false},
+	}, assets)
+
+	footer := ""
+	if t != nil {
+		footer = t.Footer
+	}
+	for i, p := range d.pages {
+		d.page = p
+		d.text(pdfMargin, pdfFooterY, 8, false, pdfGrey, fitText(footer, pdfContent-80, 8, false))
+		n := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		d.text(pdfWidth-pdfMargin-textWidth(n, 8, false), pdfFooterY, 8, false, pdfGrey, n)
+	}
+	return d.write(w)
+}
+
+// pdfDoc lays out a document's pages. Positions are from the top left of
+// the page, as a reader sees it, and turned upside down as PDF draws
+// them.
+type pdfDoc struct {
+	theme                       *theme.Theme
+	title                       string
+	header, headerText, heading string // #RRGGBB colours
+	logo                        *pdfXObject
+	pages                       []*bytes.Buffer
+	page                        *bytes.Buffer
+	y                           float64 // the top of what comes next
+}
+
+func (d *pdfDoc) newPage() {
+	d.page = new(bytes.Buffer)
+	d.pages = append(d.pages, d.page)
+	d.y = pdfMargin
+}
+
+// need starts a new page unless h more points fit on this one, and
+// reports whether it did.
+func (d *pdfDoc) need(h float64) bool {
+	if d.y+h <= pdfFooterY-12 {
+		return false
+	}
+	d.newPage()
+	return true
+}
+
+// severity returns the colour of findings of sev, as the HTML report
+// shows them.
+func (d *pdfDoc) severity(sev vuln.Severity) string {
+	return d.theme.Severity(sev, severityColors[sev])
+}
+
+func (d *pdfDoc) text(x, y, size float64, bold bool, c, s string) {
+	if s == "" {
+		return
+	}
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT /%s %s Tf %s rg %s %s Td (%s) Tj ET\n", font, num(size), rgb(c), num(x), num(pdfHeight-y), pdfString(s))
+}
+
+// rect draws a rectangle filled with fill, and outlined with stroke
+// unless it is "".
+func (d *pdfDoc) rect(x, y, w, h float64, fill, stroke string) {
+	if w <= 0 {
+		return
+	}
+	op := "f"
+	if stroke != "" {
+		fmt.Fprintf(d.page, "0.5 w %s RG ", rgb(stroke))
+		op = "B"
+	}
+	fmt.Fprintf(d.page, "%s rg %s %s %s %s re %s\n", rgb(fill), num(x), num(pdfHeight-y-h), num(w), num(h), op)
+}
+
+func (d *pdfDoc) image(x, y, w, h float64) {
+	fmt.Fprintf(d.page, "q %s 0 0 %s %s %s cm /Im1 Do Q\n", num(w), num(h), num(x), num(pdfHeight-y-h))
+}
+
+// section starts a section with its heading, on a new page unless the
+// heading and a few lines fit on this one.
+func (d *pdfDoc) section(heading string) {
+	d.y += 10
+	d.need(60)
+	d.text(pdfMargin, d.y+14, 14, true, d.heading, heading)
+	d.y += 22
+}
+
+// paragraph writes s wrapped to the content width.
+func (d *pdfDoc) paragraph(s string, size float64) {
+	for _, line := range wrapText(s, pdfContent, size) {
+		d.need(size + 4)
+		d.text(pdfMargin, d.y+size, size, false, "#000000", line)
+		d.y += size + 4
+	}
+	d.y += 4
+}
+
+// pdfColumn is a column of a table: its header, width in points and
+// whether it is right-aligned, as numbers are.
+type pdfColumn struct {
+	Header string
+	Width  float64
+	Right  bool
+}
+
+// pdfCell is a table cell: text, on a background when Fill is set, or a
+// stacked severity bar of Bars scaled so that Most fills the cell.
+type pdfCell struct {
+	Text string
+	Bold bool
+	Fill string
+	Bars []SeverityCount
+	Most int
+}
+
+// Table geometry, in points.
+const (
+	pdfRow      = 14.0
+	pdfPad      = 3.0
+	pdfCellSize = 8.0
+)
+
+// table writes rows under a header row, unless the columns have no
+// headers, repeating the header on each page the table continues on.
+func (d *pdfDoc) table(cols []pdfColumn, rows [][]pdfCell) {
+	headed := cols[0].Header != ""
+	header := func() {
+		if !headed {
+			return
+		}
+		x := pdfMargin
+		for _, c := range cols {
+			d.rect(x, d.y, c.Width, pdfRow, d.header, pdfBorder)
+			d.cell(x, c, pdfCell{Text: c.Header, Bold: true}, d.headerText)
+			x += c.Width
+		}
+		d.y += pdfRow
+	}
+	d.need(2 * pdfRow)
+	header()
+	for _, row := range rows {
+		if d.need(pdfRow) {
+			header()
+		}
+		x := pdfMargin
+		for i, c := range cols {
+			fill, text := "#FFFFFF", "#000000"
+			if f := row[i].Fill; f != "" {
+				fill, text = f, theme.Contrast(f)
+			}
+			d.rect(x, d.y, c.Width, pdfRow, fill, pdfBorder)
+			d.cell(x, c, row[i], text)
+			x += c.Width
+		}
+		d.y += pdfRow
+	}
+	d.y += 8
+}
+
+// cell writes the content of a cell of column c starting at x on the
+// current row.
+func (d *pdfDoc) cell(x float64, c pdfColumn, cell pdfCell, color string) {
+	if cell.Bars != nil {
+		bx, inner := x+pdfPad, c.Width-2*pdfPad
+		for _, b := range cell.Bars {
+			if b.Count == 0 || cell.Most == 0 {
+				continue
+			}
+			w := inner * float64(b.Count) / float64(cell.Most)
+			d.rect(bx, d.y+pdfPad, w, pdfRow-2*pdfPad, d.severity(b.Severity), "")
+			bx += w
+		}
+		return
+	}
+	s := fitText(cell.Text, c.Width-2*pdfPad, pdfCellSize, cell.Bold)
+	tx := x + pdfPad
+	if c.Right {
+		tx = x + c.Width - pdfPad - textWidth(s, pdfCellSize, cell.Bold)
+	}
+	d.text(tx, d.y+pdfRow-4, pdfCellSize, cell.Bold, color, s)
+}
+
+// fit returns s, shortened with "..." to fit width.
+func fitText(s string, width, size float64, bold bool) string {
+	if textWidth(s, size, bold) <= width {
+		return s
+	}
+	rs := []rune(s)
+	for len(rs) > 0 && textWidth(string(rs)+"...", size, bold) > width {
+		rs = rs[:len(rs)-1]
+	}
+	return string(rs) + "..."
+}
+
+// wrapText breaks s into lines of at most width points.
+func wrapText(s string, width, size float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		if line != "" && textWidth(line+" "+word, size, false) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Character widths of ' ' to '~' in Helvetica and Helvetica-Bold, in
+// thousandths of the font size, from their Adobe font metrics.
+var (
+	helvetica = [95]int16{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBold = [95]int16{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// textWidth returns the width of s in points, in Helvetica, or
+// Helvetica-Bold when bold, at size. Characters outside ASCII count as
+// wide as a digit.
+func textWidth(s string, size float64, bold bool) float64 {
+	widths := &helvetica
+	if bold {
+		widths = &helveticaBold
+	}
+	n := 0
+	for _, b := range winAnsi(s) {
+		if b >= ' ' && b <= '~' {
+			n += int(widths[b-' '])
+		} else {
+			n += 556
+		}
+	}
+	return float64(n) * size / 1000
+}
+
+// winAnsiExtra are the characters outside Latin-1 that WinAnsiEncoding,
+// the encoding of the standard fonts, has.
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// winAnsi encodes s in WinAnsiEncoding, replacing the characters it lacks
+// with '?' and control characters with spaces.
+func winAnsi(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < ' ' || r == 0x7f:
+			b = append(b, ' ')
+		case r < 0x7f, r >= 0xa0 && r <= 0xff:
+			b = append(b, byte(r))
+		case winAnsiExtra[r] != 0:
+			b = append(b, winAnsiExtra[r])
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// pdfString returns s as the contents of a PDF literal string.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, c := range winAnsi(s) {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// num formats a coordinate, to two decimals without trailing zeros.
+func num(f float64) string {
+	return strconv.FormatFloat(float64(int(f*100+0.5))/100, 'f', -1, 64)
+}
+
+// rgb returns the #RRGGBB colour c as PDF colour components.
+func rgb(c string) string {
+	r, g, b := theme.RGB(c)
+	return num(float64(r)/255) + " " + num(float64(g)/255) + " " + num(float64(b)/255)
+}
+
+// pdfXObject is an image, as a PDF image XObject and its soft mask.
+type pdfXObject struct {
+	width, height int
+	dict          string // the entries of the XObject but its length
+	data          []byte
+	mask          []byte // Flate-compressed alpha, or nil when opaque
+}
+
+// pdfImage converts a logo for embedding. JPEGs are embedded as they are;
+// PNGs are decoded and their pixels compressed, with the alpha channel as
+// a soft mask.
+func pdfImage(logo *theme.Image) (*pdfXObject, error) {
+	if logo.Type == "image/jpeg" {
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(logo.Data))
+		if err != nil {
+			return nil, err
+		}
+		space := "/DeviceRGB"
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			space = "/DeviceGray"
+		case color.CMYKModel:
+			space = "/DeviceCMYK"
+		}
+		return &pdfXObject{
+			width: cfg.Width, height: cfg.Height, data: logo.Data,
+			dict: fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode", cfg.Width, cfg.Height, space),
+		}, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(logo.Data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	pix := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			pix = append(pix, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			opaque = opaque && c.A == 0xff
+		}
+	}
+	out := &pdfXObject{
+		width: bounds.Dx(), height: bounds.Dy(), data: deflate(pix),
+		dict: fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", bounds.Dx(), bounds.Dy()),
+	}
+	if !opaque {
+		out.mask = deflate(alpha)
+	}
+	return out, nil
+}
+
+func deflate(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(b)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// write writes the document: the catalog, the page tree, the fonts, the
+// document information, the logo, then each page and its content, and
+// the cross-reference table. Nothing in it depends on the time, so the
+// same findings make the same file.
+func (d *pdfDoc) write(w io.Writer) error {
+	var objs [][]byte
+	add := func(format string, args ...any) int {
+		objs = append(objs, []byte(fmt.Sprintf(format, args...)))
+		return len(objs)
+	}
+	stream := func(dict string, data []byte) int {
+		return add("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+	}
+	const pagesObj = 2
+	add("<< /Type /Catalog /Pages %d 0 R >>", pagesObj)
+	add("") // the page tree, once the pages are numbered
+	regular := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	bold := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	info := add("<< /Title (%s) /Producer (prioritizer) >>", pdfString(d.title))
+	xobjects := ""
+	if d.logo != nil {
+		dict := d.logo.dict
+		if d.logo.mask != nil {
+			mask := stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode", d.logo.width, d.logo.height), d.logo.mask)
+			dict += fmt.Sprintf(" /SMask %d 0 R", mask)
+		}
+		xobjects = fmt.Sprintf(" /XObject << /Im1 %d 0 R >>", stream(dict, d.logo.data))
+	}
+	var kids []string
+	for _, p := range d.pages {
+		content := stream("/Filter /FlateDecode", deflate(p.Bytes()))
+		page := add("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >>%s >> /Contents %d 0 R >>",
+			pagesObj, num(pdfWidth), num(pdfHeight), regular, bold, xobjects, content)
+		kids = append(kids, strconv.Itoa(page)+" 0 R")
+	}
+	objs[pagesObj-1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, info, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
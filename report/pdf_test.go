@@ -0,0 +1,178 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// loadTheme writes a theme with a translucent PNG logo and loads it.
+func loadTheme(t *testing.T) *theme.Theme {
+	t.Helper()
+	dir := t.TempDir()
+	img := image.NewNRGBA(image.Rect(0, 0, 60, 20))
+	img.Set(0, 0, color.NRGBA{R: 0x1f, G: 0x38, B: 0x64, A: 0x80})
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logo.png"), logo.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "theme.json")
+	doc := `{"title": "Acme posture report", "footer": "Confidential (Acme)", "font": "Georgia, serif", "primary": "#1F3864", "logo": "logo.png", "severities": {"Critical": "#FF0000"}}`
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	th, err := theme.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return th
+}
+
+func TestOverviewPDF(t *testing.T) {
+	now := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{
+		{AssetName: "hasura", Identifier: "CVE-2024-3094", PackageName: "xz-utils (liblzma)", Severity: vuln.Critical, PriorityScore: 9.5, ActionTimeframe: "Immediate", KEV: true},
+		{AssetName: "match-v1", Identifier: "CVE-2018-25011", PackageName: "libwebp", Severity: vuln.High, PriorityScore: 8.55, ActionTimeframe: "Immediate"},
+	}
+	// Enough assets for the table to continue on another page.
+	for i := range 40 {
+		vs = append(vs, vuln.Vulnerability{AssetName: "host-" + strconv.Itoa(i), Identifier: "CVE-2022-3996", Severity: vuln.Low, PriorityScore: 2, ActionTimeframe: "Planned"})
+	}
+	o := NewOverview(vs, 2, now)
+
+	var plain bytes.Buffer
+	if err := o.WritePDF(&plain, nil); err != nil {
+		t.Fatal(err)
+	}
+	pages := checkPDF(t, plain.Bytes())
+	if len(pages) != 2 {
+		t.Fatalf("%d pages, want 2", len(pages))
+	}
+	for _, want := range []string{
+		"(Vulnerability report) Tj",
+		"(As of 2025-02-05) Tj",
+		`(CVE-2024-3094 \(KEV\)) Tj`,
+		`(xz-utils \(liblzma\)) Tj`,
+		"0.7 0.09 0.17 rg", // Critical, as in the HTML report
+		"(Page 1 of 2) Tj",
+	} {
+		if !strings.Contains(pages[0], want) {
+			t.Errorf("page 1 lacks %q", want)
+		}
+	}
+	if !strings.Contains(pages[1], "(Asset) Tj") || !strings.Contains(pages[1], "(Page 2 of 2) Tj") {
+		t.Errorf("page 2 does not repeat the assets header:\n%s", pages[1])
+	}
+	var again bytes.Buffer
+	o.WritePDF(&again, nil)
+	if !bytes.Equal(plain.Bytes(), again.Bytes()) {
+		t.Error("the same overview made different documents")
+	}
+
+	var themed bytes.Buffer
+	if err := o.WritePDF(&themed, loadTheme(t)); err != nil {
+		t.Fatal(err)
+	}
+	doc := themed.Bytes()
+	pages = checkPDF(t, doc)
+	for _, want := range []string{
+		"(Acme posture report) Tj",
+		"/Im1 Do",
+		"1 0 0 rg",          // the theme's Critical
+		"0.12 0.22 0.39 rg", // its primary, for headers
+		`(Confidential \(Acme\)) Tj`,
+	} {
+		if !strings.Contains(pages[0], want) {
+			t.Errorf("themed page 1 lacks %q", want)
+		}
+	}
+	if !bytes.Contains(doc, []byte("/Subtype /Image /Width 60 /Height 20")) || !bytes.Contains(doc, []byte("/SMask ")) {
+		t.Error("the logo is not embedded with its alpha channel")
+	}
+}
+
+// checkPDF checks the structure of the PDF document b, that its
+// cross-reference table points at its objects, and returns the
+// decompressed content of each page in order.
+func checkPDF(t *testing.T, b []byte) []string {
+	t.Helper()
+	if !bytes.HasPrefix(b, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(b, []byte("%%EOF\n")) {
+		t.Fatal("not a PDF document")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(b)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(b[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	for i, off := range regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(b[xref:], -1) {
+		n, _ := strconv.Atoi(string(off[1]))
+		if want := strconv.Itoa(i+1) + " 0 obj\n"; !bytes.HasPrefix(b[n:], []byte(want)) {
+			t.Errorf("object %d is not at %d", i+1, n)
+		}
+	}
+	objects := regexp.MustCompile(`(?s)(\d+) 0 obj\n(.*?)\nendobj\n`).FindAllSubmatch(b, -1)
+	body := func(ref string) []byte {
+		for _, o := range objects {
+			if string(o[1]) == ref {
+				return o[2]
+			}
+		}
+		t.Fatalf("no object %s", ref)
+		return nil
+	}
+	kids := regexp.MustCompile(`/Kids \[([^\]]*)\]`).FindSubmatch(b)
+	if kids == nil {
+		t.Fatal("no page tree")
+	}
+	var pages []string
+	for _, ref := range regexp.MustCompile(`(\d+) 0 R`).FindAllSubmatch(kids[1], -1) {
+		page := body(string(ref[1]))
+		contents := regexp.MustCompile(`/Contents (\d+) 0 R`).FindSubmatch(page)
+		if contents == nil {
+			t.Fatalf("page %s has no contents", ref[1])
+		}
+		stream := body(string(contents[1]))
+		data := stream[bytes.Index(stream, []byte("stream\n"))+len("stream\n") : bytes.LastIndex(stream, []byte("\nendstream"))]
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, string(content))
+	}
+	return pages
+}
+
+func TestTextWidth(t *testing.T) {
+	if got := textWidth("Hello", 10, false); got != 22.78 {
+		t.Errorf("Hello = %v points, want 22.78", got)
+	}
+	if got := fitText("CVE-2024-3094 in xz-utils", 50, 8, false); got != "CVE-2024-..." {
+		t.Errorf("fitText = %q", got)
+	}
+	if got := pdfString("naïve (café) → 5€"); got != "na\xefve \\(caf\xe9\\) ? 5\x80" {
+		t.Errorf("pdfString = %q", got)
+	}
+}
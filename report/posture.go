@@ -0,0 +1,104 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/tracking"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// PastFinding is a finding open on a past day, with the workflow state it
+// was in then.
+type PastFinding struct {
+	tracking.Snapshot
+	State string `json:"state"`
+}
+
+// Posture is the open findings and their scores as they stood on a past
+// day, rebuilt from the tracking store and the workflow state audit log,
+// as evidence for auditors.
+type Posture struct {
+	AsOf vuln.Date `json:"as_of"`
+	Open int       `json:"open"`
+	// Severities counts the open findings by severity, most severe first.
+	Severities []SeverityCount `json:"severities"`
+	MeanScore  float64         `json:"mean_score"`
+	Findings   []PastFinding   `json:"findings"`
+	Evidence   []Source        `json:"evidence,omitempty"`
+}
+
+// NewPosture returns the posture of snaps, the findings open on day, as
+// tracking.Store.AsOf returns them, with their workflow states then, as
+// state.StatesAt returns them.
+func NewPosture(snaps []tracking.Snapshot, states map[string]string, day vuln.Date) *Posture {
+	p := &Posture{AsOf: day, Open: len(snaps), Findings: make([]PastFinding, len(snaps))}
+	counts := make(map[vuln.Severity]int)
+	var sum float64
+	for i, s := range snaps {
+		st, ok := states[s.Fingerprint]
+		if !ok {
+			st = state.Open
+		}
+		p.Findings[i] = PastFinding{Snapshot: s, State: st}
+		counts[s.Severity]++
+		sum += s.Score
+	}
+	for _, sev := range vuln.Severities {
+		p.Severities = append(p.Severities, SeverityCount{sev, counts[sev]})
+	}
+	if len(snaps) > 0 {
+		p.MeanScore = math.Round(sum/float64(len(snaps))*100) / 100
+	}
+	return p
+}
+
+var postureHeader = []string{"Priority Score", "Action Timeframe", "Severity", "Identifier", "Asset Name", "Package Name", "Unique ID", "Status", "State", "First Seen"}
+
+func (f *PastFinding) cells() []string {
+	return []string{
+		strconv.FormatFloat(f.Score, 'f', -1, 64), f.Timeframe, string(f.Severity), f.Identifier,
+		f.AssetName, f.PackageName, f.UniqueID, f.Status, f.State, f.FirstSeen.UTC().Format(time.DateOnly),
+	}
+}
+
+// WriteText writes p as a titled evidence document: the day, the open
+// findings by severity, an aligned table of them and the sources.
+func (p *Posture) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POSTURE REPORT")
+	fmt.Fprintf(tw, "As of %s\n\n", p.AsOf)
+	counts := make([]string, len(p.Severities))
+	for i, c := range p.Severities {
+		counts[i] = fmt.Sprintf("%s %d", c.Severity, c.Count)
+	}
+	fmt.Fprintf(tw, "%d open findings (%s), mean priority score %.2f\n", p.Open, strings.Join(counts, ", "), p.MeanScore)
+	if len(p.Findings) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, strings.Join(postureHeader, "\t"))
+		for i := range p.Findings {
+			fmt.Fprintln(tw, strings.Join(p.Findings[i].cells(), "\t"))
+		}
+	}
+	writeEvidence(tw, p.Evidence)
+	return tw.Flush()
+}
+
+// WriteCSV writes p's findings as CSV with a header row, highest score
+// first.
+func (p *Posture) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(postureHeader)
+	for i := range p.Findings {
+		cw.Write(p.Findings[i].cells())
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,57 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/tracking"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestPosture(t *testing.T) {
+	first := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	snaps := []tracking.Snapshot{
+		{Key: "66d47e7d", UniqueID: "66d47e7d", Fingerprint: "fp1", Identifier: "CVE-2024-4367", AssetName: "apply-frontend", PackageName: "npm-pdfjs-dist", Severity: vuln.High, Status: tracking.Open, FirstSeen: first, Score: 8.4, Timeframe: "Within 7 days"},
+		{Key: "fp2", Fingerprint: "fp2", Identifier: "CVE-2023-4911", AssetName: "bastion", PackageName: "glibc", Severity: vuln.High, Status: tracking.Reopened, FirstSeen: first, Score: 6.2},
+		{Key: "fp3", Fingerprint: "fp3", Identifier: "CVE-2024-0727", AssetName: "bastion", PackageName: "openssl", Severity: vuln.Low, Status: tracking.Open, FirstSeen: first, Score: 3},
+	}
+	p := NewPosture(snaps, map[string]string{"fp2": state.RiskAccepted}, vuln.NewDate(2025, 3, 1))
+	if p.Open != 3 || p.MeanScore != 5.87 || p.Findings[0].State != state.Open || p.Findings[1].State != state.RiskAccepted {
+		t.Errorf("posture %+v", p)
+	}
+	if len(p.Severities) != 4 || p.Severities[1] != (SeverityCount{vuln.High, 2}) || p.Severities[3] != (SeverityCount{vuln.Low, 1}) {
+		t.Errorf("severities %+v", p.Severities)
+	}
+	p.Evidence = []Source{{Name: "tracking", Path: "findings.json", SHA256: "abc", Detail: "12 findings"}}
+
+	var text bytes.Buffer
+	if err := p.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"As of 2025-03-01\n",
+		"3 open findings (Critical 0, High 2, Medium 0, Low 1), mean priority score 5.87\n",
+		"8.4             Within 7 days     High      CVE-2024-4367",
+		"tracking  findings.json  sha256:abc",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text lacks %q:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := p.WriteCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][0] != "Priority Score" || strings.Join(rows[2], ",") != "6.2,,High,CVE-2023-4911,bastion,glibc,,reopened,risk_accepted,2025-01-10" {
+		t.Errorf("CSV %q", rows)
+	}
+}
@@ -0,0 +1,140 @@
+// Package report aggregates prioritized findings into breakdowns by an
+// attribute such as ecosystem or region.
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/VioletX-Dev/devsecops-test/eol"
+	"github.com/VioletX-Dev/devsecops-test/imageref"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Group aggregates the findings that share one key.
+type Group struct {
+	Key       string  `json:"key"`
+	Findings  int     `json:"findings"`
+	MeanScore float64 `json:"mean_score"`
+	Fixable   int     `json:"fixable"`
+	// FixRate is the share of findings with a fixed version, from 0 to 1.
+	FixRate float64 `json:"fix_rate"`
+}
+
+// Breakdown groups vs by key and returns the groups by descending finding
+// count, then key. vs must already be scored.
+func Breakdown(vs []vuln.Vulnerability, key func(*vuln.Vulnerability) string) []Group {
+	index := make(map[string]int)
+	var groups []Group
+	var sums []float64
+	for i := range vs {
+		v := &vs[i]
+		k := key(v)
+		j, ok := index[k]
+		if !ok {
+			j = len(groups)
+			index[k] = j
+			groups = append(groups, Group{Key: k})
+			sums = append(sums, 0)
+		}
+		g := &groups[j]
+		g.Findings++
+		sums[j] += v.PriorityScore
+		if v.HasFix() {
+			g.Fixable++
+		}
+	}
+	for j := range groups {
+		g := &groups[j]
+		g.MeanScore = round2(sums[j] / float64(g.Findings))
+		g.FixRate = round2(float64(g.Fixable) / float64(g.Findings))
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Findings != groups[j].Findings {
+			return groups[i].Findings > groups[j].Findings
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// ByEcosystem is the Breakdown key for package ecosystems.
+func ByEcosystem(v *vuln.Vulnerability) string {
+	return string(v.Ecosystem())
+}
+
+// ByRegion is the Breakdown key for the region set by region attribution.
+func ByRegion(v *vuln.Vulnerability) string {
+	return v.Region
+}
+
+// WriteText writes groups as an aligned table headed by title, the name of
+// the grouping attribute.
+func WriteText(w io.Writer, title string, groups []Group) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tFINDINGS\tMEAN SCORE\tFIXABLE\tFIX RATE\n", title)
+	for _, g := range groups {
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\t%d\t%.0f%%\n", g.Key, g.Findings, g.MeanScore, g.Fixable, g.FixRate*100)
+	}
+	return tw.Flush()
+}
+
+// WriteEndOfLife writes the assets past the end of OS security support as
+// an aligned table, or nothing when there are none.
+func WriteEndOfLife(w io.Writer, assets []eol.Asset) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := false
+	for _, a := range assets {
+		if !a.EndOfLife {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(tw, "\nEND-OF-LIFE ASSETS")
+			fmt.Fprintln(tw, "ASSET\tOS\tSUPPORT ENDED\tFINDINGS\tNO FIX")
+			header = true
+		}
+		name := a.AssetName
+		if name == "" {
+			name = a.AssetID
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", name, a.OS, a.SupportEnds, a.Findings, a.NoFix)
+	}
+	return tw.Flush()
+}
+
+// WriteImages writes the vulnerable container images, by digest, with the
+// tags that refer to them, as an aligned table, or nothing when there are
+// none.
+func WriteImages(w io.Writer, images []imageref.Image) error {
+	if len(images) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nVULNERABLE IMAGES")
+	fmt.Fprintln(tw, "IMAGE\tDIGEST\tTAGS\tFINDINGS\tCRITICAL\tHIGH")
+	for _, img := range images {
+		tags := strings.Join(img.Tags, ", ")
+		if tags == "" {
+			tags = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\n", img.Repository, shortDigest(img.Digest), tags, img.Findings, img.Critical, img.High)
+	}
+	return tw.Flush()
+}
+
+// shortDigest abbreviates a digest to 12 hex digits, as docker does.
+func shortDigest(d string) string {
+	alg, hex, _ := strings.Cut(d, ":")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return alg + ":" + hex
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestBreakdownByEcosystem(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{PackageName: "npm-axios", FixedVersion: "1.7.4", PriorityScore: 6},
+		{PackageName: "npm-ws", PriorityScore: 4},
+		{PackageName: "npm-ws", FixedVersion: "8.17.1", PriorityScore: 5},
+		{PackageName: "glibc", Source: "aws", PriorityScore: 7},
+		{PackageName: "go-golang.org/x/net", FixedVersion: "0.33.0", PriorityScore: 3},
+	}
+	got := Breakdown(vs, ByEcosystem)
+	want := []Group{
+		{Key: "npm", Findings: 3, MeanScore: 5, Fixable: 2, FixRate: 0.67},
+		{Key: "go", Findings: 1, MeanScore: 3, Fixable: 1, FixRate: 1},
+		{Key: "os-pkg", Findings: 1, MeanScore: 7, Fixable: 0, FixRate: 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("group %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	var buf strings.Builder
+	if err := WriteText(&buf, "ECOSYSTEM", got); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "npm        3         5.00        2        67%") {
+		t.Errorf("table:\n%s", buf.String())
+	}
+}
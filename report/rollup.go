@@ -0,0 +1,63 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Rollup aggregates the findings under one node of a hierarchy, such as a
+// division, and breaks them down by the level below.
+type Rollup struct {
+	// Level names the hierarchy level of Key, as in division.
+	Level string `json:"level"`
+	Group
+	Children []Rollup `json:"children,omitempty"`
+}
+
+// Rollups groups vs by the first key path returns, then each group by the
+// second and so on, one key per level. levels names the levels from the
+// top. Every level is ordered as Breakdown orders its groups. vs must
+// already be scored.
+func Rollups(vs []vuln.Vulnerability, levels []string, path func(*vuln.Vulnerability) []string) []Rollup {
+	return rollup(vs, levels, path, 0)
+}
+
+func rollup(vs []vuln.Vulnerability, levels []string, path func(*vuln.Vulnerability) []string, depth int) []Rollup {
+	key := func(v *vuln.Vulnerability) string { return path(v)[depth] }
+	groups := Breakdown(vs, key)
+	rs := make([]Rollup, len(groups))
+	for i, g := range groups {
+		rs[i] = Rollup{Level: levels[depth], Group: g}
+		if depth+1 == len(levels) {
+			continue
+		}
+		var sub []vuln.Vulnerability
+		for j := range vs {
+			if key(&vs[j]) == g.Key {
+				sub = append(sub, vs[j])
+			}
+		}
+		rs[i].Children = rollup(sub, levels, path, depth+1)
+	}
+	return rs
+}
+
+// WriteRollups writes rs as an aligned table headed by the level names of
+// title, with each level indented under the one above.
+func WriteRollups(w io.Writer, title string, rs []Rollup) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tFINDINGS\tMEAN SCORE\tFIXABLE\tFIX RATE\n", title)
+	writeRollups(tw, rs, 0)
+	return tw.Flush()
+}
+
+func writeRollups(w io.Writer, rs []Rollup, depth int) {
+	for _, r := range rs {
+		fmt.Fprintf(w, "%s%s\t%d\t%.2f\t%d\t%.0f%%\n", strings.Repeat("  ", depth), r.Key, r.Findings, r.MeanScore, r.Fixable, r.FixRate*100)
+		writeRollups(w, r.Children, depth+1)
+	}
+}
@@ -0,0 +1,38 @@
+package report
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestRollups(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{Team: "a/x/1", FixedVersion: "1.0", PriorityScore: 6},
+		{Team: "a/x/2", PriorityScore: 4},
+		{Team: "a/y/3", PriorityScore: 2},
+		{Team: "b/z/4", FixedVersion: "1.0", PriorityScore: 8},
+	}
+	path := func(v *vuln.Vulnerability) []string { return strings.Split(v.Team, "/") }
+	rs := Rollups(vs, []string{"division", "business_unit", "account"}, path)
+	if len(rs) != 2 || rs[0].Key != "a" || rs[0].Findings != 3 || rs[0].MeanScore != 4 || rs[0].Fixable != 1 {
+		t.Fatalf("divisions = %+v", rs)
+	}
+	if x := rs[0].Children[0]; x.Level != "business_unit" || x.Key != "x" || x.Findings != 2 || len(x.Children) != 2 {
+		t.Errorf("business unit x = %+v", x)
+	}
+	if acct := rs[1].Children[0].Children[0]; acct.Level != "account" || acct.Key != "4" || acct.FixRate != 1 || acct.Children != nil {
+		t.Errorf("account 4 = %+v", acct)
+	}
+
+	var buf strings.Builder
+	if err := WriteRollups(&buf, "DIVISION", rs); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"a         3         4.00", "  x       2         5.00", "    1     1         6.00"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("table lacks %q:\n%s", line, buf.String())
+		}
+	}
+}
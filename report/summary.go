@@ -0,0 +1,315 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/locale"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Risk is one of a group's highest-priority findings.
+type Risk struct {
+	Identifier      string  `json:"identifier"`
+	PackageName     string  `json:"package_name"`
+	PriorityScore   float64 `json:"priority_score"`
+	ActionTimeframe string  `json:"action_timeframe"`
+	KEV             bool    `json:"kev,omitempty"`
+}
+
+// Summary is the executive summary of the findings that share one key: the
+// counts, the top risks and the recommended immediate actions, with
+// Narrative as the paragraph that reads them out.
+type Summary struct {
+	Key      string `json:"key"`
+	Findings int    `json:"findings"`
+	// Severities counts the findings by severity, most severe first, with
+	// a zero count for severities the group has none of.
+	Severities []SeverityCount `json:"severities"`
+	Fixable    int             `json:"fixable"`
+	KEV        int             `json:"kev"`
+	// Immediate counts the findings in the most urgent action timeframe,
+	// and Overdue those past their due date.
+	Immediate int      `json:"immediate"`
+	Overdue   int      `json:"overdue"`
+	TopRisks  []Risk   `json:"top_risks"`
+	Actions   []string `json:"actions"`
+	Narrative string   `json:"narrative"`
+}
+
+// SeverityCount is the number of a group's findings of one severity.
+type SeverityCount struct {
+	Severity vuln.Severity `json:"severity"`
+	Count    int           `json:"count"`
+}
+
+// ByAsset is the Breakdown and Summaries key for assets: the asset name,
+// or the asset ID when the name is blank.
+func ByAsset(v *vuln.Vulnerability) string {
+	if v.AssetName != "" {
+		return v.AssetName
+	}
+	return v.AssetID
+}
+
+// Summaries groups vs by key and summarises each group, naming its top
+// highest-priority findings as its top risks, on the reference date now.
+// Groups are ordered like heatmap rows: by descending highest priority
+// score, then finding count, then key. vs must already be scored and
+// sorted, as scoring.Prioritize leaves them.
+func Summaries(vs []vuln.Vulnerability, key func(*vuln.Vulnerability) string, top int, now time.Time) []Summary {
+	index := make(map[string]int)
+	var groups [][]*vuln.Vulnerability
+	var keys []string
+	for i := range vs {
+		k := key(&vs[i])
+		j, ok := index[k]
+		if !ok {
+			j = len(groups)
+			index[k] = j
+			groups = append(groups, nil)
+			keys = append(keys, k)
+		}
+		groups[j] = append(groups[j], &vs[i])
+	}
+	ss := make([]Summary, len(groups))
+	for j, g := range groups {
+		ss[j] = summarize(keys[j], g, top, now)
+	}
+	sort.SliceStable(ss, func(i, j int) bool {
+		a, b := ss[i], ss[j]
+		if len(a.TopRisks) > 0 && len(b.TopRisks) > 0 && a.TopRisks[0].PriorityScore != b.TopRisks[0].PriorityScore {
+			return a.TopRisks[0].PriorityScore > b.TopRisks[0].PriorityScore
+		}
+		if a.Findings != b.Findings {
+			return a.Findings > b.Findings
+		}
+		return a.Key < b.Key
+	})
+	return ss
+}
+
+// summarize builds the summary of one group, whose findings are in
+// priority order.
+func summarize(k string, g []*vuln.Vulnerability, top int, now time.Time) Summary {
+	s := Summary{Key: k, Findings: len(g)}
+	bySeverity := make(map[vuln.Severity]int)
+	immediate := scoring.DefaultTiers[0].Name
+	fixes := make(map[string]int) // fixable findings by package
+	var eolOS string
+	for _, v := range g {
+		bySeverity[v.Severity]++
+		if v.HasFix() {
+			s.Fixable++
+			fixes[v.PackageName]++
+		}
+		if v.KEV {
+			s.KEV++
+		}
+		if v.ActionTimeframe == immediate {
+			s.Immediate++
+		}
+		if !v.DueDate.IsZero() && v.DueDate.DaysUntil(now) < 0 {
+			s.Overdue++
+		}
+		if v.OSEndOfLife && eolOS == "" {
+			eolOS = v.OS
+		}
+		if len(s.TopRisks) < top {
+			s.TopRisks = append(s.TopRisks, Risk{
+				Identifier:      v.Identifier,
+				PackageName:     v.PackageName,
+				PriorityScore:   v.PriorityScore,
+				ActionTimeframe: v.ActionTimeframe,
+				KEV:             v.KEV,
+			})
+		}
+	}
+	for _, sev := range vuln.Severities {
+		s.Severities = append(s.Severities, SeverityCount{sev, bySeverity[sev]})
+	}
+
+	if s.KEV > 0 {
+		s.Actions = append(s.Actions, fmt.Sprintf("patch %s known to be exploited first", the(s.KEV, "finding")))
+	}
+	if s.Immediate > 0 {
+		s.Actions = append(s.Actions, fmt.Sprintf("remediate %s within %d days", the(s.Immediate, immediate+" finding"), scoring.DefaultTiers[0].WithinDays))
+	}
+	switch {
+	case s.Overdue == 1:
+		s.Actions = append(s.Actions, "close the finding past its due date")
+	case s.Overdue > 1:
+		s.Actions = append(s.Actions, fmt.Sprintf("close the %d findings past their due date", s.Overdue))
+	}
+	if pkg := topFix(fixes); pkg != "" {
+		s.Actions = append(s.Actions, fmt.Sprintf("upgrade %s, which fixes %s", pkg, plural(fixes[pkg], "finding")))
+	}
+	if eolOS != "" {
+		s.Actions = append(s.Actions, fmt.Sprintf("plan the upgrade from %s, which no longer gets security fixes", eolOS))
+	}
+	s.Narrative = narrative(&s)
+	return s
+}
+
+// topFix returns the package with the most fixable findings, the first by
+// name on a tie, or "" when there are none.
+func topFix(fixes map[string]int) string {
+	best := ""
+	for pkg, n := range fixes {
+		if best == "" || n > fixes[best] || n == fixes[best] && pkg < best {
+			best = pkg
+		}
+	}
+	return best
+}
+
+var narrativeText = texttemplate.Must(texttemplate.New("narrative").Funcs(texttemplate.FuncMap{
+	"plural":  plural,
+	"join":    joinList,
+	"percent": func(n, of int) int { return (n*200 + of) / (of * 2) },
+	"score":   formatScore,
+	"counts":  severityCounts,
+	"last":    func(i int, rs []Risk) bool { return i == len(rs)-1 },
+}).Parse(
+	`{{.Key}} has {{plural .Findings "finding"}}: {{counts .Severities}}. ` +
+		`{{if .Fixable}}{{.Fixable}} ({{percent .Fixable .Findings}}%) can be fixed by upgrading{{else}}None has a fixed version yet{{end}}` +
+		`{{if .KEV}}, and {{.KEV}} {{if eq .KEV 1}}is{{else}}are{{end}} known to be exploited{{end}}.` +
+		`{{with .TopRisks}} The top {{if eq (len .) 1}}risk is{{else}}risks are{{end}} ` +
+		`{{range $i, $r := .}}{{if $i}}{{if last $i $.TopRisks}} and {{else}}, {{end}}{{end}}` +
+		`{{$r.Identifier}} in {{$r.PackageName}} (score {{score $r.PriorityScore}}, {{$r.ActionTimeframe}}{{if $r.KEV}}, exploited{{end}}){{end}}.{{end}}` +
+		`{{if .Actions}} Recommended immediate actions: {{join .Actions}}.` +
+		`{{else}} No immediate action is needed; remediate within the planned timeframes.{{end}}`))
+
+// narrative renders s as a paragraph.
+func narrative(s *Summary) string {
+	return render(narrativeText, s)
+}
+
+// narrativeIn renders s as a paragraph with its scores in the locale l,
+// or returns its Narrative when l is nil.
+func narrativeIn(s *Summary, l *locale.Locale) string {
+	if l == nil {
+		return s.Narrative
+	}
+	tmpl := texttemplate.Must(narrativeText.Clone())
+	return render(tmpl.Funcs(texttemplate.FuncMap{"score": func(f float64) string { return l.Float(f, 2) }}), s)
+}
+
+func render(tmpl *texttemplate.Template, s *Summary) string {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, s); err != nil {
+		// The template only fails on a programming error.
+		panic(err)
+	}
+	return b.String()
+}
+
+// severityCounts lists the non-zero counts, as in "2 critical, 5 high
+// and 1 low".
+func severityCounts(counts []SeverityCount) string {
+	var parts []string
+	for _, c := range counts {
+		if c.Count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", c.Count, strings.ToLower(string(c.Severity))))
+		}
+	}
+	return joinList(parts)
+}
+
+// joinList joins items as an English list: "a", "a and b", "a, b and c".
+func joinList(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+// the refers to n of the noun, as in "the finding" and "the 3 findings".
+func the(n int, noun string) string {
+	if n == 1 {
+		return "the " + noun
+	}
+	return "the " + plural(n, noun)
+}
+
+// plural returns n with the noun, as in "1 finding" and "3 findings".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// WriteSummaries writes each summary's narrative as a paragraph.
+func WriteSummaries(w io.Writer, ss []Summary) error {
+	for i, s := range ss {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, wrap(s.Narrative, 76)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wrap breaks text into lines of at most width characters, where words
+// allow.
+func wrap(text string, width int) string {
+	var b strings.Builder
+	line := 0
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == 0:
+		case line+1+len(word) > width:
+			b.WriteByte('\n')
+			line = 0
+		default:
+			b.WriteByte(' ')
+			line++
+		}
+		b.WriteString(word)
+		line += len(word)
+	}
+	return b.String()
+}
+
+var summariesHTML = template.Must(template.New("summaries").Funcs(template.FuncMap{
+	"narrative": pageTheme{}.narrative,
+}).Parse(`<section class="summaries">
+<h2>Executive summary</h2>
+{{range .}}<h3>{{.Key}}</h3>
+<p>{{narrative .}}</p>
+{{end}}</section>
+`))
+
+// SummariesHTML returns the summaries as an HTML section, with a heading
+// and a paragraph per group, for embedding in an HTML report.
+func SummariesHTML(ss []Summary) (template.HTML, error) {
+	return pageTheme{}.summaries(ss)
+}
+
+// summaries returns the summaries as SummariesHTML does, with their
+// numbers in the theme's locale.
+func (p pageTheme) summaries(ss []Summary) (template.HTML, error) {
+	section, err := summariesHTML.Clone()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := section.Funcs(template.FuncMap{"narrative": p.narrative}).Execute(&b, ss); err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestSummaries(t *testing.T) {
+	now := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{
+		{AssetName: "hasura", Identifier: "CVE-2024-3094", PackageName: "xz-utils", Severity: vuln.Critical, PriorityScore: 9.5, ActionTimeframe: "Immediate", KEV: true, FixedVersion: "5.6.1", DueDate: vuln.NewDate(2025, 1, 30)},
+		{AssetName: "match-v1", Identifier: "CVE-2018-25011", PackageName: "libwebp", Severity: vuln.Critical, PriorityScore: 8.55, ActionTimeframe: "Immediate", OS: "Debian 10", OSEndOfLife: true},
+		{AssetName: "hasura", Identifier: "CVE-2023-4911", PackageName: "glibc", Severity: vuln.High, PriorityScore: 7.1, ActionTimeframe: "Urgent", FixedVersion: "2.31-13+deb11u7", DueDate: vuln.NewDate(2025, 3, 1)},
+		{AssetName: "hasura", Identifier: "CVE-2023-5156", PackageName: "glibc", Severity: vuln.Medium, PriorityScore: 5, ActionTimeframe: "Scheduled", FixedVersion: "2.31-13+deb11u8"},
+		{AssetName: "hasura", Identifier: "CVE-2022-3996", PackageName: "openssl", Severity: vuln.Low, PriorityScore: 2, ActionTimeframe: "Planned"},
+		{AssetID: "i-0abc", Identifier: "CVE-2021-44228", PackageName: "log4j", Severity: vuln.Low, PriorityScore: 3, ActionTimeframe: "Planned"},
+	}
+	ss := Summaries(vs, ByAsset, 2, now)
+	var keys []string
+	for _, s := range ss {
+		keys = append(keys, s.Key)
+	}
+	if strings.Join(keys, " ") != "hasura match-v1 i-0abc" {
+		t.Fatalf("groups = %v, want by highest score", keys)
+	}
+
+	h := ss[0]
+	if h.Findings != 4 || h.Fixable != 3 || h.KEV != 1 || h.Immediate != 1 || h.Overdue != 1 {
+		t.Errorf("hasura = %+v", h)
+	}
+	if len(h.TopRisks) != 2 || h.TopRisks[1].Identifier != "CVE-2023-4911" {
+		t.Errorf("top risks = %+v", h.TopRisks)
+	}
+	want := "hasura has 4 findings: 1 critical, 1 high, 1 medium and 1 low. " +
+		"3 (75%) can be fixed by upgrading, and 1 is known to be exploited. " +
+		"The top risks are CVE-2024-3094 in xz-utils (score 9.50, Immediate, exploited) and CVE-2023-4911 in glibc (score 7.10, Urgent). " +
+		"Recommended immediate actions: patch the finding known to be exploited first, remediate the Immediate finding within 2 days, " +
+		"close the finding past its due date and upgrade glibc, which fixes 2 findings."
+	if h.Narrative != want {
+		t.Errorf("narrative =\n%s\nwant\n%s", h.Narrative, want)
+	}
+	if got := ss[1].Narrative; !strings.Contains(got, "None has a fixed version yet.") || !strings.HasSuffix(got, "plan the upgrade from Debian 10, which no longer gets security fixes.") {
+		t.Errorf("match-v1 narrative = %s", got)
+	}
+	if got := ss[2].Narrative; !strings.HasSuffix(got, "The top risk is CVE-2021-44228 in log4j (score 3.00, Planned). No immediate action is needed; remediate within the planned timeframes.") {
+		t.Errorf("i-0abc narrative = %s", got)
+	}
+
+	var text strings.Builder
+	if err := WriteSummaries(&text, ss); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(text.String(), "\n") {
+		if len(line) > 76 {
+			t.Errorf("line longer than 76 characters: %q", line)
+		}
+	}
+	if paras := strings.Count(text.String(), "\n\n"); paras != 2 {
+		t.Errorf("%d paragraph breaks, want 2:\n%s", paras, text.String())
+	}
+
+	hm := NewHeatmap(vs, 2)
+	hm.Summaries = ss
+	var page strings.Builder
+	if err := hm.WriteHTML(&page, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page.String(), "<h2>Executive summary</h2>\n<h3>hasura</h3>\n<p>hasura has 4 findings") {
+		t.Errorf("page lacks the summaries:\n%s", page.String())
+	}
+}
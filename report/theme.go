@@ -0,0 +1,72 @@
+package report
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/theme"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// pageTheme is a theme as the HTML reports apply it. The zero pageTheme
+// of a nil theme adds nothing to a page but its title.
+type pageTheme struct {
+	Title, Footer string
+	// CSS is appended to the page's stylesheet.
+	CSS template.CSS
+	// Logo is the logo as a data URL, so the page stays one file.
+	Logo  template.URL
+	theme *theme.Theme
+}
+
+// newPageTheme returns t as the HTML reports apply it, titled title
+// unless t has its own.
+func newPageTheme(t *theme.Theme, title string) pageTheme {
+	p := pageTheme{Title: title, theme: t}
+	if t == nil {
+		return p
+	}
+	if t.Title != "" {
+		p.Title = t.Title
+	}
+	p.Footer = t.Footer
+	var css strings.Builder
+	if t.Font != "" {
+		fmt.Fprintf(&css, "body { font-family: %s; }\n", t.Font)
+	}
+	if t.Primary != "" {
+		fmt.Fprintf(&css, "h1, h2, h3 { color: %s; }\nth { background: %s; color: %s; }\n", t.Primary, t.Primary, t.PrimaryText)
+	}
+	if logo := t.LogoImage(); logo != nil {
+		css.WriteString("img.logo { height: 48px; display: block; margin-bottom: 1em; }\n")
+		p.Logo = template.URL("data:" + logo.Type + ";base64," + base64.StdEncoding.EncodeToString(logo.Data))
+	}
+	if t.Footer != "" {
+		css.WriteString("footer { margin-top: 2em; font-size: 12px; color: #666; }\n")
+	}
+	p.CSS = template.CSS(css.String())
+	return p
+}
+
+// color returns the colour of findings of sev, the theme's or else the
+// default palette's.
+func (p pageTheme) color(sev vuln.Severity) template.CSS {
+	return template.CSS(p.theme.Severity(sev, severityColors[sev]))
+}
+
+// score formats a priority score in the theme's locale.
+func (p pageTheme) score(f float64) string {
+	return p.theme.Formats().Float(f, 2)
+}
+
+// date formats d in the theme's locale.
+func (p pageTheme) date(d vuln.Date) string {
+	return p.theme.Formats().Date(d)
+}
+
+// narrative returns s's narrative with its numbers in the theme's locale.
+func (p pageTheme) narrative(s Summary) string {
+	return narrativeIn(&s, p.theme.Formats())
+}
@@ -0,0 +1,59 @@
+// Package sample draws risk-weighted random samples of findings for
+// analysts to verify by hand. Checking a few findings of every run against
+// the assets themselves measures how often each scanner is right, and
+// weighting the draw by priority score spends the analysts' time where a
+// wrong finding costs the most, while every finding keeps a chance.
+package sample
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// MinWeight is the weight of findings that score less, so that a score of
+// 0 does not rule a finding out.
+const MinWeight = 0.5
+
+// Weight is the weight of v in a draw: its priority score, at least
+// MinWeight.
+func Weight(v *vuln.Vulnerability) float64 {
+	return max(v.PriorityScore, MinWeight)
+}
+
+// Draw picks up to n findings of vs at random without replacement, each
+// with a chance proportional to its Weight, and returns them by descending
+// weight. Repeated reports of a finding, which share a fingerprint, count
+// once, and findings for which skip returns true are left out. The same rng
+// state draws the same sample.
+func Draw(vs []vuln.Vulnerability, n int, rng *rand.Rand, skip func(*vuln.Vulnerability) bool) []vuln.Vulnerability {
+	// Efraimidis and Spirakis: the n highest keys u^(1/w), for u uniform
+	// in (0, 1), are a weighted sample. Their logarithms, log(u)/w, keep
+	// the order without underflowing.
+	type candidate struct {
+		i   int
+		key float64
+	}
+	var cs []candidate
+	seen := make(map[string]bool)
+	for i := range vs {
+		v := &vs[i]
+		fp := v.Fingerprint()
+		if seen[fp] || (skip != nil && skip(v)) {
+			continue
+		}
+		seen[fp] = true
+		u := 1 - rng.Float64() // (0, 1]
+		cs = append(cs, candidate{i, math.Log(u) / Weight(v)})
+	}
+	sort.SliceStable(cs, func(a, b int) bool { return cs[a].key > cs[b].key })
+	cs = cs[:min(n, len(cs))]
+	sort.SliceStable(cs, func(a, b int) bool { return Weight(&vs[cs[a].i]) > Weight(&vs[cs[b].i]) })
+	picked := make([]vuln.Vulnerability, len(cs))
+	for j, c := range cs {
+		picked[j] = vs[c.i]
+	}
+	return picked
+}
@@ -0,0 +1,48 @@
+package sample
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestDraw(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{Identifier: "CVE-1", AssetID: "i-1", PriorityScore: 9},
+		{Identifier: "CVE-1", AssetID: "i-1", PriorityScore: 9}, // repeated report
+		{Identifier: "CVE-2", AssetID: "i-1", PriorityScore: 3},
+		{Identifier: "CVE-3", AssetID: "i-1", PriorityScore: 0},
+		{Identifier: "CVE-4", AssetID: "i-1", PriorityScore: 6},
+	}
+	rng := func() *rand.Rand { return rand.New(rand.NewPCG(1, 2)) }
+	picked := Draw(vs, 10, rng(), nil)
+	if len(picked) != 4 || picked[0].Identifier != "CVE-1" || picked[3].Identifier != "CVE-3" {
+		t.Errorf("all = %+v", picked)
+	}
+	skip := func(v *vuln.Vulnerability) bool { return v.Identifier == "CVE-1" }
+	for _, v := range Draw(vs, 2, rng(), skip) {
+		if v.Identifier == "CVE-1" {
+			t.Errorf("drew skipped %s", v.Identifier)
+		}
+	}
+	a, b := Draw(vs, 2, rng(), nil), Draw(vs, 2, rng(), nil)
+	if a[0].Identifier != b[0].Identifier || a[1].Identifier != b[1].Identifier {
+		t.Errorf("the same seed drew %s, %s and %s, %s", a[0].Identifier, a[1].Identifier, b[0].Identifier, b[1].Identifier)
+	}
+
+	// Over many draws of one, each finding comes up in proportion to its
+	// weight: 9, 3, 0.5 and 6 out of 18.5.
+	counts := make(map[string]int)
+	r := rng()
+	const draws = 20000
+	for range draws {
+		counts[Draw(vs, 1, r, nil)[0].Identifier]++
+	}
+	for id, weight := range map[string]float64{"CVE-1": 9, "CVE-2": 3, "CVE-3": MinWeight, "CVE-4": 6} {
+		want := weight / 18.5
+		if got := float64(counts[id]) / draws; got < want*0.9 || got > want*1.1 {
+			t.Errorf("%s drawn %.3f of the time, want %.3f", id, got, want)
+		}
+	}
+}
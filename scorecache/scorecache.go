@@ -0,0 +1,206 @@
+// Package scorecache keeps each finding's enrichment results and static
+// score points between runs, so that a long-running process only enriches
+// and scores what changed and recomputes the time-dependent points.
+package scorecache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/VioletX-Dev/devsecops-test/enrich"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Entry is what the cache remembers about one fingerprint.
+type Entry struct {
+	// Inputs digests the input fields enrichment and static points depend
+	// on; a changed digest invalidates the entry.
+	Inputs string `json:"inputs"`
+
+	EPSS           float64 `json:"epss,omitempty"`
+	EPSSPercentile float64 `json:"epss_percentile,omitempty"`
+	KEV            bool    `json:"kev,omitempty"`
+	CVSS           float64 `json:"cvss"`
+	CVSSSource     string  `json:"cvss_source,omitempty"`
+	Region         string  `json:"region,omitempty"`
+	CVSSVector     string  `json:"cvss_vector,omitempty"`
+	// Description and CWEs are kept when enrichment filled them in, for
+	// findings the input leaves them blank on.
+	Description string   `json:"description,omitempty"`
+	CWEs        []string `json:"cwes,omitempty"`
+
+	// Static holds scoring.Weighted.StaticPoints under the weights the
+	// cache was last scored with, or is nil.
+	Static *float64 `json:"static,omitempty"`
+}
+
+// Cache maps fingerprints to entries. The zero Cache is not usable; use
+// New or Load.
+type Cache struct {
+	// Weights identifies the weights the Static points were computed with.
+	Weights string            `json:"weights"`
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// Stats counts cache use in one run.
+type Stats struct {
+	Hits, Misses, Evicted int
+}
+
+// New returns an empty cache.
+func New() *Cache {
+	return &Cache{Entries: make(map[string]*Entry)}
+}
+
+// Load reads a cache saved with Save. A missing file is an empty cache.
+func Load(path string) (*Cache, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := New()
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Entries == nil {
+		c.Entries = make(map[string]*Entry)
+	}
+	return c, nil
+}
+
+// Save writes c to path as JSON.
+func (c *Cache) Save(path string) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// Enrich restores cached enrichment for findings whose inputs are
+// unchanged, runs es on the rest, and remembers their results. Entries for
+// fingerprints absent from vs are evicted.
+func (c *Cache) Enrich(ctx context.Context, vs []vuln.Vulnerability, es []enrich.Enricher) (Stats, error) {
+	var stats Stats
+	fingerprints := make([]string, len(vs))
+	digests := make([]string, len(vs))
+	var missed []int
+	for i := range vs {
+		v := &vs[i]
+		fingerprints[i], digests[i] = v.Fingerprint(), inputs(v)
+		e, ok := c.Entries[fingerprints[i]]
+		if ok && e.Inputs == digests[i] {
+			e.restore(v)
+			stats.Hits++
+			continue
+		}
+		missed = append(missed, i)
+		stats.Misses++
+	}
+
+	sub := make([]vuln.Vulnerability, len(missed))
+	for j, i := range missed {
+		sub[j] = vs[i]
+	}
+	if len(missed) > 0 && len(es) > 0 {
+		if _, err := enrich.Run(ctx, sub, es); err != nil {
+			return stats, err
+		}
+	}
+	for j, i := range missed {
+		c.Entries[fingerprints[i]] = newEntry(&sub[j], &vs[i], digests[i])
+		vs[i] = sub[j]
+	}
+
+	live := make(map[string]bool, len(vs))
+	for _, fp := range fingerprints {
+		live[fp] = true
+	}
+	for fp := range c.Entries {
+		if !live[fp] {
+			delete(c.Entries, fp)
+			stats.Evicted++
+		}
+	}
+	return stats, nil
+}
+
+// Score sets every finding's PriorityScore from its cached static points
+// plus the time points at m.Now, computing and caching static points it
+// does not have. vs must have been passed to Enrich first.
+func (c *Cache) Score(vs []vuln.Vulnerability, m scoring.Weighted) {
+	if w := weightsKey(m.Weights); w != c.Weights {
+		for _, e := range c.Entries {
+			e.Static = nil
+		}
+		c.Weights = w
+	}
+	for i := range vs {
+		v := &vs[i]
+		v.TemporalCVSS = scoring.TemporalCVSS(v)
+		e, ok := c.Entries[v.Fingerprint()]
+		if !ok {
+			v.PriorityScore = m.Score(*v)
+			continue
+		}
+		if e.Static == nil {
+			static := m.StaticPoints(*v)
+			e.Static = &static
+		}
+		v.PriorityScore = m.Normalize(m.Total(*v, *e.Static, m.TimePoints(*v)))
+	}
+}
+
+// newEntry remembers the enrichment of v, which was before before it was
+// enriched.
+func newEntry(v, before *vuln.Vulnerability, digest string) *Entry {
+	e := &Entry{
+		Inputs:         digest,
+		EPSS:           v.EPSS,
+		EPSSPercentile: v.EPSSPercentile,
+		KEV:            v.KEV,
+		CVSS:           v.CVSS,
+		CVSSSource:     v.CVSSSource,
+		Region:         v.Region,
+		CVSSVector:     v.CVSSVector,
+	}
+	if before.Description == "" {
+		e.Description = v.Description
+	}
+	if len(before.CWEs) == 0 {
+		e.CWEs = v.CWEs
+	}
+	return e
+}
+
+func (e *Entry) restore(v *vuln.Vulnerability) {
+	v.EPSS, v.EPSSPercentile, v.KEV = e.EPSS, e.EPSSPercentile, e.KEV
+	v.CVSS, v.CVSSSource, v.Region = e.CVSS, e.CVSSSource, e.Region
+	if e.CVSSVector != "" {
+		v.CVSSVector = e.CVSSVector
+	}
+	if v.Description == "" {
+		v.Description = e.Description
+	}
+	if len(v.CWEs) == 0 {
+		v.CWEs = e.CWEs
+	}
+}
+
+// inputs digests the fields of v, beyond its fingerprint, that enrichment
+// and static points read.
+func inputs(v *vuln.Vulnerability) string {
+	return fmt.Sprintf("%q|%v|%q|%q|%q|%q|%q", v.Severity, v.CVSS, v.CVSSVector, v.Source, v.FixedVersion, v.Organization, v.Dependency)
+}
+
+// weightsKey identifies w; fmt prints maps in key order, so equal weights
+// give equal keys.
+func weightsKey(w scoring.Weights) string {
+	return fmt.Sprintf("%v", w)
+}
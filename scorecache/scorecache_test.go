@@ -0,0 +1,115 @@
+package scorecache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/enrich"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// countingEnricher sets EPSS on every finding, and a description and
+// CWE on those without, and counts them.
+type countingEnricher struct{ seen int }
+
+func (e *countingEnricher) Name() string { return "counting" }
+
+func (e *countingEnricher) Enrich(_ context.Context, vs []vuln.Vulnerability) (int, error) {
+	for i := range vs {
+		vs[i].EPSS = 0.5
+		if vs[i].Description == "" {
+			vs[i].Description, vs[i].CWEs = "from the NVD", []string{"CWE-400"}
+		}
+	}
+	e.seen += len(vs)
+	return len(vs), nil
+}
+
+func input() []vuln.Vulnerability {
+	return []vuln.Vulnerability{
+		{Identifier: "CVE-1", AssetID: "a", CVSS: 9, Severity: vuln.Critical, Source: "aws", DueDate: vuln.NewDate(2025, 2, 20)},
+		{Identifier: "CVE-2", AssetID: "a", CVSS: 5, Severity: vuln.Medium, Source: "aws", DueDate: vuln.NewDate(2025, 2, 20), Description: "from the input"},
+	}
+}
+
+func enrichers(e *countingEnricher) []enrich.Enricher {
+	return []enrich.Enricher{e}
+}
+
+func TestCacheMatchesScoring(t *testing.T) {
+	ctx := context.Background()
+	c := New()
+	e := &countingEnricher{}
+	model := scoring.Weighted{Weights: scoring.DefaultWeights(), Now: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)}
+
+	cycle := func(vs []vuln.Vulnerability, m scoring.Weighted) Stats {
+		t.Helper()
+		stats, err := c.Enrich(ctx, vs, enrichers(e))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Score(vs, m)
+		for _, v := range vs {
+			if want := m.Score(v); v.PriorityScore != want {
+				t.Errorf("%s: cached score %v, uncached %v", v.Identifier, v.PriorityScore, want)
+			}
+		}
+		return stats
+	}
+
+	if stats := cycle(input(), model); stats.Misses != 2 || e.seen != 2 {
+		t.Errorf("cold cycle: %+v, enriched %d", stats, e.seen)
+	}
+
+	// Unchanged findings skip enrichment but keep its results, and the
+	// time points move with the clock: on 2025-02-14 both are due in 6 days.
+	later := model
+	later.Now = time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC)
+	vs := input()
+	if stats := cycle(vs, later); stats.Hits != 2 || e.seen != 2 || vs[0].EPSS != 0.5 {
+		t.Errorf("warm cycle: %+v, enriched %d, EPSS %v", stats, e.seen, vs[0].EPSS)
+	}
+	if vs[0].Description != "from the NVD" || len(vs[0].CWEs) != 1 || vs[1].Description != "from the input" || vs[1].CWEs != nil {
+		t.Errorf("warm cycle restored %+v", vs)
+	}
+
+	// A changed finding is enriched again; a vanished one is evicted.
+	vs = input()[:1]
+	vs[0].FixedVersion = "1.2.3"
+	if stats := cycle(vs, model); stats.Misses != 1 || stats.Evicted != 1 || e.seen != 3 {
+		t.Errorf("changed cycle: %+v, enriched %d", stats, e.seen)
+	}
+
+	// New weights invalidate the cached static points.
+	heavy := model
+	heavy.Weights = scoring.DefaultWeights()
+	heavy.Weights.CVSS = 1
+	cycle(input()[:1], heavy)
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if c, err := Load(path); err != nil || len(c.Entries) != 0 {
+		t.Fatalf("Load of a missing file = %+v, %v", c, err)
+	}
+	c := New()
+	if _, err := c.Enrich(context.Background(), input(), enrichers(&countingEnricher{})); err != nil {
+		t.Fatal(err)
+	}
+	c.Score(input(), scoring.Weighted{Weights: scoring.DefaultWeights()})
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &countingEnricher{}
+	stats, err := loaded.Enrich(context.Background(), input(), enrichers(e))
+	if err != nil || stats.Hits != 2 || e.seen != 0 {
+		t.Errorf("after reload: %+v, %v, enriched %d", stats, err, e.seen)
+	}
+}
@@ -0,0 +1,112 @@
+package scoring
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// DefaultBands are the bounds of the histogram bands without -score_bands:
+// one point wide.
+var DefaultBands = []float64{1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+// ParseBands parses comma-separated band bounds, as in "2,4,6,8": the
+// scores, ascending and between 0 and 10, where each band after the first
+// starts.
+func ParseBands(s string) ([]float64, error) {
+	var bounds []float64
+	for _, f := range strings.Split(s, ",") {
+		b, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, fmt.Errorf("band bound %q: want a number", f)
+		}
+		if b <= 0 || b >= 10 {
+			return nil, fmt.Errorf("band bound %v: want a score between 0 and 10", b)
+		}
+		if n := len(bounds); n > 0 && b <= bounds[n-1] {
+			return nil, fmt.Errorf("band bound %v: want bounds in ascending order", b)
+		}
+		bounds = append(bounds, b)
+	}
+	return bounds, nil
+}
+
+// Histogram counts priority scores by band, so runs under different
+// weights can be compared at a glance.
+type Histogram struct {
+	Bands []Band  `json:"bands"`
+	Mean  float64 `json:"mean"`
+}
+
+// Band counts the findings scoring from From up to To, and 10 in the last
+// band.
+type Band struct {
+	From     float64 `json:"from"`
+	To       float64 `json:"to"`
+	Findings int     `json:"findings"`
+}
+
+// NewHistogram counts the priority scores of vs in the bands that start at
+// 0 and each of bounds, as ParseBands returns them.
+func NewHistogram(vs []vuln.Vulnerability, bounds []float64) Histogram {
+	h := Histogram{Bands: make([]Band, len(bounds)+1)}
+	from := 0.0
+	for i, to := range append(slices.Clip(bounds), 10) {
+		h.Bands[i] = Band{From: from, To: to}
+		from = to
+	}
+	var sum float64
+	for i := range vs {
+		score := vs[i].PriorityScore
+		sum += score
+		j := len(bounds)
+		for k, b := range bounds {
+			if score < b {
+				j = k
+				break
+			}
+		}
+		h.Bands[j].Findings++
+	}
+	if len(vs) > 0 {
+		h.Mean = round2(sum / float64(len(vs)))
+	}
+	return h
+}
+
+// Counts returns the findings of each band, in order.
+func (h Histogram) Counts() []int {
+	counts := make([]int, len(h.Bands))
+	for i, b := range h.Bands {
+		counts[i] = b.Findings
+	}
+	return counts
+}
+
+// barWidth is how many characters wide String draws the fullest band.
+const barWidth = 40
+
+// String draws h as a bar chart, one line per band, as in
+// "  5-6    496  ########################################".
+func (h Histogram) String() string {
+	most, label, count := 0, 0, 0
+	labels := make([]string, len(h.Bands))
+	for i, b := range h.Bands {
+		labels[i] = strconv.FormatFloat(b.From, 'f', -1, 64) + "-" + strconv.FormatFloat(b.To, 'f', -1, 64)
+		most, label = max(most, b.Findings), max(label, len(labels[i]))
+		count = max(count, len(strconv.Itoa(b.Findings)))
+	}
+	var sb strings.Builder
+	for i, b := range h.Bands {
+		bar := 0
+		if b.Findings > 0 {
+			bar = max(1, b.Findings*barWidth/most)
+		}
+		line := fmt.Sprintf("  %-*s  %*d  %s", label, labels[i], count, b.Findings, strings.Repeat("#", bar))
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,44 @@
+package scoring
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestHistogram(t *testing.T) {
+	var vs []vuln.Vulnerability
+	for _, score := range []float64{0, 1.99, 2, 5.5, 7.99, 8, 10} {
+		vs = append(vs, vuln.Vulnerability{PriorityScore: score})
+	}
+	h := NewHistogram(vs, []float64{2, 4, 8})
+	if got := h.Counts(); !slices.Equal(got, []int{2, 1, 2, 2}) {
+		t.Errorf("counts %v, want [2 1 2 2]", got)
+	}
+	if h.Bands[3] != (Band{From: 8, To: 10, Findings: 2}) || h.Mean != 5.07 {
+		t.Errorf("last band %+v, mean %v", h.Bands[3], h.Mean)
+	}
+	want := "" +
+		"  0-2   2  ########################################\n" +
+		"  2-4   1  ####################\n" +
+		"  4-8   2  ########################################\n" +
+		"  8-10  2  ########################################\n"
+	if got := h.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+	if empty := NewHistogram(nil, DefaultBands); len(empty.Bands) != 10 || empty.Mean != 0 || empty.String() == "" {
+		t.Errorf("empty histogram %+v, mean %v", empty.Bands, empty.Mean)
+	}
+}
+
+func TestParseBands(t *testing.T) {
+	if got, err := ParseBands("2, 4.5,8"); err != nil || !slices.Equal(got, []float64{2, 4.5, 8}) {
+		t.Errorf("ParseBands = %v, %v", got, err)
+	}
+	for _, bad := range []string{"", "0,5", "5,10", "4,2", "2,2", "high"} {
+		if _, err := ParseBands(bad); err == nil {
+			t.Errorf("ParseBands(%q): no error", bad)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Scorer is a prioritization model: it scores a finding from 0 to 10, as
+// the action timeframes of DefaultTiers read scores.
+type Scorer interface {
+	Score(v vuln.Vulnerability) float64
+}
+
+// Scoring models NewScorer builds.
+const (
+	ModelWeighted = "weighted"
+	ModelCVSS     = "cvss"
+	ModelCVSSEPSS = "cvss_epss"
+	ModelSSVC     = "ssvc"
+)
+
+// Models lists the scoring models, the default first.
+var Models = []string{ModelWeighted, ModelCVSS, ModelCVSSEPSS, ModelSSVC}
+
+// NewScorer returns the model named model, or Weighted for "". Weighted
+// scores with w at now, and CVSSOnly by the temporal score when
+// w.Temporal is set; the other models do not read w.
+func NewScorer(model string, w Weights, now time.Time) (Scorer, error) {
+	switch model {
+	case "", ModelWeighted:
+		return Weighted{Weights: w, Now: now}, nil
+	case ModelCVSS:
+		return CVSSOnly{Temporal: w.Temporal}, nil
+	case ModelCVSSEPSS:
+		return CVSSEPSS{EPSSWeight: DefaultEPSSWeight}, nil
+	case ModelSSVC:
+		return SSVC{}, nil
+	}
+	return nil, fmt.Errorf("unknown scoring model %q (want %s)", model, strings.Join(Models, ", "))
+}
+
+// CVSSOnly scores a finding by its CVSS score alone, or by its temporal
+// score when Temporal is set, in which case v.TemporalCVSS must be set.
+type CVSSOnly struct {
+	Temporal bool
+}
+
+// Score implements Scorer.
+func (m CVSSOnly) Score(v vuln.Vulnerability) float64 {
+	if m.Temporal {
+		return round2(v.TemporalCVSS)
+	}
+	return round2(v.CVSS)
+}
+
+// DefaultEPSSWeight is the share of a CVSSEPSS score that comes from the
+// likelihood of exploitation.
+const DefaultEPSSWeight = 0.5
+
+// CVSSEPSS blends severity with the likelihood of exploitation: the CVSS
+// score weighted by 1-EPSSWeight plus the EPSS percentile, scaled to 10,
+// weighted by EPSSWeight. The percentile spreads findings more evenly than
+// the probability, which is below a few percent for most. A finding in
+// the KEV catalog is at the top percentile, and one without an EPSS
+// score, as without -epss, at the bottom.
+type CVSSEPSS struct {
+	EPSSWeight float64
+}
+
+// Score implements Scorer.
+func (m CVSSEPSS) Score(v vuln.Vulnerability) float64 {
+	p := v.EPSSPercentile
+	if v.KEV {
+		p = 1
+	}
+	return round2(math.Min((1-m.EPSSWeight)*v.CVSS+m.EPSSWeight*10*p, 10))
+}
+
+// Decision is an SSVC decision, from the least to the most urgent.
+type Decision string
+
+// SSVC decisions, as CISA names them.
+const (
+	Track     Decision = "Track"
+	TrackStar Decision = "Track*"
+	Attend    Decision = "Attend"
+	Act       Decision = "Act"
+)
+
+// decisionScores are the score ranges of the decisions, placed in the
+// DefaultTiers they lead to: Act is Immediate, Attend Urgent, Track*
+// Scheduled and Track Planned.
+var decisionScores = map[Decision][2]float64{
+	Act:       {8, 10},
+	Attend:    {6, 7.99},
+	TrackStar: {4, 5.99},
+	Track:     {0, 3.99},
+}
+
+// SSVC decision point values. Mission impact is indexed low, medium, high.
+const (
+	exploitNone = iota
+	exploitPoC
+	exploitActive
+)
+
+// ssvcTree is CISA's SSVC decision tree, by exploitation, automatable and
+// total technical impact, giving the decision for low, medium and high
+// mission and well-being impact.
+var ssvcTree = [3][2][2][3]Decision{
+	exploitNone: {
+		{{Track, Track, Track}, {Track, Track, TrackStar}},
+		{{Track, Track, Attend}, {Track, Track, Attend}},
+	},
+	exploitPoC: {
+		{{Track, Track, TrackStar}, {Track, TrackStar, Attend}},
+		{{Track, Track, Attend}, {Track, TrackStar, Attend}},
+	},
+	exploitActive: {
+		{{Track, Track, Attend}, {Track, Attend, Act}},
+		{{Attend, Attend, Act}, {Attend, Act, Act}},
+	},
+}
+
+// PoCEPSS is the EPSS probability from which SSVC takes a vulnerability
+// to have a public proof of concept.
+const PoCEPSS = 0.1
+
+// SSVC follows CISA's Stakeholder-Specific Vulnerability Categorization
+// decision tree, reading its decision points from the finding:
+//
+//   - Exploitation is active for a finding in the KEV catalog, a proof of
+//     concept when a public exploit is known or its EPSS probability is
+//     at least PoCEPSS, and none otherwise.
+//   - Automatable when the CVSS vector is network attackable with low
+//     complexity and needs neither privileges nor user interaction.
+//   - Technical impact is total for remote code execution and privilege
+//     escalation, or a vector with high confidentiality and integrity
+//     impact, and partial otherwise.
+//   - Mission and well-being impact is high on confidential and restricted
+//     data, low on public data and medium otherwise.
+//
+// The score places the decision in its action timeframe, with the CVSS
+// score ordering findings within it.
+type SSVC struct{}
+
+// Score implements Scorer.
+func (m SSVC) Score(v vuln.Vulnerability) float64 {
+	r := decisionScores[m.Decide(&v)]
+	return round2(r[0] + (r[1]-r[0])*v.CVSS/10)
+}
+
+// Decide returns the SSVC decision for v.
+func (m SSVC) Decide(v *vuln.Vulnerability) Decision {
+	exploitation := exploitNone
+	switch {
+	case v.KEV:
+		exploitation = exploitActive
+	case v.Exploit != "" || v.EPSS >= PoCEPSS:
+		exploitation = exploitPoC
+	}
+	automatable, total := 0, 0
+	if v.Class == taxonomy.RCE || v.Class == taxonomy.PrivilegeEscalation {
+		total = 1
+	}
+	if vec, err := cvss.Parse(v.CVSSVector); err == nil {
+		if vec.Metric("AV") == "N" && vec.Metric("AC") == "L" && vec.Metric("PR") == "N" && vec.Metric("UI") == "N" {
+			automatable = 1
+		}
+		if vec.Metric("C") == "H" && vec.Metric("I") == "H" {
+			total = 1
+		}
+	}
+	mission := 1
+	switch v.DataClassification {
+	case vuln.ClassPublic:
+		mission = 0
+	case vuln.ClassConfidential, vuln.ClassRestricted:
+		mission = 2
+	}
+	return ssvcTree[exploitation][automatable][total][mission]
+}
@@ -0,0 +1,110 @@
+package scoring
+
+import (
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/taxonomy"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestNewScorer(t *testing.T) {
+	w := DefaultWeights()
+	w.Temporal = true
+	for model, want := range map[string]Scorer{
+		"":            Weighted{Weights: w, Now: now},
+		ModelWeighted: Weighted{Weights: w, Now: now},
+		ModelCVSS:     CVSSOnly{Temporal: true},
+		ModelCVSSEPSS: CVSSEPSS{EPSSWeight: DefaultEPSSWeight},
+		ModelSSVC:     SSVC{},
+	} {
+		s, err := NewScorer(model, w, now)
+		if err != nil {
+			t.Fatalf("NewScorer(%q): %v", model, err)
+		}
+		v := vuln.Vulnerability{CVSS: 7.5, TemporalCVSS: 7.1, Severity: vuln.High, EPSS: 0.2, EPSSPercentile: 0.9}
+		if got, want := s.Score(v), want.Score(v); got != want {
+			t.Errorf("NewScorer(%q) scores %v, want %v", model, got, want)
+		}
+	}
+	if _, err := NewScorer("epss", w, now); err == nil {
+		t.Error("unknown model accepted")
+	}
+}
+
+func TestCVSSModels(t *testing.T) {
+	v := vuln.Vulnerability{CVSS: 7.5, TemporalCVSS: 6.96}
+	if got := (CVSSOnly{}).Score(v); got != 7.5 {
+		t.Errorf("CVSS = %v", got)
+	}
+	if got := (CVSSOnly{Temporal: true}).Score(v); got != 6.96 {
+		t.Errorf("temporal CVSS = %v", got)
+	}
+
+	m := CVSSEPSS{EPSSWeight: DefaultEPSSWeight}
+	for _, tc := range []struct {
+		v    vuln.Vulnerability
+		want float64
+	}{
+		{vuln.Vulnerability{CVSS: 7.5, EPSSPercentile: 0.9}, 8.25},
+		{vuln.Vulnerability{CVSS: 9.8}, 4.9},
+		{vuln.Vulnerability{CVSS: 5, EPSSPercentile: 0.1, KEV: true}, 7.5},
+		{vuln.Vulnerability{CVSS: 2, EPSSPercentile: 0.99}, 5.95},
+	} {
+		if got := m.Score(tc.v); got != tc.want {
+			t.Errorf("CVSS+EPSS(%v, %v, KEV %v) = %v, want %v", tc.v.CVSS, tc.v.EPSSPercentile, tc.v.KEV, got, tc.want)
+		}
+	}
+}
+
+func TestSSVC(t *testing.T) {
+	const (
+		automatable = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+		partial     = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H"
+		local       = "CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H"
+	)
+	for _, tc := range []struct {
+		name string
+		v    vuln.Vulnerability
+		want Decision
+	}{
+		{"no exploitation", vuln.Vulnerability{CVSSVector: automatable}, Track},
+		{"no exploitation, high mission impact", vuln.Vulnerability{CVSSVector: partial, DataClassification: vuln.ClassRestricted}, Attend},
+		{"proof of concept, total impact", vuln.Vulnerability{EPSS: 0.1, CVSSVector: local}, TrackStar},
+		{"proof of concept, total impact by class", vuln.Vulnerability{EPSS: 0.3, Class: taxonomy.RCE}, TrackStar},
+		{"proof of concept, public data", vuln.Vulnerability{EPSS: 0.3, Class: taxonomy.RCE, DataClassification: vuln.ClassPublic}, Track},
+		{"active, not automatable", vuln.Vulnerability{KEV: true, CVSSVector: local}, Attend},
+		{"active, automatable", vuln.Vulnerability{KEV: true, CVSSVector: automatable}, Act},
+		{"active, automatable, partial, public", vuln.Vulnerability{KEV: true, CVSSVector: partial, DataClassification: vuln.ClassPublic}, Attend},
+		{"active, no vector, partial", vuln.Vulnerability{KEV: true, Class: taxonomy.DoS}, Track},
+	} {
+		if got := (SSVC{}).Decide(&tc.v); got != tc.want {
+			t.Errorf("%s: Decide = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	// Each decision scores in the tier it leads to, ordered by CVSS.
+	for _, tc := range []struct {
+		v    vuln.Vulnerability
+		want float64
+		tier string
+	}{
+		{vuln.Vulnerability{KEV: true, CVSSVector: automatable, CVSS: 9.8}, 9.96, "Immediate"},
+		{vuln.Vulnerability{KEV: true, CVSSVector: local, CVSS: 10}, 7.99, "Urgent"},
+		{vuln.Vulnerability{EPSS: 0.3, Class: taxonomy.RCE, CVSS: 5}, 5, "Scheduled"},
+		{vuln.Vulnerability{CVSS: 10}, 3.99, "Planned"},
+	} {
+		got := (SSVC{}).Score(tc.v)
+		if got != tc.want || TierFor(got, DefaultTiers).Name != tc.tier {
+			t.Errorf("SSVC score of %+v = %v, want %v (%s)", tc.v, got, tc.want, tc.tier)
+		}
+	}
+}
+
+func TestProjectOtherModels(t *testing.T) {
+	vs := []vuln.Vulnerability{{CVSS: 8, DueDate: vuln.NewDate(2025, 3, 20)}}
+	Prioritize(vs, CVSSOnly{}, DefaultTiers)
+	Project(vs, CVSSOnly{})
+	if v := vs[0]; v.PriorityScore != 8 || v.ActionTimeframe != "Immediate" || v.ScoreIn30Days != 8 || v.ScoreIn90Days != 8 {
+		t.Errorf("projected %+v", v)
+	}
+}
@@ -0,0 +1,31 @@
+package scoring
+
+import "github.com/VioletX-Dev/devsecops-test/vuln"
+
+// ProjectionDays are how many days after the reference date Project
+// projects scores to: the ScoreIn30Days, ScoreIn60Days and ScoreIn90Days
+// of a finding.
+var ProjectionDays = [3]int{30, 60, 90}
+
+// Project sets each finding's projected scores: its priority score
+// ProjectionDays after the reference date if it is left unfixed. Under
+// Weighted only the due-date urgency changes, as the due date draws near
+// and passes, so a finding about to breach its SLA rises while one long
+// overdue stays put. The other models do not read the due date, so their
+// projections are the priority score. Call it after scoring; with
+// Weights.Temporal, TemporalCVSS must be set.
+func Project(vs []vuln.Vulnerability, s Scorer) {
+	m, ok := s.(Weighted)
+	for i := range vs {
+		v := &vs[i]
+		if !ok {
+			v.ScoreIn30Days, v.ScoreIn60Days, v.ScoreIn90Days = v.PriorityScore, v.PriorityScore, v.PriorityScore
+			continue
+		}
+		static := m.StaticPoints(*v)
+		for j, score := range []*float64{&v.ScoreIn30Days, &v.ScoreIn60Days, &v.ScoreIn90Days} {
+			later := Weighted{Weights: m.Weights, Now: m.Now.AddDate(0, 0, ProjectionDays[j])}
+			*score = m.Normalize(m.Total(*v, static, later.TimePoints(*v)))
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package scoring
+
+import (
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestProject(t *testing.T) {
+	m := Weighted{Weights: DefaultWeights(), Now: now}
+	vs := []vuln.Vulnerability{
+		// Due in 43 days: 0.5 due-date points now, 1.5 in 30 days and 3
+		// once overdue, on 7.5 static points of 14.5.
+		{CVSS: 8, Severity: vuln.High, Source: "github", DueDate: vuln.NewDate(2025, 3, 20)},
+		{CVSS: 8, Severity: vuln.High, Source: "github", DueDate: vuln.NewDate(2024, 7, 14)},
+		{CVSS: 8, Severity: vuln.High, Source: "github"},
+	}
+	Project(vs, m)
+	for i, want := range [][4]float64{{5.52, 6.21, 7.24, 7.24}, {7.24, 7.24, 7.24, 7.24}, {5.17, 5.17, 5.17, 5.17}} {
+		v := vs[i]
+		if got := [4]float64{m.Score(v), v.ScoreIn30Days, v.ScoreIn60Days, v.ScoreIn90Days}; got != want {
+			t.Errorf("finding due %s: scores %v, want %v", v.DueDate, got, want)
+		}
+	}
+}
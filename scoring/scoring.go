@@ -0,0 +1,155 @@
+// Package scoring computes priority scores and recommended action
+// timeframes for vulnerabilities.
+package scoring
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Weighted is the weighted-sum priority model: each factor contributes
+// points according to Weights, and the total is normalised to 0-10.
+type Weighted struct {
+	Weights Weights
+	// Now is the reference time for due-date urgency.
+	Now time.Time
+}
+
+// Score returns the priority score of v, from 0 to 10, rounded to two
+// decimals.
+func (m Weighted) Score(v vuln.Vulnerability) float64 {
+	return m.Normalize(m.Total(v, m.StaticPoints(v), m.TimePoints(v)))
+}
+
+// Total returns the raw score of v from its static and due-date points,
+// scaled by the factors for its data classification, asset criticality
+// and environment, exploit maturity, vulnerability class, attack vector
+// and source reliability. A scaled score may exceed Max, so Normalize caps it at 10.
+func (m Weighted) Total(v vuln.Vulnerability, static, due float64) float64 {
+	w := m.Weights
+	return (static + due) * w.classification(v.DataClassification) * w.asset(v.Criticality, v.Environment) * w.exploit(v.Exploit) * w.class(v.Class) * w.attackVector(v.CVSSVector) * w.reliability(v.Source)
+}
+
+// StaticPoints returns the raw points v earns from factors that depend
+// only on the finding: CVSS, severity, source, fix availability and
+// dependency depth. They
+// can be cached while the finding and weights are unchanged. With
+// Weights.Temporal, v.TemporalCVSS must be set.
+func (m Weighted) StaticPoints(v vuln.Vulnerability) float64 {
+	w := m.Weights
+	score := v.CVSS
+	if w.Temporal {
+		score = v.TemporalCVSS
+	}
+	raw := w.CVSS*score + w.Severity[v.Severity] + w.source(v.Source)
+	if v.HasFix() {
+		raw += w.Fix
+	}
+	if v.Dependency == vuln.DependencyTransitive {
+		raw -= w.Transitive
+	}
+	return raw
+}
+
+// TimePoints returns the raw due-date urgency points of v at m.Now.
+func (m Weighted) TimePoints(v vuln.Vulnerability) float64 {
+	return m.Weights.timePoints(&v, v.DueDate.DaysUntil(m.Now))
+}
+
+// Normalize maps a raw point total to the 0-10 priority score, rounded to
+// two decimals.
+func (m Weighted) Normalize(raw float64) float64 {
+	max := m.Weights.Max()
+	if max <= 0 {
+		return 0
+	}
+	return round2(math.Min(math.Max(raw, 0)/max*10, 10))
+}
+
+// Tier is a recommended action timeframe for findings scoring at least
+// MinScore.
+type Tier struct {
+	Name       string
+	MinScore   float64
+	WithinDays int
+}
+
+// DefaultTiers maps score ranges to action timeframes, highest first.
+var DefaultTiers = []Tier{
+	{Name: "Immediate", MinScore: 8, WithinDays: 2},
+	{Name: "Urgent", MinScore: 6, WithinDays: 7},
+	{Name: "Scheduled", MinScore: 4, WithinDays: 30},
+	{Name: "Planned", MinScore: 0, WithinDays: 90},
+}
+
+// TierFor returns the first tier whose MinScore score reaches. tiers must
+// be ordered by descending MinScore; the last tier is the fallback.
+func TierFor(score float64, tiers []Tier) Tier {
+	return tiers[TierIndex(score, tiers)]
+}
+
+// TierIndex returns the index in tiers of TierFor(score, tiers); lower is
+// more urgent.
+func TierIndex(score float64, tiers []Tier) int {
+	for i, t := range tiers {
+		if score >= t.MinScore {
+			return i
+		}
+	}
+	return len(tiers) - 1
+}
+
+// Prioritize sets every finding's temporal CVSS score, scores it with m,
+// assigns its action timeframe, and sorts vs from highest to lowest
+// priority.
+func Prioritize(vs []vuln.Vulnerability, m Scorer, tiers []Tier) {
+	for i := range vs {
+		vs[i].TemporalCVSS = TemporalCVSS(&vs[i])
+		vs[i].PriorityScore = m.Score(vs[i])
+	}
+	Rank(vs, tiers)
+}
+
+// Rank assigns the action timeframe for each finding's PriorityScore and
+// sorts vs from highest to lowest priority.
+func Rank(vs []vuln.Vulnerability, tiers []Tier) {
+	for i := range vs {
+		v := &vs[i]
+		t := TierFor(v.PriorityScore, tiers)
+		v.ActionTimeframe = t.Name
+		v.RemediateWithinDays = t.WithinDays
+	}
+	Sort(vs)
+}
+
+// Sort orders vs by descending priority score. Ties are broken by the
+// earlier due date, then severity, then identifier and unique ID, so the
+// order is deterministic.
+func Sort(vs []vuln.Vulnerability) {
+	sort.SliceStable(vs, func(i, j int) bool {
+		a, b := &vs[i], &vs[j]
+		if a.PriorityScore != b.PriorityScore {
+			return a.PriorityScore > b.PriorityScore
+		}
+		if !a.DueDate.Equal(b.DueDate.Time) {
+			if a.DueDate.IsZero() || b.DueDate.IsZero() {
+				return b.DueDate.IsZero()
+			}
+			return a.DueDate.Before(b.DueDate.Time)
+		}
+		if a.Severity.Rank() != b.Severity.Rank() {
+			return a.Severity.Rank() < b.Severity.Rank()
+		}
+		if a.Identifier != b.Identifier {
+			return a.Identifier < b.Identifier
+		}
+		return a.UniqueID < b.UniqueID
+	})
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
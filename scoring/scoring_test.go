@@ -0,0 +1,265 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+var now = time.Date(2025, 2, 5, 12, 0, 0, 0, time.UTC)
+
+func TestWeightedScore(t *testing.T) {
+	m := Weighted{Weights: DefaultWeights(), Now: now}
+	max := m.Weights.Max() // 5 + 4 + 1 + 1.5 + 3
+
+	tests := []struct {
+		name string
+		v    vuln.Vulnerability
+		raw  float64
+	}{
+		{
+			name: "critical aws overdue with fix",
+			v:    vuln.Vulnerability{CVSS: 10, Severity: vuln.Critical, Source: "aws", FixedVersion: "1.2", DueDate: vuln.NewDate(2025, 1, 1)},
+			raw:  max,
+		},
+		{
+			name: "high github due in 5 days, no fix",
+			v:    vuln.Vulnerability{CVSS: 8, Severity: vuln.High, Source: "GitHub", DueDate: vuln.NewDate(2025, 2, 10)},
+			raw:  4 + 3 + 0.5 + 2.5,
+		},
+		{
+			name: "due today is not overdue",
+			v:    vuln.Vulnerability{CVSS: 5, Severity: vuln.Medium, Source: "aws", DueDate: vuln.NewDate(2025, 2, 5)},
+			raw:  2.5 + 2 + 1 + 2.5,
+		},
+		{
+			name: "low unknown source far future",
+			v:    vuln.Vulnerability{CVSS: 2, Severity: vuln.Low, Source: "other", DueDate: vuln.NewDate(2026, 1, 1)},
+			raw:  1 + 1,
+		},
+		{
+			name: "transitive dependency",
+			v:    vuln.Vulnerability{CVSS: 4, Severity: vuln.Medium, Source: "github", Dependency: vuln.DependencyTransitive},
+			raw:  2 + 2 + 0.5 - 1,
+		},
+		{
+			name: "no due date",
+			v:    vuln.Vulnerability{CVSS: 4, Severity: vuln.Medium, Source: "aws"},
+			raw:  2 + 2 + 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := round2(tt.raw / max * 10)
+			if got := m.Score(tt.v); got != want {
+				t.Errorf("Score = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWeightedScoreClassification(t *testing.T) {
+	m := Weighted{Weights: DefaultWeights(), Now: now}
+	medium := vuln.Vulnerability{CVSS: 5.5, Severity: vuln.Medium, Source: "aws", DataClassification: vuln.ClassRestricted}
+	high := vuln.Vulnerability{CVSS: 7.5, Severity: vuln.High, Source: "aws", DataClassification: vuln.ClassPublic}
+	if m.Score(medium) <= m.Score(high) {
+		t.Errorf("restricted Medium scores %v, public High %v", m.Score(medium), m.Score(high))
+	}
+	unclassified := high
+	unclassified.DataClassification = ""
+	internal := high
+	internal.DataClassification = vuln.ClassInternal
+	if m.Score(unclassified) != m.Score(internal) || m.Score(unclassified) != round2((3.75+3+1)/m.Weights.Max()*10) {
+		t.Errorf("unclassified %v, internal %v", m.Score(unclassified), m.Score(internal))
+	}
+	top := vuln.Vulnerability{CVSS: 10, Severity: vuln.Critical, Source: "aws", FixedVersion: "1.2", DueDate: vuln.NewDate(2025, 1, 1), DataClassification: vuln.ClassRestricted}
+	if got := m.Score(top); got != 10 {
+		t.Errorf("restricted top score = %v, want capped at 10", got)
+	}
+}
+
+func TestWeightedScoreAsset(t *testing.T) {
+	m := Weighted{Weights: DefaultWeights(), Now: now}
+	v := vuln.Vulnerability{CVSS: 7.5, Severity: vuln.High, Source: "aws"}
+	raw := 3.75 + 3 + 1
+	for _, tt := range []struct {
+		criticality, environment string
+		factor                   float64
+	}{
+		{"", "", 1},
+		{vuln.CriticalityMedium, vuln.EnvStaging, 0.9},
+		{vuln.CriticalityBusinessCritical, "", 1.2},
+		{"", vuln.EnvProduction, 1.1},
+		{vuln.CriticalityBusinessCritical, vuln.EnvProduction, 1.2 * 1.1},
+		{vuln.CriticalityLow, vuln.EnvDevelopment, 0.8 * 0.8},
+	} {
+		v.Criticality, v.Environment = tt.criticality, tt.environment
+		if got, want := m.Score(v), round2(math.Min(raw*tt.factor/m.Weights.Max()*10, 10)); got != want {
+			t.Errorf("%q asset in %q: score %v, want %v", tt.criticality, tt.environment, got, want)
+		}
+	}
+}
+
+func TestWeightedScoreExploit(t *testing.T) {
+	m := Weighted{Weights: DefaultWeights(), Now: now}
+	v := vuln.Vulnerability{CVSS: 7.5, Severity: vuln.High, Source: "aws"}
+	raw := 3.75 + 3 + 1
+	for maturity, factor := range map[string]float64{"": 1, vuln.ExploitPoC: 1.1, vuln.ExploitWeaponized: 1.25} {
+		v.Exploit = maturity
+		if got, want := m.Score(v), round2(raw*factor/m.Weights.Max()*10); got != want {
+			t.Errorf("%q exploit: score %v, want %v", maturity, got, want)
+		}
+	}
+	// SSVC takes a known exploit for a proof of concept.
+	v.Class, v.Exploit = "rce", vuln.ExploitPoC
+	if got := (SSVC{}).Decide(&v); got != TrackStar {
+		t.Errorf("SSVC with an exploit = %v, want %v", got, TrackStar)
+	}
+}
+
+func TestWeightedScoreClass(t *testing.T) {
+	w := DefaultWeights()
+	w.Class = map[string]float64{"rce": 1.5, "dos": 0.5}
+	m := Weighted{Weights: w, Now: now}
+	def := Weighted{Weights: DefaultWeights(), Now: now}
+	v := vuln.Vulnerability{CVSS: 7.5, Severity: vuln.High, Source: "aws"}
+	if got, want := m.Score(v), def.Score(v); got != want {
+		t.Errorf("unclassified score = %v, want %v", got, want)
+	}
+	v.Class = "dos"
+	if got, want := m.Score(v), round2(def.Score(v)/2); got != want {
+		t.Errorf("dos score = %v, want %v", got, want)
+	}
+	v.Class = "info_disclosure"
+	if got, want := m.Score(v), def.Score(v); got != want {
+		t.Errorf("unlisted class score = %v, want %v", got, want)
+	}
+	// The class and data classification factors multiply.
+	v.Class, v.DataClassification = "rce", vuln.ClassPublic
+	if got, want := m.Score(v), round2((3.75+3+1)*0.75*1.5/w.Max()*10); got != want {
+		t.Errorf("public rce score = %v, want %v", got, want)
+	}
+}
+
+func TestWeightedScoreAttackVector(t *testing.T) {
+	w := DefaultWeights()
+	w.AttackVector = map[string]float64{cvss.Network: 1.2, cvss.Physical: 0.5}
+	m := Weighted{Weights: w, Now: now}
+	def := Weighted{Weights: DefaultWeights(), Now: now}
+	v := vuln.Vulnerability{CVSS: 6.8, Severity: vuln.Medium, Source: "aws"}
+	if got, want := m.Score(v), def.Score(v); got != want {
+		t.Errorf("no vector score = %v, want %v", got, want)
+	}
+	v.CVSSVector = "CVSS:4.0/AV:P/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"
+	if got, want := m.Score(v), round2((3.4+2+1)*0.5/w.Max()*10); got != want {
+		t.Errorf("physical score = %v, want %v", got, want)
+	}
+	v.CVSSVector = "CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H"
+	if got, want := m.Score(v), def.Score(v); got != want {
+		t.Errorf("unlisted attack vector score = %v, want %v", got, want)
+	}
+	v.CVSSVector = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"
+	if got, want := m.Score(v), round2((3.4+2+1)*1.2/w.Max()*10); got != want {
+		t.Errorf("network score = %v, want %v", got, want)
+	}
+}
+
+func TestWeightedScoreReliability(t *testing.T) {
+	w := DefaultWeights()
+	w.Reliability = map[string]float64{"github": 0.8}
+	m := Weighted{Weights: w, Now: now}
+	def := Weighted{Weights: DefaultWeights(), Now: now}
+	v := vuln.Vulnerability{CVSS: 9.8, Severity: vuln.Critical, Source: "GitHub"}
+	if got, want := m.Score(v), round2((4.9+4+0.5)*0.8/w.Max()*10); got != want {
+		t.Errorf("github score = %v, want %v", got, want)
+	}
+	v.Source = "aws"
+	if got, want := m.Score(v), def.Score(v); got != want {
+		t.Errorf("unlisted source score = %v, want %v", got, want)
+	}
+}
+
+func TestWeightedScoreNormalizeMax(t *testing.T) {
+	w := DefaultWeights()
+	w.NormalizeMax = 29 // twice the default maximum
+	m := Weighted{Weights: w, Now: now}
+	v := vuln.Vulnerability{CVSS: 7.5, Severity: vuln.High, Source: "aws"}
+	def := Weighted{Weights: DefaultWeights(), Now: now}
+	if got, want := m.Score(v), round2(def.Score(v)/2); got != want {
+		t.Errorf("score = %v, want half the default %v", got, want)
+	}
+	w.NormalizeMax = 5
+	if got := (Weighted{Weights: w, Now: now}).Score(v); got != 10 {
+		t.Errorf("score over normalize_max = %v, want capped at 10", got)
+	}
+}
+
+func TestTierFor(t *testing.T) {
+	for score, want := range map[float64]string{10: "Immediate", 8: "Immediate", 7.99: "Urgent", 4: "Scheduled", 0: "Planned"} {
+		if got := TierFor(score, DefaultTiers).Name; got != want {
+			t.Errorf("TierFor(%v) = %s, want %s", score, got, want)
+		}
+	}
+}
+
+func TestPrioritizeOrder(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{UniqueID: "low", CVSS: 3, Severity: vuln.Low, Source: "github"},
+		{UniqueID: "later", CVSS: 9, Severity: vuln.Critical, Source: "aws", DueDate: vuln.NewDate(2025, 2, 20)},
+		{UniqueID: "sooner", CVSS: 9, Severity: vuln.Critical, Source: "aws", DueDate: vuln.NewDate(2025, 2, 15)},
+	}
+	Prioritize(vs, Weighted{Weights: DefaultWeights(), Now: now}, DefaultTiers)
+
+	var order []string
+	for _, v := range vs {
+		order = append(order, v.UniqueID)
+	}
+	if order[0] != "sooner" || order[1] != "later" || order[2] != "low" {
+		t.Errorf("order = %v", order)
+	}
+	if vs[2].ActionTimeframe != "Planned" || vs[2].RemediateWithinDays != 90 {
+		t.Errorf("low finding tier = %s/%d", vs[2].ActionTimeframe, vs[2].RemediateWithinDays)
+	}
+}
+
+func TestTemporalCVSS(t *testing.T) {
+	const (
+		critical = "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"                    // 9.8
+		v4       = "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H" // 10
+	)
+	tests := []struct {
+		name string
+		v    vuln.Vulnerability
+		want float64
+	}{
+		{"no vector", vuln.Vulnerability{CVSS: 7.5}, 7.5},
+		{"no vector, official fix", vuln.Vulnerability{CVSS: 7.5, FixedVersion: "1.2"}, 7.2},
+		{"vector metrics", vuln.Vulnerability{CVSS: 5, CVSSVector: critical + "/E:U/RL:W/RC:R"}, 8.4},
+		{"KEV raises exploit maturity", vuln.Vulnerability{CVSSVector: critical + "/E:U/RL:W/RC:R", KEV: true}, 9.2},
+		{"fix fills remediation level", vuln.Vulnerability{CVSSVector: critical, FixedVersion: "1.2"}, 9.4},
+		{"vector remediation level wins", vuln.Vulnerability{CVSSVector: critical + "/RL:U", FixedVersion: "1.2"}, 9.8},
+		{"invalid vector", vuln.Vulnerability{CVSS: 6.1, CVSSVector: "CVSS:3.1/AV:N"}, 6.1},
+		{"v4.0 scores its threat metrics", vuln.Vulnerability{CVSS: 10, CVSSVector: v4 + "/E:U", FixedVersion: "1.2"}, 9.1},
+		{"KEV on v4.0 is attacked", vuln.Vulnerability{CVSSVector: v4 + "/E:U", KEV: true}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TemporalCVSS(&tt.v); got != tt.want {
+				t.Errorf("TemporalCVSS = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedScoreTemporal(t *testing.T) {
+	w := DefaultWeights()
+	w.Temporal = true
+	m := Weighted{Weights: w, Now: now}
+	v := vuln.Vulnerability{CVSS: 10, TemporalCVSS: 8, Severity: vuln.Low, Source: "other"}
+	if got, want := m.Score(v), round2((4+1)/w.Max()*10); got != want {
+		t.Errorf("Score = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package scoring
+
+import (
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// TemporalCVSS returns the CVSS v3 Temporal Score of v. The temporal
+// metrics come from v's CVSSVector, when it has one, and the base score
+// from the vector or else v.CVSS. Two signals override the vector:
+//
+//   - a finding in the KEV catalog is exploited in the wild, so Exploit
+//     Code Maturity is High whatever the vector says;
+//   - a finding with a fixed version and no Remediation Level in its
+//     vector has an Official Fix.
+//
+// Report Confidence comes only from the vector. A v4.0 vector has no
+// temporal score: its threat metric, Exploit Maturity, is part of its
+// score, which is returned with a KEV finding's exploit maturity Attacked.
+func TemporalCVSS(v *vuln.Vulnerability) float64 {
+	version, base := cvss.V31, v.CVSS
+	e, rl, rc := "X", "X", "X"
+	if v.CVSSVector != "" {
+		if vec, err := cvss.Parse(v.CVSSVector); err == nil {
+			if vec.Version == cvss.V40 {
+				if v.KEV {
+					// "A" is a valid E value, so With cannot fail.
+					vec, _ = vec.With("E", "A")
+				}
+				return vec.Score()
+			}
+			version, base = vec.Version, vec.Score()
+			e, rl, rc = vec.Metric("E"), vec.Metric("RL"), vec.Metric("RC")
+		}
+	}
+	if v.KEV {
+		e = "H"
+	}
+	if rl == "X" && v.HasFix() {
+		rl = "O"
+	}
+	score, err := cvss.Temporal(version, base, e, rl, rc)
+	if err != nil {
+		// Parse validated the vector's values and the rest are constants.
+		panic(err)
+	}
+	return score
+}
@@ -0,0 +1,260 @@
+package scoring
+
+import (
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// TimeBucket awards Points to findings due within WithinDays days. A
+// bucket with WithinDays < 0 matches findings already past their due date.
+type TimeBucket struct {
+	WithinDays int     `json:"within_days"`
+	Points     float64 `json:"points"`
+}
+
+// Weights are the points each factor contributes to the raw score. The
+// raw score is normalised to 0-10 by dividing by Max.
+type Weights struct {
+	// CVSS is multiplied by the CVSS base score (0-10), or by the
+	// temporal score when Temporal is set.
+	CVSS     float64 `json:"cvss"`
+	Temporal bool    `json:"temporal,omitempty"`
+	// Severity holds points per vendor severity level.
+	Severity map[vuln.Severity]float64 `json:"severity"`
+	// Source holds points per scanner source, keyed in lower case.
+	// Unlisted sources score zero.
+	Source map[string]float64 `json:"source"`
+	// Fix is awarded when a fixed package version is available.
+	Fix float64 `json:"fix"`
+	// Transitive is deducted from findings in transitive dependencies.
+	// Findings with no dependency graph keep their points.
+	Transitive float64 `json:"transitive"`
+	// Classification scales a finding's raw score by its asset's data
+	// classification, keyed in lower case. Findings on unclassified
+	// assets, and unlisted classifications, keep their score.
+	Classification map[string]float64 `json:"classification"`
+	// Criticality scales a finding's raw score by its asset's business
+	// criticality, one of vuln.Criticalities, and Environment by the
+	// environment it runs in, one of vuln.Environments, as classification
+	// does. Findings on assets without them keep their score.
+	Criticality map[string]float64 `json:"criticality"`
+	Environment map[string]float64 `json:"environment"`
+	// Exploit scales a finding's raw score by the maturity of the public
+	// exploits of its CVE, one of vuln.ExploitMaturities, as
+	// classification does. Findings without a known exploit keep their
+	// score.
+	Exploit map[string]float64 `json:"exploit"`
+	// Class scales a finding's raw score by its vulnerability class, one
+	// of taxonomy.Classes, as classification does. None are set by
+	// default, so unclassified findings and unlisted classes keep their
+	// score.
+	Class map[string]float64 `json:"class,omitempty"`
+	// AttackVector scales a finding's raw score by the Attack Vector of
+	// its CVSS vector, keyed by cvss.AttackVector names, as
+	// classification does. None are set by default, so findings without
+	// a vector and unlisted attack vectors keep their score.
+	AttackVector map[string]float64 `json:"attack_vector,omitempty"`
+	// Reliability scales a finding's raw score by how reliable its source
+	// is, keyed in lower case as Source is, from 0 to 1. It is learned
+	// from false-positive triage with -source_reliability; see
+	// suppress.Reliability. Unlisted sources keep their score.
+	Reliability map[string]float64 `json:"reliability,omitempty"`
+	// Time buckets, checked in order; the first match wins.
+	Time []TimeBucket `json:"time"`
+	// NormalizeMax, when positive, is the raw score that normalises to 10
+	// in place of the highest one the weights can produce, so that scores
+	// stay comparable when a factor is added. Higher raw scores are capped.
+	NormalizeMax float64 `json:"normalize_max,omitempty"`
+}
+
+// DefaultWeights returns the built-in weighting:
+//
+//   - CVSS × 0.5 (up to 5 points). The base score is the best single
+//     measure of technical impact, so it carries the largest share.
+//   - Severity: Critical 4, High 3, Medium 2, Low 1. The vendor rating
+//     adds the scanner's context (e.g. distro backport status) on top of
+//     CVSS, and is what auditors sort by.
+//   - Audit due date: overdue 3, within 7 days 2.5, within 30 days 1.5,
+//     within 90 days 0.5. Missing the SOC2 audit deadline is the business
+//     risk this tool exists to prevent.
+//   - Source: AWS 1, GitHub 0.5. AWS Inspector findings are in deployed
+//     container images; GitHub findings are in code dependencies that
+//     may not be shipped.
+//   - Fix available: 1.5. A finding with a known fixed version can be
+//     closed now, so it is worth scheduling ahead of one that cannot.
+//   - Transitive dependency: -1. A team upgrades its direct dependencies
+//     itself; a transitive one usually waits on an upstream release.
+//   - Data classification: public ×0.75, internal ×1, confidential ×1.25,
+//     restricted ×1.5, after the factors above. A breach of restricted
+//     data costs far more than one of a public site, so a Medium on a
+//     restricted system outranks a High on a public one. The factors
+//     follow the CVSS environmental security requirements (0.5-1.5).
+//   - Asset criticality: low ×0.8, medium ×1, high ×1.1,
+//     business_critical ×1.2, and environment: dev and test ×0.8, staging
+//     ×0.9, prod ×1.1. They order findings on what the business depends
+//     on first, and a production business-critical asset's ×1.32 stays
+//     short of the classification's range, so that they rarely lift a
+//     whole fleet to the score cap.
+//   - Exploit maturity: a proof of concept ×1.1, a weaponized exploit
+//     ×1.25. An exploit anyone can download, let alone run from
+//     Metasploit, turns a theoretical finding into one an unskilled
+//     attacker can use.
+func DefaultWeights() Weights {
+	return Weights{
+		CVSS: 0.5,
+		Severity: map[vuln.Severity]float64{
+			vuln.Critical: 4,
+			vuln.High:     3,
+			vuln.Medium:   2,
+			vuln.Low:      1,
+		},
+		Source: map[string]float64{
+			"aws":    1,
+			"github": 0.5,
+		},
+		Fix:        1.5,
+		Transitive: 1,
+		Classification: map[string]float64{
+			vuln.ClassPublic:       0.75,
+			vuln.ClassInternal:     1,
+			vuln.ClassConfidential: 1.25,
+			vuln.ClassRestricted:   1.5,
+		},
+		Criticality: map[string]float64{
+			vuln.CriticalityLow:              0.8,
+			vuln.CriticalityMedium:           1,
+			vuln.CriticalityHigh:             1.1,
+			vuln.CriticalityBusinessCritical: 1.2,
+		},
+		Environment: map[string]float64{
+			vuln.EnvDevelopment: 0.8,
+			vuln.EnvTest:        0.8,
+			vuln.EnvStaging:     0.9,
+			vuln.EnvProduction:  1.1,
+		},
+		Exploit: map[string]float64{
+			vuln.ExploitPoC:        1.1,
+			vuln.ExploitWeaponized: 1.25,
+		},
+		Time: []TimeBucket{
+			{WithinDays: -1, Points: 3},
+			{WithinDays: 7, Points: 2.5},
+			{WithinDays: 30, Points: 1.5},
+			{WithinDays: 90, Points: 0.5},
+		},
+	}
+}
+
+// Max returns the raw score that normalises to 10: NormalizeMax when set,
+// else the highest raw score the weights can produce for a finding on an
+// unclassified asset.
+func (w Weights) Max() float64 {
+	if w.NormalizeMax > 0 {
+		return w.NormalizeMax
+	}
+	return w.CVSS*10 + maxValue(w.Severity) + maxValue(w.Source) + w.Fix + w.maxTime()
+}
+
+// classification returns the factor for a data classification.
+func (w Weights) classification(name string) float64 {
+	if f, ok := w.Classification[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return f
+	}
+	return 1
+}
+
+// asset returns the factor for an asset's criticality and environment.
+func (w Weights) asset(criticality, environment string) float64 {
+	f := 1.0
+	if c, ok := w.Criticality[criticality]; ok {
+		f *= c
+	}
+	if e, ok := w.Environment[environment]; ok {
+		f *= e
+	}
+	return f
+}
+
+// exploit returns the factor for an exploit maturity.
+func (w Weights) exploit(maturity string) float64 {
+	if f, ok := w.Exploit[maturity]; ok {
+		return f
+	}
+	return 1
+}
+
+// class returns the factor for a vulnerability class.
+func (w Weights) class(name string) float64 {
+	if f, ok := w.Class[name]; ok {
+		return f
+	}
+	return 1
+}
+
+// attackVector returns the factor for the attack vector of a CVSS vector
+// string.
+func (w Weights) attackVector(vector string) float64 {
+	if len(w.AttackVector) == 0 || vector == "" {
+		return 1
+	}
+	if vec, err := cvss.Parse(vector); err == nil {
+		if f, ok := w.AttackVector[vec.AttackVector()]; ok {
+			return f
+		}
+	}
+	return 1
+}
+
+// reliability returns the factor for a source's reliability.
+func (w Weights) reliability(source string) float64 {
+	if f, ok := w.Reliability[strings.ToLower(strings.TrimSpace(source))]; ok {
+		return f
+	}
+	return 1
+}
+
+func (w Weights) source(name string) float64 {
+	return w.Source[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// timePoints returns the points for a finding due in days days. Findings
+// without a due date score zero.
+func (w Weights) timePoints(v *vuln.Vulnerability, days int) float64 {
+	if v.DueDate.IsZero() {
+		return 0
+	}
+	for _, b := range w.Time {
+		if b.WithinDays < 0 {
+			if days < 0 {
+				return b.Points
+			}
+			continue
+		}
+		if days <= b.WithinDays {
+			return b.Points
+		}
+	}
+	return 0
+}
+
+func (w Weights) maxTime() float64 {
+	var max float64
+	for _, b := range w.Time {
+		if b.Points > max {
+			max = b.Points
+		}
+	}
+	return max
+}
+
+func maxValue[K comparable](m map[K]float64) float64 {
+	var max float64
+	for _, v := range m {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
@@ -0,0 +1,210 @@
+// Package seal encrypts output files at rest with AES-256-GCM, for reports
+// kept where others can read them, such as shared drives.
+//
+// A sealed file is a header, the magic bytes and a random salt, followed by
+// the plaintext in chunks of ChunkSize bytes, each sealed separately. Each
+// file is encrypted with its own key, derived from the key and the salt,
+// and each chunk's nonce is its number, with the last chunk marked, so
+// that chunks cannot be reordered, dropped or the file truncated unnoticed.
+package seal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// KeySize is the size of a key, in bytes.
+const KeySize = 32
+
+// Key is an AES-256 key.
+type Key [KeySize]byte
+
+// LoadKey reads a key file: 32 bytes, or their hex or base64 encoding on
+// one line, as openssl rand writes them.
+func LoadKey(path string) (*Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k := new(Key)
+	if len(b) == KeySize {
+		copy(k[:], b)
+		return k, nil
+	}
+	text := bytes.TrimSpace(b)
+	for _, decode := range []func([]byte) ([]byte, error){
+		func(s []byte) ([]byte, error) { return hex.DecodeString(string(s)) },
+		func(s []byte) ([]byte, error) { return base64.StdEncoding.DecodeString(string(s)) },
+	} {
+		if d, err := decode(text); err == nil && len(d) == KeySize {
+			copy(k[:], d)
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: want a %d-byte key, raw or as hex or base64", path, KeySize)
+}
+
+// ChunkSize is the size of the plaintext chunks sealed separately.
+const ChunkSize = 64 << 10
+
+// magic starts a sealed file and names its format version.
+var magic = []byte("PZSEAL\x00\x01")
+
+const saltSize = 16
+
+// ErrNotSealed is NewReader's error for input without the sealed header.
+var ErrNotSealed = errors.New("not a sealed file")
+
+// ErrCorrupt is the error of reading a sealed file that does not decrypt:
+// the wrong key, or a modified or truncated file.
+var ErrCorrupt = errors.New("sealed file does not decrypt: wrong key, or modified or truncated")
+
+// fileAEAD returns the cipher of the file with salt.
+func fileAEAD(k *Key, salt []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, k[:])
+	mac.Write(salt)
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce returns the nonce of chunk n, marked when it is the last.
+func nonce(n uint64, last bool) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint64(b[3:11], n)
+	if last {
+		b[11] = 1
+	}
+	return b
+}
+
+type writer struct {
+	w    io.Writer
+	aead cipher.AEAD
+	buf  []byte
+	n    uint64
+	err  error
+}
+
+// NewWriter returns a writer that seals what is written to it with k and
+// writes it to w. Close it to write the last chunk; it does not close w.
+func NewWriter(w io.Writer, k *Key) (io.WriteCloser, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := fileAEAD(k, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(append([]byte(nil), magic...), salt...)); err != nil {
+		return nil, err
+	}
+	return &writer{w: w, aead: aead, buf: make([]byte, 0, ChunkSize)}, nil
+}
+
+func (sw *writer) Write(p []byte) (int, error) {
+	written := 0
+	for sw.err == nil && len(p) > 0 {
+		// A full chunk is sealed only once more follows, so that the last
+		// one is known at Close.
+		if len(sw.buf) == ChunkSize {
+			sw.flush(false)
+			continue
+		}
+		n := copy(sw.buf[len(sw.buf):ChunkSize], p)
+		sw.buf = sw.buf[:len(sw.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, sw.err
+}
+
+func (sw *writer) flush(last bool) {
+	sealed := sw.aead.Seal(nil, nonce(sw.n, last), sw.buf, nil)
+	sw.n++
+	sw.buf = sw.buf[:0]
+	if _, err := sw.w.Write(sealed); err != nil {
+		sw.err = err
+	}
+}
+
+var errClosed = errors.New("seal: write after Close")
+
+func (sw *writer) Close() error {
+	if sw.err != nil {
+		return sw.err
+	}
+	sw.flush(true)
+	err := sw.err
+	if err == nil {
+		sw.err = errClosed
+	}
+	return err
+}
+
+type reader struct {
+	r     *bufio.Reader
+	aead  cipher.AEAD
+	chunk []byte
+	plain []byte
+	n     uint64
+	done  bool
+}
+
+// NewReader returns a reader of the plaintext of the sealed file r, which
+// was sealed with k. Reading fails with ErrCorrupt as soon as a chunk does
+// not decrypt, and at the end of a file that was cut short.
+func NewReader(r io.Reader, k *Key) (io.Reader, error) {
+	header := make([]byte, len(magic)+saltSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:len(magic)], magic) {
+		return nil, ErrNotSealed
+	}
+	aead, err := fileAEAD(k, header[len(magic):])
+	if err != nil {
+		return nil, err
+	}
+	return &reader{r: bufio.NewReaderSize(r, ChunkSize+aead.Overhead()+1), aead: aead, chunk: make([]byte, ChunkSize+aead.Overhead())}, nil
+}
+
+func (sr *reader) Read(p []byte) (int, error) {
+	for len(sr.plain) == 0 {
+		if sr.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(sr.r, sr.chunk)
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			sr.done = true
+		case err != nil:
+			return 0, err
+		default:
+			if _, err := sr.r.Peek(1); err == io.EOF {
+				sr.done = true
+			}
+		}
+		plain, err := sr.aead.Open(sr.chunk[:0], nonce(sr.n, sr.done), sr.chunk[:n], nil)
+		if err != nil {
+			return 0, ErrCorrupt
+		}
+		sr.n++
+		sr.plain = plain
+	}
+	n := copy(p, sr.plain)
+	sr.plain = sr.plain[n:]
+	return n, nil
+}
@@ -0,0 +1,125 @@
+package seal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(t *testing.T) *Key {
+	t.Helper()
+	k := new(Key)
+	if _, err := rand.Read(k[:]); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func seal(t *testing.T, k *Key, plain []byte) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	w, err := NewWriter(&b, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Odd writes, so that chunks fill across them.
+	for len(plain) > 0 {
+		n := min(len(plain), 1000)
+		if _, err := w.Write(plain[:n]); err != nil {
+			t.Fatal(err)
+		}
+		plain = plain[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func open(k *Key, sealed []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(sealed), k)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	k := testKey(t)
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3*ChunkSize + 17} {
+		plain := bytes.Repeat([]byte("Unique ID,Asset name\n"), size/21+1)[:size]
+		sealed := seal(t, k, plain)
+		if bytes.Contains(sealed, []byte("Asset name")) {
+			t.Fatalf("%d bytes: plaintext in the sealed file", size)
+		}
+		got, err := open(k, sealed)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Errorf("%d bytes: read %d bytes back, %v", size, len(got), err)
+		}
+	}
+	if bytes.Equal(seal(t, k, []byte("a")), seal(t, k, []byte("a"))) {
+		t.Error("two files sealed alike")
+	}
+}
+
+func TestTampering(t *testing.T) {
+	k := testKey(t)
+	plain := bytes.Repeat([]byte{'x'}, 2*ChunkSize+10)
+	sealed := seal(t, k, plain)
+	chunk := ChunkSize + 16
+	header := len(magic) + saltSize
+
+	flipped := bytes.Clone(sealed)
+	flipped[header+chunk+5] ^= 1
+	swapped := append(append(append([]byte(nil), sealed[:header]...), sealed[header+chunk:header+2*chunk]...), sealed[header:header+chunk]...)
+	swapped = append(swapped, sealed[header+2*chunk:]...)
+	for name, b := range map[string][]byte{
+		"flipped bit":    flipped,
+		"truncated":      sealed[:header+2*chunk],
+		"last dropped":   sealed[:header+chunk],
+		"chunks swapped": swapped,
+		"appended":       append(bytes.Clone(sealed), 0),
+	} {
+		if _, err := open(k, b); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: %v, want ErrCorrupt", name, err)
+		}
+	}
+	if _, err := open(testKey(t), sealed); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("wrong key: %v", err)
+	}
+	if _, err := open(k, []byte("Unique ID,Asset name\n")); !errors.Is(err, ErrNotSealed) {
+		t.Errorf("plaintext: %v", err)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	k := testKey(t)
+	dir := t.TempDir()
+	for name, content := range map[string][]byte{
+		"raw":    k[:],
+		"hex":    []byte(hex.EncodeToString(k[:]) + "\n"),
+		"base64": []byte(base64.StdEncoding.EncodeToString(k[:]) + "\n"),
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := LoadKey(path)
+		if err != nil || *got != *k {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	short := filepath.Join(dir, "short")
+	if err := os.WriteFile(short, []byte("0123456789abcdef\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKey(short); err == nil {
+		t.Error("loaded a short key")
+	}
+}
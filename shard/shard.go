@@ -0,0 +1,69 @@
+// Package shard partitions findings by fingerprint so that one large run
+// can be split across workers.
+package shard
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Shard is the Index-th of Count partitions, counting from 1. The zero
+// Shard is the whole input.
+type Shard struct {
+	Index, Count int
+}
+
+// Parse parses "i/N", with 1 <= i <= N. An empty string is the zero Shard.
+func Parse(s string) (Shard, error) {
+	if s == "" {
+		return Shard{}, nil
+	}
+	is, ns, ok := strings.Cut(s, "/")
+	i, err1 := strconv.Atoi(is)
+	n, err2 := strconv.Atoi(ns)
+	if !ok || err1 != nil || err2 != nil || n < 1 || i < 1 || i > n {
+		return Shard{}, fmt.Errorf("invalid shard %q: want i/N with 1 <= i <= N", s)
+	}
+	return Shard{Index: i, Count: n}, nil
+}
+
+func (s Shard) String() string {
+	if s.Count == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Includes reports whether v belongs to s. Membership depends only on v's
+// fingerprint, so every worker agrees on it and duplicates of a finding
+// land in the same shard.
+func (s Shard) Includes(v *vuln.Vulnerability) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	sum, err := hex.DecodeString(v.Fingerprint()[:16])
+	if err != nil {
+		panic(err) // Fingerprint is always hex.
+	}
+	return int(binary.BigEndian.Uint64(sum)%uint64(s.Count)) == s.Index-1
+}
+
+// Filter returns the findings of vs that belong to s, reusing vs's
+// storage.
+func (s Shard) Filter(vs []vuln.Vulnerability) []vuln.Vulnerability {
+	if s.Count <= 1 {
+		return vs
+	}
+	kept := vs[:0]
+	for i := range vs {
+		if s.Includes(&vs[i]) {
+			kept = append(kept, vs[i])
+		}
+	}
+	return kept
+}
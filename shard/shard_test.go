@@ -0,0 +1,62 @@
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"1/1", "2/4", "4/4"} {
+		sh, err := Parse(s)
+		if err != nil || sh.String() != s {
+			t.Errorf("Parse(%q) = %v, %v", s, sh, err)
+		}
+	}
+	for _, s := range []string{"0/4", "5/4", "1/0", "1", "a/b", "1/2/3"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q): want error", s)
+		}
+	}
+	if sh, err := Parse(""); err != nil || sh != (Shard{}) {
+		t.Errorf("Parse(\"\") = %v, %v", sh, err)
+	}
+}
+
+func TestShardsPartition(t *testing.T) {
+	var vs []vuln.Vulnerability
+	for i := 0; i < 1000; i++ {
+		vs = append(vs, vuln.Vulnerability{Identifier: fmt.Sprintf("CVE-2024-%04d", i), AssetID: "a", PackageName: "p"})
+	}
+	const n = 4
+	seen := make(map[string]int)
+	for i := 1; i <= n; i++ {
+		part := Shard{Index: i, Count: n}.Filter(append([]vuln.Vulnerability(nil), vs...))
+		// Each shard should get roughly a quarter.
+		if len(part) < 200 || len(part) > 300 {
+			t.Errorf("shard %d/%d has %d findings", i, n, len(part))
+		}
+		for _, v := range part {
+			seen[v.Identifier]++
+		}
+	}
+	if len(seen) != len(vs) {
+		t.Errorf("shards cover %d findings, want %d", len(seen), len(vs))
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Errorf("%s is in %d shards", id, count)
+		}
+	}
+
+	// Duplicates share a fingerprint and so a shard.
+	a := vuln.Vulnerability{Identifier: "cve-2024-1", AssetID: "a", UniqueID: "1"}
+	b := vuln.Vulnerability{Identifier: "CVE-2024-1", AssetID: "a", UniqueID: "2"}
+	for i := 1; i <= n; i++ {
+		s := Shard{Index: i, Count: n}
+		if s.Includes(&a) != s.Includes(&b) {
+			t.Errorf("duplicates split by shard %v", s)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package simulate compares how a proposed scoring and SLA configuration
+// would treat a set of findings against the current configuration.
+package simulate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/scoring"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Transition counts findings that move from one action tier to another.
+type Transition struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Count int    `json:"count"`
+}
+
+// Report summarises the differences between two configurations.
+type Report struct {
+	Findings int `json:"findings"`
+
+	TierChanges int          `json:"tier_changes"`
+	Escalated   int          `json:"escalated"`
+	Deescalated int          `json:"deescalated"`
+	Transitions []Transition `json:"transitions"`
+
+	BreachedCurrent  int `json:"breached_current"`
+	BreachedProposed int `json:"breached_proposed"`
+	NewlyBreached    int `json:"newly_breached"`
+	NoLongerBreached int `json:"no_longer_breached"`
+
+	DueDateChanges int `json:"due_date_changes"`
+	DueEarlier     int `json:"due_earlier"`
+	DueLater       int `json:"due_later"`
+}
+
+// outcome is how one configuration treats one finding.
+type outcome struct {
+	tier     int
+	due      vuln.Date
+	breached bool
+}
+
+// Run evaluates vs under both configurations at now. vs is not modified.
+func Run(vs []vuln.Vulnerability, current, proposed *config.Config, now time.Time) Report {
+	before := evaluate(vs, current, now)
+	after := evaluate(vs, proposed, now)
+
+	r := Report{Findings: len(vs)}
+	transitions := make(map[[2]int]int)
+	for i := range vs {
+		b, a := before[i], after[i]
+		if b.tier != a.tier {
+			r.TierChanges++
+			transitions[[2]int{b.tier, a.tier}]++
+			if a.tier < b.tier {
+				r.Escalated++
+			} else {
+				r.Deescalated++
+			}
+		}
+
+		if b.breached {
+			r.BreachedCurrent++
+		}
+		if a.breached {
+			r.BreachedProposed++
+		}
+		switch {
+		case a.breached && !b.breached:
+			r.NewlyBreached++
+		case b.breached && !a.breached:
+			r.NoLongerBreached++
+		}
+
+		if !a.due.Equal(b.due.Time) {
+			r.DueDateChanges++
+			if a.due.Before(b.due.Time) {
+				r.DueEarlier++
+			} else {
+				r.DueLater++
+			}
+		}
+	}
+
+	keys := make([][2]int, 0, len(transitions))
+	for k := range transitions {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		r.Transitions = append(r.Transitions, Transition{
+			From:  scoring.DefaultTiers[k[0]].Name,
+			To:    scoring.DefaultTiers[k[1]].Name,
+			Count: transitions[k],
+		})
+	}
+	return r
+}
+
+func evaluate(vs []vuln.Vulnerability, cfg *config.Config, now time.Time) []outcome {
+	model := scoring.Weighted{Weights: cfg.Weights, Now: now}
+	out := make([]outcome, len(vs))
+	for i, v := range vs {
+		if due, ok := cfg.SLA.DueDate(v); ok {
+			v.DueDate = due
+		}
+		out[i] = outcome{
+			tier:     scoring.TierIndex(model.Score(v), scoring.DefaultTiers),
+			due:      v.DueDate,
+			breached: sla.Breached(v.DueDate, now),
+		}
+	}
+	return out
+}
+
+// WriteText writes r as a human-readable summary.
+func (r Report) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `Simulated %d findings against the proposed configuration.
+
+Tier changes:      %d (%d escalated, %d de-escalated)
+`, r.Findings, r.TierChanges, r.Escalated, r.Deescalated)
+	if err != nil {
+		return err
+	}
+	for _, t := range r.Transitions {
+		if _, err := fmt.Fprintf(w, "  %-9s -> %-9s %d\n", t.From, t.To, t.Count); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, `SLA breaches:      %d now, %d proposed (%d newly breached, %d no longer breached)
+Due date changes:  %d (%d earlier, %d later)
+`, r.BreachedCurrent, r.BreachedProposed, r.NewlyBreached, r.NoLongerBreached,
+		r.DueDateChanges, r.DueEarlier, r.DueLater)
+	return err
+}
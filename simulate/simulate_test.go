@@ -0,0 +1,70 @@
+package simulate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/sla"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+var now = time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
+
+func TestRun(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		// Due far in the future, but detected long ago: a 15-day Critical
+		// SLA makes it overdue and Immediate.
+		{UniqueID: "a", CVSS: 9.8, Severity: vuln.Critical, Source: "aws", FixedVersion: "1",
+			FirstDetectedDate: vuln.NewDate(2024, 12, 1), DueDate: vuln.NewDate(2025, 6, 1)},
+		// Already overdue; the proposed 180-day Low SLA gives it until July.
+		{UniqueID: "b", CVSS: 3, Severity: vuln.Low, Source: "github",
+			FirstDetectedDate: vuln.NewDate(2025, 1, 10), DueDate: vuln.NewDate(2025, 2, 1)},
+		// Unaffected: no window for Medium.
+		{UniqueID: "c", CVSS: 5, Severity: vuln.Medium, Source: "aws",
+			FirstDetectedDate: vuln.NewDate(2025, 1, 10), DueDate: vuln.NewDate(2025, 3, 1)},
+	}
+	current := config.Default()
+	proposed := config.Default()
+	proposed.SLA = sla.Policy{vuln.Critical: 15, vuln.Low: 180}
+
+	r := Run(vs, current, proposed, now)
+	if r.Findings != 3 {
+		t.Errorf("Findings = %d", r.Findings)
+	}
+	if r.BreachedCurrent != 1 || r.BreachedProposed != 1 || r.NewlyBreached != 1 || r.NoLongerBreached != 1 {
+		t.Errorf("breaches = %+v", r)
+	}
+	if r.DueDateChanges != 2 || r.DueEarlier != 1 || r.DueLater != 1 {
+		t.Errorf("due date changes = %+v", r)
+	}
+	if r.TierChanges != 2 || r.Escalated != 1 || r.Deescalated != 1 {
+		t.Errorf("tier changes = %+v", r)
+	}
+	if len(r.Transitions) != 2 || r.Transitions[0].From != "Urgent" || r.Transitions[0].To != "Immediate" {
+		t.Errorf("transitions = %+v", r.Transitions)
+	}
+	if vs[0].DueDate.String() != "2025-06-01" {
+		t.Error("Run modified its input")
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Tier changes:      2 (1 escalated, 1 de-escalated)", "Urgent    -> Immediate", "SLA breaches:      1 now, 1 proposed"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestRunIdentical(t *testing.T) {
+	vs := []vuln.Vulnerability{{CVSS: 7, Severity: vuln.High, DueDate: vuln.NewDate(2025, 1, 1)}}
+	r := Run(vs, config.Default(), config.Default(), now)
+	if r.TierChanges != 0 || r.DueDateChanges != 0 || r.NewlyBreached != 0 || len(r.Transitions) != 0 {
+		t.Errorf("identical configs reported changes: %+v", r)
+	}
+}
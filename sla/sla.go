@@ -0,0 +1,144 @@
+// Package sla applies remediation windows (service-level agreements) to
+// findings.
+package sla
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Policy is the maximum number of days allowed to remediate a finding of
+// each severity, measured from its first detected date.
+type Policy map[vuln.Severity]int
+
+// ParsePolicy reads a policy from a list of severity windows in days, as
+// in "Critical=15,High=30".
+func ParsePolicy(s string) (Policy, error) {
+	p := make(Policy)
+	for _, item := range strings.Split(s, ",") {
+		name, days, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("window %q: want severity=days", item)
+		}
+		sev, err := vuln.ParseSeverity(name)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(days))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("window %q: invalid number of days", item)
+		}
+		p[sev] = n
+	}
+	return p, nil
+}
+
+// DueDate returns the date v must be remediated by under p. It reports
+// false when p has no window for v's severity or v has no first detected
+// date.
+func (p Policy) DueDate(v vuln.Vulnerability) (vuln.Date, bool) {
+	days, ok := p[v.Severity]
+	if !ok || v.FirstDetectedDate.IsZero() {
+		return vuln.Date{}, false
+	}
+	return vuln.Date{Time: v.FirstDetectedDate.AddDate(0, 0, days)}, true
+}
+
+// Apply replaces each finding's due date with the one p assigns. Findings
+// p has no window for keep their due date.
+func (p Policy) Apply(vs []vuln.Vulnerability) {
+	for i := range vs {
+		if due, ok := p.DueDate(vs[i]); ok {
+			vs[i].DueDate = due
+		}
+	}
+}
+
+// Breached reports whether a finding due on due is past its due date at
+// now. A finding without a due date is never breached.
+func Breached(due vuln.Date, now time.Time) bool {
+	return !due.IsZero() && due.DaysUntil(now) < 0
+}
+
+// SLA statuses of a finding with a due date.
+const (
+	StatusWithin   = "within_sla"
+	StatusBreached = "breached"
+)
+
+// Assess sets the SLAStatus and DaysOverdue of each finding in vs with a
+// due date, at now, and the BreachesSLAOn date of those within their SLA.
+// Findings without one are cleared.
+func Assess(vs []vuln.Vulnerability, now time.Time) {
+	for i := range vs {
+		v := &vs[i]
+		v.SLAStatus, v.DaysOverdue, v.BreachesSLAOn = "", 0, vuln.Date{}
+		switch {
+		case v.DueDate.IsZero():
+		case Breached(v.DueDate, now):
+			v.SLAStatus, v.DaysOverdue = StatusBreached, -v.DueDate.DaysUntil(now)
+		default:
+			v.SLAStatus = StatusWithin
+			v.BreachesSLAOn = vuln.Date{Time: v.DueDate.AddDate(0, 0, 1)}
+		}
+	}
+}
+
+// Summary counts the SLA breaches of a set of findings.
+type Summary struct {
+	// Tracked counts the findings with an SLA status, Breached those past
+	// their due date, in total and by severity.
+	Tracked    int                   `json:"tracked"`
+	Breached   int                   `json:"breached"`
+	BySeverity map[vuln.Severity]int `json:"by_severity,omitempty"`
+	// MaxDaysOverdue is how far past its due date the most overdue
+	// finding is.
+	MaxDaysOverdue int `json:"max_days_overdue,omitempty"`
+}
+
+// Summarize counts the breaches among vs, as Assess marked them.
+func Summarize(vs []vuln.Vulnerability) Summary {
+	var s Summary
+	for i := range vs {
+		v := &vs[i]
+		switch v.SLAStatus {
+		case StatusWithin:
+			s.Tracked++
+		case StatusBreached:
+			s.Tracked++
+			s.Breached++
+			if s.BySeverity == nil {
+				s.BySeverity = make(map[vuln.Severity]int)
+			}
+			s.BySeverity[v.Severity]++
+			s.MaxDaysOverdue = max(s.MaxDaysOverdue, v.DaysOverdue)
+		}
+	}
+	return s
+}
+
+// String describes s in a sentence, as in "245 of 1377 findings are past
+// their due date (Critical 2, High 144); the most overdue by 244 days."
+func (s Summary) String() string {
+	if s.Breached == 0 {
+		return "No finding is past its due date."
+	}
+	var b strings.Builder
+	if s.Breached == 1 {
+		fmt.Fprintf(&b, "1 of %d findings is past its due date", s.Tracked)
+	} else {
+		fmt.Fprintf(&b, "%d of %d findings are past their due date", s.Breached, s.Tracked)
+	}
+	var counts []string
+	for _, sev := range vuln.Severities {
+		if n := s.BySeverity[sev]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%s %d", sev, n))
+		}
+	}
+	fmt.Fprintf(&b, " (%s); the most overdue by %d days.", strings.Join(counts, ", "), s.MaxDaysOverdue)
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package sla
+
+import (
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestPolicyDueDate(t *testing.T) {
+	p := Policy{vuln.Critical: 15}
+	v := vuln.Vulnerability{Severity: vuln.Critical, FirstDetectedDate: vuln.NewDate(2025, 1, 20)}
+	due, ok := p.DueDate(v)
+	if !ok || due.String() != "2025-02-04" {
+		t.Errorf("DueDate = %v, %v; want 2025-02-04", due, ok)
+	}
+	if _, ok := p.DueDate(vuln.Vulnerability{Severity: vuln.Low, FirstDetectedDate: v.FirstDetectedDate}); ok {
+		t.Error("severity without a window: want ok = false")
+	}
+	if _, ok := p.DueDate(vuln.Vulnerability{Severity: vuln.Critical}); ok {
+		t.Error("no first detected date: want ok = false")
+	}
+
+	vs := []vuln.Vulnerability{v, {Severity: vuln.Low, DueDate: vuln.NewDate(2025, 9, 9)}}
+	p.Apply(vs)
+	if vs[0].DueDate.String() != "2025-02-04" || vs[1].DueDate.String() != "2025-09-09" {
+		t.Errorf("Apply: due dates = %v, %v", vs[0].DueDate, vs[1].DueDate)
+	}
+}
+
+func TestBreached(t *testing.T) {
+	now := time.Date(2025, 2, 5, 15, 0, 0, 0, time.UTC)
+	if Breached(vuln.NewDate(2025, 2, 5), now) {
+		t.Error("due today is not breached")
+	}
+	if !Breached(vuln.NewDate(2025, 2, 4), now) {
+		t.Error("due yesterday is breached")
+	}
+	if Breached(vuln.Date{}, now) {
+		t.Error("no due date is never breached")
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("critical=15, High=30")
+	if err != nil || len(p) != 2 || p[vuln.Critical] != 15 || p[vuln.High] != 30 {
+		t.Errorf("ParsePolicy = %v, %v", p, err)
+	}
+	for _, s := range []string{"Critical", "Urgent=2", "Low=-1", "High=soon"} {
+		if _, err := ParsePolicy(s); err == nil {
+			t.Errorf("ParsePolicy(%q): want an error", s)
+		}
+	}
+}
+
+func TestAssess(t *testing.T) {
+	now := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{
+		{Severity: vuln.High, DueDate: vuln.NewDate(2024, 7, 14)},
+		{Severity: vuln.High, DueDate: vuln.NewDate(2025, 2, 4)},
+		{Severity: vuln.Low, DueDate: vuln.NewDate(2025, 2, 5)},
+		{Severity: vuln.Critical, SLAStatus: StatusBreached, DaysOverdue: 3},
+	}
+	Assess(vs, now)
+	for i, want := range []struct {
+		status string
+		days   int
+	}{{StatusBreached, 206}, {StatusBreached, 1}, {StatusWithin, 0}, {"", 0}} {
+		if vs[i].SLAStatus != want.status || vs[i].DaysOverdue != want.days {
+			t.Errorf("vs[%d] = %q, %d overdue; want %q, %d", i, vs[i].SLAStatus, vs[i].DaysOverdue, want.status, want.days)
+		}
+	}
+
+	if got := vs[2].BreachesSLAOn.String(); got != "2025-02-06" || !vs[0].BreachesSLAOn.IsZero() {
+		t.Errorf("breaches SLA on %q and %q, want 2025-02-06 and none once breached", got, vs[0].BreachesSLAOn)
+	}
+
+	s := Summarize(vs)
+	if s.Tracked != 3 || s.Breached != 2 || s.BySeverity[vuln.High] != 2 || s.MaxDaysOverdue != 206 {
+		t.Errorf("Summarize = %+v", s)
+	}
+	if got, want := s.String(), "2 of 3 findings are past their due date (High 2); the most overdue by 206 days."; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+	if got, want := Summarize(vs[2:3]).String(), "No finding is past its due date."; got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Verdicts of an analyst who verified a sampled finding by hand. A sample
+// not verified yet has none.
+const (
+	Confirmed     = "confirmed"
+	FalsePositive = "false_positive"
+)
+
+// Verdicts lists the verdicts an analyst can give.
+var Verdicts = []string{Confirmed, FalsePositive}
+
+// ParseVerdict parses a verdict, ignoring case and accepting "-" or a
+// space for "_".
+func ParseVerdict(s string) (string, error) {
+	norm := strings.NewReplacer("-", "_", " ", "_").Replace(strings.ToLower(strings.TrimSpace(s)))
+	for _, v := range Verdicts {
+		if norm == v {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unknown verdict %q (want one of %s)", s, strings.Join(Verdicts, ", "))
+}
+
+// Sample is a finding drawn for manual verification and, once verified,
+// the analyst's verdict. The identifying fields are kept, as in Record,
+// so the store can be read on its own.
+type Sample struct {
+	Fingerprint   string     `json:"fingerprint"`
+	Identifier    string     `json:"identifier,omitempty"`
+	AssetID       string     `json:"asset_id,omitempty"`
+	AssetName     string     `json:"asset_name,omitempty"`
+	PackageName   string     `json:"package_name,omitempty"`
+	Source        string     `json:"source,omitempty"`
+	PriorityScore float64    `json:"priority_score"`
+	SampledAt     time.Time  `json:"sampled_at"`
+	SampledBy     string     `json:"sampled_by,omitempty"`
+	Verdict       string     `json:"verdict,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	VerifiedBy    string     `json:"verified_by,omitempty"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty"`
+}
+
+// Sampled reports whether the finding with fingerprint fp has been drawn
+// before.
+func (s *Store) Sampled(fp string) bool {
+	_, ok := s.Samples[fp]
+	return ok
+}
+
+// AddSample records v as drawn at at by by, pending verification.
+func (s *Store) AddSample(v *vuln.Vulnerability, at time.Time, by string) *Sample {
+	if s.Samples == nil {
+		s.Samples = make(map[string]*Sample)
+	}
+	sm := &Sample{
+		Fingerprint: v.Fingerprint(), Identifier: v.Identifier, AssetID: v.AssetID, AssetName: v.AssetName,
+		PackageName: v.PackageName, Source: strings.ToLower(strings.TrimSpace(v.Source)), PriorityScore: v.PriorityScore,
+		SampledAt: at.UTC(), SampledBy: by,
+	}
+	s.Samples[sm.Fingerprint] = sm
+	return sm
+}
+
+// FindSample returns the sample whose fingerprint is fp or, for the
+// 12-digit prefixes tables print, starts with it. It fails unless exactly
+// one sample matches.
+func (s *Store) FindSample(fp string) (*Sample, error) {
+	if sm, ok := s.Samples[fp]; ok {
+		return sm, nil
+	}
+	var found *Sample
+	for k, sm := range s.Samples {
+		if fp == "" || !strings.HasPrefix(k, fp) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%s matches more than one sample", fp)
+		}
+		found = sm
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no sample %s", fp)
+	}
+	return found, nil
+}
+
+// Verify records the verdict of the analyst by on sm at at, replacing an
+// earlier one.
+func (sm *Sample) Verify(verdict, note, by string, at time.Time) {
+	at = at.UTC()
+	sm.Verdict, sm.Note, sm.VerifiedBy, sm.VerifiedAt = verdict, note, by, &at
+}
+
+// SampleList returns the samples, most recently drawn first.
+func (s *Store) SampleList() []*Sample {
+	ss := make([]*Sample, 0, len(s.Samples))
+	for _, sm := range s.Samples {
+		ss = append(ss, sm)
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		if !ss[i].SampledAt.Equal(ss[j].SampledAt) {
+			return ss[i].SampledAt.After(ss[j].SampledAt)
+		}
+		return ss[i].Fingerprint < ss[j].Fingerprint
+	})
+	return ss
+}
+
+// Accuracy is how many of one source's sampled findings analysts have
+// confirmed.
+type Accuracy struct {
+	Source         string `json:"source"`
+	Sampled        int    `json:"sampled"`
+	Verified       int    `json:"verified"`
+	Confirmed      int    `json:"confirmed"`
+	FalsePositives int    `json:"false_positives"`
+	// Precision is the share of verified samples confirmed, from 0 to 1.
+	Precision float64 `json:"precision"`
+}
+
+// Accuracy returns the verification results of the samples by source.
+func (s *Store) Accuracy() []Accuracy {
+	index := make(map[string]int)
+	var as []Accuracy
+	for _, sm := range s.SampleList() {
+		i, ok := index[sm.Source]
+		if !ok {
+			i = len(as)
+			index[sm.Source] = i
+			as = append(as, Accuracy{Source: sm.Source})
+		}
+		a := &as[i]
+		a.Sampled++
+		switch sm.Verdict {
+		case Confirmed:
+			a.Confirmed++
+		case FalsePositive:
+			a.FalsePositives++
+		}
+	}
+	for i := range as {
+		a := &as[i]
+		a.Verified = a.Confirmed + a.FalsePositives
+		if a.Verified > 0 {
+			a.Precision = float64(a.Confirmed) / float64(a.Verified)
+		}
+	}
+	sort.Slice(as, func(i, j int) bool { return as[i].Source < as[j].Source })
+	return as
+}
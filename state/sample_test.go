@@ -0,0 +1,62 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestSamples(t *testing.T) {
+	at := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{
+		{Identifier: "CVE-1", AssetID: "i-1", PackageName: "bash", Source: "aws", PriorityScore: 7},
+		{Identifier: "CVE-2", AssetID: "i-1", PackageName: "curl", Source: "AWS", PriorityScore: 5},
+		{Identifier: "CVE-3", AssetName: "web", PackageName: "npm-ws", Source: "github", PriorityScore: 4},
+	}
+	s := New()
+	for i := range vs {
+		s.AddSample(&vs[i], at.Add(time.Duration(i)*time.Hour), "ana")
+	}
+	if !s.Sampled(vs[0].Fingerprint()) || len(s.SampleList()) != 3 || s.SampleList()[0].Identifier != "CVE-3" {
+		t.Fatalf("samples = %+v", s.SampleList())
+	}
+
+	sm, err := s.FindSample(vs[1].Fingerprint()[:12])
+	if err != nil || sm.Identifier != "CVE-2" {
+		t.Fatalf("FindSample = %+v, %v", sm, err)
+	}
+	sm.Verify(FalsePositive, "curl is not installed", "bo", at)
+	sm, _ = s.FindSample(vs[0].Fingerprint())
+	sm.Verify(Confirmed, "", "bo", at)
+	if _, err := s.FindSample("ffff"); err == nil {
+		t.Error("FindSample(ffff) found a sample")
+	}
+	if _, err := s.FindSample(""); err == nil {
+		t.Error("FindSample matched every sample")
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	got := s.Accuracy()
+	want := []Accuracy{
+		{Source: "aws", Sampled: 2, Verified: 2, Confirmed: 1, FalsePositives: 1, Precision: 0.5},
+		{Source: "github", Sampled: 1},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Accuracy = %+v, want %+v", got, want)
+	}
+
+	if v, err := ParseVerdict("False-Positive"); err != nil || v != FalsePositive {
+		t.Errorf("ParseVerdict = %q, %v", v, err)
+	}
+	if _, err := ParseVerdict("maybe"); err == nil {
+		t.Error("ParseVerdict(maybe): want error")
+	}
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Sighting is when a finding was last in a run's input. The identifying
+// fields are kept, as in Record, so the store can be read on its own.
+type Sighting struct {
+	Identifier  string    `json:"identifier,omitempty"`
+	AssetID     string    `json:"asset_id,omitempty"`
+	AssetName   string    `json:"asset_name,omitempty"`
+	PackageName string    `json:"package_name,omitempty"`
+	LastSeen    time.Time `json:"last_seen"`
+	// Missed counts the consecutive runs since that did not report it.
+	Missed int `json:"missed_runs,omitempty"`
+}
+
+// Observe records a run at at that reported the findings of vs: they are
+// seen, and every other finding seen before is missed once more. It
+// returns the changes that follow, for Commit. A finding missed by
+// maxMissed consecutive runs moves to Stale, unless it is resolved or risk
+// accepted, and is no longer tracked; a stale finding seen again moves
+// back to Open. With maxMissed 0, findings are only tracked.
+func (s *Store) Observe(vs []vuln.Vulnerability, at time.Time, maxMissed int, by string) []Change {
+	if s.Seen == nil {
+		s.Seen = make(map[string]*Sighting)
+	}
+	at = at.UTC()
+	var changes []Change
+	seen := make(map[string]bool)
+	for i := range vs {
+		v := &vs[i]
+		fp := v.Fingerprint()
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		s.Seen[fp] = &Sighting{Identifier: v.Identifier, AssetID: v.AssetID, AssetName: v.AssetName, PackageName: v.PackageName, LastSeen: at}
+		if s.Of(fp) == Stale {
+			changes = append(changes, Change{
+				At: at, By: by, Fingerprint: fp,
+				Identifier: v.Identifier, AssetID: v.AssetID, AssetName: v.AssetName, PackageName: v.PackageName,
+				From: Stale, To: Open, Reason: "seen again",
+			})
+		}
+	}
+
+	var missed []string
+	for fp, sg := range s.Seen {
+		if seen[fp] {
+			continue
+		}
+		sg.Missed++
+		if maxMissed > 0 && sg.Missed >= maxMissed {
+			missed = append(missed, fp)
+		}
+	}
+	sort.Strings(missed)
+	for _, fp := range missed {
+		sg := s.Seen[fp]
+		delete(s.Seen, fp)
+		switch from := s.Of(fp); from {
+		case Resolved, RiskAccepted, Stale:
+		default:
+			changes = append(changes, Change{
+				At: at, By: by, Fingerprint: fp,
+				Identifier: sg.Identifier, AssetID: sg.AssetID, AssetName: sg.AssetName, PackageName: sg.PackageName,
+				From: from, To: Stale, Reason: fmt.Sprintf("not seen for %d consecutive runs since %s", sg.Missed, sg.LastSeen.Format(time.DateOnly)),
+			})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,54 @@
+package state
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestObserve(t *testing.T) {
+	a := vuln.Vulnerability{Identifier: "CVE-1", AssetID: "i-1", PackageName: "openssl"}
+	b := vuln.Vulnerability{Identifier: "CVE-2", AssetID: "i-1", PackageName: "zlib"}
+	c := vuln.Vulnerability{Identifier: "CVE-3", AssetID: "i-2", PackageName: "glibc"}
+	day := func(d int) time.Time { return time.Date(2025, 2, d, 9, 0, 0, 0, time.UTC) }
+	s := New()
+	s.Commit(s.Plan([]vuln.Vulnerability{c}, Resolved, "patched", "dev", day(1)))
+
+	if changes := s.Observe([]vuln.Vulnerability{a, b, c}, day(1), 2, "test"); len(changes) != 0 || len(s.Seen) != 3 {
+		t.Fatalf("first run: changes %+v, seen %d", changes, len(s.Seen))
+	}
+	if changes := s.Observe([]vuln.Vulnerability{a}, day(2), 2, "test"); len(changes) != 0 || s.Seen[b.Fingerprint()].Missed != 1 {
+		t.Fatalf("second run: changes %+v", changes)
+	}
+	// b and c are missed twice; c is resolved, so only b goes stale.
+	changes := s.Observe([]vuln.Vulnerability{a}, day(3), 2, "test")
+	if len(changes) != 1 || changes[0].Identifier != "CVE-2" || changes[0].From != Open || changes[0].To != Stale ||
+		!strings.HasSuffix(changes[0].Reason, "2 consecutive runs since 2025-02-01") {
+		t.Fatalf("third run: changes %+v", changes)
+	}
+	s.Commit(changes)
+	if s.Of(b.Fingerprint()) != Stale || len(s.Seen) != 1 || s.Seen[a.Fingerprint()].LastSeen != day(3) {
+		t.Errorf("after aging out: state %s, seen %+v", s.Of(b.Fingerprint()), s.Seen)
+	}
+
+	changes = s.Observe([]vuln.Vulnerability{a, b}, day(4), 2, "test")
+	if len(changes) != 1 || changes[0].From != Stale || changes[0].To != Open {
+		t.Fatalf("seen again: changes %+v", changes)
+	}
+	s.Commit(changes)
+	if s.Of(b.Fingerprint()) != Open || s.Of(c.Fingerprint()) != Resolved {
+		t.Errorf("states b %s, c %s", s.Of(b.Fingerprint()), s.Of(c.Fingerprint()))
+	}
+
+	// Without aging out, findings are only tracked.
+	for i := 0; i < 5; i++ {
+		if changes := s.Observe(nil, day(5+i), 0, "test"); len(changes) != 0 {
+			t.Fatalf("tracking only: changes %+v", changes)
+		}
+	}
+	if s.Seen[a.Fingerprint()].Missed != 5 {
+		t.Errorf("missed = %d, want 5", s.Seen[a.Fingerprint()].Missed)
+	}
+}
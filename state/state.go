@@ -0,0 +1,251 @@
+// Package state records the workflow state of findings, such as resolved
+// or risk accepted, by fingerprint, and audits every change to it.
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Workflow states. Open is the state of every finding without a record.
+// Stale is set by Observe, for findings scanners have stopped reporting.
+const (
+	Open         = "open"
+	InProgress   = "in_progress"
+	Resolved     = "resolved"
+	RiskAccepted = "risk_accepted"
+	Stale        = "stale"
+)
+
+// States lists the workflow states.
+var States = []string{Open, InProgress, Resolved, RiskAccepted, Stale}
+
+// ParseState parses a workflow state, ignoring case and accepting "-" or
+// a space for "_".
+func ParseState(s string) (string, error) {
+	norm := strings.NewReplacer("-", "_", " ", "_").Replace(strings.ToLower(strings.TrimSpace(s)))
+	for _, st := range States {
+		if norm == st {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("unknown state %q (want one of %s)", s, strings.Join(States, ", "))
+}
+
+// Record is the state of one finding. The identifying fields are kept so
+// the store can be read without the original results.
+type Record struct {
+	Fingerprint string    `json:"fingerprint"`
+	Identifier  string    `json:"identifier,omitempty"`
+	AssetID     string    `json:"asset_id,omitempty"`
+	AssetName   string    `json:"asset_name,omitempty"`
+	PackageName string    `json:"package_name,omitempty"`
+	State       string    `json:"state"`
+	Reason      string    `json:"reason,omitempty"`
+	ChangedBy   string    `json:"changed_by,omitempty"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+// Store holds records by fingerprint, and, when runs are observed, the
+// sightings of every finding and, when findings are sampled for manual
+// verification, the samples. The zero Store is not usable; use New or
+// Load.
+type Store struct {
+	Records map[string]*Record   `json:"records"`
+	Seen    map[string]*Sighting `json:"seen,omitempty"`
+	Samples map[string]*Sample   `json:"samples,omitempty"`
+}
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{Records: make(map[string]*Record)}
+}
+
+// Load reads a store saved with Save. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := New()
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Records == nil {
+		s.Records = make(map[string]*Record)
+	}
+	return s, nil
+}
+
+// Save writes s to path as indented JSON.
+func (s *Store) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Of returns the state of the finding with fingerprint fp.
+func (s *Store) Of(fp string) string {
+	if r, ok := s.Records[fp]; ok {
+		return r.State
+	}
+	return Open
+}
+
+// List returns the records, most recently changed first.
+func (s *Store) List() []*Record {
+	rs := make([]*Record, 0, len(s.Records))
+	for _, r := range s.Records {
+		rs = append(rs, r)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		if !rs[i].ChangedAt.Equal(rs[j].ChangedAt) {
+			return rs[i].ChangedAt.After(rs[j].ChangedAt)
+		}
+		return rs[i].Fingerprint < rs[j].Fingerprint
+	})
+	return rs
+}
+
+// Apply sets the State of every finding in vs from the store.
+func (s *Store) Apply(vs []vuln.Vulnerability) {
+	for i := range vs {
+		vs[i].State = s.Of(vs[i].Fingerprint())
+	}
+}
+
+// Change is one finding's transition, as written to the audit log.
+type Change struct {
+	At          time.Time `json:"at"`
+	By          string    `json:"by,omitempty"`
+	Fingerprint string    `json:"fingerprint"`
+	Identifier  string    `json:"identifier"`
+	AssetID     string    `json:"asset_id,omitempty"`
+	AssetName   string    `json:"asset_name,omitempty"`
+	PackageName string    `json:"package_name,omitempty"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// Plan returns the changes that moving vs to state to would make, one per
+// fingerprint, in vs order. Findings already in state to are left out.
+func (s *Store) Plan(vs []vuln.Vulnerability, to, reason, by string, at time.Time) []Change {
+	var changes []Change
+	seen := make(map[string]bool)
+	for i := range vs {
+		v := &vs[i]
+		fp := v.Fingerprint()
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		if from := s.Of(fp); from != to {
+			changes = append(changes, Change{
+				At: at.UTC(), By: by, Fingerprint: fp,
+				Identifier: v.Identifier, AssetID: v.AssetID, AssetName: v.AssetName, PackageName: v.PackageName,
+				From: from, To: to, Reason: reason,
+			})
+		}
+	}
+	return changes
+}
+
+// Commit applies changes to s. Moving a finding to Open removes its
+// record.
+func (s *Store) Commit(changes []Change) {
+	for _, c := range changes {
+		if c.To == Open {
+			delete(s.Records, c.Fingerprint)
+			continue
+		}
+		s.Records[c.Fingerprint] = &Record{
+			Fingerprint: c.Fingerprint, Identifier: c.Identifier,
+			AssetID: c.AssetID, AssetName: c.AssetName, PackageName: c.PackageName,
+			State: c.To, Reason: c.Reason, ChangedBy: c.By, ChangedAt: c.At,
+		}
+	}
+}
+
+// AppendAudit appends changes to the audit log at path, one JSON object
+// per line, creating the file if needed.
+func AppendAudit(path string, changes []Change) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := range changes {
+		if err := enc.Encode(&changes[i]); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadAudit reads the audit log at path, oldest change first. A missing
+// file is an empty log.
+func ReadAudit(path string) ([]Change, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var changes []Change
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var c Change
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		changes = append(changes, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return changes, nil
+}
+
+// StatesAt returns the workflow state of every finding that was not open
+// just before at, by fingerprint, replaying changes, an audit log in
+// order. Every change is audited before it is made, so the log holds the
+// store's whole history.
+func StatesAt(changes []Change, at time.Time) map[string]string {
+	states := make(map[string]string)
+	for _, c := range changes {
+		if !c.At.Before(at) {
+			continue
+		}
+		if c.To == Open {
+			delete(states, c.Fingerprint)
+			continue
+		}
+		states[c.Fingerprint] = c.To
+	}
+	return states
+}
@@ -0,0 +1,120 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestParseState(t *testing.T) {
+	for in, want := range map[string]string{"Resolved": "resolved", "risk-accepted": "risk_accepted", "In Progress": "in_progress"} {
+		if got, err := ParseState(in); err != nil || got != want {
+			t.Errorf("ParseState(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseState("done"); err == nil {
+		t.Error("ParseState(done): want error")
+	}
+}
+
+func TestPlanCommit(t *testing.T) {
+	at := time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)
+	vs := []vuln.Vulnerability{
+		{UniqueID: "1", Identifier: "CVE-1", AssetID: "i-1", PackageName: "bash"},
+		{UniqueID: "2", Identifier: "CVE-1", AssetID: "i-1", PackageName: "bash"}, // repeated report
+		{UniqueID: "3", Identifier: "CVE-2", AssetID: "i-1", PackageName: "curl"},
+	}
+	s := New()
+	s.Commit(s.Plan(vs[2:], InProgress, "patching", "ana", at))
+
+	changes := s.Plan(vs, Resolved, "decommissioned", "ana", at)
+	if len(changes) != 2 || changes[0].From != Open || changes[1].From != InProgress || changes[1].To != Resolved {
+		t.Fatalf("changes = %+v", changes)
+	}
+	s.Commit(changes)
+	if len(s.Plan(vs, Resolved, "again", "ana", at)) != 0 {
+		t.Error("findings already resolved are planned again")
+	}
+	s.Apply(vs)
+	for _, v := range vs {
+		if v.State != Resolved {
+			t.Errorf("finding %s state = %q", v.UniqueID, v.State)
+		}
+	}
+
+	s.Commit(s.Plan(vs[:1], Open, "scanner still reports it", "ana", at))
+	if _, ok := s.Records[vs[0].Fingerprint()]; ok || len(s.List()) != 1 {
+		t.Errorf("reopening kept the record: %v", s.List())
+	}
+}
+
+func TestSaveLoadAudit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+	s, err := Load(path)
+	if err != nil || len(s.Records) != 0 {
+		t.Fatalf("Load of missing file = %v, %v", s, err)
+	}
+	vs := []vuln.Vulnerability{{Identifier: "CVE-1", AssetID: "i-1"}}
+	changes := s.Plan(vs, RiskAccepted, "compensating control", "ana", time.Now())
+	s.Commit(changes)
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil || got.Of(vs[0].Fingerprint()) != RiskAccepted {
+		t.Fatalf("Load after Save: %v, %v", got, err)
+	}
+
+	audit := filepath.Join(dir, "audit.jsonl")
+	for range 2 {
+		if err := AppendAudit(audit, changes); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(audit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); lines++ {
+		var c Change
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil || c.To != RiskAccepted || c.By != "ana" {
+			t.Errorf("audit line %d: %+v, %v", lines+1, c, err)
+		}
+	}
+	if lines != 2 {
+		t.Errorf("audit log has %d lines, want 2 (appended)", lines)
+	}
+	if read, err := ReadAudit(audit); err != nil || len(read) != 2 || read[1].Reason != "compensating control" {
+		t.Errorf("ReadAudit = %+v, %v", read, err)
+	}
+	if read, err := ReadAudit(filepath.Join(dir, "none.jsonl")); err != nil || read != nil {
+		t.Errorf("ReadAudit of missing file = %+v, %v", read, err)
+	}
+}
+
+func TestStatesAt(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 9, 0, 0, 0, time.UTC) }
+	changes := []Change{
+		{At: day(1), Fingerprint: "a", From: Open, To: InProgress},
+		{At: day(2), Fingerprint: "b", From: Open, To: RiskAccepted},
+		{At: day(4), Fingerprint: "a", From: InProgress, To: Resolved},
+		{At: day(6), Fingerprint: "b", From: RiskAccepted, To: Open},
+	}
+	if got := StatesAt(changes, day(1)); len(got) != 0 {
+		t.Errorf("before the first change: %v", got)
+	}
+	if got := StatesAt(changes, day(4)); len(got) != 2 || got["a"] != InProgress || got["b"] != RiskAccepted {
+		t.Errorf("at the resolution: %v", got)
+	}
+	if got := StatesAt(changes, day(7)); len(got) != 1 || got["a"] != Resolved {
+		t.Errorf("after the reopening: %v", got)
+	}
+}
@@ -0,0 +1,169 @@
+// Package stream prioritizes inputs too large to hold in memory. Findings
+// flow from a reader through a pool of scoring workers to a writer over
+// bounded channels, so memory use depends on the buffer size and not on
+// the size of the input.
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Source yields findings one at a time, as ingest.CSVStream does: a
+// *ingest.RowError for a row that is skipped, and io.EOF at the end.
+type Source interface {
+	Next() (vuln.Vulnerability, error)
+}
+
+// DefaultBuffer is the default number of findings in flight.
+const DefaultBuffer = 1024
+
+// Pipeline reads findings from a Source, processes them on Workers
+// goroutines and writes them in input order.
+type Pipeline struct {
+	// Process prioritizes one finding, passed as a slice of one so that
+	// the slice-based stages apply, and returns the findings to write:
+	// usually vs, or none to drop the finding. It is called from all the
+	// workers at once.
+	Process func(vs []vuln.Vulnerability) ([]vuln.Vulnerability, error)
+	// Write receives the processed findings in input order, from one
+	// goroutine. Blocking in it holds back the reader.
+	Write func(ctx context.Context, v vuln.Vulnerability) error
+	// Skip, if set, receives the rows the source skips, in input order,
+	// from one goroutine.
+	Skip func(*ingest.RowError) error
+	// Workers is the number of goroutines calling Process, GOMAXPROCS by
+	// default.
+	Workers int
+	// Buffer bounds the findings read but not yet written, DefaultBuffer
+	// by default.
+	Buffer int
+}
+
+// Stats count the findings of a run.
+type Stats struct {
+	Read    int // findings read, not counting skipped rows
+	Skipped int // rows the source skipped
+	Written int // findings written
+}
+
+// item is a finding in flight, numbered in input order.
+type item struct {
+	seq int
+	vs  []vuln.Vulnerability
+}
+
+// Run streams src through p until src ends, ctx is cancelled or a
+// function of p fails, and returns what it counted with the first error.
+func (p *Pipeline) Run(ctx context.Context, src Source) (Stats, error) {
+	workers, buffer := p.Workers, p.Buffer
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var stats Stats
+	// The reader takes a token for every finding and the writer returns
+	// it once the finding is written, so a slow worker cannot make the
+	// findings waiting behind it pile up.
+	tokens := make(chan struct{}, buffer)
+	in := make(chan item, buffer)
+	out := make(chan item, buffer)
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		defer close(in)
+		for seq := 0; ; {
+			v, err := src.Next()
+			if err == io.EOF {
+				return
+			}
+			var rerr *ingest.RowError
+			if errors.As(err, &rerr) {
+				stats.Skipped++
+				if p.Skip != nil {
+					if err := p.Skip(rerr); err != nil {
+						cancel(err)
+						return
+					}
+				}
+				continue
+			}
+			if err != nil {
+				cancel(err)
+				return
+			}
+			stats.Read++
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case in <- item{seq, []vuln.Vulnerability{v}}:
+			case <-ctx.Done():
+				return
+			}
+			seq++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range in {
+				var err error
+				if it.vs, err = p.Process(it.vs); err != nil {
+					cancel(err)
+					return
+				}
+				select {
+				case out <- it:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	// Findings finished out of order wait here for those before them;
+	// the tokens bound how many.
+	pending := make(map[int][]vuln.Vulnerability)
+	next := 0
+	for it := range out {
+		pending[it.seq] = it.vs
+		for vs, ok := pending[next]; ok; vs, ok = pending[next] {
+			delete(pending, next)
+			next++
+			for _, v := range vs {
+				if ctx.Err() != nil {
+					break
+				}
+				if err := p.Write(ctx, v); err != nil {
+					cancel(err)
+					break
+				}
+				stats.Written++
+			}
+			<-tokens
+		}
+	}
+	<-read
+	return stats, context.Cause(ctx)
+}
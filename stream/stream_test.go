@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/ingest"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// counter is a Source of n findings, CVE-0 to CVE-(n-1), that skips every
+// skip-th row and counts how many it has returned.
+type counter struct {
+	n, skip int
+	next    int
+	read    atomic.Int64
+}
+
+func (c *counter) Next() (vuln.Vulnerability, error) {
+	if c.next == c.n {
+		return vuln.Vulnerability{}, io.EOF
+	}
+	i := c.next
+	c.next++
+	if c.skip > 0 && i%c.skip == c.skip-1 {
+		return vuln.Vulnerability{}, &ingest.RowError{Line: i + 2, Err: errors.New("bad row")}
+	}
+	c.read.Add(1)
+	return vuln.Vulnerability{Identifier: "CVE-" + strconv.Itoa(i), CVSS: float64(i % 10)}, nil
+}
+
+func TestRunKeepsInputOrder(t *testing.T) {
+	src := &counter{n: 1000, skip: 100}
+	var got []string
+	var skipped []int
+	p := &Pipeline{
+		Process: func(vs []vuln.Vulnerability) ([]vuln.Vulnerability, error) {
+			// Slower findings finish after later ones.
+			time.Sleep(time.Duration(vs[0].CVSS) * time.Microsecond)
+			if vs[0].CVSS == 0 {
+				return nil, nil
+			}
+			vs[0].PriorityScore = vs[0].CVSS
+			return vs, nil
+		},
+		Write: func(_ context.Context, v vuln.Vulnerability) error {
+			if v.PriorityScore != v.CVSS {
+				t.Errorf("%s not processed", v.Identifier)
+			}
+			got = append(got, v.Identifier)
+			return nil
+		},
+		Skip: func(e *ingest.RowError) error {
+			skipped = append(skipped, e.Line)
+			return nil
+		},
+		Workers: 8,
+		Buffer:  16,
+	}
+	stats, err := p.Run(context.Background(), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every hundredth row is skipped, and the findings ending in 0 dropped.
+	if stats != (Stats{Read: 990, Skipped: 10, Written: 890}) || len(got) != 890 || len(skipped) != 10 {
+		t.Fatalf("stats = %+v, wrote %d, skipped %v", stats, len(got), skipped)
+	}
+	prev := -1
+	for _, id := range got {
+		i, _ := strconv.Atoi(strings.TrimPrefix(id, "CVE-"))
+		if i <= prev {
+			t.Fatalf("wrote %s after CVE-%d", id, prev)
+		}
+		prev = i
+	}
+	if skipped[0] != 101 || skipped[9] != 1001 {
+		t.Errorf("skipped lines %v", skipped)
+	}
+}
+
+func TestRunBoundsFindingsInFlight(t *testing.T) {
+	const buffer = 8
+	src := &counter{n: 500}
+	written := 0
+	ahead := int64(0)
+	p := &Pipeline{
+		Process: func(vs []vuln.Vulnerability) ([]vuln.Vulnerability, error) { return vs, nil },
+		Write: func(_ context.Context, v vuln.Vulnerability) error {
+			time.Sleep(10 * time.Microsecond)
+			written++
+			ahead = max(ahead, src.read.Load()-int64(written))
+			return nil
+		},
+		Workers: 4,
+		Buffer:  buffer,
+	}
+	if _, err := p.Run(context.Background(), src); err != nil {
+		t.Fatal(err)
+	}
+	// The reader may hold one more finding while waiting for a token.
+	if ahead > buffer+1 {
+		t.Errorf("read %d findings ahead of the writer, want at most %d", ahead, buffer+1)
+	}
+}
+
+func TestRunStopsOnError(t *testing.T) {
+	failed := errors.New("disk full")
+	p := &Pipeline{
+		Process: func(vs []vuln.Vulnerability) ([]vuln.Vulnerability, error) { return vs, nil },
+		Write: func(_ context.Context, v vuln.Vulnerability) error {
+			if v.Identifier == "CVE-10" {
+				return failed
+			}
+			return nil
+		},
+		Buffer: 4,
+	}
+	stats, err := p.Run(context.Background(), &counter{n: 100000})
+	if !errors.Is(err, failed) || stats.Written != 10 || stats.Read == 100000 {
+		t.Errorf("Run = %+v, %v", stats, err)
+	}
+
+	p.Write = func(context.Context, vuln.Vulnerability) error { return nil }
+	p.Process = func(vs []vuln.Vulnerability) ([]vuln.Vulnerability, error) {
+		if vs[0].Identifier == "CVE-50" {
+			return nil, failed
+		}
+		return vs, nil
+	}
+	if stats, err := p.Run(context.Background(), &counter{n: 100000}); !errors.Is(err, failed) || stats.Written > 50 {
+		t.Errorf("Process error: Run = %+v, %v", stats, err)
+	}
+
+	p.Skip = func(*ingest.RowError) error { return failed }
+	if _, err := p.Run(context.Background(), &counter{n: 100, skip: 50}); !errors.Is(err, failed) {
+		t.Errorf("Skip error: Run = %v", err)
+	}
+}
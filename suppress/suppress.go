@@ -0,0 +1,201 @@
+// Package suppress records findings triaged as false positives and
+// suppresses them in later runs by fingerprint.
+package suppress
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Entry is one finding marked as a false positive. The identifying fields
+// are kept so the store can be read without the original input.
+type Entry struct {
+	Fingerprint      string    `json:"fingerprint"`
+	Identifier       string    `json:"identifier,omitempty"`
+	AssetID          string    `json:"asset_id,omitempty"`
+	PackageName      string    `json:"package_name,omitempty"`
+	InstalledVersion string    `json:"installed_version,omitempty"`
+	Source           string    `json:"source,omitempty"`
+	Reason           string    `json:"reason"`
+	MarkedBy         string    `json:"marked_by,omitempty"`
+	MarkedAt         time.Time `json:"marked_at"`
+}
+
+// Store holds false-positive entries by fingerprint. The zero Store is not
+// usable; use New or Load.
+type Store struct {
+	Entries map[string]*Entry `json:"entries"`
+}
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{Entries: make(map[string]*Entry)}
+}
+
+// Load reads a store saved with Save. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := New()
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Entries == nil {
+		s.Entries = make(map[string]*Entry)
+	}
+	return s, nil
+}
+
+// Save writes s to path as indented JSON, so that it diffs well under
+// version control.
+func (s *Store) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Mark records v as a false positive for reason, replacing any earlier
+// entry for its fingerprint.
+func (s *Store) Mark(v *vuln.Vulnerability, reason, by string, at time.Time) *Entry {
+	e := &Entry{
+		Fingerprint:      v.Fingerprint(),
+		Identifier:       v.Identifier,
+		AssetID:          v.AssetID,
+		PackageName:      v.PackageName,
+		InstalledVersion: v.InstalledVersion,
+		Source:           v.Source,
+		Reason:           reason,
+		MarkedBy:         by,
+		MarkedAt:         at.UTC(),
+	}
+	s.Entries[e.Fingerprint] = e
+	return e
+}
+
+// MarkFingerprint records the finding with fingerprint fp as a false
+// positive without its identifying fields, for findings not at hand.
+func (s *Store) MarkFingerprint(fp, reason, by string, at time.Time) *Entry {
+	e := &Entry{Fingerprint: fp, Reason: reason, MarkedBy: by, MarkedAt: at.UTC()}
+	s.Entries[fp] = e
+	return e
+}
+
+// Unmark removes the entry for fingerprint fp, reporting whether there was
+// one.
+func (s *Store) Unmark(fp string) bool {
+	_, ok := s.Entries[fp]
+	delete(s.Entries, fp)
+	return ok
+}
+
+// List returns the entries, most recently marked first.
+func (s *Store) List() []*Entry {
+	es := make([]*Entry, 0, len(s.Entries))
+	for _, e := range s.Entries {
+		es = append(es, e)
+	}
+	sort.Slice(es, func(i, j int) bool {
+		if !es[i].MarkedAt.Equal(es[j].MarkedAt) {
+			return es[i].MarkedAt.After(es[j].MarkedAt)
+		}
+		return es[i].Fingerprint < es[j].Fingerprint
+	})
+	return es
+}
+
+// Filter returns the findings of vs that are not marked, and the number
+// suppressed. It reuses vs's storage.
+func (s *Store) Filter(vs []vuln.Vulnerability) ([]vuln.Vulnerability, int) {
+	kept := vs[:0]
+	for _, v := range vs {
+		if _, ok := s.Entries[v.Fingerprint()]; !ok {
+			kept = append(kept, v)
+		}
+	}
+	return kept, len(vs) - len(kept)
+}
+
+// Rate is the false-positive rate of one source.
+type Rate struct {
+	Source         string `json:"source"`
+	Findings       int    `json:"findings"`
+	FalsePositives int    `json:"false_positives"`
+	// Rate is FalsePositives / Findings, from 0 to 1.
+	Rate float64 `json:"rate"`
+	// Reliability is the factor the source's findings are scored by with
+	// -source_reliability; see Reliability.
+	Reliability float64 `json:"reliability"`
+}
+
+// Reliability learning: a source's false-positive rate is taken over its
+// findings plus PriorFindings findings that are not false positives, so
+// that a source with few findings stays close to fully reliable until
+// triage has seen enough of it, and no source falls below MinReliability.
+const (
+	PriorFindings  = 10
+	MinReliability = 0.5
+)
+
+// Reliability returns the reliability of a source with findings findings,
+// falsePositives of them marked: one minus its smoothed false-positive
+// rate, from MinReliability to 1, rounded to four decimals.
+func Reliability(findings, falsePositives int) float64 {
+	r := 1 - float64(falsePositives)/float64(findings+PriorFindings)
+	return math.Round(math.Max(r, MinReliability)*10000) / 10000
+}
+
+// Reliabilities returns the Reliability of each source of rates, keyed in
+// lower case as scoring.Weights.Source is.
+func Reliabilities(rates []Rate) map[string]float64 {
+	m := make(map[string]float64, len(rates))
+	for _, r := range rates {
+		m[strings.ToLower(strings.TrimSpace(r.Source))] = r.Reliability
+	}
+	return m
+}
+
+// Rates returns the false-positive rate of each source among vs, highest
+// first, so the noisiest scanners lead.
+func (s *Store) Rates(vs []vuln.Vulnerability) []Rate {
+	index := make(map[string]int)
+	var rates []Rate
+	for i := range vs {
+		v := &vs[i]
+		j, ok := index[v.Source]
+		if !ok {
+			j = len(rates)
+			index[v.Source] = j
+			rates = append(rates, Rate{Source: v.Source})
+		}
+		rates[j].Findings++
+		if _, ok := s.Entries[v.Fingerprint()]; ok {
+			rates[j].FalsePositives++
+		}
+	}
+	for j := range rates {
+		r := &rates[j]
+		r.Rate = math.Round(float64(r.FalsePositives)/float64(r.Findings)*10000) / 10000
+		r.Reliability = Reliability(r.Findings, r.FalsePositives)
+	}
+	sort.SliceStable(rates, func(i, j int) bool {
+		if rates[i].Rate != rates[j].Rate {
+			return rates[i].Rate > rates[j].Rate
+		}
+		return rates[i].Source < rates[j].Source
+	})
+	return rates
+}
@@ -0,0 +1,88 @@
+package suppress
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+var findings = []vuln.Vulnerability{
+	{UniqueID: "1", Identifier: "CVE-2024-37890", AssetID: "733240030", PackageName: "npm-ws", InstalledVersion: "7.5.9", Source: "github"},
+	{UniqueID: "2", Identifier: "CVE-2024-37890", AssetID: "733240030", PackageName: "npm-ws", InstalledVersion: "7.5.9", Source: "github"}, // same fingerprint
+	{UniqueID: "3", Identifier: "CVE-2024-37890", AssetID: "733240030", PackageName: "npm-ws", InstalledVersion: "8.17.0", Source: "github"},
+	{UniqueID: "4", Identifier: "CVE-2020-1752", AssetID: "arn:aws:ecr:x", PackageName: "glibc", Source: "aws"},
+	{UniqueID: "5", Identifier: "CVE-2021-3999", AssetID: "arn:aws:ecr:x", PackageName: "glibc", Source: "aws"},
+	{UniqueID: "6", Identifier: "CVE-2022-0001", AssetID: "arn:aws:ecr:x", PackageName: "linux", Source: "aws"},
+	{UniqueID: "7", Identifier: "CVE-2022-0002", AssetID: "arn:aws:ecr:x", PackageName: "linux", Source: "aws"},
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fp.json")
+	s, err := Load(path)
+	if err != nil || len(s.Entries) != 0 {
+		t.Fatalf("Load missing file = %v, %v", s, err)
+	}
+	at := time.Date(2025, 2, 5, 10, 0, 0, 0, time.UTC)
+	s.Mark(&findings[0], "ws is only a dev dependency", "alice", at)
+	s.Mark(&findings[3], "glibc iconv not reachable", "bob", at.Add(time.Hour))
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	list := s.List()
+	if len(list) != 2 || list[0].Identifier != "CVE-2020-1752" || list[1].Reason != "ws is only a dev dependency" {
+		t.Fatalf("List = %+v", list)
+	}
+
+	// Suppression is by fingerprint: the duplicate row 2 goes too, but not
+	// the same CVE in another installed version.
+	vs := append([]vuln.Vulnerability(nil), findings...)
+	kept, n := s.Filter(vs)
+	if n != 3 || len(kept) != 4 || kept[0].UniqueID != "3" {
+		t.Errorf("Filter kept %d (suppressed %d): %+v", len(kept), n, kept)
+	}
+
+	if !s.Unmark(findings[3].Fingerprint()) || s.Unmark(findings[3].Fingerprint()) {
+		t.Error("Unmark should report whether an entry existed")
+	}
+}
+
+func TestRates(t *testing.T) {
+	s := New()
+	at := time.Now()
+	s.Mark(&findings[0], "dev dependency", "", at)
+	s.Mark(&findings[3], "unreachable", "", at)
+	got := s.Rates(findings)
+	want := []Rate{
+		{Source: "github", Findings: 3, FalsePositives: 2, Rate: 0.6667, Reliability: 0.8462},
+		{Source: "aws", Findings: 4, FalsePositives: 1, Rate: 0.25, Reliability: 0.9286},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Rates = %+v, want %+v", got, want)
+	}
+	if r := Reliabilities(got); len(r) != 2 || r["github"] != 0.8462 || r["aws"] != 0.9286 {
+		t.Errorf("Reliabilities = %v", r)
+	}
+}
+
+func TestReliability(t *testing.T) {
+	for _, tt := range []struct {
+		findings, falsePositives int
+		want                     float64
+	}{
+		{0, 0, 1},
+		{1, 1, 0.9091},
+		{90, 9, 0.91},
+		{990, 99, 0.901},
+		// Every finding a false positive still counts.
+		{1000, 1000, 0.5},
+	} {
+		if got := Reliability(tt.findings, tt.falsePositives); got != tt.want {
+			t.Errorf("Reliability(%d, %d) = %v, want %v", tt.findings, tt.falsePositives, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Package taxonomy classifies findings into high-level vulnerability
+// classes, such as remote code execution or denial of service, from their
+// CWEs and, failing those, the wording of their title and description, so
+// that policy can weigh what an exploit would let an attacker do.
+package taxonomy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Vulnerability classes, from the most to the least severe outcome. A
+// finding that fits several is put in the first.
+const (
+	RCE                 = "rce"
+	PrivilegeEscalation = "privilege_escalation"
+	InfoDisclosure      = "info_disclosure"
+	DoS                 = "dos"
+)
+
+// Classes lists the vulnerability classes in order of precedence.
+var Classes = []string{RCE, PrivilegeEscalation, InfoDisclosure, DoS}
+
+// aliases are the other names Parse accepts for each class, normalised as
+// normalize leaves them.
+var aliases = map[string]string{
+	"remotecodeexecution":   RCE,
+	"codeexecution":         RCE,
+	"privesc":               PrivilegeEscalation,
+	"elevationofprivilege":  PrivilegeEscalation,
+	"informationdisclosure": InfoDisclosure,
+	"infodisclosure":        InfoDisclosure,
+	"denialofservice":       DoS,
+}
+
+// normalize lower-cases s and drops spaces, hyphens and underscores.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(s)))
+}
+
+// Parse returns the class s names: one of Classes, in any case, or its
+// spelled-out name, as in "Remote Code Execution" or "denial-of-service".
+func Parse(s string) (string, error) {
+	n := normalize(s)
+	for _, c := range Classes {
+		if n == normalize(c) {
+			return c, nil
+		}
+	}
+	if c, ok := aliases[n]; ok {
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown vulnerability class %q (want %s)", s, strings.Join(Classes, ", "))
+}
+
+// cweClasses maps CWE IDs to the class their weakness usually leads to.
+// Memory corruption that allows writes is taken as code execution, and
+// out-of-bounds reads as disclosure.
+var cweClasses = map[string]string{}
+
+func init() {
+	for class, ids := range map[string][]int{
+		RCE: {
+			77, 78, 88, 94, 95, 96, 97, 98, 119, 120, 121, 122, 123, 415, 416,
+			434, 502, 787, 913, 915, 917, 1321, 1336,
+		},
+		PrivilegeEscalation: {
+			250, 266, 267, 268, 269, 270, 271, 272, 273, 274, 276, 279, 284, 285,
+			287, 288, 290, 306, 639, 732, 862, 863, 1390,
+		},
+		InfoDisclosure: {
+			22, 23, 36, 59, 125, 126, 200, 201, 202, 203, 208, 209, 212, 213, 215,
+			312, 319, 359, 497, 532, 538, 552, 611, 668, 918,
+		},
+		DoS: {
+			369, 400, 401, 404, 405, 407, 476, 606, 617, 674, 770, 772, 789, 834,
+			835, 1050, 1333,
+		},
+	} {
+		for _, id := range ids {
+			cweClasses[fmt.Sprintf("CWE-%d", id)] = class
+		}
+	}
+}
+
+// keywords match the wording of each class in a title or description,
+// after it is lower-cased. As with CWEs, memory corruption that allows
+// writes is taken as code execution.
+var keywords = map[string]*regexp.Regexp{
+	RCE: regexp.MustCompile(`remote code execution|\brce\b|code execution|(execute|run|inject) (arbitrary|untrusted|malicious) (code|commands?|programs?|javascript)` +
+		`|arbitrary (code|command|javascript) execution|command injection|code injection|template injection|deserialization of untrusted` +
+		`|use[- ]after[- ]free|double free|buffer overflow|out-of-bounds (stack |heap )?write`),
+	PrivilegeEscalation: regexp.MustCompile(`privilege escalation|escalat\w* (\w+ )?privileges?|elevation of privileges?` +
+		`|gain (elevated |root |administrat\w* )?privileges|authentication bypass|bypass (the )?authentication|authori[sz]ation bypass`),
+	InfoDisclosure: regexp.MustCompile(`information (disclosure|exposure|leak)|(disclose|expose|leak)s? (sensitive|confidential|private)` +
+		`|sensitive (information|data)|(path|directory) traversal|read arbitrary files|arbitrary file read|out-of-bounds read` +
+		`|buffer over-read|server-side request forgery|\bssrf\b|\bxxe\b|xml external entit`),
+	DoS: regexp.MustCompile(`denial[- ]of[- ]service|\bdos\b|\bredos\b|resource (exhaustion|consumption)|memory exhaustion` +
+		`|stack exhaustion|uncontrolled recursion|infinite loop|excessive (cpu|memory|resource)|null pointer dereference|\bcrash(es|ed)?\b`),
+}
+
+// Classify returns the class of v, or "" when neither its CWEs nor its
+// wording suggest one. CWEs decide when any of them maps to a class;
+// otherwise the title is read, and then the description. Among several
+// matches the first of Classes wins.
+func Classify(v *vuln.Vulnerability) string {
+	if c := first(func(class string) bool {
+		for _, id := range v.CWEs {
+			if cweClasses[id] == class {
+				return true
+			}
+		}
+		return false
+	}); c != "" {
+		return c
+	}
+	for _, text := range []string{v.Title, v.Description} {
+		text = strings.ToLower(text)
+		if c := first(func(class string) bool { return keywords[class].MatchString(text) }); c != "" {
+			return c
+		}
+	}
+	return ""
+}
+
+// first returns the first of Classes matching reports true for, or "".
+func first(matching func(class string) bool) string {
+	for _, c := range Classes {
+		if matching(c) {
+			return c
+		}
+	}
+	return ""
+}
+
+// Apply sets the Class of each finding in vs that the input did not
+// classify, and returns how many it classified.
+func Apply(vs []vuln.Vulnerability) int {
+	n := 0
+	for i := range vs {
+		v := &vs[i]
+		if v.Class != "" {
+			continue
+		}
+		if v.Class = Classify(v); v.Class != "" {
+			n++
+		}
+	}
+	return n
+}
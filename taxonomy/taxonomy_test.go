@@ -0,0 +1,69 @@
+package taxonomy
+
+import (
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]string{
+		"rce":                    RCE,
+		"RCE":                    RCE,
+		"Remote Code Execution":  RCE,
+		"privilege-escalation":   PrivilegeEscalation,
+		"PrivEsc":                PrivilegeEscalation,
+		"Information Disclosure": InfoDisclosure,
+		"info_disclosure":        InfoDisclosure,
+		"denial-of-service":      DoS,
+		" DoS ":                  DoS,
+	} {
+		if got, err := Parse(in); err != nil || got != want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "xss", "code"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) accepted", in)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		v    vuln.Vulnerability
+		want string
+	}{
+		{"CWE", vuln.Vulnerability{CWEs: []string{"CWE-1333"}, Title: "Remote code execution in gizmo"}, DoS},
+		{"worst CWE", vuln.Vulnerability{CWEs: []string{"CWE-400", "CWE-502"}}, RCE},
+		{"unmapped CWE falls back to wording", vuln.Vulnerability{CWEs: []string{"CWE-79"}, Title: "Information disclosure in gizmo"}, InfoDisclosure},
+		{"title before description", vuln.Vulnerability{
+			Title:       "Uncontrolled resource consumption in braces",
+			Description: "A malicious user could execute arbitrary code.",
+		}, DoS},
+		{"description", vuln.Vulnerability{Description: "A local user could use this flaw to escalate their privileges on the system."}, PrivilegeEscalation},
+		{"worst in one text", vuln.Vulnerability{Description: "allows remote attackers to cause a denial of service or possibly execute arbitrary code"}, RCE},
+		{"memory corruption", vuln.Vulnerability{Description: "A use-after-free flaw was found in the Linux kernel."}, RCE},
+		{"out-of-bounds read", vuln.Vulnerability{Description: "An out-of-bounds read in libtiff."}, InfoDisclosure},
+		{"word boundaries", vuln.Vulnerability{Title: "windows docs sources force a redos"}, DoS},
+		{"no substring match", vuln.Vulnerability{Title: "Improper handling in sourcemap dosage"}, ""},
+		{"unclassified", vuln.Vulnerability{Title: "express vulnerable to XSS via response.redirect()"}, ""},
+	} {
+		v := tc.v
+		if got := Classify(&v); got != tc.want {
+			t.Errorf("%s: Classify = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	vs := []vuln.Vulnerability{
+		{Title: "ws affected by a DoS when handling a request with many HTTP headers"},
+		{Title: "ws affected by a DoS when handling a request with many HTTP headers", Class: PrivilegeEscalation},
+		{Title: "Prototype pollution in dset"},
+	}
+	if n := Apply(vs); n != 1 || vs[0].Class != DoS || vs[1].Class != PrivilegeEscalation || vs[2].Class != "" {
+		t.Errorf("Apply = %d: %q, %q, %q", n, vs[0].Class, vs[1].Class, vs[2].Class)
+	}
+}
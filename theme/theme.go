@@ -0,0 +1,242 @@
+// Package theme brands the reports that go to customers: the title and
+// footer, the font, the heading colour, a logo, the severity palette and
+// the locale of dates and numbers, applied alike to the HTML, XLSX and PDF
+// outputs. A setting left out of a
+// theme keeps the output's own default.
+package theme
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/jpeg" // logo formats
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/locale"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// MaxLogoBytes limits the logo, which is embedded in every report.
+const MaxLogoBytes = 1 << 20
+
+// Theme is a report theme, as read from a theme file.
+type Theme struct {
+	// Title replaces the reports' title, as in "Acme Security posture
+	// report".
+	Title string `json:"title,omitempty"`
+	// Footer is printed at the end of the HTML report and on every PDF
+	// page, as in "Confidential: prepared for Example Corp".
+	Footer string `json:"footer,omitempty"`
+	// Font is the font family of the HTML reports and XLSX workbooks.
+	// PDF reports keep Helvetica, which every reader has.
+	Font string `json:"font,omitempty"`
+	// Primary is the corporate colour of headings and table headers, and
+	// PrimaryText the colour of the text on them, black or white as
+	// Primary needs when it is not set.
+	Primary     string `json:"primary,omitempty"`
+	PrimaryText string `json:"primary_text,omitempty"`
+	// Logo is a PNG or JPEG file, relative to the theme file, shown at
+	// the top of the reports.
+	Logo string `json:"logo,omitempty"`
+	// Severities are the colours of findings of each severity.
+	Severities map[vuln.Severity]string `json:"severities,omitempty"`
+	// Locale formats the dates and decimal numbers of the reports, as in
+	// de-DE, in place of ISO 8601 dates and decimal points.
+	Locale string `json:"locale,omitempty"`
+
+	logo    *Image
+	formats *locale.Locale
+}
+
+// Image is a logo, ready to embed.
+type Image struct {
+	Data []byte
+	// Type is the MIME type: image/png or image/jpeg.
+	Type          string
+	Width, Height int // in pixels
+}
+
+// Load reads the theme file at path, JSON or YAML as config.Load reads
+// configuration files, with its logo. Colours are written as #RRGGBB.
+// Unknown keys are rejected.
+func Load(path string) (*Theme, error) {
+	b, err := config.ReadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Theme
+		Severities map[string]string `json:"severities,omitempty"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	t := raw.Theme
+	if err := t.normalize(raw.Severities); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if t.Logo != "" {
+		logo := t.Logo
+		if !filepath.IsAbs(logo) {
+			logo = filepath.Join(filepath.Dir(path), logo)
+		}
+		if t.logo, err = readLogo(logo); err != nil {
+			return nil, fmt.Errorf("%s: logo: %w", path, err)
+		}
+	}
+	return &t, nil
+}
+
+// normalize validates the colours, writing them as #RRGGBB, and keys the
+// severity palette by canonical severity, since files may write
+// "critical".
+func (t *Theme) normalize(severities map[string]string) error {
+	for _, c := range []struct {
+		key string
+		val *string
+	}{{"primary", &t.Primary}, {"primary_text", &t.PrimaryText}} {
+		if *c.val == "" {
+			continue
+		}
+		hex, err := parseColor(*c.val)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.key, err)
+		}
+		*c.val = hex
+	}
+	if !fontName.MatchString(t.Font) {
+		return fmt.Errorf("font %q: want a font family name, as in Open Sans, or a list of them", t.Font)
+	}
+	if t.Primary != "" && t.PrimaryText == "" {
+		t.PrimaryText = Contrast(t.Primary)
+	}
+	if t.Locale != "" {
+		if err := t.SetLocale(t.Locale); err != nil {
+			return fmt.Errorf("locale: %w", err)
+		}
+	}
+	if len(severities) > 0 {
+		t.Severities = make(map[vuln.Severity]string, len(severities))
+	}
+	for k, v := range severities {
+		sev, err := vuln.ParseSeverity(k)
+		if err != nil {
+			return fmt.Errorf("severities: %w", err)
+		}
+		hex, err := parseColor(v)
+		if err != nil {
+			return fmt.Errorf("severities: %s: %w", k, err)
+		}
+		t.Severities[sev] = hex
+	}
+	return nil
+}
+
+// fontName matches the font families a theme may name, which go into
+// stylesheets and workbooks as they are.
+var fontName = regexp.MustCompile(`^[A-Za-z0-9 ,'"-]*$`)
+
+// parseColor parses a colour written as #RRGGBB or RRGGBB, returning it as
+// #RRGGBB in upper case.
+func parseColor(s string) (string, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return "", fmt.Errorf("colour %q: want #RRGGBB", s)
+	}
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return "", fmt.Errorf("colour %q: want #RRGGBB", s)
+	}
+	return "#" + strings.ToUpper(hex), nil
+}
+
+// readLogo reads and checks a PNG or JPEG logo.
+func readLogo(path string) (*Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxLogoBytes {
+		return nil, fmt.Errorf("%s is larger than %d KiB", path, MaxLogoBytes>>10)
+	}
+	b := make([]byte, info.Size())
+	if _, err := f.ReadAt(b, 0); err != nil {
+		return nil, err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: want a PNG or JPEG image: %w", path, err)
+	}
+	return &Image{Data: b, Type: "image/" + format, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// LogoImage returns the theme's logo, or nil when it has none. A nil
+// Theme has none.
+func (t *Theme) LogoImage() *Image {
+	if t == nil {
+		return nil
+	}
+	return t.logo
+}
+
+// SetLocale sets the locale of the reports to the one named name, as
+// locale.Parse names them.
+func (t *Theme) SetLocale(name string) error {
+	l, err := locale.Parse(name)
+	if err != nil {
+		return err
+	}
+	t.Locale, t.formats = l.Name, l
+	return nil
+}
+
+// Formats returns the theme's locale, or nil for ISO 8601 dates and
+// decimal points when it has none. A nil Theme has none.
+func (t *Theme) Formats() *locale.Locale {
+	if t == nil {
+		return nil
+	}
+	return t.formats
+}
+
+// Severity returns the colour of findings of sev, as #RRGGBB, or def
+// when the theme does not set one. A nil Theme sets none.
+func (t *Theme) Severity(sev vuln.Severity, def string) string {
+	if t == nil || t.Severities[sev] == "" {
+		return def
+	}
+	return t.Severities[sev]
+}
+
+// Contrast returns the colour of text on the #RRGGBB background bg: black
+// on light colours and white on dark ones, by their relative luminance.
+func Contrast(bg string) string {
+	r, g, b := RGB(bg)
+	if 0.2126*float64(r)+0.7152*float64(g)+0.0722*float64(b) > 140 {
+		return "#000000"
+	}
+	return "#FFFFFF"
+}
+
+// RGB returns the components of the #RRGGBB colour c, or black when c is
+// not one.
+func RGB(c string) (r, g, b uint8) {
+	n, err := strconv.ParseUint(strings.TrimPrefix(c, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(c, "#")) != 6 {
+		return 0, 0, 0
+	}
+	return uint8(n >> 16), uint8(n >> 8), uint8(n)
+}
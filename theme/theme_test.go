@@ -0,0 +1,95 @@
+package theme
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func writeFile(t *testing.T, dir, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	var logo bytes.Buffer
+	if err := png.Encode(&logo, image.NewNRGBA(image.Rect(0, 0, 120, 40))); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "acme.png", logo.Bytes())
+	th, err := Load(writeFile(t, dir, "theme.yaml", []byte(`title: Acme Security posture report
+footer: "Confidential: prepared for Example Corp"
+font: "'Open Sans', sans-serif"
+primary: "#1f3864"
+logo: acme.png
+severities:
+  critical: "#7B0000"
+  Low: 9dc3e6
+locale: de_de
+`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if th.Title != "Acme Security posture report" || th.Primary != "#1F3864" || th.PrimaryText != "#FFFFFF" {
+		t.Errorf("theme = %+v", th)
+	}
+	if got := th.Severity(vuln.Critical, "#b2182b"); got != "#7B0000" {
+		t.Errorf("Critical = %s", got)
+	}
+	if got := th.Severity(vuln.Low, ""); got != "#9DC3E6" {
+		t.Errorf("Low = %s", got)
+	}
+	if got := th.Severity(vuln.High, "#ef8a62"); got != "#ef8a62" {
+		t.Errorf("High = %s, want the default", got)
+	}
+	if l := th.LogoImage(); l == nil || l.Type != "image/png" || l.Width != 120 || l.Height != 40 {
+		t.Errorf("logo = %+v", l)
+	}
+	if f := th.Formats(); th.Locale != "de-DE" || f == nil || f.Decimal != "," {
+		t.Errorf("locale %q, formats %+v", th.Locale, f)
+	}
+
+	var none *Theme
+	if none.LogoImage() != nil || none.Severity(vuln.High, "#ef8a62") != "#ef8a62" || none.Formats() != nil {
+		t.Error("a nil theme sets something")
+	}
+
+	writeFile(t, dir, "logo.txt", []byte("not an image"))
+	for name, content := range map[string]string{
+		"colour":        `{"primary": "navy"}`,
+		"short colour":  `{"primary": "#123"}`,
+		"severity":      `{"severities": {"Severe": "#FF0000"}}`,
+		"unknown key":   `{"colour": "#FF0000"}`,
+		"font":          `{"font": "Arial; } body { display: none"}`,
+		"missing logo":  `{"logo": "missing.png"}`,
+		"not an image":  `{"logo": "logo.txt"}`,
+		"severity hex":  `{"severities": {"High": "#GG0000"}}`,
+		"text is a hex": `{"primary_text": "white"}`,
+		"locale":        `{"locale": "xx-XX"}`,
+	} {
+		if _, err := Load(writeFile(t, dir, "bad.json", []byte(content))); err == nil {
+			t.Errorf("%s: loaded %s", name, content)
+		} else if !strings.Contains(err.Error(), "bad.json") {
+			t.Errorf("%s: error %q does not name the file", name, err)
+		}
+	}
+}
+
+func TestContrast(t *testing.T) {
+	for bg, want := range map[string]string{"#FFFFFF": "#000000", "#FFEB9C": "#000000", "#1F3864": "#FFFFFF", "#B2182B": "#FFFFFF"} {
+		if got := Contrast(bg); got != want {
+			t.Errorf("Contrast(%s) = %s, want %s", bg, got, want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Package ticket writes findings as CSV in the bulk import formats of
+// issue trackers, one ticket per open finding, for teams that load
+// findings into their tracker without an API integration.
+package ticket
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/quota"
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Ticket formats.
+const (
+	Jira       = "jira"
+	ServiceNow = "servicenow"
+)
+
+// Formats lists the ticket formats, the default first.
+var Formats = []string{Jira, ServiceNow}
+
+// Summary length limits: Jira's Summary field and ServiceNow's
+// short_description column.
+const (
+	jiraSummaryMax       = 255
+	serviceNowSummaryMax = 160
+)
+
+// IssueType is the Jira issue type of the tickets. Every Jira project
+// has it.
+const IssueType = "Task"
+
+// jiraPriorities are the Jira priorities of the action timeframes.
+var jiraPriorities = map[string]string{
+	"Immediate": "Highest",
+	"Urgent":    "High",
+	"Scheduled": "Medium",
+	"Planned":   "Low",
+}
+
+// serviceNowLevels are the impact, urgency and resulting priority of the
+// action timeframes, by ServiceNow's default priority matrix: 1 is the
+// highest.
+var serviceNowLevels = map[string][3]int{
+	"Immediate": {1, 1, 1},
+	"Urgent":    {1, 2, 2},
+	"Scheduled": {2, 2, 3},
+	"Planned":   {2, 3, 4},
+}
+
+// JiraPriority returns the Jira priority of the action timeframe, or ""
+// for an unknown one.
+func JiraPriority(timeframe string) string {
+	return jiraPriorities[timeframe]
+}
+
+// closed are the workflow states of findings that need no ticket.
+var closed = map[string]bool{state.Resolved: true, state.RiskAccepted: true, state.Stale: true}
+
+// Open returns the findings of vs that need a ticket, in the order of vs:
+// those that are neither closed by their workflow state nor suppressed by
+// a waiver.
+func Open(vs []vuln.Vulnerability) []vuln.Vulnerability {
+	var open []vuln.Vulnerability
+	for i := range vs {
+		if IsOpen(&vs[i]) {
+			open = append(open, vs[i])
+		}
+	}
+	return open
+}
+
+// IsOpen reports whether v needs a ticket: whether it is neither closed by
+// its workflow state nor suppressed by a waiver.
+func IsOpen(v *vuln.Vulnerability) bool {
+	return !closed[v.State] && !v.Suppressed
+}
+
+// Write writes a ticket for each of the Open findings of vs to w in
+// format, one of Formats.
+func Write(w io.Writer, format string, vs []vuln.Vulnerability) error {
+	switch format {
+	case Jira:
+		return WriteJira(w, vs)
+	case ServiceNow:
+		return WriteServiceNow(w, vs)
+	}
+	return fmt.Errorf("unknown ticket format %q (want %s)", format, strings.Join(Formats, " or "))
+}
+
+// WriteJira writes a ticket for each of the Open findings of vs to w, as
+// Jira's CSV importer reads them. The Labels columns, "security" and the
+// finding's source, severity and team, all map to the Labels field. Due
+// Date is written as yyyy-MM-dd, the date format to give the importer.
+func WriteJira(w io.Writer, vs []vuln.Vulnerability) error {
+	cw := csv.NewWriter(w)
+	header := []string{"Summary", "Issue Type", "Priority", "Due Date", "Labels", "Labels", "Labels", "Labels", "Description"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, v := range Open(vs) {
+		row := []string{
+			truncate(Summary(&v), jiraSummaryMax),
+			IssueType,
+			jiraPriorities[v.ActionTimeframe],
+			v.DueDate.String(),
+			"security",
+			label(v.Source),
+			label(string(v.Severity)),
+			label(quota.Team(&v)),
+			Description(&v),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteServiceNow writes a ticket for each of the Open findings of vs to
+// w, with the columns of an import set for ServiceNow's task tables. The
+// correlation_id is the finding's fingerprint, so a transform map that
+// coalesces on it updates the ticket of a finding imported before.
+func WriteServiceNow(w io.Writer, vs []vuln.Vulnerability) error {
+	cw := csv.NewWriter(w)
+	header := []string{"correlation_id", "short_description", "description", "impact", "urgency", "priority", "category", "cmdb_ci", "assignment_group", "due_date"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, v := range Open(vs) {
+		levels, ok := serviceNowLevels[v.ActionTimeframe]
+		row := []string{
+			v.Fingerprint(),
+			truncate(Summary(&v), serviceNowSummaryMax),
+			Description(&v),
+			"", "", "",
+			"Security",
+			asset(&v),
+			quota.Team(&v),
+			"",
+		}
+		if ok {
+			row[3], row[4], row[5] = strconv.Itoa(levels[0]), strconv.Itoa(levels[1]), strconv.Itoa(levels[2])
+		}
+		if !v.DueDate.IsZero() {
+			row[9] = v.DueDate.String() + " 00:00:00"
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Summary returns the one-line title of v's ticket, as in "CVE-2021-44228
+// in log4j-core on prod-web-1".
+func Summary(v *vuln.Vulnerability) string {
+	var b strings.Builder
+	b.WriteString(v.Identifier)
+	if p := strings.TrimSpace(v.PackageName); p != "" {
+		b.WriteString(" in " + p)
+	}
+	if a := asset(v); a != "" {
+		b.WriteString(" on " + a)
+	}
+	return b.String()
+}
+
+// Description returns the body of v's ticket: what is affected, why it is
+// prioritized as it is, and how to fix it.
+func Description(v *vuln.Vulnerability) string {
+	var lines []string
+	add := func(format string, args ...any) { lines = append(lines, fmt.Sprintf(format, args...)) }
+	if t := strings.TrimSpace(v.Title); t != "" {
+		add("%s", t)
+	}
+	add("Priority score %.2f: %s, remediate within %d days.", v.PriorityScore, v.ActionTimeframe, v.RemediateWithinDays)
+	add("Severity %s, CVSS %.1f.", v.Severity, v.CVSS)
+	if v.KEV {
+		add("Known to be exploited (CISA KEV).")
+	}
+	if a := asset(v); a != "" {
+		add("Asset: %s", a)
+	}
+	if p := strings.TrimSpace(v.PackageName); p != "" {
+		add("Package: %s %s", p, strings.TrimSpace(v.InstalledVersion))
+	}
+	if v.HasFix() {
+		add("Fixed in: %s", strings.TrimSpace(v.FixedVersion))
+	}
+	if r := strings.TrimSpace(v.Remediation); r != "" {
+		add("Remediation: %s", r)
+	}
+	add("Unique ID %s, fingerprint %s.", v.UniqueID, v.Fingerprint())
+	return strings.Join(lines, "\n")
+}
+
+// asset returns v's asset name, or else its ID.
+func asset(v *vuln.Vulnerability) string {
+	if a := strings.TrimSpace(v.AssetName); a != "" {
+		return a
+	}
+	return strings.TrimSpace(v.AssetID)
+}
+
+// label returns s as a Jira label, which cannot hold spaces.
+func label(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), "-")
+}
+
+// truncate shortens s to at most n characters, marking the cut.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,83 @@
+package ticket
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+var findings = []vuln.Vulnerability{
+	{
+		UniqueID: "1", Identifier: "CVE-2021-44228", AssetName: "prod-web-1", AssetID: "i-1", Source: "aws", Organization: "123456789012",
+		PackageName: "log4j-core", InstalledVersion: "2.14.1", FixedVersion: "2.17.1", Severity: vuln.Critical, CVSS: 10, KEV: true,
+		Title: "Log4Shell", DueDate: vuln.NewDate(2025, 2, 7), PriorityScore: 9.34, ActionTimeframe: "Immediate", RemediateWithinDays: 2,
+	},
+	{UniqueID: "2", Identifier: "CVE-2", AssetID: "repo", Source: "github", Team: "Payments Core", Severity: vuln.Medium, CVSS: 5, PriorityScore: 4.5, ActionTimeframe: "Scheduled", RemediateWithinDays: 30},
+	{UniqueID: "3", Identifier: "CVE-3", AssetName: "db", State: state.Resolved, ActionTimeframe: "Urgent"},
+	{UniqueID: "4", Identifier: "CVE-4", AssetName: "db", Suppressed: true, ActionTimeframe: "Urgent"},
+	{UniqueID: "5", Identifier: "CVE-5", AssetName: "db", State: state.InProgress, ActionTimeframe: "Urgent"},
+}
+
+func read(t *testing.T, format string) [][]string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := Write(&b, format, findings); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestWriteJira(t *testing.T) {
+	rows := read(t, Jira)
+	if len(rows) != 4 {
+		t.Fatalf("wrote %d rows, want header and the 3 open findings", len(rows))
+	}
+	got := strings.Join(rows[1][:8], "|")
+	if want := "CVE-2021-44228 in log4j-core on prod-web-1|Task|Highest|2025-02-07|security|aws|critical|123456789012"; got != want {
+		t.Errorf("row = %s, want %s", got, want)
+	}
+	if rows[2][0] != "CVE-2 on repo" || rows[2][2] != "Medium" || rows[2][3] != "" || rows[2][7] != "payments-core" {
+		t.Errorf("second row = %q", rows[2])
+	}
+	desc := rows[1][8]
+	for _, want := range []string{"Log4Shell\n", "Priority score 9.34: Immediate, remediate within 2 days.", "Known to be exploited", "Fixed in: 2.17.1", "fingerprint " + findings[0].Fingerprint()} {
+		if !strings.Contains(desc, want) {
+			t.Errorf("description lacks %q:\n%s", want, desc)
+		}
+	}
+}
+
+func TestWriteServiceNow(t *testing.T) {
+	rows := read(t, ServiceNow)
+	if len(rows) != 4 || rows[0][0] != "correlation_id" {
+		t.Fatalf("rows = %q", rows)
+	}
+	r := rows[1]
+	if r[0] != findings[0].Fingerprint() || r[3] != "1" || r[4] != "1" || r[5] != "1" || r[7] != "prod-web-1" || r[8] != "123456789012" || r[9] != "2025-02-07 00:00:00" {
+		t.Errorf("row = %q", r)
+	}
+	if r := rows[2]; r[5] != "3" || r[7] != "repo" || r[8] != "Payments Core" || r[9] != "" {
+		t.Errorf("second row = %q", r)
+	}
+	if r := rows[3]; r[5] != "2" {
+		t.Errorf("in-progress row = %q", r)
+	}
+	if err := Write(&bytes.Buffer{}, "github", findings); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	v := vuln.Vulnerability{Identifier: "CVE-1", PackageName: strings.Repeat("é", 300)}
+	if s := truncate(Summary(&v), jiraSummaryMax); len([]rune(s)) != jiraSummaryMax || !strings.HasSuffix(s, "…") {
+		t.Errorf("truncated to %d characters", len([]rune(s)))
+	}
+}
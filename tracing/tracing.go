@@ -0,0 +1,335 @@
+// Package tracing records the stages of a run as OpenTelemetry spans and
+// exports them over OTLP/HTTP in its JSON encoding, which every OTLP
+// collector accepts. It implements the small part of OpenTelemetry the
+// tool needs, which keeps it free of the OpenTelemetry SDK and its
+// dependencies.
+//
+// A Tracer starts a trace's root span; code further down the pipeline
+// starts child spans from the context with Start, which does nothing when
+// the run is not traced.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TracesPath is the path of the OTLP/HTTP traces endpoint under a
+// collector's base URL.
+const TracesPath = "/v1/traces"
+
+// Scope is the instrumentation scope spans are exported under.
+const Scope = "github.com/VioletX-Dev/devsecops-test"
+
+// Tracer starts traces and exports them.
+type Tracer struct {
+	// Endpoint is the OTLP/HTTP traces URL spans are POSTed to, as
+	// Endpoint returns it.
+	Endpoint string
+	// Headers are sent with every export, such as an API key.
+	Headers map[string]string
+	// Service and Version name the resource spans are exported for.
+	Service string
+	Version string
+	Client  *http.Client
+}
+
+// Endpoint returns the traces URL for base: base with TracesPath added
+// when it is a collector's base URL, as OTEL_EXPORTER_OTLP_ENDPOINT is,
+// or base itself when it has a path, as OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+// is.
+func Endpoint(base string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid OTLP endpoint %q (want an http(s) URL)", base)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		u.Path = TracesPath
+	}
+	return u.String(), nil
+}
+
+// ParseHeaders parses headers as in OTEL_EXPORTER_OTLP_HEADERS: comma
+// separated key=value pairs with URL-encoded values.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid header %q (want key=value)", pair)
+		}
+		v, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", k, err)
+		}
+		headers[k] = v
+	}
+	return headers, nil
+}
+
+// Attribute is a span attribute. Values are strings, bools, ints or
+// float64s.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String, Int, Float and Bool return attributes.
+func String(key, value string) Attribute    { return Attribute{key, value} }
+func Int(key string, value int) Attribute   { return Attribute{key, value} }
+func Float(key string, v float64) Attribute { return Attribute{key, v} }
+func Bool(key string, value bool) Attribute { return Attribute{key, value} }
+
+// Span is one timed operation of a trace. A nil *Span is valid and
+// records nothing, so callers need not check whether the run is traced.
+type Span struct {
+	trace    *trace
+	id       [8]byte
+	parent   [8]byte
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    []Attribute
+	err      string
+	finished bool
+}
+
+// trace holds the spans of one trace.
+type trace struct {
+	mu    sync.Mutex
+	id    [16]byte
+	spans []*Span
+}
+
+type spanKey struct{}
+
+// Start starts the root span of a new trace, and returns ctx carrying it.
+// A nil Tracer traces nothing and returns a nil Span.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	tr := &trace{}
+	rand.Read(tr.id[:])
+	return tr.start(ctx, name, [8]byte{}, attrs)
+}
+
+// Start starts a child of the span ctx carries, and returns ctx carrying
+// the child. Without a span in ctx it returns ctx and a nil Span.
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	parent, _ := ctx.Value(spanKey{}).(*Span)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.trace.start(ctx, name, parent.id, attrs)
+}
+
+func (tr *trace) start(ctx context.Context, name string, parent [8]byte, attrs []Attribute) (context.Context, *Span) {
+	s := &Span{trace: tr, parent: parent, name: name, start: time.Now(), attrs: attrs}
+	rand.Read(s.id[:])
+	tr.mu.Lock()
+	tr.spans = append(tr.spans, s)
+	tr.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// SetAttributes adds attributes to s.
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End ends s, with an error status when err is not nil. Only the first
+// call counts.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	defer s.trace.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.end, s.finished = time.Now(), true
+	if err != nil {
+		s.err = err.Error()
+	}
+}
+
+// TraceID returns the hex trace ID of s, or "" for a nil Span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.trace.id[:])
+}
+
+// errUnfinished is the status of the spans a trace is exported with that
+// had not ended, such as those of a stage that failed.
+var errUnfinished = errors.New("span did not end")
+
+// Export ends root, and exports it with every span of its trace. Spans
+// still running end as failed. A nil root exports nothing.
+func (t *Tracer) Export(ctx context.Context, root *Span) error {
+	if t == nil || root == nil {
+		return nil
+	}
+	root.End(nil)
+	tr := root.trace
+	tr.mu.Lock()
+	spans := make([]otlpSpan, 0, len(tr.spans))
+	for _, s := range tr.spans {
+		if !s.finished {
+			s.end, s.finished, s.err = time.Now(), true, errUnfinished.Error()
+		}
+		spans = append(spans, s.otlp())
+	}
+	tr.mu.Unlock()
+
+	b, err := json.Marshal(t.request(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.Endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("POST %s: %s", t.Endpoint, resp.Status)
+	}
+	return nil
+}
+
+// The OTLP JSON encoding: IDs are hex, and 64-bit integers are strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID      string          `json:"traceId"`
+		SpanID       string          `json:"spanId"`
+		ParentSpanID string          `json:"parentSpanId,omitempty"`
+		Name         string          `json:"name"`
+		Kind         int             `json:"kind"`
+		Start        string          `json:"startTimeUnixNano"`
+		End          string          `json:"endTimeUnixNano"`
+		Attributes   []otlpAttribute `json:"attributes,omitempty"`
+		Status       *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// Span kind and status codes.
+const (
+	kindInternal = 1
+	statusError  = 2
+)
+
+func (t *Tracer) request(spans []otlpSpan) otlpRequest {
+	service := t.Service
+	if service == "" {
+		service = "prioritizer"
+	}
+	resource := []Attribute{String("service.name", service)}
+	if t.Version != "" {
+		resource = append(resource, String("service.version", t.Version))
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: otlpAttributes(resource)},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: Scope, Version: t.Version}, Spans: spans}},
+	}}}
+}
+
+func (s *Span) otlp() otlpSpan {
+	o := otlpSpan{
+		TraceID: hex.EncodeToString(s.trace.id[:]),
+		SpanID:  hex.EncodeToString(s.id[:]),
+		Name:    s.name,
+		Kind:    kindInternal,
+		Start:   strconv.FormatInt(s.start.UnixNano(), 10),
+		End:     strconv.FormatInt(s.end.UnixNano(), 10),
+	}
+	if s.parent != ([8]byte{}) {
+		o.ParentSpanID = hex.EncodeToString(s.parent[:])
+	}
+	o.Attributes = otlpAttributes(s.attrs)
+	if s.err != "" {
+		o.Status = &otlpStatus{Code: statusError, Message: s.err}
+	}
+	return o
+}
+
+func otlpAttributes(attrs []Attribute) []otlpAttribute {
+	var out []otlpAttribute
+	for _, a := range attrs {
+		var v map[string]any
+		switch x := a.Value.(type) {
+		case string:
+			v = map[string]any{"stringValue": x}
+		case bool:
+			v = map[string]any{"boolValue": x}
+		case int:
+			v = map[string]any{"intValue": strconv.Itoa(x)}
+		case float64:
+			v = map[string]any{"doubleValue": x}
+		default:
+			v = map[string]any{"stringValue": fmt.Sprint(x)}
+		}
+		out = append(out, otlpAttribute{Key: a.Key, Value: v})
+	}
+	return out
+}
@@ -0,0 +1,124 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	var (
+		got     otlpRequest
+		headers http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != TracesPath {
+			http.NotFound(w, r)
+			return
+		}
+		headers = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	endpoint, err := Endpoint(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &Tracer{Endpoint: endpoint, Headers: map[string]string{"X-Api-Key": "k"}, Version: "v1.2.3", Client: srv.Client()}
+
+	ctx, root := tr.Start(context.Background(), "run", String("input", "export.csv"))
+	sctx, score := Start(ctx, "score")
+	_, nvd := Start(sctx, "enrich nvd", Int("findings", 3))
+	nvd.End(errors.New("rate limited"))
+	score.SetAttributes(Float("cpu_ms", 1.5), Bool("cached", true))
+	score.End(nil)
+	Start(ctx, "output") // never ended, as when a stage fails
+	if err := tr.Export(context.Background(), root); err != nil {
+		t.Fatal(err)
+	}
+
+	if headers.Get("X-Api-Key") != "k" || headers.Get("Content-Type") != "application/json" {
+		t.Errorf("headers %v", headers)
+	}
+	rs := got.ResourceSpans[0]
+	if a := rs.Resource.Attributes; len(a) != 2 || a[0].Value["stringValue"] != "prioritizer" || a[1].Value["stringValue"] != "v1.2.3" {
+		t.Errorf("resource %+v", a)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 4 {
+		t.Fatalf("%d spans, want 4", len(spans))
+	}
+	byName := make(map[string]otlpSpan)
+	for _, s := range spans {
+		byName[s.Name] = s
+		start, _ := strconv.ParseInt(s.Start, 10, 64)
+		end, _ := strconv.ParseInt(s.End, 10, 64)
+		if s.TraceID != root.TraceID() || len(s.TraceID) != 32 || len(s.SpanID) != 16 || start == 0 || end < start {
+			t.Errorf("span %+v", s)
+		}
+	}
+	if r := byName["run"]; r.ParentSpanID != "" || r.Status != nil || r.Attributes[0].Value["stringValue"] != "export.csv" {
+		t.Errorf("root %+v", r)
+	}
+	if s := byName["score"]; s.ParentSpanID != byName["run"].SpanID || len(s.Attributes) != 2 ||
+		s.Attributes[0].Value["doubleValue"] != 1.5 || s.Attributes[1].Value["boolValue"] != true {
+		t.Errorf("score %+v", s)
+	}
+	if s := byName["enrich nvd"]; s.ParentSpanID != byName["score"].SpanID || s.Attributes[0].Value["intValue"] != "3" ||
+		s.Status == nil || s.Status.Code != statusError || s.Status.Message != "rate limited" {
+		t.Errorf("enrich nvd %+v", s)
+	}
+	if s := byName["output"]; s.Status == nil || s.Status.Message != errUnfinished.Error() {
+		t.Errorf("unfinished span %+v", s)
+	}
+
+	tr.Endpoint = srv.URL + "/nowhere"
+	_, root = tr.Start(context.Background(), "run")
+	if err := tr.Export(context.Background(), root); err == nil {
+		t.Error("export to a missing endpoint: want error")
+	}
+}
+
+func TestUntraced(t *testing.T) {
+	var tr *Tracer
+	ctx, root := tr.Start(context.Background(), "run")
+	_, child := Start(ctx, "score")
+	if root != nil || child != nil {
+		t.Fatal("untraced run started spans")
+	}
+	child.SetAttributes(Int("n", 1))
+	child.End(nil)
+	if err := tr.Export(ctx, root); err != nil || root.TraceID() != "" {
+		t.Errorf("Export = %v", err)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	for in, want := range map[string]string{
+		"http://collector:4318":            "http://collector:4318/v1/traces",
+		"https://otlp.example.com/":        "https://otlp.example.com/v1/traces",
+		"https://otlp.example.com/otlp/v1": "https://otlp.example.com/otlp/v1",
+	} {
+		if got, err := Endpoint(in); err != nil || got != want {
+			t.Errorf("Endpoint(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"collector:4318", "grpc://collector:4317", "http://"} {
+		if _, err := Endpoint(bad); err == nil {
+			t.Errorf("Endpoint(%q): want error", bad)
+		}
+	}
+	h, err := ParseHeaders("api-key=abc%3D%3D, x-team = sec ,")
+	if err != nil || len(h) != 2 || h["api-key"] != "abc==" || h["x-team"] != "sec" {
+		t.Errorf("ParseHeaders = %v, %v", h, err)
+	}
+	if _, err := ParseHeaders("api-key"); err == nil {
+		t.Error("ParseHeaders(api-key): want error")
+	}
+}
@@ -0,0 +1,245 @@
+// Package tracking keeps the history of every finding across runs: when
+// it was first and last seen, its priority score whenever that changed,
+// and its status transitions, as the base for trends and the mean time to
+// remediate.
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Statuses of a tracked finding. A finding is resolved once a run no
+// longer reports it, and reopened when a later run reports it again.
+const (
+	Open     = "open"
+	Resolved = "resolved"
+	Reopened = "reopened"
+)
+
+// Score is a finding's priority score from the run at At on.
+type Score struct {
+	At        time.Time `json:"at"`
+	Score     float64   `json:"score"`
+	Timeframe string    `json:"action_timeframe,omitempty"`
+}
+
+// Transition is a change of a finding's status. The first, to Open, has
+// no From.
+type Transition struct {
+	At   time.Time `json:"at"`
+	From string    `json:"from,omitempty"`
+	To   string    `json:"to"`
+}
+
+// Finding is the history of one finding. The identifying fields are those
+// of the run that last saw it.
+type Finding struct {
+	UniqueID    string        `json:"unique_id"`
+	Fingerprint string        `json:"fingerprint"`
+	Identifier  string        `json:"identifier,omitempty"`
+	AssetName   string        `json:"asset_name,omitempty"`
+	PackageName string        `json:"package_name,omitempty"`
+	Severity    vuln.Severity `json:"severity,omitempty"`
+	Status      string        `json:"status"`
+	FirstSeen   time.Time     `json:"first_seen"`
+	LastSeen    time.Time     `json:"last_seen"`
+	Scores      []Score       `json:"scores"`
+	Transitions []Transition  `json:"transitions"`
+}
+
+// Store holds the tracked findings by key; see Key. The zero Store is not
+// usable; use New or Load.
+type Store struct {
+	Findings map[string]*Finding `json:"findings"`
+}
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{Findings: make(map[string]*Finding)}
+}
+
+// Load reads a store saved with Save. A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := New()
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Findings == nil {
+		s.Findings = make(map[string]*Finding)
+	}
+	return s, nil
+}
+
+// Save writes s to path as indented JSON.
+func (s *Store) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// Key returns the key v is tracked by: its unique ID, or its fingerprint
+// for inputs without unique IDs, such as scanner reports.
+func Key(v *vuln.Vulnerability) string {
+	if v.UniqueID != "" {
+		return v.UniqueID
+	}
+	return v.Fingerprint()
+}
+
+// Changes count what a run changed in the store.
+type Changes struct {
+	New      int `json:"new"`
+	Seen     int `json:"seen"` // seen before and still open
+	Reopened int `json:"reopened"`
+	Resolved int `json:"resolved"`
+	Rescored int `json:"rescored"` // whose score changed, of Seen and Reopened
+}
+
+// Upsert records vs as every finding reported by the run at now. New
+// findings are added as open; resolved ones the run reports are reopened;
+// open and reopened ones it does not report are resolved. A finding's
+// score is recorded when it is new or differs from its last.
+func (s *Store) Upsert(vs []vuln.Vulnerability, now time.Time) Changes {
+	var c Changes
+	reported := make(map[string]bool, len(vs))
+	for i := range vs {
+		v := &vs[i]
+		key := Key(v)
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+		f, ok := s.Findings[key]
+		switch {
+		case !ok:
+			f = &Finding{UniqueID: v.UniqueID, FirstSeen: now, Status: Open}
+			f.Transitions = []Transition{{At: now, To: Open}}
+			s.Findings[key] = f
+			c.New++
+		case f.Status == Resolved:
+			f.Transitions = append(f.Transitions, Transition{At: now, From: Resolved, To: Reopened})
+			f.Status = Reopened
+			c.Reopened++
+		default:
+			c.Seen++
+		}
+		f.Fingerprint, f.Identifier, f.AssetName, f.PackageName, f.Severity = v.Fingerprint(), v.Identifier, v.AssetName, v.PackageName, v.Severity
+		f.LastSeen = now
+		if n := len(f.Scores); n == 0 || f.Scores[n-1].Score != v.PriorityScore || f.Scores[n-1].Timeframe != v.ActionTimeframe {
+			if n > 0 {
+				c.Rescored++
+			}
+			f.Scores = append(f.Scores, Score{At: now, Score: v.PriorityScore, Timeframe: v.ActionTimeframe})
+		}
+	}
+	for key, f := range s.Findings {
+		if !reported[key] && f.Status != Resolved {
+			f.Transitions = append(f.Transitions, Transition{At: now, From: f.Status, To: Resolved})
+			f.Status = Resolved
+			c.Resolved++
+		}
+	}
+	return c
+}
+
+// Counts returns the number of tracked findings in each status.
+func (s *Store) Counts() map[string]int {
+	counts := map[string]int{Open: 0, Reopened: 0, Resolved: 0}
+	for _, f := range s.Findings {
+		counts[f.Status]++
+	}
+	return counts
+}
+
+// MeanTimeToRemediate returns the mean time from a finding being opened or
+// reopened to its resolution, over every resolution recorded, and how
+// many there were.
+func (s *Store) MeanTimeToRemediate() (time.Duration, int) {
+	var total time.Duration
+	n := 0
+	for _, f := range s.Findings {
+		var opened time.Time
+		for _, t := range f.Transitions {
+			switch t.To {
+			case Open, Reopened:
+				opened = t.At
+			case Resolved:
+				total += t.At.Sub(opened)
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return total / time.Duration(n), n
+}
+
+// Snapshot is a tracked finding as it stood at a past time: its status
+// and the score recorded by the last run before then. The identifying
+// fields and severity are those of the run that last saw it, as the store
+// keeps no history of them.
+type Snapshot struct {
+	Key         string        `json:"key"`
+	UniqueID    string        `json:"unique_id,omitempty"`
+	Fingerprint string        `json:"fingerprint"`
+	Identifier  string        `json:"identifier,omitempty"`
+	AssetName   string        `json:"asset_name,omitempty"`
+	PackageName string        `json:"package_name,omitempty"`
+	Severity    vuln.Severity `json:"severity,omitempty"`
+	Status      string        `json:"status"`
+	FirstSeen   time.Time     `json:"first_seen"`
+	Score       float64       `json:"priority_score"`
+	Timeframe   string        `json:"action_timeframe,omitempty"`
+}
+
+// AsOf returns the findings that were open or reopened just before at, by
+// the transitions and scores of the runs before it, highest score first
+// and then by key.
+func (s *Store) AsOf(at time.Time) []Snapshot {
+	var out []Snapshot
+	for key, f := range s.Findings {
+		status := ""
+		for _, t := range f.Transitions {
+			if t.At.Before(at) {
+				status = t.To
+			}
+		}
+		if status != Open && status != Reopened {
+			continue
+		}
+		snap := Snapshot{
+			Key: key, UniqueID: f.UniqueID, Fingerprint: f.Fingerprint, Identifier: f.Identifier,
+			AssetName: f.AssetName, PackageName: f.PackageName, Severity: f.Severity,
+			Status: status, FirstSeen: f.FirstSeen,
+		}
+		for _, sc := range f.Scores {
+			if sc.At.Before(at) {
+				snap.Score, snap.Timeframe = sc.Score, sc.Timeframe
+			}
+		}
+		out = append(out, snap)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
@@ -0,0 +1,88 @@
+package tracking
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestUpsert(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 2, d, 0, 0, 0, 0, time.UTC) }
+	a := vuln.Vulnerability{UniqueID: "1", Identifier: "CVE-1", PriorityScore: 8.5, ActionTimeframe: "Immediate"}
+	b := vuln.Vulnerability{UniqueID: "2", Identifier: "CVE-2", PriorityScore: 5}
+	scanned := vuln.Vulnerability{Identifier: "CVE-3", AssetID: "img", PriorityScore: 3}
+
+	s := New()
+	if c := s.Upsert([]vuln.Vulnerability{a, b, scanned, a}, day(1)); c != (Changes{New: 3}) {
+		t.Errorf("first run: %+v", c)
+	}
+	if _, ok := s.Findings[scanned.Fingerprint()]; !ok {
+		t.Error("finding without a unique ID not tracked by fingerprint")
+	}
+	a.PriorityScore = 8.9
+	if c := s.Upsert([]vuln.Vulnerability{a, scanned}, day(3)); c != (Changes{Seen: 2, Resolved: 1, Rescored: 1}) {
+		t.Errorf("second run: %+v", c)
+	}
+	if c := s.Upsert([]vuln.Vulnerability{a, b, scanned}, day(8)); c != (Changes{Seen: 2, Reopened: 1}) {
+		t.Errorf("third run: %+v", c)
+	}
+	s.Upsert([]vuln.Vulnerability{b, scanned}, day(10))
+
+	f := s.Findings["1"]
+	if f.Status != Resolved || !f.FirstSeen.Equal(day(1)) || !f.LastSeen.Equal(day(8)) || len(f.Scores) != 2 || f.Scores[1].Score != 8.9 {
+		t.Errorf("finding 1 = %+v", f)
+	}
+	f = s.Findings["2"]
+	if f.Status != Reopened || len(f.Transitions) != 3 || f.Transitions[2] != (Transition{At: day(8), From: Resolved, To: Reopened}) {
+		t.Errorf("finding 2 = %+v", f)
+	}
+	if got := s.Counts(); got[Open] != 1 || got[Reopened] != 1 || got[Resolved] != 1 {
+		t.Errorf("Counts = %v", got)
+	}
+	// Finding 2 took 2 days to resolve and finding 1 9 days.
+	if mttr, n := s.MeanTimeToRemediate(); n != 2 || mttr != 132*time.Hour {
+		t.Errorf("MeanTimeToRemediate = %v over %d", mttr, n)
+	}
+
+	path := filepath.Join(t.TempDir(), "findings.json")
+	if err := s.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Findings) != 3 || loaded.Findings["2"].Status != Reopened {
+		t.Errorf("loaded %+v", loaded.Findings)
+	}
+	if s, err := Load(filepath.Join(t.TempDir(), "missing.json")); err != nil || len(s.Findings) != 0 {
+		t.Errorf("Load(missing) = %v, %v", s, err)
+	}
+}
+
+func TestAsOf(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 2, d, 0, 0, 0, 0, time.UTC) }
+	a := vuln.Vulnerability{UniqueID: "1", Identifier: "CVE-1", PriorityScore: 8.5, ActionTimeframe: "Immediate"}
+	b := vuln.Vulnerability{UniqueID: "2", Identifier: "CVE-2", PriorityScore: 5}
+	s := New()
+	s.Upsert([]vuln.Vulnerability{a}, day(1))
+	a.PriorityScore = 6
+	s.Upsert([]vuln.Vulnerability{a, b}, day(3))
+	s.Upsert([]vuln.Vulnerability{b}, day(8))
+
+	if got := s.AsOf(day(1)); len(got) != 0 {
+		t.Errorf("before the first run: %+v", got)
+	}
+	if got := s.AsOf(day(2)); len(got) != 1 || got[0].Score != 8.5 || got[0].Timeframe != "Immediate" || got[0].Status != Open {
+		t.Errorf("after the first run: %+v", got)
+	}
+	// Rescored, and ranked by the scores of the time.
+	if got := s.AsOf(day(5)); len(got) != 2 || got[0].Key != "1" || got[0].Score != 6 || got[1].Score != 5 {
+		t.Errorf("after the second run: %+v", got)
+	}
+	if got := s.AsOf(day(9)); len(got) != 1 || got[0].Key != "2" {
+		t.Errorf("after the resolution: %+v", got)
+	}
+}
@@ -0,0 +1,137 @@
+package vex
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/cvss"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// cvssMethods are the CycloneDX rating methods of CVSS versions.
+var cvssMethods = map[string]string{cvss.V30: "CVSSv3", cvss.V31: "CVSSv31", cvss.V40: "CVSSv4"}
+
+// WriteBOM writes vs as a CycloneDX SBOM with vulnerabilities, as
+// Dependency-Track imports it: each asset is a component that depends on
+// its packages, components under it, and each finding is a vulnerability
+// affecting its package. A vulnerability carries the input's CVSS score,
+// severity and vector as its rating, the finding's remediation as its
+// recommendation, its Triage as its analysis and the priority score,
+// action timeframe, due date, EPSS and KEV status as properties named
+// prioritizer:priority_score and so on. Repeated reports of a finding,
+// which share a fingerprint, make one vulnerability, the first of vs.
+func WriteBOM(w io.Writer, vs []vuln.Vulnerability, d Document) error {
+	doc := newCycloneDX(d)
+	var cs cycloneDXComponents
+	depends := make(map[string]int) // product to its index in doc.Dependencies
+	listed := make(map[string]bool) // packages listed in doc.Dependencies
+	seen := make(map[string]bool)
+	var parts []string
+	for i := range vs {
+		v := &vs[i]
+		fp := v.Fingerprint()
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		s := Triage(v)
+		ref := cs.add(s.Product, s.Component)
+		j, ok := depends[s.Product]
+		if !ok {
+			j = len(doc.Dependencies)
+			depends[s.Product] = j
+			doc.Dependencies = append(doc.Dependencies, cycloneDXDependency{Ref: s.Product})
+		}
+		if !listed[ref] {
+			listed[ref] = true
+			doc.Dependencies[j].DependsOn = append(doc.Dependencies[j].DependsOn, ref)
+		}
+		doc.Vulnerabilities = append(doc.Vulnerabilities, bomVuln(v, s, fp, ref))
+		parts = append(parts, fp, ref, s.Analysis, s.Response)
+	}
+	doc.Components = cs.list
+	return encodeCycloneDX(w, doc, parts)
+}
+
+// bomVuln returns the vulnerability of finding v, with fingerprint fp and
+// triage s, affecting the package ref.
+func bomVuln(v *vuln.Vulnerability, s Statement, fp, ref string) cycloneDXVuln {
+	bv := cycloneDXVuln{
+		BOMRef: fp, ID: s.Vulnerability, Source: advisory(s.Vulnerability, v.Source),
+		References: aliasRefs(s.Aliases), Ratings: ratings(v), CWEs: cwes(v.CWEs),
+		Description: strings.TrimSpace(v.Description), Recommendation: strings.TrimSpace(v.Remediation),
+		Analysis: analysis(s), Affects: []cycloneDXAffected{{ref}},
+	}
+	if bv.Description == "" {
+		bv.Description = strings.TrimSpace(v.Title)
+	}
+	if bv.Recommendation == "" && v.HasFix() {
+		bv.Recommendation = v.UpgradeRemediation()
+	}
+	prop := func(name, value string) {
+		bv.Properties = append(bv.Properties, cycloneDXProperty{"prioritizer:" + name, value})
+	}
+	prop("priority_score", strconv.FormatFloat(v.PriorityScore, 'f', 2, 64))
+	if v.ActionTimeframe != "" {
+		prop("action_timeframe", v.ActionTimeframe)
+	}
+	if !v.DueDate.IsZero() {
+		prop("due_date", v.DueDate.String())
+	}
+	if v.EPSS > 0 {
+		prop("epss", strconv.FormatFloat(v.EPSS, 'f', -1, 64))
+	}
+	if v.KEV {
+		prop("kev", "true")
+	}
+	return bv
+}
+
+// advisory returns the source of the vulnerability id: the NVD for a CVE,
+// the GitHub Advisory Database for a GHSA and the scanner otherwise.
+func advisory(id, scanner string) *cycloneDXSource {
+	switch {
+	case strings.HasPrefix(id, "CVE-"):
+		return &cycloneDXSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + id}
+	case strings.HasPrefix(id, "GHSA-"):
+		return &cycloneDXSource{Name: "GitHub", URL: "https://github.com/advisories/GHSA-" + strings.ToLower(id[5:])}
+	case strings.TrimSpace(scanner) != "":
+		return &cycloneDXSource{Name: strings.TrimSpace(scanner)}
+	}
+	return nil
+}
+
+// ratings returns v's CVSS rating, its source the NVD when it was filled
+// in from there and the scanner otherwise. The method is the vector's CVSS
+// version, and left out when there is no vector or it does not parse.
+func ratings(v *vuln.Vulnerability) []cycloneDXRating {
+	r := cycloneDXRating{Score: v.CVSS, Severity: strings.ToLower(string(v.Severity)), Vector: strings.TrimSpace(v.CVSSVector)}
+	if r.Severity == "" {
+		r.Severity = "unknown"
+	}
+	switch name := strings.TrimSpace(v.Source); {
+	case v.CVSSSource == "nvd":
+		r.Source = &cycloneDXSource{Name: "NVD"}
+	case v.CVSSSource == "msrc":
+		r.Source = &cycloneDXSource{Name: "Microsoft"}
+	case name != "":
+		r.Source = &cycloneDXSource{Name: name}
+	}
+	if vec, err := cvss.Parse(r.Vector); err == nil {
+		r.Method = cvssMethods[vec.Version]
+	}
+	return []cycloneDXRating{r}
+}
+
+// cwes returns the numbers of the weaknesses ids, as in 79 for CWE-79,
+// leaving out ids without one.
+func cwes(ids []string) []int {
+	var ns []int
+	for _, id := range ids {
+		if n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(id)), "CWE-")); err == nil && n > 0 {
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
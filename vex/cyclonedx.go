@@ -0,0 +1,232 @@
+package vex
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CycloneDXSpecVersion is the version of the CycloneDX specification
+// documents follow.
+const CycloneDXSpecVersion = "1.5"
+
+type cycloneDX struct {
+	BOMFormat       string                `json:"bomFormat"`
+	SpecVersion     string                `json:"specVersion"`
+	SerialNumber    string                `json:"serialNumber"`
+	Version         int                   `json:"version"`
+	Metadata        cycloneDXMetadata     `json:"metadata"`
+	Components      []cycloneDXComponent  `json:"components"`
+	Dependencies    []cycloneDXDependency `json:"dependencies,omitempty"`
+	Vulnerabilities []cycloneDXVuln       `json:"vulnerabilities"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     *cycloneDXTools    `json:"tools,omitempty"`
+	Authors   []cycloneDXContact `json:"authors,omitempty"`
+}
+
+type cycloneDXTools struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXContact struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXComponent struct {
+	Type       string               `json:"type"`
+	BOMRef     string               `json:"bom-ref,omitempty"`
+	Group      string               `json:"group,omitempty"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version,omitempty"`
+	PURL       string               `json:"purl,omitempty"`
+	Components []cycloneDXComponent `json:"components,omitempty"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+type cycloneDXVuln struct {
+	BOMRef         string              `json:"bom-ref,omitempty"`
+	ID             string              `json:"id"`
+	Source         *cycloneDXSource    `json:"source,omitempty"`
+	References     []cycloneDXRef      `json:"references,omitempty"`
+	Ratings        []cycloneDXRating   `json:"ratings,omitempty"`
+	CWEs           []int               `json:"cwes,omitempty"`
+	Description    string              `json:"description,omitempty"`
+	Recommendation string              `json:"recommendation,omitempty"`
+	Analysis       cycloneDXAnalysis   `json:"analysis"`
+	Affects        []cycloneDXAffected `json:"affects"`
+	Properties     []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type cycloneDXRef struct {
+	ID     string          `json:"id"`
+	Source cycloneDXSource `json:"source"`
+}
+
+type cycloneDXRating struct {
+	Source   *cycloneDXSource `json:"source,omitempty"`
+	Score    float64          `json:"score,omitempty"`
+	Severity string           `json:"severity"`
+	Method   string           `json:"method,omitempty"`
+	Vector   string           `json:"vector,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXAnalysis struct {
+	State    string   `json:"state"`
+	Response []string `json:"response,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+type cycloneDXAffected struct {
+	Ref string `json:"ref"`
+}
+
+// WriteCycloneDX writes ss as a CycloneDX VEX document. Each product is a
+// component with its packages as components under it, whose references,
+// the product and the package URL joined by "#", the vulnerabilities'
+// affects list. Statements that say the same of one vulnerability are
+// merged into one vulnerability. The serial number is derived from the
+// document's content.
+func WriteCycloneDX(w io.Writer, ss []Statement, d Document) error {
+	doc := newCycloneDX(d)
+	var cs cycloneDXComponents
+	vulns := make(map[string]int) // statement key to its index in doc.Vulnerabilities
+	var parts []string
+	for _, s := range ss {
+		ref := cs.add(s.Product, s.Component)
+		key := strings.Join([]string{s.Vulnerability, strings.Join(s.Aliases, ","), s.Analysis, s.Response, s.Impact, s.Action}, "\x00")
+		j, ok := vulns[key]
+		if !ok {
+			j = len(doc.Vulnerabilities)
+			vulns[key] = j
+			v := cycloneDXVuln{ID: s.Vulnerability, References: aliasRefs(s.Aliases), Analysis: analysis(s)}
+			doc.Vulnerabilities = append(doc.Vulnerabilities, v)
+		}
+		if v := &doc.Vulnerabilities[j]; len(v.Affects) == 0 || v.Affects[len(v.Affects)-1].Ref != ref {
+			v.Affects = append(v.Affects, cycloneDXAffected{ref})
+		}
+		parts = append(parts, key, ref)
+	}
+	doc.Components = cs.list
+	return encodeCycloneDX(w, doc, parts)
+}
+
+// newCycloneDX returns a document with d's metadata and no components or
+// vulnerabilities.
+func newCycloneDX(d Document) cycloneDX {
+	doc := cycloneDX{
+		BOMFormat: "CycloneDX", SpecVersion: CycloneDXSpecVersion, Version: 1,
+		Metadata:   cycloneDXMetadata{Timestamp: d.Timestamp.UTC().Format(time.RFC3339)},
+		Components: []cycloneDXComponent{}, Vulnerabilities: []cycloneDXVuln{},
+	}
+	if d.Tool != "" {
+		doc.Metadata.Tools = &cycloneDXTools{[]cycloneDXComponent{{Type: "application", Name: d.Tool, Version: d.ToolVersion}}}
+	}
+	if d.Author != "" {
+		doc.Metadata.Authors = []cycloneDXContact{{d.Author}}
+	}
+	return doc
+}
+
+// encodeCycloneDX writes doc, its serial number derived from the content
+// parts and its metadata.
+func encodeCycloneDX(w io.Writer, doc cycloneDX, parts []string) error {
+	author := ""
+	if len(doc.Metadata.Authors) > 0 {
+		author = doc.Metadata.Authors[0].Name
+	}
+	doc.SerialNumber = documentID(append(parts, author, doc.Metadata.Timestamp)...)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// cycloneDXComponents lists each product as a component with its packages
+// as components under it.
+type cycloneDXComponents struct {
+	list     []cycloneDXComponent
+	products map[string]int  // product to its index in list
+	packages map[string]bool // references of the packages listed
+}
+
+// add lists the package component of product, if not listed yet, and
+// returns its reference: the product and the package URL joined by "#".
+func (cs *cycloneDXComponents) add(product, component string) string {
+	if cs.products == nil {
+		cs.products, cs.packages = make(map[string]int), make(map[string]bool)
+		cs.list = []cycloneDXComponent{}
+	}
+	i, ok := cs.products[product]
+	if !ok {
+		i = len(cs.list)
+		cs.products[product] = i
+		c := cycloneDXComponent{Type: "application", BOMRef: product, Name: product}
+		if strings.HasPrefix(product, "pkg:oci/") {
+			c.Type, c.PURL = "container", product
+			_, c.Name, c.Version = purlParts(product)
+		}
+		cs.list = append(cs.list, c)
+	}
+	ref := product + "#" + component
+	if !cs.packages[ref] {
+		cs.packages[ref] = true
+		group, name, version := purlParts(component)
+		c := &cs.list[i]
+		c.Components = append(c.Components, cycloneDXComponent{Type: "library", BOMRef: ref, Group: group, Name: name, Version: version, PURL: component})
+	}
+	return ref
+}
+
+// analysis returns the CycloneDX analysis of s.
+func analysis(s Statement) cycloneDXAnalysis {
+	a := cycloneDXAnalysis{State: s.Analysis, Detail: s.Impact + s.Action}
+	if s.Response != "" {
+		a.Response = []string{s.Response}
+	}
+	return a
+}
+
+// aliasRefs returns references to the NVD entries of aliases.
+func aliasRefs(aliases []string) []cycloneDXRef {
+	var refs []cycloneDXRef
+	for _, alias := range aliases {
+		refs = append(refs, cycloneDXRef{ID: alias, Source: cycloneDXSource{Name: "NVD"}})
+	}
+	return refs
+}
+
+// purlParts returns the namespace, as in golang.org/x or @babel, name
+// and version of the package URL purl, their escapes undone.
+func purlParts(purl string) (group, name, version string) {
+	_, path, _ := strings.Cut(strings.TrimPrefix(purl, "pkg:"), "/")
+	path, version, _ = strings.Cut(path, "@")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		group, name = path[:i], path[i+1:]
+	} else {
+		name = path
+	}
+	for _, p := range []*string{&group, &name, &version} {
+		if u, err := url.PathUnescape(*p); err == nil {
+			*p = u
+		}
+	}
+	return group, name, version
+}
@@ -0,0 +1,102 @@
+package vex
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// Document is what a VEX document says about itself.
+type Document struct {
+	// Author is who made the triage decisions, as in "Acme Security".
+	// OpenVEX documents need one, and say "Unknown Author" without.
+	Author string
+	// Tool and ToolVersion name the program that wrote the document.
+	Tool, ToolVersion string
+	Timestamp         time.Time
+}
+
+// OpenVEXContext is the version of the OpenVEX specification documents
+// follow.
+const OpenVEXContext = "https://openvex.dev/ns/v0.2.0"
+
+type openVEX struct {
+	Context    string             `json:"@context"`
+	ID         string             `json:"@id"`
+	Author     string             `json:"author"`
+	Timestamp  string             `json:"timestamp"`
+	Version    int                `json:"version"`
+	Tooling    string             `json:"tooling,omitempty"`
+	Statements []openVEXStatement `json:"statements"`
+}
+
+type openVEXStatement struct {
+	Vulnerability   openVEXVulnerability `json:"vulnerability"`
+	Products        []openVEXProduct     `json:"products"`
+	Status          string               `json:"status"`
+	ImpactStatement string               `json:"impact_statement,omitempty"`
+	ActionStatement string               `json:"action_statement,omitempty"`
+}
+
+type openVEXVulnerability struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+type openVEXProduct struct {
+	ID            string             `json:"@id"`
+	Subcomponents []openVEXComponent `json:"subcomponents,omitempty"`
+}
+
+type openVEXComponent struct {
+	ID string `json:"@id"`
+}
+
+// WriteOpenVEX writes ss as an OpenVEX document. Statements that say the
+// same of one vulnerability are merged into one, listing every product
+// and, under each, the packages. The document's ID is derived from its
+// content.
+func WriteOpenVEX(w io.Writer, ss []Statement, d Document) error {
+	author := d.Author
+	if author == "" {
+		author = "Unknown Author"
+	}
+	doc := openVEX{
+		Context: OpenVEXContext, Author: author, Timestamp: d.Timestamp.UTC().Format(time.RFC3339), Version: 1,
+		Statements: []openVEXStatement{},
+	}
+	if d.Tool != "" {
+		doc.Tooling = strings.TrimSpace(d.Tool + " " + d.ToolVersion)
+	}
+	index := make(map[string]int) // statement key to its index in doc
+	var parts []string
+	for _, s := range ss {
+		key := strings.Join([]string{s.Vulnerability, strings.Join(s.Aliases, ","), s.Status, s.Impact, s.Action}, "\x00")
+		i, ok := index[key]
+		if !ok {
+			i = len(doc.Statements)
+			index[key] = i
+			doc.Statements = append(doc.Statements, openVEXStatement{
+				Vulnerability: openVEXVulnerability{s.Vulnerability, s.Aliases}, Status: s.Status,
+				ImpactStatement: s.Impact, ActionStatement: s.Action,
+			})
+		}
+		st := &doc.Statements[i]
+		// ss is ordered by product, so a product's packages follow it.
+		if n := len(st.Products); n == 0 || st.Products[n-1].ID != s.Product {
+			st.Products = append(st.Products, openVEXProduct{ID: s.Product})
+		}
+		p := &st.Products[len(st.Products)-1]
+		// Findings whose installed versions are ranges can share a
+		// package URL.
+		if n := len(p.Subcomponents); n == 0 || p.Subcomponents[n-1].ID != s.Component {
+			p.Subcomponents = append(p.Subcomponents, openVEXComponent{s.Component})
+		}
+		parts = append(parts, key, s.Product, s.Component)
+	}
+	doc.ID = documentID(append(parts, author, doc.Timestamp)...)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
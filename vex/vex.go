@@ -0,0 +1,272 @@
+// Package vex exports the triage of findings as VEX (Vulnerability
+// Exploitability eXchange) documents, OpenVEX or CycloneDX, so that SBOM
+// tooling downstream knows which vulnerabilities of a product were fixed,
+// are false positives, are accepted or are still being looked at, rather
+// than raising every finding again.
+package vex
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/suppress"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Statuses of a vulnerability in a product, as OpenVEX names them.
+const (
+	NotAffected        = "not_affected"
+	Affected           = "affected"
+	Fixed              = "fixed"
+	UnderInvestigation = "under_investigation"
+)
+
+// Document formats.
+const (
+	OpenVEX   = "openvex"
+	CycloneDX = "cyclonedx"
+)
+
+// Formats lists the document formats, the default first.
+var Formats = []string{OpenVEX, CycloneDX}
+
+// Write writes ss to w as a document in format, one of Formats.
+func Write(w io.Writer, format string, ss []Statement, d Document) error {
+	switch format {
+	case OpenVEX:
+		return WriteOpenVEX(w, ss, d)
+	case CycloneDX:
+		return WriteCycloneDX(w, ss, d)
+	}
+	return fmt.Errorf("unknown VEX format %q (want %s)", format, strings.Join(Formats, " or "))
+}
+
+// Statement is the triage of one finding: the status of its vulnerability
+// in the package of an asset.
+type Statement struct {
+	Vulnerability string
+	// Aliases are the CVEs a vulnerability not identified by a CVE, such
+	// as a Windows KB update, addresses.
+	Aliases []string
+	// Product identifies the asset, and Component the package in it as a
+	// package URL.
+	Product, Component string
+	Status             string
+	// Analysis is the CycloneDX analysis state of Status, as in
+	// false_positive, and Response the CycloneDX response, if any, as in
+	// will_not_fix.
+	Analysis, Response string
+	// Impact explains a not_affected status, and Action what is done
+	// about an affected one.
+	Impact, Action string
+}
+
+// Statements returns the triage of vs and of the false positives, which
+// runs leave out of vs, ordered by vulnerability, product and component.
+// Repeated reports of a finding, which share a fingerprint, make one
+// statement. A false positive is not_affected, its product named as the
+// other findings of its asset in vs name it; the findings of vs have their
+// Triage.
+func Statements(vs []vuln.Vulnerability, falsePositives []*suppress.Entry) []Statement {
+	assets := make(map[string]*vuln.Vulnerability) // asset ID to a finding of it
+	for i := range vs {
+		if id := strings.TrimSpace(vs[i].AssetID); id != "" && assets[id] == nil {
+			assets[id] = &vs[i]
+		}
+	}
+	var ss []Statement
+	seen := make(map[string]bool)
+	for _, e := range falsePositives {
+		if seen[e.Fingerprint] {
+			continue
+		}
+		seen[e.Fingerprint] = true
+		v := vuln.Vulnerability{Identifier: e.Identifier, AssetID: e.AssetID, PackageName: e.PackageName, InstalledVersion: e.InstalledVersion, Source: e.Source}
+		if a := assets[strings.TrimSpace(e.AssetID)]; a != nil {
+			v.AssetName, v.ImageDigest, v.OSFamily = a.AssetName, a.ImageDigest, a.OSFamily
+		}
+		s := newStatement(&v)
+		s.Status, s.Analysis = NotAffected, "false_positive"
+		s.Impact = "False positive"
+		if e.Reason != "" {
+			s.Impact += ": " + e.Reason
+		}
+		ss = append(ss, s)
+	}
+	for i := range vs {
+		v := &vs[i]
+		fp := v.Fingerprint()
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		ss = append(ss, Triage(v))
+	}
+	sort.SliceStable(ss, func(i, j int) bool {
+		a, b := &ss[i], &ss[j]
+		if a.Vulnerability != b.Vulnerability {
+			return a.Vulnerability < b.Vulnerability
+		}
+		if a.Product != b.Product {
+			return a.Product < b.Product
+		}
+		return a.Component < b.Component
+	})
+	return ss
+}
+
+// Triage returns the statement of v: fixed when resolved, affected when
+// waived, risk accepted or in progress, and under investigation otherwise.
+func Triage(v *vuln.Vulnerability) Statement {
+	s := newStatement(v)
+	switch {
+	case v.State == state.Resolved:
+		s.Status, s.Analysis = Fixed, "resolved"
+	case v.Suppressed:
+		s.Status, s.Analysis, s.Response = Affected, "exploitable", "will_not_fix"
+		s.Action = "Waived"
+		if v.Waiver != "" {
+			s.Action += ": " + v.Waiver
+		}
+	case v.State == state.RiskAccepted:
+		s.Status, s.Analysis, s.Response = Affected, "exploitable", "will_not_fix"
+		s.Action = "Risk accepted; no remediation is planned"
+	case v.State == state.InProgress:
+		s.Status, s.Analysis = Affected, "exploitable"
+		s.Action = "Remediation in progress"
+		if v.HasFix() {
+			s.Response = "update"
+			s.Action += ": upgrade " + v.EcosystemPackage() + " to " + strings.TrimSpace(v.FixedVersion)
+		}
+	default:
+		s.Status, s.Analysis = UnderInvestigation, "in_triage"
+	}
+	return s
+}
+
+func newStatement(v *vuln.Vulnerability) Statement {
+	return Statement{
+		Vulnerability: strings.ToUpper(strings.TrimSpace(v.Identifier)),
+		Aliases:       v.RelatedCVEs,
+		Product:       Product(v),
+		Component:     PURL(v),
+	}
+}
+
+// Product identifies v's asset: by package URL, as in
+// pkg:oci/api@sha256%3A4be4..., for a container image with a known
+// digest, and otherwise by its name, or its ID when it has none.
+func Product(v *vuln.Vulnerability) string {
+	if v.ImageDigest != "" {
+		name := strings.TrimSpace(v.AssetName)
+		name = name[strings.LastIndex(name, "/")+1:]
+		if i := strings.IndexAny(name, ":@"); i >= 0 {
+			name = name[:i]
+		}
+		if name == "" {
+			name = "image"
+		}
+		return "pkg:oci/" + escape(strings.ToLower(name)) + "@" + escape(v.ImageDigest)
+	}
+	if name := strings.TrimSpace(v.AssetName); name != "" {
+		return name
+	}
+	return strings.TrimSpace(v.AssetID)
+}
+
+// osTypes are the package URL types of OS packages, by OS family.
+var osTypes = map[string]string{
+	"debian": "deb", "ubuntu": "deb",
+	"alpine": "apk",
+	"amzn":   "rpm", "rhel": "rpm", "centos": "rpm", "fedora": "rpm", "rocky": "rpm", "almalinux": "rpm", "ol": "rpm", "sles": "rpm", "opensuse-leap": "rpm",
+}
+
+// PURL returns the package URL of v's package, as in pkg:npm/braces or
+// pkg:deb/debian/openssl@1.1.1n-0%2Bdeb10u6. The version is left out
+// when the input gives a range, as in "< 3.0.3", rather than the
+// installed version. OS packages of an unknown family and packages of
+// unknown ecosystems are generic.
+func PURL(v *vuln.Vulnerability) string {
+	name := v.EcosystemPackage()
+	var path string
+	switch v.Ecosystem() {
+	case vuln.EcosystemNPM:
+		// Scoped packages, as in @babel/core, have their scope as the
+		// namespace.
+		path = "npm/" + escapePath(name)
+	case vuln.EcosystemPip:
+		path = "pypi/" + escape(strings.ReplaceAll(strings.ToLower(name), "_", "-"))
+	case vuln.EcosystemGo:
+		path = "golang/" + escapePath(name)
+	case vuln.EcosystemMaven:
+		path = "maven/" + escapePath(strings.Replace(name, ":", "/", 1))
+	case vuln.EcosystemOSPkg:
+		family := strings.ToLower(v.OSFamily)
+		if typ, ok := osTypes[family]; ok {
+			path = typ + "/" + escape(family) + "/" + escape(name)
+		} else {
+			path = "generic/" + escape(name)
+		}
+	default:
+		path = "generic/" + escape(name)
+	}
+	purl := "pkg:" + path
+	if version := installedVersion(v.InstalledVersion); version != "" {
+		purl += "@" + escape(version)
+	}
+	return purl
+}
+
+// installedVersion returns s unless it is blank or a version range, as
+// in "< 3.0.3", "^1.2" or "~1.2". A "~" further in, as in Debian's
+// "1.0~rc1", is part of a version.
+func installedVersion(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, " <>=,*^|") || strings.HasPrefix(s, "~") {
+		return ""
+	}
+	return s
+}
+
+// escapePath escapes each "/"-separated segment of p.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape percent-encodes s as a package URL segment, leaving only
+// letters, digits and ".-_~".
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte(".-_~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// documentID returns a URN that identifies a document with the content
+// parts, a UUID made of their SHA-256 digest, so that the same triage at
+// the same time has the same ID.
+func documentID(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	u := h.Sum(nil)[:16]
+	u[6] = u[6]&0x0f | 0x80 // version 8, custom
+	u[8] = u[8]&0x3f | 0x80 // RFC 9562 variant
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
@@ -0,0 +1,195 @@
+package vex
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/state"
+	"github.com/VioletX-Dev/devsecops-test/suppress"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func TestPURL(t *testing.T) {
+	for _, tc := range []struct {
+		v    vuln.Vulnerability
+		want string
+	}{
+		{vuln.Vulnerability{PackageName: "npm-braces", InstalledVersion: "< 3.0.3"}, "pkg:npm/braces"},
+		{vuln.Vulnerability{PackageName: "npm-@babel/core", InstalledVersion: "7.22.5"}, "pkg:npm/%40babel/core@7.22.5"},
+		{vuln.Vulnerability{PackageName: "pip-Django_Rest", InstalledVersion: "~3.1"}, "pkg:pypi/django-rest"},
+		{vuln.Vulnerability{PackageName: "go-golang.org/x/net", InstalledVersion: "v0.17.0"}, "pkg:golang/golang.org/x/net@v0.17.0"},
+		{vuln.Vulnerability{PackageName: "maven-org.apache.logging.log4j:log4j-core", InstalledVersion: "2.14.1"}, "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"},
+		{vuln.Vulnerability{PackageName: "openssl", InstalledVersion: "1.1.1n-0+deb10u6", OSFamily: "debian"}, "pkg:deb/debian/openssl@1.1.1n-0%2Bdeb10u6"},
+		{vuln.Vulnerability{PackageName: "bind", InstalledVersion: "1:9.16.48-0ubuntu0.20.04.1", OSFamily: "ubuntu"}, "pkg:deb/ubuntu/bind@1%3A9.16.48-0ubuntu0.20.04.1"},
+		{vuln.Vulnerability{PackageName: "libwmf", InstalledVersion: "0.2.8.4-14", Source: "aws"}, "pkg:generic/libwmf@0.2.8.4-14"},
+	} {
+		if got := PURL(&tc.v); got != tc.want {
+			t.Errorf("PURL(%s %s) = %s, want %s", tc.v.PackageName, tc.v.InstalledVersion, got, tc.want)
+		}
+	}
+	image := vuln.Vulnerability{AssetName: "123456789012.dkr.ecr.us-west-2.amazonaws.com/Api:latest", ImageDigest: "sha256:4be4"}
+	if got := Product(&image); got != "pkg:oci/api@sha256%3A4be4" {
+		t.Errorf("Product(image) = %s", got)
+	}
+	if got := Product(&vuln.Vulnerability{AssetID: "464323181"}); got != "464323181" {
+		t.Errorf("Product(unnamed) = %s", got)
+	}
+}
+
+// triaged are findings in every workflow state, and a waived one.
+var triaged = []vuln.Vulnerability{
+	{Identifier: "CVE-2024-4068", AssetID: "464322501", AssetName: "apply-api", PackageName: "npm-braces", InstalledVersion: "< 3.0.3", FixedVersion: "3.0.3", State: state.InProgress},
+	{Identifier: "CVE-2024-4068", AssetID: "464322502", AssetName: "apply-frontend", PackageName: "npm-braces", InstalledVersion: "< 3.0.3", FixedVersion: "3.0.3", State: state.InProgress},
+	{Identifier: "cve-2024-4068", AssetID: "464322502", AssetName: "apply-frontend", PackageName: "npm-braces", InstalledVersion: "< 3.0.3", State: state.InProgress},
+	{Identifier: "CVE-2023-4911", AssetID: "464322503", AssetName: "hasura", PackageName: "glibc", InstalledVersion: "2.28-10", State: state.Resolved},
+	{Identifier: "CVE-2022-3996", AssetID: "464322503", AssetName: "hasura", PackageName: "openssl", InstalledVersion: "1.1.1n", State: state.RiskAccepted},
+	{Identifier: "CVE-2018-25011", AssetID: "464322504", AssetName: "match-v1", PackageName: "libwebp", InstalledVersion: "0.6.1", Suppressed: true, Waiver: "no untrusted images are decoded"},
+	{Identifier: "CVE-2024-3094", AssetID: "464322504", AssetName: "match-v1", PackageName: "xz-utils", InstalledVersion: "5.6.0"},
+}
+
+func TestStatements(t *testing.T) {
+	fps := []*suppress.Entry{{Fingerprint: "f1", Identifier: "CVE-2021-44228", AssetID: "464322503", PackageName: "maven-org.apache.logging.log4j:log4j-core", InstalledVersion: "2.14.1", Reason: "log4j-core is not on the class path"}}
+	ss := Statements(triaged, fps)
+	type row struct{ vuln, product, status, analysis, response, detail string }
+	var got []row
+	for _, s := range ss {
+		got = append(got, row{s.Vulnerability, s.Product, s.Status, s.Analysis, s.Response, s.Impact + s.Action})
+	}
+	want := []row{
+		{"CVE-2018-25011", "match-v1", Affected, "exploitable", "will_not_fix", "Waived: no untrusted images are decoded"},
+		{"CVE-2021-44228", "hasura", NotAffected, "false_positive", "", "False positive: log4j-core is not on the class path"},
+		{"CVE-2022-3996", "hasura", Affected, "exploitable", "will_not_fix", "Risk accepted; no remediation is planned"},
+		{"CVE-2023-4911", "hasura", Fixed, "resolved", "", ""},
+		{"CVE-2024-3094", "match-v1", UnderInvestigation, "in_triage", "", ""},
+		{"CVE-2024-4068", "apply-api", Affected, "exploitable", "update", "Remediation in progress: upgrade braces to 3.0.3"},
+		{"CVE-2024-4068", "apply-frontend", Affected, "exploitable", "update", "Remediation in progress: upgrade braces to 3.0.3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statements:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestWriteOpenVEX(t *testing.T) {
+	d := Document{Author: "Acme Security", Tool: "prioritizer", ToolVersion: "v1.2.3", Timestamp: time.Date(2025, 2, 5, 12, 0, 0, 0, time.UTC)}
+	var a, b bytes.Buffer
+	if err := WriteOpenVEX(&a, Statements(triaged, nil), d); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteOpenVEX(&b, Statements(triaged, nil), d); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != b.String() {
+		t.Error("the same triage wrote different documents")
+	}
+	var doc struct {
+		Context    string `json:"@context"`
+		ID         string `json:"@id"`
+		Author     string `json:"author"`
+		Timestamp  string `json:"timestamp"`
+		Tooling    string `json:"tooling"`
+		Statements []struct {
+			Vulnerability struct{ Name string } `json:"vulnerability"`
+			Products      []struct {
+				ID            string `json:"@id"`
+				Subcomponents []struct {
+					ID string `json:"@id"`
+				} `json:"subcomponents"`
+			} `json:"products"`
+			Status string `json:"status"`
+			Action string `json:"action_statement"`
+		} `json:"statements"`
+	}
+	if err := json.Unmarshal(a.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Context != OpenVEXContext || !strings.HasPrefix(doc.ID, "urn:uuid:") || doc.Author != "Acme Security" ||
+		doc.Timestamp != "2025-02-05T12:00:00Z" || doc.Tooling != "prioritizer v1.2.3" || len(doc.Statements) != 5 {
+		t.Fatalf("document:\n%s", a.String())
+	}
+	// The braces findings on both assets make one statement.
+	braces := doc.Statements[4]
+	if braces.Vulnerability.Name != "CVE-2024-4068" || braces.Status != Affected || len(braces.Products) != 2 ||
+		braces.Products[1].ID != "apply-frontend" || len(braces.Products[1].Subcomponents) != 1 || braces.Products[1].Subcomponents[0].ID != "pkg:npm/braces" {
+		t.Errorf("braces statement = %+v", braces)
+	}
+}
+
+func TestWriteCycloneDX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, CycloneDX, Statements(triaged, nil), Document{Tool: "prioritizer", Timestamp: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+	var doc cycloneDX
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.BOMFormat != "CycloneDX" || doc.SpecVersion != CycloneDXSpecVersion || !strings.HasPrefix(doc.SerialNumber, "urn:uuid:") ||
+		len(doc.Components) != 4 || len(doc.Vulnerabilities) != 5 {
+		t.Fatalf("document:\n%s", buf.String())
+	}
+	hasura := doc.Components[1]
+	if hasura.Name != "hasura" || len(hasura.Components) != 2 || hasura.Components[1].BOMRef != "hasura#pkg:generic/glibc@2.28-10" || hasura.Components[1].Version != "2.28-10" {
+		t.Errorf("hasura component = %+v", hasura)
+	}
+	waived := doc.Vulnerabilities[0]
+	if waived.ID != "CVE-2018-25011" || waived.Analysis.State != "exploitable" || !reflect.DeepEqual(waived.Analysis.Response, []string{"will_not_fix"}) ||
+		len(waived.Affects) != 1 || waived.Affects[0].Ref != "match-v1#pkg:generic/libwebp@0.6.1" {
+		t.Errorf("waived vulnerability = %+v", waived)
+	}
+	if err := Write(&buf, "spdx", nil, Document{}); err == nil {
+		t.Error("wrote an unknown format")
+	}
+}
+
+func TestWriteBOM(t *testing.T) {
+	vs := append([]vuln.Vulnerability{{
+		Identifier: "GHSA-JCHW-25XP-JWWC", Source: "github", AssetID: "464322505", AssetName: "explore-api",
+		PackageName: "go-golang.org/x/crypto", InstalledVersion: "v0.25.0", FixedVersion: "v0.31.0",
+		CVSS: 9.1, Severity: vuln.Critical, CVSSVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N", CWEs: []string{"CWE-285", "NVD-CWE-noinfo"},
+		Title: "Misuse of ServerConfig.PublicKeyCallback", PriorityScore: 9.34, ActionTimeframe: "Immediate",
+		DueDate: vuln.NewDate(2025, 2, 5), EPSS: 0.0052, KEV: true,
+	}}, triaged...)
+	var buf bytes.Buffer
+	if err := WriteBOM(&buf, vs, Document{Tool: "prioritizer", Timestamp: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+	var doc cycloneDX
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	// One vulnerability per fingerprint, the repeated braces report left out.
+	if doc.SpecVersion != CycloneDXSpecVersion || len(doc.Components) != 5 || len(doc.Dependencies) != 5 || len(doc.Vulnerabilities) != 7 {
+		t.Fatalf("document:\n%s", buf.String())
+	}
+	crypto := doc.Components[0].Components[0]
+	if crypto.BOMRef != "explore-api#pkg:golang/golang.org/x/crypto@v0.25.0" || crypto.Group != "golang.org/x" || crypto.Name != "crypto" || crypto.Version != "v0.25.0" {
+		t.Errorf("crypto component = %+v", crypto)
+	}
+	if d := doc.Dependencies[0]; d.Ref != "explore-api" || !reflect.DeepEqual(d.DependsOn, []string{crypto.BOMRef}) {
+		t.Errorf("dependency = %+v", d)
+	}
+	v := doc.Vulnerabilities[0]
+	want := cycloneDXVuln{
+		BOMRef: vs[0].Fingerprint(), ID: "GHSA-JCHW-25XP-JWWC",
+		Source:  &cycloneDXSource{Name: "GitHub", URL: "https://github.com/advisories/GHSA-jchw-25xp-jwwc"},
+		Ratings: []cycloneDXRating{{Source: &cycloneDXSource{Name: "github"}, Score: 9.1, Severity: "critical", Method: "CVSSv31", Vector: vs[0].CVSSVector}},
+		CWEs:    []int{285}, Description: "Misuse of ServerConfig.PublicKeyCallback",
+		Recommendation: "Update go-golang.org/x/crypto from v0.25.0 to v0.31.0.",
+		Analysis:       cycloneDXAnalysis{State: "in_triage"}, Affects: []cycloneDXAffected{{crypto.BOMRef}},
+		Properties: []cycloneDXProperty{
+			{"prioritizer:priority_score", "9.34"}, {"prioritizer:action_timeframe", "Immediate"},
+			{"prioritizer:due_date", "2025-02-05"}, {"prioritizer:epss", "0.0052"}, {"prioritizer:kev", "true"},
+		},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("vulnerability:\n got %+v\nwant %+v", v, want)
+	}
+	// The waived finding is affected and will not be fixed.
+	if w := doc.Vulnerabilities[5]; w.ID != "CVE-2018-25011" || w.Analysis.State != "exploitable" || w.Source.Name != "NVD" || w.Ratings[0].Severity != "unknown" {
+		t.Errorf("waived vulnerability = %+v", w)
+	}
+}
@@ -0,0 +1,75 @@
+package vuln
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Ecosystem is the package ecosystem a finding's package belongs to.
+type Ecosystem string
+
+// Known ecosystems.
+const (
+	EcosystemNPM   Ecosystem = "npm"
+	EcosystemPip   Ecosystem = "pip"
+	EcosystemGo    Ecosystem = "go"
+	EcosystemMaven Ecosystem = "maven"
+	EcosystemOSPkg Ecosystem = "os-pkg"
+	// EcosystemWindows is Windows updates, identified by KB article.
+	EcosystemWindows Ecosystem = "windows"
+	EcosystemUnknown Ecosystem = "unknown"
+)
+
+// ecosystemPrefixes are the package-name prefixes dependency scanners use
+// to mark the ecosystem, as in "npm-axios" or "go-golang.org/x/net".
+var ecosystemPrefixes = []struct {
+	prefix    string
+	ecosystem Ecosystem
+}{
+	{"npm-", EcosystemNPM},
+	{"pip-", EcosystemPip},
+	{"pypi-", EcosystemPip},
+	{"go-", EcosystemGo},
+	{"maven-", EcosystemMaven},
+}
+
+// EcosystemPackage returns v's package name without the ecosystem
+// prefix, as in "axios" for "npm-axios".
+func (v *Vulnerability) EcosystemPackage() string {
+	name := strings.TrimSpace(v.PackageName)
+	for _, p := range ecosystemPrefixes {
+		if len(name) > len(p.prefix) && strings.EqualFold(name[:len(p.prefix)], p.prefix) {
+			return name[len(p.prefix):]
+		}
+	}
+	return name
+}
+
+// distroVersion matches the distribution revision in OS package versions
+// such as "2.28-10+deb10u2", "1:9.16.48-0ubuntu0.20.04.1" or
+// "1.0.2k-24.amzn2.0.4".
+var distroVersion = regexp.MustCompile(`deb\d|ubuntu|\.el\d|amzn|alpine|-r\d+$`)
+
+// Ecosystem classifies v's package. Findings identified by a KB article
+// are Windows updates. Others are classified by the package name prefix
+// first and otherwise by version and source: installed versions with a
+// distribution revision, and AWS Inspector findings, which cover container
+// and instance images, are OS packages.
+func (v *Vulnerability) Ecosystem() Ecosystem {
+	if v.KB() != "" {
+		return EcosystemWindows
+	}
+	name := strings.ToLower(strings.TrimSpace(v.PackageName))
+	if name == "" {
+		return EcosystemUnknown
+	}
+	for _, p := range ecosystemPrefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.ecosystem
+		}
+	}
+	if distroVersion.MatchString(strings.ToLower(v.InstalledVersion)) || strings.EqualFold(v.Source, "aws") {
+		return EcosystemOSPkg
+	}
+	return EcosystemUnknown
+}
@@ -0,0 +1,28 @@
+package vuln
+
+import (
+	"regexp"
+	"strings"
+)
+
+// kbPattern matches a Microsoft Knowledge Base article ID as exports write
+// it: "KB5034441", "kb 5034441", "KB-5034441" or "MSKB5034441".
+var kbPattern = regexp.MustCompile(`^(?i)(?:MS)?KB[\s_-]*(\d{6,7})$`)
+
+// ParseKB returns the canonical form, as in "KB5034441", of a Microsoft
+// KB article ID, and whether id is one.
+func ParseKB(id string) (string, bool) {
+	m := kbPattern.FindStringSubmatch(strings.TrimSpace(id))
+	if m == nil {
+		return "", false
+	}
+	return "KB" + m[1], true
+}
+
+// KB returns the KB article ID v is identified by, or "" when its
+// identifier is not one: Windows patch findings name the update that
+// fixes them rather than a CVE.
+func (v *Vulnerability) KB() string {
+	kb, _ := ParseKB(v.Identifier)
+	return kb
+}
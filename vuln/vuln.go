@@ -0,0 +1,411 @@
+// Package vuln defines the Vulnerability record shared by ingestion,
+// scoring and output.
+package vuln
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Severity is a vendor-assigned severity level.
+type Severity string
+
+// Severity levels, most severe first.
+const (
+	Critical Severity = "Critical"
+	High     Severity = "High"
+	Medium   Severity = "Medium"
+	Low      Severity = "Low"
+)
+
+// Severities lists every severity level, most severe first.
+var Severities = []Severity{Critical, High, Medium, Low}
+
+// ParseSeverity parses a severity level case-insensitively.
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range Severities {
+		if strings.EqualFold(strings.TrimSpace(s), string(sev)) {
+			return sev, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q", s)
+}
+
+// CVSSSeverity returns the CVSS v3 qualitative rating of score, counting
+// None as Low.
+func CVSSSeverity(score float64) Severity {
+	switch {
+	case score >= 9:
+		return Critical
+	case score >= 7:
+		return High
+	case score >= 4:
+		return Medium
+	default:
+		return Low
+	}
+}
+
+// Rank orders severities for sorting: Critical is 0, Low is 3, and
+// anything unrecognised sorts last.
+func (s Severity) Rank() int {
+	for i, sev := range Severities {
+		if s == sev {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+// DateLayout is the canonical date format used in CSV and JSON output.
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date. The zero Date means the date is unknown and
+// marshals to an empty string.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the Date for year, month and day in UTC.
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses an ISO (2006-01-02) date.
+func ParseDate(s string) (Date, error) {
+	if s == "" {
+		return Date{}, nil
+	}
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, err
+	}
+	return Date{t}, nil
+}
+
+// String formats d as 2006-01-02, or "" for the zero Date.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(DateLayout)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Date) UnmarshalText(b []byte) error {
+	parsed, err := ParseDate(string(b))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// DaysUntil returns the number of whole days from now until d; negative
+// when d has passed.
+func (d Date) DaysUntil(now time.Time) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(d.Sub(today).Hours() / 24)
+}
+
+// Data classifications of an asset, from least to most sensitive.
+const (
+	ClassPublic       = "public"
+	ClassInternal     = "internal"
+	ClassConfidential = "confidential"
+	ClassRestricted   = "restricted"
+)
+
+// Classifications are the data classifications, least sensitive first.
+var Classifications = []string{ClassPublic, ClassInternal, ClassConfidential, ClassRestricted}
+
+// ParseClassification returns the data classification s names, ignoring
+// case.
+func ParseClassification(s string) (string, error) {
+	for _, c := range Classifications {
+		if strings.EqualFold(strings.TrimSpace(s), c) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown data classification %q (want %s)", s, strings.Join(Classifications, ", "))
+}
+
+// Business criticality tiers of an asset, from least to most critical.
+const (
+	CriticalityLow              = "low"
+	CriticalityMedium           = "medium"
+	CriticalityHigh             = "high"
+	CriticalityBusinessCritical = "business_critical"
+)
+
+// Criticalities are the criticality tiers, least critical first.
+var Criticalities = []string{CriticalityLow, CriticalityMedium, CriticalityHigh, CriticalityBusinessCritical}
+
+// ParseCriticality returns the criticality tier s names, ignoring case
+// and writing "business-critical" or "business critical" alike.
+func ParseCriticality(s string) (string, error) {
+	name := strings.NewReplacer("-", "_", " ", "_").Replace(strings.ToLower(strings.TrimSpace(s)))
+	if slices.Contains(Criticalities, name) {
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown criticality %q (want %s)", s, strings.Join(Criticalities, ", "))
+}
+
+// Environments an asset is deployed in.
+const (
+	EnvDevelopment = "dev"
+	EnvTest        = "test"
+	EnvStaging     = "staging"
+	EnvProduction  = "prod"
+)
+
+// Environments are the deployment environments, furthest from customers
+// first.
+var Environments = []string{EnvDevelopment, EnvTest, EnvStaging, EnvProduction}
+
+// environmentAliases are the other names environments go by.
+var environmentAliases = map[string]string{
+	"development": EnvDevelopment,
+	"qa":          EnvTest,
+	"testing":     EnvTest,
+	"uat":         EnvStaging,
+	"stage":       EnvStaging,
+	"production":  EnvProduction,
+	"prd":         EnvProduction,
+}
+
+// ParseEnvironment returns the environment s names, ignoring case, as in
+// "prod" for "Production".
+func ParseEnvironment(s string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if slices.Contains(Environments, name) {
+		return name, nil
+	}
+	if env, ok := environmentAliases[name]; ok {
+		return env, nil
+	}
+	return "", fmt.Errorf("unknown environment %q (want %s)", s, strings.Join(Environments, ", "))
+}
+
+// Exploit maturities, from the least mature: a public proof of concept,
+// as the Exploit Database lists, and a weaponized exploit, one a
+// Metasploit module packages for anyone to run.
+const (
+	ExploitPoC        = "poc"
+	ExploitWeaponized = "weaponized"
+)
+
+// ExploitMaturities are the exploit maturities, least mature first.
+var ExploitMaturities = []string{ExploitPoC, ExploitWeaponized}
+
+// ParseExploitMaturity returns the exploit maturity s names, ignoring
+// case.
+func ParseExploitMaturity(s string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if slices.Contains(ExploitMaturities, name) {
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown exploit maturity %q (want %s)", s, strings.Join(ExploitMaturities, ", "))
+}
+
+// MoreMatureExploit reports whether exploit maturity a is more mature
+// than b, where "" is no known exploit.
+func MoreMatureExploit(a, b string) bool {
+	return slices.Index(ExploitMaturities, a) > slices.Index(ExploitMaturities, b)
+}
+
+// Dependency kinds.
+const (
+	DependencyDirect     = "direct"
+	DependencyTransitive = "transitive"
+)
+
+// Vulnerability is one finding: a vulnerability identifier affecting a
+// package on an asset, as reported by a scanner.
+type Vulnerability struct {
+	UniqueID          string   `json:"unique_id"`
+	AssetName         string   `json:"asset_name"`
+	AssetID           string   `json:"asset_id"`
+	Organization      string   `json:"organization"`
+	Identifier        string   `json:"identifier"`
+	Source            string   `json:"source"`
+	CVSS              float64  `json:"cvss"`
+	Title             string   `json:"title"`
+	Description       string   `json:"description"`
+	PackageName       string   `json:"package_name"`
+	RenamedFrom       string   `json:"renamed_from,omitempty"` // the name the input reported, when -renames renamed the package
+	InstalledVersion  string   `json:"installed_version"`
+	FixedVersion      string   `json:"fixed_version"`
+	Remediation       string   `json:"remediation"`
+	Severity          Severity `json:"severity"`
+	DueDate           Date     `json:"due_date"`
+	FirstDetectedDate Date     `json:"first_detected_date"`
+	Fixability        string   `json:"fixability"`
+	// Team is the team that owns the finding, when the input names one.
+	Team string `json:"team,omitempty"`
+	// CVSSVector is the CVSS vector string, when the input has one.
+	CVSSVector string `json:"cvss_vector,omitempty"`
+	// ImageDigest is the content digest of a container image asset, as in
+	// "sha256:4be4...", and ImageTags the tags that refer to it. See
+	// package imageref.
+	ImageDigest string   `json:"image_digest,omitempty"`
+	ImageTags   []string `json:"image_tags,omitempty"`
+	// Occurrences is how many reports of the finding were merged into it
+	// when duplicates are merged; see package dedup.
+	Occurrences int `json:"occurrences,omitempty"`
+	// Instances lists the asset IDs of the instances of a logical asset,
+	// such as an autoscaling group's clones, the finding was reported on
+	// when they are grouped; see package assetgroup.
+	Instances []string `json:"instances,omitempty"`
+
+	// CustomFields holds the input's unrecognised columns, by header, when
+	// they are passed through. Empty cells are omitted.
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
+
+	// Set by enrichment.
+	EPSS           float64 `json:"epss,omitempty"`
+	EPSSPercentile float64 `json:"epss_percentile,omitempty"`
+	KEV            bool    `json:"kev,omitempty"`
+	// Exploit is the maturity of the most mature public exploit of the
+	// finding's CVE, one of ExploitMaturities, or "" when none is known.
+	Exploit string `json:"exploit_maturity,omitempty"`
+	// CVSSSource is "nvd" or "msrc" when CVSS was filled in from the NVD
+	// or the Microsoft Security Response Center rather than taken from the
+	// input.
+	CVSSSource string `json:"cvss_source,omitempty"`
+	// RelatedCVEs are the CVEs a finding not identified by a CVE, such as
+	// a Windows KB update, addresses.
+	RelatedCVEs []string `json:"related_cves,omitempty"`
+	// CWEs are the weaknesses the finding is an instance of, as in
+	// "CWE-79", from the input or the NVD.
+	CWEs []string `json:"cwes,omitempty"`
+	// Class is the vulnerability class, one of taxonomy.Classes, from the
+	// input or classified from the CWEs and wording; see package taxonomy.
+	Class string `json:"class,omitempty"`
+	// Region is the cloud or business region the finding's account maps to.
+	Region string `json:"region,omitempty"`
+	// DataClassification is the sensitivity of the data on the finding's
+	// asset, one of Classifications, from the input or an asset mapping.
+	DataClassification string `json:"data_classification,omitempty"`
+	// Criticality is the business criticality of the finding's asset, one
+	// of Criticalities, Environment the environment it runs in, one of
+	// Environments, and Owner who answers for it, as in
+	// "payments-oncall@example.com", from the input or an asset
+	// inventory; see package inventory.
+	Criticality string `json:"criticality,omitempty"`
+	Environment string `json:"environment,omitempty"`
+	Owner       string `json:"asset_owner,omitempty"`
+	// OS is the asset's operating system release, as in "Debian 10", from
+	// the input or detected from its package versions; OSEndOfLife marks
+	// releases past the end of security support. See package eol.
+	OS          string `json:"os,omitempty"`
+	OSEndOfLife bool   `json:"os_end_of_life,omitempty"`
+	// OSFamily is the OS family by its os-release ID, as in "debian" or
+	// "amzn", OSVersion its release version, and Arch the asset's
+	// architecture, as in "x86_64"; see package platform.
+	OSFamily  string `json:"os_family,omitempty"`
+	OSVersion string `json:"os_version,omitempty"`
+	Arch      string `json:"arch,omitempty"`
+	// Discovered marks a finding NVD CPE matching found for a host package
+	// that the scanner did not report; see enrich.CPEMatch.
+	Discovered bool `json:"discovered,omitempty"`
+	// StaleAsset marks a finding on an asset listed as decommissioned; see
+	// package decommission.
+	StaleAsset bool `json:"stale_asset,omitempty"`
+	// Suppressed marks a finding covered by an active waiver, whose
+	// justification is Waiver; see package waiver.
+	Suppressed bool   `json:"suppressed,omitempty"`
+	Waiver     string `json:"waiver,omitempty"`
+
+	// Set from dependency graphs. DependencyDepth is the shortest path
+	// from the project to the package: 1 for a DependencyDirect
+	// dependency, more for a DependencyTransitive one.
+	Dependency      string `json:"dependency,omitempty"`
+	DependencyDepth int    `json:"dependency_depth,omitempty"`
+
+	// State is the finding's workflow state, such as "resolved", when
+	// states are tracked; see package state.
+	State string `json:"state,omitempty"`
+
+	// Ticket is the key of the finding's Jira issue, as in SEC-123, when
+	// findings are filed in Jira; see package jira.
+	Ticket string `json:"ticket,omitempty"`
+
+	// Set by patch window scheduling. EmergencyChange marks a finding due
+	// before NextPatchOpportunity, which cannot wait for the window.
+	NextPatchOpportunity Date `json:"next_patch_opportunity"`
+	EmergencyChange      bool `json:"emergency_change,omitempty"`
+
+	// Set by confidence rating. Confidence, from 0 to 1, is how likely the
+	// finding is real; NoiseReasons names the heuristics that lowered it.
+	Confidence   float64  `json:"confidence,omitempty"`
+	NoiseReasons []string `json:"noise_reasons,omitempty"`
+
+	// Set by effort estimation. Effort is the estimated story points of
+	// remediating the finding; see package effort.
+	Effort int `json:"effort,omitempty"`
+
+	// Set by SLA assessment. SLAStatus is "within_sla" or "breached" for
+	// a finding with a due date, and DaysOverdue is how many days past it
+	// a breached finding is; see package sla.
+	SLAStatus   string `json:"sla_status,omitempty"`
+	DaysOverdue int    `json:"days_overdue,omitempty"`
+	// BreachesSLAOn is the first day a finding within its SLA is past its
+	// due date if it is left unfixed.
+	BreachesSLAOn Date `json:"breaches_sla_on"`
+
+	// Set by scoring. TemporalCVSS is the CVSS v3 Temporal Score; see
+	// scoring.TemporalCVSS.
+	TemporalCVSS        float64 `json:"temporal_cvss"`
+	PriorityScore       float64 `json:"priority_score"`
+	ActionTimeframe     string  `json:"action_timeframe"`
+	RemediateWithinDays int     `json:"remediate_within_days"`
+	// ScoreIn30Days, ScoreIn60Days and ScoreIn90Days are the finding's
+	// priority score that many days on if it is left unfixed; see
+	// scoring.Project.
+	ScoreIn30Days float64 `json:"score_in_30_days"`
+	ScoreIn60Days float64 `json:"score_in_60_days"`
+	ScoreIn90Days float64 `json:"score_in_90_days"`
+	// ProfileScores holds the finding's score under each weight profile
+	// it was also scored against, by profile name; see config.Profile.
+	ProfileScores map[string]float64 `json:"profile_scores,omitempty"`
+}
+
+// Fingerprint identifies the finding across scans and runs: a SHA-256
+// over its identifier, asset ID, package name and installed version.
+// Repeated reports of one vulnerability on one package instance share a
+// fingerprint.
+func (v *Vulnerability) Fingerprint() string {
+	h := sha256.New()
+	for _, part := range []string{
+		strings.ToUpper(strings.TrimSpace(v.Identifier)),
+		strings.TrimSpace(v.AssetID),
+		strings.TrimSpace(v.PackageName),
+		strings.TrimSpace(v.InstalledVersion),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HasFix reports whether a fixed package version is known.
+func (v *Vulnerability) HasFix() bool {
+	return strings.TrimSpace(v.FixedVersion) != ""
+}
+
+// UpgradeRemediation returns the remediation scanners are given for v
+// when they report a fixed version but no instructions, as in "Update
+// openssl from 3.0.2-0ubuntu1.15 to 3.0.2-0ubuntu1.18.".
+func (v *Vulnerability) UpgradeRemediation() string {
+	return fmt.Sprintf("Update %s from %s to %s.", v.PackageName, v.InstalledVersion, v.FixedVersion)
+}
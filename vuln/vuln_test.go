@@ -0,0 +1,121 @@
+package vuln
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for in, want := range map[string]Severity{"Critical": Critical, "high": High, " MEDIUM ": Medium, "low": Low} {
+		got, err := ParseSeverity(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("Severe"); err == nil {
+		t.Error("ParseSeverity(Severe): want error")
+	}
+	if Critical.Rank() >= Low.Rank() || Severity("").Rank() != len(Severities) {
+		t.Error("unexpected severity ranks")
+	}
+}
+
+func TestDate(t *testing.T) {
+	d := NewDate(2025, 3, 7)
+	b, _ := d.MarshalText()
+	if string(b) != "2025-03-07" {
+		t.Errorf("MarshalText = %s", b)
+	}
+	var back Date
+	if err := back.UnmarshalText(b); err != nil || !back.Equal(d.Time) {
+		t.Errorf("UnmarshalText = %v, %v", back, err)
+	}
+	if err := back.UnmarshalText(nil); err != nil || !back.IsZero() {
+		t.Errorf("empty UnmarshalText = %v, %v", back, err)
+	}
+
+	now := time.Date(2025, 3, 5, 23, 0, 0, 0, time.UTC)
+	if got := d.DaysUntil(now); got != 2 {
+		t.Errorf("DaysUntil = %d, want 2", got)
+	}
+	if got := NewDate(2025, 3, 1).DaysUntil(now); got != -4 {
+		t.Errorf("DaysUntil past = %d, want -4", got)
+	}
+}
+
+func TestEcosystem(t *testing.T) {
+	tests := []struct {
+		v    Vulnerability
+		want Ecosystem
+	}{
+		{Vulnerability{PackageName: "npm-axios", Source: "github"}, EcosystemNPM},
+		{Vulnerability{PackageName: "go-golang.org/x/crypto", Source: "github"}, EcosystemGo},
+		{Vulnerability{PackageName: "pip-requests"}, EcosystemPip},
+		{Vulnerability{PackageName: "maven-org.apache.logging.log4j:log4j-core"}, EcosystemMaven},
+		{Vulnerability{PackageName: "glibc", InstalledVersion: "2.28-10+deb10u2", Source: "aws"}, EcosystemOSPkg},
+		{Vulnerability{Identifier: "KB5034441", PackageName: "windows-server-2019", Source: "aws"}, EcosystemWindows},
+		{Vulnerability{PackageName: "openssl", InstalledVersion: "3.0.2-0ubuntu1.18"}, EcosystemOSPkg},
+		{Vulnerability{PackageName: "bash", Source: "aws"}, EcosystemOSPkg},
+		{Vulnerability{PackageName: "left-pad", Source: "github"}, EcosystemUnknown},
+		{Vulnerability{Source: "aws"}, EcosystemUnknown},
+	}
+	for _, tt := range tests {
+		if got := tt.v.Ecosystem(); got != tt.want {
+			t.Errorf("%q %q: Ecosystem() = %s, want %s", tt.v.PackageName, tt.v.InstalledVersion, got, tt.want)
+		}
+	}
+}
+
+func TestParseKB(t *testing.T) {
+	for id, want := range map[string]string{
+		"KB5034441":    "KB5034441",
+		"kb 5034441":   "KB5034441",
+		" KB-890830 ":  "KB890830",
+		"MSKB5034441":  "KB5034441",
+		"KB_5034441":   "KB5034441",
+		"CVE-2024-1":   "",
+		"KB12":         "",
+		"5034441":      "",
+		"KB5034441abc": "",
+	} {
+		got, ok := ParseKB(id)
+		if got != want || ok != (want != "") {
+			t.Errorf("ParseKB(%q) = %q, %v, want %q", id, got, ok, want)
+		}
+	}
+}
+
+func TestEcosystemPackage(t *testing.T) {
+	for name, want := range map[string]string{
+		"npm-@grpc/grpc-js":               "@grpc/grpc-js",
+		"go-github.com/golang-jwt/jwt/v4": "github.com/golang-jwt/jwt/v4",
+		"PyPI-requests":                   "requests",
+		"openssl":                         "openssl",
+		"npm-":                            "npm-",
+	} {
+		v := Vulnerability{PackageName: name}
+		if got := v.EcosystemPackage(); got != want {
+			t.Errorf("%q: EcosystemPackage() = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Vulnerability{UniqueID: "1", Identifier: "CVE-2024-4367", AssetID: "464322508", PackageName: "npm-pdfjs-dist", InstalledVersion: "<= 4.1.392"}
+	b := a
+	b.UniqueID, b.Identifier, b.CVSS = "2", " cve-2024-4367", 8.8
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("fingerprint depends on fields outside the key")
+	}
+	c := a
+	c.InstalledVersion = "4.2.67"
+	if a.Fingerprint() == c.Fingerprint() {
+		t.Error("fingerprint ignores installed version")
+	}
+	// Fields are separated, so moving text between them changes the hash.
+	d := Vulnerability{Identifier: "CVE-1", AssetID: "ab"}
+	e := Vulnerability{Identifier: "CVE-1a", AssetID: "b"}
+	if d.Fingerprint() == e.Fingerprint() || len(d.Fingerprint()) != 64 {
+		t.Error("fingerprint fields are not delimited")
+	}
+}
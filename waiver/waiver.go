@@ -0,0 +1,164 @@
+// Package waiver suppresses findings whose risk has been accepted for a
+// while, as listed in a waiver file: a vulnerability, optionally only on
+// one asset or in one package, until an expiry date. Once a waiver
+// expires its findings are reported again, so that an accepted risk is
+// reviewed rather than forgotten.
+package waiver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/config"
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+// Modes of handling waived findings.
+const (
+	// Drop removes the findings from the run.
+	Drop = "drop"
+	// Mark keeps them, with Suppressed set.
+	Mark = "mark"
+)
+
+// Modes are the handling modes, for flag help.
+var Modes = []string{Drop, Mark}
+
+// Waiver suppresses the findings of one vulnerability.
+type Waiver struct {
+	// ID is the vulnerability's identifier, or one of its related CVEs.
+	ID string `json:"id"`
+	// Asset and Package, when set, limit the waiver to the findings on
+	// the asset, by ID or name, and in the package.
+	Asset   string `json:"asset,omitempty"`
+	Package string `json:"package,omitempty"`
+	// Expires is the last day the waiver applies, as YYYY-MM-DD.
+	Expires       string `json:"expires"`
+	Justification string `json:"justification"`
+
+	expires vuln.Date
+}
+
+// File is the waivers of a waiver file.
+type File struct {
+	Waivers []Waiver `json:"waivers"`
+}
+
+// Load reads a waiver file, as YAML when it is named .yaml or .yml and
+// JSON otherwise:
+//
+//	waivers:
+//	  - id: CVE-2024-21626
+//	    asset: i-0abc123
+//	    expires: 2025-06-30
+//	    justification: runc is not used on the host; removal tracked in OPS-412
+//
+// Unknown keys are rejected, as are waivers without an ID, an expiry date
+// or a justification, so that no waiver applies for ever or unexplained.
+func Load(path string) (*File, error) {
+	b, err := config.ReadDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	f := &File{}
+	if err := dec.Decode(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range f.Waivers {
+		w := &f.Waivers[i]
+		switch {
+		case strings.TrimSpace(w.ID) == "":
+			return nil, fmt.Errorf("%s: waiver %d has no id", path, i+1)
+		case strings.TrimSpace(w.Justification) == "":
+			return nil, fmt.Errorf("%s: %s: waiver has no justification", path, w.ID)
+		}
+		if w.expires, err = vuln.ParseDate(strings.TrimSpace(w.Expires)); err != nil || w.expires.IsZero() {
+			return nil, fmt.Errorf("%s: %s: invalid expires date %q", path, w.ID, w.Expires)
+		}
+	}
+	return f, nil
+}
+
+// Covers reports whether w applies to v, ignoring its expiry.
+func (w *Waiver) Covers(v *vuln.Vulnerability) bool {
+	if a := strings.TrimSpace(w.Asset); a != "" && a != strings.TrimSpace(v.AssetID) && a != strings.TrimSpace(v.AssetName) {
+		return false
+	}
+	if p := strings.TrimSpace(w.Package); p != "" && !strings.EqualFold(p, strings.TrimSpace(v.PackageName)) {
+		return false
+	}
+	id := strings.TrimSpace(w.ID)
+	if strings.EqualFold(id, strings.TrimSpace(v.Identifier)) {
+		return true
+	}
+	for _, cve := range v.RelatedCVEs {
+		if strings.EqualFold(id, cve) {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether w still applies on the day of now.
+func (w *Waiver) Active(now time.Time) bool {
+	return !vuln.NewDate(now.Year(), now.Month(), now.Day()).After(w.expires.Time)
+}
+
+// Result counts what Apply did.
+type Result struct {
+	// Waived counts the findings an active waiver covers.
+	Waived int
+	// Expired are the waivers past their expiry date that cover findings
+	// of the run, which are reported again.
+	Expired []*Waiver
+	// Reactivated counts the findings of Expired not covered by an active
+	// waiver.
+	Reactivated int
+}
+
+// Apply handles the findings of vs covered by an active waiver on the day
+// of now in mode: Drop removes them and Mark sets their Suppressed and
+// Waiver. It returns the findings left.
+func (f *File) Apply(vs []vuln.Vulnerability, mode string, now time.Time) ([]vuln.Vulnerability, Result) {
+	var res Result
+	expired := make(map[*Waiver]bool)
+	out := vs[:0]
+	for i := range vs {
+		v := &vs[i]
+		var active, lapsed *Waiver
+		for j := range f.Waivers {
+			w := &f.Waivers[j]
+			if !w.Covers(v) {
+				continue
+			}
+			if w.Active(now) {
+				active = w
+				break
+			}
+			if lapsed == nil {
+				lapsed = w
+			}
+		}
+		switch {
+		case active != nil:
+			res.Waived++
+			if mode == Drop {
+				continue
+			}
+			v.Suppressed, v.Waiver = true, active.Justification
+		case lapsed != nil:
+			res.Reactivated++
+			if !expired[lapsed] {
+				expired[lapsed] = true
+				res.Expired = append(res.Expired, lapsed)
+			}
+		}
+		out = append(out, *v)
+	}
+	return out, res
+}
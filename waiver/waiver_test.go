@@ -0,0 +1,110 @@
+package waiver
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/VioletX-Dev/devsecops-test/vuln"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const waivers = `# Accepted risks, reviewed monthly.
+waivers:
+  - id: CVE-2024-37890
+    asset: acme-inc-integrations
+    expires: 2025-03-31
+    justification: ws is only reached from the internal network
+  - id: cve-2023-44487
+    package: go-golang.org/x/net
+    expires: "2025-02-05"
+    justification: HTTP/2 is disabled
+  - id: CVE-2024-4068
+    expires: 2025-01-31
+    justification: braces upgrade scheduled
+`
+
+func TestLoad(t *testing.T) {
+	f, err := Load(writeFile(t, "waivers.yaml", waivers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Waivers) != 3 || f.Waivers[0].Asset != "acme-inc-integrations" || f.Waivers[1].Expires != "2025-02-05" {
+		t.Fatalf("waivers = %+v", f.Waivers)
+	}
+
+	for name, content := range map[string]string{
+		"no justification": "waivers:\n  - id: CVE-2024-1\n    expires: 2025-03-31\n",
+		"no expiry":        "waivers:\n  - id: CVE-2024-1\n    justification: later\n",
+		"bad expiry":       "waivers:\n  - id: CVE-2024-1\n    expires: soon\n    justification: later\n",
+		"no id":            "waivers:\n  - asset: web-1\n    expires: 2025-03-31\n    justification: later\n",
+		"unknown key":      "waivers:\n  - id: CVE-2024-1\n    expiry: 2025-03-31\n    justification: later\n",
+	} {
+		if _, err := Load(writeFile(t, "waivers.yaml", content)); err == nil {
+			t.Errorf("%s: loaded", name)
+		}
+	}
+	if _, err := Load(writeFile(t, "waivers.json", `{"waivers": [{"id": "CVE-2024-1", "expires": "2025-03-31", "justification": "later"}]}`)); err != nil {
+		t.Errorf("JSON: %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	f, err := Load(writeFile(t, "waivers.yaml", waivers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vs := func() []vuln.Vulnerability {
+		return []vuln.Vulnerability{
+			{UniqueID: "ws-integrations", Identifier: "CVE-2024-37890", AssetName: "acme-inc-integrations", PackageName: "npm-ws"},
+			{UniqueID: "ws-webhooks", Identifier: "CVE-2024-37890", AssetName: "acme-inc-webhooks", PackageName: "npm-ws"},
+			{UniqueID: "net", Identifier: "GHSA-qppj-fm5r-hxr3", RelatedCVEs: []string{"CVE-2023-44487"}, AssetID: "img", PackageName: "go-golang.org/x/net"},
+			{UniqueID: "braces", Identifier: "CVE-2024-4068", AssetName: "apply-api", PackageName: "npm-braces"},
+		}
+	}
+	now := time.Date(2025, 2, 5, 18, 0, 0, 0, time.UTC)
+
+	kept, res := f.Apply(vs(), Mark, now)
+	var marked []string
+	for _, v := range kept {
+		if v.Suppressed {
+			marked = append(marked, v.UniqueID+": "+v.Waiver)
+		}
+	}
+	want := []string{"ws-integrations: ws is only reached from the internal network", "net: HTTP/2 is disabled"}
+	if len(kept) != 4 || !slices.Equal(marked, want) {
+		t.Errorf("marked %q of %d, want %q", marked, len(kept), want)
+	}
+	if res.Waived != 2 || res.Reactivated != 1 || len(res.Expired) != 1 || res.Expired[0].ID != "CVE-2024-4068" {
+		t.Errorf("result = %+v", res)
+	}
+
+	kept, _ = f.Apply(vs(), Drop, now)
+	var ids []string
+	for _, v := range kept {
+		ids = append(ids, v.UniqueID)
+	}
+	if !slices.Equal(ids, []string{"ws-webhooks", "braces"}) {
+		t.Errorf("dropping kept %v", ids)
+	}
+
+	// The day after it expires, a waiver no longer applies.
+	kept, res = f.Apply(vs(), Drop, now.AddDate(0, 0, 1))
+	if len(kept) != 3 || res.Waived != 1 || res.Reactivated != 2 {
+		t.Errorf("a day later kept %d, result %+v", len(kept), res)
+	}
+	if len(res.Expired) != 2 || !strings.EqualFold(res.Expired[0].ID, "CVE-2023-44487") {
+		t.Errorf("expired %+v", res.Expired)
+	}
+}